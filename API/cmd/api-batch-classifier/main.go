@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -19,12 +21,22 @@ import (
 // Configuration for the API-based batch classifier
 type Config struct {
 	APIBaseURL           string        `json:"api_base_url"`
+	APIToken             string        `json:"-"` // JWT for /batch/classify, which requires one unless AUTH_ENABLED=false
 	MaxConcurrentWorkers int           `json:"max_concurrent_workers"`
 	BatchSize            int           `json:"batch_size"`
 	RateLimitPerMinute   int           `json:"rate_limit_per_minute"`
 	RequestTimeout       time.Duration `json:"request_timeout"`
 	RetryAttempts        int           `json:"retry_attempts"`
 	RetryDelay           time.Duration `json:"retry_delay"`
+	Output               string        `json:"output,omitempty"`   // JSONL file or s3://bucket/key for per-document results
+	Manifest             string        `json:"manifest,omitempty"` // Local CSV or JSONL file with a row per document outcome
+
+	// Connection reuse for the shared HTTP client
+	KeepAlive       bool          `json:"keep_alive"`
+	KeepAlivePeriod time.Duration `json:"keep_alive_period"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout"`
+	HTTPClient      *http.Client  `json:"-"`
 }
 
 // DocumentInfo represents a document from the storage API
@@ -40,11 +52,10 @@ type DocumentInfo struct {
 type DocumentListResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
-		Documents      []DocumentInfo `json:"documents"`
-		NextCursor     string         `json:"next_cursor"`
-		HasMore        bool           `json:"has_more"`
-		TotalReturned  int            `json:"total_returned"`
-		TotalEstimated int            `json:"total_estimated"`
+		Documents     []DocumentInfo `json:"documents"`
+		NextCursor    string         `json:"next_cursor"`
+		HasMore       bool           `json:"has_more"`
+		TotalReturned int            `json:"total_returned"`
 	} `json:"data"`
 	Message string `json:"message"`
 }
@@ -111,6 +122,7 @@ type ClassificationStats struct {
 	StartTime          time.Time     `json:"start_time"`
 	Duration           time.Duration `json:"duration"`
 	Rate               float64       `json:"rate_per_minute"`
+	RecordsWritten     int64         `json:"records_written"`
 }
 
 func main() {
@@ -127,68 +139,149 @@ func main() {
 
 	command := os.Args[1]
 
+	// Flags may come before or after the command's positional argument
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	output := flags.String("output", getEnv("BATCH_OUTPUT", ""), "write per-document results as JSONL to a file or s3://bucket/key")
+	manifest := flags.String("manifest", getEnv("BATCH_MANIFEST", ""), "append a row per document to this CSV (.csv) or JSONL file")
+	flags.Parse(os.Args[2:])
+	args := flags.Args()
+	if len(args) > 0 {
+		flags.Parse(args[1:])
+		args = args[:1]
+	}
+
 	// Load configuration
 	cfg := loadConfig()
+	cfg.Output = *output
+	cfg.Manifest = *manifest
+
+	sink, err := openResultSink(cfg)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
 	switch command {
 	case "classify-all":
-		classifyAllDocuments(cfg)
+		classifyAllDocuments(cfg, sink)
 	case "classify-batch":
 		batchSize := 100
-		if len(os.Args) > 2 {
-			if bs, err := fmt.Sscanf(os.Args[2], "%d", &batchSize); err != nil || bs != 1 {
+		if len(args) > 0 {
+			if bs, err := fmt.Sscanf(args[0], "%d", &batchSize); err != nil || bs != 1 {
 				log.Printf("Invalid batch size, using default: %d", batchSize)
 			}
 		}
-		classifyDocumentsBatch(cfg, batchSize)
+		classifyDocumentsBatch(cfg, batchSize, sink)
 	case "test-api":
 		testAPIConnection(cfg)
 	default:
 		printUsage()
 		os.Exit(1)
 	}
+
+	if sink != nil {
+		if err := sink.Close(); err != nil {
+			log.Fatalf("❌ Failed to save results: %v", err)
+		}
+		if cfg.Output != "" {
+			fmt.Printf("💾 Results written to %s\n", cfg.Output)
+		}
+		if cfg.Manifest != "" {
+			fmt.Printf("📝 Manifest written to %s\n", cfg.Manifest)
+		}
+	}
+}
+
+// openResultSink opens the configured results output and manifest, or
+// returns nil when neither is set
+func openResultSink(cfg *Config) (ResultSink, error) {
+	var sinks multiSink
+	if cfg.Output != "" {
+		sink, err := newResultSink(cfg.Output)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.Manifest != "" {
+		sink, err := newManifestSink(cfg.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	switch len(sinks) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sinks[0], nil
+	}
+	return sinks, nil
 }
 
 func printUsage() {
 	fmt.Println("API-Based Batch Document Classifier")
 	fmt.Println("==================================")
 	fmt.Println()
-	fmt.Println("Usage: go run cmd/api-batch-classifier/main.go <command> [options]")
+	fmt.Println("Usage: go run ./cmd/api-batch-classifier <command> [options]")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  test-api              - Test API connection and authentication")
 	fmt.Println("  classify-batch [size] - Classify specified number of documents")
 	fmt.Println("  classify-all          - Classify ALL documents in storage")
 	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --output <path>       - Write each document's classification to a JSONL file or s3://bucket/key")
+	fmt.Println("  --manifest <path>     - Append a row per document (path, status, type, confidence, indexed, error)")
+	fmt.Println("                          to a local file as jobs finish; CSV for .csv files, JSON lines otherwise")
+	fmt.Println()
 	fmt.Println("Examples:")
-	fmt.Println("  go run cmd/api-batch-classifier/main.go test-api")
-	fmt.Println("  go run cmd/api-batch-classifier/main.go classify-batch 500")
-	fmt.Println("  go run cmd/api-batch-classifier/main.go classify-all")
+	fmt.Println("  go run ./cmd/api-batch-classifier test-api")
+	fmt.Println("  go run ./cmd/api-batch-classifier classify-batch 500")
+	fmt.Println("  go run ./cmd/api-batch-classifier classify-all --output results.jsonl")
+	fmt.Println("  go run ./cmd/api-batch-classifier classify-all --output s3://motion-index-docs/reports/classify.jsonl")
+	fmt.Println("  go run ./cmd/api-batch-classifier classify-all --manifest run.csv")
 	fmt.Println()
 	fmt.Println("Environment Variables:")
 	fmt.Println("  API_BASE_URL          - Base URL for the Motion Index API (default: http://localhost:6000)")
 	fmt.Println("  MAX_WORKERS           - Maximum concurrent workers (default: 5)")
 	fmt.Println("  BATCH_SIZE            - Documents per batch (default: 50)")
 	fmt.Println("  RATE_LIMIT            - API requests per minute (default: 100)")
+	fmt.Println("  HTTP_KEEP_ALIVE       - Reuse API connections between requests (default: true)")
+	fmt.Println("  HTTP_MAX_IDLE_CONNS   - Idle connections kept open (default: MAX_WORKERS + 1)")
+	fmt.Println("  HTTP_IDLE_CONN_TIMEOUT_SECONDS - Close idle connections after (default: 90)")
+	fmt.Println("  HTTP_KEEP_ALIVE_SECONDS - TCP keep-alive probe interval (default: 30)")
+	fmt.Println("  BATCH_OUTPUT          - Default for --output")
+	fmt.Println("  BATCH_MANIFEST        - Default for --manifest")
+	fmt.Println("  OUTPUT_S3_ENDPOINT    - S3 endpoint for s3:// output (default: DigitalOcean Spaces in STORAGE_REGION)")
 }
 
 func loadConfig() *Config {
 	cfg := &Config{
 		APIBaseURL:           getEnv("API_BASE_URL", "http://localhost:8003"),
+		APIToken:             getEnv("API_TOKEN", ""),
 		MaxConcurrentWorkers: getEnvInt("MAX_WORKERS", 5),
 		BatchSize:            getEnvInt("BATCH_SIZE", 50),
 		RateLimitPerMinute:   getEnvInt("RATE_LIMIT", 100),
 		RequestTimeout:       time.Duration(getEnvInt("REQUEST_TIMEOUT_SECONDS", 120)) * time.Second,
 		RetryAttempts:        getEnvInt("RETRY_ATTEMPTS", 3),
 		RetryDelay:           time.Duration(getEnvInt("RETRY_DELAY_SECONDS", 5)) * time.Second,
+		KeepAlive:            getEnvBool("HTTP_KEEP_ALIVE", true),
+		KeepAlivePeriod:      time.Duration(getEnvInt("HTTP_KEEP_ALIVE_SECONDS", 30)) * time.Second,
+		IdleConnTimeout:      time.Duration(getEnvInt("HTTP_IDLE_CONN_TIMEOUT_SECONDS", 90)) * time.Second,
 	}
+	// One connection per worker plus one for document listing
+	cfg.MaxIdleConns = getEnvInt("HTTP_MAX_IDLE_CONNS", cfg.MaxConcurrentWorkers+1)
+	cfg.HTTPClient = newHTTPClient(cfg)
 
 	fmt.Printf("🔧 Configuration loaded:\n")
 	fmt.Printf("   API Base URL: %s\n", cfg.APIBaseURL)
+	fmt.Printf("   API Token: %t\n", cfg.APIToken != "")
 	fmt.Printf("   Max Workers: %d\n", cfg.MaxConcurrentWorkers)
 	fmt.Printf("   Batch Size: %d\n", cfg.BatchSize)
 	fmt.Printf("   Rate Limit: %d req/min\n", cfg.RateLimitPerMinute)
 	fmt.Printf("   Request Timeout: %s\n", cfg.RequestTimeout)
+	fmt.Printf("   Keep-Alive: %t (idle pool: %d)\n", cfg.KeepAlive, cfg.MaxIdleConns)
 	fmt.Println()
 
 	return cfg
@@ -198,7 +291,7 @@ func testAPIConnection(cfg *Config) {
 	fmt.Println("🔍 Testing API Connection")
 	fmt.Println("=========================")
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 
 	// Test health endpoint
 	fmt.Println("📊 Testing health endpoint...")
@@ -230,7 +323,7 @@ func testAPIConnection(cfg *Config) {
 		log.Fatalf("❌ Failed to decode document response: %v", err)
 	}
 
-	fmt.Printf("✅ Document listing OK - found %d documents\n", docResp.Data.TotalEstimated)
+	fmt.Printf("✅ Document listing OK - listed %d documents\n", docResp.Data.TotalReturned)
 
 	// Test document count
 	fmt.Println("🔢 Testing document count...")
@@ -249,7 +342,7 @@ func testAPIConnection(cfg *Config) {
 	fmt.Println("✅ API connection test complete!")
 }
 
-func classifyAllDocuments(cfg *Config) {
+func classifyAllDocuments(cfg *Config, sink ResultSink) {
 	fmt.Println("🚀 API-Based Classification of All Documents")
 	fmt.Println("============================================")
 
@@ -273,7 +366,7 @@ func classifyAllDocuments(cfg *Config) {
 	}
 
 	// Process all documents using pagination
-	processAllDocuments(cfg, stats)
+	processAllDocuments(cfg, stats, sink)
 
 	// Final statistics
 	stats.Duration = time.Since(startTime)
@@ -282,7 +375,7 @@ func classifyAllDocuments(cfg *Config) {
 	printFinalStats(stats)
 }
 
-func classifyDocumentsBatch(cfg *Config, maxDocuments int) {
+func classifyDocumentsBatch(cfg *Config, maxDocuments int, sink ResultSink) {
 	fmt.Printf("🚀 API-Based Classification of %d Documents\n", maxDocuments)
 	fmt.Println("==========================================")
 
@@ -306,7 +399,7 @@ func classifyDocumentsBatch(cfg *Config, maxDocuments int) {
 	}
 
 	// Process documents in batches
-	processDocumentList(cfg, documents, stats)
+	processDocumentList(cfg, documents, stats, sink)
 
 	// Final statistics
 	stats.Duration = time.Since(startTime)
@@ -315,7 +408,7 @@ func classifyDocumentsBatch(cfg *Config, maxDocuments int) {
 	printFinalStats(stats)
 }
 
-func processAllDocuments(cfg *Config, stats *ClassificationStats) {
+func processAllDocuments(cfg *Config, stats *ClassificationStats, sink ResultSink) {
 	cursor := ""
 	totalProcessed := 0
 
@@ -334,7 +427,7 @@ func processAllDocuments(cfg *Config, stats *ClassificationStats) {
 		fmt.Printf("📋 Processing batch of %d documents (cursor: %s)\n", len(documents), cursor[:min(8, len(cursor))])
 
 		// Process this batch
-		processDocumentList(cfg, documents, stats)
+		processDocumentList(cfg, documents, stats, sink)
 
 		totalProcessed += len(documents)
 		fmt.Printf("📊 Progress: %d/%d documents processed (%.1f%%)\n",
@@ -350,7 +443,7 @@ func processAllDocuments(cfg *Config, stats *ClassificationStats) {
 	}
 }
 
-func processDocumentList(cfg *Config, documents []DocumentInfo, stats *ClassificationStats) {
+func processDocumentList(cfg *Config, documents []DocumentInfo, stats *ClassificationStats, sink ResultSink) {
 	// Create worker pool with rate limiting
 	semaphore := make(chan struct{}, cfg.MaxConcurrentWorkers)
 
@@ -372,7 +465,7 @@ func processDocumentList(cfg *Config, documents []DocumentInfo, stats *Classific
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			processWorker(cfg, workerID, jobChan, semaphore, rateLimiter, stats)
+			processWorker(cfg, workerID, jobChan, semaphore, rateLimiter, stats, sink)
 		}(i)
 	}
 
@@ -391,8 +484,8 @@ func processDocumentList(cfg *Config, documents []DocumentInfo, stats *Classific
 	wg.Wait()
 }
 
-func processWorker(cfg *Config, workerID int, jobChan <-chan []DocumentInfo, semaphore chan struct{}, rateLimiter *time.Ticker, stats *ClassificationStats) {
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+func processWorker(cfg *Config, workerID int, jobChan <-chan []DocumentInfo, semaphore chan struct{}, rateLimiter *time.Ticker, stats *ClassificationStats, sink ResultSink) {
+	client := cfg.HTTPClient
 
 	for batch := range jobChan {
 		// Acquire semaphore
@@ -406,6 +499,9 @@ func processWorker(cfg *Config, workerID int, jobChan <-chan []DocumentInfo, sem
 		if err != nil {
 			log.Printf("❌ Worker %d: Failed to submit batch: %v", workerID, err)
 			atomic.AddInt64(&stats.FailedJobs, 1)
+			if sink != nil {
+				writeRecords(sink, failedRecords(batch, "", err), "", workerID, stats)
+			}
 			<-semaphore
 			continue
 		}
@@ -420,6 +516,11 @@ func processWorker(cfg *Config, workerID int, jobChan <-chan []DocumentInfo, sem
 
 		atomic.AddInt64(&stats.ProcessedDocuments, int64(len(batch)))
 
+		// Save the per-document results, which are only kept in server memory
+		if sink != nil {
+			recordJobResults(cfg, client, sink, jobID, batch, workerID, stats)
+		}
+
 		// Release semaphore
 		<-semaphore
 	}
@@ -445,7 +546,15 @@ func submitBatchJob(cfg *Config, client *http.Client, documents []DocumentInfo)
 
 	// Submit job
 	requestBody, _ := json.Marshal(request)
-	resp, err := client.Post(cfg.APIBaseURL+"/api/v1/batch/classify", "application/json", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequest("POST", cfg.APIBaseURL+"/api/v1/batch/classify", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create batch job request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIToken)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to submit batch job: %w", err)
 	}
@@ -477,16 +586,19 @@ func waitForJobCompletion(cfg *Config, client *http.Client, jobID string, worker
 			time.Sleep(checkInterval)
 			continue
 		}
-		defer resp.Body.Close()
 
+		// Close each poll's body before the next so its connection returns to the pool
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
 			log.Printf("⚠️  Worker %d: Job status check failed: HTTP %d", workerID, resp.StatusCode)
 			time.Sleep(checkInterval)
 			continue
 		}
 
 		var statusResp BatchJobStatusResponse
-		if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
+		err = json.NewDecoder(resp.Body).Decode(&statusResp)
+		resp.Body.Close()
+		if err != nil {
 			log.Printf("⚠️  Worker %d: Failed to decode status response: %v", workerID, err)
 			time.Sleep(checkInterval)
 			continue
@@ -523,10 +635,31 @@ func waitForJobCompletion(cfg *Config, client *http.Client, jobID string, worker
 	return false
 }
 
+// recordJobResults fetches a finished job's results and writes them to the
+// sink. When the results cannot be fetched, the job's documents are recorded
+// as errors.
+func recordJobResults(cfg *Config, client *http.Client, sink ResultSink, jobID string, batch []DocumentInfo, workerID int, stats *ClassificationStats) {
+	records, err := fetchJobResults(cfg, client, jobID)
+	if err != nil {
+		log.Printf("⚠️  Worker %d: Results for job %s not saved: %v", workerID, jobID[:8], err)
+		records = failedRecords(batch, jobID, err)
+	}
+	writeRecords(sink, records, jobID, workerID, stats)
+}
+
+// writeRecords writes records to the sink, logging rather than failing the run
+func writeRecords(sink ResultSink, records []ClassificationRecord, jobID string, workerID int, stats *ClassificationStats) {
+	if err := sink.Write(records); err != nil {
+		log.Printf("⚠️  Worker %d: Results for job %q not saved: %v", workerID, jobID, err)
+		return
+	}
+	atomic.AddInt64(&stats.RecordsWritten, int64(len(records)))
+}
+
 // Helper functions
 
 func getTotalDocumentCount(cfg *Config) (int, error) {
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(cfg.APIBaseURL + "/api/v1/storage/documents/count")
 	if err != nil {
 		return 0, err
@@ -556,7 +689,7 @@ func getDocuments(cfg *Config, cursor string, limit int) ([]DocumentInfo, error)
 		url += "&cursor=" + cursor
 	}
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
@@ -581,7 +714,7 @@ func getDocumentsBatch(cfg *Config, cursor string, limit int) ([]DocumentInfo, s
 		url += "&cursor=" + cursor
 	}
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, "", false, err
@@ -609,6 +742,9 @@ func printFinalStats(stats *ClassificationStats) {
 	fmt.Printf("✅ Successful Batches: %d\n", stats.SuccessfulJobs)
 	fmt.Printf("❌ Failed Batches: %d\n", stats.FailedJobs)
 	fmt.Printf("📋 Documents Processed: %d\n", stats.ProcessedDocuments)
+	if stats.RecordsWritten > 0 {
+		fmt.Printf("💾 Results Recorded: %d\n", stats.RecordsWritten)
+	}
 	if stats.Duration.Minutes() > 0 {
 		fmt.Printf("⚡ Average Rate: %.2f documents/minute\n", stats.Rate)
 	}
@@ -631,6 +767,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := fmt.Sscanf(value, "%d", &defaultValue); err == nil && intValue == 1 {
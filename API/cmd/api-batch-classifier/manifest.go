@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"motion-index-fiber/pkg/manifest"
+)
+
+// manifestSink appends a row per document to a local CSV or JSONL file as
+// each job finishes, so a crashed run still leaves the rows written so far
+type manifestSink struct {
+	mu       sync.Mutex // Keeps the rows of one job together
+	manifest *manifest.Writer
+}
+
+// newManifestSink opens the manifest at path; see manifest.Open for the
+// formats
+func newManifestSink(path string) (*manifestSink, error) {
+	writer, err := manifest.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &manifestSink{manifest: writer}, nil
+}
+
+func (s *manifestSink) Write(records []ClassificationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		entry := manifest.Entry{
+			Path:         record.DocumentPath,
+			Status:       record.Status,
+			DocumentType: record.DocumentType,
+			Confidence:   record.Confidence,
+			Indexed:      record.Indexed,
+			Error:        record.Error,
+			ProcessedAt:  record.ProcessedAt,
+		}
+		if entry.Path == "" {
+			entry.Path = record.DocumentID
+		}
+		if err := s.manifest.Record(entry); err != nil {
+			return fmt.Errorf("failed to write manifest row for %s: %w", entry.Path, err)
+		}
+	}
+	return nil
+}
+
+func (s *manifestSink) Close() error {
+	return s.manifest.Close()
+}
+
+// multiSink writes every record to several sinks
+type multiSink []ResultSink
+
+func (m multiSink) Write(records []ClassificationRecord) error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Write(records))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// failedRecords records every document of a job that could not be submitted
+// or whose results could not be fetched, so the run's outputs still list them
+func failedRecords(documents []DocumentInfo, jobID string, err error) []ClassificationRecord {
+	records := make([]ClassificationRecord, len(documents))
+	for i, doc := range documents {
+		records[i] = ClassificationRecord{
+			JobID:        jobID,
+			DocumentID:   doc.Path,
+			DocumentPath: doc.Path,
+			Status:       "error",
+			Error:        err.Error(),
+			ProcessedAt:  time.Now(),
+		}
+	}
+	return records
+}
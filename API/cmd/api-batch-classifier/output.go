@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// BatchJobResultsResponse represents the response from fetching job results
+type BatchJobResultsResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		JobID   string        `json:"job_id"`
+		Status  string        `json:"status"`
+		Results []BatchResult `json:"results"`
+	} `json:"data"`
+	Message string `json:"message"`
+}
+
+// BatchResult represents the server's result for one document in a job
+type BatchResult struct {
+	DocumentID           string `json:"document_id"`
+	DocumentPath         string `json:"document_path"`
+	Status               string `json:"status"`
+	ClassificationResult *struct {
+		DocumentType  string  `json:"document_type"`
+		LegalCategory string  `json:"legal_category"`
+		Confidence    float64 `json:"confidence"`
+	} `json:"classification_result,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Indexed     bool      `json:"indexed"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// ClassificationRecord is one line of the results output
+type ClassificationRecord struct {
+	JobID         string    `json:"job_id"`
+	DocumentID    string    `json:"document_id"`
+	DocumentPath  string    `json:"document_path,omitempty"`
+	Status        string    `json:"status"`
+	DocumentType  string    `json:"document_type,omitempty"`
+	LegalCategory string    `json:"legal_category,omitempty"`
+	Confidence    float64   `json:"confidence"`
+	Indexed       bool      `json:"indexed"`
+	Error         string    `json:"error,omitempty"`
+	ProcessedAt   time.Time `json:"processed_at"`
+}
+
+// ResultSink receives the classification records of finished jobs
+type ResultSink interface {
+	Write(records []ClassificationRecord) error
+	Close() error
+}
+
+// newResultSink creates a sink for an --output value: an s3://bucket/key path
+// or a local file. Records are written as JSON lines.
+func newResultSink(output string) (ResultSink, error) {
+	if strings.HasPrefix(output, "s3://") {
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(output, "s3://"), "/")
+		if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+			return nil, fmt.Errorf("invalid S3 output %q: use s3://bucket/path/results.jsonl", output)
+		}
+		return newS3Sink(bucket, key)
+	}
+
+	// Append so that several runs can share one record file
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	return &fileSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// fileSink appends records to a local JSONL file as jobs finish
+type fileSink struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func (s *fileSink) Write(records []ClassificationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		if err := s.encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write result for %s: %w", record.DocumentID, err)
+		}
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// s3Sink collects records in memory and uploads them as one object on Close,
+// since S3 objects cannot be appended to
+type s3Sink struct {
+	mu     sync.Mutex
+	client *s3.Client
+	bucket string
+	key    string
+	buffer bytes.Buffer
+}
+
+// newS3Sink creates an S3 client from the storage credentials. The endpoint
+// defaults to DigitalOcean Spaces in STORAGE_REGION; OUTPUT_S3_ENDPOINT
+// overrides it for other S3-compatible stores.
+func newS3Sink(bucket, key string) (*s3Sink, error) {
+	region := getEnv("STORAGE_REGION", getEnv("DO_SPACES_REGION", "nyc3"))
+	endpoint := getEnv("OUTPUT_S3_ENDPOINT", fmt.Sprintf("https://%s.digitaloceanspaces.com", region))
+
+	resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		if service == s3.ServiceID {
+			return aws.Endpoint{URL: endpoint, SigningRegion: region}, nil
+		}
+		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+	})
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(),
+		awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			getEnv("STORAGE_ACCESS_KEY", getEnv("DO_SPACES_KEY", "")),
+			getEnv("STORAGE_SECRET_KEY", getEnv("DO_SPACES_SECRET", "")),
+			"",
+		)),
+		awsconfig.WithRegion(region),
+		awsconfig.WithEndpointResolverWithOptions(resolver),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load S3 config: %w", err)
+	}
+
+	return &s3Sink{client: s3.NewFromConfig(awsConfig), bucket: bucket, key: key}, nil
+}
+
+func (s *s3Sink) Write(records []ClassificationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoder := json.NewEncoder(&s.buffer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode result for %s: %w", record.DocumentID, err)
+		}
+	}
+	return nil
+}
+
+func (s *s3Sink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key),
+		Body:        bytes.NewReader(s.buffer.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload results to s3://%s/%s: %w", s.bucket, s.key, err)
+	}
+	return nil
+}
+
+// fetchJobResults retrieves the per-document results of a finished job
+func fetchJobResults(cfg *Config, client *http.Client, jobID string) ([]ClassificationRecord, error) {
+	resp, err := client.Get(cfg.APIBaseURL + "/api/v1/batch/" + jobID + "/results")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch job results: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("job results request failed: HTTP %d", resp.StatusCode)
+	}
+
+	var resultsResp BatchJobResultsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&resultsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode job results: %w", err)
+	}
+
+	records := make([]ClassificationRecord, 0, len(resultsResp.Data.Results))
+	for _, result := range resultsResp.Data.Results {
+		record := ClassificationRecord{
+			JobID:        jobID,
+			DocumentID:   result.DocumentID,
+			DocumentPath: result.DocumentPath,
+			Status:       result.Status,
+			Indexed:      result.Indexed,
+			Error:        result.Error,
+			ProcessedAt:  result.ProcessedAt,
+		}
+		if classification := result.ClassificationResult; classification != nil {
+			record.DocumentType = classification.DocumentType
+			record.LegalCategory = classification.LegalCategory
+			record.Confidence = classification.Confidence
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// newHTTPClient builds the client shared by every API call in a run. Workers
+// poll job status concurrently, so the idle pool is sized to hold a
+// connection per worker; otherwise each poll would dial and handshake again.
+// The client's timeout still bounds each request on its own.
+func newHTTPClient(cfg *Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.KeepAlivePeriod,
+	}).DialContext
+	transport.DisableKeepAlives = !cfg.KeepAlive
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+
+	return &http.Client{
+		Timeout:   cfg.RequestTimeout,
+		Transport: transport,
+	}
+}
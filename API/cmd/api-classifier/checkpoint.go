@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint records how far a classify-all run got, so `resume` can carry
+// on after the last finished batch instead of starting over
+type Checkpoint struct {
+	Cursor    string    `json:"cursor"`    // Where the next batch starts: the storage cursor, or the last path handled for sorted runs
+	LastPath  string    `json:"last_path"` // Last successfully processed document
+	Sorted    bool      `json:"sorted"`
+	Seen      int64     `json:"seen"`     // Documents handled across all runs, including skipped ones
+	Complete  bool      `json:"complete"` // The run reached the end of the listing
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint reads a checkpoint written by saveCheckpoint
+func loadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint writes cp to a temporary file next to path and renames it
+// into place, so a run killed mid-write leaves the previous checkpoint intact
+func saveCheckpoint(path string, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// checkpoint saves progress after a batch, logging rather than failing the
+// run when it cannot
+func (cfg *Config) checkpoint(cp *Checkpoint) {
+	if cfg.CheckpointFile == "" {
+		return
+	}
+	if err := saveCheckpoint(cfg.CheckpointFile, cp); err != nil {
+		fmt.Printf("⚠️  Checkpoint not saved: %v\n", err)
+	}
+}
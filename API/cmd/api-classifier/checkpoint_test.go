@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessAllDocumentsSequentially_ResumesFromCheckpoint(t *testing.T) {
+	paths := make([]string, 120)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("cases/%03d.pdf", i)
+	}
+	api, cfg := newClassifierAPI(t, paths)
+	cfg.CheckpointFile = filepath.Join(t.TempDir(), "run.checkpoint")
+
+	// The listing fails after the first batch, ending the run early
+	api.setFailListingAt(50)
+	processAllDocumentsSequentially(cfg, &ClassificationStats{TotalDocuments: 120}, 0, nil, false, nil)
+	require.Equal(t, paths[:50], api.classified())
+
+	checkpoint, err := loadCheckpoint(cfg.CheckpointFile)
+	require.NoError(t, err)
+	assert.False(t, checkpoint.Complete)
+	assert.Equal(t, "50", checkpoint.Cursor)
+	assert.EqualValues(t, 50, checkpoint.Seen)
+	assert.Equal(t, "cases/049.pdf", checkpoint.LastPath)
+
+	// Resuming picks up at the next batch, classifying each document once
+	api.setFailListingAt(0)
+	stats := &ClassificationStats{TotalDocuments: 120}
+	processAllDocumentsSequentially(cfg, stats, 0, nil, checkpoint.Sorted, checkpoint)
+	assert.Equal(t, paths, api.classified())
+	assert.EqualValues(t, 70, stats.SuccessfulDocs)
+
+	checkpoint, err = loadCheckpoint(cfg.CheckpointFile)
+	require.NoError(t, err)
+	assert.True(t, checkpoint.Complete)
+	assert.EqualValues(t, 120, checkpoint.Seen)
+	assert.Equal(t, "cases/119.pdf", checkpoint.LastPath)
+}
+
+func TestProcessAllDocumentsSequentially_ResumesSortedRun(t *testing.T) {
+	paths := shuffledPaths(30, 5)
+	api, cfg := newClassifierAPI(t, paths)
+	cfg.CheckpointFile = filepath.Join(t.TempDir(), "run.checkpoint")
+
+	// A sorted run stopped after the document at cases/0011.pdf
+	require.NoError(t, saveCheckpoint(cfg.CheckpointFile, &Checkpoint{Cursor: "cases/0011.pdf", Sorted: true, Seen: 12}))
+	checkpoint, err := loadCheckpoint(cfg.CheckpointFile)
+	require.NoError(t, err)
+
+	processAllDocumentsSequentially(cfg, &ClassificationStats{TotalDocuments: 30}, 0, nil, checkpoint.Sorted, checkpoint)
+
+	classified := api.classified()
+	require.Len(t, classified, 18)
+	assert.Equal(t, "cases/0012.pdf", classified[0])
+	assert.Equal(t, "cases/0029.pdf", classified[17])
+
+	checkpoint, err = loadCheckpoint(cfg.CheckpointFile)
+	require.NoError(t, err)
+	assert.True(t, checkpoint.Complete)
+	assert.EqualValues(t, 30, checkpoint.Seen)
+}
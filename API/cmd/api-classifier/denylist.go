@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Denylist skips storage paths that should never be classified, such as
+// export folders or scratch files that slipped past the file type filters.
+//
+// Patterns are globs (path.Match syntax) unless prefixed with "re:", in which
+// case the rest is a regular expression matched anywhere in the path. A glob
+// that matches a parent folder excludes everything under it, so "*/tmp"
+// skips "cases/tmp/a.pdf" and "cases/tmp/old/b.pdf".
+type Denylist struct {
+	Patterns []string `json:"patterns"`
+
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// loadDenylist reads patterns from a comma-separated list and an optional
+// file with one pattern per line. Blank lines and lines starting with # are
+// ignored. It returns nil when no patterns are configured.
+func loadDenylist(list, file string) (*Denylist, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open denylist file: %w", err)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			patterns = append(patterns, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read denylist file: %w", err)
+		}
+	}
+
+	return newDenylist(patterns)
+}
+
+// newDenylist compiles the given patterns, returning nil for an empty list
+func newDenylist(patterns []string) (*Denylist, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	d := &Denylist{Patterns: patterns}
+	for _, pattern := range patterns {
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid denylist regex %q: %w", expr, err)
+			}
+			d.regexps = append(d.regexps, re)
+			continue
+		}
+
+		pattern = strings.Trim(pattern, "/")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid denylist glob %q: %w", pattern, err)
+		}
+		d.globs = append(d.globs, pattern)
+	}
+	return d, nil
+}
+
+// Denies reports whether a document path matches any pattern
+func (d *Denylist) Denies(docPath string) bool {
+	if d == nil {
+		return false
+	}
+
+	for _, re := range d.regexps {
+		if re.MatchString(docPath) {
+			return true
+		}
+	}
+
+	// Check the path itself, then each parent folder
+	for candidate := strings.Trim(docPath, "/"); candidate != "." && candidate != ""; candidate = path.Dir(candidate) {
+		for _, glob := range d.globs {
+			if matched, _ := path.Match(glob, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenylist_Denies(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "denylist")
+	require.NoError(t, os.WriteFile(file, []byte("# scratch files\n\n*.tmp\nre:^exports/\n"), 0o644))
+
+	denylist, err := loadDenylist("*/tmp, /archive/", file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*/tmp", "/archive/", "*.tmp", "re:^exports/"}, denylist.Patterns)
+
+	for docPath, denied := range map[string]bool{
+		"cases/tmp/a.pdf":     true,
+		"cases/tmp/old/b.pdf": true,
+		"archive/c.pdf":       true,
+		"upload.tmp":          true,
+		"exports/2024/d.pdf":  true,
+		"cases/e.pdf":         false,
+		"cases/tmpfile.pdf":   false,
+		"cases/exports/f.pdf": false,
+	} {
+		assert.Equal(t, denied, denylist.Denies(docPath), docPath)
+	}
+
+	var none *Denylist
+	assert.False(t, none.Denies("cases/tmp/a.pdf"))
+
+	_, err = loadDenylist("re:(", "")
+	assert.Error(t, err)
+	_, err = loadDenylist("[", "")
+	assert.Error(t, err)
+}
+
+func TestProcessAllDocumentsSequentially_SkipsDenylistedPaths(t *testing.T) {
+	paths := []string{"cases/a.pdf", "cases/tmp/b.pdf", "exports/c.pdf", "cases/d.pdf", "cases/e.pdf"}
+	denylist, err := newDenylist([]string{"*/tmp", "re:^exports/"})
+	require.NoError(t, err)
+
+	t.Run("denied paths are never classified", func(t *testing.T) {
+		api, cfg := newClassifierAPI(t, paths)
+		cfg.Denylist = denylist
+		stats := &ClassificationStats{TotalDocuments: int64(len(paths))}
+
+		processAllDocumentsSequentially(cfg, stats, 0, nil, false, nil)
+
+		assert.Equal(t, []string{"cases/a.pdf", "cases/d.pdf", "cases/e.pdf"}, api.classified())
+		assert.EqualValues(t, 2, stats.DeniedDocs)
+		assert.EqualValues(t, 3, stats.SuccessfulDocs)
+	})
+
+	t.Run("denied paths do not count toward skip", func(t *testing.T) {
+		api, cfg := newClassifierAPI(t, paths)
+		cfg.Denylist = denylist
+		stats := &ClassificationStats{TotalDocuments: int64(len(paths))}
+
+		processAllDocumentsSequentially(cfg, stats, 2, nil, false, nil)
+
+		assert.Equal(t, []string{"cases/e.pdf"}, api.classified())
+		assert.EqualValues(t, 2, stats.DeniedDocs)
+	})
+}
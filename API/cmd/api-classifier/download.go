@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultMaxRedirects is the number of redirects a download follows when
+// DOWNLOAD_MAX_REDIRECTS is not set
+const DefaultMaxRedirects = 5
+
+// Download failures that are not plain HTTP errors, so a redirect the
+// classifier could not follow is not reported as a missing document
+var (
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrRedirectBlocked  = errors.New("redirect not followed")
+	ErrRedirectLoop     = errors.New("redirect loop")
+)
+
+// signedURLParams are query parameters of presigned S3 and CDN URLs. Such
+// URLs carry their own credentials, and storage rejects a request that
+// sends an Authorization header as well.
+var signedURLParams = []string{"X-Amz-Signature", "X-Amz-Credential", "Signature", "AWSAccessKeyId"}
+
+// getFollowingRedirects sends req and follows the redirects of the responses
+// itself rather than leaving them to the client, so each hop can be checked:
+// redirects are refused unless cfg.FollowRedirects is set, a URL seen twice
+// or more than cfg.MaxRedirects hops is a loop, and credentials are only
+// sent on to the host they were meant for and never to a signed URL. The
+// final response is returned whatever its status.
+func getFollowingRedirects(cfg *Config, client *http.Client, req *http.Request) (*http.Response, error) {
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+	origin := req.URL.Host
+	visited := map[string]bool{req.URL.String(): true}
+
+	for hops := 0; ; hops++ {
+		resp, err := noFollow.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !isRedirect(resp.StatusCode) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		location := resp.Header.Get("Location")
+		if location == "" {
+			return nil, fmt.Errorf("%w: HTTP %d from %s has no Location", ErrRedirectBlocked, resp.StatusCode, displayURL(req.URL))
+		}
+		next, err := req.URL.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid Location %q: %v", ErrRedirectBlocked, location, err)
+		}
+		if !cfg.FollowRedirects {
+			return nil, fmt.Errorf("%w: %s redirects to %s (set DOWNLOAD_FOLLOW_REDIRECTS=true to follow)",
+				ErrRedirectBlocked, displayURL(req.URL), displayURL(next))
+		}
+		if visited[next.String()] || hops >= maxRedirects {
+			return nil, fmt.Errorf("%w: gave up at %s after %d redirects", ErrRedirectLoop, displayURL(next), hops+1)
+		}
+		visited[next.String()] = true
+
+		nextReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, next.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		nextReq.Header = req.Header.Clone()
+		if next.Host != origin || isSignedURL(next) {
+			nextReq.Header.Del("Authorization")
+			nextReq.Header.Del("Cookie")
+		}
+		req = nextReq
+	}
+}
+
+// isRedirect reports whether status is a redirect with a Location to follow
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// isSignedURL reports whether u carries a presigned URL's credentials
+func isSignedURL(u *url.URL) bool {
+	query := u.Query()
+	for _, param := range signedURLParams {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// displayURL returns u without its query, which for a signed URL holds the
+// signature, for use in errors and logs
+func displayURL(u *url.URL) string {
+	shown := *u
+	shown.RawQuery = ""
+	shown.Fragment = ""
+	return strings.TrimSuffix(shown.String(), "?")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSignedStorageServer serves body only at a URL signed like a presigned
+// S3 URL, rejecting requests that also send an Authorization header as
+// storage does
+func newSignedStorageServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("X-Amz-Signature") != "valid":
+			http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		case r.Header.Get("Authorization") != "":
+			http.Error(w, "Only one auth mechanism allowed", http.StatusBadRequest)
+		default:
+			io.WriteString(w, body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newRedirectingAPIServer redirects every files request to location
+func newRedirectingAPIServer(t *testing.T, location func(r *http.Request) string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location(r), http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadDocumentContent_FollowsRedirectToSignedURL(t *testing.T) {
+	storage := newSignedStorageServer(t, "%PDF-1.7 motion")
+	signedURL := storage.URL + "/documents/motion.pdf?X-Amz-Credential=key&X-Amz-Signature=valid"
+	api := newRedirectingAPIServer(t, func(r *http.Request) string { return signedURL })
+
+	cfg := &Config{APIBaseURL: api.URL, FollowRedirects: true}
+	body, err := downloadDocumentContent(cfg, http.DefaultClient, "documents/motion.pdf")
+	require.NoError(t, err)
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.7 motion", string(content))
+}
+
+func TestGetFollowingRedirects_DropsAuthorizationForSignedURL(t *testing.T) {
+	storage := newSignedStorageServer(t, "signed content")
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer api-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/api/v1/files/a.pdf" {
+			// A same-host hop keeps the token
+			http.Redirect(w, r, "/api/v1/files/b.pdf", http.StatusTemporaryRedirect)
+			return
+		}
+		http.Redirect(w, r, storage.URL+"/b.pdf?X-Amz-Signature=valid", http.StatusFound)
+	}))
+	t.Cleanup(api.Close)
+
+	req, err := http.NewRequest("GET", api.URL+"/api/v1/files/a.pdf", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer api-token")
+
+	resp, err := getFollowingRedirects(&Config{FollowRedirects: true}, http.DefaultClient, req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "signed content", string(content))
+}
+
+func TestDownloadDocumentContent_RedirectErrors(t *testing.T) {
+	storage := newSignedStorageServer(t, "content")
+	signedURL := storage.URL + "/motion.pdf?X-Amz-Signature=valid"
+	toSigned := newRedirectingAPIServer(t, func(r *http.Request) string { return signedURL })
+	loop := newRedirectingAPIServer(t, func(r *http.Request) string {
+		if r.URL.Path == "/a" {
+			return "/b"
+		}
+		return "/a"
+	})
+	endless := newRedirectingAPIServer(t, func(r *http.Request) string { return r.URL.Path + "x" })
+	missing := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(missing.Close)
+	toMissing := newRedirectingAPIServer(t, func(r *http.Request) string { return missing.URL + "/motion.pdf?X-Amz-Signature=valid" })
+
+	tests := []struct {
+		name        string
+		cfg         *Config
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name:        "redirects turned off",
+			cfg:         &Config{APIBaseURL: toSigned.URL},
+			wantErr:     ErrRedirectBlocked,
+			wantMessage: storage.URL + "/motion.pdf",
+		},
+		{
+			name:    "redirect loop",
+			cfg:     &Config{APIBaseURL: loop.URL, FollowRedirects: true},
+			wantErr: ErrRedirectLoop,
+		},
+		{
+			name:        "too many redirects",
+			cfg:         &Config{APIBaseURL: endless.URL, FollowRedirects: true, MaxRedirects: 2},
+			wantErr:     ErrRedirectLoop,
+			wantMessage: "after 3 redirects",
+		},
+		{
+			name:        "redirect to a missing document",
+			cfg:         &Config{APIBaseURL: toMissing.URL, FollowRedirects: true},
+			wantErr:     ErrDocumentNotFound,
+			wantMessage: "HTTP 404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := downloadDocumentContent(tt.cfg, http.DefaultClient, "documents/motion.pdf")
+			require.Nil(t, body)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.NotContains(t, err.Error(), "X-Amz-Signature", "signatures are kept out of errors")
+			assert.Contains(t, err.Error(), tt.wantMessage)
+		})
+	}
+}
@@ -8,21 +8,43 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"motion-index-fiber/pkg/manifest"
 )
 
 // Configuration for the single-threaded classifier
 type Config struct {
-	APIBaseURL      string        `json:"api_base_url"`
-	RequestTimeout  time.Duration `json:"request_timeout"`
-	RetryAttempts   int           `json:"retry_attempts"`
-	RetryDelay      time.Duration `json:"retry_delay"`
-	ProcessingDelay time.Duration `json:"processing_delay"`
+	APIBaseURL      string           `json:"api_base_url"`
+	APIToken        string           `json:"-"` // JWT for /categorise, which requires one unless AUTH_ENABLED=false
+	RequestTimeout  time.Duration    `json:"request_timeout"`
+	RetryAttempts   int              `json:"retry_attempts"`
+	RetryDelay      time.Duration    `json:"retry_delay"`
+	ProcessingDelay time.Duration    `json:"processing_delay"` // Applied by each worker after every document
+	Denylist        *Denylist        `json:"denylist,omitempty"`
+	Workers         int              `json:"workers"` // Documents classified concurrently; 1 keeps the run sequential
+	Manifest        *manifest.Writer `json:"-"`       // Row per document outcome, when --manifest is set
+	CheckpointFile  string           `json:"-"`       // Progress saved after each batch, when --checkpoint-file is set
+
+	// Redirects of document downloads, such as to a signed CDN URL
+	FollowRedirects bool `json:"follow_redirects"`
+	MaxRedirects    int  `json:"max_redirects"` // Zero uses DefaultMaxRedirects
+
+	// Connection reuse for the shared HTTP client
+	KeepAlive       bool          `json:"keep_alive"`
+	KeepAlivePeriod time.Duration `json:"keep_alive_period"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout"`
+	HTTPClient      *http.Client  `json:"-"`
 }
 
 // DocumentInfo represents a document from the storage API
@@ -38,11 +60,10 @@ type DocumentInfo struct {
 type DocumentListResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
-		Documents      []DocumentInfo `json:"documents"`
-		NextCursor     string         `json:"next_cursor"`
-		HasMore        bool           `json:"has_more"`
-		TotalReturned  int            `json:"total_returned"`
-		TotalEstimated int            `json:"total_estimated"`
+		Documents     []DocumentInfo `json:"documents"`
+		NextCursor    string         `json:"next_cursor"`
+		HasMore       bool           `json:"has_more"`
+		TotalReturned int            `json:"total_returned"`
 	} `json:"data"`
 	Message string `json:"message"`
 }
@@ -53,15 +74,21 @@ type ProcessResult struct {
 	Classification map[string]interface{} `json:"classification"`
 	ExtractedText  string                 `json:"extracted_text"`
 	Metadata       map[string]interface{} `json:"metadata"`
+	Indexed        bool                   `json:"indexed"`
 }
 
-// ClassificationStats tracks overall classification statistics
+// ClassificationStats tracks overall classification statistics. Workers
+// update the document counters with sync/atomic.
 type ClassificationStats struct {
 	TotalDocuments     int64         `json:"total_documents"`
 	ProcessedDocuments int64         `json:"processed_documents"`
 	SuccessfulDocs     int64         `json:"successful_docs"`
 	FailedDocs         int64         `json:"failed_docs"`
 	SkippedDocs        int64         `json:"skipped_docs"`
+	SampledOutDocs     int64         `json:"sampled_out_docs"`
+	DeniedDocs         int64         `json:"denied_docs"` // Skipped by the path denylist
+	Sampling           *Sampler      `json:"sampling,omitempty"`
+	Workers            int           `json:"workers"`
 	StartTime          time.Time     `json:"start_time"`
 	Duration           time.Duration `json:"duration"`
 	Rate               float64       `json:"rate_per_minute"`
@@ -92,16 +119,42 @@ func main() {
 	cfg := loadConfig()
 
 	switch command {
-	case "classify-all":
-		skip := 0
-		if len(os.Args) > 2 {
-			if s, err := strconv.Atoi(os.Args[2]); err == nil && s >= 0 {
-				skip = s
-			} else {
-				log.Printf("Invalid skip count, using default: %d", skip)
+	case "classify-all", "resume":
+		args, err := parseClassifyAllArgs(os.Args[2:])
+		if err != nil {
+			log.Fatalf("❌ Invalid %s arguments: %v", command, err)
+		}
+		var resume *Checkpoint
+		if command == "resume" {
+			if args.CheckpointFile == "" {
+				log.Fatalf("❌ resume requires --checkpoint-file")
+			}
+			if args.Skip > 0 {
+				log.Fatalf("❌ resume continues from the checkpoint and does not take a SKIP count")
+			}
+			if resume, err = loadCheckpoint(args.CheckpointFile); err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+			if resume.Complete {
+				fmt.Printf("✅ Checkpoint %s is from a finished run, nothing to resume\n", args.CheckpointFile)
+				return
+			}
+			args.Sorted = resume.Sorted
+		}
+		cfg.setWorkers(args.Workers)
+		cfg.CheckpointFile = args.CheckpointFile
+		if args.Manifest != "" {
+			if cfg.Manifest, err = manifest.Open(args.Manifest); err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+		}
+		classifyAllDocuments(cfg, args.Skip, args.Sampler, args.Sorted, resume)
+		if cfg.Manifest != nil {
+			if err := cfg.Manifest.Close(); err != nil {
+				log.Fatalf("❌ Failed to close manifest: %v", err)
 			}
+			fmt.Printf("📝 Manifest written to %s\n", args.Manifest)
 		}
-		classifyAllDocuments(cfg, skip)
 	case "classify-count":
 		count := 10
 		if len(os.Args) > 2 {
@@ -129,46 +182,111 @@ func printUsage() {
 	fmt.Println("Commands:")
 	fmt.Println("  test-connection        - Test API connection and authentication")
 	fmt.Println("  classify-count [N]     - Classify first N documents (default: 10)")
-	fmt.Println("  classify-all [SKIP]    - Classify ALL documents in storage (sequential)")
+	fmt.Println("  classify-all [FLAGS] [SKIP] - Classify ALL documents in storage (sequential)")
 	fmt.Println("                          SKIP: Optional number of documents to skip from the beginning")
+	fmt.Println("                          --sample N: Process every Nth document")
+	fmt.Println("                          --sample-percent P: Process a deterministic P% sample")
+	fmt.Println("                          --seed S: Seed for reproducible sampling (default: 0)")
+	fmt.Println("                          --workers N: Classify N documents concurrently (default: 1)")
+	fmt.Println("                          --sorted: Process documents in path order, so SKIP is stable across runs")
+	fmt.Println("                                    (holds the full document list in memory)")
+	fmt.Println("                          --manifest PATH: Append each document's outcome to PATH as it finishes")
+	fmt.Println("                                    (CSV for .csv files, JSON lines otherwise)")
+	fmt.Println("                          --checkpoint-file PATH: Save the cursor and last processed path after each batch")
+	fmt.Println("  resume --checkpoint-file PATH [FLAGS] - Continue classify-all from a saved checkpoint")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run cmd/api-classifier/main.go test-connection")
 	fmt.Println("  go run cmd/api-classifier/main.go classify-count 50")
 	fmt.Println("  go run cmd/api-classifier/main.go classify-all")
 	fmt.Println("  go run cmd/api-classifier/main.go classify-all 300    # Skip first 300 documents")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --sample 20 --seed 7")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --sample-percent 5")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --workers 4")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --sorted 300")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --manifest run.csv")
+	fmt.Println("  go run cmd/api-classifier/main.go classify-all --checkpoint-file run.checkpoint")
+	fmt.Println("  go run cmd/api-classifier/main.go resume --checkpoint-file run.checkpoint")
 	fmt.Println()
 	fmt.Println("Environment Variables:")
 	fmt.Println("  API_BASE_URL          - Base URL for the Motion Index API (default: http://localhost:8003)")
 	fmt.Println("  REQUEST_TIMEOUT       - Request timeout in seconds (default: 120)")
 	fmt.Println("  RETRY_ATTEMPTS        - Number of retry attempts (default: 3)")
-	fmt.Println("  PROCESSING_DELAY      - Delay between documents in milliseconds (default: 100)")
+	fmt.Println("  PROCESSING_DELAY      - Delay between documents in milliseconds, per worker (default: 100)")
+	fmt.Println("  CLASSIFY_DENYLIST     - Comma-separated globs (or re:<regex>) of paths to skip in classify-all")
+	fmt.Println("  CLASSIFY_DENYLIST_FILE - File with one denylist pattern per line")
+	fmt.Println("  HTTP_KEEP_ALIVE       - Reuse API connections between requests (default: true)")
+	fmt.Println("  HTTP_MAX_IDLE_CONNS   - Idle connections kept open (default: 2)")
+	fmt.Println("  HTTP_IDLE_CONN_TIMEOUT_SECONDS - Close idle connections after (default: 90)")
+	fmt.Println("  HTTP_KEEP_ALIVE_SECONDS - TCP keep-alive probe interval (default: 30)")
 }
 
 func loadConfig() *Config {
 	cfg := &Config{
 		APIBaseURL:      getEnv("API_BASE_URL", "http://localhost:8003"),
+		APIToken:        getEnv("API_TOKEN", ""),
 		RequestTimeout:  time.Duration(getEnvInt("REQUEST_TIMEOUT", 120)) * time.Second,
 		RetryAttempts:   getEnvInt("RETRY_ATTEMPTS", 3),
 		RetryDelay:      time.Duration(getEnvInt("RETRY_DELAY_SECONDS", 5)) * time.Second,
 		ProcessingDelay: time.Duration(getEnvInt("PROCESSING_DELAY_MS", 100)) * time.Millisecond,
+		KeepAlive:       getEnvBool("HTTP_KEEP_ALIVE", true),
+		KeepAlivePeriod: time.Duration(getEnvInt("HTTP_KEEP_ALIVE_SECONDS", 30)) * time.Second,
+		MaxIdleConns:    getEnvInt("HTTP_MAX_IDLE_CONNS", 2),
+		IdleConnTimeout: time.Duration(getEnvInt("HTTP_IDLE_CONN_TIMEOUT_SECONDS", 90)) * time.Second,
+		FollowRedirects: getEnvBool("DOWNLOAD_FOLLOW_REDIRECTS", true),
+		MaxRedirects:    getEnvInt("DOWNLOAD_MAX_REDIRECTS", DefaultMaxRedirects),
+		Workers:         1,
 	}
+	cfg.HTTPClient = newHTTPClient(cfg)
+
+	denylist, err := loadDenylist(getEnv("CLASSIFY_DENYLIST", ""), getEnv("CLASSIFY_DENYLIST_FILE", ""))
+	if err != nil {
+		log.Fatalf("❌ Invalid denylist: %v", err)
+	}
+	cfg.Denylist = denylist
 
 	fmt.Printf("🔧 Configuration loaded:\n")
 	fmt.Printf("   API Base URL: %s\n", cfg.APIBaseURL)
+	fmt.Printf("   API Token: %t\n", cfg.APIToken != "")
 	fmt.Printf("   Request Timeout: %s\n", cfg.RequestTimeout)
 	fmt.Printf("   Retry Attempts: %d\n", cfg.RetryAttempts)
 	fmt.Printf("   Processing Delay: %s\n", cfg.ProcessingDelay)
+	fmt.Printf("   Keep-Alive: %t (idle pool: %d)\n", cfg.KeepAlive, cfg.MaxIdleConns)
+	if cfg.Denylist != nil {
+		fmt.Printf("   Denylist: %d patterns\n", len(cfg.Denylist.Patterns))
+	}
 	fmt.Println()
 
 	return cfg
 }
 
+// authorize sends the API token, when one is set, with a request to a route
+// that requires a JWT
+func (cfg *Config) authorize(req *http.Request) {
+	if cfg.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIToken)
+	}
+}
+
+// setWorkers sets the worker count, growing the idle connection pool so
+// every worker can keep its connection unless HTTP_MAX_IDLE_CONNS is set
+func (cfg *Config) setWorkers(workers int) {
+	cfg.Workers = workers
+	if workers <= 1 {
+		return
+	}
+	if os.Getenv("HTTP_MAX_IDLE_CONNS") == "" && cfg.MaxIdleConns < workers+1 {
+		cfg.MaxIdleConns = workers + 1
+		cfg.HTTPClient = newHTTPClient(cfg)
+	}
+	fmt.Printf("   Workers: %d (idle pool: %d)\n\n", cfg.Workers, cfg.MaxIdleConns)
+}
+
 func testAPIConnection(cfg *Config) {
 	fmt.Println("🔍 Testing API Connection")
 	fmt.Println("=========================")
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 
 	// Test health endpoint
 	fmt.Println("📊 Testing health endpoint...")
@@ -200,13 +318,14 @@ func testAPIConnection(cfg *Config) {
 		log.Fatalf("❌ Failed to decode document response: %v", err)
 	}
 
-	fmt.Printf("✅ Document listing OK - found %d documents\n", docResp.Data.TotalEstimated)
+	fmt.Printf("✅ Document listing OK - listed %d documents\n", docResp.Data.TotalReturned)
 
 	// Test processing endpoint
 	fmt.Println("🔄 Testing processing endpoint availability...")
 	testURL := cfg.APIBaseURL + "/api/v1/categorise"
 	req, _ := http.NewRequest("POST", testURL, nil)
 	req.Header.Set("Content-Type", "multipart/form-data")
+	cfg.authorize(req)
 	
 	resp, err = client.Do(req)
 	if err != nil {
@@ -215,6 +334,8 @@ func testAPIConnection(cfg *Config) {
 		defer resp.Body.Close()
 		if resp.StatusCode == 400 {
 			fmt.Println("✅ Processing endpoint available (expected 400 for empty request)")
+		} else if resp.StatusCode == http.StatusUnauthorized {
+			fmt.Println("⚠️  Processing endpoint refused the request: set API_TOKEN to a valid JWT")
 		} else {
 			fmt.Printf("⚠️  Processing endpoint returned: HTTP %d\n", resp.StatusCode)
 		}
@@ -223,17 +344,36 @@ func testAPIConnection(cfg *Config) {
 	fmt.Println("✅ API connection test complete!")
 }
 
-func classifyAllDocuments(cfg *Config, skip int) {
+func classifyAllDocuments(cfg *Config, skip int, sampler *Sampler, sorted bool, resume *Checkpoint) {
+	mode := "Single-Threaded"
+	if cfg.Workers > 1 {
+		mode = fmt.Sprintf("%d-Worker", cfg.Workers)
+	}
 	if skip > 0 {
-		fmt.Printf("🚀 Single-Threaded Classification of All Documents (skipping first %d)\n", skip)
+		fmt.Printf("🚀 %s Classification of All Documents (skipping first %d)\n", mode, skip)
 	} else {
-		fmt.Println("🚀 Single-Threaded Classification of All Documents")
+		fmt.Printf("🚀 %s Classification of All Documents\n", mode)
 	}
 	fmt.Println("===================================================")
+	if sampler != nil {
+		fmt.Printf("🎯 Sampling: %s\n", sampler)
+	}
+	if sorted {
+		fmt.Println("🔤 Processing documents in path order")
+	}
+	if resume != nil {
+		fmt.Printf("↩️  Resuming from checkpoint saved %s (%d documents already handled, last processed: %s)\n",
+			resume.UpdatedAt.Format(time.RFC3339), resume.Seen, resume.LastPath)
+	}
+	if cfg.CheckpointFile != "" {
+		fmt.Printf("💾 Saving progress to %s after each batch\n", cfg.CheckpointFile)
+	}
 
 	startTime := time.Now()
 	stats := &ClassificationStats{
 		StartTime: startTime,
+		Sampling:  sampler,
+		Workers:   cfg.Workers,
 	}
 
 	// Get total document count first
@@ -259,7 +399,7 @@ func classifyAllDocuments(cfg *Config, skip int) {
 	}
 
 	// Process all documents using pagination
-	processAllDocumentsSequentially(cfg, stats, skip)
+	processAllDocumentsSequentially(cfg, stats, skip, sampler, sorted, resume)
 
 	// Final statistics
 	stats.Duration = time.Since(startTime)
@@ -277,6 +417,7 @@ func classifyDocumentsCount(cfg *Config, maxDocuments int) {
 	startTime := time.Now()
 	stats := &ClassificationStats{
 		StartTime: startTime,
+		Workers:   cfg.Workers,
 	}
 
 	// Get documents with limit
@@ -293,8 +434,8 @@ func classifyDocumentsCount(cfg *Config, maxDocuments int) {
 		return
 	}
 
-	// Process documents sequentially
-	processDocumentListSequentially(cfg, documents, stats)
+	// Process documents
+	processDocumentList(cfg, documents, stats)
 
 	// Final statistics
 	stats.Duration = time.Since(startTime)
@@ -305,15 +446,32 @@ func classifyDocumentsCount(cfg *Config, maxDocuments int) {
 	printFinalStats(stats)
 }
 
-func processAllDocumentsSequentially(cfg *Config, stats *ClassificationStats, skip int) {
-	cursor := ""
+func processAllDocumentsSequentially(cfg *Config, stats *ClassificationStats, skip int, sampler *Sampler, sorted bool, resume *Checkpoint) {
 	totalProcessed := 0
 	totalSkipped := 0
+	totalSampledOut := 0
+	totalDenied := 0
 	batchSize := 50 // Process documents in batches for memory efficiency
 
+	progress := &Checkpoint{Sorted: sorted}
+	if resume != nil {
+		progress.Cursor = resume.Cursor
+		progress.LastPath = resume.LastPath
+		progress.Seen = resume.Seen
+	}
+
+	nextBatch := cursorBatches(cfg, batchSize, progress.Cursor)
+	if sorted {
+		var err error
+		if nextBatch, err = sortedBatches(cfg, batchSize, progress.Cursor); err != nil {
+			log.Printf("❌ Failed to list documents: %v", err)
+			return
+		}
+	}
+
 	for {
 		// Get batch of documents
-		documents, nextCursor, hasMore, err := getDocumentsBatch(cfg, cursor, batchSize)
+		documents, position, next, hasMore, err := nextBatch()
 		if err != nil {
 			log.Printf("❌ Failed to get document batch: %v", err)
 			break
@@ -323,80 +481,169 @@ func processAllDocumentsSequentially(cfg *Config, stats *ClassificationStats, sk
 			break
 		}
 
-		cursorDisplay := cursor
-		if len(cursorDisplay) > 8 {
-			cursorDisplay = cursorDisplay[:8]
-		}
-
-		// Filter out documents we want to skip
+		// Filter out documents we want to skip, that are denylisted or that fall
+		// outside the sample. Denied documents don't count toward SKIP.
 		var documentsToProcess []DocumentInfo
+		sampledOut := 0
 		for _, doc := range documents {
-			if totalSkipped+totalProcessed < skip {
+			if cfg.Denylist.Denies(doc.Path) {
+				totalDenied++
+				stats.DeniedDocs++
+				fmt.Printf("🚫 Denylisted: %s\n", doc.Path)
+				recordManifest(cfg.Manifest, manifest.Entry{Path: doc.Path, Status: ManifestStatusDenied})
+			} else if totalSkipped+totalProcessed < skip {
 				totalSkipped++
 				fmt.Printf("⏭️  Skipping document %d: %s\n", totalSkipped, doc.Path)
+				recordManifest(cfg.Manifest, manifest.Entry{Path: doc.Path, Status: ManifestStatusSkipped})
+			} else if !sampler.Include(doc) {
+				sampledOut++
+				recordManifest(cfg.Manifest, manifest.Entry{Path: doc.Path, Status: ManifestStatusNotSampled})
 			} else {
 				documentsToProcess = append(documentsToProcess, doc)
 			}
 		}
+		totalSampledOut += sampledOut
+		stats.SampledOutDocs += int64(sampledOut)
 
 		if len(documentsToProcess) > 0 {
-			fmt.Printf("📋 Processing batch of %d documents (skipped %d, cursor: %s)\n", 
-				len(documentsToProcess), totalSkipped, cursorDisplay)
+			fmt.Printf("📋 Processing batch of %d documents (skipped %d, %s)\n", 
+				len(documentsToProcess), totalSkipped, position)
 
-			// Process this batch sequentially
-			processDocumentListSequentially(cfg, documentsToProcess, stats)
+			// Process this batch, across the workers when there are several
+			if lastPath := processDocumentList(cfg, documentsToProcess, stats); lastPath != "" {
+				progress.LastPath = lastPath
+			}
 			totalProcessed += len(documentsToProcess)
 		} else {
 			fmt.Printf("📋 Skipped entire batch of %d documents (total skipped: %d)\n", 
 				len(documents), totalSkipped)
 		}
 
-		fmt.Printf("📊 Progress: %d processed, %d skipped, %d/%d total (%.1f%%)\n",
-			totalProcessed, totalSkipped, totalSkipped+totalProcessed, 
-			stats.TotalDocuments, float64(totalSkipped+totalProcessed)/float64(stats.TotalDocuments)*100)
+		seen := totalSkipped + totalProcessed + totalSampledOut + totalDenied
+		fmt.Printf("📊 Progress: %d processed, %d skipped, %d not sampled, %d denylisted, %d/%d total (%.1f%%)\n",
+			totalProcessed, totalSkipped, totalSampledOut, totalDenied, seen,
+			stats.TotalDocuments, float64(seen)/float64(stats.TotalDocuments)*100)
+
+		progress.Cursor = next
+		progress.Seen += int64(len(documents))
+		progress.Complete = !hasMore
+		cfg.checkpoint(progress)
 
 		if !hasMore {
 			break
 		}
-		cursor = nextCursor
 
 		// Small delay between batches
-		time.Sleep(1 * time.Second)
+		if !sorted {
+			time.Sleep(1 * time.Second)
+		}
 	}
 }
 
-func processDocumentListSequentially(cfg *Config, documents []DocumentInfo, stats *ClassificationStats) {
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+// documentOutcome is the result of classifying one document of a list
+type documentOutcome struct {
+	success bool
+	err     error
+	result  *ProcessResult
+	failure *ProcessingError
+	output  bytes.Buffer
+	done    time.Time
+}
+
+// processDocumentList classifies documents across cfg.Workers workers. Each
+// worker buffers a document's log lines, and the buffers are printed in list
+// order as documents finish, so the progress log reads as it does for a
+// sequential run. A single worker logs directly, exactly as before. It
+// returns the path of the last document in the list that was processed
+// successfully, or "" if none was.
+func processDocumentList(cfg *Config, documents []DocumentInfo, stats *ClassificationStats) string {
+	client := cfg.HTTPClient
 	var errors []ProcessingError
+	lastPath := ""
 
-	for i, doc := range documents {
-		fmt.Printf("🔄 [%d/%d] Processing: %s\n", i+1, len(documents), doc.Path)
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(documents) {
+		workers = len(documents)
+	}
 
-		// Process single document
-		success, err := processDocument(cfg, client, doc)
-		
-		stats.ProcessedDocuments++
-		
-		if success {
-			stats.SuccessfulDocs++
-			fmt.Printf("✅ [%d/%d] Successfully processed: %s\n", i+1, len(documents), doc.Path)
-		} else {
-			// Failed processing
-			stats.FailedDocs++
-			errorInfo := ProcessingError{
-				DocumentPath: doc.Path,
-				Error:        fmt.Sprintf("%v", err),
-				Timestamp:    time.Now(),
+	outcomes := make([]*documentOutcome, len(documents))
+	done := make([]chan struct{}, len(documents))
+	for i := range documents {
+		outcomes[i] = &documentOutcome{}
+		done[i] = make(chan struct{})
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outcome := outcomes[i]
+				var out io.Writer = &outcome.output
+				if workers == 1 {
+					out = os.Stdout
+				}
+				doc := documents[i]
+				fmt.Fprintf(out, "🔄 [%d/%d] Processing: %s\n", i+1, len(documents), doc.Path)
+
+				// Process single document
+				outcome.result, outcome.err = processDocument(cfg, client, doc, out)
+				outcome.success = outcome.err == nil
+				outcome.done = time.Now()
+
+				atomic.AddInt64(&stats.ProcessedDocuments, 1)
+
+				if outcome.success {
+					atomic.AddInt64(&stats.SuccessfulDocs, 1)
+					fmt.Fprintf(out, "✅ [%d/%d] Successfully processed: %s\n", i+1, len(documents), doc.Path)
+				} else {
+					// Failed processing
+					atomic.AddInt64(&stats.FailedDocs, 1)
+					outcome.failure = &ProcessingError{
+						DocumentPath: doc.Path,
+						Error:        fmt.Sprintf("%v", outcome.err),
+						Timestamp:    time.Now(),
+					}
+					fmt.Fprintf(out, "❌ [%d/%d] Failed to process: %s - %v\n", i+1, len(documents), doc.Path, outcome.err)
+				}
+				close(done[i])
+
+				// Add delay between documents; with several workers this
+				// limits each worker's request rate
+				if cfg.ProcessingDelay > 0 {
+					time.Sleep(cfg.ProcessingDelay)
+				}
 			}
-			errors = append(errors, errorInfo)
-			fmt.Printf("❌ [%d/%d] Failed to process: %s - %v\n", i+1, len(documents), doc.Path, err)
-		}
+		}()
+	}
 
-		// Add delay between documents
-		if cfg.ProcessingDelay > 0 {
-			time.Sleep(cfg.ProcessingDelay)
+	go func() {
+		for i := range documents {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	for i := range documents {
+		<-done[i]
+		outcome := outcomes[i]
+		if workers > 1 {
+			os.Stdout.Write(outcome.output.Bytes())
 		}
+		if outcome.failure != nil {
+			errors = append(errors, *outcome.failure)
+		}
+		if outcome.success {
+			lastPath = documents[i].Path
+		}
+		recordManifest(cfg.Manifest, manifestEntry(documents[i], outcome))
 	}
+	wg.Wait()
 
 	// Log errors if any
 	if len(errors) > 0 {
@@ -405,51 +652,103 @@ func processDocumentListSequentially(cfg *Config, documents []DocumentInfo, stat
 			fmt.Printf("   - %s: %s\n", e.DocumentPath, e.Error)
 		}
 	}
+	return lastPath
 }
 
-func processDocument(cfg *Config, client *http.Client, doc DocumentInfo) (bool, error) {
+func processDocument(cfg *Config, client *http.Client, doc DocumentInfo, out io.Writer) (*ProcessResult, error) {
 	// Step 1: Download document content from storage
-	fmt.Printf("   📥 Downloading document content...\n")
+	fmt.Fprintf(out, "   📥 Downloading document content...\n")
 	docContent, err := downloadDocumentContent(cfg, client, doc.Path)
 	if err != nil {
-		return false, fmt.Errorf("failed to download document: %w", err)
+		return nil, fmt.Errorf("failed to download document: %w", err)
 	}
 	defer docContent.Close()
 
 	// Step 2: Process document through the processing API
-	fmt.Printf("   🤖 Classifying document...\n")
-	_, err = processDocumentWithAPI(cfg, client, doc, docContent)
+	fmt.Fprintf(out, "   🤖 Classifying document...\n")
+	result, err := processDocumentWithAPI(cfg, client, doc, docContent, out)
 	if err != nil {
-		return false, fmt.Errorf("failed to process document: %w", err)
+		return nil, fmt.Errorf("failed to process document: %w", err)
 	}
 
 	// Step 3: Document is automatically indexed by the processing pipeline
 	// No need for manual indexing since we set index_document=true
 
-	return true, nil
+	return result, nil
+}
+
+// manifestEntry builds the manifest row for a classified or failed document
+func manifestEntry(doc DocumentInfo, outcome *documentOutcome) manifest.Entry {
+	entry := manifest.Entry{Path: doc.Path, Status: ManifestStatusSuccess, ProcessedAt: outcome.done}
+	if !outcome.success {
+		entry.Status = ManifestStatusFailed
+		entry.Error = fmt.Sprintf("%v", outcome.err)
+		return entry
+	}
+	if result := outcome.result; result != nil {
+		entry.DocumentType, _ = result.Classification["category"].(string)
+		entry.Confidence, _ = result.Classification["confidence"].(float64)
+		entry.Indexed = result.Indexed
+	}
+	return entry
 }
 
 // Duplicate checking functions removed - processing all documents without checks
 
-// downloadDocumentContent downloads the document content from storage
+// documentFileURL returns the files API URL of a stored document. The path is
+// cleaned and each segment escaped, so names with spaces, '#' or '?' reach the
+// server intact; paths that would climb out of the storage root are rejected.
+func documentFileURL(baseURL, docPath string) (string, error) {
+	cleaned := path.Clean("/" + strings.TrimLeft(docPath, "/"))[1:]
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid document path %q", docPath)
+	}
+	for _, segment := range strings.Split(docPath, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid document path %q: directory traversal not allowed", docPath)
+		}
+	}
+
+	segments := strings.Split(cleaned, "/")
+	for i, segment := range segments {
+		// The server decodes with url.QueryUnescape, which reads '+' as a space
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return fmt.Sprintf("%s/api/v1/files/%s", baseURL, strings.Join(segments, "/")), nil
+}
+
+// downloadDocumentContent downloads the document content from storage. The
+// files API may redirect to the storage or CDN URL of the document; see
+// getFollowingRedirects for how redirects are followed.
 func downloadDocumentContent(cfg *Config, client *http.Client, docPath string) (io.ReadCloser, error) {
-	downloadURL := fmt.Sprintf("%s/api/v1/files/%s", cfg.APIBaseURL, docPath)
-	
-	resp, err := client.Get(downloadURL)
+	downloadURL, err := documentFileURL(cfg.APIBaseURL, docPath)
 	if err != nil {
 		return nil, err
 	}
-	
-	if resp.StatusCode != http.StatusOK {
+
+	req, err := http.NewRequest("GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := getFollowingRedirects(cfg, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: HTTP 404 from %s", ErrDocumentNotFound, displayURL(resp.Request.URL))
+	default:
 		resp.Body.Close()
-		return nil, fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("download failed: HTTP %d from %s", resp.StatusCode, displayURL(resp.Request.URL))
 	}
-	
-	return resp.Body, nil
 }
 
 // processDocumentWithAPI processes the document using the processing API
-func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo, content io.Reader) (*ProcessResult, error) {
+func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo, content io.Reader, out io.Writer) (*ProcessResult, error) {
 	processURL := cfg.APIBaseURL + "/api/v1/categorise"
 	
 	// Create multipart form with the file content
@@ -488,6 +787,7 @@ func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo,
 	}
 	
 	req.Header.Set("Content-Type", writer.FormDataContentType())
+	cfg.authorize(req)
 	
 	// Execute request with retries
 	var resp *http.Response
@@ -502,7 +802,7 @@ func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo,
 		}
 		
 		if attempt < cfg.RetryAttempts {
-			fmt.Printf("   ⏳ Retry %d/%d for processing after %v\n", attempt+1, cfg.RetryAttempts, cfg.RetryDelay)
+			fmt.Fprintf(out, "   ⏳ Retry %d/%d for processing after %v\n", attempt+1, cfg.RetryAttempts, cfg.RetryDelay)
 			time.Sleep(cfg.RetryDelay)
 		}
 	}
@@ -532,6 +832,9 @@ func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo,
 				Confidence float64  `json:"confidence"`
 				Tags       []string `json:"tags"`
 			} `json:"classification_result"`
+			IndexResult struct {
+				Success bool `json:"success"`
+			} `json:"index_result"`
 		} `json:"data"`
 		Message string `json:"message"`
 	}
@@ -548,6 +851,7 @@ func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo,
 	result := &ProcessResult{
 		DocumentID:    response.Data.DocumentID,
 		ExtractedText: response.Data.ExtractionResult.Text,
+		Indexed:       response.Data.IndexResult.Success,
 		Classification: map[string]interface{}{
 			"category":   response.Data.ClassificationResult.Category,
 			"confidence": response.Data.ClassificationResult.Confidence,
@@ -571,7 +875,7 @@ func processDocumentWithAPI(cfg *Config, client *http.Client, doc DocumentInfo,
 // Helper functions
 
 func getTotalDocumentCount(cfg *Config) (int, error) {
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(cfg.APIBaseURL + "/api/v1/storage/documents/count")
 	if err != nil {
 		return 0, err
@@ -601,7 +905,7 @@ func getDocuments(cfg *Config, cursor string, limit int) ([]DocumentInfo, error)
 		url += "&cursor=" + cursor
 	}
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
@@ -626,7 +930,7 @@ func getDocumentsBatch(cfg *Config, cursor string, limit int) ([]DocumentInfo, s
 		url += "&cursor=" + cursor
 	}
 
-	client := &http.Client{Timeout: cfg.RequestTimeout}
+	client := cfg.HTTPClient
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, "", false, err
@@ -647,13 +951,24 @@ func getDocumentsBatch(cfg *Config, cursor string, limit int) ([]DocumentInfo, s
 
 func printFinalStats(stats *ClassificationStats) {
 	fmt.Println("\n" + strings.Repeat("=", 80))
-	fmt.Println("📊 SINGLE-THREADED CLASSIFICATION COMPLETE")
+	if stats.Workers > 1 {
+		fmt.Printf("📊 %d-WORKER CLASSIFICATION COMPLETE\n", stats.Workers)
+	} else {
+		fmt.Println("📊 SINGLE-THREADED CLASSIFICATION COMPLETE")
+	}
 	fmt.Println(strings.Repeat("=", 80))
 	fmt.Printf("⏱️  Total Processing Time: %v\n", stats.Duration)
 	fmt.Printf("📁 Total Documents Found: %d\n", stats.TotalDocuments)
 	fmt.Printf("✅ Successfully Processed: %d\n", stats.SuccessfulDocs)
 	fmt.Printf("❌ Failed Documents: %d\n", stats.FailedDocs)
 	fmt.Printf("📋 Total Processed: %d\n", stats.ProcessedDocuments)
+	if stats.Sampling != nil {
+		fmt.Printf("🎯 Sampling: %s - %d processed, %d not sampled\n",
+			stats.Sampling, stats.ProcessedDocuments, stats.SampledOutDocs)
+	}
+	if stats.DeniedDocs > 0 {
+		fmt.Printf("🚫 Skipped by Denylist: %d\n", stats.DeniedDocs)
+	}
 	if stats.Duration.Minutes() > 0 {
 		fmt.Printf("⚡ Average Rate: %.2f documents/minute\n", stats.Rate)
 	}
@@ -663,8 +978,12 @@ func printFinalStats(stats *ClassificationStats) {
 	}
 	fmt.Println()
 	fmt.Println("💡 IMPLEMENTATION NOTES:")
-	fmt.Println("   - This is a sequential, single-threaded processor")
-	fmt.Println("   - Documents are processed one at a time for easier debugging")
+	if stats.Workers > 1 {
+		fmt.Printf("   - Documents were classified by %d workers; logs are printed in listing order\n", stats.Workers)
+	} else {
+		fmt.Println("   - This is a sequential, single-threaded processor")
+		fmt.Println("   - Documents are processed one at a time for easier debugging")
+	}
 	fmt.Println("   - Uses /categorise endpoint with index_document=true for integrated processing")
 	fmt.Println("   - ⚠️  DUPLICATE CHECKING DISABLED - processes ALL documents without existence checks")
 	fmt.Println("   - Supports all enhanced metadata fields (dates, court info, parties, etc.)")
@@ -692,6 +1011,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/handlers"
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/storage"
+)
+
+// fileStore holds documents in memory and serves them at their signed URLs
+type fileStore struct {
+	files   map[string]string
+	baseURL string
+}
+
+func (s *fileStore) Upload(ctx context.Context, path string, content io.Reader, metadata *storage.UploadMetadata) (*storage.UploadResult, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (s *fileStore) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	content, ok := s.files[path]
+	if !ok {
+		return nil, fmt.Errorf("not found: %s", path)
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s *fileStore) Delete(ctx context.Context, path string) error { return nil }
+
+func (s *fileStore) GetURL(path string) string {
+	return s.baseURL + "/?key=" + url.QueryEscape(path)
+}
+
+func (s *fileStore) GetSignedURL(path string, expiration time.Duration) (string, error) {
+	return s.GetURL(path), nil
+}
+
+func (s *fileStore) Exists(ctx context.Context, path string) (bool, error) {
+	_, ok := s.files[path]
+	return ok, nil
+}
+
+func (s *fileStore) List(ctx context.Context, prefix string) ([]*storage.StorageObject, error) {
+	return nil, nil
+}
+
+func (s *fileStore) ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	return &storage.ListPage{}, nil
+}
+
+func (s *fileStore) IsHealthy() bool { return true }
+
+func (s *fileStore) GetMetrics() map[string]interface{} { return nil }
+
+// newFilesServer serves files through the API's files route
+func newFilesServer(t *testing.T, files map[string]string) string {
+	t.Helper()
+	store := &fileStore{files: files}
+	content := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := store.Download(r.Context(), r.URL.Query().Get("key"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer reader.Close()
+		io.Copy(w, reader)
+	}))
+	t.Cleanup(content.Close)
+	store.baseURL = content.URL
+
+	app := fiber.New()
+	app.Get("/api/v1/files/*", handlers.NewStorageHandler(testutil.TestConfig(), store).ServeFile)
+	server := httptest.NewServer(adaptor.FiberApp(app))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestDownloadDocumentContent_EncodesPath(t *testing.T) {
+	files := map[string]string{
+		"documents/2024/Motion to Suppress.pdf":     "spaces",
+		"documents/2024/Smith & Jones #2.pdf":       "ampersand and hash",
+		"documents/2024/Peña v. Müller – Order.pdf": "unicode",
+		"documents/2024/Notice+Hearing 100%.pdf":    "plus and percent",
+	}
+	cfg := &Config{APIBaseURL: newFilesServer(t, files), FollowRedirects: true}
+
+	for docPath, expected := range files {
+		body, err := downloadDocumentContent(cfg, http.DefaultClient, docPath)
+		require.NoError(t, err, docPath)
+		content, err := io.ReadAll(body)
+		body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content), docPath)
+	}
+}
+
+func TestProcessDocumentWithAPI_SendsAPIToken(t *testing.T) {
+	api, cfg := newClassifierAPI(t, nil)
+	doc := DocumentInfo{Path: "cases/motion.pdf", Filename: "motion.pdf"}
+
+	_, err := processDocumentWithAPI(cfg, cfg.HTTPClient, doc, strings.NewReader("%PDF-1.4"), io.Discard)
+	require.NoError(t, err)
+	cfg.APIToken = "test-jwt"
+	_, err = processDocumentWithAPI(cfg, cfg.HTTPClient, doc, strings.NewReader("%PDF-1.4"), io.Discard)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "Bearer test-jwt"}, api.authorization)
+}
+
+func TestDocumentFileURL(t *testing.T) {
+	fileURL, err := documentFileURL("http://api", "/documents//2024/./a b?.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "http://api/api/v1/files/documents/2024/a%20b%3F.pdf", fileURL)
+
+	for _, docPath := range []string{"", "/", "../secrets.pdf", "documents/../../etc/passwd"} {
+		_, err := documentFileURL("http://api", docPath)
+		assert.Error(t, err, docPath)
+	}
+}
+
+// classifierAPI fakes the listing, files and categorise routes a classify-all
+// run calls. The listing pages through paths in the given order, with the
+// offset of the next page as its cursor.
+type classifierAPI struct {
+	paths []string
+
+	mu            sync.Mutex
+	downloaded    []string
+	failListingAt int      // Listing pages from this offset on fail; zero never fails
+	authorization []string // Authorization header of each categorise request
+}
+
+// setFailListingAt makes listing pages from offset on fail, or none for zero
+func (a *classifierAPI) setFailListingAt(offset int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failListingAt = offset
+}
+
+// newClassifierAPI serves paths and returns a config pointing at the fake
+func newClassifierAPI(t *testing.T, paths []string) (*classifierAPI, *Config) {
+	t.Helper()
+	api := &classifierAPI{paths: paths}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return api, &Config{APIBaseURL: server.URL, HTTPClient: server.Client(), Workers: 1}
+}
+
+func (a *classifierAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v1/storage/documents":
+		a.list(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/v1/files/"):
+		a.mu.Lock()
+		a.downloaded = append(a.downloaded, strings.TrimPrefix(r.URL.Path, "/api/v1/files/"))
+		a.mu.Unlock()
+		w.Write([]byte("%PDF-1.4"))
+	case r.URL.Path == "/api/v1/categorise":
+		a.mu.Lock()
+		a.authorization = append(a.authorization, r.Header.Get("Authorization"))
+		a.mu.Unlock()
+		w.Write([]byte(`{"success":true,"data":{"document_id":"doc","classification_result":{"category":"motion","confidence":0.9},"index_result":{"success":true}}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (a *classifierAPI) list(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	end := min(offset+limit, len(a.paths))
+
+	a.mu.Lock()
+	failing := a.failListingAt > 0 && offset >= a.failListingAt
+	a.mu.Unlock()
+	if failing {
+		http.Error(w, `{"success":false}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var response DocumentListResponse
+	response.Success = true
+	for _, docPath := range a.paths[offset:end] {
+		response.Data.Documents = append(response.Data.Documents, DocumentInfo{
+			Path: docPath, Filename: path.Base(docPath), FileType: "pdf", Size: 1024,
+		})
+	}
+	response.Data.HasMore = end < len(a.paths)
+	if response.Data.HasMore {
+		response.Data.NextCursor = strconv.Itoa(end)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// classified returns the paths downloaded for classification so far
+func (a *classifierAPI) classified() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.downloaded...)
+}
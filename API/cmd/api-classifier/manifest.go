@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	"motion-index-fiber/pkg/manifest"
+)
+
+// Manifest statuses, one per document outcome
+const (
+	ManifestStatusSuccess    = "success"
+	ManifestStatusFailed     = "failed"
+	ManifestStatusSkipped    = "skipped"     // Before the SKIP count
+	ManifestStatusNotSampled = "not_sampled" // Outside the --sample selection
+	ManifestStatusDenied     = "denylisted"
+)
+
+// recordManifest writes a row, logging rather than failing the run when it cannot
+func recordManifest(m *manifest.Writer, entry manifest.Entry) {
+	if err := m.Record(entry); err != nil {
+		fmt.Printf("⚠️  Manifest row for %s not written: %v\n", entry.Path, err)
+	}
+}
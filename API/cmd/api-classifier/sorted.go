@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// listingPageSize is the largest page the storage API returns
+const listingPageSize = 500
+
+// documentBatches returns the next batch of documents for classify-all,
+// where the batch came from for the progress log, the cursor a resumed run
+// continues from once the batch is done, and whether more follow
+type documentBatches func() (documents []DocumentInfo, position string, next string, hasMore bool, err error)
+
+// cursorBatches pages through storage in listing order from cursor, holding
+// one batch in memory at a time. Listing order is not guaranteed to be
+// stable between runs.
+func cursorBatches(cfg *Config, batchSize int, cursor string) documentBatches {
+	return func() ([]DocumentInfo, string, string, bool, error) {
+		cursorDisplay := cursor
+		if len(cursorDisplay) > 8 {
+			cursorDisplay = cursorDisplay[:8]
+		}
+
+		documents, nextCursor, hasMore, err := getDocumentsBatch(cfg, cursor, batchSize)
+		if err != nil {
+			return nil, "", "", false, err
+		}
+		cursor = nextCursor
+		return documents, "cursor: " + cursorDisplay, nextCursor, hasMore, nil
+	}
+}
+
+// sortedBatches lists every document up front and serves them in path order,
+// so SKIP counts refer to the same documents on every run. The full listing
+// is held in memory for the whole run, a few hundred bytes per document.
+// Documents at or before the after path are left out, for resumed runs.
+func sortedBatches(cfg *Config, batchSize int, after string) (documentBatches, error) {
+	documents, err := listAllDocumentsSorted(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("🔤 Listed and sorted %d documents\n", len(documents))
+
+	offset := 0
+	if after != "" {
+		offset = sort.Search(len(documents), func(i int) bool { return documents[i].Path > after })
+		fmt.Printf("🔤 Resuming after %s (%d documents already handled)\n", after, offset)
+	}
+	return func() ([]DocumentInfo, string, string, bool, error) {
+		end := min(offset+batchSize, len(documents))
+		batch := documents[offset:end]
+		position := fmt.Sprintf("offset: %d", offset)
+		offset = end
+		next := after
+		if len(batch) > 0 {
+			next = batch[len(batch)-1].Path
+		}
+		return batch, position, next, offset < len(documents), nil
+	}, nil
+}
+
+// listAllDocumentsSorted pages through the whole storage listing and sorts
+// the documents by path
+func listAllDocumentsSorted(cfg *Config) ([]DocumentInfo, error) {
+	var documents []DocumentInfo
+	cursor := ""
+	for {
+		page, nextCursor, hasMore, err := getDocumentsBatch(cfg, cursor, listingPageSize)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, page...)
+		if !hasMore || len(page) == 0 {
+			break
+		}
+		cursor = nextCursor
+	}
+
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Path < documents[j].Path
+	})
+	return documents, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shuffledPaths returns n document paths in an order set by seed, standing in
+// for a storage listing whose order changes between runs
+func shuffledPaths(n int, seed int64) []string {
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("cases/%04d.pdf", i)
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(n, func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+	return paths
+}
+
+func TestProcessAllDocumentsSequentially_SortedOrderIsDeterministic(t *testing.T) {
+	const skip = 30
+	var runs [][]string
+	for _, seed := range []int64{1, 2} {
+		paths := shuffledPaths(120, seed)
+		api, cfg := newClassifierAPI(t, paths)
+		stats := &ClassificationStats{TotalDocuments: int64(len(paths))}
+
+		processAllDocumentsSequentially(cfg, stats, skip, nil, true, nil)
+		runs = append(runs, api.classified())
+	}
+
+	require.NotEqual(t, shuffledPaths(120, 1), shuffledPaths(120, 2), "the listings must differ")
+	assert.Equal(t, runs[0], runs[1], "SKIP leaves out the same documents on every run")
+
+	expected := shuffledPaths(120, 1)
+	sort.Strings(expected)
+	assert.Equal(t, expected[skip:], runs[0])
+}
+
+func TestListAllDocumentsSorted_PagesThroughListing(t *testing.T) {
+	paths := shuffledPaths(listingPageSize*2+100, 3)
+	_, cfg := newClassifierAPI(t, paths)
+
+	documents, err := listAllDocumentsSorted(cfg)
+	require.NoError(t, err)
+	require.Len(t, documents, len(paths))
+	assert.True(t, sort.SliceIsSorted(documents, func(i, j int) bool { return documents[i].Path < documents[j].Path }))
+}
+
+func TestSortedBatches_ResumesAfterPath(t *testing.T) {
+	_, cfg := newClassifierAPI(t, shuffledPaths(10, 4))
+
+	next, err := sortedBatches(cfg, 4, "cases/0005.pdf")
+	require.NoError(t, err)
+
+	batch, _, cursor, hasMore, err := next()
+	require.NoError(t, err)
+	require.Len(t, batch, 4)
+	assert.Equal(t, "cases/0006.pdf", batch[0].Path)
+	assert.Equal(t, "cases/0009.pdf", cursor)
+	assert.False(t, hasMore)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// newHTTPClient builds the one client used for the whole run. Documents are
+// listed, downloaded and classified against the same host, so keeping the
+// connection alive between them saves a TCP and TLS handshake per call.
+// RequestTimeout applies to each request separately.
+func newHTTPClient(cfg *Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.KeepAlivePeriod,
+	}).DialContext
+	transport.DisableKeepAlives = !cfg.KeepAlive
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+
+	return &http.Client{
+		Timeout:   cfg.RequestTimeout,
+		Transport: transport,
+	}
+}
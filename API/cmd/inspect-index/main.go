@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"strings"
@@ -12,11 +13,20 @@ import (
 	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
 
 	"motion-index-fiber/internal/config"
+	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/search/client"
 	"motion-index-fiber/pkg/models"
 )
 
 func main() {
+	showStats := flag.Bool("stats", false, "report the index document count and store size instead of inspecting the mapping")
+	showHealth := flag.Bool("health", false, "report the index shard health instead of inspecting the mapping")
+	asJSON := flag.Bool("json", false, "print the --stats and --health reports as JSON")
+	flag.Parse()
+	if *asJSON && !*showStats && !*showHealth {
+		log.Fatalf("--json requires --stats or --health")
+	}
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found: %v", err)
@@ -28,9 +38,6 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	fmt.Println("🔍 OpenSearch Index Inspection")
-	fmt.Println("==============================")
-
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
@@ -40,6 +47,16 @@ func main() {
 		log.Fatalf("❌ Failed to create OpenSearch client: %v", err)
 	}
 
+	if *showStats || *showHealth {
+		if err := reportIndex(ctx, osClient, *showStats, *showHealth, *asJSON); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	fmt.Println("🔍 OpenSearch Index Inspection")
+	fmt.Println("==============================")
+
 	fmt.Printf("📋 Inspecting index: %s\n", cfg.OpenSearch.Index)
 
 	// Get index mapping
@@ -107,4 +124,65 @@ func main() {
 	expectedDoc := models.GetDocumentMapping()
 	expectedJSON, _ := json.MarshalIndent(expectedDoc, "", "  ")
 	fmt.Printf("%s\n", expectedJSON)
-}
\ No newline at end of file
+}
+
+// indexReport is the output of --stats and --health
+type indexReport struct {
+	Stats  *search.IndexStats  `json:"stats,omitempty"`
+	Health *search.IndexHealth `json:"health,omitempty"`
+}
+
+// reportIndex prints the requested stats and health of the configured index,
+// so operators can confirm it is green and populated before a batch run
+func reportIndex(ctx context.Context, osClient *client.Client, withStats, withHealth, asJSON bool) error {
+	var report indexReport
+	if withStats {
+		stats, err := search.GetIndexStats(ctx, osClient)
+		if err != nil {
+			return fmt.Errorf("failed to get index stats: %w", err)
+		}
+		report.Stats = stats
+	}
+	if withHealth {
+		health, err := search.GetIndexHealth(ctx, osClient)
+		if err != nil {
+			return fmt.Errorf("failed to get index health: %w", err)
+		}
+		report.Health = health
+	}
+
+	if asJSON {
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		fmt.Printf("%s\n", reportJSON)
+		return nil
+	}
+
+	fmt.Printf("📋 Index: %s\n", osClient.GetIndex())
+	if stats := report.Stats; stats != nil {
+		fmt.Println("\n📊 Stats:")
+		fmt.Printf("   Documents:     %d\n", stats.DocumentCount)
+		fmt.Printf("   Deleted:       %d\n", stats.DeletedCount)
+		fmt.Printf("   Primary size:  %s\n", search.FormatBytes(stats.PrimarySizeBytes))
+		fmt.Printf("   Total size:    %s (with replicas)\n", search.FormatBytes(stats.TotalSizeBytes))
+		if stats.DocumentCount == 0 {
+			fmt.Println("   ⚠️  The index is empty")
+		}
+	}
+	if health := report.Health; health != nil {
+		icon := map[string]string{"green": "🟢", "yellow": "🟡", "red": "🔴"}[health.Status]
+		if icon == "" {
+			icon = "⚪"
+		}
+		fmt.Println("\n🩺 Health:")
+		fmt.Printf("   Status:        %s %s\n", icon, health.Status)
+		fmt.Printf("   Shards:        %d primary, %d replica(s) each\n", health.NumberOfShards, health.NumberOfReplicas)
+		fmt.Printf("   Active:        %d (%d primary)\n", health.ActiveShards, health.ActivePrimaryShards)
+		fmt.Printf("   Relocating:    %d\n", health.RelocatingShards)
+		fmt.Printf("   Initializing:  %d\n", health.InitializingShards)
+		fmt.Printf("   Unassigned:    %d\n", health.UnassignedShards)
+	}
+	return nil
+}
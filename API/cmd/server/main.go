@@ -92,45 +92,47 @@ func main() {
 	app.Get("/", h.Health.Root)
 	app.Get("/health", h.Health.Health)
 
+	// Routes that change documents or grant access to stored files require a
+	// JWT unless AUTH_ENABLED=false; deleting documents and redacting them
+	// also require a role
+	requireAuth := middleware.JWTWithRoleClaim(cfg.Auth.JWTSecret, cfg.Auth.RoleClaim)
+	requireRole := middleware.RequireRole
+	if !cfg.Auth.Enabled {
+		log.Printf("WARNING: AUTH_ENABLED=false, routes that change documents are not authenticated")
+		requireAuth = func(c *fiber.Ctx) error { return c.Next() }
+		requireRole = func(roles ...string) fiber.Handler { return requireAuth }
+	}
+
 	// API routes
 	api := app.Group("/api/v1")
 
 	// Public routes
-	api.Post("/categorise", h.Processing.UploadDocument)
-	api.Post("/analyze-redactions", h.Processing.AnalyzeRedactions)
-	api.Post("/redact-document", h.Processing.RedactDocument)
 	api.Post("/search", h.Search.SearchDocuments)
+	api.Get("/pipeline/status", h.Processing.GetPipelineStatus)
 	api.Get("/legal-tags", h.Search.GetLegalTags)
 	api.Get("/document-types", h.Search.GetDocumentTypes)
+	api.Get("/document-types/:type/schema", h.Search.GetDocumentTypeSchema)
 	api.Get("/document-stats", h.Search.GetDocumentStats)
 	api.Get("/field-options", h.Search.GetFieldOptions)
 	api.Get("/all-field-options", h.Search.GetFieldOptions)  // Alias for comprehensive field options
+	api.Post("/field-options", h.Search.GetFieldOptions)      // Facet counts narrowed by a search request body
+	api.Post("/all-field-options", h.Search.GetFieldOptions)
 	api.Get("/metadata-fields", h.Search.GetMetadataFields)
 	api.Get("/metadata-fields/:field", h.Search.GetMetadataFieldValues)
 	api.Post("/metadata-field-values", h.Search.PostMetadataFieldValues)
 	api.Get("/documents/:id/redactions", h.Search.GetDocumentRedactions)
+	api.Get("/documents/:id/classification", h.Search.GetDocumentClassification)
+	api.Get("/documents/:id/text", h.Search.GetDocumentText)
 	api.Get("/documents/:id", h.Search.GetDocument)
+	api.Get("/cases/:case_number/stats", h.Search.GetCaseStats)
 
 	// File serving routes (separate from document metadata routes)
 	api.Get("/files/search", h.Storage.FindDocumentsByName)
-	
-	// Add middleware for file serving to allow embedding
-	api.Get("/files/*", func(c *fiber.Ctx) error {
-		// Remove all restrictions for embedding - TEMPORARY for development
-		// TODO: Add proper security controls for production
-		
-		// Allow framing from any origin
-		c.Set("X-Frame-Options", "")
-		c.Response().Header.Del("X-Frame-Options")
-		
-		// Remove all restrictive CORS policies
-		c.Response().Header.Del("Cross-Origin-Embedder-Policy")
-		c.Response().Header.Del("Cross-Origin-Resource-Policy")
-		c.Response().Header.Del("Cross-Origin-Opener-Policy")
-		
-		// Continue to the actual file serving handler
-		return h.Storage.ServeDocument(c)
-	})
+	api.Post("/files/embed-token", requireAuth, h.Storage.IssueEmbedToken)
+	api.Post("/files/signed-urls", requireAuth, h.Storage.GenerateSignedURLs)
+
+	// Files can be framed only by the origin named in their embed_token
+	api.Get("/files/*", h.Storage.ServeFile)
 
 	// Storage routes for document management
 	storage := api.Group("/storage")
@@ -139,25 +141,31 @@ func main() {
 
 	// Batch processing routes
 	batch := api.Group("/batch")
-	batch.Post("/classify", h.Batch.StartBatchClassification)
+	batch.Post("/classify", requireAuth, h.Batch.StartBatchClassification)
+	batch.Post("/classify-upload", requireAuth, h.Batch.StartBatchClassificationUpload)
 	batch.Get("/:job_id/status", h.Batch.GetBatchJobStatus)
 	batch.Get("/:job_id/results", h.Batch.GetBatchJobResults)
-	batch.Delete("/:job_id", h.Batch.CancelBatchJob)
+	batch.Get("/:job_id/events", h.Batch.StreamBatchJobEvents)
+	batch.Delete("/:job_id", requireAuth, h.Batch.CancelBatchJob)
+
+	// Admin routes (require the admin role); reindex jobs report through the batch endpoints
+	admin := api.Group("/admin", requireAuth, requireRole("admin"))
+	admin.Post("/reindex", h.Batch.StartReindex)
+	admin.Get("/metrics", h.Health.Metrics)
 
 	// Indexing routes
 	index := api.Group("/index")
-	index.Post("/document", h.Indexing.IndexDocument)
-	
-	// TODO: Re-enable authentication for these routes in production
-	// Currently disabled for early development - these should be protected
-	api.Post("/update-metadata", h.Processing.UpdateMetadata)
-	api.Delete("/documents/:id", h.Search.DeleteDocument)
-
-	// COMMENTED OUT: Protected routes (require authentication)
-	// TODO: Uncomment and configure JWT authentication before production deployment
-	// protected := api.Group("", middleware.JWT(cfg.Auth.JWTSecret))
-	// protected.Post("/update-metadata", h.Processing.UpdateMetadata)
-	// protected.Delete("/documents/:id", h.Search.DeleteDocument)
+	index.Post("/document", requireAuth, h.Indexing.IndexDocument)
+	index.Post("/mapping/validate", requireAuth, h.Indexing.ValidateMapping)
+
+	// Protected routes (require authentication)
+	api.Post("/categorise", requireAuth, h.Processing.UploadDocument)
+	api.Post("/analyze-redactions", requireAuth, h.Processing.AnalyzeRedactions)
+	api.Post("/update-metadata", requireAuth, h.Processing.UpdateMetadata)
+	api.Post("/documents/bulk-update-metadata", requireAuth, h.Processing.BulkUpdateMetadata)
+	api.Delete("/documents", requireAuth, requireRole("admin"), h.Search.DeleteDocuments)
+	api.Delete("/documents/:id", requireAuth, requireRole("admin"), h.Search.DeleteDocument)
+	api.Post("/redact-document", requireAuth, requireRole("reviewer", "admin"), h.Processing.RedactDocument)
 
 	// Start server
 	port := fmt.Sprintf(":%s", cfg.Server.Port)
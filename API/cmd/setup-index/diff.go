@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"motion-index-fiber/pkg/search/client"
+)
+
+// Kinds of difference between the live and expected mappings
+const (
+	fieldAdded       = "added"
+	fieldRemoved     = "removed"
+	fieldTypeChanged = "type_changed"
+)
+
+// fieldChange is one difference between the live index mapping and the
+// mapping setup-index would create
+type fieldChange struct {
+	Field    string
+	Kind     string
+	LiveType string
+	NewType  string
+}
+
+// fetchLiveMapping returns the properties of the index's current mapping, the
+// same response inspect-index prints
+func fetchLiveMapping(ctx context.Context, osClient *client.Client, indexName string) (map[string]interface{}, error) {
+	req := opensearchapi.IndicesGetMappingRequest{
+		Index: []string{indexName},
+	}
+
+	res, err := req.Do(ctx, osClient.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("get mapping request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get mapping failed with status: %s", res.Status())
+	}
+
+	var response map[string]struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode mapping response: %w", err)
+	}
+
+	// The response is keyed by the concrete index name, which differs from
+	// the configured name when that is an alias
+	for _, index := range response {
+		return index.Mappings.Properties, nil
+	}
+	return nil, fmt.Errorf("mapping response for %s is empty", indexName)
+}
+
+// diffMappings compares two sets of mapping properties field by field,
+// including nested objects and multi-fields such as "doc_type.keyword"
+func diffMappings(live, expected map[string]interface{}) []fieldChange {
+	liveFields := flattenProperties("", live, map[string]string{})
+	expectedFields := flattenProperties("", expected, map[string]string{})
+
+	var changes []fieldChange
+	for field, newType := range expectedFields {
+		liveType, exists := liveFields[field]
+		switch {
+		case !exists:
+			changes = append(changes, fieldChange{Field: field, Kind: fieldAdded, NewType: newType})
+		case liveType != newType:
+			changes = append(changes, fieldChange{Field: field, Kind: fieldTypeChanged, LiveType: liveType, NewType: newType})
+		}
+	}
+	for field, liveType := range liveFields {
+		if _, exists := expectedFields[field]; !exists {
+			changes = append(changes, fieldChange{Field: field, Kind: fieldRemoved, LiveType: liveType})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// flattenProperties maps each field's dotted path to its type. Fields with
+// sub-properties and no explicit type are objects.
+func flattenProperties(prefix string, properties map[string]interface{}, fields map[string]string) map[string]string {
+	for name, value := range properties {
+		definition, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := prefix + name
+
+		fieldType, _ := definition["type"].(string)
+		if fieldType == "" {
+			fieldType = "object"
+		}
+		fields[path] = fieldType
+
+		if sub, ok := definition["properties"].(map[string]interface{}); ok {
+			flattenProperties(path+".", sub, fields)
+		}
+		if sub, ok := definition["fields"].(map[string]interface{}); ok {
+			flattenProperties(path+".", sub, fields)
+		}
+	}
+	return fields
+}
+
+// printMappingDiff prints the changes with + for fields the new mapping adds,
+// - for fields it drops and ~ for fields whose type changes
+func printMappingDiff(changes []fieldChange) {
+	if len(changes) == 0 {
+		fmt.Println("✅ Live mapping matches the expected mapping; no reindex needed")
+		return
+	}
+
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+		switch change.Kind {
+		case fieldAdded:
+			fmt.Printf("  + %s (%s)\n", change.Field, change.NewType)
+		case fieldRemoved:
+			fmt.Printf("  - %s (%s)\n", change.Field, change.LiveType)
+		case fieldTypeChanged:
+			fmt.Printf("  ~ %s (%s → %s)\n", change.Field, change.LiveType, change.NewType)
+		}
+	}
+
+	fmt.Printf("📊 %d added, %d removed, %d type changed\n",
+		counts[fieldAdded], counts[fieldRemoved], counts[fieldTypeChanged])
+	// OpenSearch accepts new fields on a live mapping and ignores extra ones,
+	// but cannot change the type of an existing field in place
+	if counts[fieldTypeChanged] > 0 {
+		fmt.Println("   Type changes need the index to be recreated and reindexed")
+	} else {
+		fmt.Println("   No reindex needed: added fields can be put on the live mapping")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"time"
@@ -11,9 +12,14 @@ import (
 	"motion-index-fiber/internal/config"
 	"motion-index-fiber/pkg/cloud/digitalocean"
 	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/search/client"
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "print the difference between the live and expected mappings without changing the index")
+	noDelete := flag.Bool("no-delete", false, "fail instead of deleting an existing index")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found: %v", err)
@@ -54,6 +60,20 @@ func main() {
 	
 	fmt.Printf("📋 Index name: %s\n", cfg.OpenSearch.Index)
 
+	if *dryRun || *noDelete {
+		exists, err := checkMapping(ctx, cfg, *dryRun)
+		if err != nil {
+			log.Fatalf("❌ Failed to compare index mapping: %v", err)
+		}
+		if *dryRun {
+			fmt.Println("🔍 Dry run: no changes made")
+			return
+		}
+		if exists {
+			log.Fatalf("❌ Index '%s' already exists; refusing to delete it (--no-delete)", cfg.OpenSearch.Index)
+		}
+	}
+
 	// Create the index with proper mapping
 	fmt.Println("🔧 Creating index with legal document mapping...")
 	
@@ -69,6 +89,37 @@ func main() {
 	fmt.Println("   - If successful, run: go run cmd/real-batch-processor/main.go process-real")
 }
 
+// checkMapping reports whether the index exists and, if it does, prints how
+// its live mapping differs from the one setup would create
+func checkMapping(ctx context.Context, cfg *config.Config, dryRun bool) (bool, error) {
+	osClient, err := client.NewClient(&cfg.OpenSearch)
+	if err != nil {
+		return false, fmt.Errorf("failed to create OpenSearch client: %w", err)
+	}
+
+	exists, err := osClient.IndexExists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if index exists: %w", err)
+	}
+
+	expected := models.GetDocumentMapping()["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+	if !exists {
+		if dryRun {
+			fmt.Printf("📋 Index '%s' does not exist; it would be created with %d top-level fields\n", cfg.OpenSearch.Index, len(expected))
+		}
+		return false, nil
+	}
+
+	live, err := fetchLiveMapping(ctx, osClient, cfg.OpenSearch.Index)
+	if err != nil {
+		return true, err
+	}
+
+	fmt.Printf("📊 Mapping changes for '%s' (live → expected):\n", cfg.OpenSearch.Index)
+	printMappingDiff(diffMappings(live, expected))
+	return true, nil
+}
+
 // setupDocumentIndex creates the index with the proper legal document mapping
 func setupDocumentIndex(ctx context.Context, searchService interface{}, indexName string) error {
 	// Type assert to get the search service with index management methods
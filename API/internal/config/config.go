@@ -5,9 +5,11 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	doConfig "motion-index-fiber/pkg/cloud/digitalocean/config"
+	"motion-index-fiber/pkg/models"
 )
 
 type Config struct {
@@ -17,6 +19,7 @@ type Config struct {
 	Auth         AuthConfig
 	Processing   ProcessingConfig
 	OpenSearch   OpenSearchConfig
+	Search       SearchConfig
 	OpenAI       OpenAIConfig // Keep for backward compatibility
 	AI           AIConfig     // New comprehensive AI config
 	Logging      LoggingConfig
@@ -29,6 +32,12 @@ type ServerConfig struct {
 	Production     bool
 	AllowedOrigins string
 	MaxRequestSize int64
+
+	// EmbedOrigins lists the origins that may frame files from /files/*, comma
+	// separated. Each embed needs a token issued for one file and one origin,
+	// valid for EmbedTokenTTL.
+	EmbedOrigins  string
+	EmbedTokenTTL time.Duration
 }
 
 type DatabaseConfig struct {
@@ -47,6 +56,22 @@ type StorageConfig struct {
 	Bucket    string
 	Region    string
 	CDNDomain string
+
+	// OnConflict is the default action when an upload path already exists:
+	// "overwrite", "skip" or "version". Requests may override it.
+	OnConflict string
+
+	// MinFileSize is the size in bytes below which storage listings and
+	// counts skip files as empty or corrupt. Zero lists every file.
+	MinFileSize int64
+
+	// IndexFileURLs stores the storage URL and CDN URL of each file with its
+	// indexed document, so search results can link to files directly
+	IndexFileURLs bool
+
+	// MaxConcurrentDownloads caps the downloads open at once; more wait for
+	// a slot. Zero leaves downloads unbounded.
+	MaxConcurrentDownloads int
 }
 
 type AuthConfig struct {
@@ -54,6 +79,14 @@ type AuthConfig struct {
 	SupabaseURL     string
 	SupabaseAnonKey string
 	SupabaseAPIKey  string
+
+	// Enabled requires a valid JWT on routes that change documents. It
+	// defaults to true everywhere but the local environment.
+	Enabled bool
+
+	// RoleClaim is the JWT claim, or dotted path to a nested claim, that
+	// holds the user's role for routes restricted to certain roles
+	RoleClaim string
 }
 
 type ProcessingConfig struct {
@@ -61,6 +94,114 @@ type ProcessingConfig struct {
 	MaxWorkers     int
 	BatchSize      int
 	ProcessTimeout time.Duration
+
+	// RetryBackoff is the delay before the first retry of a document whose
+	// pipeline run failed with a transient error; it doubles on each further
+	// retry. The number of retries comes from the request's retry_count.
+	RetryBackoff time.Duration
+
+	// IndexFlushThreshold is the number of classified documents a batch job may
+	// hold before they are bulk indexed mid-job. Zero disables incremental flushes.
+	IndexFlushThreshold int
+
+	// PendingSpillThreshold is the number of classified documents a batch job
+	// keeps in memory while they wait to be indexed; further documents are
+	// written to a temporary file and read back for indexing. Zero keeps them
+	// all in memory.
+	PendingSpillThreshold int
+
+	// BatchConcurrency bounds how many files a synchronous batch upload runs
+	// through the pipeline at once
+	BatchConcurrency int
+
+	// MaxConcurrentBatchJobs caps how many async batch classification jobs run
+	// at once; further jobs wait in a queue. Zero means no limit.
+	MaxConcurrentBatchJobs int
+
+	// MinExtractionQuality is the extraction quality score (0-1) below which
+	// documents are flagged as low quality for review. Zero disables flagging.
+	MinExtractionQuality float64
+
+	// MinIndexableTextLength is the minimum extracted text length, in
+	// characters, for a document to be indexed. Shorter documents are still
+	// stored but skipped from the index. Zero indexes everything.
+	MinIndexableTextLength int
+
+	// Default processing steps for /categorise and batch uploads when the
+	// extract_text, classify_doc, index_document or store_document form
+	// fields are omitted
+	DefaultExtractText   bool
+	DefaultClassifyDoc   bool
+	DefaultIndexDocument bool
+	DefaultStoreDocument bool
+
+	// Redaction analysis limits. RedactionTimeout bounds a whole request;
+	// documents over RedactionMaxPages or RedactionMaxFileSize are rejected.
+	// Zero disables the page and size limits.
+	RedactionTimeout     time.Duration
+	RedactionMaxPages    int
+	RedactionMaxFileSize int64
+
+	// FieldMapping overrides how classifier output maps to index fields, as
+	// comma-separated source=target entries (see pipeline.ParseFieldMapping).
+	// It is validated when the pipeline is built.
+	FieldMapping string
+
+	// Profiles configures per-document-type processing profiles (see
+	// pipeline.ParseProfiles). Empty processes every document alike.
+	Profiles string
+
+	// RequiredMetadata lists the metadata each document type needs (see
+	// pipeline.ParseRequiredFields); documents missing it are flagged for review
+	RequiredMetadata string
+
+	// AllowedContentTypes lists the content types the pipeline accepts, as
+	// "type/subtype,type/subtype" (see pipeline.ParseContentTypes). Empty
+	// accepts pipeline.DefaultAllowedContentTypes.
+	AllowedContentTypes string
+
+	// MetadataSchemas lists the metadata fields each document type's forms
+	// show (see pipeline.ParseMetadataSchemas); unlisted types use defaults
+	MetadataSchemas string
+
+	// ConfidenceThresholds sets the classifier confidence each document type
+	// must exceed (see pipeline.ParseConfidenceThresholds); documents at or
+	// below it are flagged for review
+	ConfidenceThresholds string
+
+	// DeferBlockedIndexing queues uploaded documents for later indexing when
+	// the search index is write-blocked, e.g. read-only after a disk
+	// watermark, instead of failing the upload. The queue holds up to
+	// DeferredIndexQueueSize documents and retries every DeferredIndexRetryInterval.
+	DeferBlockedIndexing       bool
+	DeferredIndexRetryInterval time.Duration
+	DeferredIndexQueueSize     int
+
+	// RedactionAuditLog appends an audit record, with the items, legal codes,
+	// time and operator, to a document each time redactions are applied to it
+	RedactionAuditLog bool
+
+	// SkipDuplicates skips indexing uploads whose extracted text matches an
+	// already indexed document; the upload reports that document's ID
+	SkipDuplicates bool
+
+	// EnableOCR reads image-only PDFs by OCR when their text layer has fewer
+	// than OCRMinTextLength characters (see extractor.ExtractOptions)
+	EnableOCR        bool
+	OCRMinTextLength int
+	OCRLanguage      string
+	OCRMaxPages      int
+
+	// ExtractorFallbacks lists the extractors tried in turn when a format's
+	// own extractor fails or finds fewer than FallbackMinTextLength
+	// characters (see extractor.ParseFallbackChains)
+	ExtractorFallbacks    string
+	FallbackMinTextLength int
+
+	// UploadSpoolThreshold is the upload size, in bytes, above which a file
+	// is copied to a temporary file for processing instead of being read into
+	// memory. Zero keeps every upload in memory.
+	UploadSpoolThreshold int64
 }
 
 type OpenSearchConfig struct {
@@ -70,6 +211,44 @@ type OpenSearchConfig struct {
 	Password string
 	UseSSL   bool
 	Index    string
+
+	// ReadHost and ReadPort name a separate endpoint, such as a read
+	// replica, for searches and aggregations; indexing and other writes stay
+	// on Host. Empty sends everything to Host; a zero ReadPort uses Port.
+	ReadHost string
+	ReadPort int
+}
+
+// SearchConfig controls search API behaviour
+type SearchConfig struct {
+	// DebugEnabled allows clients to request scoring explanations and other
+	// diagnostic output. Leave off in production; responses grow large.
+	DebugEnabled bool
+
+	// DefaultSort orders searches with no query and no sort_by, as
+	// "field:order,field:order". Empty uses models.DefaultBrowseSort.
+	DefaultSort string
+
+	// HighlightFragmentSize and HighlightMaxFragments cap the length in
+	// characters and the number of highlight fragments returned per field
+	HighlightFragmentSize int
+	HighlightMaxFragments int
+
+	// ScrubHighlights masks text matching the redaction patterns, such as
+	// Social Security numbers, in highlight fragments before they are returned
+	ScrubHighlights bool
+
+	// EffectiveDatedFields lists the metadata fields, as "field,field", whose
+	// past values are kept with effective dates when they are updated, so
+	// searches can filter on them as of a date. Empty keeps no history.
+	EffectiveDatedFields string
+
+	// BulkMaxAttempts is how many times bulk indexing sends a document that
+	// OpenSearch rejects with 429 or 503, including the first; 1 disables
+	// retries. BulkRetryBackoff is the delay before the first retry, doubled
+	// for each further retry (see search.BulkRetryPolicy).
+	BulkMaxAttempts  int
+	BulkRetryBackoff time.Duration
 }
 
 type OpenAIConfig struct {
@@ -91,6 +270,29 @@ type AIConfig struct {
 	EnableFallback bool
 	RetryAttempts  int
 	RetryDelay     time.Duration
+
+	// Per-call retry budget and circuit breaker for hosted providers
+	RequestRetries   int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// Retries of a classification OpenAI rate-limits, and the longest wait
+	// before one, whatever its Retry-After header asks
+	RateLimitRetries int
+	MaxRateLimitWait time.Duration
+
+	// DegradeWhenUnavailable lets batch jobs classify documents from their
+	// file names when the classifier is unreachable, instead of failing them.
+	// Such documents are indexed as not AI-classified for later reclassification.
+	DegradeWhenUnavailable bool
+
+	// Few-shot examples for the classification prompt (JSON array); empty disables them
+	ExamplesFile string
+	MaxExamples  int
+
+	// Token prices in USD per 1,000 tokens for cost estimates; zero leaves cost unreported
+	PromptPricePer1K     float64
+	CompletionPricePer1K float64
 }
 
 type ClaudeConfig struct {
@@ -134,6 +336,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	opensearchReadPort, err := parseEnvInt("OPENSEARCH_READ_PORT", opensearchPort)
+	if err != nil {
+		return nil, err
+	}
+
 	maxRequestSize, err := parseEnvInt64("MAX_REQUEST_SIZE", 100*1024*1024)
 	if err != nil {
 		return nil, err
@@ -144,6 +351,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	storageMinFileSize, err := parseEnvInt64("STORAGE_MIN_FILE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+
 	maxWorkers, err := parseEnvInt("MAX_WORKERS", 10)
 	if err != nil {
 		return nil, err
@@ -159,6 +371,106 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	retryBackoff, err := parseEnvDuration("PROCESS_RETRY_BACKOFF", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	indexFlushThreshold, err := parseEnvInt("BATCH_INDEX_FLUSH_THRESHOLD", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingSpillThreshold, err := parseEnvInt("BATCH_PENDING_SPILL_THRESHOLD", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	batchConcurrency, err := parseEnvInt("BATCH_CONCURRENCY", 4)
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrentBatchJobs, err := parseEnvInt("MAX_CONCURRENT_BATCH_JOBS", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	minExtractionQuality, err := parseEnvFloat("MIN_EXTRACTION_QUALITY", 0.5)
+	if err != nil {
+		return nil, err
+	}
+
+	minIndexableTextLength, err := parseEnvInt("MIN_INDEXABLE_TEXT_LENGTH", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	bulkMaxAttempts, err := parseEnvInt("OPENSEARCH_BULK_MAX_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	bulkRetryBackoff, err := parseEnvDuration("OPENSEARCH_BULK_RETRY_BACKOFF", 500*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	deferredIndexRetryInterval, err := parseEnvDuration("DEFERRED_INDEX_RETRY_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	deferredIndexQueueSize, err := parseEnvInt("DEFERRED_INDEX_QUEUE_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	ocrMinTextLength, err := parseEnvInt("OCR_MIN_TEXT_LENGTH", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	ocrMaxPages, err := parseEnvInt("OCR_MAX_PAGES", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbackMinTextLength, err := parseEnvInt("EXTRACTOR_FALLBACK_MIN_TEXT_LENGTH", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadSpoolThreshold, err := parseEnvInt64("UPLOAD_SPOOL_THRESHOLD", 10*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+
+	redactionTimeout, err := parseEnvDuration("REDACTION_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	redactionMaxPages, err := parseEnvInt("REDACTION_MAX_PAGES", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	redactionMaxFileSize, err := parseEnvInt64("REDACTION_MAX_FILE_SIZE", 50*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+
+	promptPricePer1K, err := parseEnvFloat("AI_PROMPT_PRICE_PER_1K", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	completionPricePer1K, err := parseEnvFloat("AI_COMPLETION_PRICE_PER_1K", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Environment: environment,
 		Server: ServerConfig{
@@ -166,6 +478,9 @@ func Load() (*Config, error) {
 			Production:     environment == "production" || environment == "staging" || getEnvBool("PRODUCTION", false),
 			AllowedOrigins: getEnv("ALLOWED_ORIGINS", defaultOrigins),
 			MaxRequestSize: maxRequestSize,
+
+			EmbedOrigins:  getEnv("EMBED_ALLOWED_ORIGINS", getEnv("ALLOWED_ORIGINS", defaultOrigins)),
+			EmbedTokenTTL: getEnvDuration("EMBED_TOKEN_TTL", 15*time.Minute),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -182,18 +497,71 @@ func Load() (*Config, error) {
 			Bucket:    getEnv("STORAGE_BUCKET", getEnv("DO_SPACES_BUCKET", "motion-index-docs")),
 			Region:    getEnv("STORAGE_REGION", getEnv("DO_SPACES_REGION", "nyc3")),
 			CDNDomain: getEnv("STORAGE_CDN_DOMAIN", getEnv("DO_SPACES_CDN_DOMAIN", "")),
+
+			OnConflict:  getEnv("STORAGE_ON_CONFLICT", "version"),
+			MinFileSize: storageMinFileSize,
+
+			IndexFileURLs: getEnvBool("STORAGE_INDEX_FILE_URLS", true),
 		},
 		Auth: AuthConfig{
 			JWTSecret:       getEnv("JWT_SECRET", ""),
 			SupabaseURL:     getEnv("SUPABASE_URL", ""),
 			SupabaseAnonKey: getEnv("SUPABASE_ANON_KEY", ""),
 			SupabaseAPIKey:  getEnv("SUPABASE_SERVICE_KEY", ""),
+
+			Enabled:   getEnvBool("AUTH_ENABLED", environment != "local"),
+			RoleClaim: getEnv("JWT_ROLE_CLAIM", "role"),
 		},
 		Processing: ProcessingConfig{
 			MaxFileSize:    maxFileSize,
 			MaxWorkers:     maxWorkers,
 			BatchSize:      batchSize,
 			ProcessTimeout: processTimeout,
+			RetryBackoff:   retryBackoff,
+
+			IndexFlushThreshold: indexFlushThreshold,
+			BatchConcurrency:    batchConcurrency,
+
+			PendingSpillThreshold: pendingSpillThreshold,
+
+			MaxConcurrentBatchJobs: maxConcurrentBatchJobs,
+
+			MinExtractionQuality:   minExtractionQuality,
+			MinIndexableTextLength: minIndexableTextLength,
+
+			DefaultExtractText:   getEnvBool("PROCESS_DEFAULT_EXTRACT_TEXT", true),
+			DefaultClassifyDoc:   getEnvBool("PROCESS_DEFAULT_CLASSIFY_DOC", true),
+			DefaultIndexDocument: getEnvBool("PROCESS_DEFAULT_INDEX_DOCUMENT", true),
+			DefaultStoreDocument: getEnvBool("PROCESS_DEFAULT_STORE_DOCUMENT", true),
+
+			RedactionTimeout:     redactionTimeout,
+			RedactionMaxPages:    redactionMaxPages,
+			RedactionMaxFileSize: redactionMaxFileSize,
+
+			FieldMapping: getEnv("CLASSIFIER_FIELD_MAPPING", ""),
+			Profiles:     getEnv("PROCESSING_PROFILES", ""),
+
+			RequiredMetadata:     getEnv("REQUIRED_METADATA_FIELDS", ""),
+			AllowedContentTypes:  getEnv("ALLOWED_CONTENT_TYPES", ""),
+			MetadataSchemas:      getEnv("METADATA_SCHEMAS", ""),
+			ConfidenceThresholds: getEnv("CLASSIFICATION_CONFIDENCE_THRESHOLDS", ""),
+
+			DeferBlockedIndexing:       getEnvBool("DEFER_BLOCKED_INDEXING", true),
+			DeferredIndexRetryInterval: deferredIndexRetryInterval,
+			DeferredIndexQueueSize:     deferredIndexQueueSize,
+
+			RedactionAuditLog: getEnvBool("REDACTION_AUDIT_LOG", false),
+			SkipDuplicates:    getEnvBool("SKIP_DUPLICATE_DOCUMENTS", false),
+
+			EnableOCR:        getEnvBool("ENABLE_OCR", false),
+			OCRMinTextLength: ocrMinTextLength,
+			OCRLanguage:      getEnv("OCR_LANGUAGE", "eng"),
+			OCRMaxPages:      ocrMaxPages,
+
+			ExtractorFallbacks:    getEnv("EXTRACTOR_FALLBACKS", ""),
+			FallbackMinTextLength: fallbackMinTextLength,
+
+			UploadSpoolThreshold: uploadSpoolThreshold,
 		},
 		OpenSearch: OpenSearchConfig{
 			Host:     getEnv("OPENSEARCH_HOST", getEnv("ES_HOST", "")), // Don't use default to allow validation
@@ -202,6 +570,21 @@ func Load() (*Config, error) {
 			Password: getEnv("OPENSEARCH_PASSWORD", getEnv("ES_PASSWORD", "")),
 			UseSSL:   getEnvBool("OPENSEARCH_USE_SSL", getEnvBool("ES_USE_SSL", environment != "local")),
 			Index:    getEnv("OPENSEARCH_INDEX", getEnv("ES_INDEX", "documents")),
+
+			ReadHost: getEnv("OPENSEARCH_READ_HOST", ""),
+			ReadPort: opensearchReadPort,
+		},
+		Search: SearchConfig{
+			DebugEnabled: getEnvBool("SEARCH_DEBUG", false),
+			DefaultSort:  getEnv("SEARCH_DEFAULT_SORT", ""),
+
+			HighlightFragmentSize: getEnvInt("SEARCH_HIGHLIGHT_FRAGMENT_SIZE", models.DefaultHighlightFragmentSize),
+			HighlightMaxFragments: getEnvInt("SEARCH_HIGHLIGHT_MAX_FRAGMENTS", models.DefaultHighlightFragments),
+			ScrubHighlights:       getEnvBool("SEARCH_SCRUB_HIGHLIGHTS", false),
+			EffectiveDatedFields:  getEnv("METADATA_EFFECTIVE_DATED_FIELDS", models.DefaultEffectiveDatedFields),
+
+			BulkMaxAttempts:  bulkMaxAttempts,
+			BulkRetryBackoff: bulkRetryBackoff,
 		},
 		OpenAI: OpenAIConfig{
 			APIKey: getEnv("OPENAI_API_KEY", ""),
@@ -225,6 +608,20 @@ func Load() (*Config, error) {
 			EnableFallback: getEnvBool("AI_ENABLE_FALLBACK", true),
 			RetryAttempts:  getEnvInt("AI_RETRY_ATTEMPTS", 3),
 			RetryDelay:     getEnvDuration("AI_RETRY_DELAY", 5*time.Second),
+
+			RequestRetries:   getEnvInt("AI_REQUEST_RETRIES", 3),
+			BreakerThreshold: getEnvInt("AI_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getEnvDuration("AI_BREAKER_COOLDOWN", 60*time.Second),
+			RateLimitRetries: getEnvInt("AI_RATE_LIMIT_RETRIES", 3),
+			MaxRateLimitWait: getEnvDuration("AI_MAX_RATE_LIMIT_WAIT", 60*time.Second),
+
+			DegradeWhenUnavailable: getEnvBool("AI_DEGRADE_WHEN_UNAVAILABLE", false),
+
+			ExamplesFile: getEnv("CLASSIFICATION_EXAMPLES_FILE", ""),
+			MaxExamples:  getEnvInt("CLASSIFICATION_MAX_EXAMPLES", 5),
+
+			PromptPricePer1K:     promptPricePer1K,
+			CompletionPricePer1K: completionPricePer1K,
 		},
 		Logging: LoggingConfig{
 			Level:              getEnv("LOG_LEVEL", "info"),
@@ -308,6 +705,16 @@ func (c *Config) validateStorage() error {
 		return fmt.Errorf("STORAGE_BACKEND must be 'local' or 'spaces'")
 	}
 
+	switch c.Storage.OnConflict {
+	case "overwrite", "skip", "version":
+	default:
+		return fmt.Errorf("STORAGE_ON_CONFLICT must be 'overwrite', 'skip' or 'version'")
+	}
+
+	if c.Storage.MinFileSize < 0 {
+		return fmt.Errorf("STORAGE_MIN_FILE_SIZE cannot be negative")
+	}
+
 	// For spaces backend, validate required credentials
 	if c.Storage.Backend == "spaces" {
 		if c.Storage.AccessKey == "" {
@@ -337,6 +744,9 @@ func (c *Config) validateOpenSearch() error {
 	if c.OpenSearch.Port < 1 || c.OpenSearch.Port > 65535 {
 		return fmt.Errorf("OPENSEARCH_PORT must be between 1 and 65535")
 	}
+	if c.OpenSearch.ReadHost != "" && (c.OpenSearch.ReadPort < 1 || c.OpenSearch.ReadPort > 65535) {
+		return fmt.Errorf("OPENSEARCH_READ_PORT must be between 1 and 65535")
+	}
 
 	// For non-local environments, require authentication
 	if c.Environment != "local" {
@@ -348,6 +758,27 @@ func (c *Config) validateOpenSearch() error {
 		}
 	}
 
+	if c.Search.DefaultSort != "" {
+		if _, err := models.ParseSortSpec(c.Search.DefaultSort); err != nil {
+			return fmt.Errorf("SEARCH_DEFAULT_SORT is invalid: %w", err)
+		}
+	}
+	if c.Search.HighlightFragmentSize < 0 {
+		return fmt.Errorf("SEARCH_HIGHLIGHT_FRAGMENT_SIZE must not be negative")
+	}
+	if c.Search.HighlightMaxFragments < 0 {
+		return fmt.Errorf("SEARCH_HIGHLIGHT_MAX_FRAGMENTS must not be negative")
+	}
+	if _, err := models.ParseEffectiveDatedFields(c.Search.EffectiveDatedFields); err != nil {
+		return fmt.Errorf("METADATA_EFFECTIVE_DATED_FIELDS is invalid: %w", err)
+	}
+	if c.Search.BulkMaxAttempts < 1 {
+		return fmt.Errorf("OPENSEARCH_BULK_MAX_ATTEMPTS must be at least 1")
+	}
+	if c.Search.BulkRetryBackoff < 0 {
+		return fmt.Errorf("OPENSEARCH_BULK_RETRY_BACKOFF cannot be negative")
+	}
+
 	return nil
 }
 
@@ -401,6 +832,74 @@ func (c *Config) validateProcessing() error {
 		return fmt.Errorf("PROCESS_TIMEOUT must be positive")
 	}
 
+	if c.Processing.RetryBackoff < 0 {
+		return fmt.Errorf("PROCESS_RETRY_BACKOFF cannot be negative")
+	}
+
+	if c.Processing.DeferBlockedIndexing {
+		if c.Processing.DeferredIndexRetryInterval <= 0 {
+			return fmt.Errorf("DEFERRED_INDEX_RETRY_INTERVAL must be positive")
+		}
+		if c.Processing.DeferredIndexQueueSize <= 0 {
+			return fmt.Errorf("DEFERRED_INDEX_QUEUE_SIZE must be positive")
+		}
+	}
+
+	// Validate index flush threshold (0 disables incremental flushes)
+	if c.Processing.IndexFlushThreshold < 0 {
+		return fmt.Errorf("BATCH_INDEX_FLUSH_THRESHOLD must not be negative")
+	}
+
+	if c.Processing.PendingSpillThreshold < 0 {
+		return fmt.Errorf("BATCH_PENDING_SPILL_THRESHOLD must not be negative")
+	}
+
+	// Validate batch concurrency (0 processes batch files sequentially)
+	if c.Processing.BatchConcurrency < 0 {
+		return fmt.Errorf("BATCH_CONCURRENCY must not be negative")
+	}
+
+	// Validate batch job limit (0 runs every job immediately)
+	if c.Processing.MaxConcurrentBatchJobs < 0 {
+		return fmt.Errorf("MAX_CONCURRENT_BATCH_JOBS must not be negative")
+	}
+
+	// Validate extraction quality threshold
+	if c.Processing.MinExtractionQuality < 0 || c.Processing.MinExtractionQuality > 1 {
+		return fmt.Errorf("MIN_EXTRACTION_QUALITY must be between 0 and 1")
+	}
+
+	if c.Processing.MinIndexableTextLength < 0 {
+		return fmt.Errorf("MIN_INDEXABLE_TEXT_LENGTH cannot be negative")
+	}
+
+	// Zero uses the default text length and OCRs every page
+	if c.Processing.OCRMinTextLength < 0 {
+		return fmt.Errorf("OCR_MIN_TEXT_LENGTH cannot be negative")
+	}
+	if c.Processing.OCRMaxPages < 0 {
+		return fmt.Errorf("OCR_MAX_PAGES cannot be negative")
+	}
+	if c.Processing.FallbackMinTextLength < 0 {
+		return fmt.Errorf("EXTRACTOR_FALLBACK_MIN_TEXT_LENGTH cannot be negative")
+	}
+	if c.Processing.UploadSpoolThreshold < 0 {
+		return fmt.Errorf("UPLOAD_SPOOL_THRESHOLD cannot be negative")
+	}
+
+	if c.Processing.RedactionTimeout <= 0 {
+		return fmt.Errorf("REDACTION_TIMEOUT must be positive")
+	}
+
+	// Zero disables the page and size limits
+	if c.Processing.RedactionMaxPages < 0 {
+		return fmt.Errorf("REDACTION_MAX_PAGES cannot be negative")
+	}
+
+	if c.Processing.RedactionMaxFileSize < 0 {
+		return fmt.Errorf("REDACTION_MAX_FILE_SIZE cannot be negative")
+	}
+
 	return nil
 }
 
@@ -477,6 +976,21 @@ func parseEnvInt64(key string, defaultValue int64) (int64, error) {
 	return intValue, nil
 }
 
+// parseEnvFloat parses an environment variable as a float with error handling
+func parseEnvFloat(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a valid number", key)
+	}
+
+	return floatValue, nil
+}
+
 // parseEnvDuration parses an environment variable as a duration with error handling
 func parseEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
 	value := os.Getenv(key)
@@ -518,6 +1032,11 @@ func (c *Config) GetCDNEndpoint() string {
 	return fmt.Sprintf("https://%s.%s.cdn.digitaloceanspaces.com", c.Storage.Bucket, c.Storage.Region)
 }
 
+// CDNURL returns the CDN URL of the stored file at path
+func (c *Config) CDNURL(path string) string {
+	return c.GetCDNEndpoint() + "/" + strings.TrimPrefix(path, "/")
+}
+
 func (c *Config) GetOpenSearchURL() string {
 	protocol := "http"
 	if c.OpenSearch.UseSSL {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -12,10 +13,12 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
+	"motion-index-fiber/internal/config"
 	internalModels "motion-index-fiber/internal/models"
 	"motion-index-fiber/pkg/processing"
 	"motion-index-fiber/pkg/processing/classifier"
 	"motion-index-fiber/pkg/processing/extractor"
+	"motion-index-fiber/pkg/processing/pipeline"
 	"motion-index-fiber/pkg/processing/queue"
 	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/models"
@@ -27,10 +30,24 @@ type PendingDocument struct {
 	Document         *BatchDocumentInput              `json:"document"`
 	Text             string                          `json:"text"`
 	Classification   *classifier.ClassificationResult `json:"classification"`
+
+	// Title comes from extraction, which sees line breaks the stored text has lost
+	Title string `json:"title,omitempty"`
+
+	// Existing is the indexed document whose metadata a copy-mode reindex keeps
+	Existing *models.Document `json:"-"`
+
+	// SourceSystem is recorded on the indexed document with the job ID as its ingestion batch
+	SourceSystem string `json:"source_system,omitempty"`
+
+	// Degraded documents were classified from their file names while the
+	// classifier was unavailable and are indexed as not AI-classified
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // BatchHandler handles async batch processing operations
 type BatchHandler struct {
+	cfg              *config.Config
 	queueManager     queue.QueueManager
 	storage          storage.Service
 	search           search.Service
@@ -39,7 +56,26 @@ type BatchHandler struct {
 	jobs             map[string]*BatchJob
 	jobsMutex        sync.RWMutex
 	pendingDocs      map[string][]*PendingDocument // jobID -> documents for batch indexing
+	pendingSpills    map[string]*pendingSpill      // jobID -> documents beyond PendingSpillThreshold, guarded by pendingDocsMutex
 	pendingDocsMutex sync.RWMutex
+
+	// Job scheduling, guarded by jobsMutex. Jobs beyond the configured limit
+	// wait in waitingJobs, highest priority first and then in submission
+	// order, and start as slots free up.
+	runningJobs int
+	waitingJobs []*waitingBatchJob
+
+	// Event streams per job, for GET /batch/{job_id}/events. Always locked
+	// after jobsMutex when both are held.
+	subscribers      map[string]map[chan BatchEvent]struct{}
+	subscribersMutex sync.Mutex
+}
+
+// waitingBatchJob is a submitted job held back by the concurrent job limit
+type waitingBatchJob struct {
+	jobID     string
+	priority  int
+	documents []BatchDocumentInput
 }
 
 // BatchJob represents an async batch processing job
@@ -54,6 +90,21 @@ type BatchJob struct {
 	UpdatedAt   time.Time              `json:"updated_at"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	Options     map[string]interface{} `json:"options"`
+
+	// Priority orders queued jobs: higher priorities start first. Running
+	// jobs are never interrupted for a higher priority one.
+	Priority int `json:"priority"`
+
+	// QueuePosition is the job's 1-based place in line while it is queued
+	QueuePosition int `json:"queue_position,omitempty"`
+
+	// Degraded is set once the classifier was unavailable and documents fell
+	// back to file name classification; Progress.DegradedCount counts them
+	Degraded bool `json:"degraded,omitempty"`
+
+	// results collects Results while the job runs; Results is set from it
+	// when the job finishes
+	results *batchResults
 }
 
 // BatchProgress tracks the progress of a batch job
@@ -65,8 +116,13 @@ type BatchProgress struct {
 	SkippedCount      int     `json:"skipped_count"`
 	IndexedCount      int     `json:"indexed_count"`
 	IndexErrorCount   int     `json:"index_error_count"`
+	PendingIndexCount int     `json:"pending_index_count"`
+	IndexFlushCount   int     `json:"index_flush_count"`
+	DegradedCount     int     `json:"degraded_count"`
 	PercentComplete   float64 `json:"percent_complete"`
 	EstimatedDuration string  `json:"estimated_duration,omitempty"`
+
+	TokenUsage *classifier.UsageSummary `json:"token_usage,omitempty"` // Classification tokens and estimated cost so far
 }
 
 // BatchResult represents the result of processing a single document in a batch
@@ -79,6 +135,7 @@ type BatchResult struct {
 	Indexed              bool                             `json:"indexed"`
 	IndexError           string                           `json:"index_error,omitempty"`
 	IndexID              string                           `json:"index_id,omitempty"`
+	Degraded             bool                             `json:"degraded,omitempty"` // Classified from the file name while the classifier was unavailable
 	ProcessedAt          time.Time                        `json:"processed_at"`
 }
 
@@ -86,8 +143,12 @@ type BatchResult struct {
 type BatchClassifyRequest struct {
 	Documents []BatchDocumentInput   `json:"documents"`
 	Options   map[string]interface{} `json:"options,omitempty"`
+	Priority  int                    `json:"priority,omitempty"` // Higher priority jobs leave the queue first; default 0
 }
 
+// maxBatchDocuments caps the documents in one classification job
+const maxBatchDocuments = 1000
+
 // BatchDocumentInput represents a document to be processed
 type BatchDocumentInput struct {
 	DocumentID   string `json:"document_id"`
@@ -96,8 +157,9 @@ type BatchDocumentInput struct {
 }
 
 // NewBatchHandler creates a new batch handler
-func NewBatchHandler(queueManager queue.QueueManager, storage storage.Service, search search.Service, classifier classifier.Service, extractor extractor.Service) *BatchHandler {
+func NewBatchHandler(cfg *config.Config, queueManager queue.QueueManager, storage storage.Service, search search.Service, classifier classifier.Service, extractor extractor.Service) *BatchHandler {
 	return &BatchHandler{
+		cfg:          cfg,
 		queueManager: queueManager,
 		storage:      storage,
 		search:       search,
@@ -105,6 +167,8 @@ func NewBatchHandler(queueManager queue.QueueManager, storage storage.Service, s
 		extractor:    extractor,
 		jobs:         make(map[string]*BatchJob),
 		pendingDocs:  make(map[string][]*PendingDocument),
+
+		pendingSpills: make(map[string]*pendingSpill),
 	}
 }
 
@@ -128,44 +192,156 @@ func (h *BatchHandler) StartBatchClassification(c *fiber.Ctx) error {
 		))
 	}
 
-	if len(request.Documents) > 1000 {
+	if len(request.Documents) > maxBatchDocuments {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			fmt.Sprintf("Maximum %d documents per batch", maxBatchDocuments),
+			nil,
+		))
+	}
+
+	if err := validateClassificationOptions(request.Options); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
 			"validation_error",
-			"Maximum 1000 documents per batch",
+			err.Error(),
 			nil,
 		))
 	}
 
-	// Create batch job
-	jobID := uuid.New().String()
+	return h.submitClassificationJob(c, uuid.New().String(), request.Documents, request.Options, request.Priority)
+}
+
+// validateClassificationOptions checks the job options that processing reads
+// back without handling errors
+func validateClassificationOptions(options map[string]interface{}) error {
+	if _, err := sourceSystemFromOptions(options); err != nil {
+		return err
+	}
+	_, err := textSampleFromOptions(options)
+	return err
+}
+
+// submitClassificationJob registers a classification job for the documents
+// and starts it, or queues it behind the running jobs
+func (h *BatchHandler) submitClassificationJob(c *fiber.Ctx, jobID string, documents []BatchDocumentInput, options map[string]interface{}, priority int) error {
 	job := &BatchJob{
 		ID:     jobID,
 		Type:   "classification",
 		Status: "queued",
 		Progress: BatchProgress{
-			TotalDocuments: len(request.Documents),
+			TotalDocuments: len(documents),
 		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Options:   request.Options,
+		Options:   options,
+		Priority:  priority,
 	}
 
-	// Store job
 	h.jobsMutex.Lock()
 	h.jobs[jobID] = job
-	h.jobsMutex.Unlock()
-
-	// Start async processing
-	go h.processBatchClassification(jobID, request.Documents)
-
+	started := h.scheduleJob(jobID, documents)
 	response := map[string]interface{}{
 		"job_id":          jobID,
 		"status":          job.Status,
-		"total_documents": len(request.Documents),
+		"total_documents": len(documents),
+		"priority":        job.Priority,
 		"created_at":      job.CreatedAt,
 	}
+	if !started {
+		response["queue_position"] = job.QueuePosition
+	}
+	h.jobsMutex.Unlock()
+
+	message := "Batch classification job started"
+	if !started {
+		message = "Batch classification job queued"
+	}
+	return c.Status(fiber.StatusAccepted).JSON(internalModels.NewSuccessResponse(response, message))
+}
+
+// maxConcurrentJobs returns the configured job limit, or 0 for no limit
+func (h *BatchHandler) maxConcurrentJobs() int {
+	if h.cfg == nil {
+		return 0
+	}
+	return h.cfg.Processing.MaxConcurrentBatchJobs
+}
+
+// scheduleJob starts a job if a slot is free and queues it otherwise, ahead
+// of the queued jobs of lower priority, reporting whether it started. The
+// caller must hold jobsMutex.
+func (h *BatchHandler) scheduleJob(jobID string, documents []BatchDocumentInput) bool {
+	if limit := h.maxConcurrentJobs(); limit > 0 && h.runningJobs >= limit {
+		priority := h.jobs[jobID].Priority
+		position := len(h.waitingJobs)
+		for i, waiting := range h.waitingJobs {
+			if waiting.priority < priority {
+				position = i
+				break
+			}
+		}
+		h.waitingJobs = slices.Insert(h.waitingJobs, position, &waitingBatchJob{jobID: jobID, priority: priority, documents: documents})
+		h.renumberWaitingJobs()
+		log.Printf("[BATCH] ⏳ Job %s queued at position %d with priority %d (%d jobs running)", jobID, position+1, priority, h.runningJobs)
+		return false
+	}
+
+	h.startJob(jobID, documents)
+	return true
+}
+
+// startJob marks a job running and processes it in the background, handing
+// its slot to the next queued job when it finishes. The caller must hold jobsMutex.
+func (h *BatchHandler) startJob(jobID string, documents []BatchDocumentInput) {
+	h.runningJobs++
+	job := h.jobs[jobID]
+	job.Status = "running"
+	job.QueuePosition = 0
+	job.UpdatedAt = time.Now()
+
+	go func() {
+		defer h.releaseJobSlot()
+		h.processBatchClassification(jobID, documents)
+	}()
+}
+
+// releaseJobSlot frees a running slot and starts the first queued job, the
+// oldest of the highest priority
+func (h *BatchHandler) releaseJobSlot() {
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	h.runningJobs--
+	if len(h.waitingJobs) == 0 {
+		return
+	}
+
+	next := h.waitingJobs[0]
+	h.waitingJobs = h.waitingJobs[1:]
+	h.renumberWaitingJobs()
+	log.Printf("[BATCH] ▶️ Starting queued job %s", next.jobID)
+	h.startJob(next.jobID, next.documents)
+}
+
+// removeWaitingJob drops a queued job from the waiting list. The caller must hold jobsMutex.
+func (h *BatchHandler) removeWaitingJob(jobID string) {
+	for i, waiting := range h.waitingJobs {
+		if waiting.jobID == jobID {
+			h.waitingJobs = append(h.waitingJobs[:i], h.waitingJobs[i+1:]...)
+			h.jobs[jobID].QueuePosition = 0
+			h.renumberWaitingJobs()
+			return
+		}
+	}
+}
 
-	return c.Status(fiber.StatusAccepted).JSON(internalModels.NewSuccessResponse(response, "Batch classification job started"))
+// renumberWaitingJobs refreshes queue positions after the waiting list changes
+func (h *BatchHandler) renumberWaitingJobs() {
+	for i, waiting := range h.waitingJobs {
+		if job, exists := h.jobs[waiting.jobID]; exists {
+			job.QueuePosition = i + 1
+		}
+	}
 }
 
 // GetBatchJobStatus handles GET /api/batch/{job_id}/status - Get job progress
@@ -181,6 +357,10 @@ func (h *BatchHandler) GetBatchJobStatus(c *fiber.Ctx) error {
 
 	h.jobsMutex.RLock()
 	job, exists := h.jobs[jobID]
+	var status BatchJob
+	if exists {
+		status = *job
+	}
 	h.jobsMutex.RUnlock()
 
 	if !exists {
@@ -191,7 +371,13 @@ func (h *BatchHandler) GetBatchJobStatus(c *fiber.Ctx) error {
 		))
 	}
 
-	return c.JSON(internalModels.NewSuccessResponse(job, "Job status retrieved successfully"))
+	// A running job's results so far are copied on request rather than on
+	// every document
+	if status.Results == nil && status.results != nil {
+		status.Results = status.results.snapshot()
+	}
+
+	return c.JSON(internalModels.NewSuccessResponse(&status, "Job status retrieved successfully"))
 }
 
 // GetBatchJobResults handles GET /api/batch/{job_id}/results - Get completed results
@@ -249,11 +435,15 @@ func (h *BatchHandler) CancelBatchJob(c *fiber.Ctx) error {
 
 	h.jobsMutex.Lock()
 	job, exists := h.jobs[jobID]
-	if exists && (job.Status == "queued" || job.Status == "running") {
+	if exists && (job.Status == "listing" || job.Status == "queued" || job.Status == "running") {
+		if job.Status == "queued" {
+			h.removeWaitingJob(jobID)
+		}
 		job.Status = "cancelled"
 		job.UpdatedAt = time.Now()
 		now := time.Now()
 		job.CompletedAt = &now
+		h.publish(newBatchEvent(job))
 	}
 	h.jobsMutex.Unlock()
 
@@ -278,8 +468,18 @@ func (h *BatchHandler) processBatchClassification(jobID string, documents []Batc
 	// Update job status to running
 	h.updateJobStatus(jobID, "running", "")
 
-	var results []BatchResult
+	results := newBatchResults(len(documents))
+	h.jobsMutex.Lock()
+	if job, exists := h.jobs[jobID]; exists {
+		job.results = results
+	}
+	h.jobsMutex.Unlock()
+
+	h.invalidateJobFiles(ctx, jobID, documents)
+
 	var successCount, errorCount, skippedCount int
+	var flushThreshold int
+	usage := h.newUsageMeter()
 
 	for i, doc := range documents {
 		// Check if job was cancelled
@@ -292,20 +492,25 @@ func (h *BatchHandler) processBatchClassification(jobID string, documents []Batc
 			break
 		}
 
+		if i == 0 {
+			flushThreshold = h.indexFlushThreshold(job.Options)
+		}
+
 		result := h.processDocument(ctx, jobID, doc, job.Options)
-		results = append(results, result)
+		results.add(result)
+		if result.ClassificationResult != nil {
+			usage.Record(result.ClassificationResult.Usage)
+		}
 
-		// Track all metrics
-		var indexedCount, indexErrorCount int
-		for _, r := range results {
-			if r.Indexed {
-				indexedCount++
-			}
-			if r.IndexError != "" {
-				indexErrorCount++
-			}
+		// Flush classified documents to the index once the high-water mark is
+		// reached so pendingDocs stays bounded on large jobs
+		if flushThreshold > 0 && h.pendingDocumentCount(jobID) >= flushThreshold {
+			h.flushPendingDocuments(jobID, results)
 		}
 
+		// Track all metrics
+		indexedCount, indexErrorCount := results.indexCounts()
+
 		switch result.Status {
 		case "success":
 			successCount++
@@ -314,9 +519,14 @@ func (h *BatchHandler) processBatchClassification(jobID string, documents []Batc
 		case "skipped":
 			skippedCount++
 		}
+		if result.Degraded {
+			h.markDegraded(jobID)
+		}
 
 		// Update progress with indexing metrics
-		h.updateJobProgress(jobID, i+1, successCount, errorCount, skippedCount, indexedCount, indexErrorCount, results)
+		h.updateJobProgress(jobID, i+1, successCount, errorCount, skippedCount, indexedCount, indexErrorCount)
+		h.setPendingIndexCount(jobID, h.pendingDocumentCount(jobID))
+		h.setTokenUsage(jobID, usage.Summary())
 
 		// Log detailed progress every 10 documents
 		if (i+1)%10 == 0 || i+1 == len(documents) {
@@ -340,8 +550,22 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 		ProcessedAt:  time.Now(),
 	}
 
+	// Copy-mode reindexing needs the indexed document before doing any work
+	var existing *models.Document
+	if copiesIndexedMetadata(jobOptions) {
+		var err error
+		existing, err = h.lookupIndexedDocument(ctx, doc.DocumentID)
+		if err != nil {
+			log.Printf("[BATCH-DOC] ❌ %v (document %s)", err, doc.DocumentID)
+			result.Status = "error"
+			result.Error = err.Error()
+			return result
+		}
+	}
+
 	// Get text content
 	text := doc.Text
+	title := extractor.DetectTitle(text)
 	if text == "" && doc.DocumentPath != "" {
 		// Download and extract text from document
 		log.Printf("[BATCH-EXTRACT] 📥 Downloading document: %s", doc.DocumentID)
@@ -378,6 +602,7 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 
 		log.Printf("[BATCH-EXTRACT] ✅ Text extraction successful for document %s (%d chars)", doc.DocumentID, len(extractionResult.Text))
 		text = extractionResult.Text
+		title, _ = extractionResult.Metadata[extractor.MetadataKeyTitle].(string)
 	}
 
 	// Handle text processing and validation
@@ -403,27 +628,34 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 		originalText = text
 		isActualContent = true
 
-		// Skip first 500 characters, then take 1000 characters for AI classification
-		if len(text) > 500 {
-			startPos := 500
-			endPos := startPos + 1000
-			if endPos > len(text) {
-				endPos = len(text)
-			}
-			classificationText = text[startPos:endPos]
-			log.Printf("[BATCH-EXTRACT] 📝 Using text substring for AI classification on document %s: chars %d-%d (from %d total chars)",
-				doc.DocumentID, startPos, endPos-1, len(originalText))
+		// Sample the text for AI classification per the job's window options,
+		// which were validated when the job was submitted
+		sample, err := textSampleFromOptions(jobOptions)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+			return result
+		}
+		classificationText = sample.Apply(text)
+		if len(classificationText) < len(text) {
+			log.Printf("[BATCH-EXTRACT] 📝 Using %s sample of %d chars for AI classification on document %s (from %d total chars)",
+				sample.Strategy, len(classificationText), doc.DocumentID, len(originalText))
 		} else {
-			// Document is too short, use all available text
-			classificationText = text
+			// Document fits in the window, use all available text
 			log.Printf("[BATCH-EXTRACT] ⚠️  Document %s has only %d chars, using all available text for classification",
 				doc.DocumentID, len(text))
 		}
 	}
 
+	// Validated when the job was submitted
+	sourceSystem, _ := sourceSystemFromOptions(jobOptions)
+
 	// Check if AI classification should be skipped
 	var classificationResult *classifier.ClassificationResult
-	if skipAI, ok := jobOptions["skip_ai"].(bool); ok && skipAI {
+	if existing != nil {
+		log.Printf("[BATCH] Reusing indexed metadata for document: %s (copy reindex)", doc.DocumentID)
+		classificationResult = classificationFromDocument(existing)
+	} else if skipAI, ok := jobOptions["skip_ai"].(bool); ok && skipAI {
 		log.Printf("[BATCH] Skipping AI classification for document: %s (skip_ai option)", doc.DocumentID)
 		// Create a default classification result for indexing
 		classificationResult = &classifier.ClassificationResult{
@@ -434,11 +666,11 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 			Success:       true,
 		}
 	} else {
-		// Classify document using the processed text (actual content from chars 500-1500, or fallback metadata)
+		// Classify document using the sampled text (or fallback metadata)
 		metadata := &classifier.DocumentMetadata{
 			FileName:     doc.DocumentID,
 			FileType:     "unknown",
-			SourceSystem: "batch-processor",
+			SourceSystem: sourceSystem,
 		}
 
 		log.Printf("[BATCH-CLASSIFY] Starting classification for document: %s (using %d chars)", doc.DocumentID, len(classificationText))
@@ -447,15 +679,22 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 		if err != nil {
 			// Enhanced error logging for OpenAI API issues
 			errorType := h.categorizeClassificationError(err)
-			log.Printf("[BATCH-CLASSIFY] ❌ Classification failed for document %s: %s - %v", doc.DocumentID, errorType, err)
+			if !h.degradesWhenUnavailable() || !isClassifierUnavailable(err, errorType) {
+				log.Printf("[BATCH-CLASSIFY] ❌ Classification failed for document %s: %s - %v", doc.DocumentID, errorType, err)
 
-			result.Status = "error"
-			result.Error = fmt.Sprintf("Classification failed (%s): %v", errorType, err)
-			return result
-		}
+				result.Status = "error"
+				result.Error = fmt.Sprintf("Classification failed (%s): %v", errorType, err)
+				return result
+			}
 
-		log.Printf("[BATCH-CLASSIFY] ✅ Classification successful for document %s (confidence: %.2f, type: %s)",
-			doc.DocumentID, classificationResult.Confidence, classificationResult.DocumentType)
+			log.Printf("[BATCH-CLASSIFY] ⚠️ Classifier unavailable for document %s (%s), classifying from the file name: %v",
+				doc.DocumentID, errorType, err)
+			classificationResult = h.fallbackClassification(doc.DocumentPath, doc.DocumentID)
+			result.Degraded = true
+		} else {
+			log.Printf("[BATCH-CLASSIFY] ✅ Classification successful for document %s (confidence: %.2f, type: %s)",
+				doc.DocumentID, classificationResult.Confidence, classificationResult.DocumentType)
+		}
 	}
 
 	result.Status = "success"
@@ -473,7 +712,15 @@ func (h *BatchHandler) processDocument(ctx context.Context, jobID string, doc Ba
 		log.Printf("[BATCH-DEFER] 📝 Stored %d chars for batch indexing (isActualContent: %t)", len(indexingText), isActualContent)
 		
 		// Store document for batch indexing after classification phase completes
-		h.storePendingDocument(jobID, &doc, indexingText, classificationResult)
+		h.storePendingDocument(jobID, &PendingDocument{
+			Document:       &doc,
+			Text:           indexingText,
+			Classification: classificationResult,
+			Title:          title,
+			Existing:       existing,
+			SourceSystem:   sourceSystem,
+			Degraded:       result.Degraded,
+		})
 		
 		result.Indexed = false // Will be indexed in batch after classification completes
 		result.IndexID = ""    // Will be set when batch indexed
@@ -501,11 +748,13 @@ func (h *BatchHandler) updateJobStatus(jobID, status, errorMsg string) {
 			now := time.Now()
 			job.CompletedAt = &now
 		}
+		h.publish(newBatchEvent(job))
 	}
 }
 
-// updateJobProgress updates the progress of a batch job
-func (h *BatchHandler) updateJobProgress(jobID string, processed, success, errors, skipped, indexed, indexErrors int, results []BatchResult) {
+// updateJobProgress updates the progress counters of a batch job. Results
+// are tracked separately in the job's batchResults.
+func (h *BatchHandler) updateJobProgress(jobID string, processed, success, errors, skipped, indexed, indexErrors int) {
 	h.jobsMutex.Lock()
 	defer h.jobsMutex.Unlock()
 
@@ -517,31 +766,149 @@ func (h *BatchHandler) updateJobProgress(jobID string, processed, success, error
 		job.Progress.IndexedCount = indexed
 		job.Progress.IndexErrorCount = indexErrors
 		job.Progress.PercentComplete = float64(processed) / float64(job.Progress.TotalDocuments) * 100
-		job.Results = results
 		job.UpdatedAt = time.Now()
+		h.publish(newBatchEvent(job))
 	}
 }
 
-// storePendingDocument stores a document for batch indexing after classification completes
-func (h *BatchHandler) storePendingDocument(jobID string, doc *BatchDocumentInput, text string, classification *classifier.ClassificationResult) {
+// storePendingDocument stores a document for batch indexing after classification completes.
+// Once PendingSpillThreshold documents are held in memory, further documents
+// are written to a temporary file until the job's pending documents are indexed.
+func (h *BatchHandler) storePendingDocument(jobID string, pendingDoc *PendingDocument) {
 	h.pendingDocsMutex.Lock()
 	defer h.pendingDocsMutex.Unlock()
-	
-	pendingDoc := &PendingDocument{
-		Document:       doc,
-		Text:          text,
-		Classification: classification,
+
+	if threshold := h.pendingSpillThreshold(); threshold > 0 && len(h.pendingDocs[jobID]) >= threshold {
+		err := h.spillPendingDocument(jobID, pendingDoc)
+		if err == nil {
+			return
+		}
+		log.Printf("[BATCH-DEFER] ⚠️ Keeping document %s in memory: %v", pendingDoc.Document.DocumentID, err)
 	}
 	
 	h.pendingDocs[jobID] = append(h.pendingDocs[jobID], pendingDoc)
 	log.Printf("[BATCH-DEFER] Added document %s to pending batch for job %s (total pending: %d)", 
-		doc.DocumentID, jobID, len(h.pendingDocs[jobID]))
+		pendingDoc.Document.DocumentID, jobID, len(h.pendingDocs[jobID]))
+}
+
+// pendingSpillThreshold returns how many pending documents a job holds in
+// memory before spilling to disk, or zero to keep them all in memory
+func (h *BatchHandler) pendingSpillThreshold() int {
+	if h.cfg != nil {
+		return h.cfg.Processing.PendingSpillThreshold
+	}
+	return 0
+}
+
+// spillPendingDocument writes a pending document to the job's spill file,
+// creating it for the first; the caller holds pendingDocsMutex
+func (h *BatchHandler) spillPendingDocument(jobID string, pendingDoc *PendingDocument) error {
+	spill, exists := h.pendingSpills[jobID]
+	if !exists {
+		var err error
+		if spill, err = newPendingSpill(); err != nil {
+			return err
+		}
+		h.pendingSpills[jobID] = spill
+	}
+	if err := spill.add(pendingDoc); err != nil {
+		return err
+	}
+	log.Printf("[BATCH-DEFER] Spilled document %s to disk for job %s (total spilled: %d)",
+		pendingDoc.Document.DocumentID, jobID, spill.count())
+	return nil
+}
+
+// pendingDocumentCount returns the number of classified documents waiting to be indexed for a job
+func (h *BatchHandler) pendingDocumentCount(jobID string) int {
+	h.pendingDocsMutex.RLock()
+	defer h.pendingDocsMutex.RUnlock()
+	count := len(h.pendingDocs[jobID])
+	if spill, exists := h.pendingSpills[jobID]; exists {
+		count += spill.count()
+	}
+	return count
+}
+
+// indexFlushThreshold resolves the incremental flush high-water mark for a job.
+// The "index_flush_threshold" job option overrides the configured default.
+func (h *BatchHandler) indexFlushThreshold(options map[string]interface{}) int {
+	if value, ok := options["index_flush_threshold"].(float64); ok && value > 0 {
+		return int(value)
+	}
+	if h.cfg != nil {
+		return h.cfg.Processing.IndexFlushThreshold
+	}
+	return 0
+}
+
+// flushPendingDocuments bulk indexes the documents accumulated so far during the
+// classification phase and records the flush in the job progress
+func (h *BatchHandler) flushPendingDocuments(jobID string, results *batchResults) {
+	pending := h.pendingDocumentCount(jobID)
+	log.Printf("[BATCH-INDEX] 🌊 High-water mark reached for job %s, flushing %d documents", jobID, pending)
+
+	indexed, indexErrors := h.performBatchIndexing(jobID, results)
+
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	if job, exists := h.jobs[jobID]; exists {
+		job.Progress.IndexFlushCount++
+		job.Progress.PendingIndexCount = 0
+		job.UpdatedAt = time.Now()
+		log.Printf("[BATCH-INDEX] 📦 Incremental flush %d for job %s: %d indexed, %d failed",
+			job.Progress.IndexFlushCount, jobID, indexed, indexErrors)
+	}
+}
+
+// setPendingIndexCount records how many documents are waiting for the next index flush
+func (h *BatchHandler) setPendingIndexCount(jobID string, pending int) {
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	if job, exists := h.jobs[jobID]; exists {
+		job.Progress.PendingIndexCount = pending
+	}
+}
+
+// markDegraded counts a document a job classified from its file name
+func (h *BatchHandler) markDegraded(jobID string) {
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	if job, exists := h.jobs[jobID]; exists {
+		job.Degraded = true
+		job.Progress.DegradedCount++
+	}
+}
+
+// setTokenUsage records the classification token usage accumulated by a job
+func (h *BatchHandler) setTokenUsage(jobID string, usage *classifier.UsageSummary) {
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	if job, exists := h.jobs[jobID]; exists {
+		job.Progress.TokenUsage = usage
+	}
+}
+
+// newUsageMeter creates a per-job token meter priced from the AI config
+func (h *BatchHandler) newUsageMeter() *classifier.UsageMeter {
+	if h.cfg == nil {
+		return classifier.NewUsageMeter(0, 0)
+	}
+	return classifier.NewUsageMeter(h.cfg.AI.PromptPricePer1K, h.cfg.AI.CompletionPricePer1K)
 }
 
 // finalizeJob marks a batch job as completed and triggers batch indexing
-func (h *BatchHandler) finalizeJob(jobID string, results []BatchResult, success, errors, skipped int) {
-	// Trigger batch indexing before marking job as complete
-	indexedCount, indexErrorCount := h.performBatchIndexing(jobID, results)
+func (h *BatchHandler) finalizeJob(jobID string, results *batchResults, success, errors, skipped int) {
+	// Index whatever is left after the last incremental flush
+	h.performBatchIndexing(jobID, results)
+
+	// Totals cover every flush, not just the final one
+	indexedCount, indexErrorCount := results.indexCounts()
+	finalResults := results.snapshot()
 
 	status := "completed"
 	if errors > 0 && success == 0 {
@@ -552,13 +919,17 @@ func (h *BatchHandler) finalizeJob(jobID string, results []BatchResult, success,
 	defer h.jobsMutex.Unlock()
 
 	if job, exists := h.jobs[jobID]; exists {
-		job.Status = status
-		job.Results = results
+		// A cancelled job stops early but stays cancelled
+		if job.Status != "cancelled" {
+			job.Status = status
+		}
+		job.Results = finalResults
 		job.Progress.SuccessCount = success
 		job.Progress.ErrorCount = errors
 		job.Progress.SkippedCount = skipped
 		job.Progress.IndexedCount = indexedCount
 		job.Progress.IndexErrorCount = indexErrorCount
+		job.Progress.PendingIndexCount = 0
 		job.Progress.PercentComplete = 100.0
 		job.UpdatedAt = time.Now()
 		now := time.Now()
@@ -566,22 +937,43 @@ func (h *BatchHandler) finalizeJob(jobID string, results []BatchResult, success,
 
 		// Log final statistics
 		log.Printf("[BATCH] Job %s completed: %d processed, %d classified, %d indexed, %d index errors",
-			jobID, len(results), success, indexedCount, indexErrorCount)
+			jobID, len(finalResults), success, indexedCount, indexErrorCount)
+
+		h.publish(newBatchEvent(job))
 	}
 }
 
 // performBatchIndexing performs bulk indexing for all pending documents in a job
-func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult) (indexedCount, indexErrorCount int) {
+func (h *BatchHandler) performBatchIndexing(jobID string, results *batchResults) (indexedCount, indexErrorCount int) {
 	h.pendingDocsMutex.Lock()
 	pendingDocs, exists := h.pendingDocs[jobID]
 	if exists {
 		delete(h.pendingDocs, jobID) // Clean up pending docs
 	}
+	spill, spilled := h.pendingSpills[jobID]
+	if spilled {
+		delete(h.pendingSpills, jobID)
+	}
 	h.pendingDocsMutex.Unlock()
+
+	// Documents beyond the in-memory limit follow those held in memory
+	if spilled {
+		docs, err := spill.readAll()
+		pendingDocs = append(pendingDocs, docs...)
+		if err != nil {
+			log.Printf("[BATCH-INDEX] ❌ %v", err)
+			for _, id := range spill.ids[len(docs):] {
+				results.setIndexError(id, err.Error())
+				indexErrorCount++
+			}
+		}
+		spill.remove()
+		exists = true
+	}
 	
 	if !exists || len(pendingDocs) == 0 {
 		log.Printf("[BATCH-INDEX] No pending documents to index for job %s", jobID)
-		return 0, 0
+		return 0, indexErrorCount
 	}
 	
 	log.Printf("[BATCH-INDEX] 🚀 Starting batch indexing for job %s (%d documents)", jobID, len(pendingDocs))
@@ -591,6 +983,13 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 	docMap := make(map[string]*PendingDocument) // Track pending docs by ID
 	
 	for _, pendingDoc := range pendingDocs {
+		if pendingDoc.Existing != nil {
+			searchDoc := reindexedDocument(pendingDoc)
+			searchDocs = append(searchDocs, searchDoc)
+			docMap[searchDoc.ID] = pendingDoc
+			continue
+		}
+
 		// Create search document from classification result
 		searchDoc := &models.Document{
 			ID:            pendingDoc.Document.DocumentID,
@@ -600,6 +999,10 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
+		if h.cfg != nil && h.cfg.Storage.IndexFileURLs && searchDoc.FilePath != "" {
+			searchDoc.FileURL = h.storage.GetURL(searchDoc.FilePath)
+			searchDoc.CDNURL = storage.CDNURL(h.storage, searchDoc.FilePath)
+		}
 		
 		// Add classification metadata
 		if pendingDoc.Classification != nil {
@@ -609,11 +1012,18 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 			}
 			searchDoc.DocType = pendingDoc.Classification.DocumentType
 			searchDoc.Metadata.DocumentType = models.DocumentType(pendingDoc.Classification.DocumentType)
+			searchDoc.Metadata.Title = pendingDoc.Title
 			searchDoc.Metadata.Subject = pendingDoc.Classification.Subject
 			searchDoc.Metadata.Summary = pendingDoc.Classification.Summary
 			searchDoc.Metadata.Confidence = pendingDoc.Classification.Confidence
-			searchDoc.Metadata.AIClassified = true
+			searchDoc.Metadata.AIClassified = !pendingDoc.Degraded
 			searchDoc.Metadata.ProcessedAt = time.Now()
+			searchDoc.Metadata.SourceSystem = pendingDoc.SourceSystem
+			searchDoc.Metadata.IngestionBatchID = jobID
+			searchDoc.Metadata.Language = extractor.DetectLanguage(pendingDoc.Text)
+			if err := searchDoc.SetClassification(pendingDoc.Classification); err != nil {
+				log.Printf("[BATCH-INDEX] ⚠️ Not storing classification result for %s: %v", searchDoc.ID, err)
+			}
 		}
 		
 		searchDocs = append(searchDocs, searchDoc)
@@ -627,14 +1037,11 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 	bulkResult, err := h.search.BulkIndexDocuments(ctx, searchDocs)
 	if err != nil {
 		log.Printf("[BATCH-INDEX] ❌ Bulk indexing failed for job %s: %v", jobID, err)
-		indexErrorCount = len(pendingDocs)
+		indexErrorCount += len(pendingDocs)
 		
 		// Update results with indexing errors
-		for i := range results {
-			if docMap[results[i].DocumentID] != nil {
-				results[i].IndexError = err.Error()
-				results[i].Indexed = false
-			}
+		for docID := range docMap {
+			results.setIndexError(docID, err.Error())
 		}
 		return 0, indexErrorCount
 	}
@@ -642,6 +1049,15 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 	// Process bulk indexing results
 	log.Printf("[BATCH-INDEX] ✅ Bulk indexing completed for job %s: %d indexed, %d failed", 
 		jobID, bulkResult.Indexed, bulkResult.Failed)
+	retried := 0
+	for _, attempts := range bulkResult.Attempts {
+		if attempts > 1 {
+			retried++
+		}
+	}
+	if retried > 0 {
+		log.Printf("[BATCH-INDEX] 🔄 %d documents in job %s were retried after OpenSearch throttled them", retried, jobID)
+	}
 	
 	// Create a map of failed documents for quick lookup
 	failedDocs := make(map[string]string)
@@ -652,23 +1068,51 @@ func (h *BatchHandler) performBatchIndexing(jobID string, results []BatchResult)
 	}
 	
 	// Update results with indexing status
-	for i := range results {
-		if docMap[results[i].DocumentID] != nil {
-			if errorMsg, failed := failedDocs[results[i].DocumentID]; failed {
-				results[i].IndexError = errorMsg
-				results[i].Indexed = false
-				indexErrorCount++
-			} else {
-				results[i].Indexed = true
-				results[i].IndexID = results[i].DocumentID // Use document ID as index ID
-				indexedCount++
-			}
+	for docID := range docMap {
+		if errorMsg, failed := failedDocs[docID]; failed {
+			results.setIndexError(docID, errorMsg)
+			indexErrorCount++
+		} else {
+			results.setIndexed(docID, docID) // Use document ID as index ID
+			indexedCount++
 		}
 	}
 	
 	return indexedCount, indexErrorCount
 }
 
+// reindexedDocument refreshes an indexed document with newly extracted text,
+// keeping its metadata
+func reindexedDocument(pendingDoc *PendingDocument) *models.Document {
+	doc := *pendingDoc.Existing
+	doc.Text = pendingDoc.Text
+	doc.UpdatedAt = time.Now()
+	if title := pendingDoc.Title; title != "" {
+		metadata := models.DocumentMetadata{}
+		if doc.Metadata != nil {
+			metadata = *doc.Metadata
+		}
+		metadata.Title = title
+		doc.Metadata = &metadata
+	}
+	return &doc
+}
+
+// sourceSystemFromOptions reads the "source_system" job option, which names
+// where a job's documents came from, such as a court feed. Jobs without it
+// record the batch processor.
+func sourceSystemFromOptions(options map[string]interface{}) (string, error) {
+	value, exists := options["source_system"]
+	if !exists {
+		return models.SourceSystemBatchProcessor, nil
+	}
+	sourceSystem, ok := value.(string)
+	if !ok || sourceSystem == "" || len(sourceSystem) > 100 {
+		return "", fmt.Errorf("source_system must be a non-empty string of at most 100 characters")
+	}
+	return sourceSystem, nil
+}
+
 // getFileFormat extracts the file format from a file path
 func getFileFormat(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -753,12 +1197,60 @@ func (h *BatchHandler) generateFallbackText(documentPath, documentID string) str
 	return fallbackText
 }
 
-// enqueueForIndexing enqueues a document for asynchronous indexing
-func (h *BatchHandler) enqueueForIndexing(ctx context.Context, doc BatchDocumentInput, text string, classificationResult *classifier.ClassificationResult, jobOptions map[string]interface{}) error {
+// degradesWhenUnavailable reports whether documents fall back to file name
+// classification when the classifier is unavailable
+func (h *BatchHandler) degradesWhenUnavailable() bool {
+	return h.cfg != nil && h.cfg.AI.DegradeWhenUnavailable
+}
+
+// isClassifierUnavailable reports whether a classification error means the
+// provider could not be reached or refused service, rather than a problem
+// with the document or the request
+func isClassifierUnavailable(err error, errorType string) bool {
+	if classifier.IsCircuitOpenError(err) {
+		return true
+	}
+	switch errorType {
+	case "API_SERVER_ERROR", "TIMEOUT", "NETWORK_ERROR", "RATE_LIMIT", "QUOTA_EXCEEDED", "INSUFFICIENT_QUOTA":
+		return true
+	}
+	return false
+}
+
+// fallbackClassification classifies a document from its file name, guessing
+// the type the way profiles are pre-classified and summarizing it with
+// generateFallbackText. Confidence is zero so the document is never taken
+// for an AI classification.
+func (h *BatchHandler) fallbackClassification(documentPath, documentID string) *classifier.ClassificationResult {
+	source := documentPath
+	if source == "" {
+		source = documentID
+	}
+
+	docType := pipeline.PreClassify(source, "")
+	if docType == "" {
+		docType = classifier.DocumentTypeOther
+	}
+
+	return &classifier.ClassificationResult{
+		DocumentType:  docType,
+		LegalCategory: "unknown",
+		Summary:       strings.ReplaceAll(h.generateFallbackText(documentPath, documentID), "\n", "; "),
+		Confidence:    0.0,
+		Success:       true,
+		Metadata: map[string]interface{}{
+			"classification_mode": "fallback",
+		},
+	}
+}
+
+// enqueueForIndexing enqueues a document on the "indexing" queue for
+// asynchronous indexing
+func enqueueForIndexing(ctx context.Context, queueManager queue.QueueManager, doc BatchDocumentInput, text string, classificationResult *classifier.ClassificationResult, jobOptions map[string]interface{}) error {
 	// Prepare queue options
 	queueOptions := map[string]interface{}{
 		"file_name":    filepath.Base(doc.DocumentPath),
-		"content_type": h.determineContentType(doc.DocumentPath),
+		"content_type": determineContentType(doc.DocumentPath),
 		"size":         int64(len(text)),
 		"file_url":     fmt.Sprintf("/api/documents/%s", doc.DocumentPath),
 	}
@@ -778,7 +1270,7 @@ func (h *BatchHandler) enqueueForIndexing(ctx context.Context, doc BatchDocument
 	)
 
 	// Get or create the indexing queue
-	indexingQueue, err := h.queueManager.GetQueue("indexing")
+	indexingQueue, err := queueManager.GetQueue("indexing")
 	if err != nil {
 		// Queue doesn't exist yet, will be created when queue system is initialized
 		return fmt.Errorf("indexing queue not available: %w", err)
@@ -789,29 +1281,10 @@ func (h *BatchHandler) enqueueForIndexing(ctx context.Context, doc BatchDocument
 		return fmt.Errorf("failed to enqueue item: %w", err)
 	}
 
-	log.Printf("[BATCH] Document %s enqueued for indexing (queue item: %s)", doc.DocumentID, queueItem.ID)
+	log.Printf("[INDEXING] Document %s enqueued for indexing (queue item: %s)", doc.DocumentID, queueItem.ID)
 	return nil
 }
 
-// determineContentType determines the content type from file path
-func (h *BatchHandler) determineContentType(filePath string) string {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	switch ext {
-	case ".pdf":
-		return "application/pdf"
-	case ".docx":
-		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
-	case ".doc":
-		return "application/msword"
-	case ".txt":
-		return "text/plain"
-	case ".rtf":
-		return "application/rtf"
-	default:
-		return "application/octet-stream"
-	}
-}
-
 // categorizeClassificationError categorizes OpenAI API and classification errors for better logging
 func (h *BatchHandler) categorizeClassificationError(err error) string {
 	if err == nil {
@@ -821,15 +1294,15 @@ func (h *BatchHandler) categorizeClassificationError(err error) string {
 	errStr := strings.ToLower(err.Error())
 
 	// OpenAI quota and rate limit errors
+	if rateErr, ok := classifier.AsRateLimitError(err); ok && rateErr.InsufficientQuota || strings.Contains(errStr, "insufficient_quota") {
+		return "INSUFFICIENT_QUOTA"
+	}
 	if strings.Contains(errStr, "quota") || strings.Contains(errStr, "billing") {
 		return "QUOTA_EXCEEDED"
 	}
 	if strings.Contains(errStr, "rate limit") || strings.Contains(errStr, "status 429") {
 		return "RATE_LIMIT"
 	}
-	if strings.Contains(errStr, "insufficient_quota") {
-		return "INSUFFICIENT_QUOTA"
-	}
 
 	// OpenAI API errors
 	if strings.Contains(errStr, "status 401") || strings.Contains(errStr, "unauthorized") {
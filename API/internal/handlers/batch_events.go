@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	internalModels "motion-index-fiber/internal/models"
+)
+
+// BatchEventProgress is the SSE event name for a progress update. The final
+// event of a stream is named after the job's end status: "completed",
+// "failed" or "cancelled".
+const BatchEventProgress = "progress"
+
+// batchEventHeartbeat is how often an idle event stream sends a comment, so
+// streams whose client has gone away are noticed and closed
+const batchEventHeartbeat = 15 * time.Second
+
+// batchEventBuffer is how many events a slow client may fall behind before
+// further progress events are dropped. Each event carries the job's full
+// progress, so a dropped one is made up by the next.
+const batchEventBuffer = 32
+
+// BatchEvent is one Server-Sent Event about a batch job
+type BatchEvent struct {
+	JobID    string        `json:"job_id"`
+	Status   string        `json:"status"`
+	Progress BatchProgress `json:"progress"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// name returns the SSE event name for the event
+func (e *BatchEvent) name() string {
+	if isFinalJobStatus(e.Status) {
+		return e.Status
+	}
+	return BatchEventProgress
+}
+
+// isFinalJobStatus reports whether a job with this status will not change again
+func isFinalJobStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
+
+// newBatchEvent builds an event from a job's current state. The caller must
+// hold jobsMutex.
+func newBatchEvent(job *BatchJob) BatchEvent {
+	return BatchEvent{JobID: job.ID, Status: job.Status, Progress: job.Progress, Error: job.Error}
+}
+
+// subscribe registers a channel for a job's events. The caller must hold
+// jobsMutex, so no event is published between reading the job's state and
+// subscribing.
+func (h *BatchHandler) subscribe(jobID string) chan BatchEvent {
+	events := make(chan BatchEvent, batchEventBuffer)
+
+	h.subscribersMutex.Lock()
+	defer h.subscribersMutex.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[string]map[chan BatchEvent]struct{})
+	}
+	if h.subscribers[jobID] == nil {
+		h.subscribers[jobID] = make(map[chan BatchEvent]struct{})
+	}
+	h.subscribers[jobID][events] = struct{}{}
+	return events
+}
+
+// unsubscribe removes a stream that stopped before the job finished
+func (h *BatchHandler) unsubscribe(jobID string, events chan BatchEvent) {
+	h.subscribersMutex.Lock()
+	defer h.subscribersMutex.Unlock()
+	delete(h.subscribers[jobID], events)
+	if len(h.subscribers[jobID]) == 0 {
+		delete(h.subscribers, jobID)
+	}
+}
+
+// publish sends an event to a job's streams. Progress events are dropped for
+// streams that are too far behind; a final event is always delivered, after
+// which the streams are closed and removed. The caller must hold jobsMutex.
+func (h *BatchHandler) publish(event BatchEvent) {
+	h.subscribersMutex.Lock()
+	defer h.subscribersMutex.Unlock()
+
+	final := isFinalJobStatus(event.Status)
+	for events := range h.subscribers[event.JobID] {
+		if !final {
+			select {
+			case events <- event:
+			default:
+			}
+			continue
+		}
+
+		// Make room for the final event if the stream is behind
+		select {
+		case events <- event:
+		default:
+			select {
+			case <-events:
+			default:
+			}
+			events <- event
+		}
+		close(events)
+	}
+	if final {
+		delete(h.subscribers, event.JobID)
+	}
+}
+
+// StreamBatchJobEvents handles GET /api/batch/{job_id}/events - Stream job
+// progress as Server-Sent Events, one per processed document, ending with a
+// completed, failed or cancelled event
+func (h *BatchHandler) StreamBatchJobEvents(c *fiber.Ctx) error {
+	jobID := c.Params("job_id")
+	if jobID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"Job ID is required",
+			nil,
+		))
+	}
+
+	// The current state is sent first, so a client that connects late or
+	// after the job finished still gets an event
+	h.jobsMutex.RLock()
+	job, exists := h.jobs[jobID]
+	var current BatchEvent
+	var events chan BatchEvent
+	if exists {
+		current = newBatchEvent(job)
+		if !isFinalJobStatus(job.Status) {
+			events = h.subscribe(jobID)
+		}
+	}
+	h.jobsMutex.RUnlock()
+
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(internalModels.NewErrorResponse(
+			"job_not_found",
+			"Batch job not found",
+			nil,
+		))
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeBatchEvent(w, current); err != nil || events == nil {
+			if events != nil {
+				h.unsubscribe(jobID, events)
+			}
+			return
+		}
+
+		heartbeat := time.NewTicker(batchEventHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeBatchEvent(w, event); err != nil {
+					h.unsubscribe(jobID, events)
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := w.Flush(); err != nil {
+					h.unsubscribe(jobID, events)
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeBatchEvent writes one SSE event and flushes it to the client
+func writeBatchEvent(w *bufio.Writer, event BatchEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name(), data)
+	return w.Flush()
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import "sync"
+
+// batchResults collects a running job's per-document results in submission
+// order. Recording a result or its indexing outcome costs the same however
+// many documents the job has, so progress updates on large jobs do not
+// rescan or copy the results gathered so far.
+type batchResults struct {
+	mu          sync.Mutex
+	results     []BatchResult
+	byID        map[string][]int // Positions in results, by document ID
+	indexed     int
+	indexErrors int
+}
+
+// newBatchResults creates a collector sized for a job of n documents
+func newBatchResults(n int) *batchResults {
+	return &batchResults{
+		results: make([]BatchResult, 0, n),
+		byID:    make(map[string][]int, n),
+	}
+}
+
+// add records the result of the next document
+func (r *batchResults) add(result BatchResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byID[result.DocumentID] = append(r.byID[result.DocumentID], len(r.results))
+	r.results = append(r.results, result)
+	r.count(result, 1)
+}
+
+// setIndexed records that a document was indexed under indexID
+func (r *batchResults) setIndexed(documentID, indexID string) {
+	r.update(documentID, func(result *BatchResult) {
+		result.Indexed = true
+		result.IndexID = indexID
+		result.IndexError = ""
+	})
+}
+
+// setIndexError records that indexing a document failed
+func (r *batchResults) setIndexError(documentID, message string) {
+	r.update(documentID, func(result *BatchResult) {
+		result.Indexed = false
+		result.IndexError = message
+	})
+}
+
+// update applies change to every result for a document, keeping the
+// indexing counts in step
+func (r *batchResults) update(documentID string, change func(*BatchResult)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.byID[documentID] {
+		r.count(r.results[i], -1)
+		change(&r.results[i])
+		r.count(r.results[i], 1)
+	}
+}
+
+// count adds delta to the indexing counts a result contributes to
+func (r *batchResults) count(result BatchResult, delta int) {
+	if result.Indexed {
+		r.indexed += delta
+	}
+	if result.IndexError != "" {
+		r.indexErrors += delta
+	}
+}
+
+// indexCounts returns how many results were indexed and how many failed to
+func (r *batchResults) indexCounts() (indexed, indexErrors int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.indexed, r.indexErrors
+}
+
+// snapshot returns a copy of the results recorded so far, in order
+func (r *batchResults) snapshot() []BatchResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.results) == 0 {
+		return nil
+	}
+	return append([]BatchResult(nil), r.results...)
+}
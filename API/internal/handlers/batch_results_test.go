@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchResults_TracksIndexStatus(t *testing.T) {
+	results := newBatchResults(4)
+	for _, id := range []string{"doc-1", "doc-2", "doc-3"} {
+		results.add(BatchResult{DocumentID: id, Status: "success"})
+	}
+	results.add(BatchResult{DocumentID: "doc-4", Status: "error", Error: "classification failed"})
+
+	results.setIndexed("doc-1", "doc-1")
+	results.setIndexError("doc-2", "mapping conflict")
+	results.setIndexed("missing", "missing")
+
+	indexed, indexErrors := results.indexCounts()
+	assert.Equal(t, 1, indexed)
+	assert.Equal(t, 1, indexErrors)
+
+	// A later flush can fix an earlier failure without double counting
+	results.setIndexed("doc-2", "doc-2")
+	indexed, indexErrors = results.indexCounts()
+	assert.Equal(t, 2, indexed)
+	assert.Equal(t, 0, indexErrors)
+
+	snapshot := results.snapshot()
+	assert.Equal(t, []string{"doc-1", "doc-2", "doc-3", "doc-4"}, resultIDs(snapshot))
+	assert.True(t, snapshot[1].Indexed)
+	assert.Empty(t, snapshot[1].IndexError)
+
+	// The snapshot is a copy
+	snapshot[2].Indexed = true
+	indexed, _ = results.indexCounts()
+	assert.Equal(t, 2, indexed)
+	assert.False(t, results.snapshot()[2].Indexed)
+
+	assert.Nil(t, newBatchResults(0).snapshot())
+}
+
+func resultIDs(results []BatchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.DocumentID
+	}
+	return ids
+}
+
+// BenchmarkBatchProgress_LargeJob compares the per-document progress update of
+// a large job when the counts are recomputed from every result, as they once
+// were, with the incremental batchResults. The rescan grows with the job size,
+// the incremental update does not.
+func BenchmarkBatchProgress_LargeJob(b *testing.B) {
+	const jobSize = 20000
+	documents := make([]BatchResult, jobSize)
+	for i := range documents {
+		documents[i] = BatchResult{DocumentID: fmt.Sprintf("doc-%05d", i), Status: "success", Indexed: i%2 == 0}
+	}
+
+	b.Run("rescan", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			var results []BatchResult
+			for _, result := range documents {
+				results = append(results, result)
+				var indexed, indexErrors int
+				for _, r := range results {
+					if r.Indexed {
+						indexed++
+					}
+					if r.IndexError != "" {
+						indexErrors++
+					}
+				}
+				_, _ = indexed, indexErrors
+			}
+		}
+	})
+
+	b.Run("incremental", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			results := newBatchResults(jobSize)
+			for _, result := range documents {
+				results.add(result)
+				results.indexCounts()
+			}
+		}
+	})
+}
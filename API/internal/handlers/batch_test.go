@@ -0,0 +1,976 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
+	"motion-index-fiber/pkg/storage"
+)
+
+// newTestBatchHandler creates a batch handler backed by in-memory services
+func newTestBatchHandler(searchSvc *MockSearchService) *BatchHandler {
+	return NewBatchHandler(testutil.TestConfig(), nil, newMockStorageService(), searchSvc, &stubClassifier{}, nil)
+}
+
+// runBatchJob registers a job and processes it synchronously
+func runBatchJob(h *BatchHandler, documents []BatchDocumentInput, options map[string]interface{}) *BatchJob {
+	jobID := fmt.Sprintf("job-%d", time.Now().UnixNano())
+	h.jobs[jobID] = &BatchJob{
+		ID:       jobID,
+		Type:     "classification",
+		Status:   "queued",
+		Progress: BatchProgress{TotalDocuments: len(documents)},
+		Options:  options,
+	}
+
+	h.processBatchClassification(jobID, documents)
+
+	h.jobsMutex.RLock()
+	defer h.jobsMutex.RUnlock()
+	return h.jobs[jobID]
+}
+
+func makeBatchDocuments(n int) []BatchDocumentInput {
+	documents := make([]BatchDocumentInput, n)
+	for i := range documents {
+		documents[i] = BatchDocumentInput{
+			DocumentID: fmt.Sprintf("doc-%03d", i),
+			Text:       fmt.Sprintf("Motion to dismiss number %d filed in superior court", i),
+		}
+	}
+	return documents
+}
+
+func TestBatchClassification_IncrementalIndexFlush(t *testing.T) {
+	searchSvc := newMockSearchService()
+
+	var mu sync.Mutex
+	var flushSizes []int
+	var statusAtFlush []string
+	var h *BatchHandler
+
+	searchSvc.bulkIndexFn = func(docs []*models.Document) (*models.BulkResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		flushSizes = append(flushSizes, len(docs))
+		h.jobsMutex.RLock()
+		for _, job := range h.jobs {
+			statusAtFlush = append(statusAtFlush, job.Status)
+		}
+		h.jobsMutex.RUnlock()
+		return &models.BulkResult{Indexed: len(docs)}, nil
+	}
+
+	h = newTestBatchHandler(searchSvc)
+	job := runBatchJob(h, makeBatchDocuments(25), map[string]interface{}{
+		"index_document":        true,
+		"index_flush_threshold": float64(10),
+	})
+
+	// Two flushes at the high-water mark plus a final flush for the remainder
+	assert.Equal(t, []int{10, 10, 5}, flushSizes)
+	assert.Equal(t, []string{"running", "running", "running"}, statusAtFlush)
+
+	assert.Equal(t, "completed", job.Status)
+	assert.Equal(t, 2, job.Progress.IndexFlushCount)
+	assert.Equal(t, 25, job.Progress.IndexedCount)
+	assert.Equal(t, 0, job.Progress.PendingIndexCount)
+	for _, result := range job.Results {
+		assert.True(t, result.Indexed, result.DocumentID)
+	}
+	assert.Zero(t, h.pendingDocumentCount(job.ID))
+}
+
+func TestBatchClassification_PendingDocsBoundedByThreshold(t *testing.T) {
+	searchSvc := newMockSearchService()
+
+	maxPending := 0
+	searchSvc.bulkIndexFn = func(docs []*models.Document) (*models.BulkResult, error) {
+		if len(docs) > maxPending {
+			maxPending = len(docs)
+		}
+		return &models.BulkResult{Indexed: len(docs)}, nil
+	}
+
+	h := newTestBatchHandler(searchSvc)
+	job := runBatchJob(h, makeBatchDocuments(40), map[string]interface{}{"index_document": true})
+
+	// TestConfig sets IndexFlushThreshold to 10
+	assert.LessOrEqual(t, maxPending, 10)
+	assert.Equal(t, 4, job.Progress.IndexFlushCount)
+	assert.Equal(t, 40, job.Progress.IndexedCount)
+}
+
+func TestBatchClassification_FlushDisabled(t *testing.T) {
+	searchSvc := newMockSearchService()
+
+	var flushes int
+	searchSvc.bulkIndexFn = func(docs []*models.Document) (*models.BulkResult, error) {
+		flushes++
+		return &models.BulkResult{Indexed: len(docs)}, nil
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Processing.IndexFlushThreshold = 0
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), searchSvc, &stubClassifier{}, nil)
+
+	job := runBatchJob(h, makeBatchDocuments(25), map[string]interface{}{"index_document": true})
+
+	require.Equal(t, 1, flushes)
+	assert.Equal(t, 0, job.Progress.IndexFlushCount)
+	assert.Equal(t, 25, job.Progress.IndexedCount)
+}
+
+func TestBatchClassification_SpillsPendingDocumentsToDisk(t *testing.T) {
+	run := func(spillThreshold int) (*BatchJob, [][]string, *BatchHandler) {
+		searchSvc := newMockSearchService()
+		var flushes [][]string
+		searchSvc.bulkIndexFn = func(docs []*models.Document) (*models.BulkResult, error) {
+			var ids []string
+			result := &models.BulkResult{}
+			for _, doc := range docs {
+				ids = append(ids, doc.ID+":"+doc.Text)
+				if doc.ID == "doc-017" {
+					result.Failed++
+					result.FailedDocs = append(result.FailedDocs, &models.BulkFailedDoc{ID: doc.ID, Error: "mapper_parsing_exception", Status: 400})
+				} else {
+					result.Indexed++
+				}
+			}
+			flushes = append(flushes, ids)
+			return result, nil
+		}
+
+		cfg := testutil.TestConfig()
+		cfg.Processing.IndexFlushThreshold = 0
+		cfg.Processing.PendingSpillThreshold = spillThreshold
+		h := NewBatchHandler(cfg, nil, newMockStorageService(), searchSvc, &stubClassifier{}, nil)
+		return runBatchJob(h, makeBatchDocuments(25), map[string]interface{}{"index_document": true}), flushes, h
+	}
+
+	inMemory, memoryFlushes, _ := run(0)
+	spilled, spilledFlushes, h := run(5)
+
+	assert.Equal(t, memoryFlushes, spilledFlushes, "the same documents are indexed in the same order")
+	assert.Equal(t, inMemory.Progress.IndexedCount, spilled.Progress.IndexedCount)
+	assert.Equal(t, inMemory.Progress.IndexErrorCount, spilled.Progress.IndexErrorCount)
+	assert.Equal(t, 24, spilled.Progress.IndexedCount)
+	assert.Equal(t, 1, spilled.Progress.IndexErrorCount)
+	require.Len(t, spilled.Results, len(inMemory.Results))
+	for i := range inMemory.Results {
+		assert.Equal(t, inMemory.Results[i].Indexed, spilled.Results[i].Indexed, inMemory.Results[i].DocumentID)
+		assert.Equal(t, inMemory.Results[i].IndexError, spilled.Results[i].IndexError, inMemory.Results[i].DocumentID)
+	}
+	assert.Empty(t, h.pendingSpills, "spill files are removed once indexed")
+}
+
+func TestPendingSpill_RoundTrip(t *testing.T) {
+	spill, err := newPendingSpill()
+	require.NoError(t, err)
+	defer spill.remove()
+
+	existing := &models.Document{ID: "doc-2", Metadata: &models.DocumentMetadata{Status: "approved"}}
+	docs := []*PendingDocument{
+		{
+			Document:       &BatchDocumentInput{DocumentID: "doc-1", DocumentPath: "cases/doc-1.pdf"},
+			Text:           "Motion to suppress",
+			Classification: &classifier.ClassificationResult{DocumentType: "motion", Confidence: 0.9},
+			Title:          "MOTION TO SUPPRESS",
+			SourceSystem:   "court-feed",
+			Degraded:       true,
+		},
+		{Document: &BatchDocumentInput{DocumentID: "doc-2"}, Text: "Order", Existing: existing},
+	}
+	for _, doc := range docs {
+		require.NoError(t, spill.add(doc))
+	}
+	assert.Equal(t, 2, spill.count())
+
+	read, err := spill.readAll()
+	require.NoError(t, err)
+	assert.Equal(t, docs, read)
+}
+
+func TestStorePendingDocument_WithoutConfig(t *testing.T) {
+	h := NewBatchHandler(nil, nil, nil, nil, nil, nil)
+	h.storePendingDocument("job-1", &PendingDocument{Document: &BatchDocumentInput{DocumentID: "doc-1"}})
+
+	assert.Len(t, h.pendingDocs["job-1"], 1)
+	assert.Empty(t, h.pendingSpills)
+}
+
+// submitBatchJob posts a classification job and returns the decoded response data
+func submitBatchJob(t *testing.T, h *BatchHandler, request BatchClassifyRequest) map[string]interface{} {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/batch/classify", h.StartBatchClassification)
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/batch/classify", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return decoded["data"].(map[string]interface{})
+}
+
+func TestStartBatchClassification_QueuesJobsBeyondLimit(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Processing.MaxConcurrentBatchJobs = 2
+	classifierSvc := &stubClassifier{release: make(chan struct{})}
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), classifierSvc, nil)
+
+	var jobIDs []string
+	for i := 0; i < 4; i++ {
+		data := submitBatchJob(t, h, BatchClassifyRequest{Documents: makeBatchDocuments(2)})
+		jobIDs = append(jobIDs, data["job_id"].(string))
+		if i < 2 {
+			assert.Equal(t, "running", data["status"])
+		} else {
+			assert.Equal(t, "queued", data["status"])
+			assert.Equal(t, float64(i-1), data["queue_position"])
+		}
+	}
+
+	jobStatus := func(id string) (string, int) {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		return h.jobs[id].Status, h.jobs[id].QueuePosition
+	}
+
+	// Cancelling a queued job moves the jobs behind it up
+	app := fiber.New()
+	app.Delete("/batch/:job_id", h.CancelBatchJob)
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/batch/"+jobIDs[2], nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	status, position := jobStatus(jobIDs[3])
+	assert.Equal(t, "queued", status)
+	assert.Equal(t, 1, position)
+
+	// Queued jobs start as running ones complete
+	close(classifierSvc.release)
+	assert.Eventually(t, func() bool {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		return h.runningJobs == 0 && len(h.waitingJobs) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i, id := range jobIDs {
+		status, _ := jobStatus(id)
+		if i == 2 {
+			assert.Equal(t, "cancelled", status)
+		} else {
+			assert.Equal(t, "completed", status, id)
+		}
+	}
+}
+
+func TestStartBatchClassification_HigherPriorityJobsStartFirst(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Processing.MaxConcurrentBatchJobs = 1
+	classifierSvc := &stubClassifier{release: make(chan struct{})}
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), classifierSvc, nil)
+
+	documents := func(name string) []BatchDocumentInput {
+		return []BatchDocumentInput{{DocumentID: name, Text: "Motion to dismiss from the " + name + " job"}}
+	}
+	running := submitBatchJob(t, h, BatchClassifyRequest{Documents: documents("running")})
+	require.Equal(t, "running", running["status"])
+
+	low := submitBatchJob(t, h, BatchClassifyRequest{Documents: documents("catch-up"), Priority: -1})
+	assert.Equal(t, float64(1), low["queue_position"])
+	high := submitBatchJob(t, h, BatchClassifyRequest{Documents: documents("urgent"), Priority: 10})
+	assert.Equal(t, "queued", high["status"])
+	assert.Equal(t, float64(1), high["queue_position"], "the urgent job goes ahead of the catch-up job")
+	assert.Equal(t, float64(10), high["priority"])
+
+	h.jobsMutex.RLock()
+	assert.Equal(t, 2, h.jobs[low["job_id"].(string)].QueuePosition)
+	assert.Equal(t, 10, h.jobs[high["job_id"].(string)].Priority, "the priority is reported in job status")
+	h.jobsMutex.RUnlock()
+
+	// When the running job's slot frees the urgent job takes it
+	close(classifierSvc.release)
+	assert.Eventually(t, func() bool {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		return h.runningJobs == 0 && len(h.waitingJobs) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	classifierSvc.mu.Lock()
+	defer classifierSvc.mu.Unlock()
+	require.Len(t, classifierSvc.texts, 3)
+	assert.Contains(t, classifierSvc.texts[0], "running")
+	assert.Contains(t, classifierSvc.texts[1], "urgent")
+	assert.Contains(t, classifierSvc.texts[2], "catch-up")
+}
+
+// postReindex submits a reindex request and returns the status code and decoded body
+func postReindex(t *testing.T, h *BatchHandler, request ReindexRequest) (int, map[string]interface{}) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/admin/reindex", h.StartReindex)
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/admin/reindex", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestStartReindex_CopyKeepsIndexedMetadata(t *testing.T) {
+	storageSvc := newMockStorageService()
+	storageSvc.objects["docs/motion.txt"] = []byte("MOTION TO SUPPRESS EVIDENCE\n\nThe defendant moves to suppress all evidence.")
+	storageSvc.objects["docs/unindexed.txt"] = []byte("Never indexed before")
+	storageSvc.objects["other/skip.txt"] = []byte("Outside the prefix")
+
+	searchSvc := newMockSearchService()
+	searchSvc.documents["docs/motion.txt"] = &models.Document{
+		ID:       "docs/motion.txt",
+		FilePath: "docs/motion.txt",
+		Text:     "stale text",
+		Metadata: &models.DocumentMetadata{
+			DocumentType: models.DocumentType("motion_to_suppress"),
+			Subject:      "Suppression of evidence",
+			Judge:        &models.Judge{Name: "Hon. Jane Doe"},
+		},
+	}
+
+	// Copy mode must not classify; a classifier call would fail the document
+	classifierSvc := &stubClassifier{err: fmt.Errorf("classifier should not be called")}
+	h := NewBatchHandler(testutil.TestConfig(), nil, storageSvc, searchSvc, classifierSvc, extractor.NewService())
+
+	status, body := postReindex(t, h, ReindexRequest{Prefix: "docs/", Mode: ReindexModeCopy})
+	require.Equal(t, fiber.StatusAccepted, status)
+	jobID := body["data"].(map[string]interface{})["job_id"].(string)
+
+	var job BatchJob
+	assert.Eventually(t, func() bool {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		job = *h.jobs[jobID]
+		return job.Status == "completed" || job.Status == "failed"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "reindex", job.Type)
+	assert.Equal(t, 2, job.Progress.TotalDocuments)
+	assert.Equal(t, 1, job.Progress.SuccessCount)
+	assert.Equal(t, 1, job.Progress.ErrorCount)
+	assert.Equal(t, 1, job.Progress.IndexedCount)
+
+	reindexed, err := searchSvc.GetDocument(context.Background(), "docs/motion.txt")
+	require.NoError(t, err)
+	assert.Contains(t, reindexed.Text, "moves to suppress")
+	assert.Equal(t, "Suppression of evidence", reindexed.Metadata.Subject)
+	assert.Equal(t, "Hon. Jane Doe", reindexed.Metadata.GetJudgeName())
+	assert.Equal(t, "MOTION TO SUPPRESS EVIDENCE", reindexed.Metadata.Title)
+}
+
+// invalidatingStorage is a mock storage behind a CDN, recording the paths
+// whose cache it was asked to flush
+type invalidatingStorage struct {
+	*MockStorageService
+	mu          sync.Mutex
+	invalidated []string
+}
+
+func (s *invalidatingStorage) BulkInvalidateCache(ctx context.Context, paths []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidated = append(s.invalidated, paths...)
+	return nil
+}
+
+func TestStartReindex_InvalidatesCDN(t *testing.T) {
+	for _, invalidate := range []bool{false, true} {
+		t.Run(fmt.Sprintf("invalidate_cdn=%v", invalidate), func(t *testing.T) {
+			storageSvc := &invalidatingStorage{MockStorageService: newMockStorageService()}
+			storageSvc.objects["docs/motion.txt"] = []byte("MOTION TO SUPPRESS EVIDENCE")
+			storageSvc.objects["docs/order.txt"] = []byte("ORDER GRANTING MOTION")
+
+			h := NewBatchHandler(testutil.TestConfig(), nil, storageSvc, newMockSearchService(), &stubClassifier{}, extractor.NewService())
+			status, body := postReindex(t, h, ReindexRequest{Prefix: "docs/", Mode: ReindexModeCopy, InvalidateCDN: invalidate})
+			require.Equal(t, fiber.StatusAccepted, status)
+			jobID := body["data"].(map[string]interface{})["job_id"].(string)
+
+			assert.Eventually(t, func() bool {
+				h.jobsMutex.RLock()
+				defer h.jobsMutex.RUnlock()
+				return h.jobs[jobID].Status == "completed" || h.jobs[jobID].Status == "failed"
+			}, 5*time.Second, 10*time.Millisecond)
+
+			storageSvc.mu.Lock()
+			defer storageSvc.mu.Unlock()
+			if invalidate {
+				assert.ElementsMatch(t, []string{"docs/motion.txt", "docs/order.txt"}, storageSvc.invalidated)
+			} else {
+				assert.Empty(t, storageSvc.invalidated)
+			}
+		})
+	}
+}
+
+// slowListStorage holds List until release is closed, like a large bucket
+type slowListStorage struct {
+	*MockStorageService
+	release chan struct{}
+}
+
+func (s *slowListStorage) List(ctx context.Context, prefix string) ([]*storage.StorageObject, error) {
+	<-s.release
+	return s.MockStorageService.List(ctx, prefix)
+}
+
+func TestStartReindex_ListsDocumentsInTheJob(t *testing.T) {
+	storageSvc := &slowListStorage{MockStorageService: newMockStorageService(), release: make(chan struct{})}
+	storageSvc.objects["docs/motion.txt"] = []byte("MOTION TO SUPPRESS EVIDENCE")
+	h := NewBatchHandler(testutil.TestConfig(), nil, storageSvc, newMockSearchService(), &stubClassifier{}, extractor.NewService())
+
+	jobStatus := func(jobID string) BatchJob {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		return *h.jobs[jobID]
+	}
+
+	// The request returns while the bucket is still being listed
+	status, body := postReindex(t, h, ReindexRequest{Prefix: "docs/"})
+	require.Equal(t, fiber.StatusAccepted, status)
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, "listing", data["status"])
+	listed := data["job_id"].(string)
+
+	_, body = postReindex(t, h, ReindexRequest{Prefix: "missing/"})
+	empty := body["data"].(map[string]interface{})["job_id"].(string)
+
+	_, body = postReindex(t, h, ReindexRequest{Prefix: "docs/"})
+	cancelled := body["data"].(map[string]interface{})["job_id"].(string)
+	app := fiber.New()
+	app.Delete("/batch/:job_id", h.CancelBatchJob)
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/batch/"+cancelled, nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	close(storageSvc.release)
+	assert.Eventually(t, func() bool {
+		return jobStatus(listed).Status == "completed" && jobStatus(empty).Status == "failed"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, jobStatus(listed).Progress.TotalDocuments)
+	assert.Contains(t, jobStatus(empty).Error, "no documents found")
+	assert.Equal(t, "cancelled", jobStatus(cancelled).Status)
+	assert.Zero(t, jobStatus(cancelled).Progress.TotalDocuments)
+}
+
+func TestStartReindex_RejectsUnknownMode(t *testing.T) {
+	h := newTestBatchHandler(newMockSearchService())
+
+	status, _ := postReindex(t, h, ReindexRequest{Mode: "merge"})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
+
+func TestBatchClassification_AccumulatesTokenUsage(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.AI.PromptPricePer1K = 0.01
+	cfg.AI.CompletionPricePer1K = 0.03
+
+	stub := &stubClassifier{result: &classifier.ClassificationResult{
+		DocumentType:  classifier.DocumentTypeMotionToDismiss,
+		LegalCategory: classifier.LegalCategoryCriminal,
+		Confidence:    0.9,
+		Success:       true,
+		Usage:         &classifier.TokenUsage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200},
+	}}
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), stub, nil)
+
+	job := runBatchJob(h, makeBatchDocuments(3), map[string]interface{}{})
+
+	usage := job.Progress.TokenUsage
+	require.NotNil(t, usage)
+	assert.Equal(t, int64(3), usage.Documents)
+	assert.Equal(t, int64(3000), usage.PromptTokens)
+	assert.Equal(t, int64(600), usage.CompletionTokens)
+	assert.Equal(t, int64(3600), usage.TotalTokens)
+	assert.InDelta(t, 1200, usage.AverageTokensPerDocument, 0.001)
+	assert.InDelta(t, 0.048, usage.EstimatedCostUSD, 0.0001)
+}
+
+func TestBatchClassification_DegradesWhenClassifierUnavailable(t *testing.T) {
+	searchSvc := newMockSearchService()
+	var indexed []*models.Document
+	searchSvc.bulkIndexFn = func(docs []*models.Document) (*models.BulkResult, error) {
+		indexed = append(indexed, docs...)
+		return &models.BulkResult{Indexed: len(docs)}, nil
+	}
+
+	down := &stubClassifier{err: classifier.NewClassificationError("circuit_open", "openai circuit is open after 5 consecutive failures", nil)}
+	documents := []BatchDocumentInput{
+		{DocumentID: "doc-1", DocumentPath: "cases/2024/motion_to_suppress.pdf", Text: "Defendant moves to suppress evidence."},
+		{DocumentID: "doc-2", DocumentPath: "cases/2024/scan-0042.pdf", Text: "Illegible scan."},
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.AI.DegradeWhenUnavailable = true
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), searchSvc, down, nil)
+	job := runBatchJob(h, documents, map[string]interface{}{"index_document": true})
+
+	assert.Equal(t, "completed", job.Status)
+	assert.True(t, job.Degraded)
+	assert.Equal(t, 2, job.Progress.DegradedCount)
+	assert.Equal(t, 2, job.Progress.SuccessCount)
+	assert.Equal(t, 2, job.Progress.IndexedCount)
+	for _, result := range job.Results {
+		assert.True(t, result.Degraded, result.DocumentID)
+		assert.Equal(t, "success", result.Status, result.DocumentID)
+	}
+
+	// Documents are indexed with best-effort metadata, marked as not AI-classified
+	require.Len(t, indexed, 2)
+	assert.Equal(t, "motion_to_suppress", indexed[0].DocType)
+	assert.Equal(t, classifier.DocumentTypeOther, indexed[1].DocType)
+	for _, doc := range indexed {
+		assert.False(t, doc.Metadata.AIClassified, doc.ID)
+		assert.Zero(t, doc.Metadata.Confidence, doc.ID)
+		assert.Contains(t, doc.Metadata.Summary, "Filename:", doc.ID)
+	}
+
+	// Without the option the same outage fails every document
+	cfg = testutil.TestConfig()
+	h = NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), down, nil)
+	job = runBatchJob(h, documents, map[string]interface{}{"index_document": true})
+	assert.Equal(t, "failed", job.Status)
+	assert.False(t, job.Degraded)
+	assert.Equal(t, 2, job.Progress.ErrorCount)
+
+	// Errors that are not outages still fail with the option on
+	cfg = testutil.TestConfig()
+	cfg.AI.DegradeWhenUnavailable = true
+	badRequest := &stubClassifier{err: fmt.Errorf("openai request failed: status 400 bad request")}
+	h = NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), badRequest, nil)
+	job = runBatchJob(h, documents[:1], map[string]interface{}{"index_document": true})
+	assert.Equal(t, 1, job.Progress.ErrorCount)
+	assert.Zero(t, job.Progress.DegradedCount)
+}
+
+func TestBatchClassification_ClassificationWindow(t *testing.T) {
+	caption := "MOTION TO SUPPRESS EVIDENCE "
+	text := caption + strings.Repeat("body ", 400) + "WHEREFORE the evidence should be suppressed."
+
+	tests := []struct {
+		name     string
+		options  map[string]interface{}
+		expected string
+	}{
+		{"default skips the caption", map[string]interface{}{}, text[500:1500]},
+		{"head keeps the caption", map[string]interface{}{
+			"sample_strategy":       "head",
+			"classification_length": float64(40),
+		}, text[:40]},
+		{"custom offset", map[string]interface{}{
+			"classification_offset": float64(10),
+			"classification_length": float64(20),
+		}, text[10:30]},
+		{"offset past the end falls back to the head", map[string]interface{}{
+			"classification_offset": float64(100000),
+			"classification_length": float64(20),
+		}, text[:20]},
+		{"head and tail", map[string]interface{}{
+			"sample_strategy":       "head_and_tail",
+			"classification_length": float64(60),
+		}, text[:30] + headAndTailSeparator + text[len(text)-30:]},
+		{"short document is used whole", map[string]interface{}{
+			"sample_strategy":       "head_and_tail",
+			"classification_length": float64(len(text)),
+		}, text},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubClassifier{}
+			h := NewBatchHandler(testutil.TestConfig(), nil, newMockStorageService(), newMockSearchService(), stub, nil)
+
+			job := runBatchJob(h, []BatchDocumentInput{{DocumentID: "doc-1", Text: text}}, tt.options)
+
+			assert.Equal(t, "completed", job.Status)
+			require.Len(t, stub.texts, 1)
+			assert.Equal(t, tt.expected, stub.texts[0])
+		})
+	}
+}
+
+func TestStartBatchClassification_RejectsInvalidWindow(t *testing.T) {
+	h := newTestBatchHandler(newMockSearchService())
+	app := fiber.New()
+	app.Post("/batch/classify", h.StartBatchClassification)
+
+	for _, options := range []map[string]interface{}{
+		{"sample_strategy": "middle"},
+		{"sample_strategy": 1},
+		{"classification_offset": -1},
+		{"classification_length": 0},
+		{"classification_length": 10.5},
+		{"classification_length": "1000"},
+	} {
+		body, err := json.Marshal(BatchClassifyRequest{Documents: makeBatchDocuments(1), Options: options})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/batch/classify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, options)
+	}
+	assert.Empty(t, h.jobs)
+}
+
+func TestStartBatchClassificationUpload_StoresAndClassifiesFiles(t *testing.T) {
+	storageSvc := newMockStorageService()
+	classifierSvc := &stubClassifier{}
+	h := NewBatchHandler(testutil.TestConfig(), nil, storageSvc, newMockSearchService(), classifierSvc, extractor.NewService())
+	app := fiber.New()
+	app.Post("/batch/classify-upload", h.StartBatchClassificationUpload)
+
+	// A zip of two filings, with the junk macOS adds, next to a plain upload
+	archive := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(archive)
+	for name, content := range map[string]string{
+		"filings/order.txt":            "ORDER GRANTING MOTION TO CONTINUE",
+		"filings/brief.txt":            "OPENING BRIEF OF APPELLANT",
+		"__MACOSX/filings/._order.txt": "resource fork",
+		"filings/.DS_Store":            "finder data",
+	} {
+		entry, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range map[string][]byte{
+		"motion.txt":  []byte("MOTION TO SUPPRESS EVIDENCE"),
+		"filings.zip": archive.Bytes(),
+	} {
+		part, err := writer.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.WriteField("options", `{"source_system": "court-feed-sf"}`))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/batch/classify-upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	data := decoded["data"].(map[string]interface{})
+	assert.Equal(t, float64(3), data["total_documents"])
+	jobID := data["job_id"].(string)
+
+	storageSvc.mu.Lock()
+	assert.Len(t, storageSvc.objects, 3)
+	storageSvc.mu.Unlock()
+
+	// The stored files go through the same job machinery as path-based batches
+	assert.Eventually(t, func() bool {
+		h.jobsMutex.RLock()
+		defer h.jobsMutex.RUnlock()
+		return h.jobs[jobID].Status == "completed"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	h.jobsMutex.RLock()
+	job := h.jobs[jobID]
+	h.jobsMutex.RUnlock()
+	assert.Equal(t, 3, job.Progress.SuccessCount)
+	assert.Equal(t, "court-feed-sf", job.Options["source_system"])
+
+	classifierSvc.mu.Lock()
+	defer classifierSvc.mu.Unlock()
+	assert.ElementsMatch(t, []string{
+		"MOTION TO SUPPRESS EVIDENCE",
+		"ORDER GRANTING MOTION TO CONTINUE",
+		"OPENING BRIEF OF APPELLANT",
+	}, classifierSvc.texts)
+}
+
+func TestStartBatchClassificationUpload_Validation(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Processing.MaxFileSize = 10
+	h := NewBatchHandler(cfg, nil, newMockStorageService(), newMockSearchService(), &stubClassifier{}, nil)
+	app := fiber.New()
+	app.Post("/batch/classify-upload", h.StartBatchClassificationUpload)
+
+	post := func(fileContent, options string) int {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		if fileContent != "" {
+			part, err := writer.CreateFormFile("files", "motion.txt")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(fileContent))
+			require.NoError(t, err)
+		}
+		if options != "" {
+			require.NoError(t, writer.WriteField("options", options))
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest("POST", "/batch/classify-upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusBadRequest, post("", ""), "no files")
+	assert.Equal(t, fiber.StatusBadRequest, post("MOTION TO SUPPRESS EVIDENCE", ""), "file over the size limit")
+	assert.Equal(t, fiber.StatusBadRequest, post("MOTION", "not json"), "malformed options")
+	assert.Equal(t, fiber.StatusBadRequest, post("MOTION", `{"sample_strategy": "middle"}`), "invalid options")
+}
+
+// occupiedStorage reports every unversioned path as taken, as if an earlier
+// upload had used the same document ID
+type occupiedStorage struct {
+	*invalidatingStorage
+}
+
+func (s *occupiedStorage) Exists(ctx context.Context, path string) (bool, error) {
+	return !strings.Contains(path, "_v2."), nil
+}
+
+func TestStartBatchClassificationUpload_AppliesConflictPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		status      int
+		stored      string
+		invalidated bool
+	}{
+		{policy: "", status: fiber.StatusAccepted, stored: "_v2.txt"},
+		{policy: "skip", status: fiber.StatusAccepted},
+		{policy: "overwrite", status: fiber.StatusAccepted, stored: "/motion.txt", invalidated: true},
+		{policy: "replace", status: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("on_conflict="+tt.policy, func(t *testing.T) {
+			storageSvc := &occupiedStorage{&invalidatingStorage{MockStorageService: newMockStorageService()}}
+			h := NewBatchHandler(testutil.TestConfig(), nil, storageSvc, newMockSearchService(), &stubClassifier{}, extractor.NewService())
+			app := fiber.New()
+			app.Post("/batch/classify-upload", h.StartBatchClassificationUpload)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("files", "motion.txt")
+			require.NoError(t, err)
+			_, err = part.Write([]byte("MOTION TO SUPPRESS EVIDENCE"))
+			require.NoError(t, err)
+			if tt.policy != "" {
+				require.NoError(t, writer.WriteField("on_conflict", tt.policy))
+			}
+			require.NoError(t, writer.Close())
+
+			req := httptest.NewRequest("POST", "/batch/classify-upload", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.status, resp.StatusCode)
+
+			storageSvc.MockStorageService.mu.Lock()
+			var stored []string
+			for path := range storageSvc.objects {
+				stored = append(stored, path)
+			}
+			storageSvc.MockStorageService.mu.Unlock()
+			if tt.stored == "" {
+				assert.Empty(t, stored)
+			} else {
+				require.Len(t, stored, 1)
+				assert.True(t, strings.HasSuffix(stored[0], tt.stored), stored[0])
+			}
+
+			storageSvc.invalidatingStorage.mu.Lock()
+			defer storageSvc.invalidatingStorage.mu.Unlock()
+			if tt.invalidated {
+				assert.Equal(t, stored, storageSvc.invalidated)
+			} else {
+				assert.Empty(t, storageSvc.invalidated)
+			}
+		})
+	}
+}
+
+// sseEvent is one event read from a batch event stream
+type sseEvent struct {
+	name  string
+	event BatchEvent
+}
+
+// readBatchEvents opens a job's event stream, runs whileOpen once the stream
+// is subscribed, and returns the events sent before the server closed it
+func readBatchEvents(t *testing.T, h *BatchHandler, jobID string, whileOpen func()) []sseEvent {
+	t.Helper()
+
+	app := fiber.New()
+	app.Get("/batch/:job_id/events", h.StreamBatchJobEvents)
+
+	if whileOpen != nil {
+		go func() {
+			assert.Eventually(t, func() bool {
+				h.subscribersMutex.Lock()
+				defer h.subscribersMutex.Unlock()
+				return len(h.subscribers[jobID]) > 0
+			}, 5*time.Second, 5*time.Millisecond)
+			whileOpen()
+		}()
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/batch/"+jobID+"/events", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(string(body)), "\n\n") {
+		var e sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				e.name = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				require.NoError(t, json.Unmarshal([]byte(data), &e.event))
+			}
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestStreamBatchJobEvents(t *testing.T) {
+	newJob := func(h *BatchHandler, status string, documents int) string {
+		jobID := fmt.Sprintf("job-%d", time.Now().UnixNano())
+		h.jobs[jobID] = &BatchJob{
+			ID:       jobID,
+			Type:     "classification",
+			Status:   status,
+			Progress: BatchProgress{TotalDocuments: documents},
+		}
+		return jobID
+	}
+
+	t.Run("progress per document then completed", func(t *testing.T) {
+		h := newTestBatchHandler(newMockSearchService())
+		jobID := newJob(h, "queued", 3)
+
+		events := readBatchEvents(t, h, jobID, func() {
+			h.processBatchClassification(jobID, makeBatchDocuments(3))
+		})
+
+		require.NotEmpty(t, events)
+		assert.Equal(t, BatchEventProgress, events[0].name)
+		assert.Equal(t, "queued", events[0].event.Status)
+
+		var processed []int
+		for _, e := range events[:len(events)-1] {
+			assert.Equal(t, BatchEventProgress, e.name)
+			assert.Equal(t, jobID, e.event.JobID)
+			if e.event.Status == "running" && e.event.Progress.ProcessedCount > 0 {
+				processed = append(processed, e.event.Progress.ProcessedCount)
+			}
+		}
+		assert.Equal(t, []int{1, 2, 3}, processed)
+
+		last := events[len(events)-1]
+		assert.Equal(t, "completed", last.name)
+		assert.Equal(t, "completed", last.event.Status)
+		assert.Equal(t, 3, last.event.Progress.SuccessCount)
+		assert.Equal(t, 100.0, last.event.Progress.PercentComplete)
+
+		h.subscribersMutex.Lock()
+		assert.Empty(t, h.subscribers, "finished streams are removed")
+		h.subscribersMutex.Unlock()
+	})
+
+	t.Run("cancelled job ends the stream", func(t *testing.T) {
+		h := newTestBatchHandler(newMockSearchService())
+		jobID := newJob(h, "queued", 2)
+		h.waitingJobs = append(h.waitingJobs, &waitingBatchJob{jobID: jobID})
+
+		app := fiber.New()
+		app.Delete("/batch/:job_id", h.CancelBatchJob)
+		events := readBatchEvents(t, h, jobID, func() {
+			resp, err := app.Test(httptest.NewRequest("DELETE", "/batch/"+jobID, nil), -1)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+
+		require.Len(t, events, 2)
+		assert.Equal(t, "cancelled", events[1].name)
+		assert.Equal(t, "cancelled", events[1].event.Status)
+	})
+
+	t.Run("finished job sends its final state", func(t *testing.T) {
+		h := newTestBatchHandler(newMockSearchService())
+		jobID := newJob(h, "failed", 1)
+
+		events := readBatchEvents(t, h, jobID, nil)
+
+		require.Len(t, events, 1)
+		assert.Equal(t, "failed", events[0].name)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		app := fiber.New()
+		app.Get("/batch/:job_id/events", newTestBatchHandler(newMockSearchService()).StreamBatchJobEvents)
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/batch/missing/events", nil), -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
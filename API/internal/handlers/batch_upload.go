@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	internalModels "motion-index-fiber/internal/models"
+	"motion-index-fiber/pkg/storage"
+)
+
+// uploadedBatchFile is one file of a classify-upload request, either a
+// multipart file or an entry of an uploaded zip archive
+type uploadedBatchFile struct {
+	name string
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
+// StartBatchClassificationUpload handles POST /api/batch/classify-upload. It
+// stores each uploaded file, expanding zip archives, then classifies the
+// stored files as one batch job.
+func (h *BatchHandler) StartBatchClassificationUpload(c *fiber.Ctx) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"multipart_error",
+			"Failed to parse multipart form",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+
+	options := map[string]interface{}{}
+	if raw := c.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &options); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				"options must be a JSON object",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+	if err := validateClassificationOptions(options); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+	priority := 0
+	if raw := c.FormValue("priority"); raw != "" {
+		if priority, err = strconv.Atoi(raw); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				"priority must be an integer",
+				map[string]interface{}{"priority": raw},
+			))
+		}
+	}
+
+	files, archives, err := h.collectUploadedFiles(form.File["files"])
+	defer func() {
+		for _, archive := range archives {
+			archive.Close()
+		}
+	}()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+	if len(files) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"missing_files",
+			"No files provided",
+			nil,
+		))
+	}
+
+	warmCDN := c.FormValue("warm_cdn") == "true"
+	onConflict := c.FormValue("on_conflict")
+	if onConflict == "" && h.cfg != nil {
+		onConflict = h.cfg.Storage.OnConflict
+	}
+	policy, err := storage.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
+	jobID := uuid.New().String()
+	documents := make([]BatchDocumentInput, 0, len(files))
+	var overwritten []string
+	for _, file := range files {
+		document, action, err := h.storeUploadedFile(c.Context(), jobID, file, policy, warmCDN)
+		if err != nil {
+			log.Printf("[BATCH-UPLOAD] ❌ Failed to store %s for job %s: %v", file.name, jobID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+				"storage_error",
+				fmt.Sprintf("Failed to store %s", file.name),
+				map[string]interface{}{"error": err.Error(), "stored": len(documents)},
+			))
+		}
+		documents = append(documents, document)
+		if action == storage.ConflictActionOverwritten {
+			overwritten = append(overwritten, document.DocumentPath)
+		}
+	}
+
+	// The CDN would keep serving the replaced files
+	if err := storage.InvalidateCache(c.Context(), h.storage, overwritten); err != nil {
+		log.Printf("[BATCH-UPLOAD] ⚠️ CDN cache invalidation failed for job %s: %v", jobID, err)
+	}
+
+	log.Printf("[BATCH-UPLOAD] 📦 Stored %d files for job %s", len(documents), jobID)
+	return h.submitClassificationJob(c, jobID, documents, options, priority)
+}
+
+// collectUploadedFiles lists the files to classify, replacing each zip archive
+// with the files it contains. The archives it opened are returned, even on
+// error, for the caller to close once the files are stored. Files over the
+// configured size limit, or more files than one job takes, reject the request.
+func (h *BatchHandler) collectUploadedFiles(headers []*multipart.FileHeader) ([]uploadedBatchFile, []io.Closer, error) {
+	var files []uploadedBatchFile
+	var archives []io.Closer
+	for _, header := range headers {
+		header := header
+		if strings.ToLower(filepath.Ext(header.Filename)) == ".zip" {
+			archive, entries, err := zipEntries(header)
+			if err != nil {
+				return nil, archives, fmt.Errorf("failed to read archive %s: %w", header.Filename, err)
+			}
+			archives = append(archives, archive)
+			files = append(files, entries...)
+		} else {
+			files = append(files, uploadedBatchFile{
+				name: header.Filename,
+				size: header.Size,
+				open: func() (io.ReadCloser, error) { return header.Open() },
+			})
+		}
+
+		if len(files) > maxBatchDocuments {
+			return nil, archives, fmt.Errorf("maximum %d documents per batch", maxBatchDocuments)
+		}
+	}
+
+	maxSize := h.maxUploadFileSize()
+	for _, file := range files {
+		if maxSize > 0 && file.size > maxSize {
+			return nil, archives, fmt.Errorf("%s is larger than the %d byte limit", file.name, maxSize)
+		}
+	}
+	return files, archives, nil
+}
+
+// zipEntries opens an uploaded zip archive and lists the files in it,
+// skipping directories and the hidden files archivers add, such as
+// __MACOSX/ and .DS_Store. The archive must stay open while entries are read.
+func zipEntries(header *multipart.FileHeader) (io.Closer, []uploadedBatchFile, error) {
+	archive, err := header.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := zip.NewReader(archive, header.Size)
+	if err != nil {
+		archive.Close()
+		return nil, nil, err
+	}
+
+	var entries []uploadedBatchFile
+	for _, entry := range reader.File {
+		name := entry.Name
+		if entry.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		entry := entry
+		entries = append(entries, uploadedBatchFile{
+			name: path.Base(name),
+			size: int64(entry.UncompressedSize64),
+			open: func() (io.ReadCloser, error) { return entry.Open() },
+		})
+	}
+	return archive, entries, nil
+}
+
+// storeUploadedFile uploads one file under its own document ID, as single
+// uploads are stored, and returns it as a batch document with the
+// storage.ConflictAction* taken under policy. warmCDN primes the CDN cache for
+// the stored file.
+func (h *BatchHandler) storeUploadedFile(parent context.Context, jobID string, file uploadedBatchFile, policy storage.ConflictPolicy, warmCDN bool) (BatchDocumentInput, string, error) {
+	documentID := generateDocumentID(file.name)
+	storagePath := fmt.Sprintf("documents/%s/%s", documentID, storage.SanitizeFileName(file.name))
+
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+	defer cancel()
+
+	storagePath, action, err := storage.ResolveConflict(ctx, h.storage, storagePath, policy)
+	if err != nil {
+		return BatchDocumentInput{}, "", err
+	}
+	document := BatchDocumentInput{
+		DocumentID:   documentID,
+		DocumentPath: storagePath,
+	}
+	if action == storage.ConflictActionSkipped {
+		// Classify the file already stored there
+		return document, action, nil
+	}
+
+	content, err := file.open()
+	if err != nil {
+		return BatchDocumentInput{}, "", err
+	}
+	defer content.Close()
+
+	var reader io.Reader = content
+	if maxSize := h.maxUploadFileSize(); maxSize > 0 {
+		// Zip entries declare their size; never store more than the limit
+		reader = io.LimitReader(content, maxSize)
+	}
+
+	_, err = h.storage.Upload(ctx, storagePath, reader, &storage.UploadMetadata{
+		ContentType:        mime.TypeByExtension(strings.ToLower(filepath.Ext(file.name))),
+		Size:               file.size,
+		FileName:           file.name,
+		ContentDisposition: storage.ContentDisposition("inline", file.name),
+		Tags: map[string]string{
+			"document_id":  documentID,
+			"batch_job_id": jobID,
+		},
+		WarmAfterUpload: warmCDN,
+	})
+	if err != nil {
+		return BatchDocumentInput{}, "", err
+	}
+	return document, action, nil
+}
+
+// maxUploadFileSize returns the configured per-file limit, or 0 for none
+func (h *BatchHandler) maxUploadFileSize() int64 {
+	if h.cfg == nil {
+		return 0
+	}
+	return h.cfg.Processing.MaxFileSize
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+
+	"motion-index-fiber/internal/models"
+)
+
+// embedTokenAudience is the audience of every embed token
+const embedTokenAudience = "file-embed"
+
+// EmbedClaims scope an embed token to one stored file and one embedding origin
+type EmbedClaims struct {
+	Path   string `json:"path"`
+	Origin string `json:"origin"`
+	jwt.RegisteredClaims
+}
+
+// EmbedTokenRequest is the body of POST /api/v1/files/embed-token
+type EmbedTokenRequest struct {
+	Path   string `json:"path"`             // Storage path, with or without the documents/ prefix
+	Origin string `json:"origin,omitempty"` // Defaults to the request's Origin header
+}
+
+// EmbedTokenResponse is a token and the file URL to put in the frame
+type EmbedTokenResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	Path      string    `json:"path"`
+	Origin    string    `json:"origin"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssueEmbedToken handles POST /api/v1/files/embed-token - Issue a short-lived
+// token allowing one origin to frame one file
+func (h *StorageHandler) IssueEmbedToken(c *fiber.Ctx) error {
+	var req EmbedTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_request",
+			"Invalid request body",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	if req.Origin == "" {
+		req.Origin = c.Get("Origin")
+	}
+
+	if err := h.validateDocumentPath(req.Path); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_path",
+			"A valid document path is required",
+			map[string]interface{}{"path": req.Path, "error": err.Error()},
+		))
+	}
+	documentPath := embedPath(req.Path)
+
+	origin, ok := normalizeOrigin(req.Origin)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_origin",
+			"A valid origin such as https://example.org is required",
+			map[string]interface{}{"origin": req.Origin},
+		))
+	}
+	if !h.embedOriginAllowed(origin) {
+		return c.Status(fiber.StatusForbidden).JSON(models.NewErrorResponse(
+			"origin_not_allowed",
+			"Origin is not allowed to embed files",
+			map[string]interface{}{"origin": origin},
+		))
+	}
+
+	exists, err := h.storage.Exists(c.Context(), documentPath)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
+			"storage_error",
+			"Failed to check document existence",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(models.NewErrorResponse(
+			"not_found",
+			"Document not found",
+			map[string]interface{}{"path": documentPath},
+		))
+	}
+
+	expiresAt := time.Now().Add(h.embedTokenTTL())
+	token, err := h.signEmbedToken(documentPath, origin, expiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
+			"token_error",
+			"Failed to issue embed token",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+
+	return c.JSON(models.NewSuccessResponse(EmbedTokenResponse{
+		Token:     token,
+		URL:       fmt.Sprintf("/api/v1/files/%s?embed_token=%s", url.PathEscape(documentPath), url.QueryEscape(token)),
+		Path:      documentPath,
+		Origin:    origin,
+		ExpiresAt: expiresAt.UTC(),
+	}, "Embed token issued successfully"))
+}
+
+// ServeFile handles GET /api/v1/files/* - Serve a document, framable only by
+// the origin named in a valid embed_token. Without a token, files are served
+// for direct use; in production they cannot be framed at all.
+func (h *StorageHandler) ServeFile(c *fiber.Ctx) error {
+	tokenString := c.Query("embed_token")
+	if tokenString == "" {
+		if h.cfg.IsProduction() {
+			if isFrameRequest(c) {
+				return c.Status(fiber.StatusForbidden).JSON(models.NewErrorResponse(
+					"embed_token_required",
+					"Embedding files requires an embed token",
+					nil,
+				))
+			}
+			c.Set("X-Frame-Options", "DENY")
+			c.Set("Content-Security-Policy", "frame-ancestors 'none'")
+		}
+		return h.ServeDocument(c)
+	}
+
+	claims, err := h.parseEmbedToken(tokenString)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(models.NewErrorResponse(
+			"invalid_embed_token",
+			"Embed token is invalid or expired",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+
+	requestedPath, err := url.QueryUnescape(c.Params("*"))
+	if err != nil || claims.Path != embedPath(requestedPath) {
+		return c.Status(fiber.StatusForbidden).JSON(models.NewErrorResponse(
+			"invalid_embed_token",
+			"Embed token was issued for a different file",
+			nil,
+		))
+	}
+
+	// Browsers enforce frame-ancestors; the request's own origin, when sent,
+	// is checked too so a leaked token is refused outright elsewhere
+	if origin := requestOrigin(c); (origin != "" && origin != claims.Origin) || !h.embedOriginAllowed(claims.Origin) {
+		return c.Status(fiber.StatusForbidden).JSON(models.NewErrorResponse(
+			"origin_not_allowed",
+			"Embed token is not valid for this origin",
+			map[string]interface{}{"origin": origin},
+		))
+	}
+
+	c.Set("Content-Security-Policy", "frame-ancestors "+claims.Origin)
+	return h.ServeDocument(c)
+}
+
+// signEmbedToken signs a token letting origin frame the file at documentPath
+func (h *StorageHandler) signEmbedToken(documentPath, origin string, expiresAt time.Time) (string, error) {
+	key, err := h.embedSigningKey()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, EmbedClaims{
+		Path:   documentPath,
+		Origin: origin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{embedTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	return token.SignedString(key)
+}
+
+// parseEmbedToken verifies an embed token's signature, audience and expiry
+func (h *StorageHandler) parseEmbedToken(tokenString string) (*EmbedClaims, error) {
+	key, err := h.embedSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(embedTokenAudience),
+		jwt.WithExpirationRequired(),
+	)
+	claims := &EmbedClaims{}
+	if _, err := parser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	}); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// embedSigningKey derives the embed token key from JWT_SECRET. Embed tokens are
+// handed to browsers, so they are signed with a different key than login
+// tokens and cannot pass the JWT middleware.
+func (h *StorageHandler) embedSigningKey() ([]byte, error) {
+	if h.cfg == nil || h.cfg.Auth.JWTSecret == "" {
+		return nil, errors.New("JWT_SECRET is not configured")
+	}
+	mac := hmac.New(sha256.New, []byte(h.cfg.Auth.JWTSecret))
+	mac.Write([]byte(embedTokenAudience))
+	return mac.Sum(nil), nil
+}
+
+// embedTokenTTL returns how long issued embed tokens stay valid
+func (h *StorageHandler) embedTokenTTL() time.Duration {
+	if h.cfg != nil && h.cfg.Server.EmbedTokenTTL > 0 {
+		return h.cfg.Server.EmbedTokenTTL
+	}
+	return 15 * time.Minute
+}
+
+// embedOriginAllowed reports whether origin is in EMBED_ALLOWED_ORIGINS
+func (h *StorageHandler) embedOriginAllowed(origin string) bool {
+	if h.cfg == nil {
+		return false
+	}
+	for _, allowed := range strings.Split(h.cfg.Server.EmbedOrigins, ",") {
+		if normalized, ok := normalizeOrigin(strings.TrimSpace(allowed)); ok && normalized == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// embedPath returns the storage key of a document path, as ServeDocument
+// resolves it
+func embedPath(path string) string {
+	if !strings.HasPrefix(path, "documents/") {
+		path = "documents/" + path
+	}
+	return path
+}
+
+// normalizeOrigin reduces an origin or URL to its lower-case scheme://host[:port]
+func normalizeOrigin(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), true
+}
+
+// requestOrigin returns the origin a request came from, from the Origin
+// header or else the Referer, or "" when neither is sent
+func requestOrigin(c *fiber.Ctx) string {
+	for _, header := range []string{"Origin", "Referer"} {
+		if origin, ok := normalizeOrigin(c.Get(header)); ok {
+			return origin
+		}
+	}
+	return ""
+}
+
+// isFrameRequest reports whether the browser is loading the request into a
+// frame, embed or object element
+func isFrameRequest(c *fiber.Ctx) bool {
+	switch c.Get("Sec-Fetch-Dest") {
+	case "iframe", "frame", "embed", "object":
+		return true
+	}
+	return false
+}
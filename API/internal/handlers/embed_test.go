@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/testutil"
+)
+
+const (
+	embedTestOrigin = "https://app.example.org"
+	embedTestPath   = "documents/2024/motion.pdf"
+)
+
+// newEmbedTestApp serves one stored PDF through the files routes, with
+// embedTestOrigin allowed to embed it
+func newEmbedTestApp(t *testing.T, production bool) (*fiber.App, *StorageHandler) {
+	t.Helper()
+	storageSvc := newMockStorageService()
+	_, err := storageSvc.Upload(context.Background(), embedTestPath, strings.NewReader("%PDF-1.4 motion"), nil)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := storageSvc.Download(r.Context(), strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer reader.Close()
+		io.Copy(w, reader)
+	}))
+	t.Cleanup(server.Close)
+	storageSvc.baseURL = server.URL
+
+	cfg := testutil.TestConfig()
+	cfg.Server.Production = production
+	cfg.Server.EmbedOrigins = "http://localhost:5173, " + embedTestOrigin + "/"
+	cfg.Server.EmbedTokenTTL = time.Minute
+
+	h := NewStorageHandler(cfg, storageSvc)
+	app := fiber.New()
+	app.Post("/api/v1/files/embed-token", h.IssueEmbedToken)
+	app.Get("/api/v1/files/*", h.ServeFile)
+	return app, h
+}
+
+// issueEmbedToken requests a token for path from origin
+func issueEmbedToken(t *testing.T, app *fiber.App, path, origin string) (int, EmbedTokenResponse) {
+	t.Helper()
+	body, err := json.Marshal(EmbedTokenRequest{Path: path})
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/api/v1/files/embed-token", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", origin)
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Data EmbedTokenResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded.Data
+}
+
+// getEmbedded loads target the way a browser loads a frame on origin's page
+func getEmbedded(t *testing.T, app *fiber.App, target, origin string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("Sec-Fetch-Dest", "iframe")
+	if origin != "" {
+		req.Header.Set("Referer", origin+"/cases/42")
+	}
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServeFile_ValidEmbedToken(t *testing.T) {
+	app, _ := newEmbedTestApp(t, true)
+
+	status, issued := issueEmbedToken(t, app, "2024/motion.pdf", embedTestOrigin)
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, embedTestPath, issued.Path)
+	assert.Equal(t, embedTestOrigin, issued.Origin)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), issued.ExpiresAt, 5*time.Second)
+
+	resp := getEmbedded(t, app, issued.URL, embedTestOrigin)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "frame-ancestors "+embedTestOrigin, resp.Header.Get("Content-Security-Policy"))
+	assert.Empty(t, resp.Header.Get("X-Frame-Options"))
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4 motion", string(content))
+}
+
+func TestServeFile_ExpiredEmbedToken(t *testing.T) {
+	app, h := newEmbedTestApp(t, true)
+
+	token, err := h.signEmbedToken(embedTestPath, embedTestOrigin, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	resp := getEmbedded(t, app, "/api/v1/files/"+url.PathEscape(embedTestPath)+"?embed_token="+token, embedTestOrigin)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+}
+
+func TestServeFile_WrongOriginEmbedToken(t *testing.T) {
+	app, h := newEmbedTestApp(t, true)
+
+	// Origins outside EMBED_ALLOWED_ORIGINS get no token
+	status, _ := issueEmbedToken(t, app, embedTestPath, "https://evil.example.com")
+	assert.Equal(t, fiber.StatusForbidden, status)
+
+	status, issued := issueEmbedToken(t, app, embedTestPath, embedTestOrigin)
+	require.Equal(t, fiber.StatusOK, status)
+
+	// A token used from another page is refused
+	resp := getEmbedded(t, app, issued.URL, "https://evil.example.com")
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// So is a token for another file
+	otherPath := "documents/2024/other.pdf"
+	_, err := h.storage.Upload(context.Background(), otherPath, strings.NewReader("%PDF-1.4 other"), nil)
+	require.NoError(t, err)
+	resp = getEmbedded(t, app, "/api/v1/files/"+url.PathEscape(otherPath)+"?embed_token="+url.QueryEscape(issued.Token), embedTestOrigin)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// A login token signed with JWT_SECRET is not an embed token
+	login := jwt.NewWithClaims(jwt.SigningMethodHS256, EmbedClaims{
+		Path:   embedTestPath,
+		Origin: embedTestOrigin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{embedTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	forged, err := login.SignedString([]byte(h.cfg.Auth.JWTSecret))
+	require.NoError(t, err)
+	resp = getEmbedded(t, app, "/api/v1/files/"+url.PathEscape(embedTestPath)+"?embed_token="+forged, embedTestOrigin)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestServeFile_WithoutEmbedToken(t *testing.T) {
+	app, _ := newEmbedTestApp(t, true)
+	target := "/api/v1/files/" + url.PathEscape(embedTestPath) + "?proxy=true"
+
+	// Production refuses to be framed without a token
+	resp := getEmbedded(t, app, target, embedTestOrigin)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// Direct requests are still served, with framing denied
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "frame-ancestors 'none'", resp.Header.Get("Content-Security-Policy"))
+
+	// Development keeps unrestricted embedding
+	app, _ = newEmbedTestApp(t, false)
+	resp = getEmbedded(t, app, target, embedTestOrigin)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+}
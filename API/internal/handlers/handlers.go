@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"motion-index-fiber/internal/config"
+	"motion-index-fiber/internal/middleware"
 	"motion-index-fiber/pkg/cloud/digitalocean"
 	"motion-index-fiber/pkg/processing"
 	"motion-index-fiber/pkg/processing/classifier"
 	"motion-index-fiber/pkg/processing/extractor"
 	"motion-index-fiber/pkg/processing/pipeline"
 	"motion-index-fiber/pkg/processing/queue"
+	"motion-index-fiber/pkg/search"
 )
 
 type Handlers struct {
@@ -22,6 +25,10 @@ type Handlers struct {
 	Batch        *BatchHandler
 	Indexing     *IndexingHandler
 	queueManager queue.QueueManager
+
+	// deferredIndexer retries uploads refused by a write-blocked index; nil
+	// when DEFER_BLOCKED_INDEXING is off
+	deferredIndexer *pipeline.DeferredIndexer
 }
 
 func New(cfg *config.Config) (*Handlers, error) {
@@ -44,15 +51,72 @@ func New(cfg *config.Config) (*Handlers, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create search service: %w", err)
 	}
+	if configurer, ok := searchService.(search.BulkRetryConfigurer); ok {
+		configurer.SetBulkRetryPolicy(search.BulkRetryPolicy{
+			MaxAttempts: cfg.Search.BulkMaxAttempts,
+			Backoff:     cfg.Search.BulkRetryBackoff,
+		})
+	}
 
 	// Initialize text extraction service
-	extractorService := extractor.NewService()
+	fallbackChains, err := extractor.ParseFallbackChains(cfg.Processing.ExtractorFallbacks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXTRACTOR_FALLBACKS: %w", err)
+	}
+	extractorService := extractor.NewServiceWithOptions(extractor.ExtractOptions{
+		EnableOCR:             cfg.Processing.EnableOCR,
+		OCRMinTextLength:      cfg.Processing.OCRMinTextLength,
+		OCRLanguage:           cfg.Processing.OCRLanguage,
+		OCRMaxPages:           cfg.Processing.OCRMaxPages,
+		FallbackChains:        fallbackChains,
+		FallbackMinTextLength: cfg.Processing.FallbackMinTextLength,
+	})
 
 	// Initialize classification service with fallback support
-	classifierService, err := createClassificationService(cfg)
+	baseClassifier, err := createClassificationService(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create classification service: %w", err)
 	}
+	classifierService := classifier.NewMeteredService(
+		baseClassifier,
+		classifier.NewUsageMeter(cfg.AI.PromptPricePer1K, cfg.AI.CompletionPricePer1K),
+	)
+
+	fieldMapping, err := pipeline.ParseFieldMapping(cfg.Processing.FieldMapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLASSIFIER_FIELD_MAPPING: %w", err)
+	}
+
+	profiles, err := pipeline.ParseProfiles(cfg.Processing.Profiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROCESSING_PROFILES: %w", err)
+	}
+
+	requiredFields, err := pipeline.ParseRequiredFields(cfg.Processing.RequiredMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUIRED_METADATA_FIELDS: %w", err)
+	}
+
+	allowedContentTypes, err := pipeline.ParseContentTypes(cfg.Processing.AllowedContentTypes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ALLOWED_CONTENT_TYPES: %w", err)
+	}
+
+	metadataSchemas, err := pipeline.ParseMetadataSchemas(cfg.Processing.MetadataSchemas)
+	if err != nil {
+		return nil, fmt.Errorf("invalid METADATA_SCHEMAS: %w", err)
+	}
+
+	confidenceThresholds, err := pipeline.ParseConfidenceThresholds(cfg.Processing.ConfidenceThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CLASSIFICATION_CONFIDENCE_THRESHOLDS: %w", err)
+	}
+
+	var deferredIndexer *pipeline.DeferredIndexer
+	if cfg.Processing.DeferBlockedIndexing {
+		deferredIndexer = pipeline.NewDeferredIndexer(searchService,
+			cfg.Processing.DeferredIndexRetryInterval, cfg.Processing.DeferredIndexQueueSize)
+	}
 
 	// Initialize processing pipeline
 	pipelineConfig := &pipeline.Config{
@@ -62,6 +126,22 @@ func New(cfg *config.Config) (*Handlers, error) {
 		RetryAttempts:  3,
 		RetryDelay:     1 * time.Second,
 		EnableMetrics:  true,
+
+		MinExtractionQuality:   cfg.Processing.MinExtractionQuality,
+		MinIndexableTextLength: cfg.Processing.MinIndexableTextLength,
+
+		FieldMapping: fieldMapping,
+		Profiles:     profiles,
+
+		RequiredFields:       requiredFields,
+		ConfidenceThresholds: confidenceThresholds,
+		DeferredIndexer:      deferredIndexer,
+
+		SkipDuplicates: cfg.Processing.SkipDuplicates,
+		IndexFileURLs:  cfg.Storage.IndexFileURLs,
+
+		MaxFileSize:         cfg.Processing.MaxFileSize,
+		AllowedContentTypes: allowedContentTypes,
 	}
 
 	processingPipeline, err := pipeline.NewPipeline(
@@ -92,10 +172,11 @@ func New(cfg *config.Config) (*Handlers, error) {
 
 	// Create indexing processor
 	indexingProcessorConfig := &processing.IndexingProcessorConfig{
-		APIBaseURL:     "http://localhost:6000", // TODO: Make this configurable
+		APIBaseURL:     "http://localhost:" + cfg.Server.Port,
 		RequestTimeout: 30 * time.Second,
 		MaxRetries:     3,
 		RetryDelay:     5 * time.Second,
+		AuthToken:      indexingQueueToken(cfg.Auth.JWTSecret),
 	}
 	indexingProcessor := processing.NewIndexingProcessor(indexingProcessorConfig)
 
@@ -105,22 +186,50 @@ func New(cfg *config.Config) (*Handlers, error) {
 		return nil, fmt.Errorf("failed to create indexing queue: %w", err)
 	}
 
+	searchHandler := NewSearchHandler(cfg, searchService)
+	searchHandler.storage = storageService
+	searchHandler.schemas = metadataSchemas
+	searchHandler.required = requiredFields
+
+	processingHandler := NewProcessingHandler(cfg, processingPipeline, storageService, searchService)
+	processingHandler.required = requiredFields
+	processingHandler.queueManager = queueManager
+
 	return &Handlers{
-		Health:       NewHealthHandler(storageService, searchService),
-		Processing:   NewProcessingHandler(cfg, processingPipeline, storageService, searchService),
-		Search:       NewSearchHandler(searchService),
+		Health:       NewHealthHandler(storageService, searchService, classifierService),
+		Processing:   processingHandler,
+		Search:       searchHandler,
 		Storage:      NewStorageHandler(cfg, storageService),
-		Batch:        NewBatchHandler(queueManager, storageService, searchService, classifierService, extractorService),
+		Batch:        NewBatchHandler(cfg, queueManager, storageService, searchService, classifierService, extractorService),
 		Indexing:     NewIndexingHandler(searchService),
 		queueManager: queueManager,
+
+		deferredIndexer: deferredIndexer,
 	}, nil
 }
 
+// indexingQueueToken returns a source of short-lived JWTs for the indexing
+// queue's calls to the index endpoint, which requires authentication
+func indexingQueueToken(secret string) func() (string, error) {
+	return func() (string, error) {
+		claims := middleware.UserClaims{
+			UserID: "indexing-queue",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			},
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	}
+}
+
 // StartQueueProcessing starts the queue manager and all worker pools
 func (h *Handlers) StartQueueProcessing(ctx context.Context) error {
 	if h.queueManager == nil {
 		return fmt.Errorf("queue manager not initialized")
 	}
+	if h.deferredIndexer != nil {
+		h.deferredIndexer.Start(ctx)
+	}
 	return h.queueManager.Start(ctx)
 }
 
@@ -137,7 +246,11 @@ func (h *Handlers) GetQueueStats() map[string]*queue.QueueStats {
 	if h.queueManager == nil {
 		return nil
 	}
-	return h.queueManager.GetAllStats()
+	stats := h.queueManager.GetAllStats()
+	if h.deferredIndexer != nil {
+		stats["deferred_indexing"] = h.deferredIndexer.Stats()
+	}
+	return stats
 }
 
 // IsQueueHealthy returns true if all queues are healthy
@@ -150,6 +263,11 @@ func (h *Handlers) IsQueueHealthy() bool {
 
 // createClassificationService creates a classification service with fallback support
 func createClassificationService(cfg *config.Config) (classifier.Service, error) {
+	examples, err := loadClassificationExamples(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if fallback is enabled and we have multiple providers configured
 	if cfg.AI.EnableFallback && (cfg.AI.Claude.APIKey != "" || cfg.AI.Ollama.BaseURL != "") {
 		// Create fallback classifier
@@ -162,47 +280,58 @@ func createClassificationService(cfg *config.Config) (classifier.Service, error)
 		// Configure Ollama (primary - cost-effective local model)
 		if cfg.AI.Ollama.BaseURL != "" {
 			fallbackConfig.Ollama = &classifier.OllamaConfig{
-				BaseURL: cfg.AI.Ollama.BaseURL,
-				Model:   cfg.AI.Ollama.Model,
-				Timeout: cfg.AI.Ollama.Timeout,
+				BaseURL:  cfg.AI.Ollama.BaseURL,
+				Model:    cfg.AI.Ollama.Model,
+				Timeout:  cfg.AI.Ollama.Timeout,
+				Examples: examples,
 			}
 		}
 		
 		// Configure OpenAI (fallback only)
 		if cfg.AI.OpenAI.APIKey != "" {
 			fallbackConfig.OpenAI = &classifier.Config{
-				Provider:   "openai",
-				APIKey:     cfg.AI.OpenAI.APIKey,
-				Model:      cfg.AI.OpenAI.Model,
-				MaxRetries: 3,
-				Timeout:    30 * time.Second,
+				Provider:         "openai",
+				APIKey:           cfg.AI.OpenAI.APIKey,
+				Model:            cfg.AI.OpenAI.Model,
+				MaxRetries:       cfg.AI.RequestRetries,
+				Timeout:          30 * time.Second,
+				Breaker:          openAIBreakerConfig(cfg),
+				RateLimitRetries: cfg.AI.RateLimitRetries,
+				MaxRateLimitWait: cfg.AI.MaxRateLimitWait,
+				Examples:         examples,
 			}
 		} else if cfg.OpenAI.APIKey != "" {
 			// Backward compatibility
 			fallbackConfig.OpenAI = &classifier.Config{
-				Provider:   "openai",
-				APIKey:     cfg.OpenAI.APIKey,
-				Model:      cfg.OpenAI.Model,
-				MaxRetries: 3,
-				Timeout:    30 * time.Second,
+				Provider:         "openai",
+				APIKey:           cfg.OpenAI.APIKey,
+				Model:            cfg.OpenAI.Model,
+				MaxRetries:       cfg.AI.RequestRetries,
+				Timeout:          30 * time.Second,
+				Breaker:          openAIBreakerConfig(cfg),
+				RateLimitRetries: cfg.AI.RateLimitRetries,
+				MaxRateLimitWait: cfg.AI.MaxRateLimitWait,
+				Examples:         examples,
 			}
 		}
 
 		// Configure Claude (first fallback)
 		if cfg.AI.Claude.APIKey != "" {
 			fallbackConfig.Claude = &classifier.ClaudeConfig{
-				APIKey:  cfg.AI.Claude.APIKey,
-				Model:   cfg.AI.Claude.Model,
-				BaseURL: cfg.AI.Claude.BaseURL,
+				APIKey:   cfg.AI.Claude.APIKey,
+				Model:    cfg.AI.Claude.Model,
+				BaseURL:  cfg.AI.Claude.BaseURL,
+				Examples: examples,
 			}
 		}
 
 		// Configure Ollama (local fallback)
 		if cfg.AI.Ollama.BaseURL != "" {
 			fallbackConfig.Ollama = &classifier.OllamaConfig{
-				BaseURL: cfg.AI.Ollama.BaseURL,
-				Model:   cfg.AI.Ollama.Model,
-				Timeout: cfg.AI.Ollama.Timeout,
+				BaseURL:  cfg.AI.Ollama.BaseURL,
+				Model:    cfg.AI.Ollama.Model,
+				Timeout:  cfg.AI.Ollama.Timeout,
+				Examples: examples,
 			}
 		}
 
@@ -220,9 +349,10 @@ func createClassificationService(cfg *config.Config) (classifier.Service, error)
 	if cfg.AI.Ollama.BaseURL != "" {
 		// Use Ollama as single provider (cost-effective local model)
 		ollamaConfig := &classifier.OllamaConfig{
-			BaseURL: cfg.AI.Ollama.BaseURL,
-			Model:   cfg.AI.Ollama.Model,
-			Timeout: cfg.AI.Ollama.Timeout,
+			BaseURL:  cfg.AI.Ollama.BaseURL,
+			Model:    cfg.AI.Ollama.Model,
+			Timeout:  cfg.AI.Ollama.Timeout,
+			Examples: examples,
 		}
 		
 		ollamaClassifier, err := classifier.NewOllamaClassifier(ollamaConfig)
@@ -246,13 +376,34 @@ func createClassificationService(cfg *config.Config) (classifier.Service, error)
 	}
 
 	classifierConfig := &classifier.Config{
-		Provider:   "openai",
-		APIKey:     primaryAPIKey,
-		Model:      primaryModel,
-		MaxRetries: 3,
-		Timeout:    30 * time.Second,
+		Provider:         "openai",
+		APIKey:           primaryAPIKey,
+		Model:            primaryModel,
+		MaxRetries:       cfg.AI.RequestRetries,
+		Timeout:          30 * time.Second,
+		Breaker:          openAIBreakerConfig(cfg),
+		RateLimitRetries: cfg.AI.RateLimitRetries,
+		MaxRateLimitWait: cfg.AI.MaxRateLimitWait,
+		Examples:         examples,
 	}
 
 	return classifier.NewService(classifierConfig)
 }
 
+// loadClassificationExamples reads the configured few-shot examples, if any
+func loadClassificationExamples(cfg *config.Config) ([]classifier.FewShotExample, error) {
+	if cfg.AI.ExamplesFile == "" {
+		return nil, nil
+	}
+
+	return classifier.LoadFewShotExamples(cfg.AI.ExamplesFile, cfg.AI.MaxExamples)
+}
+
+// openAIBreakerConfig builds the OpenAI circuit breaker settings from AI config
+func openAIBreakerConfig(cfg *config.Config) *classifier.BreakerConfig {
+	return &classifier.BreakerConfig{
+		FailureThreshold: cfg.AI.BreakerThreshold,
+		Cooldown:         cfg.AI.BreakerCooldown,
+	}
+}
+
@@ -4,25 +4,29 @@ import (
 	"context"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"motion-index-fiber/internal/models"
+	"motion-index-fiber/pkg/processing/classifier"
 	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/storage"
 )
 
 // HealthHandler handles health check and status endpoints
 type HealthHandler struct {
-	storage   storage.Service
-	searchSvc search.Service
+	storage    storage.Service
+	searchSvc  search.Service
+	classifier classifier.Service
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(storage storage.Service, searchSvc search.Service) *HealthHandler {
+func NewHealthHandler(storage storage.Service, searchSvc search.Service, classifierSvc classifier.Service) *HealthHandler {
 	return &HealthHandler{
-		storage:   storage,
-		searchSvc: searchSvc,
+		storage:    storage,
+		searchSvc:  searchSvc,
+		classifier: classifierSvc,
 	}
 }
 
@@ -38,16 +42,79 @@ func (h *HealthHandler) Root(c *fiber.Ctx) error {
 	return c.JSON(models.NewSuccessResponse(response, "Motion Index API is running"))
 }
 
-// Health returns basic health status
+// Health returns the health of the service and each of its dependencies:
+// search, storage, the CDN and the classifier. Any dependency that is not
+// healthy degrades the service; an unhealthy search or storage dependency
+// also makes it unavailable.
 func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	checks := h.checkDependencies()
+
 	response := &models.HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
 		Version:   "1.0.0",
 		Service:   "motion-index-fiber",
+		Checks:    checks,
 	}
 
-	return c.JSON(models.NewSuccessResponse(response, "Service is healthy"))
+	httpStatus := fiber.StatusOK
+	for _, check := range checks {
+		if check.Status == "not_configured" || check.Status == "healthy" {
+			continue
+		}
+		response.Status = "degraded"
+		if check.Critical && check.Status == "unhealthy" {
+			httpStatus = fiber.StatusServiceUnavailable
+		}
+	}
+
+	message := "Service is healthy"
+	if response.Status != "healthy" {
+		message = "Service is degraded"
+	}
+	return c.Status(httpStatus).JSON(models.NewSuccessResponse(response, message))
+}
+
+// checkDependencies checks each dependency at once, timing every check
+func (h *HealthHandler) checkDependencies() map[string]*models.DependencyCheck {
+	probes := []struct {
+		name     string
+		critical bool
+		check    func() *models.ComponentStatus
+	}{
+		{"search", true, h.getSearchStatus},
+		{"storage", true, h.getStorageStatus},
+		{"cdn", false, h.getCDNStatus},
+		{"classifier", false, h.getClassifierStatus},
+	}
+
+	checks := make(map[string]*models.DependencyCheck, len(probes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			status := probe.check()
+			check := &models.DependencyCheck{
+				Status:    "not_configured",
+				Critical:  probe.critical,
+				LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+			}
+			if status != nil {
+				check.Status = status.Status
+				check.Error = status.Error
+				check.Details = status.Details
+			}
+
+			mu.Lock()
+			checks[probe.name] = check
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return checks
 }
 
 // HealthCheck returns basic health status
@@ -65,17 +132,19 @@ func (h *HealthHandler) HealthCheck(c *fiber.Ctx) error {
 // DetailedStatus returns comprehensive system status
 func (h *HealthHandler) DetailedStatus(c *fiber.Ctx) error {
 	status := &models.SystemStatus{
-		Service:   "motion-index-fiber",
-		Version:   "1.0.0",
-		Status:    "healthy",
-		Timestamp: time.Now(),
-		Uptime:    getUptime(),
-		System:    getSystemInfo(),
-		Storage:   h.getStorageStatus(),
-		Indexer:   h.getSearchStatus(),
+		Service:    "motion-index-fiber",
+		Version:    "1.0.0",
+		Status:     "healthy",
+		Timestamp:  time.Now(),
+		Uptime:     getUptime(),
+		System:     getSystemInfo(),
+		Storage:    h.getStorageStatus(),
+		Indexer:    h.getSearchStatus(),
+		Classifier: h.getClassifierStatus(),
 	}
 
-	// Overall health determination
+	// Overall health determination. An open classifier circuit is reported on the
+	// component but does not fail the instance - uploads and search still work.
 	if status.Storage.Status != "healthy" || status.Indexer.Status != "healthy" {
 		status.Status = "degraded"
 	}
@@ -134,6 +203,7 @@ func (h *HealthHandler) Metrics(c *fiber.Ctx) error {
 		GC:         getGCStats(),
 		Storage:    h.getStorageMetrics(),
 		Indexer:    h.getSearchMetrics(),
+		Classifier: h.getClassifierMetrics(),
 	}
 
 	return c.JSON(models.NewSuccessResponse(metrics, "Application metrics"))
@@ -216,6 +286,88 @@ func (h *HealthHandler) getSearchStatus() *models.ComponentStatus {
 	return status
 }
 
+// getCDNStatus reports the health of the CDN in front of storage, or nil
+// when storage has no CDN
+func (h *HealthHandler) getCDNStatus() *models.ComponentStatus {
+	reporter, ok := h.storage.(storage.CDNHealthReporter)
+	if !ok {
+		return nil
+	}
+	health := reporter.GetCDNHealthStatus()
+	if configured, _ := health["cdn_configured"].(bool); !configured {
+		return nil
+	}
+
+	status := &models.ComponentStatus{
+		Name:      "cdn",
+		Status:    "healthy",
+		Timestamp: time.Now(),
+		Details:   health,
+	}
+	if open, _ := health["circuit_breaker_open"].(bool); open {
+		status.Status = "unhealthy"
+		status.Error = "CDN circuit breaker is open; files are served from storage"
+	} else if healthy, _ := health["is_healthy"].(bool); !healthy {
+		status.Status = "unhealthy"
+		status.Error = "CDN is not healthy"
+	}
+	return status
+}
+
+// getClassifierStatus reports classifier health, including any open circuit breakers
+func (h *HealthHandler) getClassifierStatus() *models.ComponentStatus {
+	if h.classifier == nil {
+		return nil
+	}
+
+	status := &models.ComponentStatus{
+		Name:      "classifier",
+		Status:    "healthy",
+		Timestamp: time.Now(),
+	}
+
+	if !h.classifier.IsHealthy() {
+		status.Status = "unhealthy"
+		status.Error = "classifier is not configured"
+		status.LastError = time.Now()
+		return status
+	}
+
+	if breakers := h.classifierBreakers(); len(breakers) > 0 {
+		status.Details = map[string]interface{}{"circuit_breakers": breakers}
+		for _, breaker := range breakers {
+			if breaker.State != classifier.BreakerStateClosed {
+				status.Status = "degraded"
+				status.Error = breaker.Name + " circuit breaker is " + string(breaker.State)
+				if breaker.OpenedAt != nil {
+					status.LastError = *breaker.OpenedAt
+				}
+			}
+		}
+	}
+
+	return status
+}
+
+// getClassifierMetrics returns classifier circuit breaker and token usage metrics
+func (h *HealthHandler) getClassifierMetrics() map[string]interface{} {
+	metrics := make(map[string]interface{})
+	if breakers := h.classifierBreakers(); len(breakers) > 0 {
+		metrics["circuit_breakers"] = breakers
+	}
+	if reporter, ok := h.classifier.(classifier.UsageReporter); ok {
+		metrics["token_usage"] = reporter.Usage()
+	}
+	return metrics
+}
+
+func (h *HealthHandler) classifierBreakers() []*classifier.BreakerStatus {
+	if reporter, ok := h.classifier.(classifier.BreakerReporter); ok {
+		return reporter.BreakerStatuses()
+	}
+	return nil
+}
+
 // getMemoryStats returns current memory statistics
 func getMemoryStats() *models.MemoryInfo {
 	var m runtime.MemStats
@@ -1,9 +1,20 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/models"
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/search"
+	"motion-index-fiber/pkg/storage"
 )
 
 func TestHealthHandlerExists(t *testing.T) {
@@ -13,4 +24,92 @@ func TestHealthHandlerExists(t *testing.T) {
 
 // TODO: Reimplement health handler tests with proper service interfaces
 // The original tests need to be updated to work with the new service
-// interfaces and mock implementations.
\ No newline at end of file
+// interfaces and mock implementations.
+
+// cdnStorage is storage behind a CDN that reports the CDN's health
+type cdnStorage struct {
+	*MockStorageService
+	health map[string]interface{}
+}
+
+func (s *cdnStorage) GetCDNHealthStatus() map[string]interface{} {
+	return s.health
+}
+
+func TestHealth_DependencyChecks(t *testing.T) {
+	check := func(t *testing.T, store storage.Service, searchSvc search.Service, classifierSvc classifier.Service) (int, *models.HealthResponse) {
+		app := fiber.New()
+		app.Get("/health", NewHealthHandler(store, searchSvc, classifierSvc).Health)
+		resp, err := app.Test(httptest.NewRequest("GET", "/health", nil), -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body struct {
+			Data *models.HealthResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.NotNil(t, body.Data)
+		return resp.StatusCode, body.Data
+	}
+	healthyCDN := func() *cdnStorage {
+		return &cdnStorage{newMockStorageService(), map[string]interface{}{"cdn_configured": true, "is_healthy": true}}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		code, health := check(t, healthyCDN(), newMockSearchService(), &stubClassifier{})
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Equal(t, "healthy", health.Status)
+		require.Len(t, health.Checks, 4)
+		for name, dependency := range health.Checks {
+			assert.Equal(t, "healthy", dependency.Status, name)
+			assert.GreaterOrEqual(t, dependency.LatencyMS, 0.0, name)
+		}
+		assert.True(t, health.Checks["search"].Critical)
+		assert.True(t, health.Checks["storage"].Critical)
+		assert.False(t, health.Checks["cdn"].Critical)
+	})
+
+	t.Run("without CDN or classifier", func(t *testing.T) {
+		code, health := check(t, newMockStorageService(), newMockSearchService(), nil)
+		assert.Equal(t, fiber.StatusOK, code)
+		assert.Equal(t, "healthy", health.Status)
+		assert.Equal(t, "not_configured", health.Checks["cdn"].Status)
+		assert.Equal(t, "not_configured", health.Checks["classifier"].Status)
+	})
+
+	t.Run("critical dependency down", func(t *testing.T) {
+		searchSvc := newMockSearchService()
+		searchSvc.healthy = false
+		code, health := check(t, healthyCDN(), searchSvc, &stubClassifier{})
+		assert.Equal(t, fiber.StatusServiceUnavailable, code)
+		assert.Equal(t, "degraded", health.Status)
+		assert.Equal(t, "unhealthy", health.Checks["search"].Status)
+		assert.NotEmpty(t, health.Checks["search"].Error)
+	})
+
+	t.Run("optional dependencies down", func(t *testing.T) {
+		store := healthyCDN()
+		store.health["circuit_breaker_open"] = true
+		code, health := check(t, store, newMockSearchService(), &stubClassifier{err: errors.New("unreachable")})
+		assert.Equal(t, fiber.StatusOK, code, "search and storage still work")
+		assert.Equal(t, "degraded", health.Status)
+		assert.Equal(t, "unhealthy", health.Checks["cdn"].Status)
+		assert.Equal(t, "unhealthy", health.Checks["classifier"].Status)
+	})
+}
+
+func TestHealth_SpacesCDNStatus(t *testing.T) {
+	cfg := testutil.TestConfig()
+	spaces, err := storage.NewSpacesService(cfg)
+	require.NoError(t, err)
+	assert.Nil(t, NewHealthHandler(spaces, nil, nil).getCDNStatus(), "no CDN domain configured")
+
+	cfg.Storage.CDNDomain = "cdn.example.com"
+	spaces, err = storage.NewSpacesService(cfg)
+	require.NoError(t, err)
+	status := NewHealthHandler(spaces, nil, nil).getCDNStatus()
+	require.NotNil(t, status)
+	assert.Equal(t, "healthy", status.Status)
+	assert.Equal(t, "cdn.example.com", status.Details["cdn_domain"])
+	assert.Equal(t, false, status.Details["circuit_breaker_open"])
+}
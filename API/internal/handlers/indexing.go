@@ -14,6 +14,7 @@ import (
 
 	internalModels "motion-index-fiber/internal/models"
 	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
 	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/models"
 )
@@ -111,8 +112,180 @@ func (h *IndexingHandler) validateIndexRequest(req *internalModels.IndexDocument
 	return nil
 }
 
+// MappingValidationResponse is the document a classification result maps
+// to and how the live index mapping would take it
+type MappingValidationResponse struct {
+	Document *models.Document `json:"document"`
+	*search.MappingCheck
+}
+
+// ValidateMapping handles POST /api/v1/index/mapping/validate - Map a sample
+// classification result to a document and check it against the live index
+// mapping without indexing it, so an import is not halfway through when
+// OpenSearch starts rejecting documents.
+func (h *IndexingHandler) ValidateMapping(c *fiber.Ctx) error {
+	checker, ok := h.search.(search.MappingChecker)
+	if !ok {
+		return c.Status(fiber.StatusNotImplemented).JSON(internalModels.NewErrorResponse(
+			"not_supported",
+			"Search service cannot check the index mapping",
+			nil,
+		))
+	}
+
+	var request internalModels.MappingValidationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"parse_error",
+				"Failed to parse request body",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+
+	indexRequest := &internalModels.IndexDocumentRequest{
+		DocumentID:           "mapping-validation-sample",
+		Text:                 request.Text,
+		ClassificationResult: request.ClassificationResult,
+		FileName:             request.FileName,
+		SourceSystem:         models.SourceSystemUpload,
+	}
+	if indexRequest.Text == "" {
+		indexRequest.Text = sampleDocumentText
+	}
+	if indexRequest.ClassificationResult == nil {
+		indexRequest.ClassificationResult = sampleClassificationResult()
+	}
+	if indexRequest.FileName == "" {
+		indexRequest.FileName = "sample-motion.pdf"
+	}
+	if err := h.validateIndexRequest(indexRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
+	searchDoc, err := h.buildSearchDocument(indexRequest)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	check, err := checker.CheckDocumentMapping(ctx, searchDoc)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+			"mapping_check_failed",
+			fmt.Sprintf("Failed to check the index mapping: %v", err),
+			nil,
+		))
+	}
+
+	message := "Index mapping accepts the document"
+	if !check.Compatible {
+		incompatible := 0
+		for _, issue := range check.Issues {
+			if !issue.Warning {
+				incompatible++
+			}
+		}
+		message = fmt.Sprintf("Index mapping has %d incompatible fields", incompatible)
+	}
+	return c.JSON(internalModels.NewSuccessResponse(MappingValidationResponse{
+		Document:     searchDoc,
+		MappingCheck: check,
+	}, message))
+}
+
+// sampleDocumentText stands in for extracted text when a mapping validation
+// request has none
+const sampleDocumentText = "The defendant moves this court for an order suppressing all evidence " +
+	"obtained from the warrantless search of his vehicle. Case No. 24-CR-001234."
+
+// sampleClassificationResult is a classifier result that fills every field
+// the indexed document takes from it
+func sampleClassificationResult() *classifier.ClassificationResult {
+	date := "2024-03-01"
+	return &classifier.ClassificationResult{
+		DocumentType:  string(models.DocTypeMotionToSuppress),
+		LegalCategory: "criminal",
+		SubCategory:   "suppression",
+		Subject:       "Motion to suppress evidence from vehicle search",
+		Summary:       "Defendant seeks suppression of evidence from a warrantless vehicle search.",
+		Confidence:    0.92,
+		Keywords:      []string{"suppression", "vehicle search"},
+		LegalTags:     []string{"fourth_amendment", "suppression"},
+		CaseInfo: &classifier.CaseInfo{
+			CaseNumber: "24-CR-001234",
+			CaseName:   "People v. Doe",
+			CaseType:   "criminal",
+			Docket:     "24-CR-001234",
+		},
+		CourtInfo: &classifier.CourtInfo{
+			CourtID:      "ca-sf-superior",
+			CourtName:    "Superior Court of California, County of San Francisco",
+			Jurisdiction: "state",
+			Level:        "trial",
+			County:       "San Francisco",
+		},
+		Parties: []classifier.Party{
+			{Name: "People of the State of California", Role: "plaintiff", PartyType: "government"},
+			{Name: "John Doe", Role: "defendant", PartyType: "individual"},
+		},
+		Attorneys: []classifier.Attorney{
+			{Name: "Jane Roe", BarNumber: "123456", Role: "defense", Organization: "Public Defender"},
+		},
+		Judge: &classifier.Judge{Name: "Hon. Maria Lopez", Title: "Judge"},
+		Charges: []classifier.Charge{
+			{Statute: "PC 459", Description: "Burglary", Grade: "felony", Count: 1},
+		},
+		Authorities: []classifier.Authority{
+			{Citation: "Terry v. Ohio, 392 U.S. 1 (1968)", CaseTitle: "Terry v. Ohio", Type: "case_law", Precedent: true},
+		},
+		FilingDate:    &date,
+		EventDate:     &date,
+		HearingDate:   &date,
+		DecisionDate:  &date,
+		ServedDate:    &date,
+		SignatureDate: &date,
+		Status:        "pending",
+		Success:       true,
+	}
+}
+
 // indexDocument performs the actual document indexing
 func (h *IndexingHandler) indexDocument(ctx context.Context, req *internalModels.IndexDocumentRequest) (string, error) {
+	searchDoc, err := h.buildSearchDocument(req)
+	if err != nil {
+		return "", err
+	}
+
+	// Index the document
+	log.Printf("[INDEXING] Calling OpenSearch IndexDocument for %s", req.DocumentID)
+	indexID, err := h.search.IndexDocument(ctx, searchDoc)
+	if err != nil {
+		log.Printf("[INDEXING] ❌ OpenSearch IndexDocument failed for %s: %v", req.DocumentID, err)
+		return "", fmt.Errorf("failed to index document: %w", err)
+	}
+	log.Printf("[INDEXING] ✅ OpenSearch IndexDocument succeeded for %s, got ID: %s", req.DocumentID, indexID)
+
+	if indexID == "" {
+		return "", fmt.Errorf("indexing succeeded but no document ID was returned")
+	}
+
+	return indexID, nil
+}
+
+// buildSearchDocument maps an indexing request to the document that is indexed
+func (h *IndexingHandler) buildSearchDocument(req *internalModels.IndexDocumentRequest) (*models.Document, error) {
 	// Prepare document for indexing
 	now := time.Now()
 	
@@ -156,26 +329,24 @@ func (h *IndexingHandler) indexDocument(ctx context.Context, req *internalModels
 		Size:        size,
 		Metadata:    h.buildDocumentMetadata(req.ClassificationResult),
 	}
-
-	// Validate the document structure
-	if err := h.validateDocumentForIndexing(searchDoc); err != nil {
-		return "", fmt.Errorf("document validation failed: %w", err)
+	searchDoc.Metadata.SourceSystem = req.SourceSystem
+	searchDoc.Metadata.IngestionBatchID = req.IngestionBatchID
+	searchDoc.Metadata.Language = extractor.DetectLanguage(req.Text)
+	if err := searchDoc.SetClassification(req.ClassificationResult); err != nil {
+		return nil, err
 	}
 
-	// Index the document
-	log.Printf("[INDEXING] Calling OpenSearch IndexDocument for %s", req.DocumentID)
-	indexID, err := h.search.IndexDocument(ctx, searchDoc)
-	if err != nil {
-		log.Printf("[INDEXING] ❌ OpenSearch IndexDocument failed for %s: %v", req.DocumentID, err)
-		return "", fmt.Errorf("failed to index document: %w", err)
+	// Fall back to the document text when the classifier found no docket number
+	if searchDoc.Metadata.Case == nil || searchDoc.Metadata.Case.Docket == "" {
+		searchDoc.Metadata.SetDocket(classifier.ExtractDocketNumber(req.Text))
 	}
-	log.Printf("[INDEXING] ✅ OpenSearch IndexDocument succeeded for %s, got ID: %s", req.DocumentID, indexID)
 
-	if indexID == "" {
-		return "", fmt.Errorf("indexing succeeded but no document ID was returned")
+	// Validate the document structure
+	if err := h.validateDocumentForIndexing(searchDoc); err != nil {
+		return nil, fmt.Errorf("document validation failed: %w", err)
 	}
 
-	return indexID, nil
+	return searchDoc, nil
 }
 
 // validateDocumentForIndexing validates that a document has all required fields
@@ -216,7 +387,6 @@ func (h *IndexingHandler) buildDocumentMetadata(classResult *classifier.Classifi
 		Summary:       classResult.Summary,
 		DocumentType:  models.DocumentType(classResult.DocumentType),
 		Status:        classResult.Status,
-		Language:      "en", // Default to English
 		ProcessedAt:   time.Now(),
 		Confidence:    classResult.Confidence,
 		AIClassified:  true,
@@ -230,7 +400,7 @@ func (h *IndexingHandler) buildDocumentMetadata(classResult *classifier.Classifi
 			CaseName:     classResult.CaseInfo.CaseName,
 			CaseType:     classResult.CaseInfo.CaseType,
 			Chapter:      classResult.CaseInfo.Chapter,
-			Docket:       classResult.CaseInfo.Docket,
+			Docket:       models.NormalizeDocketNumber(classResult.CaseInfo.Docket),
 			NatureOfSuit: classResult.CaseInfo.NatureOfSuit,
 		}
 	}
@@ -252,11 +422,7 @@ func (h *IndexingHandler) buildDocumentMetadata(classResult *classifier.Classifi
 	if len(classResult.Parties) > 0 {
 		metadata.Parties = make([]models.Party, len(classResult.Parties))
 		for i, party := range classResult.Parties {
-			metadata.Parties[i] = models.Party{
-				Name:      party.Name,
-				Role:      party.Role,
-				PartyType: party.PartyType,
-			}
+			metadata.Parties[i] = models.NewParty(party.Name, party.Role, party.PartyType)
 		}
 	}
 
@@ -310,7 +476,7 @@ func (h *IndexingHandler) buildDocumentMetadata(classResult *classifier.Classifi
 		}
 	}
 
-	// Parse dates if available (all 5 enhanced date fields)
+	// Parse dates if available (all enhanced date fields)
 	if classResult.FilingDate != nil {
 		if filingTime, err := time.Parse("2006-01-02", *classResult.FilingDate); err == nil {
 			metadata.FilingDate = &filingTime
@@ -341,6 +507,12 @@ func (h *IndexingHandler) buildDocumentMetadata(classResult *classifier.Classifi
 		}
 	}
 
+	if classResult.SignatureDate != nil {
+		if signatureTime, err := time.Parse("2006-01-02", *classResult.SignatureDate); err == nil {
+			metadata.SignatureDate = &signatureTime
+		}
+	}
+
 	return metadata
 }
 
@@ -364,6 +536,10 @@ func determineContentType(filePath string) string {
 		return "text/plain"
 	case ".rtf":
 		return "application/rtf"
+	case ".eml":
+		return "message/rfc822"
+	case ".msg":
+		return "application/vnd.ms-outlook"
 	default:
 		return "application/octet-stream"
 	}
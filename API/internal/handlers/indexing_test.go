@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/search"
+)
+
+// postMappingValidation posts body to the mapping validation route
+func postMappingValidation(t *testing.T, app *fiber.App, body string) (int, MappingValidationResponse) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/index/mapping/validate", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Data MappingValidationResponse `json:"data"`
+	}
+	if resp.StatusCode == fiber.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	}
+	return resp.StatusCode, decoded.Data
+}
+
+func TestValidateMapping(t *testing.T) {
+	searchSvc := newMockSearchService()
+	app := fiber.New()
+	app.Post("/api/v1/index/mapping/validate", NewIndexingHandler(searchSvc).ValidateMapping)
+
+	// The representative sample fits the current mapping
+	status, data := postMappingValidation(t, app, "")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.True(t, data.Compatible)
+	assert.Empty(t, data.Issues)
+	assert.Empty(t, data.NewFields)
+	require.NotNil(t, data.Document)
+	assert.Equal(t, "Hon. Maria Lopez", data.Document.Metadata.Judge.Name)
+	assert.Empty(t, searchSvc.documents, "validation must not index")
+
+	// A strict index created before metadata.judge was an object
+	searchSvc.mapping = map[string]interface{}{
+		"properties": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"dynamic": "strict",
+				"properties": map[string]interface{}{
+					"judge":       map[string]interface{}{"type": "keyword"},
+					"filing_date": map[string]interface{}{"type": "date"},
+				},
+			},
+		},
+	}
+	status, data = postMappingValidation(t, app, `{
+		"text": "The defendant moves to suppress.",
+		"classification_result": {
+			"document_type": "motion_to_suppress",
+			"legal_category": "criminal",
+			"judge": {"name": "Hon. Maria Lopez"},
+			"filing_date": "2024-03-01"
+		}
+	}`)
+	require.Equal(t, fiber.StatusOK, status)
+	assert.False(t, data.Compatible)
+	assert.Contains(t, data.Issues, search.MappingIssue{
+		Index:       "documents",
+		Field:       "metadata.judge",
+		MappingType: "keyword",
+		Problem:     "expected a keyword value, got an object",
+	})
+	for _, issue := range data.Issues {
+		assert.NotEqual(t, "metadata.filing_date", issue.Field)
+	}
+	assert.Contains(t, data.NewFields, "file_name")
+	assert.Equal(t, models.DocumentType("motion_to_suppress"), data.Document.Metadata.DocumentType)
+
+	status, _ = postMappingValidation(t, app, `{"classification_result": {"legal_category": "criminal"}}`)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
@@ -0,0 +1,542 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/search"
+	"motion-index-fiber/pkg/storage"
+)
+
+// MockSearchService implements search.Service for handler tests.
+// Indexed documents are kept in memory so tests can inspect them. Like
+// OpenSearch, documents only show up in searches after a refresh.
+type MockSearchService struct {
+	mu         sync.Mutex
+	healthy    bool
+	documents  map[string]*models.Document
+	searchable map[string]bool
+
+	// mapping is the live index mapping CheckDocumentMapping compares
+	// documents with; nil uses the current document mapping
+	mapping map[string]interface{}
+
+	// metadataUpdates records the UpdateDocumentMetadata calls
+	metadataUpdates []metadataUpdate
+
+	// Optional hooks; when nil a sensible default is used
+	bulkIndexFn func(docs []*models.Document) (*models.BulkResult, error)
+	searchFn    func(req *models.SearchRequest) (*models.SearchResult, error)
+	indexErrFn  func(doc *models.Document) error
+}
+
+// metadataUpdate is one UpdateDocumentMetadata call
+type metadataUpdate struct {
+	docID  string
+	set    map[string]interface{}
+	remove []string
+}
+
+func newMockSearchService() *MockSearchService {
+	return &MockSearchService{
+		healthy:    true,
+		documents:  make(map[string]*models.Document),
+		searchable: make(map[string]bool),
+	}
+}
+
+// SearchService methods
+func (m *MockSearchService) SearchDocuments(ctx context.Context, req *models.SearchRequest) (*models.SearchResult, error) {
+	if m.searchFn != nil {
+		return m.searchFn(req)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := models.NewSearchResult()
+	for id := range m.searchable {
+		result.Documents = append(result.Documents, &models.SearchDocument{ID: id})
+	}
+	result.TotalHits = int64(len(result.Documents))
+	return result, nil
+}
+
+// IndexDocumentAndWait implements search.RefreshingIndexer
+func (m *MockSearchService) IndexDocumentAndWait(ctx context.Context, doc *models.Document) (string, error) {
+	id, err := m.IndexDocument(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	m.refresh()
+	return id, nil
+}
+
+// refresh makes every indexed document visible to search
+func (m *MockSearchService) refresh() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.documents {
+		m.searchable[id] = true
+	}
+}
+
+func (m *MockSearchService) IndexDocument(ctx context.Context, doc *models.Document) (string, error) {
+	if doc.ID == "" {
+		return "", fmt.Errorf("document ID is required")
+	}
+	if m.indexErrFn != nil {
+		if err := m.indexErrFn(doc); err != nil {
+			return "", err
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.documents[doc.ID] = doc
+	return doc.ID, nil
+}
+
+func (m *MockSearchService) BulkIndexDocuments(ctx context.Context, docs []*models.Document) (*models.BulkResult, error) {
+	if m.bulkIndexFn != nil {
+		return m.bulkIndexFn(docs)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range docs {
+		m.documents[doc.ID] = doc
+	}
+	return &models.BulkResult{Indexed: len(docs), FailedDocs: []*models.BulkFailedDoc{}}, nil
+}
+
+func (m *MockSearchService) UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadataUpdates = append(m.metadataUpdates, metadataUpdate{docID: docID, set: metadata, remove: remove})
+	return nil
+}
+
+// UpdateVersionedMetadata implements search.VersionedMetadataUpdater
+func (m *MockSearchService) UpdateVersionedMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string, operator string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadataUpdates = append(m.metadataUpdates, metadataUpdate{docID: docID, set: metadata, remove: remove})
+	if doc, ok := m.documents[docID]; ok {
+		doc.MetadataVersions = append(doc.MetadataVersions, models.MetadataVersion{
+			Values: metadata, Removed: remove, Operator: operator, ChangedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+func (m *MockSearchService) DeleteDocument(ctx context.Context, docID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.documents, docID)
+	delete(m.searchable, docID)
+	return nil
+}
+
+func (m *MockSearchService) BulkDeleteDocuments(ctx context.Context, ids []string) (*models.BulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := &models.BulkResult{FailedDocs: []*models.BulkFailedDoc{}}
+	for _, id := range ids {
+		if _, ok := m.documents[id]; !ok {
+			result.Failed++
+			result.FailedDocs = append(result.FailedDocs, &models.BulkFailedDoc{ID: id, Error: "document not found", Status: 404})
+			continue
+		}
+		delete(m.documents, id)
+		delete(m.searchable, id)
+		result.Deleted++
+	}
+	return result, nil
+}
+
+func (m *MockSearchService) GetDocument(ctx context.Context, docID string) (*models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if doc, ok := m.documents[docID]; ok {
+		return doc, nil
+	}
+	return nil, fmt.Errorf("document not found")
+}
+
+func (m *MockSearchService) DocumentExists(ctx context.Context, docID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.documents[docID]
+	return ok, nil
+}
+
+func (m *MockSearchService) FindByHash(ctx context.Context, hash string) (*models.Document, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range m.documents {
+		if doc.Hash == hash {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveRedactions implements search.RedactionStore
+func (m *MockSearchService) SaveRedactions(ctx context.Context, docID string, analysis *models.RedactionAnalysis) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.documents[docID]
+	if !ok {
+		return fmt.Errorf("document not found")
+	}
+	doc.Redactions = analysis
+	return nil
+}
+
+// CheckDocumentMapping implements search.MappingChecker
+func (m *MockSearchService) CheckDocumentMapping(ctx context.Context, doc *models.Document) (*search.MappingCheck, error) {
+	mapping := m.mapping
+	if mapping == nil {
+		mapping = models.GetDocumentMapping()["mappings"].(map[string]interface{})
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var source map[string]interface{}
+	if err := json.Unmarshal(encoded, &source); err != nil {
+		return nil, err
+	}
+	issues, newFields := search.CheckMapping(mapping, source)
+	for i := range issues {
+		issues[i].Index = "documents"
+	}
+	return &search.MappingCheck{
+		Indices:    []string{"documents"},
+		Compatible: len(issues) == 0,
+		Issues:     issues,
+		NewFields:  newFields,
+	}, nil
+}
+
+// AmendDocumentMetadata implements search.MetadataHistoryStore
+func (m *MockSearchService) AmendDocumentMetadata(ctx context.Context, docID string, values map[string]string, effective time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.documents[docID]
+	if !ok {
+		return fmt.Errorf("document not found")
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = &models.DocumentMetadata{}
+	}
+
+	now := time.Now()
+	for field, value := range values {
+		doc.MetadataHistory = models.AmendMetadataHistory(doc.MetadataHistory, field, doc.Metadata.EffectiveDatedValue(field), value, effective, now)
+		current, ok := models.MetadataValueAsOf(doc.MetadataHistory, field, now)
+		if !ok {
+			continue
+		}
+		switch field {
+		case "judge":
+			doc.Metadata.Judge = &models.Judge{Name: current}
+		case "court":
+			doc.Metadata.Court = &models.CourtInfo{CourtName: current}
+		case "status":
+			doc.Metadata.Status = current
+		}
+	}
+	return nil
+}
+
+// BulkUpdateMetadata implements search.BulkMetadataUpdater
+func (m *MockSearchService) BulkUpdateMetadata(ctx context.Context, updates []models.BulkMetadataUpdate, operator string) (*models.BulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := &models.BulkResult{FailedDocs: []*models.BulkFailedDoc{}}
+	for _, update := range updates {
+		doc, ok := m.documents[update.ID]
+		if !ok {
+			result.Failed++
+			result.FailedDocs = append(result.FailedDocs, &models.BulkFailedDoc{ID: update.ID, Error: "document not found", Status: 404})
+			continue
+		}
+		if doc.Metadata == nil {
+			doc.Metadata = &models.DocumentMetadata{}
+		}
+		previous := make(map[string]interface{})
+		values := make(map[string]interface{}, len(update.Metadata))
+		for field, value := range update.Metadata {
+			values[field] = value
+			switch field {
+			case "status":
+				previous[field] = doc.Metadata.Status
+				doc.Metadata.Status = value
+			case "subject":
+				previous[field] = doc.Metadata.Subject
+				doc.Metadata.Subject = value
+			}
+		}
+		doc.MetadataVersions = append(doc.MetadataVersions, models.MetadataVersion{
+			Values: values, Previous: previous, Operator: operator, ChangedAt: time.Now(),
+		})
+		result.Updated++
+	}
+	return result, nil
+}
+
+// AppendRedactionAudit implements search.RedactionAuditStore
+func (m *MockSearchService) AppendRedactionAudit(ctx context.Context, docID string, record *models.RedactionAuditRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.documents[docID]
+	if !ok {
+		return fmt.Errorf("document not found")
+	}
+	doc.RedactionAudit = append(doc.RedactionAudit, *record)
+	return nil
+}
+
+// AggregationService methods
+func (m *MockSearchService) GetLegalTags(ctx context.Context) ([]*models.TagCount, error) {
+	return []*models.TagCount{}, nil
+}
+
+func (m *MockSearchService) GetDocumentTypes(ctx context.Context) ([]*models.TypeCount, error) {
+	return []*models.TypeCount{}, nil
+}
+
+func (m *MockSearchService) GetMetadataFieldValues(ctx context.Context, field string, prefix string, size int) ([]*models.FieldValue, error) {
+	return []*models.FieldValue{}, nil
+}
+
+func (m *MockSearchService) GetMetadataFieldValuesWithFilters(ctx context.Context, req *models.MetadataFieldValuesRequest) ([]*models.FieldValue, error) {
+	return []*models.FieldValue{}, nil
+}
+
+func (m *MockSearchService) GetDocumentStats(ctx context.Context) (*models.DocumentStats, error) {
+	return &models.DocumentStats{LastUpdated: time.Now(), FieldStats: make(map[string]models.FieldStat)}, nil
+}
+
+func (m *MockSearchService) GetAllFieldOptions(ctx context.Context) (*models.FieldOptions, error) {
+	return &models.FieldOptions{}, nil
+}
+
+func (m *MockSearchService) GetFieldOptionsForQuery(ctx context.Context, req *models.SearchRequest) (*models.FieldOptions, error) {
+	return &models.FieldOptions{}, nil
+}
+
+// GetCaseStats counts indexed documents by type for the case
+func (m *MockSearchService) GetCaseStats(ctx context.Context, caseNumber string) (*models.CaseStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := &models.CaseStats{CaseNumber: caseNumber}
+	typeCounts := make(map[string]*models.TypeCount)
+	for _, doc := range m.documents {
+		if doc.Metadata == nil || doc.Metadata.GetCaseNumber() != caseNumber {
+			continue
+		}
+		stats.TotalDocuments++
+		if typeCounts[doc.DocType] == nil {
+			typeCounts[doc.DocType] = &models.TypeCount{Type: doc.DocType}
+			stats.TypeCounts = append(stats.TypeCounts, typeCounts[doc.DocType])
+		}
+		typeCounts[doc.DocType].Count++
+	}
+	return stats, nil
+}
+
+// HealthChecker methods
+func (m *MockSearchService) IsHealthy() bool {
+	return m.healthy
+}
+
+func (m *MockSearchService) Health(ctx context.Context) (*search.HealthStatus, error) {
+	return &search.HealthStatus{Status: "green", IndexExists: true, IndexHealth: "green"}, nil
+}
+
+// MockStorageService implements storage.Service for handler tests with an in-memory object store
+type MockStorageService struct {
+	mu        sync.Mutex
+	healthy   bool
+	objects   map[string][]byte
+	fileNames map[string]string
+
+	// existsCalls and metadataCalls count object lookups
+	existsCalls   int
+	metadataCalls int
+
+	// baseURL, when set, replaces the fake CDN host so URLs can point at a test server
+	baseURL string
+}
+
+func newMockStorageService() *MockStorageService {
+	return &MockStorageService{
+		healthy:   true,
+		objects:   make(map[string][]byte),
+		fileNames: make(map[string]string),
+	}
+}
+
+func (m *MockStorageService) Upload(ctx context.Context, path string, content io.Reader, metadata *storage.UploadMetadata) (*storage.UploadResult, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[path] = data
+	if metadata != nil {
+		m.fileNames[path] = metadata.FileName
+	}
+	return &storage.UploadResult{Path: path, URL: m.GetURL(path), Size: int64(len(data)), Success: true, UploadedAt: time.Now()}, nil
+}
+
+func (m *MockStorageService) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[path]
+	if !ok {
+		return nil, storage.NewStorageError("not_found", "object not found", path, nil)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MockStorageService) Delete(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, path)
+	return nil
+}
+
+func (m *MockStorageService) GetURL(path string) string {
+	if m.baseURL != "" {
+		return m.baseURL + "/" + path
+	}
+	return "https://cdn.example.com/" + path
+}
+
+func (m *MockStorageService) GetSignedURL(path string, expiration time.Duration) (string, error) {
+	if m.baseURL != "" {
+		return m.baseURL + "/" + path + "?signature=test", nil
+	}
+	return "https://storage.example.com/" + path + "?signature=test", nil
+}
+
+// GetSignedURLWithDisposition implements storage.DispositionSigner
+func (m *MockStorageService) GetSignedURLWithDisposition(path string, expiration time.Duration, disposition string) (string, error) {
+	signed, err := m.GetSignedURL(path, expiration)
+	if err != nil {
+		return "", err
+	}
+	return signed + "&response-content-disposition=" + url.QueryEscape(disposition), nil
+}
+
+// GetFileMetadata implements storage.MetadataProvider
+func (m *MockStorageService) GetFileMetadata(ctx context.Context, path string) (*storage.FileMetadata, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadataCalls++
+	data, ok := m.objects[path]
+	if !ok {
+		return nil, storage.NewStorageError("not_found", "object not found", path, nil)
+	}
+	return &storage.FileMetadata{FileName: m.fileNames[path], Size: int64(len(data))}, nil
+}
+
+func (m *MockStorageService) Exists(ctx context.Context, path string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.existsCalls++
+	_, ok := m.objects[path]
+	return ok, nil
+}
+
+func (m *MockStorageService) List(ctx context.Context, prefix string) ([]*storage.StorageObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []*storage.StorageObject
+	for path, data := range m.objects {
+		if len(prefix) == 0 || (len(path) >= len(prefix) && path[:len(prefix)] == prefix) {
+			objects = append(objects, &storage.StorageObject{Path: path, Size: int64(len(data)), LastModified: time.Now()})
+		}
+	}
+	return objects, nil
+}
+
+// ListPaged lists objects in path order; its continuation token is the last
+// path of the previous page
+func (m *MockStorageService) ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	objects, _ := m.List(ctx, prefix)
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Path < objects[j].Path })
+
+	start := sort.Search(len(objects), func(i int) bool { return objects[i].Path > continuationToken })
+	objects = objects[start:]
+	page := &storage.ListPage{Objects: objects}
+	if len(objects) > maxKeys {
+		page.Objects = objects[:maxKeys]
+		page.NextContinuationToken = objects[maxKeys-1].Path
+	}
+	return page, nil
+}
+
+func (m *MockStorageService) IsHealthy() bool {
+	return m.healthy
+}
+
+func (m *MockStorageService) GetMetrics() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// stubClassifier implements classifier.Service and returns a fixed result
+type stubClassifier struct {
+	result *classifier.ClassificationResult
+	err    error
+
+	// release, when set, blocks every call until it is closed
+	release chan struct{}
+
+	mu    sync.Mutex
+	texts []string // text passed to each call
+}
+
+func (s *stubClassifier) ClassifyDocument(ctx context.Context, text string, metadata *classifier.DocumentMetadata) (*classifier.ClassificationResult, error) {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	s.texts = append(s.texts, text)
+	s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.result != nil {
+		result := *s.result
+		return &result, nil
+	}
+	return &classifier.ClassificationResult{
+		DocumentType:  classifier.DocumentTypeMotionToDismiss,
+		LegalCategory: classifier.LegalCategoryCriminal,
+		Confidence:    0.9,
+		Success:       true,
+	}, nil
+}
+
+func (s *stubClassifier) GetAvailableCategories() []string {
+	return classifier.GetDefaultCategories()
+}
+
+func (s *stubClassifier) IsHealthy() bool {
+	return s.err == nil
+}
+
+func (s *stubClassifier) ValidateResult(result *classifier.ClassificationResult) error {
+	return nil
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// pendingSpill holds the pending documents of a batch job beyond the
+// in-memory limit in a temporary file, one JSON document per line, so a
+// long job does not keep every extracted text in memory until it is indexed
+type pendingSpill struct {
+	file *os.File
+	enc  *json.Encoder
+	ids  []string // IDs of the spilled documents, in order
+}
+
+// spilledDocument is a pending document as written to a spill file. The
+// indexed document a reindex keeps is not part of the PendingDocument JSON
+// and is written alongside it.
+type spilledDocument struct {
+	*PendingDocument
+	Existing *models.Document `json:"existing,omitempty"`
+}
+
+// newPendingSpill creates an empty spill file
+func newPendingSpill() (*pendingSpill, error) {
+	file, err := os.CreateTemp("", "batch-pending-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pending document spill file: %w", err)
+	}
+	return &pendingSpill{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// add appends a document to the spill file
+func (s *pendingSpill) add(doc *PendingDocument) error {
+	if err := s.enc.Encode(spilledDocument{PendingDocument: doc, Existing: doc.Existing}); err != nil {
+		return fmt.Errorf("failed to spill pending document %s: %w", doc.Document.DocumentID, err)
+	}
+	s.ids = append(s.ids, doc.Document.DocumentID)
+	return nil
+}
+
+// count returns the number of spilled documents
+func (s *pendingSpill) count() int {
+	return len(s.ids)
+}
+
+// readAll reads the spilled documents back in the order they were added
+func (s *pendingSpill) readAll() ([]*PendingDocument, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind pending document spill file: %w", err)
+	}
+
+	docs := make([]*PendingDocument, 0, len(s.ids))
+	dec := json.NewDecoder(s.file)
+	for range s.ids {
+		spilled := spilledDocument{PendingDocument: &PendingDocument{}}
+		if err := dec.Decode(&spilled); err != nil {
+			return docs, fmt.Errorf("failed to read pending document spill file: %w", err)
+		}
+		spilled.PendingDocument.Existing = spilled.Existing
+		docs = append(docs, spilled.PendingDocument)
+	}
+	return docs, nil
+}
+
+// remove closes and deletes the spill file
+func (s *pendingSpill) remove() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
@@ -3,17 +3,31 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"maps"
+	"mime/multipart"
+	"os"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"motion-index-fiber/internal/config"
+	"motion-index-fiber/internal/middleware"
 	internalModels "motion-index-fiber/internal/models"
 	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
 	"motion-index-fiber/pkg/processing/pipeline"
+	"motion-index-fiber/pkg/processing/queue"
 	"motion-index-fiber/pkg/processing/redaction"
 	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/storage"
@@ -25,6 +39,14 @@ type ProcessingHandler struct {
 	pipeline  pipeline.Pipeline
 	storage   storage.Service
 	searchSvc search.Service
+
+	// required is REQUIRED_METADATA_FIELDS, parsed once by New; nil skips
+	// re-flagging documents after metadata edits
+	required pipeline.RequiredFields
+
+	// queueManager holds the "indexing" queue that documents uploaded without
+	// index_immediately go through; nil indexes them inline
+	queueManager queue.QueueManager
 }
 
 // NewProcessingHandler creates a new processing handler
@@ -66,23 +88,59 @@ func (h *ProcessingHandler) AnalyzeRedactions(c *fiber.Ctx) error {
 
 	file := files[0]
 
-	// Analyze document for redactions
+	// Only PDFs have page positions to report
+	if format, err := redaction.DetectFormat(file.Filename, file.Header.Get("Content-Type")); err != nil || format != redaction.FormatPDF {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"file_type_error",
+			"Only PDF files can be analyzed for redactions",
+			map[string]interface{}{"supported_formats": []string{redaction.FormatPDF}},
+		))
+	}
+
+	if maxSize := h.cfg.Processing.RedactionMaxFileSize; maxSize > 0 && file.Size > maxSize {
+		status, response := redactionErrorResponse(&redaction.LimitError{Limit: "file_size", Max: maxSize, Actual: file.Size}, "", "")
+		return c.Status(status).JSON(response)
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+			"file_read_error",
+			"Failed to read uploaded file",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	defer fileReader.Close()
+
+	// California patterns are on unless california_laws=false
+	options := &redaction.Options{
+		UseAI:          c.FormValue("use_ai") == "true",
+		CaliforniaLaws: c.FormValue("california_laws") != "false",
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), h.cfg.Processing.RedactionTimeout)
+	defer cancel()
+
+	analysis, err := h.redactionService().AnalyzePDF(ctx, fileReader, options)
+	if err != nil {
+		status, response := redactionErrorResponse(err, "analysis_error", "Failed to analyze document")
+		return c.Status(status).JSON(response)
+	}
+	if !analysis.Success {
+		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+			"analysis_failed",
+			analysis.Error,
+			nil,
+		))
+	}
+
 	response := &internalModels.RedactionAnalysisResult{
-		DocumentID:      generateDocumentID(file.Filename),
-		FileName:        file.Filename,
-		RedactionsFound: 5,
-		RedactionRegions: []internalModels.RedactionRegion{
-			{
-				Page:   1,
-				X:      100,
-				Y:      200,
-				Width:  150,
-				Height: 20,
-				Type:   "text",
-			},
-		},
-		AnalyzedAt: time.Now(),
-		Status:     "completed",
+		DocumentID:       generateDocumentID(file.Filename),
+		FileName:         file.Filename,
+		RedactionsFound:  analysis.TotalCount,
+		RedactionRegions: convertRedactionRegions(analysis.Redactions),
+		AnalyzedAt:       time.Now(),
+		Status:           "completed",
 	}
 
 	return c.JSON(internalModels.NewSuccessResponse(response, "Redaction analysis completed"))
@@ -109,26 +167,121 @@ func (h *ProcessingHandler) UpdateMetadata(c *fiber.Ctx) error {
 			nil,
 		))
 	}
+	if len(request.Metadata) == 0 && len(request.Set) == 0 && len(request.Remove) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"metadata, set or remove is required",
+			nil,
+		))
+	}
 
 	// Update metadata using search service
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Convert map[string]string to map[string]interface{}
+	// Effective-dated fields are amended with their history; the rest are
+	// overwritten. Convert map[string]string to map[string]interface{}
+	effectiveDated, _ := models.ParseEffectiveDatedFields(h.cfg.Search.EffectiveDatedFields)
 	metadata := make(map[string]interface{})
+	amended := make(map[string]string)
 	for k, v := range request.Metadata {
-		metadata[k] = v
+		if slices.Contains(effectiveDated, k) {
+			amended[k] = v
+		} else {
+			metadata[k] = v
+		}
 	}
 
-	err := h.searchSvc.UpdateDocumentMetadata(ctx, request.DocumentID, metadata)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
-			"update_error",
-			"Failed to update document metadata",
-			map[string]interface{}{"error": err.Error()},
+	for path, value := range request.Set {
+		metadata[path] = value
+	}
+	// Typed updates may change the parts of an effective-dated field its
+	// history does not track, such as a court's jurisdiction, but not the
+	// tracked value itself
+	for _, path := range append(slices.Collect(maps.Keys(request.Set)), request.Remove...) {
+		field, _, _ := strings.Cut(path, ".")
+		tracked := models.EffectiveDatedPath(field)
+		if slices.Contains(effectiveDated, field) && (path == field || path == tracked || strings.HasPrefix(tracked, path+".")) {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				fmt.Sprintf("field %q is effective-dated; send its new value in metadata", path),
+				map[string]interface{}{"effective_dated_fields": effectiveDated},
+			))
+		}
+	}
+	// Legacy metadata values are set like typed ones, so they are checked
+	// against the mapping too
+	if err := search.ValidateMetadataUpdate(metadata, request.Remove); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
+	if request.EffectiveDate != nil && len(amended) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"effective_date requires a value for an effective-dated field",
+			map[string]interface{}{"effective_dated_fields": effectiveDated},
 		))
 	}
 
+	if len(amended) > 0 {
+		store, ok := h.searchSvc.(search.MetadataHistoryStore)
+		if !ok {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(internalModels.NewErrorResponse(
+				"service_unavailable",
+				"Metadata history cannot be stored with documents",
+				nil,
+			))
+		}
+
+		effective := time.Now()
+		if request.EffectiveDate != nil {
+			effective = *request.EffectiveDate
+		}
+		if err := models.CheckEffectiveDate(effective, time.Now()); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				err.Error(),
+				nil,
+			))
+		}
+		if err := store.AmendDocumentMetadata(ctx, request.DocumentID, amended, effective); err != nil {
+			if err.Error() == "document not found" {
+				return c.Status(fiber.StatusNotFound).JSON(internalModels.NewErrorResponse(
+					"not_found",
+					"Document not found",
+					map[string]interface{}{"document_id": request.DocumentID},
+				))
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+				"update_error",
+				"Failed to update document metadata",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+
+	if len(metadata) > 0 || len(request.Remove) > 0 || len(amended) == 0 {
+		var err error
+		if versioned, ok := h.searchSvc.(search.VersionedMetadataUpdater); ok {
+			err = versioned.UpdateVersionedMetadata(ctx, request.DocumentID, metadata, request.Remove, requestOperator(c))
+		} else {
+			err = h.searchSvc.UpdateDocumentMetadata(ctx, request.DocumentID, metadata, request.Remove)
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+				"update_error",
+				"Failed to update document metadata",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+
+	h.reflagRequiredMetadata(ctx, request.DocumentID)
+
 	response := &internalModels.UpdateMetadataResponse{
 		DocumentID: request.DocumentID,
 		UpdatedAt:  time.Now(),
@@ -138,9 +291,163 @@ func (h *ProcessingHandler) UpdateMetadata(c *fiber.Ctx) error {
 	return c.JSON(internalModels.NewSuccessResponse(response, "Metadata updated successfully"))
 }
 
+// reflagRequiredMetadata re-evaluates the required metadata of a document
+// after its fields were edited. The edit itself has succeeded, so a failure
+// here is logged rather than returned.
+func (h *ProcessingHandler) reflagRequiredMetadata(ctx context.Context, docID string) {
+	if h.required == nil {
+		return
+	}
+
+	doc, err := h.searchSvc.GetDocument(ctx, docID)
+	if err != nil {
+		log.Printf("[PROCESSING] ⚠️ Could not re-check required metadata of %s: %v", docID, err)
+		return
+	}
+	set, remove, changed := h.required.Reflag(doc.Metadata)
+	if !changed {
+		return
+	}
+	if err := h.searchSvc.UpdateDocumentMetadata(ctx, docID, set, remove); err != nil {
+		log.Printf("[PROCESSING] ⚠️ Could not update review status of %s: %v", docID, err)
+	}
+}
+
+// BulkUpdateMetadata sets metadata fields of many documents by ID in one
+// bulk request. Each entry is validated and applied on its own, so invalid
+// entries and unknown IDs are reported without stopping the others.
+func (h *ProcessingHandler) BulkUpdateMetadata(c *fiber.Ctx) error {
+	var request internalModels.BulkUpdateMetadataRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"parse_error",
+			"Failed to parse request body",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	if err := request.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			map[string]interface{}{"max_updates": internalModels.MaxBulkMetadataUpdateSize},
+		))
+	}
+
+	updater, ok := h.searchSvc.(search.BulkMetadataUpdater)
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(internalModels.NewErrorResponse(
+			"service_unavailable",
+			"Bulk metadata updates are not supported by the search service",
+			nil,
+		))
+	}
+
+	// Validate every entry, sending only the valid ones, each ID once
+	effectiveDated, _ := models.ParseEffectiveDatedFields(h.cfg.Search.EffectiveDatedFields)
+	results := make([]internalModels.BulkMetadataResult, len(request.Updates))
+	seen := make(map[string]bool)
+	var valid []models.BulkMetadataUpdate
+	for i, update := range request.Updates {
+		results[i].ID = update.ID
+		if err := internalModels.ValidateBulkMetadataUpdate(update, effectiveDated); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if seen[update.ID] {
+			results[i].Error = "duplicate id"
+			continue
+		}
+		seen[update.ID] = true
+		valid = append(valid, update)
+	}
+
+	if len(valid) > 0 {
+		ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+		defer cancel()
+
+		// Effective-dated fields are amended with their history one document
+		// at a time, after the rest of each entry is sent in one bulk request
+		amendments := make(map[string]map[string]string)
+		var bulk []models.BulkMetadataUpdate
+		for _, update := range valid {
+			fields := make(map[string]string, len(update.Metadata))
+			for field, value := range update.Metadata {
+				if slices.Contains(effectiveDated, field) {
+					if amendments[update.ID] == nil {
+						amendments[update.ID] = make(map[string]string)
+					}
+					amendments[update.ID][field] = value
+				} else {
+					fields[field] = value
+				}
+			}
+			if len(fields) > 0 {
+				bulk = append(bulk, models.BulkMetadataUpdate{ID: update.ID, Metadata: fields})
+			}
+		}
+
+		failures := make(map[string]string)
+		if len(bulk) > 0 {
+			bulkResult, err := updater.BulkUpdateMetadata(ctx, bulk, requestOperator(c))
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+					"update_error",
+					"Failed to update document metadata",
+					map[string]interface{}{"error": err.Error()},
+				))
+			}
+			for _, failed := range bulkResult.FailedDocs {
+				failures[failed.ID] = failed.Error
+			}
+		}
+
+		store, canAmend := h.searchSvc.(search.MetadataHistoryStore)
+		for _, update := range valid {
+			amended := amendments[update.ID]
+			if len(amended) == 0 || failures[update.ID] != "" {
+				continue
+			}
+			if !canAmend {
+				failures[update.ID] = "metadata history cannot be stored with documents"
+				continue
+			}
+			effective := time.Now()
+			if update.EffectiveDate != nil {
+				effective = *update.EffectiveDate
+			}
+			if err := store.AmendDocumentMetadata(ctx, update.ID, amended, effective); err != nil {
+				failures[update.ID] = err.Error()
+			}
+		}
+		for i := range results {
+			if results[i].Error != "" || !seen[results[i].ID] {
+				continue
+			}
+			if reason, failed := failures[results[i].ID]; failed {
+				results[i].Error = reason
+			} else {
+				results[i].Success = true
+				h.reflagRequiredMetadata(ctx, results[i].ID)
+			}
+		}
+	}
+
+	response := &internalModels.BulkUpdateMetadataResponse{Results: results}
+	for _, result := range results {
+		if result.Success {
+			response.Updated++
+		} else {
+			response.Failed++
+		}
+	}
+
+	return c.JSON(internalModels.NewSuccessResponse(response,
+		fmt.Sprintf("Updated metadata of %d of %d documents", response.Updated, len(results))))
+}
+
 // RedactDocument creates a redacted version of a document
 func (h *ProcessingHandler) RedactDocument(c *fiber.Ctx) error {
-	ctx, cancel := context.WithTimeout(c.Context(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(c.Context(), h.cfg.Processing.RedactionTimeout)
 	defer cancel()
 
 	// Handle multipart form for file upload or JSON for existing document
@@ -153,7 +460,17 @@ func (h *ProcessingHandler) RedactDocument(c *fiber.Ctx) error {
 	}
 }
 
-// redactUploadedFile handles redaction of an uploaded PDF file
+// Redaction output formats for uploaded files
+const (
+	// RedactionOutputPDF returns redaction items, plus the redacted PDF when
+	// apply_redactions is set
+	RedactionOutputPDF = "pdf"
+	// RedactionOutputOverlay returns only the regions to draw over the
+	// original PDF and never produces a redacted file
+	RedactionOutputOverlay = "overlay"
+)
+
+// redactUploadedFile handles redaction of an uploaded PDF, DOCX or RTF file
 func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context) error {
 	// Parse multipart form
 	file, err := c.FormFile("file")
@@ -165,15 +482,45 @@ func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context
 		))
 	}
 
-	// Validate file type
-	if !strings.HasSuffix(strings.ToLower(file.Filename), ".pdf") {
+	output := c.FormValue("output", RedactionOutputPDF)
+	if output != RedactionOutputPDF && output != RedactionOutputOverlay {
 		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
-			"file_type_error",
-			"Only PDF files are supported for redaction",
+			"validation_error",
+			fmt.Sprintf("Invalid output %q: use %q or %q", output, RedactionOutputPDF, RedactionOutputOverlay),
 			nil,
 		))
 	}
 
+	// Validate file type
+	format, err := redaction.DetectFormat(file.Filename, file.Header.Get("Content-Type"))
+	if err != nil {
+		status, response := redactionErrorResponse(err, "", "")
+		return c.Status(status).JSON(response)
+	}
+	if output == RedactionOutputOverlay && format != redaction.FormatPDF {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"Overlay output is only available for PDF files",
+			map[string]interface{}{"format": format},
+		))
+	}
+
+	// An upload of an indexed document's PDF can store its results with the
+	// document; check the document exists before running the analysis
+	documentID := c.FormValue("document_id")
+	if documentID != "" {
+		if status, response := h.checkRedactionTarget(ctx, documentID); response != nil {
+			return c.Status(status).JSON(response)
+		}
+	}
+
+	// Reject oversized uploads before reading them; the page limit is checked
+	// by the redaction service once the file is read
+	if maxSize := h.cfg.Processing.RedactionMaxFileSize; maxSize > 0 && file.Size > maxSize {
+		status, response := redactionErrorResponse(&redaction.LimitError{Limit: "file_size", Max: maxSize, Actual: file.Size}, "", "")
+		return c.Status(status).JSON(response)
+	}
+
 	// Open the uploaded file
 	fileReader, err := file.Open()
 	if err != nil {
@@ -198,30 +545,83 @@ func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context
 	if replacementChar := c.FormValue("replacement_char"); replacementChar != "" {
 		options.ReplacementChar = replacementChar
 	}
+	if rules := c.FormValue("replacement_rules"); rules != "" {
+		if err := json.Unmarshal([]byte(rules), &options.ReplacementRules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				"replacement_rules must be a JSON object keyed by redaction type",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+	if err := redaction.ValidateReplacementRules(options.ReplacementRules); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+	options.PreserveFormat = c.FormValue("preserve_format") == "true"
 
 	// Create redaction service
-	redactionService := redaction.NewService(true, h.cfg.OpenAI.APIKey)
+	redactionService := h.redactionService()
 
 	// Determine if we should apply redactions or just analyze
 	applyRedactions := c.FormValue("apply_redactions") == "true"
+	start := time.Now()
 
-	if applyRedactions {
-		// Apply redactions and return redacted PDF
-		result, err := redactionService.RedactPDF(ctx, fileReader, options)
+	if output == RedactionOutputOverlay {
+		analysis, err := redactionService.AnalyzeDocument(ctx, fileReader, format, options)
 		if err != nil {
+			status, response := redactionErrorResponse(err, "analysis_error", "Failed to analyze document")
+			return c.Status(status).JSON(response)
+		}
+		if !analysis.Success {
 			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
-				"redaction_error",
-				"Failed to redact document",
-				map[string]interface{}{"error": err.Error()},
+				"analysis_failed",
+				analysis.Error,
+				nil,
 			))
 		}
 
+		if documentID != "" {
+			if err := h.saveRedactions(ctx, documentID, analysis.Redactions, analysis.TotalCount); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+			}
+		}
+
+		response := &internalModels.RedactionOverlayResponse{
+			DocumentID:      documentID,
+			Filename:        file.Filename,
+			CoordinateSpace: redaction.CoordinateSpacePDFPoints,
+			Regions:         convertOverlayRegions(analysis.Redactions),
+			TotalRegions:    analysis.TotalCount,
+			AnalysisTimeMs:  time.Since(start).Milliseconds(),
+		}
+		return c.JSON(internalModels.NewSuccessResponse(response, "Redaction overlay generated"))
+	}
+
+	if applyRedactions {
+		// Apply redactions and return the redacted file
+		result, err := redactionService.RedactDocument(ctx, fileReader, format, options)
+		if err != nil {
+			status, response := redactionErrorResponse(err, "redaction_error", "Failed to redact document")
+			return c.Status(status).JSON(response)
+		}
+
+		filename := file.Filename
+		if result.Format != "" && result.Format != format {
+			filename = strings.TrimSuffix(filename, path.Ext(filename)) + "." + result.Format
+		}
 		response := &internalModels.RedactDocumentResponse{
 			Success:         result.Success,
 			PDFBase64:       result.PDFBase64,
-			Filename:        fmt.Sprintf("redacted_%s", file.Filename),
+			DocumentBase64:  result.DocumentBase64,
+			Format:          result.Format,
+			Filename:        fmt.Sprintf("redacted_%s", filename),
 			Redactions:      convertRedactionItems(result.Redactions),
 			TotalRedactions: result.TotalCount,
+			AnalysisTimeMs:  time.Since(start).Milliseconds(),
 			Message:         "Document redacted successfully",
 		}
 
@@ -234,16 +634,23 @@ func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context
 			))
 		}
 
+		if documentID != "" {
+			if err := h.saveRedactions(ctx, documentID, result.Redactions, result.TotalCount); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+			}
+			if err := h.auditRedactions(c, ctx, documentID, result.Redactions, result.TotalCount); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+			}
+			response.DocumentID = documentID
+		}
+
 		return c.JSON(internalModels.NewSuccessResponse(response, "Document redacted successfully"))
 	} else {
 		// Just analyze for potential redactions
-		analysis, err := redactionService.AnalyzePDF(ctx, fileReader, options)
+		analysis, err := redactionService.AnalyzeDocument(ctx, fileReader, format, options)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
-				"analysis_error",
-				"Failed to analyze document",
-				map[string]interface{}{"error": err.Error()},
-			))
+			status, response := redactionErrorResponse(err, "analysis_error", "Failed to analyze document")
+			return c.Status(status).JSON(response)
 		}
 
 		response := &internalModels.RedactDocumentResponse{
@@ -251,6 +658,7 @@ func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context
 			Filename:        file.Filename,
 			Redactions:      convertRedactionItems(analysis.Redactions),
 			TotalRedactions: analysis.TotalCount,
+			AnalysisTimeMs:  time.Since(start).Milliseconds(),
 			Message:         "Document analyzed for potential redactions",
 		}
 
@@ -263,49 +671,539 @@ func (h *ProcessingHandler) redactUploadedFile(c *fiber.Ctx, ctx context.Context
 			))
 		}
 
+		if documentID != "" {
+			if err := h.saveRedactions(ctx, documentID, analysis.Redactions, analysis.TotalCount); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+			}
+			response.DocumentID = documentID
+		}
+
 		return c.JSON(internalModels.NewSuccessResponse(response, "Document analysis completed"))
 	}
 }
 
-// redactExistingDocument handles redaction of an existing document by ID
-func (h *ProcessingHandler) redactExistingDocument(c *fiber.Ctx, ctx context.Context) error {
-	var request internalModels.RedactDocumentRequest
-
-	// Parse request body
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
-			"parse_error",
-			"Failed to parse request body",
-			map[string]interface{}{"error": err.Error()},
-		))
+// checkRedactionTarget reports an error response when redaction results
+// cannot be stored with the document: the search service cannot store them or
+// the document is not indexed
+func (h *ProcessingHandler) checkRedactionTarget(ctx context.Context, documentID string) (int, *internalModels.APIResponse) {
+	if _, ok := h.searchSvc.(search.RedactionStore); !ok {
+		return fiber.StatusServiceUnavailable, internalModels.NewErrorResponse(
+			"service_unavailable",
+			"Redaction results cannot be stored with documents",
+			nil,
+		)
+	}
+	if _, ok := h.searchSvc.(search.RedactionAuditStore); h.cfg.Processing.RedactionAuditLog && !ok {
+		return fiber.StatusServiceUnavailable, internalModels.NewErrorResponse(
+			"service_unavailable",
+			"Redactions cannot be recorded in the audit log",
+			nil,
+		)
+	}
+
+	exists, err := h.searchSvc.DocumentExists(ctx, documentID)
+	if err != nil {
+		return fiber.StatusInternalServerError, internalModels.NewErrorResponse(
+			"search_error",
+			"Failed to look up document",
+			map[string]interface{}{"error": err.Error()},
+		)
+	}
+	if !exists {
+		return fiber.StatusNotFound, internalModels.NewErrorResponse(
+			"not_found",
+			"Document not found",
+			map[string]interface{}{"document_id": documentID},
+		)
+	}
+	return 0, nil
+}
+
+// saveRedactions stores redaction items with an indexed document, replacing
+// any earlier analysis
+func (h *ProcessingHandler) saveRedactions(ctx context.Context, documentID string, items []redaction.RedactionItem, total int) error {
+	store, ok := h.searchSvc.(search.RedactionStore)
+	if !ok {
+		return fmt.Errorf("search service cannot store redactions")
+	}
+
+	return store.SaveRedactions(ctx, documentID, &models.RedactionAnalysis{
+		Items:           storedRedactions(items),
+		TotalCount:      total,
+		CoordinateSpace: redaction.CoordinateSpacePDFPoints,
+		AnalyzedAt:      time.Now(),
+	})
+}
+
+// auditRedactions appends a record of redactions applied to an indexed
+// document to its audit log, when REDACTION_AUDIT_LOG is enabled. The
+// operator is taken from the request's JWT claims when there are any.
+func (h *ProcessingHandler) auditRedactions(c *fiber.Ctx, ctx context.Context, documentID string, items []redaction.RedactionItem, total int) error {
+	if !h.cfg.Processing.RedactionAuditLog {
+		return nil
+	}
+	store, ok := h.searchSvc.(search.RedactionAuditStore)
+	if !ok {
+		return fmt.Errorf("search service cannot record a redaction audit log")
+	}
+
+	record := &models.RedactionAuditRecord{
+		Items:      storedRedactions(items),
+		TotalCount: total,
+		AppliedAt:  time.Now(),
+	}
+	for _, item := range items {
+		if item.LegalCode != "" && !slices.Contains(record.LegalCodes, item.LegalCode) {
+			record.LegalCodes = append(record.LegalCodes, item.LegalCode)
+		}
+	}
+	record.Operator = requestOperator(c)
+
+	return store.AppendRedactionAudit(ctx, documentID, record)
+}
+
+// requestOperator returns the email, or failing that the subject, of the
+// request's JWT, or "" when the request has none
+func requestOperator(c *fiber.Ctx) string {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return ""
+	}
+	if user.Email != "" {
+		return user.Email
+	}
+	return user.UserID
+}
+
+// storedRedactions converts redaction items to the form stored with documents
+func storedRedactions(items []redaction.RedactionItem) []models.Redaction {
+	stored := make([]models.Redaction, len(items))
+	for i, item := range items {
+		stored[i] = models.Redaction{
+			ID:        item.ID,
+			Page:      item.Page,
+			Text:      item.Text,
+			BBox:      item.BBox,
+			Type:      item.Type,
+			Citation:  item.Citation,
+			Reason:    item.Reason,
+			LegalCode: item.LegalCode,
+			Applied:   item.Applied,
+		}
+	}
+	return stored
+}
+
+// redactionStoreErrorResponse reports redaction results that were produced
+// but could not be stored with the document
+func redactionStoreErrorResponse(documentID string, err error) *internalModels.APIResponse {
+	return internalModels.NewErrorResponse(
+		"redaction_store_error",
+		"Failed to store redaction results with the document",
+		map[string]interface{}{"document_id": documentID, "error": err.Error()},
+	)
+}
+
+// redactionErrorResponse maps a redaction error to a status and response.
+// Documents over a limit get 413 and timeouts 504; anything else is a 500
+// with the given code and message.
+func redactionErrorResponse(err error, code, message string) (int, *internalModels.APIResponse) {
+	var limitErr *redaction.LimitError
+	var formatErr *redaction.UnsupportedFormatError
+	switch {
+	case errors.As(err, &formatErr):
+		return fiber.StatusBadRequest, internalModels.NewErrorResponse(
+			"file_type_error",
+			formatErr.Error(),
+			map[string]interface{}{"supported_formats": redaction.SupportedFormats},
+		)
+	case errors.Is(err, redaction.ErrPDFRedactionNotImplemented):
+		return fiber.StatusNotImplemented, internalModels.NewErrorResponse(
+			"not_implemented",
+			"Applying redactions to a PDF is not supported yet; analyze it without apply_redactions, or upload it with output=overlay to get the regions to cover",
+			nil,
+		)
+	case errors.Is(err, redaction.ErrInvalidDocument):
+		return fiber.StatusBadRequest, internalModels.NewErrorResponse(
+			"invalid_document",
+			err.Error(),
+			nil,
+		)
+	case errors.As(err, &limitErr):
+		return fiber.StatusRequestEntityTooLarge, internalModels.NewErrorResponse(
+			"document_too_large",
+			limitErr.Error(),
+			map[string]interface{}{
+				"limit":  limitErr.Limit,
+				"max":    limitErr.Max,
+				"actual": limitErr.Actual,
+			},
+		)
+	case errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout, internalModels.NewErrorResponse(
+			"redaction_timeout",
+			"Redaction analysis timed out",
+			map[string]interface{}{"error": err.Error()},
+		)
+	}
+	return fiber.StatusInternalServerError, internalModels.NewErrorResponse(
+		code,
+		message,
+		map[string]interface{}{"error": err.Error()},
+	)
+}
+
+// redactExistingDocument redacts the PDF of an indexed document, downloaded
+// from storage, or a PDF sent as pdf_base64. As with uploads, results are
+// stored with the document when document_id is given. With store_result the
+// redacted PDF is also uploaded under the redacted/ prefix.
+func (h *ProcessingHandler) redactExistingDocument(c *fiber.Ctx, ctx context.Context) error {
+	var request internalModels.RedactDocumentRequest
+
+	// Parse request body
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"parse_error",
+			"Failed to parse request body",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+
+	// Validate request - need either document_id or pdf_base64
+	if request.DocumentID == "" && request.PDFBase64 == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"Either document_id or pdf_base64 is required",
+			nil,
+		))
+	}
+	if len(request.CustomRedactions) > 0 && !request.ApplyRedactions {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			"custom_redactions requires apply_redactions",
+			nil,
+		))
+	}
+
+	documentID := request.DocumentID
+	if documentID != "" {
+		if status, response := h.checkRedactionTarget(ctx, documentID); response != nil {
+			return c.Status(status).JSON(response)
+		}
+	}
+
+	// A PDF sent with the request takes the place of the stored one
+	var content []byte
+	filename := "document.pdf"
+	sourcePath := ""
+	if request.PDFBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(request.PDFBase64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"validation_error",
+				"pdf_base64 is not valid base64",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+		content = decoded
+		if documentID != "" {
+			filename = documentID + ".pdf"
+		}
+	} else {
+		document, data, status, response := h.downloadDocumentPDF(ctx, documentID)
+		if response != nil {
+			return c.Status(status).JSON(response)
+		}
+		content = data
+		sourcePath = document.FilePath
+		filename = document.FileName
+		if filename == "" {
+			filename = path.Base(document.FilePath)
+		}
+	}
+
+	if !bytes.HasPrefix(content, []byte("%PDF-")) {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"file_type_error",
+			"Only PDF files are supported for redaction",
+			nil,
+		))
+	}
+
+	options := &redaction.Options{
+		CaliforniaLaws:  true, // Default to California laws
+		ReplacementChar: "■",
+	}
+	if opts := request.Options; opts != nil {
+		options.UseAI = opts.UseAI
+		options.IncludePatterns = opts.IncludePatterns
+		options.ExcludePatterns = opts.ExcludePatterns
+		if opts.ReplacementChar != "" {
+			options.ReplacementChar = opts.ReplacementChar
+		}
+		options.ReplacementRules = opts.ReplacementRules
+	}
+	if err := redaction.ValidateReplacementRules(options.ReplacementRules); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
+	redactionService := h.redactionService()
+	start := time.Now()
+
+	if !request.ApplyRedactions {
+		analysis, err := redactionService.AnalyzePDF(ctx, bytes.NewReader(content), options)
+		if err != nil {
+			status, response := redactionErrorResponse(err, "analysis_error", "Failed to analyze document")
+			return c.Status(status).JSON(response)
+		}
+		if !analysis.Success {
+			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+				"analysis_failed",
+				analysis.Error,
+				nil,
+			))
+		}
+
+		if documentID != "" {
+			if err := h.saveRedactions(ctx, documentID, analysis.Redactions, analysis.TotalCount); err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+			}
+		}
+
+		response := &internalModels.RedactDocumentResponse{
+			Success:         true,
+			DocumentID:      documentID,
+			Filename:        filename,
+			Redactions:      convertRedactionItems(analysis.Redactions),
+			TotalRedactions: analysis.TotalCount,
+			AnalysisTimeMs:  time.Since(start).Milliseconds(),
+			Message:         "Document analyzed for potential redactions",
+		}
+		return c.JSON(internalModels.NewSuccessResponse(response, "Document analysis completed"))
+	}
+
+	// Redactions chosen by the caller replace the pattern matches
+	var result *redaction.Result
+	var err error
+	if len(request.CustomRedactions) > 0 {
+		result, err = redactionService.ApplyCustomRedactions(ctx, bytes.NewReader(content), convertInternalRedactionItems(request.CustomRedactions))
+	} else {
+		result, err = redactionService.RedactPDF(ctx, bytes.NewReader(content), options)
+	}
+	if err != nil {
+		status, response := redactionErrorResponse(err, "redaction_error", "Failed to redact document")
+		return c.Status(status).JSON(response)
+	}
+	if !result.Success {
+		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+			"redaction_failed",
+			result.Error,
+			nil,
+		))
+	}
+
+	response := &internalModels.RedactDocumentResponse{
+		Success:         true,
+		DocumentID:      documentID,
+		PDFBase64:       result.PDFBase64,
+		Filename:        fmt.Sprintf("redacted_%s", filename),
+		Redactions:      convertRedactionItems(result.Redactions),
+		TotalRedactions: result.TotalCount,
+		AnalysisTimeMs:  time.Since(start).Milliseconds(),
+		Message:         "Document redacted successfully",
+	}
+
+	if documentID != "" {
+		if err := h.saveRedactions(ctx, documentID, result.Redactions, result.TotalCount); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+		}
+		if err := h.auditRedactions(c, ctx, documentID, result.Redactions, result.TotalCount); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(redactionStoreErrorResponse(documentID, err))
+		}
+	}
+
+	if request.StoreResult {
+		if sourcePath == "" {
+			sourcePath = response.Filename
+		}
+		url, err := h.storeRedactedPDF(ctx, sourcePath, response.Filename, result.PDFBase64)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+				"storage_error",
+				"Failed to store redacted document",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+		response.RedactedURL = &url
+	}
+
+	return c.JSON(internalModels.NewSuccessResponse(response, "Document redacted successfully"))
+}
+
+// redactionService creates a redaction service with the configured limits
+func (h *ProcessingHandler) redactionService() redaction.Service {
+	return redaction.NewServiceWithLimits(true, h.cfg.OpenAI.APIKey, redaction.Limits{
+		MaxPages:    h.cfg.Processing.RedactionMaxPages,
+		MaxFileSize: h.cfg.Processing.RedactionMaxFileSize,
+	})
+}
+
+// downloadDocumentPDF looks up an indexed document and downloads its PDF
+// from storage, reporting an error response when the document or its file
+// is missing, is not a PDF or is over REDACTION_MAX_FILE_SIZE, or when the
+// download fails or times out
+func (h *ProcessingHandler) downloadDocumentPDF(ctx context.Context, documentID string) (*models.Document, []byte, int, *internalModels.APIResponse) {
+	document, err := h.searchSvc.GetDocument(ctx, documentID)
+	if err != nil {
+		if err.Error() == "document not found" {
+			return nil, nil, fiber.StatusNotFound, internalModels.NewErrorResponse(
+				"not_found",
+				"Document not found",
+				map[string]interface{}{"document_id": documentID},
+			)
+		}
+		return nil, nil, fiber.StatusInternalServerError, internalModels.NewErrorResponse(
+			"search_error",
+			"Failed to retrieve document",
+			map[string]interface{}{"error": err.Error()},
+		)
+	}
+	if document.FilePath == "" {
+		return nil, nil, fiber.StatusNotFound, internalModels.NewErrorResponse(
+			"not_found",
+			"Document has no stored file",
+			map[string]interface{}{"document_id": documentID},
+		)
+	}
+
+	isPDF := strings.HasSuffix(strings.ToLower(document.FilePath), ".pdf")
+	if document.ContentType != "" {
+		isPDF = strings.Contains(strings.ToLower(document.ContentType), "pdf")
+	}
+	if !isPDF {
+		return nil, nil, fiber.StatusBadRequest, internalModels.NewErrorResponse(
+			"file_type_error",
+			"Only PDF files are supported for redaction",
+			map[string]interface{}{"document_id": documentID, "content_type": document.ContentType, "path": document.FilePath},
+		)
+	}
+
+	if h.storage == nil {
+		return nil, nil, fiber.StatusServiceUnavailable, internalModels.NewErrorResponse(
+			"service_unavailable",
+			"Storage service is not configured",
+			nil,
+		)
+	}
+
+	reader, err := h.storage.Download(ctx, document.FilePath)
+	if err == nil {
+		defer reader.Close()
+		var content []byte
+		content, err = readRedactionSource(reader, h.cfg.Processing.RedactionMaxFileSize)
+		if err == nil {
+			return document, content, 0, nil
+		}
+	}
+
+	var limitErr *redaction.LimitError
+	var storageErr *storage.StorageError
+	switch {
+	case errors.As(err, &limitErr):
+		status, response := redactionErrorResponse(err, "", "")
+		return nil, nil, status, response
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, nil, fiber.StatusGatewayTimeout, internalModels.NewErrorResponse(
+			"storage_timeout",
+			"Timed out downloading document from storage",
+			map[string]interface{}{"path": document.FilePath, "error": err.Error()},
+		)
+	case errors.As(err, &storageErr) && storageErr.Type == "not_found":
+		return nil, nil, fiber.StatusNotFound, internalModels.NewErrorResponse(
+			"not_found",
+			"Document file not found in storage",
+			map[string]interface{}{"document_id": documentID, "path": document.FilePath},
+		)
+	}
+	return nil, nil, fiber.StatusInternalServerError, internalModels.NewErrorResponse(
+		"storage_error",
+		"Failed to download document from storage",
+		map[string]interface{}{"path": document.FilePath, "error": err.Error()},
+	)
+}
+
+// readRedactionSource reads a downloaded PDF, stopping with a LimitError as
+// soon as it is larger than maxSize bytes
+func readRedactionSource(reader io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(reader)
+	}
+	content, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, &redaction.LimitError{Limit: "file_size", Max: maxSize, Actual: int64(len(content))}
+	}
+	return content, nil
+}
+
+// storeRedactedPDF uploads a redacted PDF to redacted/ followed by the
+// original's storage path and returns its URL
+func (h *ProcessingHandler) storeRedactedPDF(ctx context.Context, sourcePath, filename, pdfBase64 string) (string, error) {
+	if h.storage == nil {
+		return "", fmt.Errorf("storage service is not configured")
 	}
-
-	// Validate request - need either document_id or pdf_base64
-	if request.DocumentID == "" && request.PDFBase64 == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
-			"validation_error",
-			"Either document_id or pdf_base64 is required",
-			nil,
-		))
+	content, err := base64.StdEncoding.DecodeString(pdfBase64)
+	if err != nil {
+		return "", fmt.Errorf("redacted PDF is not valid base64: %w", err)
 	}
 
-	// TODO: For now, return a comprehensive placeholder response
-	// In a full implementation, this would:
-	// 1. Retrieve the document from storage if document_id is provided
-	// 2. Decode PDF from base64 if pdf_base64 is provided
-	// 3. Apply the redaction service
-	// 4. Store the redacted document if needed
-	// 5. Return the result
+	result, err := h.storage.Upload(ctx, "redacted/"+strings.TrimPrefix(sourcePath, "/"), bytes.NewReader(content), &storage.UploadMetadata{
+		ContentType: "application/pdf",
+		Size:        int64(len(content)),
+		FileName:    filename,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.URL, nil
+}
 
-	response := &internalModels.RedactDocumentResponse{
-		Success:         false,
-		DocumentID:      request.DocumentID,
-		Redactions:      request.CustomRedactions, // These are already the right type
-		TotalRedactions: len(request.CustomRedactions),
-		Message:         "Document redaction by ID is not yet fully implemented - requires document retrieval from storage",
+// convertOverlayRegions keeps the positional fields of redaction items for overlay rendering
+func convertOverlayRegions(items []redaction.RedactionItem) []internalModels.RedactionOverlayRegion {
+	regions := make([]internalModels.RedactionOverlayRegion, len(items))
+	for i, item := range items {
+		regions[i] = internalModels.RedactionOverlayRegion{
+			ID:        item.ID,
+			Page:      item.Page,
+			BBox:      item.BBox,
+			Type:      item.Type,
+			Reason:    item.Reason,
+			Citation:  item.Citation,
+			LegalCode: item.LegalCode,
+		}
 	}
+	return regions
+}
 
-	return c.JSON(internalModels.NewSuccessResponse(response, "Redaction request processed"))
+// convertRedactionRegions converts redaction items to the regions they cover
+func convertRedactionRegions(items []redaction.RedactionItem) []internalModels.RedactionRegion {
+	regions := make([]internalModels.RedactionRegion, len(items))
+	for i, item := range items {
+		regions[i] = internalModels.RedactionRegion{Page: item.Page, Type: item.Type}
+		if len(item.BBox) == 4 {
+			regions[i].X = item.BBox[0]
+			regions[i].Y = item.BBox[1]
+			regions[i].Width = item.BBox[2] - item.BBox[0]
+			regions[i].Height = item.BBox[3] - item.BBox[1]
+		}
+	}
+	return regions
 }
 
 // convertRedactionItems converts between redaction types
@@ -346,6 +1244,69 @@ func convertInternalRedactionItems(items []internalModels.RedactionItem) []redac
 	return result
 }
 
+// GetPipelineStatus handles GET /api/v1/pipeline/status, reporting whether each
+// processing stage is configured and healthy plus the default upload options
+func (h *ProcessingHandler) GetPipelineStatus(c *fiber.Ctx) error {
+	response := &internalModels.PipelineStatusResponse{
+		ProcessorStatus:     make(map[string]*internalModels.ProcessorStatus),
+		LastUpdate:          time.Now(),
+		DefaultOptions:      h.defaultProcessOptions(),
+		BatchDefaultOptions: h.defaultBatchProcessOptions(),
+	}
+
+	healthy := true
+	if h.pipeline != nil {
+		status := h.pipeline.GetStatus()
+		response.Mode = "pipeline"
+		response.ActiveJobs = int64(status.ActiveJobs)
+		response.QueuedJobs = int64(status.QueuedJobs)
+		response.CompletedJobs = status.CompletedJobs
+		response.FailedJobs = status.FailedJobs
+		if status.WorkerPoolStats != nil {
+			response.WorkerCount = status.WorkerPoolStats.WorkerCount
+		}
+		for _, proc := range status.ProcessorStatus {
+			response.ProcessorStatus[string(proc.Type)] = processorStatus(string(proc.Type), proc.Configured, proc.Healthy)
+		}
+		healthy = h.pipeline.IsHealthy()
+	} else {
+		// Legacy mode stores and indexes directly; extraction and classification are placeholders
+		response.Mode = "legacy"
+		response.ProcessorStatus[string(pipeline.ProcessorTypeExtraction)] = processorStatus(string(pipeline.ProcessorTypeExtraction), false, false)
+		response.ProcessorStatus[string(pipeline.ProcessorTypeClassification)] = processorStatus(string(pipeline.ProcessorTypeClassification), false, false)
+		response.ProcessorStatus[string(pipeline.ProcessorTypeStorage)] = processorStatus(string(pipeline.ProcessorTypeStorage), h.storage != nil, h.storage != nil && h.storage.IsHealthy())
+		response.ProcessorStatus[string(pipeline.ProcessorTypeIndexing)] = processorStatus(string(pipeline.ProcessorTypeIndexing), h.searchSvc != nil, h.searchSvc != nil && h.searchSvc.IsHealthy())
+		healthy = response.ProcessorStatus[string(pipeline.ProcessorTypeStorage)].Healthy &&
+			response.ProcessorStatus[string(pipeline.ProcessorTypeIndexing)].Healthy
+	}
+
+	response.Status = "healthy"
+	if !healthy {
+		response.Status = "degraded"
+	}
+
+	return c.JSON(internalModels.NewSuccessResponse(response, "Pipeline status retrieved successfully"))
+}
+
+// processorStatus builds the reported status of a single processing stage
+func processorStatus(procType string, configured, healthy bool) *internalModels.ProcessorStatus {
+	status := &internalModels.ProcessorStatus{
+		Type:       procType,
+		Status:     "healthy",
+		Configured: configured,
+		Healthy:    healthy,
+	}
+	switch {
+	case !configured:
+		status.Status = "unavailable"
+		status.Error = "processor not configured"
+	case !healthy:
+		status.Status = "unhealthy"
+		status.Error = "processor unhealthy"
+	}
+	return status
+}
+
 // ProcessDocument processes a single document upload
 func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 	// Parse the multipart form
@@ -370,36 +1331,29 @@ func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 
 	file := files[0]
 
-	// Parse processing options from individual form fields or JSON string
-	var processOptions *internalModels.ProcessOptions
-	if optionsStr := c.FormValue("options"); optionsStr != "" {
+	// Parse processing options from individual form fields or JSON string.
+	// Omitted step fields fall back to the configured server defaults.
+	processOptions := h.defaultProcessOptions()
+	if optionsStr := c.FormValue("options"); optionsStr == "" {
 		// TODO: Parse JSON from the options string in future
-		processOptions = internalModels.DefaultProcessOptions()
-	} else {
-		// Parse individual form fields (priority over defaults)
-		processOptions = &internalModels.ProcessOptions{
-			ExtractText:    c.FormValue("extract_text") != "false",    // Default true, set false only if explicitly "false"
-			ClassifyDoc:    c.FormValue("classify_doc") != "false",    // Default true, set false only if explicitly "false"
-			IndexDocument:  c.FormValue("index_document") != "false",  // Default true, set false only if explicitly "false"
-			StoreDocument:  c.FormValue("store_document") != "false",  // Default true, set false only if explicitly "false"
-			TimeoutSeconds: 120,
-			RetryCount:     1,
-		}
-		
-		// Override defaults if explicit values provided
-		if c.FormValue("extract_text") == "false" {
-			processOptions.ExtractText = false
-		}
-		if c.FormValue("classify_doc") == "false" {
-			processOptions.ClassifyDoc = false
-		}
-		if c.FormValue("index_document") == "false" {
-			processOptions.IndexDocument = false
-		}
-		if c.FormValue("store_document") == "false" {
-			processOptions.StoreDocument = false
-		}
+		applyProcessingStepFields(c, processOptions)
+		processOptions.IndexImmediately = c.FormValue("index_immediately") != "false" // Single uploads are searchable on return unless deferred
+		processOptions.ExtractReferences = c.FormValue("extract_references") == "true"
+		processOptions.ExtractPages = c.FormValue("extract_pages") == "true"
+		processOptions.ComputeReadability = c.FormValue("compute_readability") == "true"
+	}
+
+	if minLength, err := parseMinIndexableTextLength(c); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	} else if minLength != nil {
+		processOptions.MinIndexableTextLength = minLength
 	}
+	processOptions.OnConflict = c.FormValue("on_conflict")
+	processOptions.WarmCDN = c.FormValue("warm_cdn") == "true"
 
 	// Validate and apply defaults
 	if err := processOptions.Validate(); err != nil {
@@ -411,6 +1365,15 @@ func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 	}
 	processOptions.ApplyDefaults()
 
+	// Applied after the defaults so an explicit retry_count of 0 disables retries
+	if err := applyRetryCount(c, processOptions); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
 	// Build processing request
 	request := &internalModels.ProcessDocumentRequest{
 		File:        file,
@@ -422,7 +1385,20 @@ func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 		Judge:       c.FormValue("judge"),
 		Court:       c.FormValue("court"),
 		Options:     processOptions,
+
+		SourceSystem:     c.FormValue("source_system", models.SourceSystemUpload),
+		IngestionBatchID: c.FormValue("ingestion_batch_id"),
+	}
+
+	customMetadata, err := models.ParseCustomMetadata(c.FormValue("custom_metadata"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
 	}
+	request.CustomMetadata = customMetadata
 
 	// Validate the request
 	if err := internalModels.ValidateStruct(request); err != nil {
@@ -442,7 +1418,19 @@ func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 
 	// Process the document using the pipeline
 	startTime := time.Now()
-	result, err := h.processDocumentWithPipeline(request)
+	result, err := h.processDocumentWithPipeline(context.Background(), request)
+	var validationErr *pipeline.ValidationError
+	if errors.As(err, &validationErr) {
+		return c.Status(validationStatus(validationErr)).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			validationErr.Message,
+			map[string]interface{}{
+				"field": validationErr.Field,
+				"value": validationErr.Value,
+				"limit": validationErr.Limit,
+			},
+		))
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
 			"processing_error",
@@ -452,6 +1440,10 @@ func (h *ProcessingHandler) ProcessDocument(c *fiber.Ctx) error {
 	}
 
 	result.ProcessingTime = time.Since(startTime).Milliseconds()
+	if result.Timing != nil {
+		// The total covers the whole request, as processing_time_ms does
+		result.Timing.TotalMs = result.ProcessingTime
+	}
 	result.CreatedAt = time.Now()
 
 	// Return successful response
@@ -480,8 +1472,25 @@ func (h *ProcessingHandler) BatchProcessDocuments(c *fiber.Ctx) error {
 		))
 	}
 
-	// Parse processing options
-	processOptions := internalModels.DefaultProcessOptions()
+	// Parse processing options. Batches defer index visibility to the periodic
+	// refresh for throughput unless the client asks otherwise.
+	processOptions := h.defaultBatchProcessOptions()
+	applyProcessingStepFields(c, processOptions)
+	processOptions.IndexImmediately = c.FormValue("index_immediately") == "true"
+	processOptions.ExtractReferences = c.FormValue("extract_references") == "true"
+	processOptions.ExtractPages = c.FormValue("extract_pages") == "true"
+	processOptions.ComputeReadability = c.FormValue("compute_readability") == "true"
+	processOptions.OnConflict = c.FormValue("on_conflict")
+	processOptions.WarmCDN = c.FormValue("warm_cdn") == "true"
+	minLength, err := parseMinIndexableTextLength(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+	processOptions.MinIndexableTextLength = minLength
 	if err := processOptions.Validate(); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
 			"validation_error",
@@ -491,6 +1500,15 @@ func (h *ProcessingHandler) BatchProcessDocuments(c *fiber.Ctx) error {
 	}
 	processOptions.ApplyDefaults()
 
+	// Applied after the defaults so an explicit retry_count of 0 disables retries
+	if err := applyRetryCount(c, processOptions); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
+	}
+
 	// Build batch processing request
 	request := &internalModels.BatchProcessRequest{
 		Files:       files,
@@ -499,6 +1517,18 @@ func (h *ProcessingHandler) BatchProcessDocuments(c *fiber.Ctx) error {
 		CaseName:    c.FormValue("case_name"),
 		CaseNumber:  c.FormValue("case_number"),
 		Options:     processOptions,
+
+		SourceSystem:     c.FormValue("source_system", models.SourceSystemBatchUpload),
+		IngestionBatchID: c.FormValue("ingestion_batch_id"),
+	}
+
+	request.CustomMetadata, err = models.ParseCustomMetadata(c.FormValue("custom_metadata"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			err.Error(),
+			nil,
+		))
 	}
 
 	// Validate the request
@@ -523,12 +1553,37 @@ func (h *ProcessingHandler) BatchProcessDocuments(c *fiber.Ctx) error {
 	batchResult.ProcessingTime = time.Since(startTime).Milliseconds()
 	batchResult.CompletedAt = time.Now()
 
-	// Return batch response
-	return c.JSON(internalModels.NewSuccessResponse(batchResult, "Batch processing completed"))
+	// Mixed results get 207 so clients that only look at the status code
+	// still notice the failed files; total failure is reported as an error.
+	statusCode := batchStatusCode(batchResult)
+	switch batchResult.Status {
+	case internalModels.BatchStatusFailed:
+		return c.Status(statusCode).JSON(internalModels.NewErrorResponse(
+			"batch_failed",
+			"All documents in the batch failed to process",
+			map[string]interface{}{"failure_count": batchResult.FailureCount},
+		).WithData(batchResult))
+	case internalModels.BatchStatusPartialSuccess:
+		return c.Status(statusCode).JSON(internalModels.NewSuccessResponse(batchResult, "Batch processing completed with failures"))
+	default:
+		return c.Status(statusCode).JSON(internalModels.NewSuccessResponse(batchResult, "Batch processing completed"))
+	}
+}
+
+// batchStatusCode maps a batch status to the HTTP status code returned to the client
+func batchStatusCode(result *internalModels.BatchProcessResponse) int {
+	switch result.Status {
+	case internalModels.BatchStatusPartialSuccess:
+		return fiber.StatusMultiStatus
+	case internalModels.BatchStatusFailed:
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusOK
+	}
 }
 
 // processDocumentWithPipeline processes a single document through the pipeline
-func (h *ProcessingHandler) processDocumentWithPipeline(request *internalModels.ProcessDocumentRequest) (*internalModels.ProcessDocumentResponse, error) {
+func (h *ProcessingHandler) processDocumentWithPipeline(parent context.Context, request *internalModels.ProcessDocumentRequest) (*internalModels.ProcessDocumentResponse, error) {
 	file := request.File
 
 	// Generate document ID
@@ -540,11 +1595,12 @@ func (h *ProcessingHandler) processDocumentWithPipeline(request *internalModels.
 		Status:     "processing",
 		Steps:      []*internalModels.ProcessingStep{},
 		Metadata: &models.DocumentMetadata{
-			DocumentName: file.Filename,
-			CaseName:     request.CaseName,
-			CaseNumber:   request.CaseNumber,
-			Author:       request.Author,
-			ProcessedAt:  time.Now(),
+			DocumentName:     file.Filename,
+			OriginalFileName: file.Filename,
+			CaseName:         request.CaseName,
+			CaseNumber:       request.CaseNumber,
+			Author:           request.Author,
+			ProcessedAt:      time.Now(),
 		},
 	}
 	
@@ -564,23 +1620,16 @@ func (h *ProcessingHandler) processDocumentWithPipeline(request *internalModels.
 
 	// Check if pipeline is available
 	if h.pipeline == nil {
-		return h.processDocumentLegacyMode(request)
-	}
-
-	// Read file content
-	fileReader, err := file.Open()
-	if err != nil {
-		response.Status = "failed"
-		return response, fmt.Errorf("failed to open file: %w", err)
+		return h.processDocumentLegacyMode(parent, request)
 	}
-	defer fileReader.Close()
 
-	// Read content into memory
-	content, err := io.ReadAll(fileReader)
+	// Large files are spooled to disk rather than held in memory
+	content, cleanup, err := h.uploadContent(file)
 	if err != nil {
 		response.Status = "failed"
-		return response, fmt.Errorf("failed to read file content: %w", err)
+		return response, err
 	}
+	defer cleanup()
 
 	// Create pipeline processing request
 	pipelineRequest := &pipeline.ProcessRequest{
@@ -588,42 +1637,191 @@ func (h *ProcessingHandler) processDocumentWithPipeline(request *internalModels.
 		FileName:    file.Filename,
 		ContentType: file.Header.Get("Content-Type"),
 		Size:        file.Size,
-		Content:     bytes.NewReader(content),
 		Options: &pipeline.ProcessOptions{
 			ExtractText:    request.Options.ExtractText,
 			ClassifyDoc:    request.Options.ClassifyDoc,
 			StoreDocument:  request.Options.StoreDocument,
 			IndexDocument:  request.Options.IndexDocument,
 			TimeoutSeconds: int(request.Options.TimeoutSeconds),
+
+			IndexImmediately:       request.Options.IndexImmediately,
+			ExtractReferences:      request.Options.ExtractReferences,
+			ExtractPages:           request.Options.ExtractPages,
+			ComputeReadability:     request.Options.ComputeReadability,
+			MinIndexableTextLength: request.Options.MinIndexableTextLength,
+			WarmCDN:                request.Options.WarmCDN,
+			OnConflict:             h.conflictPolicy(request.Options.OnConflict),
 		},
 		Metadata: map[string]string{
-			"case_name":   request.CaseName,
-			"case_number": request.CaseNumber,
-			"author":      request.Author,
-			"judge":       request.Judge,
-			"court":       request.Court,
-			"category":    request.Category,
+			"case_name":          request.CaseName,
+			"case_number":        request.CaseNumber,
+			"author":             request.Author,
+			"judge":              request.Judge,
+			"court":              request.Court,
+			"category":           request.Category,
+			"original_file_name": file.Filename,
+			"source_system":      request.SourceSystem,
+			"ingestion_batch_id": request.IngestionBatchID,
 		},
+		CustomMetadata: request.CustomMetadata,
+	}
+
+	// Documents that need not be searchable on return skip the pipeline's
+	// indexing step and are handed to the indexing queue afterwards
+	queueIndexing := h.queuesIndexing(request.Options)
+	if queueIndexing {
+		pipelineRequest.Options.IndexDocument = false
 	}
 
-	// Process document through pipeline
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(request.Options.TimeoutSeconds)*time.Second)
+	// Process document through pipeline. The timeout covers every attempt.
+	ctx, cancel := context.WithTimeout(parent, time.Duration(request.Options.TimeoutSeconds)*time.Second)
 	defer cancel()
 
-	pipelineResult, err := h.pipeline.ProcessDocument(ctx, pipelineRequest)
+	pipelineResult, attempts, err := h.processWithRetry(ctx, pipelineRequest, content, request.Options.RetryCount)
+	response.Attempts = attempts
 	if err != nil {
 		response.Status = "failed"
+		if attempts > 1 {
+			return response, fmt.Errorf("pipeline processing failed after %d attempts: %w", attempts, err)
+		}
 		return response, fmt.Errorf("pipeline processing failed: %w", err)
 	}
 
 	// Convert pipeline results to handler response format
 	h.convertPipelineResults(pipelineResult, response)
 
+	if queueIndexing {
+		var text, documentPath string
+		if pipelineResult.ExtractionResult != nil {
+			text = pipelineResult.ExtractionResult.Text
+		}
+		if pipelineResult.StorageResult != nil {
+			documentPath = pipelineResult.StorageResult.StoragePath
+		}
+		response.IndexResult = h.enqueueIndexing(ctx, documentID, documentPath, text, pipelineResult.ClassificationResult)
+	}
+
 	return response, nil
 }
 
+// queuesIndexing reports whether a document processed with opts is indexed
+// through the indexing queue rather than inline. The queue indexes documents
+// from their text and classification, so unclassified ones stay inline.
+func (h *ProcessingHandler) queuesIndexing(opts *internalModels.ProcessOptions) bool {
+	return opts.IndexDocument && !opts.IndexImmediately && opts.ExtractText && opts.ClassifyDoc && h.queueManager != nil
+}
+
+// enqueueIndexing hands a processed document to the indexing queue. A
+// document left without text or a classification cannot be queued and is
+// reported as skipped.
+func (h *ProcessingHandler) enqueueIndexing(ctx context.Context, documentID, documentPath, text string, classificationResult *classifier.ClassificationResult) *internalModels.IndexResult {
+	result := &internalModels.IndexResult{
+		DocumentID: documentID,
+		IndexName:  "documents",
+	}
+	if text == "" || classificationResult == nil {
+		result.Success = true
+		result.Skipped = true
+		result.SkipReason = "no extracted text or classification to index"
+		return result
+	}
+
+	doc := BatchDocumentInput{DocumentID: documentID, DocumentPath: documentPath}
+	if err := enqueueForIndexing(ctx, h.queueManager, doc, text, classificationResult, nil); err != nil {
+		log.Printf("[INDEXING] ❌ Failed to queue %s for indexing: %v", documentID, err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	result.Queued = true
+	return result
+}
+
+// validationStatus is the HTTP status of a document the pipeline rejects
+func validationStatus(err *pipeline.ValidationError) int {
+	switch err.Field {
+	case "size":
+		return fiber.StatusRequestEntityTooLarge
+	case "content_type":
+		return fiber.StatusUnsupportedMediaType
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// processWithRetry runs a document through the pipeline, retrying the whole
+// run up to retries more times while it fails with a transient error. Each
+// attempt reads the content afresh. It returns the number of attempts made.
+func (h *ProcessingHandler) processWithRetry(ctx context.Context, req *pipeline.ProcessRequest, content io.ReadSeeker, retries int) (*pipeline.ProcessResult, int, error) {
+	backoff := h.retryBackoff()
+	for attempt := 1; ; attempt++ {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, attempt, fmt.Errorf("failed to rewind file content: %w", err)
+		}
+		attemptReq := *req
+		attemptReq.Content = content
+		attemptReq.Metadata = maps.Clone(req.Metadata)
+
+		result, err := h.pipeline.ProcessDocument(ctx, &attemptReq)
+		if err == nil || attempt > retries || !pipeline.IsRetryable(err) || ctx.Err() != nil {
+			return result, attempt, err
+		}
+
+		// Give up rather than sleep past the request deadline
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return result, attempt, err
+		}
+		backoff *= 2
+	}
+}
+
+// uploadContent opens an uploaded file for the pipeline. Files up to
+// UPLOAD_SPOOL_THRESHOLD bytes are read into memory; larger ones are copied
+// to a temporary file, so concurrent large uploads don't each hold their
+// whole content in memory. cleanup closes and removes the temporary file.
+func (h *ProcessingHandler) uploadContent(file *multipart.FileHeader) (io.ReadSeeker, func(), error) {
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer fileReader.Close()
+
+	threshold := h.cfg.Processing.UploadSpoolThreshold
+	if threshold <= 0 || file.Size <= threshold {
+		content, err := io.ReadAll(fileReader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read file content: %w", err)
+		}
+		return bytes.NewReader(content), func() {}, nil
+	}
+
+	spool, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(spool, fileReader); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to spool file content: %w", err)
+	}
+	return spool, cleanup, nil
+}
+
+// retryBackoff returns the delay before the first pipeline retry
+func (h *ProcessingHandler) retryBackoff() time.Duration {
+	if h.cfg != nil {
+		return h.cfg.Processing.RetryBackoff
+	}
+	return time.Second
+}
+
 // processDocumentLegacyMode processes document using the legacy implementation (fallback)
-func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.ProcessDocumentRequest) (*internalModels.ProcessDocumentResponse, error) {
+func (h *ProcessingHandler) processDocumentLegacyMode(parent context.Context, request *internalModels.ProcessDocumentRequest) (*internalModels.ProcessDocumentResponse, error) {
 	file := request.File
 
 	// Generate document ID
@@ -635,11 +1833,12 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 		Status:     "processing",
 		Steps:      []*internalModels.ProcessingStep{},
 		Metadata: &models.DocumentMetadata{
-			DocumentName: file.Filename,
-			CaseName:     request.CaseName,
-			CaseNumber:   request.CaseNumber,
-			Author:       request.Author,
-			ProcessedAt:  time.Now(),
+			DocumentName:     file.Filename,
+			OriginalFileName: file.Filename,
+			CaseName:         request.CaseName,
+			CaseNumber:       request.CaseNumber,
+			Author:           request.Author,
+			ProcessedAt:      time.Now(),
 		},
 	}
 	
@@ -684,7 +1883,7 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 		extractionResult := &internalModels.ExtractionResult{
 			Text:      "Extracted text content will be processed by the pipeline",
 			PageCount: 1,
-			Language:  "en",
+			Language:  extractor.LanguageUnknown,
 		}
 
 		step.Status = "completed"
@@ -746,26 +1945,53 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 		}
 		defer fileReader.Close()
 
-		// Create storage path
-		storagePath := fmt.Sprintf("documents/%s/%s", documentID, file.Filename)
+		// Create storage path from a storage-safe name; the original name travels
+		// with the object so downloads can restore it
+		storagePath := fmt.Sprintf("documents/%s/%s", documentID, storage.SanitizeFileName(file.Filename))
+
+		// Upload with context
+		ctx, cancel := context.WithTimeout(parent, 30*time.Second)
+		defer cancel()
+
+		// Never silently replace an existing object unless the policy says so
+		policy, err := storage.ParseConflictPolicy(h.conflictPolicy(request.Options.OnConflict))
+		if err == nil {
+			storagePath, response.StorageAction, err = storage.ResolveConflict(ctx, h.storage, storagePath, policy)
+		}
+		if err != nil {
+			step.Status = "failed"
+			step.Error = err.Error()
+			step.EndTime = time.Now()
+			step.Duration = step.EndTime.Sub(step.StartTime).Milliseconds()
+			response.Status = "failed"
+			return response, fmt.Errorf("document storage failed: %w", err)
+		}
 
 		// Create upload metadata
 		uploadMetadata := &storage.UploadMetadata{
-			ContentType: file.Header.Get("Content-Type"),
-			Size:        file.Size,
-			FileName:    file.Filename,
+			ContentType:        file.Header.Get("Content-Type"),
+			Size:               file.Size,
+			FileName:           file.Filename,
+			ContentDisposition: storage.ContentDisposition("inline", file.Filename),
 			Tags: map[string]string{
 				"document_id": documentID,
 				"category":    request.Category,
 				"case_name":   request.CaseName,
 			},
+			WarmAfterUpload: request.Options.WarmCDN,
 		}
 
-		// Upload with context
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		uploadResult, err := h.storage.Upload(ctx, storagePath, fileReader, uploadMetadata)
+		var uploadResult *storage.UploadResult
+		if response.StorageAction == storage.ConflictActionSkipped {
+			// Keep the stored file; later steps refer to it
+			uploadResult = &storage.UploadResult{
+				Path:    storagePath,
+				URL:     h.storage.GetURL(storagePath),
+				Success: true,
+			}
+		} else {
+			uploadResult, err = h.storage.Upload(ctx, storagePath, fileReader, uploadMetadata)
+		}
 		if err != nil {
 			step.Status = "failed"
 			step.Error = err.Error()
@@ -780,11 +2006,48 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 		step.Duration = step.EndTime.Sub(step.StartTime).Milliseconds()
 		response.StorageResult = uploadResult
 		response.URL = uploadResult.URL
-		response.CDN_URL = uploadResult.URL // CDN URL same as URL for now
+		response.CDN_URL = storage.CDNURL(h.storage, uploadResult.Path)
+
+		// The CDN would keep serving the replaced file
+		if response.StorageAction == storage.ConflictActionOverwritten {
+			if err := storage.InvalidateCache(ctx, h.storage, []string{uploadResult.Path}); err != nil {
+				log.Printf("[PROCESSING] ⚠️ CDN cache invalidation failed for %s: %v", uploadResult.Path, err)
+			}
+		}
 	}
 
 	// Step 4: Document Indexing (if enabled)
-	if request.Options.IndexDocument && response.ExtractionResult != nil {
+	if h.queuesIndexing(request.Options) && response.ExtractionResult != nil {
+		step := &internalModels.ProcessingStep{
+			Name:      "document_indexing",
+			Status:    "running",
+			StartTime: time.Now(),
+		}
+		response.Steps = append(response.Steps, step)
+
+		var classificationResult *classifier.ClassificationResult
+		if response.ClassificationResult != nil {
+			classificationResult = &classifier.ClassificationResult{
+				DocumentType: response.ClassificationResult.Category,
+				Confidence:   response.ClassificationResult.Confidence,
+				LegalTags:    response.ClassificationResult.Tags,
+				Success:      true,
+			}
+		}
+		var documentPath string
+		if response.StorageResult != nil {
+			documentPath = response.StorageResult.Path
+		}
+
+		response.IndexResult = h.enqueueIndexing(parent, documentID, documentPath, response.ExtractionResult.Text, classificationResult)
+		step.Status = "completed"
+		if !response.IndexResult.Success {
+			step.Status = "failed"
+			step.Error = response.IndexResult.Error
+		}
+		step.EndTime = time.Now()
+		step.Duration = step.EndTime.Sub(step.StartTime).Milliseconds()
+	} else if request.Options.IndexDocument && response.ExtractionResult != nil {
 		step := &internalModels.ProcessingStep{
 			Name:      "document_indexing",
 			Status:    "running",
@@ -801,14 +2064,25 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			Metadata: &models.DocumentMetadata{
-				DocumentName: file.Filename,
-				CaseName:     request.CaseName,
-				CaseNumber:   request.CaseNumber,
-				Author:       request.Author,
+				DocumentName:     file.Filename,
+				OriginalFileName: file.Filename,
+				CaseName:         request.CaseName,
+				CaseNumber:       request.CaseNumber,
+				Author:           request.Author,
+				Custom:           request.CustomMetadata,
+				SourceSystem:     request.SourceSystem,
+				IngestionBatchID: request.IngestionBatchID,
 				// Note: Judge and Court fields are now complex structures in enhanced schema
 				// Legacy string fields are preserved in CaseName, CaseNumber, Author
 			},
 		}
+		if response.StorageResult != nil {
+			indexDoc.FilePath = response.StorageResult.Path
+			if h.cfg.Storage.IndexFileURLs {
+				indexDoc.FileURL = response.StorageResult.URL
+				indexDoc.CDNURL = response.CDN_URL
+			}
+		}
 
 		// Map legacy Judge and Court strings to enhanced structures
 		if request.Judge != "" {
@@ -833,10 +2107,11 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 		}
 
 		// Index the document
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 		defer cancel()
 
-		_, err := h.searchSvc.IndexDocument(ctx, indexDoc)
+		_, canWait := h.searchSvc.(search.RefreshingIndexer)
+		_, err := search.IndexDocument(ctx, h.searchSvc, indexDoc, request.Options.IndexImmediately)
 		if err != nil {
 			step.Status = "failed"
 			step.Error = err.Error()
@@ -851,6 +2126,7 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 				DocumentID: documentID,
 				IndexName:  "documents", // Default index name
 				Success:    true,
+				Searchable: request.Options.IndexImmediately && canWait,
 			}
 		}
 	}
@@ -859,9 +2135,16 @@ func (h *ProcessingHandler) processDocumentLegacyMode(request *internalModels.Pr
 	return response, nil
 }
 
-// processBatchDocuments processes multiple documents
+// processBatchDocuments processes multiple documents, running up to
+// BatchConcurrency files through the pipeline at once. Results and outcomes
+// keep the order of the uploaded files. Files that have not started when the
+// batch deadline passes are reported as timed out.
 func (h *ProcessingHandler) processBatchDocuments(request *internalModels.BatchProcessRequest) *internalModels.BatchProcessResponse {
 	batchID := generateBatchID()
+	ingestionBatchID := request.IngestionBatchID
+	if ingestionBatchID == "" {
+		ingestionBatchID = batchID
+	}
 
 	response := &internalModels.BatchProcessResponse{
 		BatchID:      batchID,
@@ -870,52 +2153,116 @@ func (h *ProcessingHandler) processBatchDocuments(request *internalModels.BatchP
 		FailureCount: 0,
 		Results:      make([]*internalModels.ProcessDocumentResponse, 0, len(request.Files)),
 		Errors:       make([]*internalModels.BatchProcessError, 0),
+		Outcomes:     make([]*internalModels.BatchFileOutcome, 0, len(request.Files)),
 		Status:       "processing",
 	}
 
-	// Process each file
-	for _, file := range request.Files {
-		// Create individual processing request
-		individualRequest := &internalModels.ProcessDocumentRequest{
-			File:        file,
-			Category:    request.Category,
-			Description: request.Description,
-			CaseName:    request.CaseName,
-			CaseNumber:  request.CaseNumber,
-			Options:     request.Options,
+	ctx, cancel := context.WithTimeout(context.Background(), h.batchTimeout())
+	defer cancel()
+
+	// Each worker writes only its own slot, so no locking is needed
+	results := make([]*internalModels.ProcessDocumentResponse, len(request.Files))
+	errs := make([]error, len(request.Files))
+
+	semaphore := make(chan struct{}, h.batchConcurrency())
+	var wg sync.WaitGroup
+	for i, file := range request.Files {
+		// Acquire a worker slot, giving up once the batch deadline passes
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = fmt.Errorf("batch timed out before file was processed: %w", ctx.Err())
+			continue
 		}
 
-		// Process the document
-		result, err := h.processDocumentWithPipeline(individualRequest)
-		if err != nil {
+		wg.Add(1)
+		go func(index int, file *multipart.FileHeader) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// Create individual processing request
+			individualRequest := &internalModels.ProcessDocumentRequest{
+				File:        file,
+				Category:    request.Category,
+				Description: request.Description,
+				CaseName:    request.CaseName,
+				CaseNumber:  request.CaseNumber,
+				Options:     request.Options,
+
+				CustomMetadata: request.CustomMetadata,
+
+				SourceSystem:     request.SourceSystem,
+				IngestionBatchID: ingestionBatchID,
+			}
+			results[index], errs[index] = h.processDocumentWithPipeline(ctx, individualRequest)
+		}(i, file)
+	}
+	wg.Wait()
+
+	for i, file := range request.Files {
+		outcome := &internalModels.BatchFileOutcome{
+			Index:    i,
+			FileName: file.Filename,
+		}
+
+		if err := errs[i]; err != nil {
+			code := "processing_error"
+			var validationErr *pipeline.ValidationError
+			if errors.Is(err, context.DeadlineExceeded) {
+				code = "timeout"
+			} else if errors.As(err, &validationErr) {
+				code = "validation_error"
+			}
 			response.FailureCount++
 			response.Errors = append(response.Errors, &internalModels.BatchProcessError{
 				FileName: file.Filename,
 				Error:    err.Error(),
-				Code:     "processing_error",
+				Code:     code,
 			})
+			outcome.Status = internalModels.BatchOutcomeFailed
+			outcome.Error = err.Error()
+			outcome.Code = code
 		} else {
 			response.SuccessCount++
-			response.Results = append(response.Results, result)
+			response.Results = append(response.Results, results[i])
+			outcome.Status = internalModels.BatchOutcomeSucceeded
+			outcome.DocumentID = results[i].DocumentID
 		}
+		response.Outcomes = append(response.Outcomes, outcome)
 	}
 
 	if response.FailureCount > 0 && response.SuccessCount == 0 {
-		response.Status = "failed"
+		response.Status = internalModels.BatchStatusFailed
 	} else if response.FailureCount > 0 {
-		response.Status = "partial_success"
+		response.Status = internalModels.BatchStatusPartialSuccess
 	} else {
-		response.Status = "completed"
+		response.Status = internalModels.BatchStatusCompleted
 	}
 
 	return response
 }
 
+// batchConcurrency returns how many files a synchronous batch processes at once
+func (h *ProcessingHandler) batchConcurrency() int {
+	if h.cfg != nil && h.cfg.Processing.BatchConcurrency > 0 {
+		return h.cfg.Processing.BatchConcurrency
+	}
+	return 1
+}
+
+// batchTimeout returns the deadline for a whole synchronous batch request
+func (h *ProcessingHandler) batchTimeout() time.Duration {
+	if h.cfg != nil && h.cfg.Processing.ProcessTimeout > 0 {
+		return h.cfg.Processing.ProcessTimeout
+	}
+	return 5 * time.Minute
+}
+
 // Helper functions
 
 func generateDocumentID(filename string) string {
 	timestamp := strconv.FormatInt(time.Now().UnixNano(), 10)
-	return fmt.Sprintf("doc_%s_%s", timestamp, filename)
+	return fmt.Sprintf("doc_%s_%s", timestamp, storage.SanitizeFileName(filename))
 }
 
 func generateBatchID() string {
@@ -950,12 +2297,23 @@ func (h *ProcessingHandler) convertPipelineResults(pipelineResult *pipeline.Proc
 		response.Steps = append(response.Steps, handlerStep)
 	}
 
+	if pipelineResult.Profile != nil {
+		response.Profile = pipelineResult.Profile.Name
+	}
+
+	if doc := pipelineResult.Document; doc != nil && doc.Metadata != nil {
+		response.ReviewStatus = doc.Metadata.ReviewStatus
+		response.MissingFields = doc.Metadata.MissingFields
+	}
+
 	// Convert extraction results
 	if pipelineResult.ExtractionResult != nil {
 		response.ExtractionResult = &internalModels.ExtractionResult{
 			Text:      pipelineResult.ExtractionResult.Text,
 			PageCount: pipelineResult.ExtractionResult.PageCount,
 			Language:  pipelineResult.ExtractionResult.Language,
+			OCRUsed:   pipelineResult.ExtractionResult.OCRUsed,
+			Extractor: pipelineResult.ExtractionResult.Extractor,
 		}
 
 		// Update metadata with extraction results
@@ -990,7 +2348,8 @@ func (h *ProcessingHandler) convertPipelineResults(pipelineResult *pipeline.Proc
 			UploadedAt: time.Now(),
 		}
 		response.URL = pipelineResult.StorageResult.URL
-		response.CDN_URL = pipelineResult.StorageResult.URL
+		response.CDN_URL = pipelineResult.StorageResult.CDNURL
+		response.StorageAction = pipelineResult.StorageResult.Action
 	}
 
 	// Convert indexing results
@@ -999,10 +2358,96 @@ func (h *ProcessingHandler) convertPipelineResults(pipelineResult *pipeline.Proc
 			DocumentID: pipelineResult.IndexResult.DocumentID,
 			IndexName:  "documents", // Default index name
 			Success:    pipelineResult.IndexResult.Success,
+			Searchable: pipelineResult.IndexResult.Searchable,
+			Skipped:    pipelineResult.IndexResult.Skipped,
+			SkipReason: pipelineResult.IndexResult.SkipReason,
+			Deferred:   pipelineResult.IndexResult.Deferred,
+			Warning:    pipelineResult.IndexResult.Warning,
+			Error:      pipelineResult.IndexResult.Error,
+
+			DuplicateOf: pipelineResult.IndexResult.DuplicateOf,
 		}
 	}
 
 	// Set processing metadata
 	response.ProcessingTime = pipelineResult.ProcessingTime
 	response.CreatedAt = pipelineResult.StartTime
+	response.Timing = internalModels.NewTimingSummary(response.Steps, pipelineResult.ProcessingTime)
+}
+
+// conflictPolicy returns the request's on-conflict override, or the deployment default
+func (h *ProcessingHandler) conflictPolicy(override string) string {
+	if override != "" {
+		return override
+	}
+	if h.cfg != nil {
+		return h.cfg.Storage.OnConflict
+	}
+	return ""
+}
+
+// defaultProcessOptions returns the single-upload options with the processing
+// steps set from the deployment's configured defaults
+func (h *ProcessingHandler) defaultProcessOptions() *internalModels.ProcessOptions {
+	opts := internalModels.DefaultProcessOptions()
+	if h.cfg != nil {
+		opts.ExtractText = h.cfg.Processing.DefaultExtractText
+		opts.ClassifyDoc = h.cfg.Processing.DefaultClassifyDoc
+		opts.IndexDocument = h.cfg.Processing.DefaultIndexDocument
+		opts.StoreDocument = h.cfg.Processing.DefaultStoreDocument
+	}
+	return opts
+}
+
+// defaultBatchProcessOptions returns the batch upload options, which differ from
+// single uploads only in deferring index visibility
+func (h *ProcessingHandler) defaultBatchProcessOptions() *internalModels.ProcessOptions {
+	opts := h.defaultProcessOptions()
+	opts.IndexImmediately = internalModels.DefaultBatchProcessOptions().IndexImmediately
+	return opts
+}
+
+// applyProcessingStepFields overrides the processing steps with any
+// extract_text, classify_doc, index_document or store_document form fields.
+// Omitted or unparseable fields keep the default.
+func applyProcessingStepFields(c *fiber.Ctx, opts *internalModels.ProcessOptions) {
+	for field, step := range map[string]*bool{
+		"extract_text":   &opts.ExtractText,
+		"classify_doc":   &opts.ClassifyDoc,
+		"index_document": &opts.IndexDocument,
+		"store_document": &opts.StoreDocument,
+	} {
+		if value, err := strconv.ParseBool(c.FormValue(field)); err == nil {
+			*step = value
+		}
+	}
+}
+
+// parseMinIndexableTextLength reads the optional min_indexable_text_length form
+// field, returning nil when the deployment default should apply
+func parseMinIndexableTextLength(c *fiber.Ctx) (*int, error) {
+	value := c.FormValue("min_indexable_text_length")
+	if value == "" {
+		return nil, nil
+	}
+	minLength, err := strconv.Atoi(value)
+	if err != nil || minLength < 0 {
+		return nil, fmt.Errorf("min_indexable_text_length must be a non-negative integer, got %q", value)
+	}
+	return &minLength, nil
+}
+
+// applyRetryCount reads the optional retry_count form field, the number of
+// times a transiently failing document is reprocessed before giving up
+func applyRetryCount(c *fiber.Ctx, opts *internalModels.ProcessOptions) error {
+	value := c.FormValue("retry_count")
+	if value == "" {
+		return nil
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 || retries > internalModels.MaxRetryCount {
+		return fmt.Errorf("retry_count must be an integer between 0 and %d, got %q", internalModels.MaxRetryCount, value)
+	}
+	opts.RetryCount = retries
+	return nil
 }
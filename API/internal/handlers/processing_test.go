@@ -1,9 +1,36 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/config"
+	"motion-index-fiber/internal/middleware"
+	internalModels "motion-index-fiber/internal/models"
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
+	"motion-index-fiber/pkg/processing/pipeline"
+	"motion-index-fiber/pkg/processing/queue"
+	"motion-index-fiber/pkg/processing/redaction"
+	"motion-index-fiber/pkg/storage"
 )
 
 func TestProcessingHandlerExists(t *testing.T) {
@@ -11,6 +38,1656 @@ func TestProcessingHandlerExists(t *testing.T) {
 	assert.True(t, true)
 }
 
-// TODO: Reimplement processing handler tests with proper service interfaces
-// The original tests need to be updated to work with the new service
-// interfaces and constructor signatures.
\ No newline at end of file
+// fakePipeline fails any document whose file name contains "bad"
+type fakePipeline struct{}
+
+func (p *fakePipeline) ProcessDocument(ctx context.Context, req *pipeline.ProcessRequest) (*pipeline.ProcessResult, error) {
+	if strings.Contains(req.FileName, "bad") {
+		return &pipeline.ProcessResult{ID: req.ID, Success: false}, fmt.Errorf("extraction failed")
+	}
+	return &pipeline.ProcessResult{ID: req.ID, Success: true}, nil
+}
+
+func (p *fakePipeline) ProcessBatch(ctx context.Context, requests []*pipeline.ProcessRequest) (*pipeline.BatchResult, error) {
+	return &pipeline.BatchResult{}, nil
+}
+
+func (p *fakePipeline) GetStatus() *pipeline.PipelineStatus { return &pipeline.PipelineStatus{} }
+func (p *fakePipeline) Stop(ctx context.Context) error      { return nil }
+func (p *fakePipeline) IsHealthy() bool                     { return true }
+
+func performBatchRequest(t *testing.T, fileNames ...string) (int, map[string]interface{}) {
+	t.Helper()
+	return performBatchRequestWith(t, NewProcessingHandler(testutil.TestConfig(), &fakePipeline{}, nil, nil), fileNames...)
+}
+
+func performBatchRequestWith(t *testing.T, h *ProcessingHandler, fileNames ...string) (int, map[string]interface{}) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/batch", h.BatchProcessDocuments)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, name := range fileNames {
+		part, err := writer.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = part.Write([]byte("document content"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/batch", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestBatchProcessDocuments_AllSucceeded(t *testing.T) {
+	status, body := performBatchRequest(t, "a.txt", "b.txt")
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, true, body["success"])
+
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, internalModels.BatchStatusCompleted, data["status"])
+	outcomes := data["outcomes"].([]interface{})
+	require.Len(t, outcomes, 2)
+	for _, o := range outcomes {
+		outcome := o.(map[string]interface{})
+		assert.Equal(t, internalModels.BatchOutcomeSucceeded, outcome["status"])
+		assert.NotEmpty(t, outcome["document_id"])
+	}
+}
+
+func TestBatchProcessDocuments_PartialSuccess(t *testing.T) {
+	status, body := performBatchRequest(t, "good.txt", "bad.txt", "also-good.txt")
+
+	assert.Equal(t, fiber.StatusMultiStatus, status)
+	assert.Equal(t, true, body["success"])
+
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, internalModels.BatchStatusPartialSuccess, data["status"])
+	assert.Equal(t, float64(2), data["success_count"])
+	assert.Equal(t, float64(1), data["failure_count"])
+
+	outcomes := data["outcomes"].([]interface{})
+	require.Len(t, outcomes, 3)
+
+	failed := outcomes[1].(map[string]interface{})
+	assert.Equal(t, float64(1), failed["index"])
+	assert.Equal(t, "bad.txt", failed["file_name"])
+	assert.Equal(t, internalModels.BatchOutcomeFailed, failed["status"])
+	assert.Equal(t, "processing_error", failed["code"])
+	assert.Contains(t, failed["error"], "extraction failed")
+	assert.Nil(t, failed["document_id"])
+
+	succeeded := outcomes[2].(map[string]interface{})
+	assert.Equal(t, "also-good.txt", succeeded["file_name"])
+	assert.Equal(t, internalModels.BatchOutcomeSucceeded, succeeded["status"])
+}
+
+func TestBatchProcessDocuments_AllFailed(t *testing.T) {
+	status, body := performBatchRequest(t, "bad-1.txt", "bad-2.txt")
+
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, false, body["success"])
+	assert.Equal(t, "batch_failed", body["error"].(map[string]interface{})["code"])
+
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, internalModels.BatchStatusFailed, data["status"])
+	outcomes := data["outcomes"].([]interface{})
+	require.Len(t, outcomes, 2)
+	for _, o := range outcomes {
+		assert.Equal(t, internalModels.BatchOutcomeFailed, o.(map[string]interface{})["status"])
+	}
+}
+
+// concurrentPipeline records how many documents are processed at once. Files
+// named "bad*" fail and every call blocks until released or the context ends.
+type concurrentPipeline struct {
+	fakePipeline
+	delay    time.Duration
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (p *concurrentPipeline) ProcessDocument(ctx context.Context, req *pipeline.ProcessRequest) (*pipeline.ProcessResult, error) {
+	current := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		seen := p.maxSeen.Load()
+		if current <= seen || p.maxSeen.CompareAndSwap(seen, current) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return &pipeline.ProcessResult{ID: req.ID, Success: false}, ctx.Err()
+	}
+	return p.fakePipeline.ProcessDocument(ctx, req)
+}
+
+func TestBatchProcessDocuments_ConcurrentAggregation(t *testing.T) {
+	// BatchProcessRequest accepts at most 10 files
+	fileNames := make([]string, 10)
+	for i := range fileNames {
+		if i%5 == 0 {
+			fileNames[i] = fmt.Sprintf("bad-%02d.txt", i)
+		} else {
+			fileNames[i] = fmt.Sprintf("file-%02d.txt", i)
+		}
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Processing.BatchConcurrency = 4
+	p := &concurrentPipeline{delay: 20 * time.Millisecond}
+
+	status, body := performBatchRequestWith(t, NewProcessingHandler(cfg, p, nil, nil), fileNames...)
+
+	assert.Equal(t, fiber.StatusMultiStatus, status)
+	assert.LessOrEqual(t, p.maxSeen.Load(), int32(4))
+	assert.Greater(t, p.maxSeen.Load(), int32(1))
+
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, float64(8), data["success_count"])
+	assert.Equal(t, float64(2), data["failure_count"])
+	assert.Len(t, data["errors"], 2)
+
+	// Outcomes and results keep upload order regardless of completion order
+	outcomes := data["outcomes"].([]interface{})
+	require.Len(t, outcomes, len(fileNames))
+	var succeeded []string
+	for i, o := range outcomes {
+		outcome := o.(map[string]interface{})
+		assert.Equal(t, float64(i), outcome["index"])
+		assert.Equal(t, fileNames[i], outcome["file_name"])
+		if outcome["status"] == internalModels.BatchOutcomeSucceeded {
+			succeeded = append(succeeded, fileNames[i])
+		}
+	}
+
+	results := data["results"].([]interface{})
+	require.Len(t, results, len(succeeded))
+	for i, r := range results {
+		assert.Equal(t, succeeded[i], r.(map[string]interface{})["file_name"])
+	}
+}
+
+func TestBatchProcessDocuments_RespectsBatchTimeout(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Processing.BatchConcurrency = 2
+	cfg.Processing.ProcessTimeout = 50 * time.Millisecond
+	p := &concurrentPipeline{delay: time.Minute}
+
+	start := time.Now()
+	status, body := performBatchRequestWith(t, NewProcessingHandler(cfg, p, nil, nil), "a.txt", "b.txt", "c.txt", "d.txt")
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+
+	data := body["data"].(map[string]interface{})
+	for _, o := range data["outcomes"].([]interface{}) {
+		outcome := o.(map[string]interface{})
+		assert.Equal(t, internalModels.BatchOutcomeFailed, outcome["status"])
+		assert.Equal(t, "timeout", outcome["code"])
+	}
+}
+
+func TestBatchStatusCode(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected int
+	}{
+		{internalModels.BatchStatusCompleted, fiber.StatusOK},
+		{internalModels.BatchStatusPartialSuccess, fiber.StatusMultiStatus},
+		{internalModels.BatchStatusFailed, fiber.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			result := &internalModels.BatchProcessResponse{Status: tt.status}
+			assert.Equal(t, tt.expected, batchStatusCode(result))
+		})
+	}
+}
+
+// uploadForIndexing uploads a small text document through the legacy path and
+// returns the decoded index result
+func uploadForIndexing(t *testing.T, app *fiber.App, fields map[string]string) (string, map[string]interface{}) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "motion.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("MOTION TO SUPPRESS EVIDENCE"))
+	require.NoError(t, err)
+	if _, ok := fields["classify_doc"]; !ok {
+		require.NoError(t, writer.WriteField("classify_doc", "false"))
+	}
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var decoded struct {
+		Data struct {
+			DocumentID  string                 `json:"document_id"`
+			IndexResult map[string]interface{} `json:"index_result"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	require.NotNil(t, decoded.Data.IndexResult)
+	return decoded.Data.DocumentID, decoded.Data.IndexResult
+}
+
+func searchHitIDs(t *testing.T, searchSvc *MockSearchService) []string {
+	t.Helper()
+	result, err := searchSvc.SearchDocuments(context.Background(), &models.SearchRequest{Query: "motion", Size: 10})
+	require.NoError(t, err)
+	ids := make([]string, 0, len(result.Documents))
+	for _, doc := range result.Documents {
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func TestProcessDocument_IndexImmediately(t *testing.T) {
+	searchSvc := newMockSearchService()
+	h := NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), searchSvc)
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	// Single uploads default to immediate indexing and are searchable on return
+	docID, indexResult := uploadForIndexing(t, app, nil)
+	assert.Equal(t, true, indexResult["searchable"])
+	assert.Contains(t, searchHitIDs(t, searchSvc), docID)
+}
+
+func TestProcessDocument_IndexDeferred(t *testing.T) {
+	searchSvc := newMockSearchService()
+	h := NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), searchSvc)
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	docID, indexResult := uploadForIndexing(t, app, map[string]string{"index_immediately": "false"})
+	assert.Equal(t, true, indexResult["success"])
+	assert.Equal(t, false, indexResult["searchable"])
+	assert.NotContains(t, searchHitIDs(t, searchSvc), docID)
+
+	// The document appears once the index refreshes
+	searchSvc.refresh()
+	assert.Contains(t, searchHitIDs(t, searchSvc), docID)
+}
+
+// newIndexingQueue returns a queue manager with an "indexing" queue whose
+// workers are never started, so enqueued items stay put
+func newIndexingQueue(t *testing.T) (queue.QueueManager, queue.Queue) {
+	t.Helper()
+	manager := queue.NewQueueManager()
+	indexingQueue, err := manager.CreateQueue(&queue.QueueConfig{
+		Name:        "indexing",
+		Type:        queue.QueueTypeIndexing,
+		MaxSize:     10,
+		WorkerCount: 1,
+	}, func(ctx context.Context, item *queue.QueueItem) *queue.ProcessingResult {
+		return &queue.ProcessingResult{Success: true}
+	})
+	require.NoError(t, err)
+	return manager, indexingQueue
+}
+
+func TestProcessDocument_IndexDeferredGoesThroughQueue(t *testing.T) {
+	searchSvc := newMockSearchService()
+	h := NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), searchSvc)
+	manager, indexingQueue := newIndexingQueue(t)
+	h.queueManager = manager
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	docID, indexResult := uploadForIndexing(t, app, map[string]string{"index_immediately": "false", "classify_doc": "true"})
+	assert.Equal(t, true, indexResult["success"])
+	assert.Equal(t, true, indexResult["queued"])
+	assert.Equal(t, false, indexResult["searchable"])
+
+	// The queue worker indexes the document; the upload did not
+	require.Equal(t, 1, indexingQueue.Size())
+	item, err := indexingQueue.Peek(context.Background())
+	require.NoError(t, err)
+	queued, ok := item.Data.(*processing.IndexingQueueItem)
+	require.True(t, ok)
+	assert.Equal(t, docID, queued.DocumentID)
+	assert.NotEmpty(t, queued.Text)
+	require.NotNil(t, queued.ClassificationResult)
+	searchSvc.refresh()
+	assert.NotContains(t, searchHitIDs(t, searchSvc), docID)
+
+	// Immediate uploads bypass the queue and are searchable on return
+	docID, indexResult = uploadForIndexing(t, app, map[string]string{"classify_doc": "true"})
+	assert.Equal(t, true, indexResult["searchable"])
+	assert.Nil(t, indexResult["queued"])
+	assert.Contains(t, searchHitIDs(t, searchSvc), docID)
+
+	// The queue indexes from the classification, so unclassified documents
+	// are indexed inline
+	docID, indexResult = uploadForIndexing(t, app, map[string]string{"index_immediately": "false"})
+	assert.Nil(t, indexResult["queued"])
+	searchSvc.refresh()
+	assert.Contains(t, searchHitIDs(t, searchSvc), docID)
+	assert.Equal(t, 1, indexingQueue.Size())
+}
+
+func TestProcessDocument_ConfiguredDefaultOptions(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Processing.DefaultStoreDocument = false
+
+	storageSvc := newMockStorageService()
+	h := NewProcessingHandler(cfg, nil, storageSvc, newMockSearchService())
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	// No store_document field: the deployment default skips storage but still indexes
+	_, indexResult := uploadForIndexing(t, app, nil)
+	assert.Equal(t, true, indexResult["success"])
+	assert.Empty(t, storageSvc.objects)
+
+	// An explicit form field still wins over the default
+	uploadForIndexing(t, app, map[string]string{"store_document": "true"})
+	assert.Len(t, storageSvc.objects, 1)
+
+	status := getPipelineStatus(t, h)
+	assert.False(t, status.DefaultOptions.StoreDocument)
+	assert.False(t, status.BatchDefaultOptions.StoreDocument)
+	assert.True(t, status.DefaultOptions.IndexDocument)
+}
+
+func getPipelineStatus(t *testing.T, h *ProcessingHandler) *internalModels.PipelineStatusResponse {
+	t.Helper()
+
+	app := fiber.New()
+	app.Get("/pipeline/status", h.GetPipelineStatus)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pipeline/status", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var decoded struct {
+		Data *internalModels.PipelineStatusResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	require.NotNil(t, decoded.Data)
+	return decoded.Data
+}
+
+func TestGetPipelineStatus_UnconfiguredClassifier(t *testing.T) {
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, newMockSearchService(), newMockStorageService(), nil)
+	require.NoError(t, err)
+
+	status := getPipelineStatus(t, NewProcessingHandler(testutil.TestConfig(), p, nil, nil))
+
+	assert.Equal(t, "pipeline", status.Mode)
+	assert.Equal(t, "degraded", status.Status)
+	require.Len(t, status.ProcessorStatus, 5)
+
+	classification := status.ProcessorStatus["classification"]
+	assert.False(t, classification.Configured)
+	assert.False(t, classification.Healthy)
+	assert.Equal(t, "unavailable", classification.Status)
+
+	for _, stage := range []string{"validation", "extraction", "storage", "indexing"} {
+		assert.True(t, status.ProcessorStatus[stage].Configured, stage)
+		assert.True(t, status.ProcessorStatus[stage].Healthy, stage)
+	}
+
+	require.NotNil(t, status.DefaultOptions)
+	assert.True(t, status.DefaultOptions.ClassifyDoc)
+	assert.True(t, status.DefaultOptions.IndexImmediately)
+	require.NotNil(t, status.BatchDefaultOptions)
+	assert.False(t, status.BatchDefaultOptions.IndexImmediately)
+}
+
+func TestGetPipelineStatus_LegacyMode(t *testing.T) {
+	status := getPipelineStatus(t, NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), newMockSearchService()))
+
+	assert.Equal(t, "legacy", status.Mode)
+	assert.Equal(t, "healthy", status.Status)
+	assert.False(t, status.ProcessorStatus["classification"].Configured)
+	assert.False(t, status.ProcessorStatus["extraction"].Configured)
+	assert.True(t, status.ProcessorStatus["storage"].Healthy)
+	assert.True(t, status.ProcessorStatus["indexing"].Healthy)
+}
+
+// uploadToPipeline uploads a text document through the pipeline path and
+// returns the decoded response data
+func uploadToPipeline(t *testing.T, h *ProcessingHandler, content string, fields map[string]string) map[string]interface{} {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "cover.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	if _, ok := fields["classify_doc"]; !ok {
+		require.NoError(t, writer.WriteField("classify_doc", "false"))
+	}
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return decoded["data"].(map[string]interface{})
+}
+
+func TestUploadDocument_SkipsIndexingShortText(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	pipelineConfig := pipeline.DefaultConfig()
+	pipelineConfig.MinIndexableTextLength = 50
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "  Page 1  \n", nil)
+
+	indexResult := data["index_result"].(map[string]interface{})
+	assert.Equal(t, true, indexResult["skipped"])
+	assert.Contains(t, indexResult["skip_reason"], "below the minimum of 50")
+	assert.NotNil(t, data["storage_result"], "short documents are still stored")
+	assert.Empty(t, searchSvc.documents)
+
+	// A per-request override of zero indexes the same document
+	data = uploadToPipeline(t, h, "  Page 1  \n", map[string]string{"min_indexable_text_length": "0"})
+	indexResult = data["index_result"].(map[string]interface{})
+	assert.Nil(t, indexResult["skipped"])
+	assert.Equal(t, true, indexResult["success"])
+	assert.Len(t, searchSvc.documents, 1)
+}
+
+func TestUploadDocument_SkipsDuplicateContent(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	pipelineConfig := pipeline.DefaultConfig()
+	pipelineConfig.SkipDuplicates = true
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	const text = "Notice of motion and motion to suppress evidence obtained without a warrant."
+	first := uploadToPipeline(t, h, text, nil)
+	firstID := first["document_id"].(string)
+	require.Contains(t, searchSvc.documents, firstID)
+	assert.Equal(t, extractor.ContentHash(text), searchSvc.documents[firstID].Hash)
+
+	second := uploadToPipeline(t, h, text, nil)
+	assert.NotEqual(t, firstID, second["document_id"])
+	indexResult := second["index_result"].(map[string]interface{})
+	assert.Equal(t, true, indexResult["skipped"])
+	assert.Equal(t, firstID, indexResult["duplicate_of"])
+	assert.Equal(t, firstID, indexResult["document_id"])
+	assert.NotNil(t, second["storage_result"], "duplicates are still stored")
+	assert.Len(t, searchSvc.documents, 1)
+
+	// Different text is indexed as usual
+	third := uploadToPipeline(t, h, text+" Hearing set for March.", nil)
+	assert.Nil(t, third["index_result"].(map[string]interface{})["skipped"])
+	assert.Len(t, searchSvc.documents, 2)
+}
+
+func TestUploadDocument_SpoolsLargeFilesToDisk(t *testing.T) {
+	spoolDir := t.TempDir()
+	t.Setenv("TMPDIR", spoolDir)
+
+	text := strings.Repeat("The motion to suppress evidence is granted. ", 20)
+	upload := func(threshold int64) *models.Document {
+		searchSvc := newMockSearchService()
+		storageSvc := newMockStorageService()
+		p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, nil)
+		require.NoError(t, err)
+		cfg := testutil.TestConfig()
+		cfg.Processing.UploadSpoolThreshold = threshold
+		h := NewProcessingHandler(cfg, p, storageSvc, searchSvc)
+
+		data := uploadToPipeline(t, h, text, nil)
+		doc := searchSvc.documents[data["document_id"].(string)]
+		require.NotNil(t, doc)
+		return doc
+	}
+
+	inMemory := upload(0)
+	spooled := upload(64)
+
+	assert.Equal(t, inMemory.Text, spooled.Text)
+	assert.Equal(t, inMemory.Hash, spooled.Hash)
+	assert.Equal(t, inMemory.Metadata.Title, spooled.Metadata.Title)
+
+	leftovers, err := filepath.Glob(filepath.Join(spoolDir, "upload-*"))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers, "spooled uploads are removed after processing")
+}
+
+func TestUploadDocument_TimingSummary(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, nil)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "Notice of motion and motion to compel discovery responses.", nil)
+
+	steps := data["steps"].([]interface{})
+	require.NotEmpty(t, steps)
+	byStage := map[string]float64{}
+	var stepsTotal float64
+	for _, s := range steps {
+		step := s.(map[string]interface{})
+		byStage[step["name"].(string)] += step["duration_ms"].(float64)
+		stepsTotal += step["duration_ms"].(float64)
+	}
+
+	timing := data["timing"].(map[string]interface{})
+	stages := timing["stages_ms"].(map[string]interface{})
+	require.Len(t, stages, len(byStage))
+	for name, duration := range byStage {
+		assert.Equal(t, duration, stages[name], name)
+	}
+	assert.Contains(t, stages, "extraction")
+	assert.Contains(t, stages, "indexing")
+	assert.Equal(t, stepsTotal, timing["steps_ms"])
+	assert.Equal(t, data["processing_time_ms"], timing["total_ms"])
+	assert.GreaterOrEqual(t, timing["total_ms"], timing["steps_ms"])
+}
+
+func TestUploadDocument_RetriesTransientFailure(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, nil)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	// The index is briefly unavailable for the first attempt only
+	var calls atomic.Int32
+	searchSvc.indexErrFn = func(doc *models.Document) error {
+		if calls.Add(1) == 1 {
+			return fmt.Errorf("index failed with status: 503 Service Unavailable")
+		}
+		return nil
+	}
+
+	data := uploadToPipeline(t, h, "Notice of motion and motion to compel discovery responses.", nil)
+
+	assert.Equal(t, float64(2), data["attempts"])
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Contains(t, searchSvc.documents, data["document_id"])
+
+	// With retries disabled the same failure is returned to the client
+	calls.Store(0)
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "cover.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("Notice of motion and motion to compel discovery responses."))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteField("classify_doc", "false"))
+	require.NoError(t, writer.WriteField("retry_count", "0"))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestUploadDocument_CustomMetadata(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, nil)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "Notice of motion and motion to compel discovery responses.", map[string]string{
+		"custom_metadata": `{"client_matter": "CM-2024-017", "billable": true, "box": 12}`,
+	})
+	docID := data["document_id"].(string)
+
+	indexed, err := searchSvc.GetDocument(context.Background(), docID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"client_matter": "CM-2024-017",
+		"billable":      "true",
+		"box":           "12",
+	}, indexed.Metadata.Custom)
+
+	// Searching on a custom field reaches the search service as a filter
+	var received *models.SearchRequest
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		return models.NewSearchResult(), nil
+	}
+	status, _ := postSearch(t, NewSearchHandler(testutil.TestConfig(), searchSvc).SearchDocuments, map[string]interface{}{
+		"custom_metadata": map[string]string{"client_matter": "CM-2024-017"},
+	})
+	require.Equal(t, fiber.StatusOK, status)
+	require.NotNil(t, received)
+	assert.Equal(t, "CM-2024-017", received.CustomMetadata["client_matter"])
+
+	status, _ = postSearch(t, NewSearchHandler(testutil.TestConfig(), searchSvc).SearchDocuments, map[string]interface{}{
+		"custom_metadata": map[string]string{"Client Matter": "x"},
+	})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+}
+
+func TestUploadDocument_FlagsIncompleteMetadata(t *testing.T) {
+	requiredFields, err := pipeline.ParseRequiredFields("order:judge,decision_date")
+	require.NoError(t, err)
+
+	decisionDate := "2024-03-01"
+	classifierSvc := &stubClassifier{result: &classifier.ClassificationResult{
+		DocumentType:  classifier.DocumentTypeOrder,
+		LegalCategory: classifier.LegalCategoryCriminal,
+		DecisionDate:  &decisionDate,
+		Confidence:    0.9,
+		Success:       true,
+	}}
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	pipelineConfig := pipeline.DefaultConfig()
+	pipelineConfig.RequiredFields = requiredFields
+	p, err := pipeline.NewPipeline(extractor.NewService(), classifierSvc, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	// An order without a judge is indexed but flagged for review
+	data := uploadToPipeline(t, h, "ORDER GRANTING MOTION TO CONTINUE. The motion is granted.", map[string]string{
+		"classify_doc": "true",
+	})
+	assert.Equal(t, models.ReviewStatusIncompleteMetadata, data["review_status"])
+	assert.Equal(t, []interface{}{"judge"}, data["missing_fields"])
+
+	indexed, err := searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewStatusIncompleteMetadata, indexed.Metadata.ReviewStatus)
+	assert.Equal(t, []string{"judge"}, indexed.Metadata.MissingFields)
+
+	// The same order with a judge is complete
+	classifierSvc.result.Judge = &classifier.Judge{Name: "Hon. Jane Doe"}
+	data = uploadToPipeline(t, h, "ORDER GRANTING MOTION TO CONTINUE. The motion is granted.", map[string]string{
+		"classify_doc": "true",
+	})
+	assert.Nil(t, data["review_status"])
+	assert.Nil(t, data["missing_fields"])
+}
+
+func TestUploadDocument_DefersIndexingWhileIndexReadOnly(t *testing.T) {
+	blocked := true
+	searchSvc := newMockSearchService()
+	searchSvc.indexErrFn = func(doc *models.Document) error {
+		if blocked {
+			return fmt.Errorf("indexing failed with status: 429 Too Many Requests, body: " +
+				`{"error":{"type":"cluster_block_exception","reason":"index [documents] blocked by: ` +
+				`[TOO_MANY_REQUESTS/12/disk usage exceeded flood-stage watermark, index has read-only-allow-delete block];"},"status":429}`)
+		}
+		return nil
+	}
+	storageSvc := newMockStorageService()
+	deferred := pipeline.NewDeferredIndexer(searchSvc, time.Minute, 10)
+	pipelineConfig := pipeline.DefaultConfig()
+	pipelineConfig.DeferredIndexer = deferred
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	// The upload succeeds with a warning instead of failing on the read-only index
+	data := uploadToPipeline(t, h, "MOTION TO SUPPRESS EVIDENCE. The defendant moves to suppress.", nil)
+	assert.Equal(t, "completed", data["status"])
+	assert.NotNil(t, data["storage_result"])
+	indexResult := data["index_result"].(map[string]interface{})
+	assert.Equal(t, true, indexResult["deferred"])
+	assert.Equal(t, false, indexResult["success"])
+	assert.Contains(t, indexResult["warning"], "read-only")
+	assert.Empty(t, searchSvc.documents)
+	assert.Equal(t, 1, deferred.Pending())
+
+	// Retries wait while the block remains
+	indexed, err := deferred.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, indexed)
+	assert.Equal(t, 1, deferred.Pending())
+
+	// Once the block clears the document is indexed from the queue
+	blocked = false
+	indexed, err = deferred.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexed)
+	assert.Equal(t, 0, deferred.Pending())
+	_, err = searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	assert.NoError(t, err)
+}
+
+func TestGetDocumentClassification_RoundTrips(t *testing.T) {
+	classified := &classifier.ClassificationResult{
+		DocumentType:  classifier.DocumentTypeOrder,
+		LegalCategory: classifier.LegalCategoryCriminal,
+		Confidence:    0.87,
+		Keywords:      []string{"continuance", "good cause"},
+		Entities:      []*classifier.Entity{{Text: "Hon. Jane Doe", Type: "judge", Confidence: 0.8}},
+		Metadata:      map[string]interface{}{"model": "gpt-4o-mini"},
+		Success:       true,
+	}
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), &stubClassifier{result: classified}, searchSvc, storageSvc, pipeline.DefaultConfig())
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "ORDER GRANTING MOTION TO CONTINUE. The motion is granted.", map[string]string{
+		"classify_doc": "true",
+	})
+	documentID := data["document_id"].(string)
+
+	app := fiber.New()
+	app.Get("/documents/:id/classification", NewSearchHandler(testutil.TestConfig(), searchSvc).GetDocumentClassification)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/documents/"+documentID+"/classification", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data struct {
+			DocumentID     string                           `json:"document_id"`
+			Classification *classifier.ClassificationResult `json:"classification"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, documentID, body.Data.DocumentID)
+	// Keywords and entities have no metadata field but are kept in the stored result
+	assert.Equal(t, classified, body.Data.Classification)
+
+	// Documents indexed without a classifier have no stored result
+	searchSvc.documents["unclassified"] = &models.Document{ID: "unclassified", Metadata: &models.DocumentMetadata{}}
+	resp, err = app.Test(httptest.NewRequest("GET", "/documents/unclassified/classification", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestUploadDocument_RecordsProvenance(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipeline.DefaultConfig())
+	require.NoError(t, err)
+	h := NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "MOTION TO SUPPRESS EVIDENCE. The defendant moves to suppress.", map[string]string{
+		"source_system":      "court-feed-sf",
+		"ingestion_batch_id": "import-2024-03",
+	})
+	doc, err := searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "court-feed-sf", doc.Metadata.SourceSystem)
+	assert.Equal(t, "import-2024-03", doc.Metadata.IngestionBatchID)
+
+	// Uploads without provenance record the upload endpoint
+	data = uploadToPipeline(t, h, "ORDER GRANTING MOTION TO CONTINUE. The motion is granted.", nil)
+	doc, err = searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, models.SourceSystemUpload, doc.Metadata.SourceSystem)
+	assert.Empty(t, doc.Metadata.IngestionBatchID)
+}
+
+func TestUploadDocument_IndexesFileURLs(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	cfg := testutil.TestConfig()
+	pipelineConfig := pipeline.DefaultConfig()
+	pipelineConfig.IndexFileURLs = cfg.Storage.IndexFileURLs
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	h := NewProcessingHandler(cfg, p, storageSvc, searchSvc)
+
+	data := uploadToPipeline(t, h, "MOTION TO SUPPRESS EVIDENCE. The defendant moves to suppress.", nil)
+	doc, err := searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	require.NoError(t, err)
+	require.NotEmpty(t, doc.FilePath)
+	assert.Equal(t, "https://cdn.example.com/"+doc.FilePath, doc.FileURL)
+	assert.Equal(t, "https://cdn.example.com/"+doc.FilePath, doc.CDNURL)
+	assert.Equal(t, doc.CDNURL, data["cdn_url"])
+
+	// A new CDN domain applies to documents already indexed
+	cfg.Storage.CDNDomain = "files.example.org"
+	app := fiber.New()
+	app.Get("/documents/:id", NewSearchHandler(cfg, searchSvc).GetDocument)
+	resp, err := app.Test(httptest.NewRequest("GET", "/documents/"+doc.ID, nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var decoded struct {
+		Data models.Document `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	assert.Equal(t, "https://files.example.org/"+doc.FilePath, decoded.Data.CDNURL)
+	assert.Equal(t, doc.FileURL, decoded.Data.FileURL)
+
+	// With the option off only the storage path is indexed
+	pipelineConfig = pipeline.DefaultConfig()
+	pipelineConfig.IndexFileURLs = false
+	p, err = pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipelineConfig)
+	require.NoError(t, err)
+	data = uploadToPipeline(t, NewProcessingHandler(cfg, p, storageSvc, searchSvc), "ORDER GRANTING MOTION TO CONTINUE. The motion is granted.", nil)
+	doc, err = searchSvc.GetDocument(context.Background(), data["document_id"].(string))
+	require.NoError(t, err)
+	assert.NotEmpty(t, doc.FilePath)
+	assert.Empty(t, doc.FileURL)
+	assert.Empty(t, doc.CDNURL)
+}
+
+func TestUploadDocument_RejectsUnsupportedContentType(t *testing.T) {
+	searchSvc := newMockSearchService()
+	storageSvc := newMockStorageService()
+	p, err := pipeline.NewPipeline(extractor.NewService(), nil, searchSvc, storageSvc, pipeline.DefaultConfig())
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Post("/upload", NewProcessingHandler(testutil.TestConfig(), p, storageSvc, searchSvc).UploadDocument)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "setup.exe")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("MZ binary content"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusUnsupportedMediaType, resp.StatusCode)
+
+	var decoded models.APIResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	require.NotNil(t, decoded.Error)
+	assert.Equal(t, "validation_error", decoded.Error.Code)
+	assert.Equal(t, "content_type", decoded.Error.Details["field"])
+	assert.Contains(t, decoded.Error.Details["limit"], "application/pdf")
+	assert.Empty(t, searchSvc.documents)
+}
+
+func TestUploadDocument_CustomMetadataCannotShadowBuiltInFields(t *testing.T) {
+	h := NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), newMockSearchService())
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "motion.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("MOTION TO SUPPRESS EVIDENCE"))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteField("custom_metadata", `{"judge": "Hon. Someone Else"}`))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUploadDocument_RejectsUnknownConflictPolicy(t *testing.T) {
+	h := NewProcessingHandler(testutil.TestConfig(), nil, newMockStorageService(), newMockSearchService())
+	app := fiber.New()
+	app.Post("/upload", h.UploadDocument)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "motion.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("MOTION TO SUPPRESS EVIDENCE"))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteField("on_conflict", "rename"))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+// postRedaction uploads a PDF for redaction analysis and returns the status
+// and decoded response
+func postRedaction(t *testing.T, h *ProcessingHandler, pdf []byte, fields map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	return postRedactionFile(t, h, "exhibit.pdf", pdf, fields)
+}
+
+// postRedactionFile uploads a file with the given name to the redaction endpoint
+func postRedactionFile(t *testing.T, h *ProcessingHandler, filename string, content []byte, fields map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	return postFile(t, h.RedactDocument, filename, content, fields)
+}
+
+// postFile uploads a file with form fields to handler
+func postFile(t *testing.T, handler fiber.Handler, filename string, content []byte, fields map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/redact", handler)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/redact", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+// fakePDF builds a PDF-like file with the given number of page objects
+func fakePDF(pages int) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n1 0 obj << /Type /Pages /Count ")
+	b.WriteString(fmt.Sprintf("%d >> endobj\n", pages))
+	for i := 0; i < pages; i++ {
+		b.WriteString(fmt.Sprintf("%d 0 obj << /Type /Page /Parent 1 0 R >> endobj\n", i+2))
+	}
+	b.WriteString("%%EOF\n")
+	return []byte(b.String())
+}
+
+func TestRedactDocument_RejectsOversizedPDF(t *testing.T) {
+	t.Run("file size", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Processing.RedactionMaxFileSize = 1024
+		pdf := append(fakePDF(1), bytes.Repeat([]byte(" "), 2048)...)
+
+		status, body := postRedaction(t, NewProcessingHandler(cfg, nil, nil, nil), pdf, nil)
+
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, status)
+		errBody := body["error"].(map[string]interface{})
+		assert.Equal(t, "document_too_large", errBody["code"])
+		assert.Equal(t, "file_size", errBody["details"].(map[string]interface{})["limit"])
+	})
+
+	t.Run("page count", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Processing.RedactionMaxPages = 3
+
+		status, body := postRedaction(t, NewProcessingHandler(cfg, nil, nil, nil), fakePDF(5), nil)
+
+		assert.Equal(t, fiber.StatusRequestEntityTooLarge, status)
+		errBody := body["error"].(map[string]interface{})
+		assert.Equal(t, "document_too_large", errBody["code"])
+		assert.Contains(t, errBody["message"], "5 pages")
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Processing.RedactionMaxPages = 3
+
+		status, body := postRedaction(t, NewProcessingHandler(cfg, nil, nil, nil), fakePDF(2), nil)
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Contains(t, data, "total_redactions")
+	})
+}
+
+func TestRedactDocument_OverlayOutput(t *testing.T) {
+	pdf := append(fakePDF(1), []byte("Contact: jane@example.com, SSN 123-45-6789\n")...)
+
+	status, body := postRedaction(t, NewProcessingHandler(testutil.TestConfig(), nil, nil, nil), pdf, map[string]string{
+		"output":           "overlay",
+		"apply_redactions": "true",
+	})
+
+	require.Equal(t, fiber.StatusOK, status)
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, "pdf_points", data["coordinate_space"])
+	assert.Equal(t, "exhibit.pdf", data["filename"])
+	assert.Contains(t, data, "analysis_time_ms")
+	assert.NotContains(t, data, "pdf_base64", "overlay output must not produce a redacted PDF")
+
+	regions := data["regions"].([]interface{})
+	require.NotEmpty(t, regions)
+	assert.Equal(t, float64(len(regions)), data["total_regions"])
+	for _, r := range regions {
+		region := r.(map[string]interface{})
+		assert.Contains(t, region, "page")
+		assert.Len(t, region["bbox"], 4)
+		assert.NotEmpty(t, region["type"])
+		assert.NotEmpty(t, region["reason"])
+		assert.NotEmpty(t, region["citation"])
+		assert.NotContains(t, region, "text")
+	}
+}
+
+func TestAnalyzeRedactions(t *testing.T) {
+	h := NewProcessingHandler(testutil.TestConfig(), nil, nil, nil)
+	pdf := append(fakePDF(1), "Victim SSN 123-45-6789\n"...)
+
+	t.Run("analyzes the upload", func(t *testing.T) {
+		status, body := postFile(t, h.AnalyzeRedactions, "order.pdf", pdf, map[string]string{"use_ai": "false"})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "order.pdf", data["file_name"])
+		assert.EqualValues(t, 1, data["redactions_found"])
+		regions := data["redaction_regions"].([]interface{})
+		require.Len(t, regions, 1)
+		assert.Equal(t, "ssn", regions[0].(map[string]interface{})["type"])
+	})
+
+	t.Run("california patterns off", func(t *testing.T) {
+		status, body := postFile(t, h.AnalyzeRedactions, "order.pdf", pdf, map[string]string{"california_laws": "false"})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.EqualValues(t, 0, data["redactions_found"])
+		assert.Empty(t, data["redaction_regions"])
+	})
+
+	t.Run("unsupported file type", func(t *testing.T) {
+		for _, filename := range []string{"order.rtf", "notes.txt"} {
+			status, body := postFile(t, h.AnalyzeRedactions, filename, []byte("Victim SSN 123-45-6789"), nil)
+
+			assert.Equal(t, fiber.StatusBadRequest, status, filename)
+			assert.Equal(t, "file_type_error", body["error"].(map[string]interface{})["code"], filename)
+		}
+	})
+}
+
+func TestRedactDocument_RTFUpload(t *testing.T) {
+	rtf := []byte(`{\rtf1\ansi{\fonttbl{\f0 Times;}}\f0 Victim SSN 123-45-6789\par Contact jane@example.com}`)
+	h := NewProcessingHandler(testutil.TestConfig(), nil, nil, nil)
+
+	t.Run("preserve format", func(t *testing.T) {
+		status, body := postRedactionFile(t, h, "order.rtf", rtf, map[string]string{
+			"apply_redactions": "true",
+			"preserve_format":  "true",
+			"replacement_char": "X",
+		})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "rtf", data["format"])
+		assert.Equal(t, "redacted_order.rtf", data["filename"])
+		assert.NotContains(t, data, "pdf_base64")
+		assert.NotEmpty(t, data["redactions"])
+
+		redacted, err := base64.StdEncoding.DecodeString(data["document_base64"].(string))
+		require.NoError(t, err)
+		assert.Contains(t, string(redacted), `Victim SSN XXXXXXXXXXX\par`)
+		assert.NotContains(t, string(redacted), "jane@example.com")
+	})
+
+	t.Run("converted to PDF", func(t *testing.T) {
+		status, body := postRedactionFile(t, h, "order.rtf", rtf, map[string]string{"apply_redactions": "true"})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "pdf", data["format"])
+		assert.Equal(t, "redacted_order.pdf", data["filename"])
+		pdf, err := base64.StdEncoding.DecodeString(data["pdf_base64"].(string))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+		assert.NotContains(t, string(pdf), "6789")
+	})
+
+	t.Run("overlay", func(t *testing.T) {
+		status, body := postRedactionFile(t, h, "order.rtf", rtf, map[string]string{"output": "overlay"})
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, "validation_error", body["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		status, body := postRedactionFile(t, h, "brief.doc", []byte("binary"), nil)
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		errBody := body["error"].(map[string]interface{})
+		assert.Equal(t, "file_type_error", errBody["code"])
+		assert.Contains(t, errBody["message"], `"doc"`)
+		assert.Equal(t, []interface{}{"pdf", "docx", "rtf"}, errBody["details"].(map[string]interface{})["supported_formats"])
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		status, body := postRedactionFile(t, h, "brief.docx", []byte("not a zip"), nil)
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, "invalid_document", body["error"].(map[string]interface{})["code"])
+	})
+}
+
+func TestRedactDocument_InvalidOutput(t *testing.T) {
+	status, body := postRedaction(t, NewProcessingHandler(testutil.TestConfig(), nil, nil, nil), fakePDF(1), map[string]string{"output": "png"})
+
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, "validation_error", body["error"].(map[string]interface{})["code"])
+}
+
+func TestRedactDocument_StoresRedactionsForDocument(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1"}
+	pdf := append(fakePDF(1), []byte("Contact: jane@example.com, SSN 123-45-6789\n")...)
+
+	app := fiber.New()
+	app.Get("/documents/:id/redactions", NewSearchHandler(testutil.TestConfig(), searchSvc).GetDocumentRedactions)
+	getRedactions := func(id string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/documents/"+id+"/redactions", nil), -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var decoded map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+		return resp.StatusCode, decoded
+	}
+
+	status, _ := getRedactions("doc-1")
+	assert.Equal(t, fiber.StatusNotFound, status, "nothing is stored before analysis")
+
+	h := NewProcessingHandler(testutil.TestConfig(), nil, nil, searchSvc)
+	status, body := postRedaction(t, h, pdf, map[string]string{"document_id": "doc-1"})
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "doc-1", body["data"].(map[string]interface{})["document_id"])
+
+	status, body = getRedactions("doc-1")
+	require.Equal(t, fiber.StatusOK, status)
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, "doc-1", data["document_id"])
+	assert.Equal(t, "pdf_points", data["coordinate_space"])
+	assert.NotEmpty(t, data["analyzed_at"])
+	items := data["items"].([]interface{})
+	require.NotEmpty(t, items)
+	assert.Equal(t, float64(len(items)), data["total_count"])
+	for _, i := range items {
+		item := i.(map[string]interface{})
+		assert.Len(t, item["bbox"], 4)
+		assert.NotEmpty(t, item["type"])
+		assert.NotEmpty(t, item["citation"])
+		assert.Equal(t, false, item["applied"])
+	}
+
+	// Overlay output replaces the stored analysis too
+	status, _ = postRedaction(t, h, pdf, map[string]string{"document_id": "doc-1", "output": "overlay"})
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, len(items), searchSvc.documents["doc-1"].Redactions.TotalCount)
+
+	// Unknown documents are rejected before analysis
+	status, body = postRedaction(t, h, pdf, map[string]string{"document_id": "missing"})
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, "not_found", body["error"].(map[string]interface{})["code"])
+	status, _ = getRedactions("missing")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
+
+// blockingStorage is a storage service whose downloads never finish before
+// the request's deadline
+type blockingStorage struct {
+	*MockStorageService
+}
+
+func (s *blockingStorage) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRedactDocument_ExistingDocument(t *testing.T) {
+	pdf := append(fakePDF(1), []byte("Contact: jane@example.com, SSN 123-45-6789\n")...)
+
+	newHandler := func(cfg *config.Config, storageSvc storage.Service) (*ProcessingHandler, *MockSearchService) {
+		searchSvc := newMockSearchService()
+		searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1", FileName: "motion.pdf", FilePath: "documents/motion.pdf", ContentType: "application/pdf"}
+		searchSvc.documents["doc-2"] = &models.Document{ID: "doc-2", FileName: "notes.docx", FilePath: "documents/notes.docx"}
+		searchSvc.documents["doc-3"] = &models.Document{ID: "doc-3", FilePath: "documents/gone.pdf"}
+		return NewProcessingHandler(cfg, nil, storageSvc, searchSvc), searchSvc
+	}
+	postJSON := func(h *ProcessingHandler, request map[string]interface{}) (int, map[string]interface{}) {
+		app := fiber.New()
+		app.Post("/redact", h.RedactDocument)
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/redact", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var decoded map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+		return resp.StatusCode, decoded
+	}
+	errorCode := func(body map[string]interface{}) interface{} {
+		return body["error"].(map[string]interface{})["code"]
+	}
+
+	t.Run("analyzes the stored PDF", func(t *testing.T) {
+		storageSvc := newMockStorageService()
+		storageSvc.objects["documents/motion.pdf"] = pdf
+		h, searchSvc := newHandler(testutil.TestConfig(), storageSvc)
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "doc-1"})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "doc-1", data["document_id"])
+		assert.Equal(t, "motion.pdf", data["filename"])
+		assert.NotEmpty(t, data["redactions"])
+		require.NotNil(t, searchSvc.documents["doc-1"].Redactions)
+		assert.Equal(t, int(data["total_redactions"].(float64)), searchSvc.documents["doc-1"].Redactions.TotalCount)
+	})
+
+	t.Run("analyzes a PDF sent as base64", func(t *testing.T) {
+		h, _ := newHandler(testutil.TestConfig(), nil)
+
+		status, body := postJSON(h, map[string]interface{}{"pdf_base64": base64.StdEncoding.EncodeToString(pdf)})
+
+		require.Equal(t, fiber.StatusOK, status)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "document.pdf", data["filename"])
+		assert.NotEmpty(t, data["redactions"])
+
+		status, body = postJSON(h, map[string]interface{}{"pdf_base64": "not base64!"})
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, "validation_error", errorCode(body))
+	})
+
+	t.Run("applying redactions is not implemented", func(t *testing.T) {
+		storageSvc := newMockStorageService()
+		storageSvc.objects["documents/motion.pdf"] = pdf
+		h, searchSvc := newHandler(testutil.TestConfig(), storageSvc)
+		custom := []map[string]interface{}{{"id": "r1", "page": 1, "text": "jane@example.com", "bbox": []float64{10, 10, 90, 20}, "type": "email"}}
+
+		for _, request := range []map[string]interface{}{
+			{"document_id": "doc-1", "apply_redactions": true},
+			{"document_id": "doc-1", "apply_redactions": true, "custom_redactions": custom},
+		} {
+			status, body := postJSON(h, request)
+			assert.Equal(t, fiber.StatusNotImplemented, status)
+			assert.Equal(t, "not_implemented", errorCode(body))
+		}
+		assert.Nil(t, searchSvc.documents["doc-1"].Redactions)
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "doc-1", "custom_redactions": custom})
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, "validation_error", errorCode(body))
+	})
+
+	t.Run("unknown document", func(t *testing.T) {
+		h, _ := newHandler(testutil.TestConfig(), newMockStorageService())
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "missing"})
+
+		assert.Equal(t, fiber.StatusNotFound, status)
+		assert.Equal(t, "not_found", errorCode(body))
+	})
+
+	t.Run("file missing from storage", func(t *testing.T) {
+		h, _ := newHandler(testutil.TestConfig(), newMockStorageService())
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "doc-3"})
+
+		assert.Equal(t, fiber.StatusNotFound, status)
+		assert.Equal(t, "not_found", errorCode(body))
+	})
+
+	t.Run("not a PDF", func(t *testing.T) {
+		storageSvc := newMockStorageService()
+		storageSvc.objects["documents/notes.docx"] = []byte("PK\x03\x04")
+		h, _ := newHandler(testutil.TestConfig(), storageSvc)
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "doc-2"})
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		assert.Equal(t, "file_type_error", errorCode(body))
+	})
+
+	t.Run("download timeout", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Processing.RedactionTimeout = 50 * time.Millisecond
+		h, _ := newHandler(cfg, &blockingStorage{newMockStorageService()})
+
+		status, body := postJSON(h, map[string]interface{}{"document_id": "doc-1"})
+
+		assert.Equal(t, fiber.StatusGatewayTimeout, status)
+		assert.Equal(t, "storage_timeout", errorCode(body))
+	})
+}
+
+func TestAuditRedactions_AppendsRecordOnApply(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1"}
+
+	cfg := testutil.TestConfig()
+	cfg.Processing.RedactionAuditLog = true
+	h := NewProcessingHandler(cfg, nil, nil, searchSvc)
+
+	// The PDF redactor is not implemented yet, so the audit step is driven
+	// with the items it would have applied. The JWT middleware leaves the
+	// operator's claims in the context.
+	applied := []redaction.RedactionItem{
+		{ID: "r1", Page: 1, Text: "123-45-6789", BBox: []float64{72, 640, 190, 652}, Type: "ssn", LegalCode: "CCP_1798.3", Applied: true},
+		{ID: "r2", Page: 2, Text: "987-65-4321", BBox: []float64{72, 500, 190, 512}, Type: "ssn", LegalCode: "CCP_1798.3", Applied: true},
+		{ID: "r3", Page: 2, Text: "jane@example.com", BBox: []float64{72, 480, 220, 492}, Type: "email", LegalCode: "CCP_1798.80", Applied: true},
+	}
+	app := fiber.New()
+	app.Post("/apply/:id", func(c *fiber.Ctx) error {
+		c.Locals("user", &middleware.UserClaims{UserID: "user-7", Email: "clerk@example.org"})
+		return h.auditRedactions(c, c.Context(), c.Params("id"), applied, len(applied))
+	})
+	app.Get("/documents/:id/redactions", NewSearchHandler(cfg, searchSvc).GetDocumentRedactions)
+	apply := func() {
+		resp, err := app.Test(httptest.NewRequest("POST", "/apply/doc-1", nil), -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	before := time.Now()
+	apply()
+	audit := searchSvc.documents["doc-1"].RedactionAudit
+	require.Len(t, audit, 1)
+	record := audit[0]
+	assert.Equal(t, "clerk@example.org", record.Operator)
+	assert.False(t, record.AppliedAt.Before(before))
+	assert.Equal(t, 3, record.TotalCount)
+	assert.Equal(t, []string{"CCP_1798.3", "CCP_1798.80"}, record.LegalCodes)
+	require.Len(t, record.Items, 3)
+	assert.Equal(t, "123-45-6789", record.Items[0].Text)
+	assert.True(t, record.Items[0].Applied)
+
+	// A second application is appended, leaving the first record as it was
+	apply()
+	audit = searchSvc.documents["doc-1"].RedactionAudit
+	require.Len(t, audit, 2)
+	assert.Equal(t, record, audit[0])
+
+	// The log is served alongside the latest analysis
+	searchSvc.documents["doc-1"].Redactions = &models.RedactionAnalysis{Items: record.Items, TotalCount: 3}
+	resp, err := app.Test(httptest.NewRequest("GET", "/documents/doc-1/redactions", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	auditLog := decoded["data"].(map[string]interface{})["audit_log"].([]interface{})
+	require.Len(t, auditLog, 2)
+	assert.Equal(t, "clerk@example.org", auditLog[0].(map[string]interface{})["operator"])
+
+	// Nothing is recorded unless REDACTION_AUDIT_LOG is enabled
+	cfg.Processing.RedactionAuditLog = false
+	apply()
+	assert.Len(t, searchSvc.documents["doc-1"].RedactionAudit, 2)
+}
+
+func TestUpdateMetadata_EffectiveDatedFields(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1", Metadata: &models.DocumentMetadata{Judge: &models.Judge{Name: "Hon. Smith"}}}
+	cfg := testutil.TestConfig()
+	cfg.Search.EffectiveDatedFields = "judge"
+
+	app := fiber.New()
+	app.Post("/update-metadata", NewProcessingHandler(cfg, nil, nil, searchSvc).UpdateMetadata)
+	update := func(body string) int {
+		req := httptest.NewRequest("POST", "/update-metadata", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, fiber.StatusOK, update(`{"document_id": "doc-1", "metadata": {"judge": "Hon. Jones"}, "effective_date": "2024-03-01T00:00:00Z"}`))
+
+	doc := searchSvc.documents["doc-1"]
+	assert.Equal(t, "Hon. Jones", doc.Metadata.GetJudgeName(), "the current value is kept for filtering")
+	judge, ok := models.MetadataValueAsOf(doc.MetadataHistory, "judge", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, "Hon. Smith", judge, "the judge before the reassignment is kept")
+	judge, _ = models.MetadataValueAsOf(doc.MetadataHistory, "judge", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "Hon. Jones", judge)
+
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "metadata": {"status": "approved"}, "effective_date": "2024-03-01T00:00:00Z"}`),
+		"effective_date needs an effective-dated field")
+	assert.Equal(t, fiber.StatusNotFound, update(`{"document_id": "missing", "metadata": {"judge": "Hon. Lee"}}`))
+
+	future := time.Now().AddDate(0, 1, 0).Format(time.RFC3339)
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "metadata": {"judge": "Hon. Lee"}, "effective_date": "`+future+`"}`),
+		"a future amendment would never become the current value")
+	assert.Equal(t, "Hon. Jones", searchSvc.documents["doc-1"].Metadata.GetJudgeName())
+}
+
+func TestUpdateMetadata_SetAndRemove(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1"}
+	cfg := testutil.TestConfig()
+	cfg.Search.EffectiveDatedFields = "court"
+
+	app := fiber.New()
+	app.Post("/update-metadata", NewProcessingHandler(cfg, nil, nil, searchSvc).UpdateMetadata)
+	update := func(body string) int {
+		req := httptest.NewRequest("POST", "/update-metadata", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, fiber.StatusOK, update(`{"document_id": "doc-1", "metadata": {"status": "filed"}, `+
+		`"set": {"court.jurisdiction": "state", "confidence": 0.75}, "remove": ["custom.matter"]}`))
+	require.Len(t, searchSvc.metadataUpdates, 1)
+	assert.Equal(t, metadataUpdate{
+		docID:  "doc-1",
+		set:    map[string]interface{}{"status": "filed", "court.jurisdiction": "state", "confidence": 0.75},
+		remove: []string{"custom.matter"},
+	}, searchSvc.metadataUpdates[0])
+	versions := searchSvc.documents["doc-1"].MetadataVersions
+	require.Len(t, versions, 1, "the change is recorded")
+	assert.Equal(t, []string{"custom.matter"}, versions[0].Removed)
+	assert.Equal(t, "state", versions[0].Values["court.jurisdiction"])
+
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "set": {"evil_field": "x"}}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "metadata": {"evil_field": "x"}}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "metadata": {"a.b.c": "x"}}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "metadata": {"status": "filed"}, "remove": ["status"]}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "set": {"filing_date": "not a date"}}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "set": {"court.court_name": "Superior Court"}}`),
+		"the tracked value of an effective-dated field is amended through metadata")
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1", "remove": ["court"]}`))
+	assert.Equal(t, fiber.StatusBadRequest, update(`{"document_id": "doc-1"}`))
+	assert.Len(t, searchSvc.metadataUpdates, 1, "rejected updates are not applied")
+}
+
+func TestUpdateMetadata_ReflagsRequiredMetadata(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["order-1"] = &models.Document{ID: "order-1", Metadata: &models.DocumentMetadata{
+		DocumentType:  models.DocTypeOrder,
+		ReviewStatus:  models.ReviewStatusIncompleteMetadata,
+		MissingFields: []string{"judge"},
+	}}
+	searchSvc.documents["order-2"] = &models.Document{ID: "order-2", Metadata: &models.DocumentMetadata{
+		DocumentType: models.DocTypeOrder,
+		Judge:        &models.Judge{Name: "Hon. Smith"},
+	}}
+	cfg := testutil.TestConfig()
+	cfg.Search.EffectiveDatedFields = "judge"
+
+	handler := NewProcessingHandler(cfg, nil, nil, searchSvc)
+	var err error
+	handler.required, err = pipeline.ParseRequiredFields("order:judge,status")
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Post("/update-metadata", handler.UpdateMetadata)
+	app.Post("/bulk-update-metadata", handler.BulkUpdateMetadata)
+	post := func(path, body string) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	// Filling in the judge leaves the status missing
+	post("/update-metadata", `{"document_id": "order-1", "metadata": {"judge": "Hon. Jones"}}`)
+	require.Len(t, searchSvc.metadataUpdates, 1)
+	assert.Equal(t, metadataUpdate{
+		docID: "order-1",
+		set:   map[string]interface{}{"review_status": models.ReviewStatusIncompleteMetadata, "missing_fields": []string{"status"}},
+	}, searchSvc.metadataUpdates[0])
+
+	// A bulk update that completes a record clears its flag, and one that
+	// changes nothing required stores nothing more
+	searchSvc.documents["order-2"].Metadata.Status = "filed"
+	post("/bulk-update-metadata", `{"updates": [
+		{"id": "order-1", "metadata": {"status": "filed"}},
+		{"id": "order-2", "metadata": {"subject": "Order granting suppression"}}
+	]}`)
+	require.Len(t, searchSvc.metadataUpdates, 2)
+	assert.Equal(t, metadataUpdate{
+		docID:  "order-1",
+		set:    map[string]interface{}{},
+		remove: []string{"review_status", "missing_fields"},
+	}, searchSvc.metadataUpdates[1])
+	assert.Empty(t, searchSvc.documents["order-1"].Metadata.ReviewStatus)
+}
+
+func TestBulkUpdateMetadata(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1", Metadata: &models.DocumentMetadata{Status: "draft"}}
+	searchSvc.documents["doc-2"] = &models.Document{ID: "doc-2"}
+	searchSvc.documents["doc-3"] = &models.Document{ID: "doc-3", Metadata: &models.DocumentMetadata{Judge: &models.Judge{Name: "Hon. Smith"}}}
+	cfg := testutil.TestConfig()
+	cfg.Search.EffectiveDatedFields = "judge"
+
+	app := fiber.New()
+	app.Post("/bulk-update-metadata", NewProcessingHandler(cfg, nil, nil, searchSvc).BulkUpdateMetadata)
+	post := func(body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/bulk-update-metadata", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var decoded map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+		return resp.StatusCode, decoded
+	}
+
+	status, body := post(`{"updates": [
+		{"id": "doc-1", "metadata": {"status": "approved"}},
+		{"id": "missing", "metadata": {"status": "approved"}},
+		{"id": "", "metadata": {"status": "approved"}},
+		{"id": "doc-2", "metadata": {}},
+		{"id": "doc-3", "metadata": {"judge": "Hon. Lee"}, "effective_date": "2024-03-01T00:00:00Z"},
+		{"id": "doc-2", "metadata": {"subject": "Motion to suppress"}},
+		{"id": "doc-1", "metadata": {"status": "archived"}},
+		{"id": "doc-4", "metadata": {"judge": "Hon. Lee"}, "effective_date": "` + time.Now().AddDate(0, 1, 0).Format(time.RFC3339) + `"},
+		{"id": "doc-5", "metadata": {"status": "filed"}, "effective_date": "2024-03-01T00:00:00Z"}
+	]}`)
+
+	require.Equal(t, fiber.StatusOK, status)
+	data := body["data"].(map[string]interface{})
+	assert.EqualValues(t, 3, data["updated"])
+	assert.EqualValues(t, 6, data["failed"])
+
+	results := data["results"].([]interface{})
+	require.Len(t, results, 9)
+	outcome := func(i int) (bool, string) {
+		result := results[i].(map[string]interface{})
+		errMsg, _ := result["error"].(string)
+		return result["success"].(bool), errMsg
+	}
+	for i, want := range []struct {
+		success bool
+		err     string
+	}{
+		{true, ""},
+		{false, "document not found"},
+		{false, "id is required"},
+		{false, "metadata is required"},
+		{true, ""},
+		{true, ""},
+		{false, "duplicate id"},
+		{false, "in the future"},
+		{false, "effective_date requires"},
+	} {
+		success, errMsg := outcome(i)
+		assert.Equal(t, want.success, success, "entry %d", i)
+		assert.Contains(t, errMsg, want.err, "entry %d", i)
+	}
+
+	doc := searchSvc.documents["doc-1"]
+	assert.Equal(t, "approved", doc.Metadata.Status)
+	require.Len(t, doc.MetadataVersions, 1, "each change is recorded")
+	assert.Equal(t, map[string]interface{}{"status": "approved"}, doc.MetadataVersions[0].Values)
+	assert.Equal(t, "draft", doc.MetadataVersions[0].Previous["status"])
+	assert.Equal(t, "Motion to suppress", searchSvc.documents["doc-2"].Metadata.Subject)
+
+	// Effective-dated fields are amended with their history
+	amended := searchSvc.documents["doc-3"]
+	assert.Equal(t, "Hon. Lee", amended.Metadata.GetJudgeName())
+	judge, ok := models.MetadataValueAsOf(amended.MetadataHistory, "judge", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, "Hon. Smith", judge)
+
+	t.Run("batch size is capped", func(t *testing.T) {
+		updates := make([]string, internalModels.MaxBulkMetadataUpdateSize+1)
+		for i := range updates {
+			updates[i] = fmt.Sprintf(`{"id": "doc-%d", "metadata": {"status": "approved"}}`, i)
+		}
+		status, _ := post(`{"updates": [` + strings.Join(updates, ",") + `]}`)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+
+		status, _ = post(`{"updates": []}`)
+		assert.Equal(t, fiber.StatusBadRequest, status)
+	})
+}
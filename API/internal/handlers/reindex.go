@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	internalModels "motion-index-fiber/internal/models"
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/storage"
+)
+
+// Reindex modes
+const (
+	// ReindexModeReprocess extracts, classifies and indexes every document again
+	ReindexModeReprocess = "reprocess"
+	// ReindexModeCopy re-extracts text but keeps the metadata already in the index
+	ReindexModeCopy = "copy"
+)
+
+// ReindexRequest represents a request to reindex documents from storage
+type ReindexRequest struct {
+	Prefix string `json:"prefix,omitempty"` // Storage prefix to reindex; empty reindexes everything
+	Mode   string `json:"mode,omitempty"`   // "reprocess" (default) or "copy"
+
+	// Priority orders the job among queued batch jobs, as for batch classification
+	Priority int `json:"priority,omitempty"`
+
+	// InvalidateCDN flushes the CDN cache for the reindexed files, for files
+	// replaced in the bucket without going through the API
+	InvalidateCDN bool `json:"invalidate_cdn,omitempty"`
+}
+
+// StartReindex handles POST /api/v1/admin/reindex - Start async reindex job.
+// Documents are listed from storage by the job, which then runs through the
+// batch job machinery, so progress, results and cancellation use the
+// /batch/{job_id} endpoints.
+func (h *BatchHandler) StartReindex(c *fiber.Ctx) error {
+	var request ReindexRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+				"parse_error",
+				"Failed to parse request body",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+	}
+
+	if request.Mode == "" {
+		request.Mode = ReindexModeReprocess
+	}
+	if request.Mode != ReindexModeReprocess && request.Mode != ReindexModeCopy {
+		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
+			"validation_error",
+			fmt.Sprintf("Invalid reindex mode %q: use %q or %q", request.Mode, ReindexModeReprocess, ReindexModeCopy),
+			nil,
+		))
+	}
+
+	jobID := uuid.New().String()
+	job := &BatchJob{
+		ID:        jobID,
+		Type:      "reindex",
+		Status:    "listing",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Options: map[string]interface{}{
+			"index_document": true,
+			"reindex_mode":   request.Mode,
+			"prefix":         request.Prefix,
+			"invalidate_cdn": request.InvalidateCDN,
+		},
+		Priority: request.Priority,
+	}
+
+	h.jobsMutex.Lock()
+	h.jobs[jobID] = job
+	response := map[string]interface{}{
+		"job_id":     jobID,
+		"status":     job.Status,
+		"mode":       request.Mode,
+		"priority":   job.Priority,
+		"created_at": job.CreatedAt,
+	}
+	h.jobsMutex.Unlock()
+
+	log.Printf("[REINDEX] 🔁 Job %s created: listing documents under %q (mode: %s)", jobID, request.Prefix, request.Mode)
+	go h.listReindexDocuments(jobID, request.Prefix)
+
+	return c.Status(fiber.StatusAccepted).JSON(internalModels.NewSuccessResponse(response, "Reindex job started"))
+}
+
+// listReindexDocuments lists a reindex job's documents from storage and
+// schedules the job. Listing a large bucket can take minutes, so it runs
+// after the request has returned; a job cancelled meanwhile is not scheduled.
+func (h *BatchHandler) listReindexDocuments(jobID, prefix string) {
+	objects, err := h.storage.List(context.Background(), prefix)
+	if err != nil {
+		log.Printf("[REINDEX] ❌ Job %s failed to list documents: %v", jobID, err)
+		h.finishListing(jobID, nil, fmt.Sprintf("failed to list documents for reindexing: %v", err))
+		return
+	}
+
+	// Document IDs are storage paths, matching the batch classifier CLI
+	var documents []BatchDocumentInput
+	for _, object := range objects {
+		if strings.HasSuffix(object.Path, "/") {
+			continue
+		}
+		documents = append(documents, BatchDocumentInput{
+			DocumentID:   object.Path,
+			DocumentPath: object.Path,
+		})
+	}
+	if len(documents) == 0 {
+		h.finishListing(jobID, nil, fmt.Sprintf("no documents found to reindex under %q", prefix))
+		return
+	}
+
+	log.Printf("[REINDEX] 📋 Job %s listed %d documents under %q", jobID, len(documents), prefix)
+	h.finishListing(jobID, documents, "")
+}
+
+// finishListing schedules a listed reindex job, or fails it with errorMsg,
+// unless it was cancelled while listing
+func (h *BatchHandler) finishListing(jobID string, documents []BatchDocumentInput, errorMsg string) {
+	h.jobsMutex.Lock()
+	defer h.jobsMutex.Unlock()
+
+	job, exists := h.jobs[jobID]
+	if !exists || job.Status != "listing" {
+		return
+	}
+	job.UpdatedAt = time.Now()
+	if errorMsg != "" {
+		job.Status = "failed"
+		job.Error = errorMsg
+		now := time.Now()
+		job.CompletedAt = &now
+		h.publish(newBatchEvent(job))
+		return
+	}
+
+	job.Status = "queued"
+	job.Progress.TotalDocuments = len(documents)
+	if !h.scheduleJob(jobID, documents) {
+		h.publish(newBatchEvent(job))
+	}
+}
+
+// invalidateJobFiles flushes the CDN cache for the files of a job whose
+// invalidate_cdn option is set
+func (h *BatchHandler) invalidateJobFiles(ctx context.Context, jobID string, documents []BatchDocumentInput) {
+	h.jobsMutex.RLock()
+	invalidate, _ := h.jobs[jobID].Options["invalidate_cdn"].(bool)
+	h.jobsMutex.RUnlock()
+	if !invalidate {
+		return
+	}
+
+	paths := make([]string, 0, len(documents))
+	for _, doc := range documents {
+		paths = append(paths, doc.DocumentPath)
+	}
+	if err := storage.InvalidateCache(ctx, h.storage, paths); err != nil {
+		log.Printf("[REINDEX] ⚠️ CDN cache invalidation failed for job %s: %v", jobID, err)
+	}
+}
+
+// copiesIndexedMetadata reports whether a job reindexes with the metadata
+// already stored in the index instead of classifying again
+func copiesIndexedMetadata(options map[string]interface{}) bool {
+	mode, _ := options["reindex_mode"].(string)
+	return mode == ReindexModeCopy
+}
+
+// lookupIndexedDocument fetches the indexed copy of a document for copy-mode reindexing
+func (h *BatchHandler) lookupIndexedDocument(ctx context.Context, docID string) (*models.Document, error) {
+	existing, err := h.search.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("no indexed document to copy metadata from: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("no indexed document to copy metadata from")
+	}
+	return existing, nil
+}
+
+// classificationFromDocument rebuilds a classification result from an indexed document
+func classificationFromDocument(doc *models.Document) *classifier.ClassificationResult {
+	result := &classifier.ClassificationResult{
+		DocumentType: doc.DocType,
+		Success:      true,
+	}
+	if doc.Metadata != nil {
+		if doc.Metadata.DocumentType != "" {
+			result.DocumentType = string(doc.Metadata.DocumentType)
+		}
+		result.Subject = doc.Metadata.Subject
+		result.Summary = doc.Metadata.Summary
+		result.Confidence = doc.Metadata.Confidence
+	}
+	return result
+}
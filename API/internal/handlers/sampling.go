@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Sample strategies for the "sample_strategy" job option, which decides what
+// part of a document's text is sent to the classifier
+const (
+	// SampleStrategyHead takes the first classification_length characters
+	SampleStrategyHead = "head"
+	// SampleStrategyOffset skips classification_offset characters, then takes classification_length
+	SampleStrategyOffset = "offset"
+	// SampleStrategyHeadAndTail joins the start and end of the document, splitting classification_length between them
+	SampleStrategyHeadAndTail = "head_and_tail"
+)
+
+// Defaults for the classification text window. Skipping the first 500
+// characters passes over most court headers and attorney blocks.
+const (
+	defaultClassificationOffset = 500
+	defaultClassificationLength = 1000
+)
+
+// headAndTailSeparator marks the text omitted between the head and tail samples
+const headAndTailSeparator = "\n...\n"
+
+// textSample describes which part of a document's text is classified
+type textSample struct {
+	Strategy string
+	Offset   int
+	Length   int
+}
+
+// textSampleFromOptions reads the classification_offset, classification_length
+// and sample_strategy job options, falling back to the defaults for any that
+// are missing
+func textSampleFromOptions(options map[string]interface{}) (textSample, error) {
+	sample := textSample{
+		Strategy: SampleStrategyOffset,
+		Offset:   defaultClassificationOffset,
+		Length:   defaultClassificationLength,
+	}
+
+	if value, exists := options["sample_strategy"]; exists {
+		strategy, ok := value.(string)
+		if !ok {
+			return sample, fmt.Errorf("sample_strategy must be a string")
+		}
+		switch strategy {
+		case SampleStrategyHead, SampleStrategyOffset, SampleStrategyHeadAndTail:
+			sample.Strategy = strategy
+		default:
+			return sample, fmt.Errorf("invalid sample_strategy %q: use %q, %q or %q",
+				strategy, SampleStrategyHead, SampleStrategyOffset, SampleStrategyHeadAndTail)
+		}
+	}
+
+	offset, err := intOption(options, "classification_offset", sample.Offset)
+	if err != nil {
+		return sample, err
+	}
+	if offset < 0 {
+		return sample, fmt.Errorf("classification_offset must not be negative")
+	}
+	sample.Offset = offset
+
+	length, err := intOption(options, "classification_length", sample.Length)
+	if err != nil {
+		return sample, err
+	}
+	if length <= 0 {
+		return sample, fmt.Errorf("classification_length must be positive")
+	}
+	sample.Length = length
+
+	return sample, nil
+}
+
+// intOption reads a whole-number job option. JSON decodes numbers as float64.
+func intOption(options map[string]interface{}, key string, defaultValue int) (int, error) {
+	value, exists := options[key]
+	if !exists {
+		return defaultValue, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+		return 0, fmt.Errorf("%s must be a whole number", key)
+	}
+	return int(number), nil
+}
+
+// Apply returns the part of text to classify, fitted to the document's
+// length: the offset window runs to the end of text shorter than it, an
+// offset past the end of the text falls back to the head of the document,
+// and the head strategies use text no longer than the window whole. Samples
+// never cut a multi-byte character in two.
+func (s textSample) Apply(text string) string {
+	switch s.Strategy {
+	case SampleStrategyHead:
+		return text[:runeStart(text, s.Length)]
+	case SampleStrategyHeadAndTail:
+		if len(text) <= s.Length {
+			return text
+		}
+		head := runeStart(text, s.Length/2)
+		// The tail starts after a character it would cut, keeping within the window
+		tail := len(text) - (s.Length - s.Length/2)
+		for tail < len(text) && !utf8.RuneStart(text[tail]) {
+			tail++
+		}
+		return text[:head] + headAndTailSeparator + text[tail:]
+	default:
+		if s.Offset >= len(text) {
+			return text[:runeStart(text, s.Length)]
+		}
+		start := runeStart(text, s.Offset)
+		return text[start:runeStart(text, start+s.Length)]
+	}
+}
+
+// runeStart returns the byte index i, capped at the length of text and moved
+// back to the start of the character it falls inside
+func runeStart(text string, i int) int {
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
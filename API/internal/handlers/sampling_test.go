@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextSample_Apply(t *testing.T) {
+	defaults := textSample{Strategy: SampleStrategyOffset, Offset: defaultClassificationOffset, Length: defaultClassificationLength}
+
+	// Texts between the offset and the window length still skip the caption
+	text := strings.Repeat("a", 800)
+	assert.Equal(t, text[500:], defaults.Apply(text))
+	short := strings.Repeat("a", 400)
+	assert.Equal(t, short, defaults.Apply(short))
+
+	// Each boundary falls inside a two-byte character
+	accented := strings.Repeat("ñ", 1000)
+	tests := []struct {
+		name   string
+		sample textSample
+		runes  int
+	}{
+		{"offset", textSample{Strategy: SampleStrategyOffset, Offset: 501, Length: 601}, 300},
+		{"offset past the end", textSample{Strategy: SampleStrategyOffset, Offset: 5000, Length: 11}, 5},
+		{"head", textSample{Strategy: SampleStrategyHead, Length: 11}, 5},
+		{"head and tail", textSample{Strategy: SampleStrategyHeadAndTail, Length: 22}, 5 + 5 + utf8.RuneCountInString(headAndTailSeparator)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample := tt.sample.Apply(accented)
+			assert.True(t, utf8.ValidString(sample))
+			assert.Equal(t, tt.runes, utf8.RuneCountInString(sample))
+		})
+	}
+}
@@ -2,24 +2,43 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
+	"motion-index-fiber/internal/config"
 	internalModels "motion-index-fiber/internal/models"
 	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/pipeline"
+	"motion-index-fiber/pkg/processing/redaction"
 	"motion-index-fiber/pkg/search"
+	"motion-index-fiber/pkg/storage"
 )
 
 // SearchHandler handles search-related HTTP requests
 type SearchHandler struct {
+	config        *config.Config
 	searchService search.Service
+
+	// storage holds the files of indexed documents; deletes flush them from
+	// its CDN. Nil skips the flush.
+	storage storage.Service
+
+	// schemas and required are METADATA_SCHEMAS and REQUIRED_METADATA_FIELDS,
+	// parsed once by New. Nil schemas serve the defaults.
+	schemas  pipeline.MetadataSchemas
+	required pipeline.RequiredFields
 }
 
 // NewSearchHandler creates a new search handler
-func NewSearchHandler(searchService search.Service) *SearchHandler {
+func NewSearchHandler(cfg *config.Config, searchService search.Service) *SearchHandler {
 	return &SearchHandler{
+		config:        cfg,
 		searchService: searchService,
 	}
 }
@@ -58,12 +77,40 @@ func (h *SearchHandler) SearchDocuments(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	// The generated query exposes index field names, so it is only served in debug mode
+	if req.DebugQuery && !h.debugEnabled() {
+		return fiber.NewError(fiber.StatusForbidden, "debug_query requires search debug mode (SEARCH_DEBUG=true)")
+	}
+
+	// Scoring explanations are a debugging aid and only served in debug mode
+	if req.Explain {
+		if !h.debugEnabled() {
+			return fiber.NewError(fiber.StatusForbidden, "explain requires search debug mode (SEARCH_DEBUG=true)")
+		}
+		if req.Size > models.MaxExplainSize {
+			req.Size = models.MaxExplainSize
+		}
+	}
+
+	req.DefaultSort = h.defaultSort()
+	if h.config != nil {
+		req.HighlightFragmentSize = h.config.Search.HighlightFragmentSize
+		req.HighlightFragments = h.config.Search.HighlightMaxFragments
+	}
+
 	// Execute search
 	result, err := h.searchService.SearchDocuments(ctx, &req)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Search failed: "+err.Error())
 	}
 
+	if h.config != nil && h.config.Search.ScrubHighlights {
+		scrubHighlights(result)
+	}
+	for _, doc := range result.Documents {
+		h.refreshCDNURL(doc.Document)
+	}
+
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"data":    result,
@@ -103,6 +150,23 @@ func (h *SearchHandler) GetDocumentTypes(c *fiber.Ctx) error {
 	})
 }
 
+// GetDocumentTypeSchema handles GET /document-types/:type/schema
+func (h *SearchHandler) GetDocumentTypeSchema(c *fiber.Ctx) error {
+	docType, err := url.PathUnescape(c.Params("type"))
+	if err != nil || strings.TrimSpace(docType) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Document type is required")
+	}
+
+	schemas := h.schemas
+	if schemas == nil {
+		schemas = pipeline.DefaultMetadataSchemas
+	}
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   schemas.SchemaFor(docType, h.required),
+	})
+}
+
 // GetDocumentStats handles GET /document-stats
 func (h *SearchHandler) GetDocumentStats(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 15*time.Second)
@@ -119,19 +183,73 @@ func (h *SearchHandler) GetDocumentStats(c *fiber.Ctx) error {
 	})
 }
 
-// GetFieldOptions handles GET /field-options
+// GetCaseStats handles GET /cases/{case_number}/stats. Case numbers often
+// contain slashes or colons, so the parameter is URL-decoded; clients should
+// percent-encode it (e.g. "CR%2F2024%2F001").
+func (h *SearchHandler) GetCaseStats(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 15*time.Second)
+	defer cancel()
+
+	caseNumber, err := url.PathUnescape(c.Params("case_number"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid case number encoding: "+err.Error())
+	}
+	caseNumber = strings.TrimSpace(caseNumber)
+	if caseNumber == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Case number is required")
+	}
+
+	stats, err := h.searchService.GetCaseStats(ctx, caseNumber)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve case stats: "+err.Error())
+	}
+	if stats.TotalDocuments == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No documents found for case "+caseNumber)
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data":   stats,
+	})
+}
+
+// GetFieldOptions handles GET and POST /field-options (and /all-field-options).
+// An optional search request body (or ?q=) narrows the facet counts to the
+// matching documents; without one the counts cover the whole corpus.
 func (h *SearchHandler) GetFieldOptions(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 15*time.Second)
 	defer cancel()
 
-	options, err := h.searchService.GetAllFieldOptions(ctx)
+	var req models.SearchRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+	}
+	if req.Query == "" {
+		req.Query = c.Query("q", "")
+	}
+	if !req.DateRange.HasValidField() {
+		return fiber.NewError(fiber.StatusBadRequest, "unsupported date_range field: "+req.DateRange.Field)
+	}
+
+	filtered := req.Query != "" || req.HasFilters()
+
+	var options *models.FieldOptions
+	var err error
+	if filtered {
+		options, err = h.searchService.GetFieldOptionsForQuery(ctx, &req)
+	} else {
+		options, err = h.searchService.GetAllFieldOptions(ctx)
+	}
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve field options: "+err.Error())
 	}
 
 	return c.JSON(fiber.Map{
-		"status": "success",
-		"data":   options,
+		"status":   "success",
+		"data":     options,
+		"filtered": filtered,
 	})
 }
 
@@ -142,14 +260,29 @@ func (h *SearchHandler) GetMetadataFields(c *fiber.Ctx) error {
 	fields := []map[string]interface{}{
 		{"id": "case_name", "name": "Case Name", "type": "string"},
 		{"id": "case_number", "name": "Case Number", "type": "string"},
+		{"id": "docket", "name": "Docket Number", "type": "string"},
 		{"id": "author", "name": "Author", "type": "string"},
 		{"id": "judge", "name": "Judge", "type": "string"},
 		{"id": "court", "name": "Court", "type": "string"},
 		{"id": "legal_tags", "name": "Legal Tags", "type": "array"},
+		{"id": "party_role", "name": "Party Role", "type": "array"},
 		{"id": "doc_type", "name": "Document Type", "type": "string"},
 		{"id": "category", "name": "Category", "type": "string"},
 		{"id": "status", "name": "Status", "type": "string"},
 		{"id": "created_at", "name": "Created Date", "type": "date"},
+		{"id": "filing_date", "name": "Filing Date", "type": "date"},
+		{"id": "event_date", "name": "Event Date", "type": "date"},
+		{"id": "hearing_date", "name": "Hearing Date", "type": "date"},
+		{"id": "decision_date", "name": "Decision Date", "type": "date"},
+		{"id": "served_date", "name": "Served Date", "type": "date"},
+		{"id": "signature_date", "name": "Signature Date", "type": "date"},
+		{"id": "extraction_quality", "name": "Extraction Quality", "type": "number"},
+		{"id": "low_quality_extraction", "name": "Low-Quality Extraction", "type": "boolean"},
+		{"id": "review_status", "name": "Review Status", "type": "string"},
+		{"id": "missing_fields", "name": "Missing Fields", "type": "array"},
+		{"id": "source_system", "name": "Source System", "type": "string"},
+		{"id": "ingestion_batch_id", "name": "Ingestion Batch", "type": "string"},
+		{"id": "language", "name": "Language", "type": "string"},
 	}
 
 	response := map[string]interface{}{
@@ -237,7 +370,16 @@ func (h *SearchHandler) PostMetadataFieldValues(c *fiber.Ctx) error {
 	}, "Metadata field values retrieved successfully"))
 }
 
-// GetDocument handles GET /documents/{id}
+// documentSource is a document without its extracted text, which can run
+// to megabytes, for callers that only need the metadata
+type documentSource struct {
+	*models.Document
+	Text  string                `json:"text,omitempty"`
+	Pages []models.DocumentPage `json:"pages,omitempty"`
+}
+
+// GetDocument handles GET /documents/{id}. With source_only=true the
+// extracted text is left out; GET /documents/{id}/text serves it.
 func (h *SearchHandler) GetDocument(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
@@ -255,12 +397,106 @@ func (h *SearchHandler) GetDocument(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve document: "+err.Error())
 	}
 
+	if h.config != nil && document.CDNURL != "" && document.FilePath != "" {
+		document.CDNURL = h.config.CDNURL(document.FilePath)
+	}
+
+	if c.QueryBool("source_only") {
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   documentSource{Document: document},
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"data":   document,
 	})
 }
 
+// GetDocumentText handles GET /documents/{id}/text, returning only the
+// document's extracted text, or with ?page=N the text of that page for
+// documents indexed with page text
+func (h *SearchHandler) GetDocumentText(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	docID := c.Params("id")
+	if docID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Document ID is required")
+	}
+
+	page := 0
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return fiber.NewError(fiber.StatusBadRequest, "page must be a positive integer")
+		}
+		page = n
+	}
+
+	document, err := h.searchService.GetDocument(ctx, docID)
+	if err != nil {
+		if err.Error() == "document not found" {
+			return fiber.NewError(fiber.StatusNotFound, "Document not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve document: "+err.Error())
+	}
+
+	data := fiber.Map{
+		"document_id": document.ID,
+		"page_count":  len(document.Pages),
+	}
+	if page == 0 {
+		data["text"] = document.Text
+		return c.JSON(fiber.Map{"status": "success", "data": data})
+	}
+
+	if len(document.Pages) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No page text stored for this document")
+	}
+	for _, p := range document.Pages {
+		if p.Number == page {
+			data["page"] = p.Number
+			data["text"] = p.Text
+			return c.JSON(fiber.Map{"status": "success", "data": data})
+		}
+	}
+	return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Page %d not found; the document has %d pages", page, len(document.Pages)))
+}
+
+// GetDocumentClassification handles GET /documents/{id}/classification,
+// returning the classifier's full result for the document. Documents indexed
+// before results were stored, or never classified, have none.
+func (h *SearchHandler) GetDocumentClassification(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	docID := c.Params("id")
+	if docID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Document ID is required")
+	}
+
+	document, err := h.searchService.GetDocument(ctx, docID)
+	if err != nil {
+		if err.Error() == "document not found" {
+			return fiber.NewError(fiber.StatusNotFound, "Document not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve document: "+err.Error())
+	}
+	if len(document.Classification) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No classification result stored for this document")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"document_id":    document.ID,
+			"classification": document.Classification,
+		},
+	})
+}
+
 // DeleteDocument handles DELETE /documents/{id} (protected endpoint)
 func (h *SearchHandler) DeleteDocument(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
@@ -282,8 +518,104 @@ func (h *SearchHandler) DeleteDocument(c *fiber.Ctx) error {
 	})
 }
 
-// GetDocumentRedactions gets redaction analysis for a specific document
+// DeleteDocuments handles DELETE /documents, removing documents by ID or by query
+func (h *SearchHandler) DeleteDocuments(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+	defer cancel()
+
+	var req internalModels.BulkDeleteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	ids := req.IDs
+	if req.Query != nil {
+		if err := validateSearchRequest(req.Query); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		var err error
+		ids, err = h.resolveQueryIDs(ctx, req.Query)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The file paths are only known while the documents are indexed
+	paths := h.cachedFilePaths(ctx, ids)
+
+	result, err := h.searchService.BulkDeleteDocuments(ctx, ids)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete documents: "+err.Error())
+	}
+
+	if err := storage.InvalidateCache(ctx, h.storage, paths); err != nil {
+		log.Printf("[SEARCH] ⚠️ CDN cache invalidation failed after deleting %d documents: %v", result.Deleted, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"data":    result,
+		"message": fmt.Sprintf("Deleted %d of %d documents", result.Deleted, len(ids)),
+	})
+}
+
+// cachedFilePaths returns the stored file paths of the given documents, to
+// flush from the CDN. Without a CDN to flush it looks nothing up.
+func (h *SearchHandler) cachedFilePaths(ctx context.Context, ids []string) []string {
+	if _, ok := h.storage.(storage.CacheInvalidator); !ok {
+		return nil
+	}
+	var paths []string
+	for _, id := range ids {
+		doc, err := h.searchService.GetDocument(ctx, id)
+		if err == nil && doc != nil && doc.FilePath != "" {
+			paths = append(paths, doc.FilePath)
+		}
+	}
+	return paths
+}
+
+// resolveQueryIDs pages through a search and returns the IDs of every match.
+// Queries matching more than MaxBulkDeleteSize documents are rejected rather
+// than partially deleted.
+func (h *SearchHandler) resolveQueryIDs(ctx context.Context, query *models.SearchRequest) ([]string, error) {
+	page := *query
+	page.Size = models.MaxSearchSize
+	page.From = 0
+	page.DebugQuery = false
+	page.Explain = false
+
+	var ids []string
+	for {
+		result, err := h.searchService.SearchDocuments(ctx, &page)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Search failed: "+err.Error())
+		}
+		if result.TotalHits > internalModels.MaxBulkDeleteSize {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf(
+				"query matches %d documents, more than the bulk delete limit of %d",
+				result.TotalHits, internalModels.MaxBulkDeleteSize))
+		}
+		for _, doc := range result.Documents {
+			ids = append(ids, doc.ID)
+		}
+		if len(result.Documents) < page.Size || int64(len(ids)) >= result.TotalHits {
+			return ids, nil
+		}
+		page.From += page.Size
+	}
+}
+
+// GetDocumentRedactions gets the redaction analysis stored with a document by
+// redact-document, with each item's page, bounding box, type and citation for
+// a reviewer to overlay on the original PDF
 func (h *SearchHandler) GetDocumentRedactions(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
 	docID := c.Params("id")
 	if docID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(internalModels.NewErrorResponse(
@@ -293,20 +625,87 @@ func (h *SearchHandler) GetDocumentRedactions(c *fiber.Ctx) error {
 		))
 	}
 
-	// For now, return a placeholder response indicating no redaction analysis found
-	// TODO: Implement actual redaction analysis retrieval from storage or search service
-	// This would typically involve:
-	// 1. Querying the document from search service to get metadata
-	// 2. Checking if redaction analysis exists for this document
-	// 3. Returning the redaction analysis data
+	document, err := h.searchService.GetDocument(ctx, docID)
+	if err != nil {
+		if err.Error() == "document not found" {
+			return c.Status(fiber.StatusNotFound).JSON(internalModels.NewErrorResponse(
+				"not_found",
+				"Document not found",
+				map[string]interface{}{
+					"document_id": docID,
+				},
+			))
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(internalModels.NewErrorResponse(
+			"search_error",
+			"Failed to retrieve document",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	if document.Redactions == nil {
+		return c.Status(fiber.StatusNotFound).JSON(internalModels.NewErrorResponse(
+			"not_found",
+			"No redaction analysis found for this document",
+			map[string]interface{}{
+				"document_id": docID,
+			},
+		))
+	}
 
-	return c.Status(fiber.StatusNotFound).JSON(internalModels.NewErrorResponse(
-		"not_found",
-		"No redaction analysis found for this document",
-		map[string]interface{}{
-			"document_id": docID,
-		},
-	))
+	return c.JSON(internalModels.NewSuccessResponse(fiber.Map{
+		"document_id":      docID,
+		"items":            document.Redactions.Items,
+		"total_count":      document.Redactions.TotalCount,
+		"coordinate_space": document.Redactions.CoordinateSpace,
+		"analyzed_at":      document.Redactions.AnalyzedAt,
+		"audit_log":        document.RedactionAudit,
+	}, "Redaction analysis retrieved successfully"))
+}
+
+// debugEnabled reports whether diagnostic search options are allowed
+func (h *SearchHandler) debugEnabled() bool {
+	return h.config != nil && h.config.Search.DebugEnabled
+}
+
+// defaultSort returns the configured browse sort, or nil to use the built-in one
+func (h *SearchHandler) defaultSort() []models.SortOptions {
+	if h.config == nil || h.config.Search.DefaultSort == "" {
+		return nil
+	}
+	sorts, err := models.ParseSortSpec(h.config.Search.DefaultSort)
+	if err != nil {
+		return nil
+	}
+	return sorts
+}
+
+// refreshCDNURL rebuilds the indexed CDN URL of a search hit from its storage
+// path, so links follow changes to the configured CDN domain without
+// reindexing. Hits indexed without a CDN URL are left alone.
+func (h *SearchHandler) refreshCDNURL(source map[string]interface{}) {
+	cdnURL, _ := source["cdn_url"].(string)
+	filePath, _ := source["file_path"].(string)
+	if h.config == nil || cdnURL == "" || filePath == "" {
+		return
+	}
+	source["cdn_url"] = h.config.CDNURL(filePath)
+}
+
+// scrubHighlights masks text matching the redaction patterns in the
+// highlight fragments of a search result, keeping the highlight tags
+func scrubHighlights(result *models.SearchResult) {
+	for _, doc := range result.Documents {
+		for field, fragments := range doc.Highlights {
+			for i, fragment := range fragments {
+				doc.Highlights[field][i] = redaction.ScrubText(fragment, models.HighlightPreTag, models.HighlightPostTag)
+			}
+		}
+		for _, page := range doc.Pages {
+			for i, fragment := range page.Highlights {
+				page.Highlights[i] = redaction.ScrubText(fragment, models.HighlightPreTag, models.HighlightPostTag)
+			}
+		}
+	}
 }
 
 // validateSearchRequest validates a search request
@@ -326,5 +725,23 @@ func validateSearchRequest(req *models.SearchRequest) error {
 		req.SortOrder = "desc"
 	}
 
+	if !req.DateRange.HasValidField() {
+		return fmt.Errorf("unsupported date_range field: %s", req.DateRange.Field)
+	}
+
+	if req.SearchAfter != nil && req.From > 0 {
+		return fmt.Errorf("search_after cannot be combined with from")
+	}
+
+	if err := models.ValidateSortClauses(req.Sort); err != nil {
+		return err
+	}
+
+	for key := range req.CustomMetadata {
+		if err := models.ValidateCustomMetadataKey(key); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -1,9 +1,24 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	internalModels "motion-index-fiber/internal/models"
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/pipeline"
+	"motion-index-fiber/pkg/storage"
 )
 
 func TestSearchHandlerExists(t *testing.T) {
@@ -11,4 +26,505 @@ func TestSearchHandlerExists(t *testing.T) {
 	assert.True(t, true)
 }
 
-// TODO: Reimplement search handler tests with proper service interfaces
\ No newline at end of file
+// postSearch sends a JSON body to a search handler method and returns the response status and body
+func postSearch(t *testing.T, handler fiber.Handler, payload map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/search", handler)
+
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/search", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+func TestSearchDocuments_Explain(t *testing.T) {
+	var received *models.SearchRequest
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		result := models.NewSearchResult()
+		result.Documents = []*models.SearchDocument{{
+			ID:          "doc-1",
+			Score:       1.2,
+			Explanation: map[string]interface{}{"value": 1.2, "description": "sum of:"},
+		}}
+		return result, nil
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Search.DebugEnabled = true
+
+	status, body := postSearch(t, NewSearchHandler(cfg, searchSvc).SearchDocuments, map[string]interface{}{
+		"query":   "suppress",
+		"size":    50,
+		"explain": true,
+	})
+	require.Equal(t, fiber.StatusOK, status)
+
+	require.NotNil(t, received)
+	assert.True(t, received.Explain)
+	assert.Equal(t, models.MaxExplainSize, received.Size)
+
+	docs := body["data"].(map[string]interface{})["documents"].([]interface{})
+	require.Len(t, docs, 1)
+	explanation, ok := docs[0].(map[string]interface{})["explanation"].(map[string]interface{})
+	require.True(t, ok, "explanation should be present when requested")
+	assert.Equal(t, "sum of:", explanation["description"])
+}
+
+func TestSearchDocuments_ExplainRequiresDebugMode(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		t.Fatal("search should not run when explain is rejected")
+		return nil, nil
+	}
+
+	status, _ := postSearch(t, NewSearchHandler(testutil.TestConfig(), searchSvc).SearchDocuments, map[string]interface{}{
+		"query":   "suppress",
+		"explain": true,
+	})
+	assert.Equal(t, fiber.StatusForbidden, status)
+}
+
+func TestSearchDocuments_SortClauses(t *testing.T) {
+	var received *models.SearchRequest
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		return models.NewSearchResult(), nil
+	}
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+
+	status, _ := postSearch(t, h.SearchDocuments, map[string]interface{}{
+		"query": "suppress",
+		"sort": []map[string]interface{}{
+			{"field": "metadata.court.court_name", "order": "asc"},
+			{"field": "metadata.filing_date"},
+			{"field": "_score"},
+		},
+	})
+	require.Equal(t, fiber.StatusOK, status)
+	require.NotNil(t, received)
+	assert.Equal(t, []models.SortClause{
+		{Field: "metadata.court.court_name", Order: models.SortOrderAsc},
+		{Field: "metadata.filing_date", Order: models.SortOrderDesc},
+		{Field: "_score", Order: models.SortOrderDesc},
+	}, received.Sort)
+
+	// Analyzed text cannot be sorted on
+	received = nil
+	status, _ = postSearch(t, h.SearchDocuments, map[string]interface{}{
+		"query": "suppress",
+		"sort":  []map[string]interface{}{{"field": "text"}},
+	})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Nil(t, received)
+}
+
+func TestSearchDocuments_SearchAfter(t *testing.T) {
+	var received *models.SearchRequest
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		return models.NewSearchResult(), nil
+	}
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+
+	status, _ := postSearch(t, h.SearchDocuments, map[string]interface{}{
+		"query":        "suppress",
+		"search_after": []interface{}{2.5, "doc-50"},
+	})
+	require.Equal(t, fiber.StatusOK, status)
+	require.NotNil(t, received)
+	assert.Equal(t, []interface{}{2.5, "doc-50"}, received.SearchAfter)
+
+	received = nil
+	status, _ = postSearch(t, h.SearchDocuments, map[string]interface{}{
+		"query":        "suppress",
+		"from":         50,
+		"search_after": []interface{}{2.5, "doc-50"},
+	})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Nil(t, received)
+}
+
+func TestSearchDocuments_DebugQueryOnlyInDebugMode(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		result := models.NewSearchResult()
+		if req.DebugQuery {
+			result.GeneratedQuery = map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}
+		}
+		return result, nil
+	}
+	payload := map[string]interface{}{"query": "suppress", "debug_query": true}
+
+	status, body := postSearch(t, NewSearchHandler(testutil.TestConfig(), searchSvc).SearchDocuments, payload)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.NotContains(t, body, "data")
+
+	cfg := testutil.TestConfig()
+	cfg.Search.DebugEnabled = true
+	status, body = postSearch(t, NewSearchHandler(cfg, searchSvc).SearchDocuments, payload)
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Contains(t, body["data"], "generated_query")
+
+	status, body = postSearch(t, NewSearchHandler(cfg, searchSvc).SearchDocuments, map[string]interface{}{"query": "suppress"})
+	require.Equal(t, fiber.StatusOK, status)
+	assert.NotContains(t, body["data"], "generated_query")
+}
+
+func TestSearchDocuments_ScrubsHighlights(t *testing.T) {
+	// The standard analyzer splits an SSN, so each part is highlighted on its own
+	const fragment = "Defendant <mark>John</mark> Doe, SSN <mark>123</mark>-<mark>45</mark>-<mark>6789</mark>, moved to suppress"
+	var received *models.SearchRequest
+	searchSvc := newMockSearchService()
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		result := models.NewSearchResult()
+		result.Documents = []*models.SearchDocument{{
+			ID:         "doc-1",
+			Highlights: map[string][]string{"text": {fragment}},
+			Pages:      []*models.PageMatch{{Number: 2, Highlights: []string{"SSN 123-45-6789"}}},
+		}}
+		return result, nil
+	}
+	payload := map[string]interface{}{"query": "john 123 45 6789", "include_highlights": true}
+
+	cfg := testutil.TestConfig()
+	cfg.Search.HighlightFragmentSize = 100
+	cfg.Search.HighlightMaxFragments = 2
+
+	// Off by default
+	status, body := postSearch(t, NewSearchHandler(cfg, searchSvc).SearchDocuments, payload)
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, 100, received.HighlightFragmentSize)
+	assert.Equal(t, 2, received.HighlightFragments)
+	doc := body["data"].(map[string]interface{})["documents"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, fragment, doc["highlights"].(map[string]interface{})["text"].([]interface{})[0])
+
+	cfg.Search.ScrubHighlights = true
+	status, body = postSearch(t, NewSearchHandler(cfg, searchSvc).SearchDocuments, payload)
+	require.Equal(t, fiber.StatusOK, status)
+
+	doc = body["data"].(map[string]interface{})["documents"].([]interface{})[0].(map[string]interface{})
+	highlight := doc["highlights"].(map[string]interface{})["text"].([]interface{})[0].(string)
+	plain := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(highlight)
+	assert.NotContains(t, plain, "123-45-6789")
+	assert.NotContains(t, plain, "6789")
+	assert.Equal(t, "Defendant <mark>John</mark> Doe, SSN <mark>■■■</mark>■<mark>■■</mark>■<mark>■■■■</mark>, moved to suppress", highlight)
+
+	page := doc["pages"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "SSN ■■■■■■■■■■■", page["highlights"].([]interface{})[0])
+}
+
+func TestGetDocumentTypeSchema(t *testing.T) {
+	handler := NewSearchHandler(testutil.TestConfig(), newMockSearchService())
+	var err error
+	handler.schemas, err = pipeline.ParseMetadataSchemas("order:case_number,judge,decision_date")
+	require.NoError(t, err)
+	handler.required, err = pipeline.ParseRequiredFields("order:judge,decision_date")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Get("/document-types/:type/schema", handler.GetDocumentTypeSchema)
+
+	getSchema := func(docType string) map[string]interface{} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/document-types/"+docType+"/schema", nil), -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body["data"].(map[string]interface{})
+	}
+
+	schema := getSchema("order")
+	assert.Equal(t, "order", schema["document_type"])
+	assert.Equal(t, false, schema["default"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "case_number", "type": "string", "required": false},
+		map[string]interface{}{"name": "judge", "type": "string", "required": true},
+		map[string]interface{}{"name": "decision_date", "type": "date", "required": true},
+	}, schema["fields"])
+
+	schema = getSchema("affidavit")
+	assert.Equal(t, true, schema["default"])
+	assert.NotEmpty(t, schema["fields"])
+}
+
+func getDocumentJSON(t *testing.T, app *fiber.App, target string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	if resp.StatusCode == fiber.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
+
+func TestGetDocument_SourceOnlyAndText(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["paged"] = &models.Document{
+		ID:       "paged",
+		FileName: "motion.pdf",
+		Text:     "first page\nsecond page",
+		Pages:    []models.DocumentPage{{Number: 1, Text: "first page"}, {Number: 2, Text: "second page"}},
+		Metadata: &models.DocumentMetadata{DocumentType: "motion"},
+	}
+	searchSvc.documents["flat"] = &models.Document{ID: "flat", Text: "whole text"}
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+
+	app := fiber.New()
+	app.Get("/documents/:id/text", h.GetDocumentText)
+	app.Get("/documents/:id", h.GetDocument)
+
+	status, body := getDocumentJSON(t, app, "/documents/paged")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "first page\nsecond page", body["data"].(map[string]interface{})["text"])
+
+	status, body = getDocumentJSON(t, app, "/documents/paged?source_only=true")
+	require.Equal(t, fiber.StatusOK, status)
+	data := body["data"].(map[string]interface{})
+	assert.NotContains(t, data, "text")
+	assert.NotContains(t, data, "pages")
+	assert.Equal(t, "motion.pdf", data["file_name"])
+	assert.Equal(t, "motion", data["metadata"].(map[string]interface{})["document_type"])
+
+	status, body = getDocumentJSON(t, app, "/documents/paged/text")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{
+		"document_id": "paged",
+		"text":        "first page\nsecond page",
+		"page_count":  float64(2),
+	}, body["data"])
+
+	status, body = getDocumentJSON(t, app, "/documents/paged/text?page=2")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "second page", body["data"].(map[string]interface{})["text"])
+	assert.Equal(t, float64(2), body["data"].(map[string]interface{})["page"])
+
+	status, _ = getDocumentJSON(t, app, "/documents/paged/text?page=3")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	status, _ = getDocumentJSON(t, app, "/documents/paged/text?page=0")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+
+	// Documents indexed without page text only have the whole text
+	status, body = getDocumentJSON(t, app, "/documents/flat/text")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "whole text", body["data"].(map[string]interface{})["text"])
+	status, _ = getDocumentJSON(t, app, "/documents/flat/text?page=1")
+	assert.Equal(t, fiber.StatusNotFound, status)
+
+	status, _ = getDocumentJSON(t, app, "/documents/missing/text")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
+
+func TestGetFieldOptions_WithFilter(t *testing.T) {
+	h := NewSearchHandler(testutil.TestConfig(), newMockSearchService())
+
+	status, body := postSearch(t, h.GetFieldOptions, map[string]interface{}{
+		"court": []string{"Superior Court"},
+	})
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, true, body["filtered"])
+
+	status, body = postSearch(t, h.GetFieldOptions, map[string]interface{}{})
+	require.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, false, body["filtered"])
+}
+
+// TODO: Reimplement search handler tests with proper service interfaces
+
+func TestGetCaseStats_CountsDocumentsInCase(t *testing.T) {
+	searchSvc := newMockSearchService()
+	for id, doc := range map[string]*models.Document{
+		"motion-1": {DocType: "Motion", Metadata: &models.DocumentMetadata{Case: &models.CaseInfo{CaseNumber: "CR/2024/001"}}},
+		"motion-2": {DocType: "Motion", Metadata: &models.DocumentMetadata{CaseNumber: "CR/2024/001"}},
+		"order-1":  {DocType: "Order", Metadata: &models.DocumentMetadata{Case: &models.CaseInfo{CaseNumber: "CR/2024/001"}}},
+		"other":    {DocType: "Motion", Metadata: &models.DocumentMetadata{Case: &models.CaseInfo{CaseNumber: "CR/2024/002"}}},
+	} {
+		searchSvc.documents[id] = doc
+	}
+
+	app := fiber.New()
+	app.Get("/cases/:case_number/stats", NewSearchHandler(testutil.TestConfig(), searchSvc).GetCaseStats)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/cases/CR%2F2024%2F001/stats", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data models.CaseStats `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "CR/2024/001", body.Data.CaseNumber)
+	assert.Equal(t, int64(3), body.Data.TotalDocuments)
+	assert.ElementsMatch(t, []*models.TypeCount{{Type: "Motion", Count: 2}, {Type: "Order", Count: 1}}, body.Data.TypeCounts)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/cases/CR%2F2099%2F404/stats", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+// deleteDocuments sends a JSON body to DELETE /documents and returns the response status and body
+func deleteDocuments(t *testing.T, h *SearchHandler, payload map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	app := fiber.New()
+	app.Delete("/documents", h.DeleteDocuments)
+
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	req := httptest.NewRequest("DELETE", "/documents", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+func TestDeleteDocuments_ByIDs(t *testing.T) {
+	searchSvc := newMockSearchService()
+	searchSvc.documents["doc-1"] = &models.Document{ID: "doc-1"}
+	searchSvc.documents["doc-2"] = &models.Document{ID: "doc-2"}
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+
+	status, body := deleteDocuments(t, h, map[string]interface{}{"ids": []string{"doc-1", "missing"}})
+	require.Equal(t, fiber.StatusOK, status)
+
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, float64(1), data["deleted"])
+	assert.Equal(t, float64(1), data["failed"])
+	failed := data["failed_docs"].([]interface{})
+	require.Len(t, failed, 1)
+	assert.Equal(t, "missing", failed[0].(map[string]interface{})["id"])
+	assert.NotContains(t, searchSvc.documents, "doc-1")
+	assert.Contains(t, searchSvc.documents, "doc-2")
+}
+
+func TestDeleteDocuments_ByQuery(t *testing.T) {
+	var received *models.SearchRequest
+	searchSvc := newMockSearchService()
+	searchSvc.documents["motion-1"] = &models.Document{ID: "motion-1"}
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		received = req
+		result := models.NewSearchResult()
+		result.Documents = []*models.SearchDocument{{ID: "motion-1"}}
+		result.TotalHits = 1
+		return result, nil
+	}
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+
+	status, body := deleteDocuments(t, h, map[string]interface{}{"query": map[string]interface{}{"doc_type": "motion"}})
+	require.Equal(t, fiber.StatusOK, status)
+	require.NotNil(t, received)
+	assert.Equal(t, "motion", received.DocType)
+	assert.Equal(t, float64(1), body["data"].(map[string]interface{})["deleted"])
+	assert.NotContains(t, searchSvc.documents, "motion-1")
+
+	// A query matching more than the cap deletes nothing
+	searchSvc.documents["motion-2"] = &models.Document{ID: "motion-2"}
+	searchSvc.searchFn = func(req *models.SearchRequest) (*models.SearchResult, error) {
+		result := models.NewSearchResult()
+		result.Documents = []*models.SearchDocument{{ID: "motion-2"}}
+		result.TotalHits = internalModels.MaxBulkDeleteSize + 1
+		return result, nil
+	}
+	status, _ = deleteDocuments(t, h, map[string]interface{}{"query": map[string]interface{}{"doc_type": "motion"}})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Contains(t, searchSvc.documents, "motion-2")
+}
+
+func TestDeleteDocuments_Validation(t *testing.T) {
+	h := NewSearchHandler(testutil.TestConfig(), newMockSearchService())
+
+	tooMany := make([]string, internalModels.MaxBulkDeleteSize+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("doc-%d", i)
+	}
+
+	for name, payload := range map[string]map[string]interface{}{
+		"empty":     {},
+		"empty ids": {"ids": []string{}},
+		"too many":  {"ids": tooMany},
+		"blank id":  {"ids": []string{"doc-1", ""}},
+		"both":      {"ids": []string{"doc-1"}, "query": map[string]interface{}{"doc_type": "motion"}},
+	} {
+		status, _ := deleteDocuments(t, h, payload)
+		assert.Equal(t, fiber.StatusBadRequest, status, name)
+	}
+}
+
+// flushRecorder records the CDN cache flushes of a SpacesService
+type flushRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (f *flushRecorder) FlushCDNCache(ctx context.Context, files []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, files)
+	return nil
+}
+
+func (f *flushRecorder) flushed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var files []string
+	for _, batch := range f.batches {
+		files = append(files, batch...)
+	}
+	return files
+}
+
+// newFlushingStorage returns a SpacesService that flushes its CDN cache
+// through a recorder
+func newFlushingStorage(t *testing.T) (*storage.SpacesService, *flushRecorder) {
+	t.Helper()
+	spaces, err := storage.NewSpacesService(testutil.TestConfig())
+	require.NoError(t, err)
+	recorder := &flushRecorder{}
+	spaces.SetCDNFlusher(recorder, storage.CDNFlushPolicy{MaxConcurrent: 1})
+	return spaces, recorder
+}
+
+func TestDeleteDocuments_InvalidatesCDN(t *testing.T) {
+	searchSvc := newMockSearchService()
+	var ids []string
+	for i := 0; i < 60; i++ {
+		id := fmt.Sprintf("doc-%d", i)
+		searchSvc.documents[id] = &models.Document{ID: id, FilePath: fmt.Sprintf("documents/motion-%d.pdf", i)}
+		ids = append(ids, id)
+	}
+	spaces, recorder := newFlushingStorage(t)
+	h := NewSearchHandler(testutil.TestConfig(), searchSvc)
+	h.storage = spaces
+
+	status, _ := deleteDocuments(t, h, map[string]interface{}{"ids": ids})
+	require.Equal(t, fiber.StatusOK, status)
+
+	require.Len(t, recorder.batches, 2)
+	assert.ElementsMatch(t, []int{50, 10}, []int{len(recorder.batches[0]), len(recorder.batches[1])})
+	assert.Len(t, recorder.flushed(), 60)
+	assert.Contains(t, recorder.flushed(), "documents/motion-59.pdf")
+}
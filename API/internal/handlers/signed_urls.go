@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"motion-index-fiber/internal/models"
+)
+
+// maxSignedURLExpiration caps how long a signed URL stays valid
+const maxSignedURLExpiration = 24 * time.Hour
+
+// maxSignedURLPaths caps the paths one signed URL request may ask for
+const maxSignedURLPaths = 100
+
+// SignedURLsRequest is the body of POST /api/v1/files/signed-urls
+type SignedURLsRequest struct {
+	Paths   []string `json:"paths"`             // Storage paths, with or without the documents/ prefix
+	Expires string   `json:"expires,omitempty"` // Duration such as "2h"; defaults to 1h, capped at 24h
+}
+
+// SignedURLResult is the signed URL of one requested path, or why there is none
+type SignedURLResult struct {
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// SignedURLsResponse maps each requested path to its result
+type SignedURLsResponse struct {
+	URLs      map[string]SignedURLResult `json:"urls"`
+	Generated int                        `json:"generated"`
+	Failed    int                        `json:"failed"`
+	ExpiresAt time.Time                  `json:"expires_at"`
+}
+
+// GenerateSignedURLs handles POST /api/v1/files/signed-urls - Sign URLs for
+// many stored files at once. Paths that are invalid or not in storage get an
+// error entry without failing the others.
+func (h *StorageHandler) GenerateSignedURLs(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+	defer cancel()
+
+	var req SignedURLsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_request",
+			"Invalid request body",
+			map[string]interface{}{"error": err.Error()},
+		))
+	}
+	if len(req.Paths) == 0 || len(req.Paths) > maxSignedURLPaths {
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_request",
+			fmt.Sprintf("Between 1 and %d paths are required", maxSignedURLPaths),
+			map[string]interface{}{"paths": len(req.Paths)},
+		))
+	}
+
+	expiration := time.Hour
+	if req.Expires != "" {
+		parsed, err := time.ParseDuration(req.Expires)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+				"invalid_request",
+				"expires must be a positive duration such as 2h",
+				map[string]interface{}{"expires": req.Expires},
+			))
+		}
+		expiration = min(parsed, maxSignedURLExpiration)
+	}
+
+	response := SignedURLsResponse{
+		URLs:      make(map[string]SignedURLResult, len(req.Paths)),
+		ExpiresAt: time.Now().Add(expiration).UTC(),
+	}
+	for _, path := range req.Paths {
+		if _, seen := response.URLs[path]; seen {
+			continue
+		}
+		result := h.signedURL(ctx, path, expiration)
+		response.URLs[path] = result
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Generated++
+		}
+	}
+
+	return c.JSON(models.NewSuccessResponse(response, "Signed URLs generated"))
+}
+
+// signedURL signs one requested path after checking it is valid and stored
+func (h *StorageHandler) signedURL(ctx context.Context, path string, expiration time.Duration) SignedURLResult {
+	if err := h.validateDocumentPath(path); err != nil {
+		return SignedURLResult{Error: "invalid path: " + err.Error()}
+	}
+	documentPath := embedPath(path)
+
+	exists, err := h.storage.Exists(ctx, documentPath)
+	if err != nil {
+		return SignedURLResult{Error: "failed to check document existence: " + err.Error()}
+	}
+	if !exists {
+		return SignedURLResult{Error: "document not found"}
+	}
+
+	signed, err := h.storage.GetSignedURL(documentPath, expiration)
+	if err != nil {
+		return SignedURLResult{Error: "failed to generate signed URL: " + err.Error()}
+	}
+	return SignedURLResult{URL: signed}
+}
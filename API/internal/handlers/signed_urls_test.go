@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/testutil"
+)
+
+// postSignedURLs posts payload to the signed URLs route
+func postSignedURLs(t *testing.T, app *fiber.App, payload interface{}) (int, SignedURLsResponse) {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/api/v1/files/signed-urls", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded struct {
+		Data SignedURLsResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded.Data
+}
+
+func TestGenerateSignedURLs(t *testing.T) {
+	storageSvc := newMockStorageService()
+	for _, path := range []string{"documents/2024/motion.pdf", "documents/2024/order.pdf"} {
+		_, err := storageSvc.Upload(context.Background(), path, strings.NewReader("%PDF-1.4"), nil)
+		require.NoError(t, err)
+	}
+	app := fiber.New()
+	app.Post("/api/v1/files/signed-urls", NewStorageHandler(testutil.TestConfig(), storageSvc).GenerateSignedURLs)
+
+	start := time.Now()
+	status, data := postSignedURLs(t, app, SignedURLsRequest{
+		Paths:   []string{"documents/2024/motion.pdf", "2024/order.pdf", "documents/2024/missing.pdf", "../secrets.pdf"},
+		Expires: "72h",
+	})
+	require.Equal(t, fiber.StatusOK, status)
+
+	assert.Equal(t, 2, data.Generated)
+	assert.Equal(t, 2, data.Failed)
+	assert.Equal(t, "https://storage.example.com/documents/2024/motion.pdf?signature=test", data.URLs["documents/2024/motion.pdf"].URL)
+	assert.Equal(t, "https://storage.example.com/documents/2024/order.pdf?signature=test", data.URLs["2024/order.pdf"].URL)
+	assert.Equal(t, "document not found", data.URLs["documents/2024/missing.pdf"].Error)
+	assert.Empty(t, data.URLs["documents/2024/missing.pdf"].URL)
+	assert.Contains(t, data.URLs["../secrets.pdf"].Error, "invalid path")
+
+	// Expiry is capped at 24 hours
+	assert.WithinDuration(t, start.Add(maxSignedURLExpiration), data.ExpiresAt, time.Minute)
+
+	for name, payload := range map[string]interface{}{
+		"no paths":        SignedURLsRequest{},
+		"too many paths":  SignedURLsRequest{Paths: make([]string, maxSignedURLPaths+1)},
+		"invalid expires": SignedURLsRequest{Paths: []string{"documents/2024/motion.pdf"}, Expires: "soon"},
+		"negative expiry": SignedURLsRequest{Paths: []string{"documents/2024/motion.pdf"}, Expires: "-1h"},
+	} {
+		status, _ := postSignedURLs(t, app, payload)
+		assert.Equal(t, fiber.StatusBadRequest, status, name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 	"motion-index-fiber/internal/config"
@@ -19,6 +22,17 @@ import (
 	"motion-index-fiber/pkg/storage"
 )
 
+const (
+	// maxListPagesPerRequest bounds the storage pages one document listing
+	// reads when filters drop most objects; the response then holds fewer
+	// documents than the limit but still has a next cursor
+	maxListPagesPerRequest = 10
+
+	// countPageSize is the page size used when counting documents, the
+	// largest an S3 listing returns
+	countPageSize = 1000
+)
+
 type StorageHandler struct {
 	cfg     *config.Config
 	storage storage.Service
@@ -57,24 +71,43 @@ func (h *StorageHandler) ListDocuments(c *fiber.Ctx) error {
 		maxSize, _ = strconv.ParseInt(maxSizeStr, 10, 64)
 	}
 
-	// List documents from storage
-	objects, err := h.storage.List(ctx, prefix)
+	// The cursor carries the storage continuation token of the next page
+	continuationToken, err := decodeListCursor(cursor)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
-			"storage_error",
-			"Failed to list documents",
+		return c.Status(fiber.StatusBadRequest).JSON(models.NewErrorResponse(
+			"invalid_cursor",
+			"Invalid cursor",
 			map[string]interface{}{"error": err.Error()},
 		))
 	}
 
-	// Apply filters and pagination
-	filtered := h.filterDocuments(objects, fileType, minSize, maxSize)
-	paginatedResult := h.paginateDocuments(filtered, cursor, limit)
+	// Read storage pages until the page of documents is full. Each request
+	// asks only for as many keys as documents are still wanted, so no listed
+	// object is skipped when the next cursor picks up after the last page.
+	var objects []*storage.StorageObject
+	for pages := 0; pages < maxListPagesPerRequest; pages++ {
+		page, err := h.storage.ListPaged(ctx, prefix, continuationToken, limit-len(objects))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
+				"storage_error",
+				"Failed to list documents",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
+
+		objects = append(objects, h.filterDocuments(page.Objects, fileType, minSize, maxSize)...)
+		continuationToken = page.NextContinuationToken
+		if continuationToken == "" || len(objects) >= limit {
+			break
+		}
+	}
 
-	return c.JSON(models.NewSuccessResponse(paginatedResult, "Documents listed successfully"))
+	return c.JSON(models.NewSuccessResponse(h.paginateDocuments(objects, continuationToken), "Documents listed successfully"))
 }
 
-// GetDocumentsCount handles GET /api/storage/documents/count - Get total document count
+// GetDocumentsCount handles GET /api/storage/documents/count - Get total document count.
+// Storage listings have no count, so this still reads every page under the
+// prefix; only the memory use is bounded, not the time or the listing calls.
 func (h *StorageHandler) GetDocumentsCount(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
 	defer cancel()
@@ -92,25 +125,34 @@ func (h *StorageHandler) GetDocumentsCount(c *fiber.Ctx) error {
 		maxSize, _ = strconv.ParseInt(maxSizeStr, 10, 64)
 	}
 
-	// List and filter documents
-	objects, err := h.storage.List(ctx, prefix)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
-			"storage_error",
-			"Failed to count documents",
-			map[string]interface{}{"error": err.Error()},
-		))
-	}
+	// Count page by page so only one page of objects is held at a time
+	total := 0
+	continuationToken := ""
+	for {
+		page, err := h.storage.ListPaged(ctx, prefix, continuationToken, countPageSize)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.NewErrorResponse(
+				"storage_error",
+				"Failed to count documents",
+				map[string]interface{}{"error": err.Error()},
+			))
+		}
 
-	filtered := h.filterDocuments(objects, fileType, minSize, maxSize)
+		total += len(h.filterDocuments(page.Objects, fileType, minSize, maxSize))
+		if page.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = page.NextContinuationToken
+	}
 
 	response := map[string]interface{}{
-		"total_count":    len(filtered),
+		"total_count":    total,
 		"prefix":         prefix,
 		"applied_filters": map[string]interface{}{
-			"file_type": fileType,
-			"min_size":  minSize,
-			"max_size":  maxSize,
+			"file_type":      fileType,
+			"min_size":       minSize,
+			"max_size":       maxSize,
+			"min_size_floor": h.cfg.Storage.MinFileSize,
 		},
 	}
 
@@ -153,8 +195,8 @@ func (h *StorageHandler) ServeDocument(c *fiber.Ctx) error {
 		documentPath = "documents/" + documentPath
 	}
 
-	// Check if document exists
-	exists, err := h.storage.Exists(ctx, documentPath)
+	// Check if document exists, reading the name it was uploaded under too
+	storedName, exists, err := h.lookupDocument(ctx, documentPath)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to check document existence",
@@ -187,15 +229,44 @@ func (h *StorageHandler) ServeDocument(c *fiber.Ctx) error {
 	}
 
 	// Limit maximum expiration to 24 hours for security
-	if expiration > 24*time.Hour {
-		expiration = 24 * time.Hour
+	if expiration > maxSignedURLExpiration {
+		expiration = maxSignedURLExpiration
 	}
 
+	// Get file extension for content type determination
+	ext := strings.ToLower(filepath.Ext(documentPath))
+	contentType := getContentTypeFromExtension(ext)
+
+	dispositionType, err := requestedDisposition(c, ext)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Invalid disposition",
+			"details": err.Error(),
+		})
+	}
+	fileName := c.Query("filename")
+	if fileName != "" {
+		if err := validateDownloadFileName(fileName); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   "Invalid filename",
+				"details": err.Error(),
+			})
+		}
+	} else {
+		fileName = storedName
+	}
+	disposition := storage.ContentDisposition(dispositionType, fileName)
+
 	var documentURL string
 	
 	if useSignedURL {
-		// Generate signed URL for secure access
-		documentURL, err = h.storage.GetSignedURL(documentPath, expiration)
+		// Generate signed URL for secure access; backends that can have the
+		// storage response carry the disposition, so redirects keep it too
+		if signer, ok := h.storage.(storage.DispositionSigner); ok {
+			documentURL, err = signer.GetSignedURLWithDisposition(documentPath, expiration, disposition)
+		} else {
+			documentURL, err = h.storage.GetSignedURL(documentPath, expiration)
+		}
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to generate signed URL",
@@ -217,28 +288,97 @@ func (h *StorageHandler) ServeDocument(c *fiber.Ctx) error {
 		}
 	}
 
-	// Get file extension for content type determination
-	ext := strings.ToLower(filepath.Ext(documentPath))
-	contentType := getContentTypeFromExtension(ext)
-
 	// Determine if we should proxy the file content vs redirect
 	shouldProxy := h.shouldProxyFile(c, ext)
 
 	if shouldProxy {
 		// Proxy the file content for embedding/display
-		return h.proxyFileContent(c, documentURL, contentType, documentPath)
+		return h.proxyFileContent(c, documentURL, contentType, documentPath, disposition)
 	}
 
-	// For download requests or when redirect is preferred
-	if c.Query("download", "false") == "true" {
-		c.Set("Content-Type", contentType)
-		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(documentPath)))
-	}
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", disposition)
 
 	// Redirect to CDN URL
 	return c.Redirect(documentURL, fiber.StatusFound)
 }
 
+// inlineExtensions are the file types browsers display themselves, which are
+// served inline unless the request asks otherwise
+var inlineExtensions = map[string]bool{
+	".pdf": true, ".txt": true,
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true,
+}
+
+// requestedDisposition resolves the Content-Disposition type of a served
+// file: the disposition query parameter, then download=true for attachment,
+// then inline for types browsers display and attachment for the rest
+func requestedDisposition(c *fiber.Ctx, ext string) (string, error) {
+	switch disposition := strings.ToLower(c.Query("disposition")); disposition {
+	case "inline", "attachment":
+		return disposition, nil
+	case "":
+	default:
+		return "", fmt.Errorf("disposition must be inline or attachment, got %q", disposition)
+	}
+
+	if c.Query("download", "false") == "true" || !inlineExtensions[ext] {
+		return "attachment", nil
+	}
+	return "inline", nil
+}
+
+// maxDownloadFileNameLength caps a filename override in bytes, the limit of
+// most filesystems
+const maxDownloadFileNameLength = 255
+
+// validateDownloadFileName checks a filename override is a single, printable
+// file name a browser can save without touching other directories
+func validateDownloadFileName(name string) error {
+	if strings.TrimSpace(name) == "" || name == "." || name == ".." {
+		return fmt.Errorf("filename cannot be empty")
+	}
+	if len(name) > maxDownloadFileNameLength {
+		return fmt.Errorf("filename too long (max %d bytes, got %d)", maxDownloadFileNameLength, len(name))
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("filename must be valid UTF-8")
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' {
+			return fmt.Errorf("filename cannot contain path separators")
+		}
+		if unicode.IsControl(r) {
+			return fmt.Errorf("filename cannot contain control characters")
+		}
+	}
+	return nil
+}
+
+// lookupDocument reports whether a document exists and the name it should be
+// served under. Storage keys use a sanitized form of the upload name, so when
+// the backend records object metadata the original is read from it, in the
+// same request that checks the document exists.
+func (h *StorageHandler) lookupDocument(ctx context.Context, documentPath string) (string, bool, error) {
+	provider, ok := h.storage.(storage.MetadataProvider)
+	if !ok {
+		exists, err := h.storage.Exists(ctx, documentPath)
+		return filepath.Base(documentPath), exists, err
+	}
+
+	metadata, err := provider.GetFileMetadata(ctx, documentPath)
+	var storageErr *storage.StorageError
+	switch {
+	case errors.As(err, &storageErr) && storageErr.Type == "not_found":
+		return "", false, nil
+	case err != nil:
+		return "", false, err
+	case metadata.FileName != "":
+		return metadata.FileName, true, nil
+	}
+	return filepath.Base(documentPath), true, nil
+}
+
 // filterDocuments applies file type and size filters to the document list
 func (h *StorageHandler) filterDocuments(objects []*storage.StorageObject, fileType string, minSize, maxSize int64) []*storage.StorageObject {
 	var filtered []*storage.StorageObject
@@ -249,8 +389,8 @@ func (h *StorageHandler) filterDocuments(objects []*storage.StorageObject, fileT
 			continue
 		}
 
-		// Skip very small files (likely empty or corrupt)
-		if obj.Size < 100 {
+		// Skip files under the configured floor (likely empty or corrupt)
+		if obj.Size < h.cfg.Storage.MinFileSize {
 			continue
 		}
 
@@ -271,7 +411,7 @@ func (h *StorageHandler) filterDocuments(objects []*storage.StorageObject, fileT
 			}
 		}
 
-		// Apply size filters
+		// Apply size filters; min_size can only raise the floor above
 		if obj.Size < minSize {
 			continue
 		}
@@ -285,39 +425,15 @@ func (h *StorageHandler) filterDocuments(objects []*storage.StorageObject, fileT
 	return filtered
 }
 
-// paginateDocuments implements cursor-based pagination
-func (h *StorageHandler) paginateDocuments(objects []*storage.StorageObject, cursor string, limit int) map[string]interface{} {
-	startIndex := 0
-
-	// Decode cursor if provided
-	if cursor != "" {
-		if decoded, err := base64.URLEncoding.DecodeString(cursor); err == nil {
-			var cursorData map[string]interface{}
-			if json.Unmarshal(decoded, &cursorData) == nil {
-				if idx, ok := cursorData["index"].(float64); ok {
-					startIndex = int(idx)
-				}
-			}
-		}
-	}
-
-	// Apply pagination
-	endIndex := startIndex + limit
-	if endIndex > len(objects) {
-		endIndex = len(objects)
-	}
-
-	var paginatedObjects []*storage.StorageObject
-	if startIndex < len(objects) {
-		paginatedObjects = objects[startIndex:endIndex]
-	}
-
-	// Generate next cursor
+// paginateDocuments builds the response for one page of listed documents,
+// with a cursor for the next page when storage has more objects. There is no
+// total: only the count endpoint reads the whole listing to find one.
+func (h *StorageHandler) paginateDocuments(objects []*storage.StorageObject, continuationToken string) map[string]interface{} {
 	var nextCursor string
-	hasMore := endIndex < len(objects)
+	hasMore := continuationToken != ""
 	if hasMore {
 		cursorData := map[string]interface{}{
-			"index": endIndex,
+			"token": continuationToken,
 		}
 		if cursorBytes, err := json.Marshal(cursorData); err == nil {
 			nextCursor = base64.URLEncoding.EncodeToString(cursorBytes)
@@ -326,7 +442,7 @@ func (h *StorageHandler) paginateDocuments(objects []*storage.StorageObject, cur
 
 	// Convert storage objects to response format
 	var documents []map[string]interface{}
-	for _, obj := range paginatedObjects {
+	for _, obj := range objects {
 		documents = append(documents, map[string]interface{}{
 			"path":          obj.Path,
 			"size":          obj.Size,
@@ -337,12 +453,31 @@ func (h *StorageHandler) paginateDocuments(objects []*storage.StorageObject, cur
 	}
 
 	return map[string]interface{}{
-		"documents":        documents,
-		"next_cursor":      nextCursor,
-		"has_more":         hasMore,
-		"total_returned":   len(documents),
-		"total_estimated":  len(objects),
+		"documents":      documents,
+		"next_cursor":    nextCursor,
+		"has_more":       hasMore,
+		"total_returned": len(documents),
+	}
+}
+
+// decodeListCursor returns the storage continuation token in a list cursor,
+// or "" for no cursor
+func decodeListCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	decoded, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("cursor is not valid base64")
+	}
+	var cursorData struct {
+		Token string `json:"token"`
 	}
+	if err := json.Unmarshal(decoded, &cursorData); err != nil || cursorData.Token == "" {
+		return "", fmt.Errorf("cursor has no continuation token")
+	}
+	return cursorData.Token, nil
 }
 
 // validateDocumentPath validates and sanitizes the document path
@@ -384,14 +519,14 @@ func (h *StorageHandler) validateDocumentPath(path string) error {
 	ext := strings.ToLower(filepath.Ext(path))
 	if ext != "" {
 		validExtensions := map[string]bool{
-			".pdf": true, ".docx": true, ".doc": true, ".txt": true, ".rtf": true,
+			".pdf": true, ".docx": true, ".doc": true, ".txt": true, ".rtf": true, ".eml": true, ".msg": true,
 			".json": true, ".xml": true, ".html": true, ".htm": true,
 			".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true,
 			".tiff": true, ".tif": true, ".webp": true,
 		}
 
 		if !validExtensions[ext] {
-			return fmt.Errorf("unsupported file extension: %s (allowed: pdf, docx, doc, txt, rtf, json, xml, html, jpg, jpeg, png, gif, bmp, tiff, webp)", ext)
+			return fmt.Errorf("unsupported file extension: %s (allowed: pdf, docx, doc, txt, rtf, eml, msg, json, xml, html, jpg, jpeg, png, gif, bmp, tiff, webp)", ext)
 		}
 	}
 
@@ -508,6 +643,11 @@ func (h *StorageHandler) shouldProxyFile(c *fiber.Ctx, ext string) bool {
 		return true
 	}
 
+	// The frame-ancestors policy of an embed token would be lost on a redirect
+	if c.Query("embed_token") != "" {
+		return true
+	}
+
 	// Always proxy for browser embedding requests
 	secFetchDest := c.Get("Sec-Fetch-Dest")
 	secFetchMode := c.Get("Sec-Fetch-Mode")
@@ -555,7 +695,7 @@ func (h *StorageHandler) shouldProxyFile(c *fiber.Ctx, ext string) bool {
 }
 
 // proxyFileContent fetches the file from storage and streams it to the client
-func (h *StorageHandler) proxyFileContent(c *fiber.Ctx, fileURL, contentType, documentPath string) error {
+func (h *StorageHandler) proxyFileContent(c *fiber.Ctx, fileURL, contentType, documentPath, disposition string) error {
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -586,17 +726,8 @@ func (h *StorageHandler) proxyFileContent(c *fiber.Ctx, fileURL, contentType, do
 	c.Set("Content-Length", resp.Header.Get("Content-Length"))
 	c.Set("Cache-Control", "public, max-age=3600") // Cache for 1 hour
 	c.Set("ETag", resp.Header.Get("ETag"))
-	
-	// Remove all embedding restrictions - TEMPORARY for development
-	// TODO: Add proper security controls for production
-	
-	// Allow framing from any origin
-	c.Response().Header.Del("X-Frame-Options")
-	
-	// Remove all restrictive security headers for embedded content
-	c.Response().Header.Del("Cross-Origin-Embedder-Policy")
-	c.Response().Header.Del("Cross-Origin-Resource-Policy") 
-	c.Response().Header.Del("Cross-Origin-Opener-Policy")
+
+	// Framing is allowed or refused by ServeFile, from the request's embed token
 	
 	// Handle range requests for partial content (useful for large PDFs)
 	if rangeHeader := c.Get("Range"); rangeHeader != "" {
@@ -605,10 +736,7 @@ func (h *StorageHandler) proxyFileContent(c *fiber.Ctx, fileURL, contentType, do
 		// For now, we'll serve the full content
 	}
 
-	// Set filename for download (always inline for now since we removed embedding checks)
-	// TODO: Re-add embedding detection when security is re-enabled
-	filename := filepath.Base(documentPath)
-	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
+	c.Set("Content-Disposition", disposition)
 
 	// Stream the content
 	_, err = io.Copy(c.Response().BodyWriter(), resp.Body)
@@ -635,6 +763,10 @@ func getContentTypeFromExtension(ext string) string {
 		return "text/plain"
 	case ".rtf":
 		return "application/rtf"
+	case ".eml":
+		return "message/rfc822"
+	case ".msg":
+		return "application/vnd.ms-outlook"
 	case ".json":
 		return "application/json"
 	case ".xml":
@@ -1,9 +1,25 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/internal/testutil"
+	"motion-index-fiber/pkg/storage"
 )
 
 func TestStorageHandlerExists(t *testing.T) {
@@ -11,4 +27,316 @@ func TestStorageHandlerExists(t *testing.T) {
 	assert.True(t, true)
 }
 
-// TODO: Reimplement storage handler tests with proper service interfaces
\ No newline at end of file
+// TODO: Reimplement storage handler tests with proper service interfaces
+
+func TestServeDocument_OriginalFileNameRoundTrip(t *testing.T) {
+	const originalName = "Moción de prueba ñ.pdf"
+	content := []byte("%PDF-1.4 test document")
+
+	storageSvc := newMockStorageService()
+
+	// Serve stored objects over HTTP so the proxy path can fetch them
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := storageSvc.Download(r.Context(), strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer reader.Close()
+		io.Copy(w, reader)
+	}))
+	defer server.Close()
+	storageSvc.baseURL = server.URL
+
+	cfg := testutil.TestConfig()
+	app := fiber.New()
+	app.Post("/upload", NewProcessingHandler(cfg, nil, storageSvc, newMockSearchService()).UploadDocument)
+	app.Get("/documents/*", NewStorageHandler(cfg, storageSvc).ServeDocument)
+
+	// Upload
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", originalName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteField("classify_doc", "false"))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var uploaded struct {
+		Data struct {
+			DocumentID    string `json:"document_id"`
+			StorageResult struct {
+				Path string `json:"path"`
+			} `json:"storage_result"`
+			Metadata struct {
+				OriginalFileName string `json:"original_file_name"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&uploaded))
+	resp.Body.Close()
+
+	// The storage key and ID use a safe name; the original is kept separately
+	storagePath := uploaded.Data.StorageResult.Path
+	assert.True(t, strings.HasSuffix(storagePath, "/Moci_n_de_prueba.pdf"), storagePath)
+	assert.NotContains(t, uploaded.Data.DocumentID, " ")
+	assert.Equal(t, originalName, uploaded.Data.Metadata.OriginalFileName)
+
+	// Download through the proxy and check the original name comes back
+	resp, err = app.Test(httptest.NewRequest("GET", "/documents/"+url.PathEscape(storagePath)+"?proxy=true", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	downloaded, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, content, downloaded)
+
+	disposition, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	require.NoError(t, err)
+	assert.Equal(t, "inline", disposition)
+	assert.Equal(t, originalName, params["filename"])
+
+	// Redirected downloads carry the same name as an attachment
+	resp, err = app.Test(httptest.NewRequest("GET", "/documents/"+url.PathEscape(storagePath)+"?download=true", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusFound, resp.StatusCode)
+
+	disposition, params, err = mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", disposition)
+	assert.Equal(t, originalName, params["filename"])
+}
+
+func TestServeDocument_Disposition(t *testing.T) {
+	storageSvc := newMockStorageService()
+	for path, content := range map[string]string{
+		"documents/motion.pdf": "%PDF-1.4 motion",
+		"documents/brief.docx": "PK brief",
+	} {
+		_, err := storageSvc.Upload(context.Background(), path, strings.NewReader(content), nil)
+		require.NoError(t, err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := storageSvc.Download(r.Context(), strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer reader.Close()
+		io.Copy(w, reader)
+	}))
+	defer server.Close()
+	storageSvc.baseURL = server.URL
+
+	app := fiber.New()
+	app.Get("/documents/*", NewStorageHandler(testutil.TestConfig(), storageSvc).ServeDocument)
+
+	tests := []struct {
+		name        string
+		target      string
+		disposition string
+		filename    string
+	}{
+		{"viewable types default to inline", "/documents/motion.pdf", "inline", "motion.pdf"},
+		{"other types default to attachment", "/documents/brief.docx", "attachment", "brief.docx"},
+		{"download keeps attachment", "/documents/motion.pdf?download=true", "attachment", "motion.pdf"},
+		{"attachment forced", "/documents/motion.pdf?disposition=attachment", "attachment", "motion.pdf"},
+		{"inline forced", "/documents/brief.docx?disposition=inline", "inline", "brief.docx"},
+		{"filename override", "/documents/motion.pdf?disposition=attachment&filename=" + url.QueryEscape("People v. Peña – Motion.pdf"), "attachment", "People v. Peña – Motion.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []string{"proxy=true", "proxy=false"} {
+				target := tt.target + "?" + mode
+				if strings.Contains(tt.target, "?") {
+					target = tt.target + "&" + mode
+				}
+				resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+				require.NoError(t, err)
+				resp.Body.Close()
+
+				header := resp.Header.Get("Content-Disposition")
+				disposition, params, err := mime.ParseMediaType(header)
+				require.NoError(t, err, mode)
+				assert.Equal(t, tt.disposition, disposition, mode)
+				assert.Equal(t, tt.filename, params["filename"], mode)
+
+				if mode == "proxy=false" {
+					// The signed URL has the storage response set the header too
+					require.Equal(t, fiber.StatusFound, resp.StatusCode)
+					location, err := url.Parse(resp.Header.Get("Location"))
+					require.NoError(t, err)
+					assert.Equal(t, header, location.Query().Get("response-content-disposition"))
+				} else {
+					assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+				}
+			}
+		})
+	}
+
+	for _, target := range []string{
+		"/documents/motion.pdf?disposition=download",
+		"/documents/motion.pdf?filename=" + url.QueryEscape("../motion.pdf"),
+		"/documents/motion.pdf?filename=" + url.QueryEscape("motion\r\n.pdf"),
+		"/documents/motion.pdf?filename=" + strings.Repeat("a", 256),
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil), -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, target)
+	}
+}
+
+func TestServeDocument_LooksUpObjectOnce(t *testing.T) {
+	storageSvc := newMockStorageService()
+	_, err := storageSvc.Upload(context.Background(), "documents/motion.pdf", strings.NewReader("%PDF-1.4 motion"), &storage.UploadMetadata{FileName: "Motion – Peña.pdf"})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Get("/documents/*", NewStorageHandler(testutil.TestConfig(), storageSvc).ServeDocument)
+
+	// The metadata read that finds the original name also checks existence
+	resp, err := app.Test(httptest.NewRequest("GET", "/documents/motion.pdf?proxy=false", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusFound, resp.StatusCode)
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	require.NoError(t, err)
+	assert.Equal(t, "Motion – Peña.pdf", params["filename"])
+	assert.Equal(t, 1, storageSvc.metadataCalls)
+	assert.Zero(t, storageSvc.existsCalls)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/documents/missing.pdf", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, 2, storageSvc.metadataCalls)
+	assert.Zero(t, storageSvc.existsCalls)
+}
+
+func TestFilterDocuments_MinFileSizeFloor(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Storage.MinFileSize = 20
+	h := NewStorageHandler(cfg, newMockStorageService())
+
+	objects := []*storage.StorageObject{
+		{Path: "documents/below.txt", Size: 19},
+		{Path: "documents/at.txt", Size: 20},
+		{Path: "documents/above.txt", Size: 21},
+	}
+
+	paths := func(objects []*storage.StorageObject) []string {
+		var result []string
+		for _, obj := range objects {
+			result = append(result, obj.Path)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"documents/at.txt", "documents/above.txt"}, paths(h.filterDocuments(objects, "", 0, -1)))
+
+	// min_size raises the floor but cannot lower it
+	assert.Equal(t, []string{"documents/above.txt"}, paths(h.filterDocuments(objects, "", 21, -1)))
+	assert.Equal(t, []string{"documents/at.txt", "documents/above.txt"}, paths(h.filterDocuments(objects, "", 5, -1)))
+
+	// A zero floor keeps tiny files
+	cfg.Storage.MinFileSize = 0
+	assert.Len(t, h.filterDocuments(objects, "", 0, -1), 3)
+}
+
+func TestGetDocumentsCount_AppliesMinFileSizeFloor(t *testing.T) {
+	storageSvc := newMockStorageService()
+	storageSvc.objects["documents/tiny.txt"] = bytes.Repeat([]byte("a"), 99)
+	storageSvc.objects["documents/small.txt"] = bytes.Repeat([]byte("a"), 100)
+
+	app := fiber.New()
+	app.Get("/count", NewStorageHandler(testutil.TestConfig(), storageSvc).GetDocumentsCount)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/count", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data struct {
+			TotalCount     int `json:"total_count"`
+			AppliedFilters struct {
+				MinSizeFloor int64 `json:"min_size_floor"`
+			} `json:"applied_filters"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 1, body.Data.TotalCount)
+	assert.Equal(t, int64(100), body.Data.AppliedFilters.MinSizeFloor)
+}
+
+func TestListDocuments_CursorCarriesContinuationToken(t *testing.T) {
+	storageSvc := newMockStorageService()
+	for _, name := range []string{"a.pdf", "b.txt", "c.pdf", "d.pdf", "e.pdf"} {
+		storageSvc.objects["documents/"+name] = bytes.Repeat([]byte("a"), 200)
+	}
+	storageSvc.objects["documents/tiny.pdf"] = []byte("a")
+
+	app := fiber.New()
+	app.Get("/documents", NewStorageHandler(testutil.TestConfig(), storageSvc).ListDocuments)
+
+	type listResponse struct {
+		Data struct {
+			Documents []struct {
+				Path string `json:"path"`
+			} `json:"documents"`
+			NextCursor string `json:"next_cursor"`
+			HasMore    bool   `json:"has_more"`
+		} `json:"data"`
+	}
+	list := func(cursor string) listResponse {
+		resp, err := app.Test(httptest.NewRequest("GET", "/documents?file_type=pdf&limit=2&cursor="+cursor, nil), -1)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body listResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	var paths []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		body := list(cursor)
+		assert.LessOrEqual(t, len(body.Data.Documents), 2)
+		for _, doc := range body.Data.Documents {
+			paths = append(paths, doc.Path)
+		}
+		if !body.Data.HasMore {
+			break
+		}
+		require.NotEmpty(t, body.Data.NextCursor)
+		cursor = body.Data.NextCursor
+	}
+
+	assert.Equal(t, []string{"documents/a.pdf", "documents/c.pdf", "documents/d.pdf", "documents/e.pdf"}, paths)
+}
+
+func TestListDocuments_RejectsInvalidCursor(t *testing.T) {
+	app := fiber.New()
+	app.Get("/documents", NewStorageHandler(testutil.TestConfig(), newMockStorageService()).ListDocuments)
+
+	// An offset cursor from before cursors carried continuation tokens
+	cursor := base64.URLEncoding.EncodeToString([]byte(`{"index":50}`))
+	for _, c := range []string{cursor, "not-base64!"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/documents?cursor="+c, nil), -1)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, c)
+	}
+}
@@ -1,53 +1,71 @@
 package middleware
 
 import (
+	"errors"
+	"slices"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// UserClaims are the claims the JWT middleware reads from a bearer token
 type UserClaims struct {
 	UserID string `json:"sub"`
 	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func JWT(secret string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Get token from Authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return fiber.NewError(fiber.StatusUnauthorized, "Missing Authorization header")
-		}
-
-		// Extract token from "Bearer <token>"
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid Authorization header format")
-		}
+// DefaultRoleClaim is the JWT claim holding the user's role
+const DefaultRoleClaim = "role"
 
-		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+// JWT returns middleware that requires a valid bearer token signed with
+// secret. The token's claims are stored in the context for
+// GetUserFromContext; missing, malformed, expired or wrongly signed tokens
+// get 401.
+func JWT(secret string) fiber.Handler {
+	return JWTWithRoleClaim(secret, DefaultRoleClaim)
+}
 
+// JWTWithRoleClaim is JWT reading the user's role from roleClaim, a claim
+// name or a dotted path into nested claims such as "app_metadata.role"
+func JWTWithRoleClaim(secret, roleClaim string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString, err := extractTokenFromHeader(c.Get("Authorization"))
 		if err != nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+			return err
 		}
 
-		if !token.Valid {
-			return fiber.NewError(fiber.StatusUnauthorized, "Token is not valid")
+		claims, err := parseJWT(tokenString, secret)
+		if err != nil {
+			return err
 		}
-
-		// Extract claims
-		claims, ok := token.Claims.(*UserClaims)
-		if !ok {
-			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
+		if roleClaim != "" && roleClaim != DefaultRoleClaim {
+			claims.Role = roleFromToken(tokenString, roleClaim)
 		}
 
 		// Store user claims in context
 		c.Locals("user", claims)
+		c.Locals("user_id", claims.UserID)
+		c.Locals("role", claims.Role)
+		return c.Next()
+	}
+}
+
+// RequireRole returns middleware that lets a request through only when the
+// role the JWT middleware stored for the user is one of roles. Requests
+// that did not pass the JWT middleware get 401, users with another role 403.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if GetUserFromContext(c) == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+		}
+
+		role, _ := c.Locals("role").(string)
+		if !slices.Contains(roles, role) {
+			return fiber.NewError(fiber.StatusForbidden, "Requires the "+strings.Join(roles, " or ")+" role")
+		}
 		return c.Next()
 	}
 }
@@ -86,28 +104,65 @@ func extractTokenFromHeader(authHeader string) (string, error) {
 	return token, nil
 }
 
+// roleFromToken reads the string claim at a dotted path from a token whose
+// signature has already been verified, or "" when it is missing
+func roleFromToken(tokenString, path string) string {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return ""
+	}
+
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[part]
+	}
+	role, _ := value.(string)
+	return role
+}
+
 // validateJWT validates a JWT token with the given secret
 func validateJWT(tokenString, secret string) error {
+	_, err := parseJWT(tokenString, secret)
+	return err
+}
+
+// parseJWT verifies an HMAC-signed token with the given secret and returns
+// its claims. Tokens must carry an expiry.
+func parseJWT(tokenString, secret string) (*UserClaims, error) {
 	if tokenString == "" {
-		return fiber.NewError(fiber.StatusUnauthorized, "Empty token")
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Empty token")
+	}
+	if secret == "" {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Authentication is not configured")
 	}
 
-	// Parse and validate token
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Check signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
-		}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodHS384.Alg(),
+			jwt.SigningMethodHS512.Alg(),
+		}),
+		jwt.WithExpirationRequired(),
+	)
+	claims := &UserClaims{}
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
 	})
 
-	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token: "+err.Error())
-	}
-
-	if !token.Valid {
-		return fiber.NewError(fiber.StatusUnauthorized, "Token is not valid")
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Token has expired")
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Malformed token")
+	case err != nil:
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token: "+err.Error())
+	case !token.Valid:
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Token is not valid")
 	}
 
-	return nil
+	return claims, nil
 }
@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"io"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJWT(t *testing.T) {
@@ -369,3 +373,176 @@ func TestJWTMiddlewareConfiguration(t *testing.T) {
 		})
 	}
 }
+
+// signTestToken signs claims with secret using HS256
+func signTestToken(t *testing.T, secret string, claims UserClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestJWT_ValidatesBearerTokens(t *testing.T) {
+	const secret = "test-secret-key-for-jwt-testing"
+	claims := func(expiresAt time.Time) UserClaims {
+		return UserClaims{
+			UserID: "user-7",
+			Email:  "clerk@example.org",
+			Role:   "editor",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		authHeader  string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:       "valid token",
+			authHeader: "Bearer " + signTestToken(t, secret, claims(time.Now().Add(time.Hour))),
+			wantStatus: 200,
+		},
+		{
+			name:        "expired token",
+			authHeader:  "Bearer " + signTestToken(t, secret, claims(time.Now().Add(-time.Minute))),
+			wantStatus:  401,
+			wantMessage: "Token has expired",
+		},
+		{
+			name:        "token without expiry",
+			authHeader:  "Bearer " + signTestToken(t, secret, UserClaims{UserID: "user-7"}),
+			wantStatus:  401,
+			wantMessage: "Invalid token",
+		},
+		{
+			name:        "token signed with another secret",
+			authHeader:  "Bearer " + signTestToken(t, "another-secret", claims(time.Now().Add(time.Hour))),
+			wantStatus:  401,
+			wantMessage: "Invalid token",
+		},
+		{
+			name:        "malformed token",
+			authHeader:  "Bearer not-a.jwt",
+			wantStatus:  401,
+			wantMessage: "Malformed token",
+		},
+		{
+			name:        "missing token",
+			wantStatus:  401,
+			wantMessage: "Missing Authorization header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Delete("/documents/:id", JWT(secret), func(c *fiber.Ctx) error {
+				user := GetUserFromContext(c)
+				require.NotNil(t, user)
+				assert.Equal(t, "user-7", c.Locals("user_id"))
+				assert.Equal(t, "editor", c.Locals("role"))
+				return c.SendString(user.Email)
+			})
+
+			req := httptest.NewRequest("DELETE", "/documents/doc-1", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus == 200 {
+				assert.Equal(t, "clerk@example.org", string(body))
+			} else {
+				assert.Contains(t, string(body), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	const secret = "test-secret-key-for-jwt-testing"
+	token := func(role string) string {
+		return "Bearer " + signTestToken(t, secret, UserClaims{
+			UserID:           "user-7",
+			Role:             role,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		})
+	}
+
+	app := fiber.New()
+	app.Delete("/documents/:id", JWT(secret), RequireRole("admin"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	app.Post("/redact-document", JWT(secret), RequireRole("reviewer", "admin"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/no-jwt", RequireRole("admin"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		authHeader string
+		wantStatus int
+	}{
+		{"admin deletes", "DELETE", "/documents/doc-1", token("admin"), fiber.StatusNoContent},
+		{"reviewer cannot delete", "DELETE", "/documents/doc-1", token("reviewer"), fiber.StatusForbidden},
+		{"no role cannot delete", "DELETE", "/documents/doc-1", token(""), fiber.StatusForbidden},
+		{"reviewer redacts", "POST", "/redact-document", token("reviewer"), fiber.StatusOK},
+		{"admin redacts", "POST", "/redact-document", token("admin"), fiber.StatusOK},
+		{"editor cannot redact", "POST", "/redact-document", token("editor"), fiber.StatusForbidden},
+		{"missing token", "POST", "/redact-document", "", fiber.StatusUnauthorized},
+		{"without JWT middleware", "POST", "/no-jwt", token("admin"), fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestJWTWithRoleClaim_ReadsNestedClaim(t *testing.T) {
+	const secret = "test-secret-key-for-jwt-testing"
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":          "user-7",
+		"role":         "authenticated",
+		"app_metadata": map[string]interface{}{"role": "reviewer"},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Post("/redact-document", JWTWithRoleClaim(secret, "app_metadata.role"), RequireRole("reviewer"), func(c *fiber.Ctx) error {
+		return c.SendString(GetUserFromContext(c).Role)
+	})
+
+	req := httptest.NewRequest("POST", "/redact-document", nil)
+	req.Header.Set("Authorization", "Bearer "+signed)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "reviewer", string(body))
+}
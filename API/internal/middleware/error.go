@@ -23,13 +23,18 @@ var availableRoutes = []string{
 	"GET /api/v1/document-types",
 	"GET /api/v1/document-stats",
 	"GET /api/v1/field-options",
+	"POST /api/v1/field-options",
 	"GET /api/v1/metadata-fields/{field}",
 	"GET /api/v1/documents/{id}",
+	"GET /api/v1/documents/{id}/text",
 	"POST /api/v1/categorise",
 	"POST /api/v1/analyze-redactions",
 	"POST /api/v1/search",
+	"GET /api/v1/pipeline/status",
 	"POST /api/v1/update-metadata (auth required)",
+	"POST /api/v1/documents/bulk-update-metadata (auth required)",
 	"DELETE /api/v1/documents/{id} (auth required)",
+	"POST /api/v1/index/mapping/validate (auth required)",
 }
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
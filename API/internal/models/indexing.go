@@ -16,6 +16,17 @@ type IndexDocumentRequest struct {
 	ContentType          string                            `json:"content_type,omitempty"`
 	Size                 int64                             `json:"size,omitempty"`
 	FileURL              string                            `json:"file_url,omitempty"`
+	SourceSystem         string                            `json:"source_system,omitempty"`
+	IngestionBatchID     string                            `json:"ingestion_batch_id,omitempty"`
+}
+
+// MappingValidationRequest is a sample document to check against the live
+// index mapping. Without a classification result a representative one is
+// used; empty text gets sample text.
+type MappingValidationRequest struct {
+	Text                 string                            `json:"text,omitempty"`
+	ClassificationResult *classifier.ClassificationResult `json:"classification_result,omitempty"`
+	FileName             string                            `json:"file_name,omitempty"`
 }
 
 // IndexDocumentResponse represents the response from indexing a document
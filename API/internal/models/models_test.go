@@ -516,3 +516,28 @@ func generateLargeMetadata(size int) map[string]string {
 	}
 	return metadata
 }
+
+func TestNewTimingSummary(t *testing.T) {
+	steps := []*ProcessingStep{
+		{Name: "extraction", Duration: 120},
+		{Name: "classification", Duration: 300},
+		{Name: "indexing", Duration: 40},
+		{Name: "indexing", Duration: 15},
+		{Name: "storage", Duration: 25},
+	}
+
+	summary := NewTimingSummary(steps, 520)
+
+	assert.Equal(t, map[string]int64{
+		"extraction":     120,
+		"classification": 300,
+		"indexing":       55,
+		"storage":        25,
+	}, summary.Stages)
+	assert.Equal(t, int64(500), summary.StepsMs)
+	assert.Equal(t, int64(520), summary.TotalMs)
+
+	empty := NewTimingSummary(nil, 3)
+	assert.Empty(t, empty.Stages)
+	assert.Zero(t, empty.StepsMs)
+}
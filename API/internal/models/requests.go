@@ -1,9 +1,14 @@
 package models
 
 import (
+	"fmt"
 	"mime/multipart"
+	"slices"
+	"time"
 
 	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/redaction"
+	"motion-index-fiber/pkg/storage"
 )
 
 // ProcessDocumentRequest represents a document processing request
@@ -18,6 +23,14 @@ type ProcessDocumentRequest struct {
 	Court       string                `form:"court" validate:"omitempty,max=200"`
 	LegalTags   []string              `form:"legal_tags" validate:"omitempty,dive,max=50"`
 	Options     *ProcessOptions       `json:"options,omitempty"`
+
+	// CustomMetadata holds the parsed custom_metadata form field
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	// Provenance recorded on the indexed document. SourceSystem defaults to
+	// "upload"; IngestionBatchID can tag every document of one import run.
+	SourceSystem     string `form:"source_system" validate:"omitempty,max=100"`
+	IngestionBatchID string `form:"ingestion_batch_id" validate:"omitempty,max=100"`
 }
 
 // ProcessOptions defines processing options
@@ -27,7 +40,39 @@ type ProcessOptions struct {
 	IndexDocument  bool `json:"index_document" validate:"omitempty"`
 	StoreDocument  bool `json:"store_document" validate:"omitempty"`
 	TimeoutSeconds int  `json:"timeout_seconds" validate:"omitempty,min=1,max=300"`
-	RetryCount     int  `json:"retry_count" validate:"omitempty,min=0,max=3"`
+	RetryCount     int  `json:"retry_count" validate:"omitempty,min=0,max=3"` // Reprocessing attempts after a transient pipeline failure
+
+	// IndexImmediately indexes the document before the response is returned
+	// and waits for an index refresh so it is searchable right away. This adds
+	// the indexing time and refresh latency (up to the index refresh interval,
+	// ~1s by default) to every request and forces small segments, so batch
+	// uploads leave it off: their documents go through the indexing queue and
+	// appear in search once a queue worker has indexed them.
+	IndexImmediately bool `json:"index_immediately" validate:"omitempty"`
+
+	// ExtractReferences pulls hyperlinks and statute citations out of the
+	// document into searchable metadata. Off by default.
+	ExtractReferences bool `json:"extract_references" validate:"omitempty"`
+
+	// ExtractPages indexes per-page text so search results can point at the
+	// matching pages. Off by default because it roughly doubles index size.
+	ExtractPages bool `json:"extract_pages" validate:"omitempty"`
+
+	// ComputeReadability adds the Flesch reading ease score and average
+	// sentence and word lengths to the document's metadata. Off by default.
+	ComputeReadability bool `json:"compute_readability" validate:"omitempty"`
+
+	// MinIndexableTextLength overrides the deployment's minimum extracted text
+	// length for indexing. Documents below it are stored but not indexed.
+	MinIndexableTextLength *int `json:"min_indexable_text_length,omitempty" validate:"omitempty,min=0"`
+
+	// OnConflict overrides the deployment's policy for an upload path that is
+	// already taken: "overwrite", "skip" or "version"
+	OnConflict string `json:"on_conflict,omitempty" validate:"omitempty,oneof=overwrite skip version"`
+
+	// WarmCDN primes the CDN edge cache for the stored file after upload, for
+	// documents such as templates that are downloaded soon and often
+	WarmCDN bool `json:"warm_cdn,omitempty" validate:"omitempty"`
 }
 
 // BatchProcessRequest represents a batch document processing request
@@ -38,6 +83,14 @@ type BatchProcessRequest struct {
 	CaseName    string                  `form:"case_name" validate:"omitempty,max=200"`
 	CaseNumber  string                  `form:"case_number" validate:"omitempty,max=50"`
 	Options     *ProcessOptions         `json:"options,omitempty"`
+
+	// CustomMetadata is applied to every file in the batch
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	// Provenance for every file in the batch; SourceSystem defaults to
+	// "batch-upload" and IngestionBatchID to the generated batch ID
+	SourceSystem     string `form:"source_system" validate:"omitempty,max=100"`
+	IngestionBatchID string `form:"ingestion_batch_id" validate:"omitempty,max=100"`
 }
 
 // Re-export SearchRequest from pkg/models for consistency
@@ -46,7 +99,7 @@ type SearchDocumentsRequest = models.SearchRequest
 // UpdateMetadataRequest represents a request to update document metadata
 type UpdateMetadataRequest struct {
 	DocumentID string            `json:"document_id" validate:"required"`
-	Metadata   map[string]string `json:"metadata" validate:"required"`
+	Metadata   map[string]string `json:"metadata"`
 	CaseName   string            `json:"case_name" validate:"omitempty,max=200"`
 	CaseNumber string            `json:"case_number" validate:"omitempty,max=50"`
 	Author     string            `json:"author" validate:"omitempty,max=100"`
@@ -54,6 +107,68 @@ type UpdateMetadataRequest struct {
 	Court      string            `json:"court" validate:"omitempty,max=200"`
 	LegalTags  []string          `json:"legal_tags" validate:"omitempty,dive,max=50"`
 	Status     string            `json:"status" validate:"omitempty,oneof=draft review approved published archived"`
+
+	// EffectiveDate is when new values of effective-dated fields took
+	// effect; now when omitted. It may be in the past or the future.
+	EffectiveDate *time.Time `json:"effective_date,omitempty"`
+
+	// Set holds typed values by metadata field path, such as
+	// "court.court_name" or "court" with an object; each replaces the field
+	// at its path. Remove lists field paths to unset. Both are checked
+	// against the index mapping.
+	Set    map[string]interface{} `json:"set,omitempty"`
+	Remove []string               `json:"remove,omitempty"`
+}
+
+// MaxBulkMetadataUpdateSize is the maximum number of documents one bulk
+// metadata update may change
+const MaxBulkMetadataUpdateSize = 500
+
+// BulkUpdateMetadataRequest sets metadata fields of many documents by ID
+type BulkUpdateMetadataRequest struct {
+	Updates []models.BulkMetadataUpdate `json:"updates"`
+}
+
+// Validate checks that the request has entries and is within the cap; the
+// entries themselves are checked one by one with ValidateBulkMetadataUpdate
+func (r *BulkUpdateMetadataRequest) Validate() error {
+	if len(r.Updates) == 0 {
+		return fmt.Errorf("updates is required")
+	}
+	if len(r.Updates) > MaxBulkMetadataUpdateSize {
+		return fmt.Errorf("too many updates: %d (maximum %d)", len(r.Updates), MaxBulkMetadataUpdateSize)
+	}
+	return nil
+}
+
+// ValidateBulkMetadataUpdate checks one entry of a bulk metadata update.
+// Effective-dated fields are amended with their history, so an effective
+// date needs one of them and may not be in the future.
+func ValidateBulkMetadataUpdate(update models.BulkMetadataUpdate, effectiveDated []string) error {
+	if update.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(update.Metadata) == 0 {
+		return fmt.Errorf("metadata is required")
+	}
+	amends := false
+	for field := range update.Metadata {
+		if field == "" {
+			return fmt.Errorf("metadata field names cannot be empty")
+		}
+		if slices.Contains(effectiveDated, field) {
+			amends = true
+		}
+	}
+	if update.EffectiveDate != nil {
+		if !amends {
+			return fmt.Errorf("effective_date requires a value for an effective-dated field")
+		}
+		if err := models.CheckEffectiveDate(*update.EffectiveDate, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DeleteDocumentRequest represents a request to delete a document
@@ -62,6 +177,35 @@ type DeleteDocumentRequest struct {
 	Reason     string `json:"reason" validate:"omitempty,max=200"`
 }
 
+// MaxBulkDeleteSize is the maximum number of documents removed by one bulk delete
+const MaxBulkDeleteSize = 1000
+
+// BulkDeleteRequest selects documents to delete, either by ID or by a search
+// query whose matches are all deleted
+type BulkDeleteRequest struct {
+	IDs   []string              `json:"ids,omitempty"`
+	Query *models.SearchRequest `json:"query,omitempty"`
+}
+
+// Validate checks that exactly one selector is given and the ID list is within the cap
+func (r *BulkDeleteRequest) Validate() error {
+	if len(r.IDs) == 0 && r.Query == nil {
+		return fmt.Errorf("either ids or query is required")
+	}
+	if len(r.IDs) > 0 && r.Query != nil {
+		return fmt.Errorf("ids and query cannot be combined")
+	}
+	if len(r.IDs) > MaxBulkDeleteSize {
+		return fmt.Errorf("too many ids: %d (maximum %d)", len(r.IDs), MaxBulkDeleteSize)
+	}
+	for _, id := range r.IDs {
+		if id == "" {
+			return fmt.Errorf("ids cannot contain empty values")
+		}
+	}
+	return nil
+}
+
 // AnalyzeRedactionsRequest represents a request to analyze document redactions
 type AnalyzeRedactionsRequest struct {
 	File        *multipart.FileHeader `form:"file" validate:"required"`
@@ -99,6 +243,10 @@ type HealthCheckRequest struct {
 	Deep      bool   `json:"deep" validate:"omitempty"`
 }
 
+// MaxRetryCount is the most times a document may be reprocessed after a
+// transient pipeline failure
+const MaxRetryCount = 3
+
 // Default values for process options
 func DefaultProcessOptions() *ProcessOptions {
 	return &ProcessOptions{
@@ -108,9 +256,19 @@ func DefaultProcessOptions() *ProcessOptions {
 		StoreDocument:  true,
 		TimeoutSeconds: 120,
 		RetryCount:     1,
+
+		IndexImmediately: true,
 	}
 }
 
+// DefaultBatchProcessOptions returns the options used for batch uploads, which
+// defer index visibility to the periodic refresh for throughput
+func DefaultBatchProcessOptions() *ProcessOptions {
+	opts := DefaultProcessOptions()
+	opts.IndexImmediately = false
+	return opts
+}
+
 // Validate checks if ProcessOptions are valid
 func (opts *ProcessOptions) Validate() error {
 	if opts == nil {
@@ -121,10 +279,20 @@ func (opts *ProcessOptions) Validate() error {
 		opts.TimeoutSeconds = 120
 	}
 
-	if opts.RetryCount < 0 || opts.RetryCount > 3 {
+	if opts.RetryCount < 0 || opts.RetryCount > MaxRetryCount {
 		opts.RetryCount = 1
 	}
 
+	if opts.MinIndexableTextLength != nil && *opts.MinIndexableTextLength < 0 {
+		return fmt.Errorf("min_indexable_text_length cannot be negative")
+	}
+
+	if opts.OnConflict != "" {
+		if _, err := storage.ParseConflictPolicy(opts.OnConflict); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -136,6 +304,9 @@ type RedactDocumentRequest struct {
 	Options          *RedactionOptions      `json:"options,omitempty"`
 	// For file upload redaction (alternative to document_id)
 	PDFBase64        string                 `json:"pdf_base64,omitempty"`
+
+	// StoreResult uploads the redacted PDF to storage under the redacted/ prefix
+	StoreResult bool `json:"store_result,omitempty"`
 }
 
 // RedactionOptions configures redaction behavior
@@ -145,6 +316,9 @@ type RedactionOptions struct {
 	IncludePatterns  []string `json:"include_patterns,omitempty"`
 	ExcludePatterns  []string `json:"exclude_patterns,omitempty"`
 	ReplacementChar  string   `json:"replacement_char"`
+
+	// ReplacementRules styles the replacement of each redaction type
+	ReplacementRules map[string]redaction.ReplacementStyle `json:"replacement_rules,omitempty"`
 }
 
 // RedactionItem represents a single redaction to apply
@@ -12,6 +12,8 @@ type ExtractionResult struct {
 	Text      string `json:"text"`
 	PageCount int    `json:"page_count"`
 	Language  string `json:"language"`
+	OCRUsed   bool   `json:"ocr_used,omitempty"` // Text was read by OCR and may contain recognition errors
+	Extractor string `json:"extractor,omitempty"` // Extractor that produced the text, e.g. a fallback such as "pdf_ocr"
 }
 
 // ClassificationResult represents the result of document classification
@@ -35,11 +37,18 @@ type ProcessDocumentResponse struct {
 	ClassificationResult *ClassificationResult `json:"classification_result,omitempty"`
 	IndexResult          *IndexResult          `json:"index_result,omitempty"`
 	StorageResult        *storage.UploadResult `json:"storage_result,omitempty"`
+	StorageAction        string                `json:"storage_action,omitempty"` // created, overwritten, skipped or versioned
+	Attempts             int                   `json:"attempts,omitempty"`       // Pipeline runs made, including retries of transient failures
+	Profile              string                `json:"profile,omitempty"`        // Processing profile chosen by pre-classification
+	ReviewStatus         string                `json:"review_status,omitempty"`  // "incomplete_metadata" or "low_confidence" when the document needs review
+	MissingFields        []string              `json:"missing_fields,omitempty"` // Required metadata the document lacks
 	URL                  string                `json:"url,omitempty"`
 	CDN_URL              string                `json:"cdn_url,omitempty"`
 	Steps                []*ProcessingStep     `json:"steps,omitempty"`
 	Metadata             *DocumentMetadata     `json:"metadata,omitempty"`
 	CreatedAt            time.Time             `json:"created_at"`
+
+	Timing *TimingSummary `json:"timing,omitempty"` // Step durations rolled up by stage
 }
 
 // BatchProcessResponse represents the response from batch processing
@@ -50,6 +59,7 @@ type BatchProcessResponse struct {
 	FailureCount   int                        `json:"failure_count"`
 	Results        []*ProcessDocumentResponse `json:"results"`
 	Errors         []*BatchProcessError       `json:"errors,omitempty"`
+	Outcomes       []*BatchFileOutcome        `json:"outcomes"`
 	ProcessingTime int64                      `json:"processing_time_ms"`
 	Status         string                     `json:"status"`
 	CompletedAt    time.Time                  `json:"completed_at"`
@@ -62,12 +72,45 @@ type BatchProcessError struct {
 	Code     string `json:"code"`
 }
 
+// BatchFileOutcome describes what happened to a single file in a batch.
+// Outcomes are reported in upload order so clients can match them to the
+// files they sent.
+type BatchFileOutcome struct {
+	Index      int    `json:"index"`
+	FileName   string `json:"file_name"`
+	Status     string `json:"status"` // "succeeded" or "failed"
+	DocumentID string `json:"document_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Code       string `json:"code,omitempty"`
+}
+
+// Batch status values
+const (
+	BatchStatusCompleted      = "completed"
+	BatchStatusPartialSuccess = "partial_success"
+	BatchStatusFailed         = "failed"
+)
+
+// Batch file outcome status values
+const (
+	BatchOutcomeSucceeded = "succeeded"
+	BatchOutcomeFailed    = "failed"
+)
+
 // IndexResult represents the result of document indexing
 type IndexResult struct {
 	DocumentID string `json:"document_id"`
 	IndexName  string `json:"index_name"`
 	Success    bool   `json:"success"`
+	Searchable bool   `json:"searchable"` // Visible to search when the response was returned
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+	Deferred   bool   `json:"deferred,omitempty"` // Queued until a cluster block on the index clears
+	Queued     bool   `json:"queued,omitempty"`   // Handed to the indexing queue instead of indexed inline
+	Warning    string `json:"warning,omitempty"`
 	Error      string `json:"error,omitempty"`
+
+	DuplicateOf string `json:"duplicate_of,omitempty"` // Indexed document with the same content, when skipped as a duplicate
 }
 
 // ProcessingStep represents a single step in the processing pipeline
@@ -81,6 +124,24 @@ type ProcessingStep struct {
 	ProcessedBy string    `json:"processed_by,omitempty"`
 }
 
+// TimingSummary breaks a document's processing time down by stage so
+// clients can show where the time went
+type TimingSummary struct {
+	Stages  map[string]int64 `json:"stages_ms"` // Summed step durations by stage, e.g. "extraction"
+	StepsMs int64            `json:"steps_ms"`  // Sum of all step durations
+	TotalMs int64            `json:"total_ms"`  // The whole run, including work between steps
+}
+
+// NewTimingSummary sums the durations of steps by stage name
+func NewTimingSummary(steps []*ProcessingStep, totalMs int64) *TimingSummary {
+	summary := &TimingSummary{Stages: make(map[string]int64), TotalMs: totalMs}
+	for _, step := range steps {
+		summary.Stages[step.Name] += step.Duration
+		summary.StepsMs += step.Duration
+	}
+	return summary
+}
+
 // Re-export DocumentMetadata from pkg/models
 type DocumentMetadata = models.DocumentMetadata
 
@@ -226,6 +287,7 @@ type Area struct {
 // PipelineStatusResponse represents pipeline status
 type PipelineStatusResponse struct {
 	Status          string                      `json:"status"`
+	Mode            string                      `json:"mode"` // "pipeline" or "legacy"
 	ActiveJobs      int64                       `json:"active_jobs"`
 	QueuedJobs      int64                       `json:"queued_jobs"`
 	CompletedJobs   int64                       `json:"completed_jobs"`
@@ -234,12 +296,19 @@ type PipelineStatusResponse struct {
 	ProcessorStatus map[string]*ProcessorStatus `json:"processor_status"`
 	Uptime          int64                       `json:"uptime_seconds"`
 	LastUpdate      time.Time                   `json:"last_update"`
+
+	// Options applied when a client sends none, so UIs can pre-fill their toggles
+	DefaultOptions      *ProcessOptions `json:"default_options,omitempty"`
+	BatchDefaultOptions *ProcessOptions `json:"batch_default_options,omitempty"`
 }
 
 // ProcessorStatus represents the status of a processor
 type ProcessorStatus struct {
 	Type              string    `json:"type"`
 	Status            string    `json:"status"`
+	Configured        bool      `json:"configured"`
+	Healthy           bool      `json:"healthy"`
+	Error             string    `json:"error,omitempty"`
 	ProcessedCount    int64     `json:"processed_count"`
 	ErrorCount        int64     `json:"error_count"`
 	AvgProcessingTime int64     `json:"avg_processing_time_ms"`
@@ -253,27 +322,43 @@ type HealthResponse struct {
 	Timestamp time.Time `json:"timestamp"`
 	Version   string    `json:"version"`
 	Service   string    `json:"service"`
+
+	// Checks holds the result of checking each dependency, by name
+	Checks map[string]*DependencyCheck `json:"checks,omitempty"`
+}
+
+// DependencyCheck is the result of checking one dependency. Status is
+// healthy, degraded, unhealthy or not_configured. A critical dependency that
+// is unhealthy makes the service unavailable.
+type DependencyCheck struct {
+	Status    string                 `json:"status"`
+	Critical  bool                   `json:"critical"`
+	LatencyMS float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
 // SystemStatus represents comprehensive system status
 type SystemStatus struct {
-	Service   string           `json:"service"`
-	Version   string           `json:"version"`
-	Status    string           `json:"status"`
-	Timestamp time.Time        `json:"timestamp"`
-	Uptime    time.Duration    `json:"uptime"`
-	System    *SystemInfo      `json:"system"`
-	Storage   *ComponentStatus `json:"storage"`
-	Indexer   *ComponentStatus `json:"indexer"`
+	Service    string           `json:"service"`
+	Version    string           `json:"version"`
+	Status     string           `json:"status"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Uptime     time.Duration    `json:"uptime"`
+	System     *SystemInfo      `json:"system"`
+	Storage    *ComponentStatus `json:"storage"`
+	Indexer    *ComponentStatus `json:"indexer"`
+	Classifier *ComponentStatus `json:"classifier,omitempty"`
 }
 
 // ComponentStatus represents the status of a system component
 type ComponentStatus struct {
-	Name      string    `json:"name"`
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Error     string    `json:"error,omitempty"`
-	LastError time.Time `json:"last_error,omitempty"`
+	Name      string                 `json:"name"`
+	Status    string                 `json:"status"`
+	Timestamp time.Time              `json:"timestamp"`
+	Error     string                 `json:"error,omitempty"`
+	LastError time.Time              `json:"last_error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
 // ReadinessResponse represents readiness check response
@@ -298,6 +383,7 @@ type MetricsResponse struct {
 	GC         *GCStats               `json:"gc"`
 	Storage    map[string]interface{} `json:"storage"`
 	Indexer    map[string]interface{} `json:"indexer"`
+	Classifier map[string]interface{} `json:"classifier,omitempty"`
 }
 
 // MemoryInfo represents memory statistics
@@ -336,6 +422,21 @@ type RedactionRegion struct {
 	Type   string  `json:"type"`
 }
 
+// BulkUpdateMetadataResponse reports the outcome of each entry of a bulk
+// metadata update, in request order
+type BulkUpdateMetadataResponse struct {
+	Results []BulkMetadataResult `json:"results"`
+	Updated int                  `json:"updated"`
+	Failed  int                  `json:"failed"`
+}
+
+// BulkMetadataResult is the outcome of one entry of a bulk metadata update
+type BulkMetadataResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
 // UpdateMetadataResponse represents the response from updating metadata
 type UpdateMetadataResponse struct {
 	DocumentID string    `json:"document_id"`
@@ -352,7 +453,34 @@ type RedactDocumentResponse struct {
 	Filename         string          `json:"filename,omitempty"`
 	Redactions       []RedactionItem `json:"redactions"`
 	TotalRedactions  int             `json:"total_redactions"`
+	AnalysisTimeMs   int64           `json:"analysis_time_ms,omitempty"`
 	Message          string          `json:"message"`
+
+	// Set when a DOCX or RTF file is redacted with preserve_format=true
+	DocumentBase64 string `json:"document_base64,omitempty"`
+	Format         string `json:"format,omitempty"` // Format of the redacted file: pdf, docx or rtf
+}
+
+// RedactionOverlayResponse lists redaction regions for a client to draw over
+// the original PDF instead of downloading a redacted copy
+type RedactionOverlayResponse struct {
+	DocumentID      string                   `json:"document_id,omitempty"` // Set when the regions were stored with the document
+	Filename        string                   `json:"filename,omitempty"`
+	CoordinateSpace string                   `json:"coordinate_space"`
+	Regions         []RedactionOverlayRegion `json:"regions"`
+	TotalRegions    int                      `json:"total_regions"`
+	AnalysisTimeMs  int64                    `json:"analysis_time_ms"`
+}
+
+// RedactionOverlayRegion is a single box to draw over a page
+type RedactionOverlayRegion struct {
+	ID        string    `json:"id"`
+	Page      int       `json:"page"`
+	BBox      []float64 `json:"bbox"` // [x0, y0, x1, y1] in CoordinateSpace
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason,omitempty"`
+	Citation  string    `json:"citation,omitempty"`
+	LegalCode string    `json:"legal_code,omitempty"`
 }
 
 // Re-export helper functions from pkg/models for convenience
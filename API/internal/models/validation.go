@@ -156,7 +156,7 @@ func DefaultFileValidationRules() *FileValidationRules {
 	return &FileValidationRules{
 		MaxSize: 100 * 1024 * 1024, // 100MB
 		AllowedExtensions: []string{
-			"pdf", "doc", "docx", "txt", "rtf", "html", "htm",
+			"pdf", "doc", "docx", "txt", "rtf", "html", "htm", "eml", "msg",
 		},
 		AllowedMimeTypes: []string{
 			"application/pdf",
@@ -165,6 +165,8 @@ func DefaultFileValidationRules() *FileValidationRules {
 			"text/plain",
 			"application/rtf",
 			"text/html",
+			"message/rfc822",
+			"application/vnd.ms-outlook",
 		},
 		MinSize: 1, // 1 byte minimum
 	}
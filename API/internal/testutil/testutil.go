@@ -108,6 +108,11 @@ func TestConfig() *config.Config {
 			Bucket:    "test-bucket",
 			Region:    "nyc3",
 			CDNDomain: "",
+
+			OnConflict:  "version",
+			MinFileSize: 100,
+
+			IndexFileURLs: true,
 		},
 		Auth: config.AuthConfig{
 			JWTSecret:       "test-secret",
@@ -120,6 +125,28 @@ func TestConfig() *config.Config {
 			MaxWorkers:     2,
 			BatchSize:      10,
 			ProcessTimeout: 30 * time.Second,
+			RetryBackoff:   10 * time.Millisecond,
+
+			IndexFlushThreshold: 10,
+			BatchConcurrency:    4,
+
+			MaxConcurrentBatchJobs: 2,
+
+			MinExtractionQuality:   0.5,
+			MinIndexableTextLength: 0,
+
+			DefaultExtractText:   true,
+			DefaultClassifyDoc:   true,
+			DefaultIndexDocument: true,
+			DefaultStoreDocument: true,
+
+			RedactionTimeout:     30 * time.Second,
+			RedactionMaxPages:    100,
+			RedactionMaxFileSize: 10 * 1024 * 1024, // 10MB
+
+			DeferBlockedIndexing:       true,
+			DeferredIndexRetryInterval: time.Minute,
+			DeferredIndexQueueSize:     100,
 		},
 		OpenSearch: config.OpenSearchConfig{
 			Host:     "localhost",
@@ -0,0 +1,55 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"motion-index-fiber/pkg/cloud/digitalocean/spaces"
+)
+
+// cdnFlusher flushes the DigitalOcean CDN endpoint whose origin is a Spaces
+// bucket. The endpoint is looked up on the first flush and remembered.
+type cdnFlusher struct {
+	api    spaces.DOAPIClient
+	origin string
+
+	mu    sync.Mutex
+	cdnID string
+}
+
+// newCDNFlusher returns a flusher for the CDN in front of bucket
+func newCDNFlusher(api spaces.DOAPIClient, bucket, region string) *cdnFlusher {
+	return &cdnFlusher{api: api, origin: fmt.Sprintf("%s.%s.digitaloceanspaces.com", bucket, region)}
+}
+
+// FlushCDNCache purges files from the bucket's CDN endpoint
+func (f *cdnFlusher) FlushCDNCache(ctx context.Context, files []string) error {
+	cdnID, err := f.endpointID(ctx)
+	if err != nil {
+		return err
+	}
+	return f.api.FlushCDNCache(ctx, cdnID, files)
+}
+
+// endpointID finds the CDN endpoint serving the bucket. A failed lookup is
+// tried again on the next flush.
+func (f *cdnFlusher) endpointID(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cdnID != "" {
+		return f.cdnID, nil
+	}
+
+	cdns, err := f.api.ListCDNs(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, cdn := range cdns {
+		if cdn.Origin == f.origin {
+			f.cdnID = cdn.ID
+			return f.cdnID, nil
+		}
+	}
+	return "", fmt.Errorf("no CDN endpoint found for %s", f.origin)
+}
@@ -44,6 +44,11 @@ type Config struct {
 			Password string `json:"password"`
 			UseSSL   bool   `json:"use_ssl"`
 			Index    string `json:"index" validate:"required"`
+
+			// ReadHost and ReadPort name a separate endpoint for searches and
+			// aggregations; empty sends everything to Host
+			ReadHost string `json:"read_host,omitempty"`
+			ReadPort int    `json:"read_port,omitempty"`
 		} `json:"opensearch"`
 	} `json:"digitalocean"`
 
@@ -62,6 +67,10 @@ type Config struct {
 		ChunkSizeBytes         int  `json:"chunk_size_bytes" validate:"min=1024"`
 		EnableCaching          bool `json:"enable_caching"`
 		CacheTTLSeconds        int  `json:"cache_ttl_seconds" validate:"min=60"`
+
+		// MaxConcurrentCDNFlushes caps the CDN cache flush requests a bulk
+		// invalidation sends at once, to stay under the API rate limit
+		MaxConcurrentCDNFlushes int `json:"max_concurrent_cdn_flushes" validate:"min=1,max=10"`
 	} `json:"performance"`
 }
 
@@ -133,6 +142,20 @@ func LoadFromEnvironment() (*Config, error) {
 		config.DigitalOcean.OpenSearch.Port = 25060 // Default DO OpenSearch port
 	}
 
+	config.DigitalOcean.OpenSearch.ReadHost = os.Getenv("DO_OPENSEARCH_READ_HOST")
+	config.DigitalOcean.OpenSearch.ReadPort = config.DigitalOcean.OpenSearch.Port
+	if port := os.Getenv("DO_OPENSEARCH_READ_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, &ConfigError{
+				Field:   "DO_OPENSEARCH_READ_PORT",
+				Message: "invalid port number",
+				Value:   port,
+			}
+		}
+		config.DigitalOcean.OpenSearch.ReadPort = p
+	}
+
 	// Parse boolean values
 	config.DigitalOcean.OpenSearch.UseSSL = getEnvBoolWithDefault("DO_OPENSEARCH_USE_SSL", true)
 
@@ -148,6 +171,7 @@ func LoadFromEnvironment() (*Config, error) {
 	config.Performance.ChunkSizeBytes = getEnvIntWithDefault("PERF_CHUNK_SIZE_BYTES", 8*1024*1024) // 8MB
 	config.Performance.EnableCaching = getEnvBoolWithDefault("PERF_ENABLE_CACHING", true)
 	config.Performance.CacheTTLSeconds = getEnvIntWithDefault("PERF_CACHE_TTL_SECONDS", 3600) // 1 hour
+	config.Performance.MaxConcurrentCDNFlushes = getEnvIntWithDefault("PERF_MAX_CONCURRENT_CDN_FLUSHES", 2)
 
 	// Validate configuration
 	if err := config.Validate(); err != nil {
@@ -344,12 +368,15 @@ func DefaultConfig() *Config {
 			ChunkSizeBytes         int  `json:"chunk_size_bytes" validate:"min=1024"`
 			EnableCaching          bool `json:"enable_caching"`
 			CacheTTLSeconds        int  `json:"cache_ttl_seconds" validate:"min=60"`
+
+			MaxConcurrentCDNFlushes int `json:"max_concurrent_cdn_flushes" validate:"min=1,max=10"`
 		}{
-			MaxConcurrentUploads:   10,
-			MaxConcurrentDownloads: 20,
-			ChunkSizeBytes:         8 * 1024 * 1024, // 8MB
-			EnableCaching:          true,
-			CacheTTLSeconds:        3600, // 1 hour
+			MaxConcurrentUploads:    10,
+			MaxConcurrentDownloads:  20,
+			ChunkSizeBytes:          8 * 1024 * 1024, // 8MB
+			EnableCaching:           true,
+			CacheTTLSeconds:         3600, // 1 hour
+			MaxConcurrentCDNFlushes: 2,
 		},
 	}
 }
@@ -166,12 +166,15 @@ func TestConfigValidation(t *testing.T) {
 					ChunkSizeBytes         int  `json:"chunk_size_bytes" validate:"min=1024"`
 					EnableCaching          bool `json:"enable_caching"`
 					CacheTTLSeconds        int  `json:"cache_ttl_seconds" validate:"min=60"`
+
+					MaxConcurrentCDNFlushes int `json:"max_concurrent_cdn_flushes" validate:"min=1,max=10"`
 				}{
-					MaxConcurrentUploads:   10,
-					MaxConcurrentDownloads: 20,
-					ChunkSizeBytes:         8 * 1024 * 1024,
-					EnableCaching:          true,
-					CacheTTLSeconds:        3600,
+					MaxConcurrentUploads:    10,
+					MaxConcurrentDownloads:  20,
+					ChunkSizeBytes:          8 * 1024 * 1024,
+					EnableCaching:           true,
+					CacheTTLSeconds:         3600,
+					MaxConcurrentCDNFlushes: 2,
 				},
 			},
 			expectError: false,
@@ -197,12 +200,15 @@ func TestConfigValidation(t *testing.T) {
 					ChunkSizeBytes         int  `json:"chunk_size_bytes" validate:"min=1024"`
 					EnableCaching          bool `json:"enable_caching"`
 					CacheTTLSeconds        int  `json:"cache_ttl_seconds" validate:"min=60"`
+
+					MaxConcurrentCDNFlushes int `json:"max_concurrent_cdn_flushes" validate:"min=1,max=10"`
 				}{
-					MaxConcurrentUploads:   10,
-					MaxConcurrentDownloads: 20,
-					ChunkSizeBytes:         8 * 1024 * 1024,
-					EnableCaching:          true,
-					CacheTTLSeconds:        3600,
+					MaxConcurrentUploads:    10,
+					MaxConcurrentDownloads:  20,
+					ChunkSizeBytes:          8 * 1024 * 1024,
+					EnableCaching:           true,
+					CacheTTLSeconds:         3600,
+					MaxConcurrentCDNFlushes: 2,
 				},
 			},
 			expectError: true,
@@ -229,12 +235,15 @@ func TestConfigValidation(t *testing.T) {
 					ChunkSizeBytes         int  `json:"chunk_size_bytes" validate:"min=1024"`
 					EnableCaching          bool `json:"enable_caching"`
 					CacheTTLSeconds        int  `json:"cache_ttl_seconds" validate:"min=60"`
+
+					MaxConcurrentCDNFlushes int `json:"max_concurrent_cdn_flushes" validate:"min=1,max=10"`
 				}{
-					MaxConcurrentUploads:   10,
-					MaxConcurrentDownloads: 20,
-					ChunkSizeBytes:         512, // Invalid: too small
-					EnableCaching:          true,
-					CacheTTLSeconds:        3600,
+					MaxConcurrentUploads:    10,
+					MaxConcurrentDownloads:  20,
+					ChunkSizeBytes:          512, // Invalid: too small
+					EnableCaching:           true,
+					CacheTTLSeconds:         3600,
+					MaxConcurrentCDNFlushes: 2,
 				},
 			},
 			expectError: true,
@@ -448,6 +457,7 @@ func clearEnvironment() {
 		"HEALTH_CIRCUIT_BREAKER",
 		"PERF_MAX_CONCURRENT_UPLOADS",
 		"PERF_MAX_CONCURRENT_DOWNLOADS",
+		"PERF_MAX_CONCURRENT_CDN_FLUSHES",
 		"PERF_CHUNK_SIZE_BYTES",
 		"PERF_ENABLE_CACHING",
 		"PERF_CACHE_TTL_SECONDS",
@@ -6,6 +6,7 @@ import (
 
 	internalConfig "motion-index-fiber/internal/config"
 	"motion-index-fiber/pkg/cloud/digitalocean/config"
+	"motion-index-fiber/pkg/cloud/digitalocean/spaces"
 	"motion-index-fiber/pkg/search"
 	"motion-index-fiber/pkg/search/client"
 	"motion-index-fiber/pkg/storage"
@@ -86,11 +87,29 @@ func (f *ServiceFactory) createSpacesStorageService() (storage.Service, error) {
 			Bucket:      f.config.DigitalOcean.Spaces.Bucket,
 			Region:      f.config.DigitalOcean.Spaces.Region,
 			CDNDomain:   f.config.DigitalOcean.Spaces.CDNEndpoint,
+
+			MaxConcurrentDownloads: f.config.Performance.MaxConcurrentDownloads,
 		},
 	}
 	
 	// Use the properly implemented SpacesService
-	return storage.NewSpacesService(internalCfg)
+	service, err := storage.NewSpacesService(internalCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flushing the CDN cache needs the DigitalOcean API
+	if token := f.config.DigitalOcean.APIToken; token != "" {
+		service.SetCDNFlusher(
+			newCDNFlusher(spaces.NewDOAPIClient(token), internalCfg.Storage.Bucket, internalCfg.Storage.Region),
+			storage.CDNFlushPolicy{
+				MaxConcurrent: f.config.Performance.MaxConcurrentCDNFlushes,
+				MaxRetries:    f.config.Health.MaxRetries,
+				Backoff:       storage.DefaultCDNFlushPolicy.Backoff,
+			},
+		)
+	}
+	return service, nil
 }
 
 
@@ -179,6 +198,9 @@ func (f *ServiceFactory) bridgeOpenSearchConfig() *internalConfig.OpenSearchConf
 		Password: doOpenSearch.Password,
 		UseSSL:   doOpenSearch.UseSSL,
 		Index:    doOpenSearch.Index,
+
+		ReadHost: doOpenSearch.ReadHost,
+		ReadPort: doOpenSearch.ReadPort,
 	}
 }
 
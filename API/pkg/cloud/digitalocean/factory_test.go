@@ -572,6 +572,10 @@ func (m *MockStorageService) List(ctx context.Context, prefix string) ([]*storag
 	return nil, nil
 }
 
+func (m *MockStorageService) ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	return &storage.ListPage{}, nil
+}
+
 func (m *MockStorageService) IsHealthy() bool {
 	return m.healthy
 }
@@ -614,7 +618,7 @@ func (m *MockSearchService) BulkIndexDocuments(ctx context.Context, docs []*mode
 	}, nil
 }
 
-func (m *MockSearchService) UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}) error {
+func (m *MockSearchService) UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string) error {
 	return nil
 }
 
@@ -622,6 +626,14 @@ func (m *MockSearchService) DeleteDocument(ctx context.Context, docID string) er
 	return nil
 }
 
+func (m *MockSearchService) BulkDeleteDocuments(ctx context.Context, ids []string) (*models.BulkResult, error) {
+	return &models.BulkResult{
+		Deleted:    len(ids),
+		Items:      []models.BulkResultItem{},
+		FailedDocs: []*models.BulkFailedDoc{},
+	}, nil
+}
+
 func (m *MockSearchService) GetDocument(ctx context.Context, docID string) (*models.Document, error) {
 	return nil, fmt.Errorf("document not found")
 }
@@ -630,6 +642,10 @@ func (m *MockSearchService) DocumentExists(ctx context.Context, docID string) (b
 	return false, nil
 }
 
+func (m *MockSearchService) FindByHash(ctx context.Context, hash string) (*models.Document, error) {
+	return nil, nil
+}
+
 // AggregationService methods
 func (m *MockSearchService) GetLegalTags(ctx context.Context) ([]*models.TagCount, error) {
 	return []*models.TagCount{}, nil
@@ -662,6 +678,14 @@ func (m *MockSearchService) GetAllFieldOptions(ctx context.Context) (*models.Fie
 	return &models.FieldOptions{}, nil
 }
 
+func (m *MockSearchService) GetFieldOptionsForQuery(ctx context.Context, req *models.SearchRequest) (*models.FieldOptions, error) {
+	return &models.FieldOptions{}, nil
+}
+
+func (m *MockSearchService) GetCaseStats(ctx context.Context, caseNumber string) (*models.CaseStats, error) {
+	return &models.CaseStats{CaseNumber: caseNumber}, nil
+}
+
 // HealthChecker methods
 func (m *MockSearchService) IsHealthy() bool {
 	return m.healthy
@@ -26,6 +26,7 @@ type S3Client interface {
 	// File management
 	Exists(ctx context.Context, bucket, key string) (bool, error)
 	List(ctx context.Context, bucket, prefix string, maxKeys int) ([]*storage.StorageObject, error)
+	ListPage(ctx context.Context, bucket, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error)
 
 	// URL generation
 	GetPublicURL(bucket, key string, useSSL bool) string
@@ -249,6 +250,44 @@ func (c *s3ClientImpl) List(ctx context.Context, bucket, prefix string, maxKeys
 	return objects, nil
 }
 
+func (c *s3ClientImpl) ListPage(ctx context.Context, bucket, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	if !c.initialized {
+		return nil, fmt.Errorf("S3 client not initialized")
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(int32(maxKeys)),
+	}
+	if continuationToken != "" {
+		input.ContinuationToken = aws.String(continuationToken)
+	}
+
+	result, err := c.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	page := &storage.ListPage{Objects: make([]*storage.StorageObject, 0, len(result.Contents))}
+	for _, obj := range result.Contents {
+		if obj.Key == nil {
+			continue
+		}
+		page.Objects = append(page.Objects, &storage.StorageObject{
+			Path:         aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			LastModified: aws.ToTime(obj.LastModified),
+			ETag:         aws.ToString(obj.ETag),
+		})
+	}
+	if aws.ToBool(result.IsTruncated) {
+		page.NextContinuationToken = aws.ToString(result.NextContinuationToken)
+	}
+
+	return page, nil
+}
+
 // URL generation
 
 func (c *s3ClientImpl) GetPublicURL(bucket, key string, useSSL bool) string {
@@ -2,9 +2,13 @@ package spaces
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"motion-index-fiber/pkg/cloud/digitalocean/config"
@@ -22,11 +26,15 @@ type SpacesClient struct {
 	// Performance and reliability settings
 	maxConcurrentUploads   int
 	maxConcurrentDownloads int
+	maxConcurrentFlushes   int
 	retryConfig            *RetryConfig
 
 	// CDN health and failover state
 	cdnHealthState *CDNHealthState
 
+	// cdnHTTPClient sends the HEAD requests that warm the CDN cache
+	cdnHTTPClient *http.Client
+
 	// Metrics and monitoring
 	metrics *SpacesMetrics
 }
@@ -107,6 +115,7 @@ func NewSpacesClient(cfg *config.Config) (*SpacesClient, error) {
 		// Performance settings from config
 		maxConcurrentUploads:   cfg.Performance.MaxConcurrentUploads,
 		maxConcurrentDownloads: cfg.Performance.MaxConcurrentDownloads,
+		maxConcurrentFlushes:   cfg.Performance.MaxConcurrentCDNFlushes,
 
 		// Default retry configuration
 		retryConfig: &RetryConfig{
@@ -128,6 +137,8 @@ func NewSpacesClient(cfg *config.Config) (*SpacesClient, error) {
 			CircuitBreakerTimeout:  30 * time.Second, // Try again after 30 seconds
 		},
 
+		cdnHTTPClient: &http.Client{Timeout: cdnWarmTimeout},
+
 		// Initialize metrics
 		metrics: &SpacesMetrics{
 			LastHealthCheck: time.Time{},
@@ -174,11 +185,82 @@ func (c *SpacesClient) Upload(ctx context.Context, path string, content io.Reade
 	// Optimize URL for CDN if available
 	if c.cdnInfo != nil {
 		result.URL = c.getCDNURL(path)
+
+		// Warm in the background so the upload doesn't wait on the CDN
+		if metadata != nil && metadata.WarmAfterUpload {
+			go func() {
+				warmCtx, cancel := context.WithTimeout(context.Background(), cdnWarmTimeout)
+				defer cancel()
+				if err := c.WarmCDN(warmCtx, []string{path}); err != nil {
+					log.Printf("[SPACES] ⚠️ CDN cache warming failed for %s: %v", path, err)
+				}
+			}()
+		}
 	}
 
 	return result, nil
 }
 
+// cdnWarmTimeout bounds each cache warming request
+const cdnWarmTimeout = 10 * time.Second
+
+// WarmCDN primes the CDN edge cache for the given paths with HEAD requests
+// against their CDN URLs, so the first reader doesn't hit a cold cache. It
+// does nothing without a CDN, and stops while the CDN circuit breaker is
+// open. Server errors and failed requests count as CDN failures; other error
+// statuses are reported without affecting CDN health.
+func (c *SpacesClient) WarmCDN(ctx context.Context, paths []string) error {
+	if c.cdnInfo == nil || len(paths) == 0 {
+		return nil
+	}
+	if !c.isCDNHealthy() {
+		return fmt.Errorf("CDN is unavailable, skipped warming %d paths", len(paths))
+	}
+
+	client := c.cdnHTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var errs []error
+	for i, path := range paths {
+		if c.cdnHealthState.CircuitBreakerOpen {
+			errs = append(errs, fmt.Errorf("CDN circuit breaker opened, skipped warming %d paths", len(paths)-i))
+			break
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.getCDNURL(sanitizePath(path)), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				errs = append(errs, ctx.Err())
+				break
+			}
+			c.recordCDNFailure()
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			c.recordCDNFailure()
+			errs = append(errs, fmt.Errorf("%s: CDN returned HTTP %d", path, resp.StatusCode))
+			continue
+		}
+		c.recordCDNSuccess()
+		if resp.StatusCode >= http.StatusBadRequest {
+			// The CDN answered, but has nothing cached for the path
+			errs = append(errs, fmt.Errorf("%s: CDN returned HTTP %d", path, resp.StatusCode))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // Download downloads a document from DigitalOcean Spaces
 func (c *SpacesClient) Download(ctx context.Context, path string) (io.ReadCloser, error) {
 	startTime := time.Now()
@@ -296,6 +378,18 @@ func (c *SpacesClient) List(ctx context.Context, prefix string) ([]*storage.Stor
 	return objects, nil
 }
 
+// ListPaged lists one page of documents in a directory
+func (c *SpacesClient) ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	prefix = sanitizePath(prefix)
+
+	page, err := c.s3Client.ListPage(ctx, c.bucket, prefix, continuationToken, maxKeys)
+	if err != nil {
+		return nil, storage.NewStorageError("list", "failed to list objects in Spaces", prefix, err)
+	}
+
+	return page, nil
+}
+
 // IsHealthy returns true if the storage service is healthy
 func (c *SpacesClient) IsHealthy() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Health.TimeoutSeconds)*time.Second)
@@ -440,6 +534,90 @@ func (c *SpacesClient) invalidateCDNCache(ctx context.Context, files []string) e
 	return c.doAPIClient.FlushCDNCache(ctx, c.cdnInfo.ID, files)
 }
 
+// maxCDNFlushFiles is the most paths the DigitalOcean API accepts in one CDN
+// cache flush
+const maxCDNFlushFiles = 50
+
+// BulkInvalidateCache flushes the CDN cache for many paths in batches of up
+// to maxCDNFlushFiles, instead of one API call per file. Up to the configured
+// number of flushes run at once, and each failed flush is retried with
+// backoff. It does nothing without a CDN. The error lists the batches that
+// still failed; the others were flushed.
+func (c *SpacesClient) BulkInvalidateCache(ctx context.Context, paths []string) error {
+	if c.cdnInfo == nil || len(paths) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(paths))
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = sanitizePath(path)
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, path)
+	}
+
+	var batches [][]string
+	for start := 0; start < len(files); start += maxCDNFlushFiles {
+		batches = append(batches, files[start:min(start+maxCDNFlushFiles, len(files))])
+	}
+
+	sem := make(chan struct{}, max(c.maxConcurrentFlushes, 1))
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			if err := c.flushWithRetry(ctx, batch); err != nil {
+				errs[i] = fmt.Errorf("flushing %d paths from %s: %w", len(batch), batch[0], err)
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// flushWithRetry flushes one batch of paths, retrying failures as the
+// client's retry configuration allows
+func (c *SpacesClient) flushWithRetry(ctx context.Context, files []string) error {
+	retries := 0
+	delay := time.Duration(0)
+	if c.retryConfig != nil {
+		retries = c.retryConfig.MaxRetries
+		delay = c.retryConfig.InitialDelay
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = c.doAPIClient.FlushCDNCache(ctx, c.cdnInfo.ID, files); err == nil || attempt >= retries {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = time.Duration(float64(delay) * c.retryConfig.BackoffFactor)
+		if c.retryConfig.MaxDelay > 0 && delay > c.retryConfig.MaxDelay {
+			delay = c.retryConfig.MaxDelay
+		}
+	}
+}
+
 // updateUploadMetrics updates upload-related metrics
 func (c *SpacesClient) updateUploadMetrics(startTime time.Time, result *storage.UploadResult, err error) {
 	duration := time.Since(startTime)
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -103,6 +106,11 @@ func (m *MockS3Client) List(ctx context.Context, bucket, prefix string, maxKeys
 	return args.Get(0).([]*storage.StorageObject), args.Error(1)
 }
 
+func (m *MockS3Client) ListPage(ctx context.Context, bucket, prefix, continuationToken string, maxKeys int) (*storage.ListPage, error) {
+	args := m.Called(ctx, bucket, prefix, continuationToken, maxKeys)
+	return args.Get(0).(*storage.ListPage), args.Error(1)
+}
+
 func (m *MockS3Client) GetPublicURL(bucket, key string, useSSL bool) string {
 	args := m.Called(bucket, key, useSSL)
 	return args.String(0)
@@ -169,6 +177,7 @@ func createSpacesClientWithMocks(t *testing.T) (*SpacesClient, *MockDOAPIClient,
 
 		maxConcurrentUploads:   cfg.Performance.MaxConcurrentUploads,
 		maxConcurrentDownloads: cfg.Performance.MaxConcurrentDownloads,
+		maxConcurrentFlushes:   cfg.Performance.MaxConcurrentCDNFlushes,
 
 		retryConfig: &RetryConfig{
 			MaxRetries:    cfg.Health.MaxRetries,
@@ -461,6 +470,148 @@ func TestSpacesClient_GetURL(t *testing.T) {
 	})
 }
 
+func TestSpacesClient_BulkInvalidateCache(t *testing.T) {
+	var _ storage.CacheInvalidator = (*SpacesClient)(nil)
+
+	newClient := func(t *testing.T) (*SpacesClient, *MockDOAPIClient) {
+		client, mockDOAPI, _ := createSpacesClientWithMocks(t)
+		client.cdnInfo = &CDNInfo{ID: "test-cdn-id", Endpoint: "test-bucket.nyc3.cdn.digitaloceanspaces.com"}
+		client.retryConfig = &RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, BackoffFactor: 2}
+		return client, mockDOAPI
+	}
+	pathsN := func(n int) []string {
+		paths := make([]string, n)
+		for i := range paths {
+			paths[i] = fmt.Sprintf("/documents/doc-%03d.pdf", i)
+		}
+		return paths
+	}
+
+	t.Run("skips flushing without a CDN", func(t *testing.T) {
+		client, mockDOAPI, _ := createSpacesClientWithMocks(t)
+
+		assert.NoError(t, client.BulkInvalidateCache(context.Background(), pathsN(3)))
+		mockDOAPI.AssertNotCalled(t, "FlushCDNCache", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("flushes paths in batches of the API limit", func(t *testing.T) {
+		client, mockDOAPI := newClient(t)
+		mockDOAPI.On("FlushCDNCache", mock.Anything, "test-cdn-id", mock.Anything).Return(nil)
+
+		// Duplicates are flushed once
+		paths := append(pathsN(120), "documents/doc-000.pdf")
+		assert.NoError(t, client.BulkInvalidateCache(context.Background(), paths))
+
+		mockDOAPI.AssertNumberOfCalls(t, "FlushCDNCache", 3)
+		var sizes []int
+		flushed := make(map[string]bool)
+		for _, call := range mockDOAPI.Calls {
+			files := call.Arguments.Get(2).([]string)
+			sizes = append(sizes, len(files))
+			for _, file := range files {
+				flushed[file] = true
+			}
+		}
+		assert.ElementsMatch(t, []int{50, 50, 20}, sizes)
+		assert.Len(t, flushed, 120)
+		assert.True(t, flushed["documents/doc-000.pdf"])
+	})
+
+	t.Run("retries a failed batch", func(t *testing.T) {
+		client, mockDOAPI := newClient(t)
+		mockDOAPI.On("FlushCDNCache", mock.Anything, "test-cdn-id", mock.Anything).Return(fmt.Errorf("429 too many requests")).Once()
+		mockDOAPI.On("FlushCDNCache", mock.Anything, "test-cdn-id", mock.Anything).Return(nil)
+
+		assert.NoError(t, client.BulkInvalidateCache(context.Background(), pathsN(10)))
+		mockDOAPI.AssertNumberOfCalls(t, "FlushCDNCache", 2)
+	})
+
+	t.Run("reports batches that keep failing", func(t *testing.T) {
+		client, mockDOAPI := newClient(t)
+		mockDOAPI.On("FlushCDNCache", mock.Anything, "test-cdn-id", mock.Anything).Return(fmt.Errorf("503 service unavailable"))
+
+		err := client.BulkInvalidateCache(context.Background(), pathsN(60))
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "flushing 50 paths")
+		assert.Contains(t, err.Error(), "flushing 10 paths")
+		// Each of the two batches is sent once and retried twice
+		mockDOAPI.AssertNumberOfCalls(t, "FlushCDNCache", 6)
+	})
+}
+
+func TestSpacesClient_WarmCDN(t *testing.T) {
+	// newCDN serves HEAD requests with the given status and records the paths
+	newCDN := func(t *testing.T, status int) (*SpacesClient, *[]string) {
+		var mu sync.Mutex
+		var requested []string
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodHead, r.Method)
+			mu.Lock()
+			requested = append(requested, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+
+		client, _, _ := createSpacesClientWithMocks(t)
+		client.cdnInfo = &CDNInfo{
+			ID:       "test-cdn-id",
+			Endpoint: strings.TrimPrefix(server.URL, "https://"),
+		}
+		client.cdnHTTPClient = server.Client()
+		client.cdnHealthState.LastHealthCheck = time.Now() // Skip the API health check
+		return client, &requested
+	}
+
+	t.Run("skips warming without a CDN", func(t *testing.T) {
+		client, _, _ := createSpacesClientWithMocks(t)
+
+		assert.NoError(t, client.WarmCDN(context.Background(), []string{"documents/template.pdf"}))
+	})
+
+	t.Run("sends a HEAD request per path", func(t *testing.T) {
+		client, requested := newCDN(t, http.StatusOK)
+
+		err := client.WarmCDN(context.Background(), []string{"/documents/template.pdf", "documents/notice.docx"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/documents/template.pdf", "/documents/notice.docx"}, *requested)
+	})
+
+	t.Run("skips warming while the circuit breaker is open", func(t *testing.T) {
+		client, requested := newCDN(t, http.StatusOK)
+		client.cdnHealthState.CircuitBreakerOpen = true
+		client.cdnHealthState.LastFailure = time.Now()
+
+		err := client.WarmCDN(context.Background(), []string{"documents/template.pdf"})
+
+		assert.Error(t, err)
+		assert.Empty(t, *requested)
+	})
+
+	t.Run("server errors open the circuit breaker", func(t *testing.T) {
+		client, requested := newCDN(t, http.StatusBadGateway)
+		paths := []string{"a.pdf", "b.pdf", "c.pdf", "d.pdf", "e.pdf"}
+
+		err := client.WarmCDN(context.Background(), paths)
+
+		assert.Error(t, err)
+		assert.Len(t, *requested, client.cdnHealthState.MaxConsecutiveFailures)
+		assert.True(t, client.cdnHealthState.CircuitBreakerOpen)
+	})
+
+	t.Run("missing objects do not count against the CDN", func(t *testing.T) {
+		client, _ := newCDN(t, http.StatusNotFound)
+
+		err := client.WarmCDN(context.Background(), []string{"a.pdf", "b.pdf", "c.pdf"})
+
+		assert.Error(t, err)
+		assert.Zero(t, client.cdnHealthState.ConsecutiveFailures)
+		assert.False(t, client.cdnHealthState.CircuitBreakerOpen)
+	})
+}
+
 func TestSpacesClient_IsHealthy(t *testing.T) {
 	t.Run("returns healthy when S3 is healthy", func(t *testing.T) {
 		client, _, mockS3 := createSpacesClientWithMocks(t)
@@ -0,0 +1,118 @@
+// Package manifest writes the run manifests of the classifier commands: a row
+// per document outcome, appended to a CSV or JSON lines file as a run goes.
+package manifest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Columns is the CSV header, in the order of Entry
+var Columns = []string{"path", "status", "document_type", "confidence", "indexed", "error", "processed_at"}
+
+// Entry is one document's row in a run manifest
+type Entry struct {
+	Path         string    `json:"path"`
+	Status       string    `json:"status"`
+	DocumentType string    `json:"document_type,omitempty"`
+	Confidence   float64   `json:"confidence,omitempty"`
+	Indexed      bool      `json:"indexed"`
+	Error        string    `json:"error,omitempty"`
+	ProcessedAt  time.Time `json:"processed_at"`
+}
+
+// Writer appends rows to a manifest file, so a crashed run still leaves the
+// rows written so far. It is safe for concurrent use, and a nil Writer
+// records nothing.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	csv  *csv.Writer   // Set for .csv files
+	json *json.Encoder // Set for every other file
+}
+
+// Open opens path for appending, writing the CSV header when the file is new
+// or empty. Files ending in .csv are written as CSV, anything else as JSON
+// lines.
+func Open(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+
+	w := &Writer{file: file}
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		w.json = json.NewEncoder(file)
+		return w, nil
+	}
+
+	w.csv = csv.NewWriter(file)
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat manifest: %w", err)
+	}
+	if info.Size() == 0 {
+		w.csv.Write(Columns)
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write manifest header: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// Record writes one row and hands it to the operating system before
+// returning, so it survives the process crashing. A zero ProcessedAt is
+// recorded as now.
+func (w *Writer) Record(entry Entry) error {
+	if w == nil {
+		return nil
+	}
+	if entry.ProcessedAt.IsZero() {
+		entry.ProcessedAt = time.Now()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.json != nil {
+		return w.json.Encode(entry)
+	}
+
+	confidence := ""
+	if entry.DocumentType != "" {
+		confidence = strconv.FormatFloat(entry.Confidence, 'f', -1, 64)
+	}
+	indexed := "n"
+	if entry.Indexed {
+		indexed = "y"
+	}
+	w.csv.Write([]string{
+		entry.Path,
+		entry.Status,
+		entry.DocumentType,
+		confidence,
+		indexed,
+		entry.Error,
+		entry.ProcessedAt.UTC().Format(time.RFC3339),
+	})
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Close closes the manifest file
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.csv")
+	processed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	w, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, w.Record(Entry{Path: "cases/a.pdf", Status: "success", DocumentType: "motion", Confidence: 0.92, Indexed: true, ProcessedAt: processed}))
+
+	// Rows are on disk before the manifest is closed, so a crash keeps them
+	rows := readCSV(t, path)
+	require.Len(t, rows, 2)
+	assert.Equal(t, Columns, rows[0])
+	assert.Equal(t, []string{"cases/a.pdf", "success", "motion", "0.92", "y", "", "2024-03-01T12:00:00Z"}, rows[1])
+	require.NoError(t, w.Close())
+
+	// Reopening appends without a second header
+	w, err = Open(path)
+	require.NoError(t, err)
+	require.NoError(t, w.Record(Entry{Path: "cases/b.pdf", Status: "failed", Error: "download failed, HTTP 404", ProcessedAt: processed}))
+	require.NoError(t, w.Close())
+
+	rows = readCSV(t, path)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"cases/b.pdf", "failed", "", "", "n", "download failed, HTTP 404", "2024-03-01T12:00:00Z"}, rows[2])
+}
+
+func TestWriter_JSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+
+	w, err := Open(path)
+	require.NoError(t, err)
+	before := time.Now()
+	require.NoError(t, w.Record(Entry{Path: "cases/a.pdf", Status: "skipped"}))
+	require.NoError(t, w.Record(Entry{Path: "cases/b.pdf", Status: "success", DocumentType: "order", Confidence: 0.8}))
+	require.NoError(t, w.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, entries, 2)
+	assert.Equal(t, "skipped", entries[0].Status)
+	assert.False(t, entries[0].ProcessedAt.Before(before.Truncate(time.Second)), "a zero time is recorded as now")
+	assert.Equal(t, "order", entries[1].DocumentType)
+}
+
+func TestWriter_ConcurrentRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.csv")
+	w, err := Open(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, w.Record(Entry{Path: fmt.Sprintf("cases/%02d.pdf", i), Status: "success"}))
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, w.Close())
+
+	assert.Len(t, readCSV(t, path), 51, "every row is written whole")
+}
+
+func TestWriter_Nil(t *testing.T) {
+	var w *Writer
+	assert.NoError(t, w.Record(Entry{Path: "cases/a.pdf"}))
+	assert.NoError(t, w.Close())
+
+	_, err := Open(filepath.Join(t.TempDir(), "missing", "run.csv"))
+	assert.Error(t, err)
+}
+
+// readCSV reads every row of a CSV manifest
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
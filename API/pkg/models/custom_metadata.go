@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits for caller-supplied custom metadata
+const (
+	MaxCustomMetadataFields      = 20
+	MaxCustomMetadataValueLength = 256
+)
+
+// customMetadataKeyPattern keeps keys usable as OpenSearch sub-field paths
+var customMetadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// reservedMetadataKeys are the JSON names of the built-in document and
+// metadata fields. Custom keys may not reuse them, so a custom "judge" can't
+// be mistaken for the classified one in filters or exports.
+var reservedMetadataKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	for _, t := range []reflect.Type{reflect.TypeOf(Document{}), reflect.TypeOf(DocumentMetadata{})} {
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name != "" && name != "-" {
+				keys[name] = true
+			}
+		}
+	}
+	return keys
+}()
+
+// ParseCustomMetadata decodes a JSON object of custom metadata. Values may be
+// strings, numbers or booleans and are stored as strings; nested objects and
+// arrays are rejected. An empty input returns nil.
+func ParseCustomMetadata(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var decoded map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("custom_metadata must be a JSON object: %w", err)
+	}
+
+	custom := make(map[string]string, len(decoded))
+	for key, value := range decoded {
+		switch v := value.(type) {
+		case string:
+			custom[key] = v
+		case json.Number:
+			custom[key] = v.String()
+		case bool:
+			custom[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("custom_metadata value for %q must be a string, number or boolean", key)
+		}
+	}
+
+	if err := ValidateCustomMetadata(custom); err != nil {
+		return nil, err
+	}
+	return custom, nil
+}
+
+// ValidateCustomMetadata checks custom metadata keys and values against the
+// naming rules and size limits
+func ValidateCustomMetadata(custom map[string]string) error {
+	if len(custom) > MaxCustomMetadataFields {
+		return fmt.Errorf("custom_metadata has %d fields, maximum is %d", len(custom), MaxCustomMetadataFields)
+	}
+	for key, value := range custom {
+		if err := ValidateCustomMetadataKey(key); err != nil {
+			return err
+		}
+		if len(value) > MaxCustomMetadataValueLength {
+			return fmt.Errorf("custom_metadata value for %q exceeds %d characters", key, MaxCustomMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+// ValidateCustomMetadataKey checks that a key is well formed and does not
+// shadow a built-in metadata field
+func ValidateCustomMetadataKey(key string) error {
+	if !customMetadataKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid custom_metadata key %q: use lowercase letters, digits and underscores, starting with a letter", key)
+	}
+	if reservedMetadataKeys[key] {
+		return fmt.Errorf("custom_metadata key %q conflicts with a built-in metadata field", key)
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Document represents a legal document in the search index
 type Document struct {
@@ -8,6 +12,7 @@ type Document struct {
 	FileName    string            `json:"file_name"`
 	FilePath    string            `json:"file_path"`
 	FileURL     string            `json:"file_url,omitempty"`
+	CDNURL      string            `json:"cdn_url,omitempty"` // Rebuilt from FilePath when served, so it follows CDN changes
 	S3URI       string            `json:"s3_uri,omitempty"`
 	Text        string            `json:"text"`
 	DocType     string            `json:"doc_type"`
@@ -19,18 +24,143 @@ type Document struct {
 	Size        int64             `json:"size,omitempty"`
 	ContentType string            `json:"content_type,omitempty"`
 
+	// Pages is the per-page text, indexed as nested objects so searches can
+	// report matching page numbers. Only populated when page extraction was requested.
+	Pages []DocumentPage `json:"pages,omitempty"`
+
+	// Classification is the classifier's result exactly as it was returned,
+	// including fields the metadata mapping drops. It is stored for review
+	// and debugging but not indexed.
+	Classification json.RawMessage `json:"classification,omitempty"`
+
+	// Redactions is the latest redaction analysis of the document, kept so a
+	// reviewer can overlay it on the original PDF. Stored but not indexed.
+	Redactions *RedactionAnalysis `json:"redactions,omitempty"`
+
+	// RedactionAudit records every time redactions were applied to the
+	// document, oldest first. Records are only ever appended. Stored but not
+	// indexed.
+	RedactionAudit []RedactionAuditRecord `json:"redaction_audit,omitempty"`
+
+	// MetadataHistory holds the past and current values of effective-dated
+	// metadata fields, indexed as nested objects for as-of searches. The
+	// current values are also kept in Metadata for fast filtering.
+	MetadataHistory []MetadataAmendment `json:"metadata_history,omitempty"`
+
+	// MetadataVersions records each metadata update of the document, single
+	// or bulk, oldest first, with the values it replaced. Stored but not
+	// indexed. Effective-dated fields are kept in MetadataHistory instead.
+	MetadataVersions []MetadataVersion `json:"metadata_versions,omitempty"`
+
 	// For backward compatibility with tests
 	Title   string `json:"title,omitempty"`
 	Content string `json:"content,omitempty"`
 }
 
+// SetClassification stores the classifier result as the document's Classification
+func (d *Document) SetClassification(result interface{}) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode classification result: %w", err)
+	}
+	d.Classification = raw
+	return nil
+}
+
+// RedactionAnalysis is the result of a redaction run stored with a document
+type RedactionAnalysis struct {
+	Items           []Redaction `json:"items"`
+	TotalCount      int         `json:"total_count"`
+	CoordinateSpace string      `json:"coordinate_space"` // Units and origin of BBox, e.g. "pdf_points"
+	AnalyzedAt      time.Time   `json:"analyzed_at"`
+}
+
+// Redaction is one region found by redaction analysis. Applied reports
+// whether it was blacked out in a redacted copy or only flagged.
+type Redaction struct {
+	ID        string    `json:"id"`
+	Page      int       `json:"page"`
+	Text      string    `json:"text"`
+	BBox      []float64 `json:"bbox"` // [x0, y0, x1, y1]
+	Type      string    `json:"type"`
+	Citation  string    `json:"citation,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	LegalCode string    `json:"legal_code,omitempty"`
+	Applied   bool      `json:"applied"`
+}
+
+// RedactionAuditRecord records one application of redactions to a document
+type RedactionAuditRecord struct {
+	Items      []Redaction `json:"items"`
+	TotalCount int         `json:"total_count"`
+	LegalCodes []string    `json:"legal_codes,omitempty"` // Distinct legal codes of the items, in order of appearance
+	Operator   string      `json:"operator,omitempty"`    // Email or subject of the JWT, when the request had one
+	AppliedAt  time.Time   `json:"applied_at"`
+}
+
+// MetadataVersion records one change to a document's metadata
+type MetadataVersion struct {
+	Values    map[string]interface{} `json:"values"`             // Fields set by the change, by path
+	Removed   []string               `json:"removed,omitempty"`  // Fields unset by the change, by path
+	Previous  map[string]interface{} `json:"previous,omitempty"` // Values of those fields before the change, where they had one
+	Operator  string                 `json:"operator,omitempty"` // Email or subject of the JWT, when the request had one
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+// DocumentPage is the text of one page of a document
+type DocumentPage struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// ReviewStatusIncompleteMetadata marks a document that lacks metadata its
+// document type requires, such as an order without a judge
+const ReviewStatusIncompleteMetadata = "incomplete_metadata"
+
+// ReviewStatusLowConfidence marks a document whose classification fell below
+// the confidence threshold for its document type
+const ReviewStatusLowConfidence = "low_confidence"
+
+// Source systems recorded on documents whose caller did not name one
+const (
+	SourceSystemUpload         = "upload"
+	SourceSystemBatchUpload    = "batch-upload"
+	SourceSystemBatchProcessor = "batch-processor"
+)
+
+// Readability holds text complexity metrics computed from the extracted
+// text. FleschReadingEase is only set for English documents.
+type Readability struct {
+	FleschReadingEase     *float64 `json:"flesch_reading_ease,omitempty"`
+	AverageSentenceLength float64  `json:"average_sentence_length"` // Words per sentence
+	AverageWordLength     float64  `json:"average_word_length"`     // Letters per word
+	SentenceCount         int      `json:"sentence_count"`
+	Language              string   `json:"language,omitempty"`
+}
+
+// EmailMetadata holds the headers of an email document (.eml or .msg).
+// Addresses are formatted as "Name <address>".
+type EmailMetadata struct {
+	From        string     `json:"from,omitempty"`
+	To          []string   `json:"to,omitempty"`
+	Cc          []string   `json:"cc,omitempty"`
+	SentDate    *time.Time `json:"sent_date,omitempty"`
+	Subject     string     `json:"subject,omitempty"`
+	MessageID   string     `json:"message_id,omitempty"`
+	Attachments []string   `json:"attachments,omitempty"` // Attachment file names
+	// Text extracted from attachments in a supported format, for search
+	AttachmentText string `json:"attachment_text,omitempty"`
+}
+
 // DocumentMetadata contains comprehensive legal-specific metadata
 type DocumentMetadata struct {
 	// Basic Information
-	DocumentName string       `json:"document_name"`
-	Subject      string       `json:"subject"`
-	Summary      string       `json:"summary,omitempty"` // Enhanced legal summary
-	DocumentType DocumentType `json:"document_type"`
+	DocumentName     string       `json:"document_name"`
+	OriginalFileName string       `json:"original_file_name,omitempty"` // Filename as uploaded, for display and downloads
+	Title            string       `json:"title,omitempty"`              // Heading taken from the document itself, unlike the AI-written Subject and Summary
+	Subject          string       `json:"subject"`
+	Summary          string       `json:"summary,omitempty"` // Enhanced legal summary
+	DocumentType     DocumentType `json:"document_type"`
 
 	// Case Information
 	Case *CaseInfo `json:"case,omitempty"`
@@ -44,13 +174,14 @@ type DocumentMetadata struct {
 	Judge     *Judge     `json:"judge,omitempty"`
 
 	// Dates & Status - Enhanced date fields for legal documents
-	FilingDate   *time.Time `json:"filing_date,omitempty"`   // When document was filed with court
-	EventDate    *time.Time `json:"event_date,omitempty"`    // Key event or action date
-	HearingDate  *time.Time `json:"hearing_date,omitempty"`  // Scheduled court hearing date
-	DecisionDate *time.Time `json:"decision_date,omitempty"` // When court decision was made
-	ServedDate   *time.Time `json:"served_date,omitempty"`   // When documents were served
-	Timestamp    *time.Time `json:"timestamp,omitempty"`     // For backward compatibility
-	Status       string     `json:"status,omitempty"`
+	FilingDate    *time.Time `json:"filing_date,omitempty"`    // When document was filed with court
+	EventDate     *time.Time `json:"event_date,omitempty"`     // Key event or action date
+	HearingDate   *time.Time `json:"hearing_date,omitempty"`   // Scheduled court hearing date
+	DecisionDate  *time.Time `json:"decision_date,omitempty"`  // When court decision was made
+	ServedDate    *time.Time `json:"served_date,omitempty"`    // When documents were served
+	SignatureDate *time.Time `json:"signature_date,omitempty"` // When document was signed/executed (latest signature)
+	Timestamp     *time.Time `json:"timestamp,omitempty"`      // For backward compatibility
+	Status        string     `json:"status,omitempty"`
 
 	// Document Properties
 	Language  string `json:"language,omitempty"`
@@ -62,6 +193,35 @@ type DocumentMetadata struct {
 	Charges     []Charge    `json:"charges,omitempty"`
 	Authorities []Authority `json:"authorities,omitempty"`
 
+	// Cross-references, populated when reference extraction is enabled
+	Links      []string `json:"links,omitempty"`      // Normalized hyperlinks found in the document
+	References []string `json:"references,omitempty"` // Statutory and rule citations, e.g. "42 U.S.C. § 1983"
+
+	// Extraction quality; low-quality documents are kept but flagged for review
+	ExtractionQuality    float64 `json:"extraction_quality,omitempty"`
+	LowQualityExtraction bool    `json:"low_quality_extraction,omitempty"`
+
+	// Metadata completeness; documents missing fields their type requires are
+	// kept but marked ReviewStatusIncompleteMetadata for curators to complete,
+	// and those classified with too little confidence ReviewStatusLowConfidence
+	ReviewStatus  string   `json:"review_status,omitempty"`
+	MissingFields []string `json:"missing_fields,omitempty"`
+
+	// Provenance: the system that ingested the document, such as an upload,
+	// a bulk import or a court feed, and the batch or job it arrived in
+	SourceSystem     string `json:"source_system,omitempty"`
+	IngestionBatchID string `json:"ingestion_batch_id,omitempty"`
+
+	// Text complexity metrics, computed when the upload asks for them
+	Readability *Readability `json:"readability,omitempty"`
+
+	// Sender, recipients and attachments of email documents
+	Email *EmailMetadata `json:"email,omitempty"`
+
+	// Caller-supplied key-value pairs such as a client matter number, kept
+	// apart from the built-in fields (see ValidateCustomMetadata)
+	Custom map[string]string `json:"custom,omitempty"`
+
 	// Processing Metadata
 	ProcessedAt  time.Time `json:"processed_at"`
 	Confidence   float64   `json:"confidence,omitempty"`
@@ -187,7 +347,7 @@ func NewDocumentMetadata() *DocumentMetadata {
 		ProcessedAt:  time.Now(),
 		AIClassified: false,
 		DocumentType: DocTypeUnknown,
-		Language:     "en",
+		Language:     "unknown",
 		LegalTags:    make([]string, 0),
 		Parties:      make([]Party, 0),
 		Attorneys:    make([]Attorney, 0),
@@ -219,6 +379,10 @@ func GetDocumentMapping() map[string]interface{} {
 					"type":  "keyword",
 					"index": false,
 				},
+				"cdn_url": map[string]interface{}{
+					"type":  "keyword",
+					"index": false,
+				},
 				"s3_uri": map[string]interface{}{
 					"type":  "keyword",
 					"index": false,
@@ -227,6 +391,54 @@ func GetDocumentMapping() map[string]interface{} {
 					"type":     "text",
 					"analyzer": "legal_analyzer",
 				},
+				"classification": map[string]interface{}{
+					"type":    "object",
+					"enabled": false,
+				},
+				"redactions": map[string]interface{}{
+					"type":    "object",
+					"enabled": false,
+				},
+				"redaction_audit": map[string]interface{}{
+					"type":    "object",
+					"enabled": false,
+				},
+				"metadata_versions": map[string]interface{}{
+					"type":    "object",
+					"enabled": false,
+				},
+				"pages": map[string]interface{}{
+					"type": "nested",
+					"properties": map[string]interface{}{
+						"number": map[string]interface{}{
+							"type": "integer",
+						},
+						"text": map[string]interface{}{
+							"type":     "text",
+							"analyzer": "legal_analyzer",
+						},
+					},
+				},
+				"metadata_history": map[string]interface{}{
+					"type": "nested",
+					"properties": map[string]interface{}{
+						"field": map[string]interface{}{
+							"type": "keyword",
+						},
+						"value": map[string]interface{}{
+							"type": "keyword",
+						},
+						"effective_from": map[string]interface{}{
+							"type": "date",
+						},
+						"effective_to": map[string]interface{}{
+							"type": "date",
+						},
+						"recorded_at": map[string]interface{}{
+							"type": "date",
+						},
+					},
+				},
 				"doc_type": map[string]interface{}{
 					"type": "keyword",
 				},
@@ -299,6 +511,23 @@ func getMetadataMapping() map[string]interface{} {
 					},
 				},
 			},
+			"original_file_name": map[string]interface{}{
+				"type": "text",
+				"fields": map[string]interface{}{
+					"keyword": map[string]interface{}{
+						"type": "keyword",
+					},
+				},
+			},
+			"title": map[string]interface{}{
+				"type":     "text",
+				"analyzer": "legal_analyzer",
+				"fields": map[string]interface{}{
+					"keyword": map[string]interface{}{
+						"type": "keyword",
+					},
+				},
+			},
 			"subject": map[string]interface{}{
 				"type":     "text",
 				"analyzer": "legal_analyzer",
@@ -328,6 +557,9 @@ func getMetadataMapping() map[string]interface{} {
 			"served_date": map[string]interface{}{
 				"type": "date",
 			},
+			"signature_date": map[string]interface{}{
+				"type": "date",
+			},
 			"processed_at": map[string]interface{}{
 				"type": "date",
 			},
@@ -337,6 +569,31 @@ func getMetadataMapping() map[string]interface{} {
 			"ai_classified": map[string]interface{}{
 				"type": "boolean",
 			},
+			"extraction_quality": map[string]interface{}{
+				"type": "float",
+			},
+			"low_quality_extraction": map[string]interface{}{
+				"type": "boolean",
+			},
+			"review_status": map[string]interface{}{
+				"type": "keyword",
+			},
+			"missing_fields": map[string]interface{}{
+				"type": "keyword",
+			},
+			"source_system": map[string]interface{}{
+				"type": "keyword",
+			},
+			"ingestion_batch_id": map[string]interface{}{
+				"type": "keyword",
+			},
+			"readability": getReadabilityMapping(),
+			"email":       getEmailMapping(),
+			// flat_object indexes every custom key as a keyword sub-field
+			// without adding a mapping per key
+			"custom": map[string]interface{}{
+				"type": "flat_object",
+			},
 			"case":        getCaseMapping(),
 			"court":       getCourtMapping(),
 			"parties":     getPartiesMapping(),
@@ -347,6 +604,17 @@ func getMetadataMapping() map[string]interface{} {
 			"legal_tags": map[string]interface{}{
 				"type": "keyword",
 			},
+			"links": map[string]interface{}{
+				"type": "keyword",
+			},
+			"references": map[string]interface{}{
+				"type": "text",
+				"fields": map[string]interface{}{
+					"keyword": map[string]interface{}{
+						"type": "keyword",
+					},
+				},
+			},
 			"language": map[string]interface{}{
 				"type": "keyword",
 			},
@@ -444,6 +712,9 @@ func getPartiesMapping() map[string]interface{} {
 			"role": map[string]interface{}{
 				"type": "keyword",
 			},
+			"raw_role": map[string]interface{}{
+				"type": "keyword",
+			},
 			"party_type": map[string]interface{}{
 				"type": "keyword",
 			},
@@ -478,6 +749,59 @@ func getAttorneysMapping() map[string]interface{} {
 	}
 }
 
+func getReadabilityMapping() map[string]interface{} {
+	return map[string]interface{}{
+		"properties": map[string]interface{}{
+			"flesch_reading_ease": map[string]interface{}{
+				"type": "float",
+			},
+			"average_sentence_length": map[string]interface{}{
+				"type": "float",
+			},
+			"average_word_length": map[string]interface{}{
+				"type": "float",
+			},
+			"sentence_count": map[string]interface{}{
+				"type": "integer",
+			},
+			"language": map[string]interface{}{
+				"type": "keyword",
+			},
+		},
+	}
+}
+
+func getEmailMapping() map[string]interface{} {
+	return map[string]interface{}{
+		"properties": map[string]interface{}{
+			"from": map[string]interface{}{
+				"type": "keyword",
+			},
+			"to": map[string]interface{}{
+				"type": "keyword",
+			},
+			"cc": map[string]interface{}{
+				"type": "keyword",
+			},
+			"sent_date": map[string]interface{}{
+				"type": "date",
+			},
+			"subject": map[string]interface{}{
+				"type": "text",
+			},
+			"message_id": map[string]interface{}{
+				"type": "keyword",
+			},
+			"attachments": map[string]interface{}{
+				"type": "keyword",
+			},
+			"attachment_text": map[string]interface{}{
+				"type": "text",
+			},
+		},
+	}
+}
+
 func getJudgeMapping() map[string]interface{} {
 	return map[string]interface{}{
 		"properties": map[string]interface{}{
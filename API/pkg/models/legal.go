@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
 
 // CaseInfo contains detailed case information
 type CaseInfo struct {
@@ -12,6 +17,38 @@ type CaseInfo struct {
 	NatureOfSuit string `json:"nature_of_suit,omitempty"`
 }
 
+var (
+	docketLabelPattern     = regexp.MustCompile(`(?i)^(?:(?:case|docket|dkt|cause|index)\.?[\s:#]+)?(?:(?:no|num|number)[.:#\s]+|#[\s:]*)?`)
+	docketSeparatorPattern = regexp.MustCompile(`[\s_\x{2010}-\x{2015}]+`)
+	docketDashRunPattern   = regexp.MustCompile(`-{2,}`)
+)
+
+// NormalizeDocketNumber puts a docket number into the canonical form used for
+// indexing and filtering: labels such as "Case No." are dropped, letters are
+// upper-cased and whitespace or dash variants become a single hyphen, so
+// "Case No. 2:23 cv 01234" and "2:23-CV-01234" compare equal.
+func NormalizeDocketNumber(raw string) string {
+	docket := strings.TrimSpace(raw)
+	docket = docketLabelPattern.ReplaceAllString(docket, "")
+	docket = strings.ToUpper(docket)
+	docket = docketSeparatorPattern.ReplaceAllString(docket, "-")
+	docket = docketDashRunPattern.ReplaceAllString(docket, "-")
+	return strings.Trim(docket, "-.,;: ")
+}
+
+// SetDocket stores a normalized docket number on the metadata's case info,
+// creating the case info if needed. Empty values are ignored.
+func (m *DocumentMetadata) SetDocket(raw string) {
+	docket := NormalizeDocketNumber(raw)
+	if docket == "" {
+		return
+	}
+	if m.Case == nil {
+		m.Case = &CaseInfo{}
+	}
+	m.Case.Docket = docket
+}
+
 // CourtInfo contains detailed court information
 type CourtInfo struct {
 	CourtID      string `json:"court_id"`
@@ -26,11 +63,88 @@ type CourtInfo struct {
 // Party represents a party to the case
 type Party struct {
 	Name      string     `json:"name"`
-	Role      string     `json:"role"`                    // "defendant", "plaintiff", "appellant"
+	Role      string     `json:"role"`                    // Canonical role, one of the PartyRole constants
+	RawRole   string     `json:"raw_role,omitempty"`      // Role as written in the document, e.g. "Def." or "Defendant and Appellant"
 	PartyType string     `json:"party_type,omitempty"`    // "individual", "corporation", "government"
 	Date      *time.Time `json:"date,omitempty"`          // Date associated with this party
 }
 
+// Canonical party roles
+const (
+	PartyRolePlaintiff           = "plaintiff"
+	PartyRoleDefendant           = "defendant"
+	PartyRolePetitioner          = "petitioner"
+	PartyRoleRespondent          = "respondent"
+	PartyRoleAppellant           = "appellant"
+	PartyRoleAppellee            = "appellee"
+	PartyRoleIntervenor          = "intervenor"
+	PartyRoleRealPartyInInterest = "real_party_in_interest"
+	PartyRoleOther               = "other"
+)
+
+// partyRoleAliases maps lower-cased role words and abbreviations to canonical roles
+var partyRoleAliases = map[string]string{
+	"plaintiff": PartyRolePlaintiff, "plaintiffs": PartyRolePlaintiff, "pltf": PartyRolePlaintiff,
+	"pltff": PartyRolePlaintiff, "plf": PartyRolePlaintiff, "pl": PartyRolePlaintiff,
+	"complainant": PartyRolePlaintiff, "prosecution": PartyRolePlaintiff, "people": PartyRolePlaintiff,
+
+	"defendant": PartyRoleDefendant, "defendants": PartyRoleDefendant, "def": PartyRoleDefendant,
+	"deft": PartyRoleDefendant, "dft": PartyRoleDefendant, "defs": PartyRoleDefendant,
+	"accused": PartyRoleDefendant,
+
+	"petitioner": PartyRolePetitioner, "petitioners": PartyRolePetitioner, "pet": PartyRolePetitioner,
+	"petr": PartyRolePetitioner,
+
+	"respondent": PartyRoleRespondent, "respondents": PartyRoleRespondent, "resp": PartyRoleRespondent,
+	"respt": PartyRoleRespondent,
+
+	"appellant": PartyRoleAppellant, "appellants": PartyRoleAppellant, "aplt": PartyRoleAppellant,
+	"applt": PartyRoleAppellant,
+
+	"appellee": PartyRoleAppellee, "appellees": PartyRoleAppellee, "aple": PartyRoleAppellee,
+
+	"intervenor": PartyRoleIntervenor, "intervener": PartyRoleIntervenor, "intervenors": PartyRoleIntervenor,
+
+	"real party in interest": PartyRoleRealPartyInInterest, "real parties in interest": PartyRoleRealPartyInInterest,
+	"rpi": PartyRoleRealPartyInInterest,
+}
+
+// NormalizePartyRole maps a free-text party role to a canonical PartyRole
+// value. Abbreviations and plurals are folded ("Def.", "Pltf", "Respondents"),
+// and for compound appellate captions the last recognized role wins, so
+// "Defendant and Appellant" is an appellant and "Cross-Defendant" a defendant.
+// Unrecognized roles map to PartyRoleOther; empty roles stay empty.
+func NormalizePartyRole(raw string) string {
+	words := strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	if role, ok := partyRoleAliases[strings.Join(words, " ")]; ok {
+		return role
+	}
+
+	role := PartyRoleOther
+	for _, word := range words {
+		if canonical, ok := partyRoleAliases[word]; ok {
+			role = canonical
+		}
+	}
+	return role
+}
+
+// NewParty creates a party with a canonical role, keeping the role as written in RawRole
+func NewParty(name, role, partyType string) Party {
+	return Party{
+		Name:      name,
+		Role:      NormalizePartyRole(role),
+		RawRole:   strings.TrimSpace(role),
+		PartyType: partyType,
+	}
+}
+
 // Attorney represents legal counsel
 type Attorney struct {
 	Name         string `json:"name"`
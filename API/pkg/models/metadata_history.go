@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultEffectiveDatedFields are the metadata fields whose past values are
+// kept when no other list is configured
+const DefaultEffectiveDatedFields = "judge,court"
+
+// EffectiveDatableFields maps each metadata field that can be effective-dated
+// to the keyword field holding its current value
+var EffectiveDatableFields = map[string]string{
+	"judge":  "metadata.judge.name",
+	"court":  "metadata.court.court_name",
+	"status": "metadata.status",
+}
+
+// MetadataAmendment is a value a metadata field held over a period of time.
+// EffectiveFrom is nil for a value recorded before the field was amended,
+// which holds from the start; EffectiveTo is nil while the value is current.
+type MetadataAmendment struct {
+	Field         string     `json:"field"`
+	Value         string     `json:"value"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	RecordedAt    time.Time  `json:"recorded_at"`
+}
+
+// ParseEffectiveDatedFields parses a comma-separated list of field names,
+// each of which must be in EffectiveDatableFields
+func ParseEffectiveDatedFields(spec string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := EffectiveDatableFields[field]; !ok {
+			return nil, fmt.Errorf("field %q cannot be effective-dated", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// EffectiveDatedValue returns the current value of an effective-datable field
+func (dm *DocumentMetadata) EffectiveDatedValue(field string) string {
+	switch field {
+	case "judge":
+		return dm.GetJudgeName()
+	case "court":
+		return dm.GetCourtName()
+	case "status":
+		return dm.Status
+	}
+	return ""
+}
+
+// EffectiveDatedPath returns the metadata path, such as "court.court_name",
+// holding the value whose history an effective-datable field keeps
+func EffectiveDatedPath(field string) string {
+	switch field {
+	case "judge":
+		return "judge.name"
+	case "court":
+		return "court.court_name"
+	}
+	return field
+}
+
+// EffectiveDatedSource returns the value to store in a document's metadata
+// for an effective-datable field, in the shape the index maps it
+func EffectiveDatedSource(field, value string) interface{} {
+	switch field {
+	case "judge":
+		return &Judge{Name: value}
+	case "court":
+		return &CourtInfo{CourtName: value}
+	}
+	return value
+}
+
+// maxEffectiveDateSkew is how far past now an effective date may lie, for
+// clients whose clocks run a little ahead
+const maxEffectiveDateSkew = time.Minute
+
+// CheckEffectiveDate rejects effective dates in the future. The current value
+// of an effective-dated field is written when it is amended, so a value that
+// took effect later would never become current.
+func CheckEffectiveDate(effective, now time.Time) error {
+	if effective.After(now.Add(maxEffectiveDateSkew)) {
+		return fmt.Errorf("effective_date %s is in the future; record an amendment once it takes effect", effective.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// AmendMetadataHistory records that field took value from effective. A
+// field's first amendment also records the value it held before, so as-of
+// lookups for earlier dates still find it. An amendment with the same
+// effective date as an earlier one replaces it. The periods of the field's
+// values are recomputed so each ends where the next begins.
+func AmendMetadataHistory(history []MetadataAmendment, field, previous, value string, effective, now time.Time) []MetadataAmendment {
+	var others, entries []MetadataAmendment
+	for _, entry := range history {
+		if entry.Field != field {
+			others = append(others, entry)
+		} else if entry.EffectiveFrom == nil || !entry.EffectiveFrom.Equal(effective) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 && previous != "" && previous != value {
+		entries = append(entries, MetadataAmendment{Field: field, Value: previous, RecordedAt: now})
+	}
+	from := effective
+	entries = append(entries, MetadataAmendment{Field: field, Value: value, EffectiveFrom: &from, RecordedAt: now})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].EffectiveFrom == nil || entries[j].EffectiveFrom == nil {
+			return entries[i].EffectiveFrom == nil && entries[j].EffectiveFrom != nil
+		}
+		return entries[i].EffectiveFrom.Before(*entries[j].EffectiveFrom)
+	})
+	for i := range entries {
+		entries[i].EffectiveTo = nil
+		if i+1 < len(entries) {
+			entries[i].EffectiveTo = entries[i+1].EffectiveFrom
+		}
+	}
+
+	return append(others, entries...)
+}
+
+// MetadataValueAsOf returns the value field held at t according to history,
+// and false when the history has no value for that time
+func MetadataValueAsOf(history []MetadataAmendment, field string, t time.Time) (string, bool) {
+	for _, entry := range history {
+		if entry.Field != field {
+			continue
+		}
+		if entry.EffectiveFrom != nil && entry.EffectiveFrom.After(t) {
+			continue
+		}
+		if entry.EffectiveTo != nil && !entry.EffectiveTo.After(t) {
+			continue
+		}
+		return entry.Value, true
+	}
+	return "", false
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // SearchRequest represents a search query with legal-specific filters
 type SearchRequest struct {
@@ -8,24 +12,78 @@ type SearchRequest struct {
 	DocType           string             `json:"doc_type,omitempty"`
 	CaseNumber        string             `json:"case_number,omitempty"`
 	CaseName          string             `json:"case_name,omitempty"`
+	Docket            string             `json:"docket,omitempty"` // Normalized before matching metadata.case.docket
 	Judge             []string           `json:"judge,omitempty"`
 	Court             []string           `json:"court,omitempty"`
 	Author            string             `json:"author,omitempty"`
 	Status            string             `json:"status,omitempty"`
 	LegalTags         []string           `json:"legal_tags,omitempty"`
 	LegalTagsMatchAll bool               `json:"legal_tags_match_all"`
+	PartyRole         []string           `json:"party_role,omitempty"` // Normalized before matching metadata.parties.role
 	DateRange         *DateRange         `json:"date_range,omitempty"`
+
+	// Extraction quality filters; LowQualityExtraction=true lists documents awaiting review
+	MinExtractionQuality float64 `json:"min_extraction_quality,omitempty"`
+	LowQualityExtraction *bool   `json:"low_quality_extraction,omitempty"`
+
+	// Completeness filters; ReviewStatus "incomplete_metadata" lists documents
+	// missing fields their type requires, and MissingFields narrows to any of the named fields.
+	// ReviewStatus "low_confidence" lists documents classified below their type's threshold.
+	ReviewStatus  string   `json:"review_status,omitempty"`
+	MissingFields []string `json:"missing_fields,omitempty"`
+
+	// Provenance filters, e.g. every document from one court feed or one import run
+	SourceSystem     []string `json:"source_system,omitempty"`
+	IngestionBatchID string   `json:"ingestion_batch_id,omitempty"`
+
+	// Language matches the detected language of the text, e.g. ["es"];
+	// "unknown" lists documents whose language could not be detected
+	Language []string `json:"language,omitempty"`
+
+	// Flesch reading ease bounds; documents without a score, such as
+	// non-English ones, never match. Zero is a valid score, hence pointers.
+	MinReadingEase *float64 `json:"min_reading_ease,omitempty"`
+	MaxReadingEase *float64 `json:"max_reading_ease,omitempty"`
+
+	// AsOf matches Judge, Court and Status against the values the fields held
+	// at that time, for fields with effective-dated history
+	AsOf *time.Time `json:"as_of,omitempty"`
+
+	// CustomMetadata matches exact values of caller-supplied metadata keys
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	// Facets names the aggregations to count over the matching documents and
+	// return with the hits, such as "document_types" or "courts". Unknown
+	// names are ignored.
+	Facets []string `json:"facets,omitempty"`
+
 	Size              int                `json:"size" validate:"min=1,max=100"`
 	From              int                `json:"from" validate:"min=0"`
+
+	// SearchAfter pages by cursor rather than From, past the index's result
+	// window: an empty list for the first page, then the NextSearchAfter of
+	// the page before. Hits are sorted with a final tiebreaker on _id so no
+	// document is skipped or repeated. From must be 0.
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+
 	SortBy            string             `json:"sort_by,omitempty"`
 	SortOrder         string             `json:"sort_order,omitempty"`
 	IncludeHighlights bool               `json:"include_highlights"`
 	FuzzySearch       bool               `json:"fuzzy_search"`
+	Explain           bool               `json:"explain,omitempty"` // Return per-hit scoring explanations; debug mode only
+	DebugQuery        bool               `json:"debug_query,omitempty"` // Return the generated OpenSearch query; debug mode only
+	IncludePages      bool               `json:"include_pages,omitempty"` // Return the matching page numbers of documents indexed with page text
 	Filters           interface{}        `json:"filters,omitempty"` // Can be *Filters or map[string]interface{}
-	Sort              *SortOptions       `json:"sort,omitempty"`
+	Sort              []SortClause       `json:"sort,omitempty"` // Sorts by each clause in turn; SortBy and SortOrder apply when empty
+	DefaultSort       []SortOptions      `json:"-"` // Sort for empty-query searches without sort_by; DefaultBrowseSort when empty
 	Pagination        *PaginationOptions `json:"pagination,omitempty"`
 	Highlight         *HighlightOptions  `json:"highlight,omitempty"`
 	Limit             int                `json:"limit,omitempty"` // For backward compatibility with tests
+
+	// Highlight fragment caps set by the server; zero uses
+	// DefaultHighlightFragmentSize and DefaultHighlightFragments
+	HighlightFragmentSize int `json:"-"`
+	HighlightFragments    int `json:"-"`
 }
 
 // SearchResult represents the response from a search query
@@ -36,6 +94,16 @@ type SearchResult struct {
 	Aggregations map[string]interface{} `json:"aggregations,omitempty"`
 	Took         int64                  `json:"took_ms"`
 	TimedOut     bool                   `json:"timed_out"`
+
+	// GeneratedQuery is the OpenSearch query body, present when DebugQuery was requested
+	GeneratedQuery map[string]interface{} `json:"generated_query,omitempty"`
+
+	// Facets holds the buckets of each requested facet, keyed by facet name
+	Facets map[string][]AggregationBucket `json:"facets,omitempty"`
+
+	// NextSearchAfter is the cursor for the next page of a SearchAfter
+	// search: the sort values of the last hit. It is empty on the last page.
+	NextSearchAfter []interface{} `json:"next_search_after,omitempty"`
 }
 
 // SearchDocument represents a document in search results
@@ -44,6 +112,19 @@ type SearchDocument struct {
 	Score      float64                `json:"score,omitempty"`
 	Document   map[string]interface{} `json:"document"`
 	Highlights map[string][]string    `json:"highlights,omitempty"`
+
+	// Explanation is the OpenSearch scoring breakdown, present when Explain was requested
+	Explanation map[string]interface{} `json:"explanation,omitempty"`
+
+	// Pages lists the best-matching pages, best first, when IncludePages was requested
+	Pages []*PageMatch `json:"pages,omitempty"`
+}
+
+// PageMatch is a page of a document that matched the search query
+type PageMatch struct {
+	Number     int      `json:"number"`
+	Score      float64  `json:"score"`
+	Highlights []string `json:"highlights,omitempty"`
 }
 
 // TagCount represents a legal tag with its document count
@@ -74,6 +155,25 @@ type DocumentStats struct {
 	FieldStats     map[string]FieldStat `json:"field_stats"`
 }
 
+// CaseStats summarizes the documents filed in one case
+type CaseStats struct {
+	CaseNumber     string          `json:"case_number"`
+	TotalDocuments int64           `json:"total_documents"`
+	TypeCounts     []*TypeCount    `json:"type_counts"`
+	FirstFiling    *time.Time      `json:"first_filing,omitempty"`
+	LastFiling     *time.Time      `json:"last_filing,omitempty"`
+	Parties        []*FieldValue   `json:"parties"`   // Counted by documents naming the party
+	Attorneys      []*FieldValue   `json:"attorneys"` // Counted by documents naming the attorney
+	Judges         []*FieldValue   `json:"judges"`
+	Timeline       []*FilingPeriod `json:"timeline"` // Filings per month, oldest first
+}
+
+// FilingPeriod is the number of documents filed in one month
+type FilingPeriod struct {
+	Period string `json:"period"` // YYYY-MM
+	Count  int64  `json:"count"`
+}
+
 // FieldStat represents statistics for a specific field
 type FieldStat struct {
 	UniqueValues int64 `json:"unique_values"`
@@ -88,6 +188,16 @@ type FieldOptions struct {
 	LegalTags []*FieldValue `json:"legal_tags"`
 	Statuses  []*FieldValue `json:"statuses"`
 	Authors   []*FieldValue `json:"authors"`
+	Dockets   []*FieldValue `json:"dockets"`
+
+	// SourceSystems counts documents by the system that ingested them
+	SourceSystems []*FieldValue `json:"source_systems"`
+
+	// Languages counts documents by the detected language of their text
+	Languages []*FieldValue `json:"languages"`
+
+	// PartyRoles counts documents with at least one party in each canonical role
+	PartyRoles []*FieldValue `json:"party_roles"`
 }
 
 // BulkResult represents the result of a bulk operation
@@ -96,8 +206,23 @@ type BulkResult struct {
 	Errors     bool             `json:"errors"`
 	Items      []BulkResultItem `json:"items"`
 	Indexed    int              `json:"indexed"`
+	Deleted    int              `json:"deleted,omitempty"`
+	Updated    int              `json:"updated,omitempty"`
 	Failed     int              `json:"failed"`
 	FailedDocs []*BulkFailedDoc `json:"failed_docs,omitempty"`
+
+	// Attempts is how many bulk requests each indexed document ID was sent
+	// in, counting retries of documents rejected while OpenSearch throttled
+	Attempts map[string]int `json:"attempts,omitempty"`
+}
+
+// BulkMetadataUpdate sets metadata fields of one document in a bulk update.
+// EffectiveDate is when new values of effective-dated fields took effect;
+// now when omitted.
+type BulkMetadataUpdate struct {
+	ID            string            `json:"id"`
+	Metadata      map[string]string `json:"metadata"`
+	EffectiveDate *time.Time        `json:"effective_date,omitempty"`
 }
 
 // BulkResultItem represents a single item in bulk operation result
@@ -144,6 +269,26 @@ const DefaultSearchSize = 20
 // MaxSearchSize is the maximum number of results allowed
 const MaxSearchSize = 100
 
+// MaxExplainSize caps the page size of explained searches; explanations
+// are large and grow with the number of query clauses
+const MaxExplainSize = 10
+
+// MaxPageMatches is the number of matching pages returned per document
+const MaxPageMatches = 5
+
+// DefaultHighlightFragmentSize and DefaultHighlightFragments are the length in
+// characters and the number of highlight fragments returned per field
+const (
+	DefaultHighlightFragmentSize = 200
+	DefaultHighlightFragments    = 3
+)
+
+// Tags wrapping the matched terms in highlight fragments
+const (
+	HighlightPreTag  = "<mark>"
+	HighlightPostTag = "</mark>"
+)
+
 // MetadataFieldValuesRequest represents a request for metadata field values with custom filters
 type MetadataFieldValuesRequest struct {
 	Field         string                 `json:"field" validate:"required"`
@@ -214,6 +359,7 @@ type AggregationResponse struct {
 	DateRanges    []AggregationBucket     `json:"date_ranges,omitempty"`
 	Courts        []AggregationBucket     `json:"courts,omitempty"`
 	Judges        []AggregationBucket     `json:"judges,omitempty"`
+	Dockets       []AggregationBucket     `json:"dockets,omitempty"`
 }
 
 // SortOptions represents sorting configuration for search queries
@@ -223,11 +369,136 @@ type SortOptions struct {
 	Ascending bool      `json:"ascending"` // For backward compatibility with tests
 }
 
+// SortClause is one field of a multi-field sort. Later clauses order
+// documents the earlier ones rank equal.
+type SortClause struct {
+	Field string    `json:"field"`
+	Order SortOrder `json:"order,omitempty"` // asc or desc; defaults to desc
+}
+
+// scoreSortField sorts by relevance, which has no mapping of its own
+const scoreSortField = "_score"
+
+// ValidateSortClauses checks that every clause sorts on a sortable indexed
+// field in a known order, and defaults empty orders to desc
+func ValidateSortClauses(clauses []SortClause) error {
+	for i := range clauses {
+		clause := &clauses[i]
+		if _, err := ResolveSortField(clause.Field); err != nil {
+			return err
+		}
+		switch SortOrder(strings.ToLower(string(clause.Order))) {
+		case "", SortOrderDesc:
+			clause.Order = SortOrderDesc
+		case SortOrderAsc:
+			clause.Order = SortOrderAsc
+		default:
+			return fmt.Errorf("invalid sort order %q for field %s: must be asc or desc", clause.Order, clause.Field)
+		}
+	}
+	return nil
+}
+
+// ResolveSortField returns the indexed field OpenSearch sorts on for field:
+// the field itself for keyword, date, numeric and boolean fields, and the
+// keyword sub-field of a text field that has one. Analyzed text fields and
+// fields inside nested or unindexed objects cannot be sorted on.
+func ResolveSortField(field string) (string, error) {
+	if field == "" {
+		return "", fmt.Errorf("sort field is required")
+	}
+	if field == scoreSortField {
+		return field, nil
+	}
+
+	mappings, _ := GetDocumentMapping()["mappings"].(map[string]interface{})
+	current := mappings
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		properties, _ := current["properties"].(map[string]interface{})
+		mapping, ok := properties[part].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unknown sort field: %s", field)
+		}
+		fieldType, _ := mapping["type"].(string)
+		if fieldType == "nested" || mapping["enabled"] == false || mapping["index"] == false {
+			return "", fmt.Errorf("field %s cannot be sorted on", field)
+		}
+		if i < len(parts)-1 {
+			current = mapping
+			continue
+		}
+
+		switch fieldType {
+		case "keyword", "date", "boolean", "integer", "long", "float", "double":
+			return field, nil
+		case "text":
+			subFields, _ := mapping["fields"].(map[string]interface{})
+			if _, ok := subFields["keyword"]; ok {
+				return field + ".keyword", nil
+			}
+			return "", fmt.Errorf("text field %s cannot be sorted on", field)
+		default:
+			return "", fmt.Errorf("field %s cannot be sorted on", field)
+		}
+	}
+	return "", fmt.Errorf("unknown sort field: %s", field)
+}
+
+// DefaultBrowseSort orders empty-query searches: newest filings first, then by
+// document ID so documents with the same date keep the same order on every page
+const DefaultBrowseSort = "metadata.filing_date:desc,id:asc"
+
+// browseTiebreakField is unique per document, so sorting on it last makes
+// pagination deterministic
+const browseTiebreakField = "id"
+
+// ParseSortSpec parses a comma-separated list of "field:order" pairs, such as
+// DefaultBrowseSort. The order defaults to desc. A tiebreak on the document ID
+// is appended unless the spec already sorts on it.
+func ParseSortSpec(spec string) ([]SortOptions, error) {
+	var sorts []SortOptions
+	hasTiebreak := false
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field, order, _ := strings.Cut(part, ":")
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, fmt.Errorf("invalid sort %q: missing field", part)
+		}
+
+		sortOrder := SortOrderDesc
+		switch strings.ToLower(strings.TrimSpace(order)) {
+		case "", "desc":
+		case "asc":
+			sortOrder = SortOrderAsc
+		default:
+			return nil, fmt.Errorf("invalid sort %q: order must be asc or desc", part)
+		}
+
+		sorts = append(sorts, SortOptions{Field: field, Order: sortOrder})
+		hasTiebreak = hasTiebreak || field == browseTiebreakField
+	}
+
+	if len(sorts) == 0 {
+		return nil, fmt.Errorf("sort spec is empty")
+	}
+	if !hasTiebreak {
+		sorts = append(sorts, SortOptions{Field: browseTiebreakField, Order: SortOrderAsc})
+	}
+	return sorts, nil
+}
+
 // Filters represents search filters that can be applied
 type Filters struct {
 	DocType       []string            `json:"doc_type,omitempty"`
 	Court         []string            `json:"court,omitempty"`
 	Judge         []string            `json:"judge,omitempty"`
+	Docket        []string            `json:"docket,omitempty"`
 	Author        []string            `json:"author,omitempty"`
 	Status        []string            `json:"status,omitempty"`
 	LegalTags     []string            `json:"legal_tags,omitempty"`
@@ -277,7 +548,15 @@ func ValidateSearchRequest(req *SearchRequest) error {
 		}
 	}
 
-	return nil
+	if !req.DateRange.HasValidField() {
+		return fmt.Errorf("unsupported date_range field: %s", req.DateRange.Field)
+	}
+
+	if req.SearchAfter != nil && req.From > 0 {
+		return fmt.Errorf("search_after cannot be combined with from")
+	}
+
+	return ValidateSortClauses(req.Sort)
 }
 
 // ApplyDefaults applies default values to a search request
@@ -320,11 +599,21 @@ func (sr *SearchRequest) HasFilters() bool {
 	return sr.DocType != "" ||
 		sr.CaseNumber != "" ||
 		sr.CaseName != "" ||
+		sr.Docket != "" ||
 		len(sr.Judge) > 0 ||
 		len(sr.Court) > 0 ||
 		sr.Author != "" ||
 		sr.Status != "" ||
 		len(sr.LegalTags) > 0 ||
+		len(sr.PartyRole) > 0 ||
+		sr.MinExtractionQuality > 0 ||
+		sr.LowQualityExtraction != nil ||
+		len(sr.SourceSystem) > 0 ||
+		sr.IngestionBatchID != "" ||
+		len(sr.Language) > 0 ||
+		sr.MinReadingEase != nil ||
+		sr.MaxReadingEase != nil ||
+		len(sr.CustomMetadata) > 0 ||
 		sr.DateRange != nil && !sr.DateRange.IsEmpty()
 }
 
@@ -340,6 +629,9 @@ func (sr *SearchRequest) GetFilterCount() int {
 	if sr.CaseName != "" {
 		count++
 	}
+	if sr.Docket != "" {
+		count++
+	}
 	if len(sr.Judge) > 0 {
 		count++
 	}
@@ -355,6 +647,30 @@ func (sr *SearchRequest) GetFilterCount() int {
 	if len(sr.LegalTags) > 0 {
 		count++
 	}
+	if len(sr.PartyRole) > 0 {
+		count++
+	}
+	if sr.MinExtractionQuality > 0 {
+		count++
+	}
+	if sr.LowQualityExtraction != nil {
+		count++
+	}
+	if len(sr.SourceSystem) > 0 {
+		count++
+	}
+	if sr.IngestionBatchID != "" {
+		count++
+	}
+	if len(sr.Language) > 0 {
+		count++
+	}
+	if sr.MinReadingEase != nil || sr.MaxReadingEase != nil {
+		count++
+	}
+	if len(sr.CustomMetadata) > 0 {
+		count++
+	}
 	if sr.DateRange != nil && !sr.DateRange.IsEmpty() {
 		count++
 	}
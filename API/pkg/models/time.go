@@ -2,10 +2,28 @@ package models
 
 import "time"
 
+// DateRangeFields maps the date fields that can be range-filtered to their index paths
+var DateRangeFields = map[string]string{
+	"created_at":     "created_at",
+	"filing_date":    "metadata.filing_date",
+	"event_date":     "metadata.event_date",
+	"hearing_date":   "metadata.hearing_date",
+	"decision_date":  "metadata.decision_date",
+	"served_date":    "metadata.served_date",
+	"signature_date": "metadata.signature_date",
+}
+
+// DefaultDateRangeField is the date field filtered when no field is specified
+const DefaultDateRangeField = "created_at"
+
 // DateRange represents a date range filter for queries and searches
 type DateRange struct {
 	From *time.Time `json:"from,omitempty"`
 	To   *time.Time `json:"to,omitempty"`
+
+	// Field selects which date the range applies to (see DateRangeFields).
+	// Defaults to created_at.
+	Field string `json:"field,omitempty"`
 	
 	// Alternative field names for backward compatibility
 	Start *time.Time `json:"start,omitempty"`
@@ -43,6 +61,25 @@ func (dr *DateRange) IsValid() bool {
 	return from.Before(*to) || from.Equal(*to)
 }
 
+// HasValidField reports whether the range targets a known date field
+func (dr *DateRange) HasValidField() bool {
+	if dr == nil || dr.Field == "" {
+		return true
+	}
+	_, ok := DateRangeFields[dr.Field]
+	return ok
+}
+
+// GetField returns the index path of the date field the range applies to
+func (dr *DateRange) GetField() string {
+	if dr != nil {
+		if path, ok := DateRangeFields[dr.Field]; ok {
+			return path
+		}
+	}
+	return DateRangeFields[DefaultDateRangeField]
+}
+
 // GetFrom returns the start date, checking both From and Start fields
 func (dr *DateRange) GetFrom() *time.Time {
 	if dr == nil {
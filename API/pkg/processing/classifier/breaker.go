@@ -0,0 +1,206 @@
+package classifier
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker
+type BreakerState string
+
+const (
+	BreakerStateClosed   BreakerState = "closed"    // Calls flow normally
+	BreakerStateOpen     BreakerState = "open"      // Calls fail fast until the cooldown elapses
+	BreakerStateHalfOpen BreakerState = "half_open" // A single probe call is allowed through
+)
+
+// Default circuit breaker settings
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 60 * time.Second
+)
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           `json:"failure_threshold"` // Consecutive failures before the circuit opens
+	Cooldown         time.Duration `json:"cooldown"`          // How long the circuit stays open before probing
+}
+
+// BreakerStatus is a snapshot of a circuit breaker for health and metrics reporting
+type BreakerStatus struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	FailureThreshold    int          `json:"failure_threshold"`
+	Trips               int64        `json:"trips"`
+	RejectedCalls       int64        `json:"rejected_calls"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
+
+// BreakerReporter is implemented by classifiers and services that guard calls with circuit breakers
+type BreakerReporter interface {
+	BreakerStatuses() []*BreakerStatus
+}
+
+// CircuitBreaker stops calling a failing dependency after a run of consecutive
+// failures and lets a single probe through once the cooldown has elapsed
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	state    BreakerState
+	failures int
+
+	// probeStartedAt is when the half-open probe was let through
+	probeStartedAt time.Time
+	openedAt       time.Time
+	trips          int64
+	rejected       int64
+
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker, applying defaults for unset values
+func NewCircuitBreaker(name string, config *BreakerConfig) *CircuitBreaker {
+	threshold := DefaultBreakerThreshold
+	cooldown := DefaultBreakerCooldown
+	if config != nil {
+		if config.FailureThreshold > 0 {
+			threshold = config.FailureThreshold
+		}
+		if config.Cooldown > 0 {
+			cooldown = config.Cooldown
+		}
+	}
+
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerStateClosed,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. It returns a circuit_open
+// ClassificationError while the circuit is open.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerStateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			b.rejected++
+			return b.openError()
+		}
+		// Cooldown elapsed - let one probe through
+		b.state = BreakerStateHalfOpen
+		b.probeStartedAt = b.now()
+		log.Printf("[BREAKER] 🔄 %s circuit half-open, probing", b.name)
+		return nil
+	case BreakerStateHalfOpen:
+		// A probe is already in flight. One that never reported back within
+		// a cooldown is given up on, and another probe takes its place.
+		if b.now().Sub(b.probeStartedAt) >= b.cooldown {
+			b.probeStartedAt = b.now()
+			log.Printf("[BREAKER] 🔄 %s probe expired without an outcome, probing again", b.name)
+			return nil
+		}
+		b.rejected++
+		return b.openError()
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerStateClosed {
+		log.Printf("[BREAKER] ✅ %s circuit closed after successful probe", b.name)
+	}
+	b.state = BreakerStateClosed
+	b.failures = 0
+}
+
+// Release ends a call let through by Allow that says nothing about the
+// dependency's health, such as one the caller cancelled. A released probe
+// returns the circuit to open with its cooldown already elapsed, so the next
+// call probes again.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerStateHalfOpen {
+		b.state = BreakerStateOpen
+	}
+}
+
+// RecordFailure counts a failed call and opens the circuit once the threshold is reached.
+// A failed probe re-opens the circuit for another cooldown period.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.state == BreakerStateHalfOpen || (b.state == BreakerStateClosed && b.failures >= b.threshold) {
+		b.state = BreakerStateOpen
+		b.openedAt = b.now()
+		b.trips++
+		log.Printf("[BREAKER] ⛔ %s circuit opened after %d consecutive failures (cooldown %v)", b.name, b.failures, b.cooldown)
+	}
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Status returns a snapshot of the breaker
+func (b *CircuitBreaker) Status() *BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := &BreakerStatus{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		FailureThreshold:    b.threshold,
+		Trips:               b.trips,
+		RejectedCalls:       b.rejected,
+	}
+	if b.state != BreakerStateClosed {
+		openedAt := b.openedAt
+		retryAt := b.openedAt.Add(b.cooldown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+func (b *CircuitBreaker) openError() error {
+	retryIn := b.cooldown - b.now().Sub(b.openedAt)
+	if retryIn < 0 {
+		retryIn = 0
+	}
+	return NewClassificationError("circuit_open",
+		fmt.Sprintf("%s circuit is open after %d consecutive failures; retry in %v", b.name, b.failures, retryIn.Round(time.Second)), nil)
+}
+
+// IsCircuitOpenError returns true if err was returned by an open circuit breaker
+func IsCircuitOpenError(err error) bool {
+	var classErr *ClassificationError
+	return errors.As(err, &classErr) && classErr.Type == "circuit_open"
+}
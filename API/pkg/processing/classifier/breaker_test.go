@@ -0,0 +1,202 @@
+package classifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock lets tests move a breaker past its cooldown without sleeping
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time          { return f.now }
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker("test", &BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	breaker.now = clock.Now
+
+	require.NoError(t, breaker.Allow())
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerStateClosed, breaker.State())
+
+	require.NoError(t, breaker.Allow())
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerStateOpen, breaker.State())
+
+	err := breaker.Allow()
+	require.Error(t, err)
+	assert.True(t, IsCircuitOpenError(err))
+
+	// After the cooldown a single probe is let through
+	clock.Advance(time.Minute)
+	require.NoError(t, breaker.Allow())
+	assert.Equal(t, BreakerStateHalfOpen, breaker.State())
+	assert.True(t, IsCircuitOpenError(breaker.Allow()))
+
+	// A failed probe re-opens immediately
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerStateOpen, breaker.State())
+
+	clock.Advance(time.Minute)
+	require.NoError(t, breaker.Allow())
+	breaker.RecordSuccess()
+	assert.Equal(t, BreakerStateClosed, breaker.State())
+
+	status := breaker.Status()
+	assert.Equal(t, int64(2), status.Trips)
+	assert.Equal(t, int64(2), status.RejectedCalls)
+	assert.Zero(t, status.ConsecutiveFailures)
+	assert.Nil(t, status.OpenedAt)
+}
+
+func TestCircuitBreaker_UnresolvedProbe(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewCircuitBreaker("test", &BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	breaker.now = clock.Now
+
+	breaker.RecordFailure()
+	clock.Advance(time.Minute)
+	require.NoError(t, breaker.Allow())
+
+	// A released probe lets the next call probe straight away
+	breaker.Release()
+	assert.Equal(t, BreakerStateOpen, breaker.State())
+	require.NoError(t, breaker.Allow())
+	assert.Equal(t, BreakerStateHalfOpen, breaker.State())
+
+	// A probe that never reports back expires after a cooldown
+	assert.True(t, IsCircuitOpenError(breaker.Allow()))
+	clock.Advance(time.Minute)
+	require.NoError(t, breaker.Allow())
+	breaker.RecordSuccess()
+	assert.Equal(t, BreakerStateClosed, breaker.State())
+}
+
+// newFlakyOpenAIServer returns a fake chat completions endpoint that fails while down is set
+func newFlakyOpenAIServer(down *atomic.Bool, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"service unavailable"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"document_type\":\"motion_to_dismiss\",\"legal_category\":\"criminal\",\"confidence\":0.9}"}}]}`))
+	}))
+}
+
+func TestOpenAIClassifier_CircuitBreakerSustainedFailureAndRecovery(t *testing.T) {
+	var down atomic.Bool
+	var calls atomic.Int32
+	down.Store(true)
+
+	server := newFlakyOpenAIServer(&down, &calls)
+	defer server.Close()
+
+	c, err := NewOpenAIClassifier(&Config{
+		APIKey:     "test-key",
+		BaseURL:    server.URL,
+		MaxRetries: 1,
+		Breaker:    &BreakerConfig{FailureThreshold: 3, Cooldown: time.Minute},
+	})
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Now()}
+	openai := c.(*openaiClassifier)
+	openai.breaker.now = clock.Now
+
+	ctx := context.Background()
+
+	// Sustained outage: the first failures reach the API, then the circuit opens
+	for i := 0; i < 3; i++ {
+		_, err := c.Classify(ctx, "MOTION TO DISMISS", nil)
+		require.Error(t, err)
+		assert.False(t, IsCircuitOpenError(err))
+	}
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, BreakerStateOpen, openai.breaker.State())
+
+	// Further calls fail fast without touching the API
+	for i := 0; i < 5; i++ {
+		_, err := c.Classify(ctx, "MOTION TO DISMISS", nil)
+		require.Error(t, err)
+		assert.True(t, IsCircuitOpenError(err))
+	}
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Probe while still down re-opens the circuit
+	clock.Advance(time.Minute)
+	_, err = c.Classify(ctx, "MOTION TO DISMISS", nil)
+	require.Error(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+	assert.Equal(t, BreakerStateOpen, openai.breaker.State())
+
+	// OpenAI recovers: the next probe succeeds and closes the circuit
+	down.Store(false)
+	clock.Advance(time.Minute)
+	result, err := c.Classify(ctx, "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DocumentTypeMotionToDismiss, result.DocumentType)
+	assert.Equal(t, BreakerStateClosed, openai.breaker.State())
+
+	_, err = c.Classify(ctx, "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), calls.Load())
+
+	statuses := openai.BreakerStatuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "openai", statuses[0].Name)
+	assert.Equal(t, int64(2), statuses[0].Trips)
+	assert.Equal(t, int64(5), statuses[0].RejectedCalls)
+}
+
+func TestOpenAIClassifier_CancelledProbeReleasesCircuit(t *testing.T) {
+	var down atomic.Bool
+	var calls atomic.Int32
+	down.Store(true)
+
+	server := newFlakyOpenAIServer(&down, &calls)
+	defer server.Close()
+
+	c, err := NewOpenAIClassifier(&Config{
+		APIKey:     "test-key",
+		BaseURL:    server.URL,
+		MaxRetries: 1,
+		Breaker:    &BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute},
+	})
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Now()}
+	openai := c.(*openaiClassifier)
+	openai.breaker.now = clock.Now
+
+	_, err = c.Classify(context.Background(), "MOTION TO DISMISS", nil)
+	require.Error(t, err)
+	require.Equal(t, BreakerStateOpen, openai.breaker.State())
+
+	// The probe's caller gives up before OpenAI answers
+	clock.Advance(time.Minute)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Classify(cancelled, "MOTION TO DISMISS", nil)
+	require.Error(t, err)
+	assert.False(t, IsCircuitOpenError(err))
+	assert.Equal(t, BreakerStateOpen, openai.breaker.State())
+
+	// The next call probes instead of failing fast on a stuck half-open circuit
+	down.Store(false)
+	_, err = c.Classify(context.Background(), "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, BreakerStateClosed, openai.breaker.State())
+}
@@ -18,6 +18,7 @@ type claudeClassifier struct {
 	model      string
 	baseURL    string
 	httpClient *http.Client
+	examples   []FewShotExample
 }
 
 // NewClaudeClassifier creates a new Claude-based classifier
@@ -43,6 +44,7 @@ func NewClaudeClassifier(config *ClaudeConfig) (Classifier, error) {
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
+		examples: config.Examples,
 	}, nil
 }
 
@@ -52,7 +54,7 @@ func (c *claudeClassifier) Classify(ctx context.Context, text string, metadata *
 	prompt := c.buildClassificationPrompt(text, metadata)
 
 	// Make request to Claude
-	response, err := c.makeClaudeRequest(ctx, prompt)
+	response, usage, err := c.makeClaudeRequest(ctx, prompt, modelFor(metadata, c.model))
 	if err != nil {
 		return nil, NewClassificationError("claude_request", "failed to classify document", err)
 	}
@@ -62,6 +64,7 @@ func (c *claudeClassifier) Classify(ctx context.Context, text string, metadata *
 	if err != nil {
 		return nil, NewClassificationError("response_parsing", "failed to parse classification response", err)
 	}
+	result.Usage = usage
 
 	return result, nil
 }
@@ -79,15 +82,18 @@ func (c *claudeClassifier) IsConfigured() bool {
 // buildClassificationPrompt creates a prompt for Claude classification using unified prompts
 func (c *claudeClassifier) buildClassificationPrompt(text string, metadata *DocumentMetadata) string {
 	// Use the unified prompt builder with Claude-specific configuration
-	config := DefaultPromptConfigs["claude"]
-	if config == nil {
-		config = &PromptConfig{
-			Model:         c.model,
-			MaxTextLength: 15000,
-			IncludeContext: true,
-			DetailLevel:   "comprehensive",
-		}
+	config := &PromptConfig{
+		Model:         c.model,
+		MaxTextLength: 15000,
+		IncludeContext: true,
+		DetailLevel:   "comprehensive",
+	}
+	if defaults := DefaultPromptConfigs["claude"]; defaults != nil {
+		copied := *defaults
+		config = &copied
 	}
+	config.MaxTextLength = textWindowFor(metadata, config.MaxTextLength)
+	config.Examples = c.examples
 	
 	builder := NewPromptBuilder(config)
 	return builder.BuildClassificationPrompt(text, metadata)
@@ -114,12 +120,16 @@ type claudeResponse struct {
 		Type    string `json:"type"`
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // makeClaudeRequest sends a request to Claude's API
-func (c *claudeClassifier) makeClaudeRequest(ctx context.Context, prompt string) (string, error) {
+func (c *claudeClassifier) makeClaudeRequest(ctx context.Context, prompt, model string) (string, *TokenUsage, error) {
 	reqBody := claudeRequest{
-		Model:     c.model,
+		Model:     model,
 		MaxTokens: 1500,
 		Messages: []claudeMessage{
 			{
@@ -131,12 +141,12 @@ func (c *claudeClassifier) makeClaudeRequest(ctx context.Context, prompt string)
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -145,33 +155,42 @@ func (c *claudeClassifier) makeClaudeRequest(ctx context.Context, prompt string)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return "", nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Claude API returned status %d: %s", resp.StatusCode, string(body))
+		return "", nil, fmt.Errorf("Claude API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var claudeResp claudeResponse
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if claudeResp.Error != nil {
-		return "", fmt.Errorf("Claude API error: %s", claudeResp.Error.Message)
+		return "", nil, fmt.Errorf("Claude API error: %s", claudeResp.Error.Message)
 	}
 
 	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("no content returned from Claude")
+		return "", nil, fmt.Errorf("no content returned from Claude")
 	}
 
-	return claudeResp.Content[0].Text, nil
+	var usage *TokenUsage
+	if claudeResp.Usage != nil {
+		usage = &TokenUsage{
+			PromptTokens:     claudeResp.Usage.InputTokens,
+			CompletionTokens: claudeResp.Usage.OutputTokens,
+			TotalTokens:      claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
+		}
+	}
+
+	return claudeResp.Content[0].Text, usage, nil
 }
 
 // parseClassificationResponse parses the Claude response into a ClassificationResult
@@ -245,6 +264,12 @@ func (c *claudeClassifier) parseClassificationResponse(response string) (*Classi
 			result.ServedDate = nil
 		}
 	}
+	if result.SignatureDate != nil {
+		if !dateExtractor.validateDate(*result.SignatureDate, "signature_date") {
+			log.Printf("[CLAUDE] Invalid signature_date: %s, setting to nil", *result.SignatureDate)
+			result.SignatureDate = nil
+		}
+	}
 
 	// Validate document type against known types
 	validTypes := GetDefaultDocumentTypes()
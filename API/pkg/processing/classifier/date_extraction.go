@@ -16,12 +16,13 @@ type DateRange struct {
 
 // DateExtractionResult contains all extracted date information
 type DateExtractionResult struct {
-	FilingDate   *string     `json:"filing_date,omitempty"`
-	EventDate    *string     `json:"event_date,omitempty"`
-	HearingDate  *string     `json:"hearing_date,omitempty"`
-	DecisionDate *string     `json:"decision_date,omitempty"`
-	ServedDate   *string     `json:"served_date,omitempty"`
-	DateRanges   []DateRange `json:"date_ranges,omitempty"`
+	FilingDate    *string     `json:"filing_date,omitempty"`
+	EventDate     *string     `json:"event_date,omitempty"`
+	HearingDate   *string     `json:"hearing_date,omitempty"`
+	DecisionDate  *string     `json:"decision_date,omitempty"`
+	ServedDate    *string     `json:"served_date,omitempty"`
+	SignatureDate *string     `json:"signature_date,omitempty"`
+	DateRanges    []DateRange `json:"date_ranges,omitempty"`
 }
 
 // Common date formats found in legal documents
@@ -60,6 +61,10 @@ var datePatterns = map[string]*regexp.Regexp{
 	"hearing_date": regexp.MustCompile(`(?i)(?:hearing|scheduled|arraignment|calendar)(?:\s+(?:set|on|for))?\s*:?\s*([^,\n\r;]+)`),
 	"decision_date": regexp.MustCompile(`(?i)(?:decided|ruling|ordered|judgment|entered)(?:\s+on)?\s*:?\s*([^,\n\r;]+)`),
 	"served_date": regexp.MustCompile(`(?i)(?:served|service)(?:\s+on)?\s*:?\s*([^,\n\r;]+)`),
+	// Signature dates usually read "Dated: March 3, 2024" or "Executed on 03/03/2024", so the
+	// capture group matches whole dates (commas included) rather than stopping at a comma.
+	// Word boundaries keep words such as "updated" or "cosigned" from counting
+	"signature_date": regexp.MustCompile(`(?i)\b(?:signed|executed|dated)\b(?:\s+(?:on|this))?\s*:?\s*([A-Za-z]+\.?\s+\d{1,2},?\s+\d{4}|\d{1,2}[/-]\d{1,2}[/-]\d{4}|\d{4}-\d{1,2}-\d{1,2})`),
 }
 
 // DateExtractor provides date extraction and validation functionality
@@ -88,6 +93,9 @@ func (de *DateExtractor) ExtractDatesFromText(text string) *DateExtractionResult
 	result.DecisionDate = de.extractDateByType(text, "decision_date")
 	result.ServedDate = de.extractDateByType(text, "served_date")
 
+	// Documents with several signature blocks are dated by the last signature
+	result.SignatureDate = de.extractLatestDateByType(text, "signature_date")
+
 	// Extract date ranges (future enhancement)
 	result.DateRanges = de.extractDateRanges(text)
 
@@ -116,6 +124,34 @@ func (de *DateExtractor) extractDateByType(text, dateType string) *string {
 	return parsedDate
 }
 
+// extractLatestDateByType extracts every date of the given type and returns the latest one
+func (de *DateExtractor) extractLatestDateByType(text, dateType string) *string {
+	pattern, exists := datePatterns[dateType]
+	if !exists {
+		return nil
+	}
+
+	var latest *string
+	for _, matches := range pattern.FindAllStringSubmatch(text, -1) {
+		if len(matches) < 2 {
+			continue
+		}
+
+		dateStr := strings.TrimSpace(matches[1])
+		if dateStr == "" {
+			continue
+		}
+
+		parsed := de.parseAndValidateDate(dateStr, dateType)
+		// ISO dates compare correctly as strings
+		if parsed != nil && (latest == nil || *parsed > *latest) {
+			latest = parsed
+		}
+	}
+
+	return latest
+}
+
 // parseAndValidateDate attempts to parse a date string and validate it
 func (de *DateExtractor) parseAndValidateDate(dateStr, dateType string) *string {
 	// Clean the date string
@@ -302,6 +338,10 @@ func (de *DateExtractor) validateDate(dateStr, dateType string) bool {
 	case "served_date":
 		// Service dates should not be in the future
 		return t.Before(now.AddDate(0, 0, 1))
+
+	case "signature_date":
+		// Documents cannot be signed in the future
+		return t.Before(now.AddDate(0, 0, 1))
 		
 	default:
 		return true
@@ -395,6 +435,10 @@ func (de *DateExtractor) ValidateAllDates(result *DateExtractionResult) error {
 	if result.ServedDate != nil && !de.validateDate(*result.ServedDate, "served_date") {
 		errors = append(errors, fmt.Sprintf("invalid served_date: %s", *result.ServedDate))
 	}
+
+	if result.SignatureDate != nil && !de.validateDate(*result.SignatureDate, "signature_date") {
+		errors = append(errors, fmt.Sprintf("invalid signature_date: %s", *result.SignatureDate))
+	}
 	
 	if len(errors) > 0 {
 		return fmt.Errorf("date validation errors: %s", strings.Join(errors, "; "))
@@ -443,6 +487,12 @@ func MergeDates(primary, secondary *DateExtractionResult) *DateExtractionResult
 	} else {
 		result.ServedDate = secondary.ServedDate
 	}
+
+	if primary.SignatureDate != nil {
+		result.SignatureDate = primary.SignatureDate
+	} else {
+		result.SignatureDate = secondary.SignatureDate
+	}
 	
 	// Merge date ranges
 	result.DateRanges = append(primary.DateRanges, secondary.DateRanges...)
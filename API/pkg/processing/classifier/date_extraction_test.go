@@ -0,0 +1,80 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateExtractor_SignatureDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected *string
+	}{
+		{
+			name:     "dated line with month name",
+			text:     "Respectfully submitted,\nDated: March 3, 2023\n/s/ Jane Doe",
+			expected: stringPtr("2023-03-03"),
+		},
+		{
+			name:     "executed on numeric date",
+			text:     "I declare under penalty of perjury. Executed on 04/15/2022 at Oakland, California.",
+			expected: stringPtr("2022-04-15"),
+		},
+		{
+			name:     "multiple signatures keep the latest",
+			text:     "Dated: January 5, 2023\n/s/ Counsel for Plaintiff\n\nDated: February 10, 2023\n/s/ Counsel for Defendant\n\nSigned on 2023-01-20",
+			expected: stringPtr("2023-02-10"),
+		},
+		{
+			name:     "future signature is rejected",
+			text:     "Dated: January 5, 2999",
+			expected: nil,
+		},
+		{
+			name:     "no signature date",
+			text:     "Filed on 01/02/2023 in the Superior Court.",
+			expected: nil,
+		},
+		{
+			name:     "updated is not dated",
+			text:     "Updated on March 3, 2023 to correct the caption.",
+			expected: nil,
+		},
+	}
+
+	de := NewDateExtractor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := de.ExtractDatesFromText(tt.text)
+			if tt.expected == nil {
+				assert.Nil(t, result.SignatureDate)
+				return
+			}
+			require.NotNil(t, result.SignatureDate)
+			assert.Equal(t, *tt.expected, *result.SignatureDate)
+		})
+	}
+}
+
+func TestDateExtractor_ValidateAllDates_SignatureDate(t *testing.T) {
+	de := NewDateExtractor()
+
+	err := de.ValidateAllDates(&DateExtractionResult{SignatureDate: stringPtr("2023-03-03")})
+	assert.NoError(t, err)
+
+	err = de.ValidateAllDates(&DateExtractionResult{SignatureDate: stringPtr("2999-01-01")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signature_date")
+}
+
+func TestMergeDates_SignatureDate(t *testing.T) {
+	primary := &DateExtractionResult{}
+	secondary := &DateExtractionResult{SignatureDate: stringPtr("2023-03-03")}
+
+	merged := MergeDates(primary, secondary)
+	require.NotNil(t, merged.SignatureDate)
+	assert.Equal(t, "2023-03-03", *merged.SignatureDate)
+}
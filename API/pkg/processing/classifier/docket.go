@@ -0,0 +1,31 @@
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// "Case No. CR-2024-001234", "Docket No.: 2:23-cv-01234", "Dkt. # 45-1"
+	labeledDocketPattern = regexp.MustCompile(`(?i)\b(?:case|docket|dkt|cause|index)\.?\s*(?:no\.?|num\.?|number|#)\s*[:#]?\s*([A-Za-z0-9]+(?:[:\-./][A-Za-z0-9]+)*)`)
+
+	// Federal style numbers that often appear without a label, e.g. "1:23-cv-00456-JDB"
+	federalDocketPattern = regexp.MustCompile(`(?i)\b(\d{1,2}:\d{2}-[a-z]{2,4}-\d{2,6}(?:-[a-z]{2,4})*)\b`)
+)
+
+// ExtractDocketNumber finds the first docket number in document text. It is used
+// when the classifier did not return one. The raw match is returned; callers
+// normalize it with models.NormalizeDocketNumber before indexing.
+func ExtractDocketNumber(text string) string {
+	for _, match := range labeledDocketPattern.FindAllStringSubmatch(text, -1) {
+		if strings.ContainsAny(match[1], "0123456789") {
+			return match[1]
+		}
+	}
+
+	if match := federalDocketPattern.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
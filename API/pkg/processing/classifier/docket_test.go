@@ -0,0 +1,47 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractDocketNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "labeled case number",
+			text:     "SUPERIOR COURT OF CALIFORNIA\nPEOPLE v. SMITH\nCase No. CR-2024-001234\nMOTION TO SUPPRESS",
+			expected: "CR-2024-001234",
+		},
+		{
+			name:     "docket label with colon",
+			text:     "Docket No.: 2:23-cv-01234\nORDER GRANTING MOTION",
+			expected: "2:23-cv-01234",
+		},
+		{
+			name:     "unlabeled federal number",
+			text:     "Civil Action 1:23-cv-00456-JDB\nMEMORANDUM OPINION",
+			expected: "1:23-cv-00456-JDB",
+		},
+		{
+			name:     "label without a number is skipped",
+			text:     "The case number will be assigned later. Case No. 19CR4411",
+			expected: "19CR4411",
+		},
+		{
+			name:     "no docket number",
+			text:     "NOTICE OF MOTION AND MOTION TO DISMISS",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractDocketNumber(tt.text))
+		})
+	}
+}
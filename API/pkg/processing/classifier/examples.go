@@ -0,0 +1,83 @@
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Limits for few-shot examples injected into the classification prompt
+const (
+	maxExampleTextLength  = 800 // Snippets are cut to keep each example short
+	exampleBudgetFraction = 4   // Examples may use up to 1/4 of the prompt's text budget
+)
+
+// FewShotExample is a labelled document snippet shown to the model before the
+// document being classified
+type FewShotExample struct {
+	Text          string `json:"text"`
+	DocumentType  string `json:"document_type"`
+	LegalCategory string `json:"legal_category,omitempty"`
+	Subject       string `json:"subject,omitempty"`
+}
+
+// LoadFewShotExamples reads a JSON array of examples from path, keeping at
+// most maxExamples (0 keeps all). Examples without text or a document type
+// are rejected so a typo in the file fails at startup, not silently.
+func LoadFewShotExamples(path string, maxExamples int) ([]FewShotExample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read classification examples: %w", err)
+	}
+
+	var examples []FewShotExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("failed to parse classification examples %s: %w", path, err)
+	}
+
+	for i, example := range examples {
+		if strings.TrimSpace(example.Text) == "" || strings.TrimSpace(example.DocumentType) == "" {
+			return nil, fmt.Errorf("classification example %d needs both text and document_type", i+1)
+		}
+	}
+
+	if maxExamples > 0 && len(examples) > maxExamples {
+		examples = examples[:maxExamples]
+	}
+	return examples, nil
+}
+
+// buildExamplesSection formats examples for the prompt, stopping once the
+// character budget is spent. Returns an empty string when no example fits.
+func buildExamplesSection(examples []FewShotExample, budget int) string {
+	var sb strings.Builder
+	used := 0
+	for _, example := range examples {
+		text := strings.Join(strings.Fields(example.Text), " ")
+		if len(text) > maxExampleTextLength {
+			text = text[:maxExampleTextLength] + "..."
+		}
+
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "\nExample %d:\nText: %s\nExpected: document_type=%s", used+1, text, example.DocumentType)
+		if example.LegalCategory != "" {
+			fmt.Fprintf(&entry, ", legal_category=%s", example.LegalCategory)
+		}
+		if example.Subject != "" {
+			fmt.Fprintf(&entry, ", subject=%s", example.Subject)
+		}
+		entry.WriteString("\n")
+
+		if sb.Len()+entry.Len() > budget {
+			break
+		}
+		sb.WriteString(entry.String())
+		used++
+	}
+
+	if used == 0 {
+		return ""
+	}
+	return "CLASSIFICATION EXAMPLES (labelled documents for reference; do not classify these):\n" + sb.String()
+}
@@ -0,0 +1,86 @@
+package classifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExamplesFile(t *testing.T, examples []FewShotExample) string {
+	t.Helper()
+	data, err := json.Marshal(examples)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "examples.json")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func TestLoadFewShotExamples(t *testing.T) {
+	path := writeExamplesFile(t, []FewShotExample{
+		{Text: "PETITION FOR WRIT OF HABEAS CORPUS", DocumentType: "writ", LegalCategory: "criminal"},
+		{Text: "NOTICE OF APPEAL", DocumentType: "notice"},
+		{Text: "STIPULATION TO CONTINUE", DocumentType: "stipulation"},
+	})
+
+	examples, err := LoadFewShotExamples(path, 2)
+	require.NoError(t, err)
+	require.Len(t, examples, 2)
+	assert.Equal(t, "writ", examples[0].DocumentType)
+
+	invalid := writeExamplesFile(t, []FewShotExample{{Text: "MOTION IN LIMINE"}})
+	_, err = LoadFewShotExamples(invalid, 0)
+	assert.Error(t, err)
+}
+
+func TestBuildExamplesSection_RespectsBudget(t *testing.T) {
+	examples := []FewShotExample{
+		{Text: strings.Repeat("first ", 50), DocumentType: "writ"},
+		{Text: strings.Repeat("second ", 50), DocumentType: "notice"},
+	}
+
+	section := buildExamplesSection(examples, 400)
+	assert.Contains(t, section, "document_type=writ")
+	assert.NotContains(t, section, "document_type=notice")
+
+	assert.Empty(t, buildExamplesSection(examples, 10))
+}
+
+func TestOpenAIClassifier_PromptIncludesExamples(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && len(req.Messages) > 0 {
+			prompt = req.Messages[len(req.Messages)-1].Content
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"document_type\":\"writ\",\"legal_category\":\"criminal\",\"confidence\":0.9}"}}]}`))
+	}))
+	defer server.Close()
+
+	c, err := NewOpenAIClassifier(&Config{
+		APIKey:     "test-key",
+		BaseURL:    server.URL,
+		MaxRetries: 1,
+		Examples: []FewShotExample{
+			{Text: "PETITION FOR WRIT OF MANDATE directing respondent court", DocumentType: "writ", LegalCategory: "criminal"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = c.Classify(context.Background(), "PETITION FOR WRIT OF PROHIBITION", nil)
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "CLASSIFICATION EXAMPLES")
+	assert.Contains(t, prompt, "PETITION FOR WRIT OF MANDATE directing respondent court")
+	assert.Contains(t, prompt, "document_type=writ, legal_category=criminal")
+	// Examples come before the document so the model doesn't mistake them for it
+	assert.Less(t, strings.Index(prompt, "CLASSIFICATION EXAMPLES"), strings.Index(prompt, "Document text:"))
+}
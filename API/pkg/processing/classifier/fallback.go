@@ -172,19 +172,24 @@ func (fc *fallbackClassifier) categorizeError(err error) string {
 	if err == nil {
 		return "unknown"
 	}
+
+	// Provider is being skipped by its circuit breaker
+	if IsCircuitOpenError(err) {
+		return "CIRCUIT_OPEN"
+	}
 	
 	errStr := strings.ToLower(err.Error())
 	
 	// Quota and rate limit errors (good candidates for fallback)
+	if rateErr, ok := AsRateLimitError(err); ok && rateErr.InsufficientQuota || strings.Contains(errStr, "insufficient_quota") {
+		return "INSUFFICIENT_QUOTA"
+	}
 	if strings.Contains(errStr, "quota") || strings.Contains(errStr, "billing") {
 		return "QUOTA_EXCEEDED"
 	}
 	if strings.Contains(errStr, "rate limit") || strings.Contains(errStr, "status 429") {
 		return "RATE_LIMIT"
 	}
-	if strings.Contains(errStr, "insufficient_quota") {
-		return "INSUFFICIENT_QUOTA"
-	}
 	
 	// Auth errors (not good for fallback)
 	if strings.Contains(errStr, "status 401") || strings.Contains(errStr, "unauthorized") {
@@ -229,6 +234,8 @@ func (fc *fallbackClassifier) shouldFallback(err error) bool {
 		return true
 	case "RESPONSE_PARSE_ERROR": // Provider-specific parsing issue
 		return true
+	case "CIRCUIT_OPEN":
+		return true
 	}
 	
 	// These errors are not good for fallback (will likely fail on other providers too)
@@ -246,4 +253,15 @@ func NewFallbackService(aiConfig interface{}) (Service, error) {
 	// This would be called from the handlers with the AI config
 	// For now, return a simple implementation
 	return nil, fmt.Errorf("NewFallbackService not yet implemented - use NewFallbackClassifier directly")
-}
\ No newline at end of file
+}
+
+// BreakerStatuses reports circuit breaker state from every configured provider
+func (fc *fallbackClassifier) BreakerStatuses() []*BreakerStatus {
+	var statuses []*BreakerStatus
+	for _, provider := range []Classifier{fc.ollama, fc.claude, fc.openai} {
+		if reporter, ok := provider.(BreakerReporter); ok {
+			statuses = append(statuses, reporter.BreakerStatuses()...)
+		}
+	}
+	return statuses
+}
@@ -40,6 +40,28 @@ type DocumentMetadata struct {
 	PageCount    int               `json:"page_count"`
 	Properties   map[string]string `json:"properties,omitempty"`
 	SourceSystem string            `json:"source_system,omitempty"`
+
+	// Per-document overrides, typically from a processing profile. Model must
+	// name a model of the classifier's provider. Zero values keep the
+	// classifier's configured model and text window.
+	Model         string `json:"model,omitempty"`
+	MaxTextLength int    `json:"max_text_length,omitempty"`
+}
+
+// modelFor returns the document's model override, or model when none is set
+func modelFor(metadata *DocumentMetadata, model string) string {
+	if metadata != nil && metadata.Model != "" {
+		return metadata.Model
+	}
+	return model
+}
+
+// textWindowFor returns the document's text window override, or length when none is set
+func textWindowFor(metadata *DocumentMetadata, length int) int {
+	if metadata != nil && metadata.MaxTextLength > 0 {
+		return metadata.MaxTextLength
+	}
+	return length
 }
 
 // ClassificationResult contains the result of document classification
@@ -64,12 +86,13 @@ type ClassificationResult struct {
 	Authorities []Authority `json:"authorities,omitempty"`
 	
 	// Enhanced Date Fields - ISO date strings (YYYY-MM-DD)
-	FilingDate   *string     `json:"filing_date,omitempty"`   // When document was filed with court
-	EventDate    *string     `json:"event_date,omitempty"`    // Key event or action date
-	HearingDate  *string     `json:"hearing_date,omitempty"`  // Scheduled court hearing date
-	DecisionDate *string     `json:"decision_date,omitempty"` // When court decision was made
-	ServedDate   *string     `json:"served_date,omitempty"`   // When documents were served
-	DateRanges   []DateRange `json:"date_ranges,omitempty"`   // Multi-day events
+	FilingDate    *string     `json:"filing_date,omitempty"`    // When document was filed with court
+	EventDate     *string     `json:"event_date,omitempty"`     // Key event or action date
+	HearingDate   *string     `json:"hearing_date,omitempty"`   // Scheduled court hearing date
+	DecisionDate  *string     `json:"decision_date,omitempty"`  // When court decision was made
+	ServedDate    *string     `json:"served_date,omitempty"`    // When documents were served
+	SignatureDate *string     `json:"signature_date,omitempty"` // When document was signed/executed (latest if several)
+	DateRanges    []DateRange `json:"date_ranges,omitempty"`    // Multi-day events
 	
 	Status      string      `json:"status,omitempty"`
 
@@ -78,6 +101,9 @@ type ClassificationResult struct {
 	Success        bool                   `json:"success"`
 	Error          string                 `json:"error,omitempty"`
 	ProcessingTime int64                  `json:"processing_time_ms"`
+
+	// Usage is the token count reported by the provider, when it reports one
+	Usage *TokenUsage `json:"usage,omitempty"`
 }
 
 // CaseInfo contains case-related information extracted from documents
@@ -17,6 +17,7 @@ type ollamaClassifier struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	examples   []FewShotExample
 }
 
 // NewOllamaClassifier creates a new Ollama-based classifier
@@ -42,6 +43,7 @@ func NewOllamaClassifier(config *OllamaConfig) (Classifier, error) {
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		examples: config.Examples,
 	}, nil
 }
 
@@ -51,7 +53,7 @@ func (o *ollamaClassifier) Classify(ctx context.Context, text string, metadata *
 	prompt := o.buildClassificationPrompt(text, metadata)
 
 	// Make request to Ollama
-	response, err := o.makeOllamaRequest(ctx, prompt)
+	response, usage, err := o.makeOllamaRequest(ctx, prompt, modelFor(metadata, o.model))
 	if err != nil {
 		return nil, NewClassificationError("ollama_request", "failed to classify document", err)
 	}
@@ -61,6 +63,7 @@ func (o *ollamaClassifier) Classify(ctx context.Context, text string, metadata *
 	if err != nil {
 		return nil, NewClassificationError("response_parsing", "failed to parse classification response", err)
 	}
+	result.Usage = usage
 
 	return result, nil
 }
@@ -78,15 +81,18 @@ func (o *ollamaClassifier) IsConfigured() bool {
 // buildClassificationPrompt creates a prompt for Ollama classification using unified prompts
 func (o *ollamaClassifier) buildClassificationPrompt(text string, metadata *DocumentMetadata) string {
 	// Use the unified prompt builder with Ollama-specific configuration
-	config := DefaultPromptConfigs["ollama"]
-	if config == nil {
-		config = &PromptConfig{
-			Model:         o.model,
-			MaxTextLength: 8000,
-			IncludeContext: false,
-			DetailLevel:   "standard",
-		}
+	config := &PromptConfig{
+		Model:         o.model,
+		MaxTextLength: 8000,
+		IncludeContext: false,
+		DetailLevel:   "standard",
+	}
+	if defaults := DefaultPromptConfigs["ollama"]; defaults != nil {
+		copied := *defaults
+		config = &copied
 	}
+	config.MaxTextLength = textWindowFor(metadata, config.MaxTextLength)
+	config.Examples = o.examples
 	
 	builder := NewPromptBuilder(config)
 	return builder.BuildClassificationPrompt(text, metadata)
@@ -103,57 +109,69 @@ type ollamaResponse struct {
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
 	Error    string `json:"error,omitempty"`
+
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"` // Prompt tokens
+	EvalCount       int `json:"eval_count,omitempty"`        // Generated tokens
 }
 
 // makeOllamaRequest sends a request to Ollama's API
-func (o *ollamaClassifier) makeOllamaRequest(ctx context.Context, prompt string) (string, error) {
+func (o *ollamaClassifier) makeOllamaRequest(ctx context.Context, prompt, model string) (string, *TokenUsage, error) {
 	reqBody := ollamaRequest{
-		Model:  o.model,
+		Model:  model,
 		Prompt: prompt,
 		Stream: false, // We want the complete response
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return "", nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama API returned status %d: %s", resp.StatusCode, string(body))
+		return "", nil, fmt.Errorf("Ollama API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var ollamaResp ollamaResponse
 	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if ollamaResp.Error != "" {
-		return "", fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
+		return "", nil, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
 	if !ollamaResp.Done {
-		return "", fmt.Errorf("Ollama response not complete")
+		return "", nil, fmt.Errorf("Ollama response not complete")
+	}
+
+	var usage *TokenUsage
+	if ollamaResp.PromptEvalCount > 0 || ollamaResp.EvalCount > 0 {
+		usage = &TokenUsage{
+			PromptTokens:     ollamaResp.PromptEvalCount,
+			CompletionTokens: ollamaResp.EvalCount,
+			TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
+		}
 	}
 
-	return ollamaResp.Response, nil
+	return ollamaResp.Response, usage, nil
 }
 
 // parseClassificationResponse parses the Ollama response into a ClassificationResult
@@ -218,6 +236,12 @@ func (o *ollamaClassifier) parseClassificationResponse(response string) (*Classi
 			result.ServedDate = nil
 		}
 	}
+	if result.SignatureDate != nil {
+		if !dateExtractor.validateDate(*result.SignatureDate, "signature_date") {
+			log.Printf("[OLLAMA] Invalid signature_date: %s, setting to nil", *result.SignatureDate)
+			result.SignatureDate = nil
+		}
+	}
 
 	// Validate document type against known types
 	validTypes := GetDefaultDocumentTypes()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,9 +17,20 @@ import (
 type openaiClassifier struct {
 	apiKey     string
 	model      string
+	baseURL    string
+	maxRetries int
 	httpClient *http.Client
+
+	// rateLimitRetries and maxRateLimitWait bound how long 429s are waited out
+	rateLimitRetries int
+	maxRateLimitWait time.Duration
+
+	breaker  *CircuitBreaker
+	examples []FewShotExample
 }
 
+const defaultOpenAIBaseURL = "https://api.openai.com"
+
 // NewOpenAIClassifier creates a new OpenAI-based classifier
 func NewOpenAIClassifier(config *Config) (Classifier, error) {
 	if config.APIKey == "" {
@@ -35,12 +47,38 @@ func NewOpenAIClassifier(config *Config) (Classifier, error) {
 		timeout = 30 * time.Second
 	}
 
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+
+	maxRetries := config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 5
+	}
+
+	rateLimitRetries := config.RateLimitRetries
+	if rateLimitRetries <= 0 {
+		rateLimitRetries = DefaultRateLimitRetries
+	}
+	maxRateLimitWait := config.MaxRateLimitWait
+	if maxRateLimitWait <= 0 {
+		maxRateLimitWait = DefaultMaxRateLimitWait
+	}
+
 	return &openaiClassifier{
-		apiKey: config.APIKey,
-		model:  model,
+		apiKey:     config.APIKey,
+		model:      model,
+		baseURL:    baseURL,
+		maxRetries: maxRetries,
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		breaker:  NewCircuitBreaker("openai", config.Breaker),
+		examples: config.Examples,
+
+		rateLimitRetries: rateLimitRetries,
+		maxRateLimitWait: maxRateLimitWait,
 	}, nil
 }
 
@@ -49,21 +87,50 @@ func (c *openaiClassifier) Classify(ctx context.Context, text string, metadata *
 	// Create the classification prompt
 	prompt := c.buildClassificationPrompt(text, metadata)
 
+	// Fail fast while OpenAI is known to be down
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	// A call that records no outcome releases the breaker, so a half-open
+	// probe can't hold the circuit half-open
+	recorded := false
+	defer func() {
+		if !recorded {
+			c.breaker.Release()
+		}
+	}()
+
 	// Make request to OpenAI
-	response, err := c.makeOpenAIRequest(ctx, prompt)
+	response, usage, err := c.makeOpenAIRequest(ctx, prompt, modelFor(metadata, c.model))
 	if err != nil {
+		// A caller cancelling the request says nothing about OpenAI's health,
+		// nor does a rate limit, which the request has already waited out
+		rateErr, limited := AsRateLimitError(err)
+		if !errors.Is(err, context.Canceled) && (!limited || rateErr.InsufficientQuota) {
+			c.breaker.RecordFailure()
+			recorded = true
+		}
 		return nil, NewClassificationError("openai_request", "failed to classify document", err)
 	}
+	c.breaker.RecordSuccess()
+	recorded = true
 
 	// Parse the response
 	result, err := c.parseClassificationResponse(response)
 	if err != nil {
 		return nil, NewClassificationError("response_parsing", "failed to parse classification response", err)
 	}
+	result.Usage = usage
 
 	return result, nil
 }
 
+// BreakerStatuses reports the state of the OpenAI circuit breaker
+func (c *openaiClassifier) BreakerStatuses() []*BreakerStatus {
+	return []*BreakerStatus{c.breaker.Status()}
+}
+
 // GetSupportedCategories returns the categories this classifier can identify
 func (c *openaiClassifier) GetSupportedCategories() []string {
 	return GetDefaultCategories()
@@ -77,18 +144,19 @@ func (c *openaiClassifier) IsConfigured() bool {
 // buildClassificationPrompt creates an enhanced prompt for OpenAI classification using unified prompts
 func (c *openaiClassifier) buildClassificationPrompt(text string, metadata *DocumentMetadata) string {
 	// Use the unified prompt builder with OpenAI-specific configuration
-	config := DefaultPromptConfigs["openai"]
-	if config == nil {
-		config = &PromptConfig{
-			Model:         c.model,
-			MaxTextLength: c.calculateOptimalTextLength(metadata),
-			IncludeContext: true,
-			DetailLevel:   "comprehensive",
-		}
-	} else {
-		// Update max text length based on document characteristics
-		config.MaxTextLength = c.calculateOptimalTextLength(metadata)
-	}
+	config := &PromptConfig{
+		Model:         c.model,
+		IncludeContext: true,
+		DetailLevel:   "comprehensive",
+	}
+	if defaults := DefaultPromptConfigs["openai"]; defaults != nil {
+		// Copy so per-document settings don't leak into the shared defaults
+		copied := *defaults
+		config = &copied
+	}
+	// Update max text length based on document characteristics
+	config.MaxTextLength = textWindowFor(metadata, c.calculateOptimalTextLength(metadata))
+	config.Examples = c.examples
 	
 	builder := NewPromptBuilder(config)
 	return builder.BuildClassificationPrompt(text, metadata)
@@ -113,19 +181,38 @@ type openaiResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
 	} `json:"error,omitempty"`
 }
 
-// makeOpenAIRequest sends a request to OpenAI's API with retry logic and rate limiting
-func (c *openaiClassifier) makeOpenAIRequest(ctx context.Context, prompt string) (string, error) {
+// makeOpenAIRequest sends a request to OpenAI's API, waiting out the rate
+// limits OpenAI reports for up to rateLimitRetries further attempts
+func (c *openaiClassifier) makeOpenAIRequest(ctx context.Context, prompt, model string) (string, *TokenUsage, error) {
+	var response string
+	var usage *TokenUsage
+	err := withRateLimitBackoff(ctx, c.rateLimitRetries, c.maxRateLimitWait, func() error {
+		var err error
+		response, usage, err = c.retryOpenAIRequest(ctx, prompt, model)
+		return err
+	})
+	return response, usage, err
+}
+
+// retryOpenAIRequest sends a request to OpenAI's API with retry logic for
+// server and connection errors
+func (c *openaiClassifier) retryOpenAIRequest(ctx context.Context, prompt, model string) (string, *TokenUsage, error) {
 	const (
-		maxRetries = 5
-		baseDelay  = 2 * time.Second
-		maxDelay   = 60 * time.Second
+		baseDelay = 2 * time.Second
+		maxDelay  = 60 * time.Second
 	)
+	maxRetries := c.maxRetries
 
 	var lastErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
@@ -138,32 +225,32 @@ func (c *openaiClassifier) makeOpenAIRequest(ctx context.Context, prompt string)
 			
 			select {
 			case <-ctx.Done():
-				return "", ctx.Err()
+				return "", nil, ctx.Err()
 			case <-time.After(delay):
 				// Continue with retry
 			}
 		}
 
-		response, err := c.doOpenAIRequest(ctx, prompt)
+		response, usage, err := c.doOpenAIRequest(ctx, prompt, model)
 		if err == nil {
-			return response, nil
+			return response, usage, nil
 		}
 
 		lastErr = err
 		
 		// Check if this is a retryable error
 		if !c.isRetryableError(err) {
-			return "", err
+			return "", nil, err
 		}
 	}
 
-	return "", fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	return "", nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
 }
 
 // doOpenAIRequest performs a single request to OpenAI's API
-func (c *openaiClassifier) doOpenAIRequest(ctx context.Context, prompt string) (string, error) {
+func (c *openaiClassifier) doOpenAIRequest(ctx context.Context, prompt, model string) (string, *TokenUsage, error) {
 	reqBody := openaiRequest{
-		Model: c.model,
+		Model: model,
 		Messages: []openaiMessage{
 			{
 				Role:    "user",
@@ -176,12 +263,12 @@ func (c *openaiClassifier) doOpenAIRequest(ctx context.Context, prompt string) (
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -189,33 +276,45 @@ func (c *openaiClassifier) doOpenAIRequest(ctx context.Context, prompt string) (
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return "", nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", nil, newRateLimitError(resp, body, time.Now())
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API returned status %d: %s", resp.StatusCode, string(body))
+		return "", nil, fmt.Errorf("OpenAI API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var openaiResp openaiResponse
 	if err := json.Unmarshal(body, &openaiResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if openaiResp.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s", openaiResp.Error.Message)
+		return "", nil, fmt.Errorf("OpenAI API error: %s", openaiResp.Error.Message)
 	}
 
 	if len(openaiResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices returned from OpenAI")
+		return "", nil, fmt.Errorf("no choices returned from OpenAI")
+	}
+
+	var usage *TokenUsage
+	if openaiResp.Usage != nil {
+		usage = &TokenUsage{
+			PromptTokens:     openaiResp.Usage.PromptTokens,
+			CompletionTokens: openaiResp.Usage.CompletionTokens,
+			TotalTokens:      openaiResp.Usage.TotalTokens,
+		}
 	}
 
-	return openaiResp.Choices[0].Message.Content, nil
+	return openaiResp.Choices[0].Message.Content, usage, nil
 }
 
 // parseClassificationResponse parses the enhanced OpenAI response into a ClassificationResult
@@ -289,6 +388,12 @@ func (c *openaiClassifier) parseClassificationResponse(response string) (*Classi
 			result.ServedDate = nil
 		}
 	}
+	if result.SignatureDate != nil {
+		if !dateExtractor.validateDate(*result.SignatureDate, "signature_date") {
+			log.Printf("[OPENAI] Invalid signature_date: %s, setting to nil", *result.SignatureDate)
+			result.SignatureDate = nil
+		}
+	}
 
 	// Validate document type against known types
 	validTypes := GetDefaultDocumentTypes()
@@ -434,12 +539,13 @@ func (c *openaiClassifier) isRetryableError(err error) bool {
 		return false
 	}
 
-	errStr := err.Error()
-	
-	// Retry on rate limit errors (429)
-	if strings.Contains(errStr, "status 429") {
-		return true
+	// Rate limits are waited out by makeOpenAIRequest, which knows how long
+	// OpenAI asked to wait
+	if _, limited := AsRateLimitError(err); limited {
+		return false
 	}
+
+	errStr := err.Error()
 	
 	// Retry on server errors (5xx)
 	if strings.Contains(errStr, "status 5") {
@@ -11,6 +11,7 @@ type PromptConfig struct {
 	MaxTextLength   int
 	IncludeContext  bool
 	DetailLevel     string // "minimal", "standard", "comprehensive"
+	Examples        []FewShotExample // Few-shot examples shown before the document
 }
 
 // DefaultPromptConfigs contains default configurations for different models
@@ -65,6 +66,10 @@ Extract ALL relevant dates with high precision. Look for:
    - Look for: "served on", "service date", "personally served", "mailed on"
    - Important for calculating response deadlines
 
+6. SIGNATURE_DATE: When the document was signed or executed
+   - Look for: "Dated:", "Executed on", "Signed this ___ day of", dates beside signature lines
+   - Often differs from the filing date; if several signatures are dated, use the LATEST one
+
 DATE PARSING RULES:
 - Convert all dates to YYYY-MM-DD format
 - If only month/year available, use first day of month: YYYY-MM-01
@@ -150,6 +155,7 @@ Extract entities with confidence scores:
   "hearing_date": "<YYYY-MM-DD format or null>",
   "decision_date": "<YYYY-MM-DD format or null>",
   "served_date": "<YYYY-MM-DD format or null>",
+  "signature_date": "<YYYY-MM-DD format or null>",
   "status": "<filed|granted|denied|pending|served>",
   "entities": [
     {
@@ -220,6 +226,9 @@ func (pb *PromptBuilder) BuildClassificationPrompt(text string, metadata *Docume
 	// Get summarization rules
 	summaryRules := pb.buildSummarizationRules()
 
+	// Examples share the text budget so the prompt stays within the model's limit
+	examplesSection := buildExamplesSection(pb.config.Examples, pb.config.MaxTextLength/exampleBudgetFraction)
+
 	// Build the complete prompt
 	prompt := fmt.Sprintf(`%s
 
@@ -244,6 +253,8 @@ DOCUMENT-SPECIFIC SUMMARIZATION REQUIREMENTS:
 
 %s
 
+%s
+
 Document text:
 %s
 
@@ -259,6 +270,7 @@ Use null for any field that cannot be determined from the document text.`,
 		EntityExtractionGuidelines,
 		pb.getModelSpecificInstructions(),
 		summaryRules,
+		examplesSection,
 		text,
 		JSONResponseSchema,
 	)
@@ -0,0 +1,124 @@
+package classifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default rate limit backoff settings
+const (
+	DefaultRateLimitRetries = 3
+	DefaultRateLimitWait    = 10 * time.Second // First wait when the provider sends no Retry-After
+	DefaultMaxRateLimitWait = 60 * time.Second
+)
+
+// RateLimitError is a request the provider refused with 429 Too Many
+// Requests. RetryAfter is how long the provider asked callers to wait, zero
+// when it did not say. InsufficientQuota means the account is out of credit,
+// which waiting cannot fix.
+type RateLimitError struct {
+	StatusCode        int
+	RetryAfter        time.Duration
+	InsufficientQuota bool
+	Body              string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("OpenAI API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// AsRateLimitError returns the rate limit error in err's chain, if any
+func AsRateLimitError(err error) (*RateLimitError, bool) {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr, true
+	}
+	return nil, false
+}
+
+// newRateLimitError builds the error for a 429 response from its headers and body
+func newRateLimitError(resp *http.Response, body []byte, now time.Time) *RateLimitError {
+	var payload struct {
+		Error *struct {
+			Type string `json:"type"`
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	insufficientQuota := false
+	if json.Unmarshal(body, &payload) == nil && payload.Error != nil {
+		insufficientQuota = payload.Error.Code == "insufficient_quota" || payload.Error.Type == "insufficient_quota"
+	} else {
+		insufficientQuota = strings.Contains(string(body), "insufficient_quota")
+	}
+
+	return &RateLimitError{
+		StatusCode:        resp.StatusCode,
+		RetryAfter:        parseRetryAfter(resp.Header.Get("Retry-After"), now),
+		InsufficientQuota: insufficientQuota,
+		Body:              string(body),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date. It returns zero when the header is missing or unreadable.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(header, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// rateLimitWait returns how long to wait before retry attempt (1 for the
+// first retry) of a rate-limited request: the provider's Retry-After when it
+// sent one, otherwise a doubling default, never more than maxWait
+func rateLimitWait(rateErr *RateLimitError, attempt int, maxWait time.Duration) time.Duration {
+	wait := rateErr.RetryAfter
+	if wait <= 0 {
+		wait = DefaultRateLimitWait << (attempt - 1)
+	}
+	if wait > maxWait || wait <= 0 {
+		wait = maxWait
+	}
+	return wait
+}
+
+// withRateLimitBackoff runs call, waiting out rate limits the provider
+// reports for up to retries further attempts. An account without quota fails
+// at once. A context that ends while waiting fails with both its error and
+// the rate limit, so callers can still tell the provider refused the call.
+func withRateLimitBackoff(ctx context.Context, retries int, maxWait time.Duration, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		rateErr, limited := AsRateLimitError(err)
+		if !limited || rateErr.InsufficientQuota || attempt >= retries {
+			return err
+		}
+
+		wait := rateLimitWait(rateErr, attempt+1, maxWait)
+		log.Printf("[CLASSIFIER] ⏳ Rate limited, retrying in %v (retry %d of %d)", wait, attempt+1, retries)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w while waiting out %w", ctx.Err(), rateErr)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package classifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 20*time.Second, parseRetryAfter("20", now))
+	assert.Equal(t, 1500*time.Millisecond, parseRetryAfter("1.5", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+	assert.Zero(t, parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now), "a date in the past asks for no wait")
+}
+
+func TestRateLimitWait(t *testing.T) {
+	assert.Equal(t, 5*time.Second, rateLimitWait(&RateLimitError{RetryAfter: 5 * time.Second}, 1, time.Minute))
+	assert.Equal(t, time.Minute, rateLimitWait(&RateLimitError{RetryAfter: time.Hour}, 1, time.Minute), "Retry-After is capped")
+	assert.Equal(t, DefaultRateLimitWait, rateLimitWait(&RateLimitError{}, 1, time.Minute))
+	assert.Equal(t, 2*DefaultRateLimitWait, rateLimitWait(&RateLimitError{}, 2, time.Minute))
+	assert.Equal(t, time.Minute, rateLimitWait(&RateLimitError{}, 64, time.Minute))
+}
+
+// newRateLimitedOpenAIServer answers the first limited requests with 429 and body
+func newRateLimitedOpenAIServer(limited int32, body string, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= limited {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(body))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"document_type\":\"motion_to_dismiss\",\"legal_category\":\"criminal\",\"confidence\":0.9}"}}]}`))
+	}))
+}
+
+func TestService_ClassifyDocument_WaitsOutRateLimits(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(2, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, &calls)
+	defer server.Close()
+
+	svc, err := NewService(&Config{
+		Provider:         "openai",
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		MaxRetries:       1,
+		Breaker:          &BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute},
+		RateLimitRetries: 3,
+		MaxRateLimitWait: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	result, err := svc.ClassifyDocument(context.Background(), "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "motion_to_dismiss", result.DocumentType)
+	assert.Equal(t, int32(3), calls.Load(), "two rate-limited attempts, then success")
+
+	breakers := svc.(BreakerReporter).BreakerStatuses()
+	assert.Equal(t, BreakerStateClosed, breakers[0].State, "rate limits do not trip the breaker")
+}
+
+func TestOpenAIClassifier_RateLimitedProbeReleasesCircuit(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(2, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`, &calls)
+	defer server.Close()
+
+	c, err := NewOpenAIClassifier(&Config{
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		MaxRetries:       1,
+		Breaker:          &BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute},
+		RateLimitRetries: 1,
+		MaxRateLimitWait: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: time.Now()}
+	openai := c.(*openaiClassifier)
+	openai.breaker.now = clock.Now
+	openai.breaker.RecordFailure()
+	clock.Advance(time.Minute)
+
+	// The probe is rate limited, which neither closes nor re-opens the circuit
+	_, err = c.Classify(context.Background(), "MOTION TO DISMISS", nil)
+	_, limited := AsRateLimitError(err)
+	require.True(t, limited)
+	assert.NotEqual(t, BreakerStateHalfOpen, openai.breaker.State())
+
+	// so the next call probes again rather than failing fast
+	result, err := c.Classify(context.Background(), "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DocumentTypeMotionToDismiss, result.DocumentType)
+	assert.Equal(t, BreakerStateClosed, openai.breaker.State())
+}
+
+func TestService_ClassifyDocument_GivesUpAfterRateLimitRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(10, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`, &calls)
+	defer server.Close()
+
+	svc, err := NewService(&Config{
+		Provider:         "openai",
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		MaxRetries:       3,
+		RateLimitRetries: 2,
+		MaxRateLimitWait: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.ClassifyDocument(context.Background(), "MOTION TO DISMISS", nil)
+	require.Error(t, err)
+	rateErr, ok := AsRateLimitError(err)
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, rateErr.RetryAfter)
+	assert.Equal(t, int32(3), calls.Load(), "one request per attempt; server error retries are not spent on rate limits")
+}
+
+func TestServiceWrapper_ClassifyDocument_WaitsOutRateLimits(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(2, `{"error":{"message":"Rate limit reached","code":"rate_limit_exceeded"}}`, &calls)
+	defer server.Close()
+
+	// The fallback chain and single-provider setups wrap classifiers directly
+	openai, err := NewOpenAIClassifier(&Config{
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		MaxRetries:       1,
+		RateLimitRetries: 3,
+		MaxRateLimitWait: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	svc := &ServiceWrapper{Classifier: openai}
+
+	result, err := svc.ClassifyDocument(context.Background(), "MOTION TO DISMISS", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DocumentTypeMotionToDismiss, result.DocumentType)
+	assert.Equal(t, int32(3), calls.Load(), "two rate-limited attempts, then success")
+}
+
+func TestService_ClassifyDocument_InsufficientQuotaFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(10, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, &calls)
+	defer server.Close()
+
+	svc, err := NewService(&Config{
+		Provider:         "openai",
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		RateLimitRetries: 3,
+		MaxRateLimitWait: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.ClassifyDocument(context.Background(), "MOTION TO DISMISS", nil)
+	require.Error(t, err)
+	rateErr, ok := AsRateLimitError(err)
+	require.True(t, ok)
+	assert.True(t, rateErr.InsufficientQuota)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestService_ClassifyDocument_RateLimitWaitHonorsContext(t *testing.T) {
+	var calls atomic.Int32
+	server := newRateLimitedOpenAIServer(10, `{"error":{"message":"Rate limit reached"}}`, &calls)
+	defer server.Close()
+
+	svc, err := NewService(&Config{
+		Provider:         "openai",
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		MaxRateLimitWait: time.Minute,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = svc.ClassifyDocument(ctx, "MOTION TO DISMISS", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
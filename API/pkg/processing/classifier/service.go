@@ -17,22 +17,37 @@ type Config struct {
 	Provider   string        `json:"provider"` // "openai", "claude", "ollama", "fallback", "mock", etc.
 	APIKey     string        `json:"api_key"`
 	Model      string        `json:"model"`
-	MaxRetries int           `json:"max_retries"`
+	MaxRetries int           `json:"max_retries"` // Attempts per API call, including the first
 	Timeout    time.Duration `json:"timeout"`
+	BaseURL    string        `json:"base_url,omitempty"`
+
+	// Circuit breaker around API calls; zero values use the defaults
+	Breaker *BreakerConfig `json:"breaker,omitempty"`
+
+	// Rate limits: how many times a classification refused with 429 is
+	// retried, and the longest wait before a retry, whatever Retry-After
+	// asks. Zero values use DefaultRateLimitRetries and DefaultMaxRateLimitWait.
+	RateLimitRetries int           `json:"rate_limit_retries,omitempty"`
+	MaxRateLimitWait time.Duration `json:"max_rate_limit_wait,omitempty"`
+
+	// Examples are injected into every classification prompt
+	Examples []FewShotExample `json:"-"`
 }
 
 // ClaudeConfig holds configuration for Claude API
 type ClaudeConfig struct {
-	APIKey  string `json:"api_key"`
-	Model   string `json:"model"`
-	BaseURL string `json:"base_url"`
+	APIKey   string           `json:"api_key"`
+	Model    string           `json:"model"`
+	BaseURL  string           `json:"base_url"`
+	Examples []FewShotExample `json:"-"`
 }
 
 // OllamaConfig holds configuration for Ollama local models
 type OllamaConfig struct {
-	BaseURL string        `json:"base_url"`
-	Model   string        `json:"model"`
-	Timeout time.Duration `json:"timeout"`
+	BaseURL  string           `json:"base_url"`
+	Model    string           `json:"model"`
+	Timeout  time.Duration    `json:"timeout"`
+	Examples []FewShotExample `json:"-"`
 }
 
 // FallbackConfig holds configuration for fallback classification service
@@ -60,16 +75,18 @@ func NewService(config *Config) (Service, error) {
 		classifier, err = NewOpenAIClassifier(config)
 	case "claude":
 		claudeConfig := &ClaudeConfig{
-			APIKey:  config.APIKey,
-			Model:   config.Model,
-			BaseURL: "https://api.anthropic.com",
+			APIKey:   config.APIKey,
+			Model:    config.Model,
+			BaseURL:  "https://api.anthropic.com",
+			Examples: config.Examples,
 		}
 		classifier, err = NewClaudeClassifier(claudeConfig)
 	case "ollama":
 		ollamaConfig := &OllamaConfig{
-			BaseURL: "http://localhost:11434",
-			Model:   config.Model,
-			Timeout: config.Timeout,
+			BaseURL:  "http://localhost:11434",
+			Model:    config.Model,
+			Timeout:  config.Timeout,
+			Examples: config.Examples,
 		}
 		classifier, err = NewOllamaClassifier(ollamaConfig)
 	case "mock":
@@ -129,6 +146,14 @@ func (s *service) ClassifyDocument(ctx context.Context, text string, metadata *D
 	return result, nil
 }
 
+// BreakerStatuses reports circuit breaker state from the underlying classifier
+func (s *service) BreakerStatuses() []*BreakerStatus {
+	if reporter, ok := s.classifier.(BreakerReporter); ok {
+		return reporter.BreakerStatuses()
+	}
+	return nil
+}
+
 // GetAvailableCategories returns all available classification categories
 func (s *service) GetAvailableCategories() []string {
 	if s.classifier != nil {
@@ -0,0 +1,121 @@
+package classifier
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenUsage is the token count for a single classification call
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// UsageSummary totals token usage over many classifications
+type UsageSummary struct {
+	Documents                int64   `json:"documents"` // Classifications that reported usage
+	PromptTokens             int64   `json:"prompt_tokens"`
+	CompletionTokens         int64   `json:"completion_tokens"`
+	TotalTokens              int64   `json:"total_tokens"`
+	AverageTokensPerDocument float64 `json:"average_tokens_per_document"`
+	EstimatedCostUSD         float64 `json:"estimated_cost_usd"`
+}
+
+// UsageReporter is implemented by services that meter token usage
+type UsageReporter interface {
+	Usage() *UsageSummary
+}
+
+// UsageMeter accumulates token usage and prices it. Prices are USD per 1,000
+// tokens; leave them at zero when the provider is free or the price is unknown.
+type UsageMeter struct {
+	promptPricePer1K     float64
+	completionPricePer1K float64
+
+	mu               sync.Mutex
+	documents        int64
+	promptTokens     int64
+	completionTokens int64
+	totalTokens      int64
+}
+
+// NewUsageMeter creates a meter with the given per-1K-token prices
+func NewUsageMeter(promptPricePer1K, completionPricePer1K float64) *UsageMeter {
+	return &UsageMeter{
+		promptPricePer1K:     promptPricePer1K,
+		completionPricePer1K: completionPricePer1K,
+	}
+}
+
+// Record adds one classification's usage; nil usage is ignored
+func (m *UsageMeter) Record(usage *TokenUsage) {
+	if usage == nil {
+		return
+	}
+
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.documents++
+	m.promptTokens += int64(usage.PromptTokens)
+	m.completionTokens += int64(usage.CompletionTokens)
+	m.totalTokens += int64(total)
+}
+
+// Summary returns the totals recorded so far
+func (m *UsageMeter) Summary() *UsageSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary := &UsageSummary{
+		Documents:        m.documents,
+		PromptTokens:     m.promptTokens,
+		CompletionTokens: m.completionTokens,
+		TotalTokens:      m.totalTokens,
+		EstimatedCostUSD: float64(m.promptTokens)/1000*m.promptPricePer1K +
+			float64(m.completionTokens)/1000*m.completionPricePer1K,
+	}
+	if m.documents > 0 {
+		summary.AverageTokensPerDocument = float64(m.totalTokens) / float64(m.documents)
+	}
+	return summary
+}
+
+// MeteredService records the token usage of every classification made
+// through the wrapped service
+type MeteredService struct {
+	Service
+	meter *UsageMeter
+}
+
+// NewMeteredService wraps a classification service with a usage meter
+func NewMeteredService(service Service, meter *UsageMeter) *MeteredService {
+	return &MeteredService{Service: service, meter: meter}
+}
+
+// ClassifyDocument classifies through the wrapped service and records usage
+func (m *MeteredService) ClassifyDocument(ctx context.Context, text string, metadata *DocumentMetadata) (*ClassificationResult, error) {
+	result, err := m.Service.ClassifyDocument(ctx, text, metadata)
+	if result != nil {
+		m.meter.Record(result.Usage)
+	}
+	return result, err
+}
+
+// Usage returns the totals recorded across all classifications
+func (m *MeteredService) Usage() *UsageSummary {
+	return m.meter.Summary()
+}
+
+// BreakerStatuses reports circuit breaker state from the wrapped service
+func (m *MeteredService) BreakerStatuses() []*BreakerStatus {
+	if reporter, ok := m.Service.(BreakerReporter); ok {
+		return reporter.BreakerStatuses()
+	}
+	return nil
+}
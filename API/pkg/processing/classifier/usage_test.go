@@ -0,0 +1,46 @@
+package classifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageMeter_Summary(t *testing.T) {
+	meter := NewUsageMeter(0.5, 1.5)
+	meter.Record(&TokenUsage{PromptTokens: 2000, CompletionTokens: 1000, TotalTokens: 3000})
+	meter.Record(&TokenUsage{PromptTokens: 1000, CompletionTokens: 0})
+	meter.Record(nil)
+
+	summary := meter.Summary()
+	assert.Equal(t, int64(2), summary.Documents)
+	assert.Equal(t, int64(3000), summary.PromptTokens)
+	assert.Equal(t, int64(1000), summary.CompletionTokens)
+	assert.Equal(t, int64(4000), summary.TotalTokens)
+	assert.InDelta(t, 2000, summary.AverageTokensPerDocument, 0.001)
+	assert.InDelta(t, 3.0, summary.EstimatedCostUSD, 0.0001)
+
+	assert.Zero(t, NewUsageMeter(1, 1).Summary().AverageTokensPerDocument)
+}
+
+func TestOpenAIClassifier_ReportsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"document_type\":\"writ\",\"legal_category\":\"criminal\",\"confidence\":0.9}"}}],"usage":{"prompt_tokens":812,"completion_tokens":64,"total_tokens":876}}`))
+	}))
+	defer server.Close()
+
+	c, err := NewOpenAIClassifier(&Config{APIKey: "test-key", BaseURL: server.URL, MaxRetries: 1})
+	require.NoError(t, err)
+
+	result, err := c.Classify(context.Background(), "PETITION FOR WRIT OF MANDATE", nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.Usage)
+	assert.Equal(t, 812, result.Usage.PromptTokens)
+	assert.Equal(t, 64, result.Usage.CompletionTokens)
+	assert.Equal(t, 876, result.Usage.TotalTokens)
+}
@@ -30,6 +30,14 @@ func (sw *ServiceWrapper) IsHealthy() bool {
 	return sw.Classifier.IsConfigured()
 }
 
+// BreakerStatuses reports circuit breaker state from the wrapped classifier
+func (sw *ServiceWrapper) BreakerStatuses() []*BreakerStatus {
+	if reporter, ok := sw.Classifier.(BreakerReporter); ok {
+		return reporter.BreakerStatuses()
+	}
+	return nil
+}
+
 // ValidateResult validates a classification result (required by Service interface)
 func (sw *ServiceWrapper) ValidateResult(result *ClassificationResult) error {
 	if result == nil {
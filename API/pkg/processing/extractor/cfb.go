@@ -0,0 +1,296 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Compound File Binary (OLE2) constants, as used by Outlook .msg files
+const (
+	cfbHeaderSize     = 512
+	cfbDirEntrySize   = 128
+	cfbHeaderFATSlots = 109
+	cfbFreeSector     = 0xFFFFFFFF
+	cfbEndOfChain     = 0xFFFFFFFE
+	cfbNoEntry        = 0xFFFFFFFF
+
+	cfbTypeStorage = 1
+	cfbTypeStream  = 2
+	cfbTypeRoot    = 5
+)
+
+// cfbSignature opens every compound file
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// compoundFile reads the streams of a Compound File Binary container. It
+// holds the whole file in memory, which is fine for email-sized files.
+type compoundFile struct {
+	data       []byte
+	sectorSize int
+	miniSize   int
+	miniCutoff uint64
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	entries    []cfbEntry
+}
+
+// cfbEntry is one directory entry: a storage (folder) or a stream (file)
+type cfbEntry struct {
+	name               string
+	kind               byte
+	left, right, child uint32
+	start              uint32
+	size               uint64
+}
+
+// isCompoundFile reports whether data starts with the compound file signature
+func isCompoundFile(data []byte) bool {
+	return bytes.HasPrefix(data, cfbSignature)
+}
+
+// openCompoundFile parses the header, allocation tables and directory of a
+// compound file
+func openCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < cfbHeaderSize || !isCompoundFile(data) {
+		return nil, fmt.Errorf("not a compound file")
+	}
+
+	le := binary.LittleEndian
+	sectorShift := le.Uint16(data[30:])
+	if sectorShift != 9 && sectorShift != 12 {
+		return nil, fmt.Errorf("unsupported sector size 2^%d", sectorShift)
+	}
+	cf := &compoundFile{
+		data:       data,
+		sectorSize: 1 << sectorShift,
+		miniSize:   1 << le.Uint16(data[32:]),
+		miniCutoff: uint64(le.Uint32(data[56:])),
+	}
+	numFATSectors := int(le.Uint32(data[44:]))
+	firstDirSector := le.Uint32(data[48:])
+	firstMiniFATSector := le.Uint32(data[60:])
+	firstDIFATSector := le.Uint32(data[68:])
+	numDIFATSectors := int(le.Uint32(data[72:]))
+
+	// Counts come straight from the header; a file cannot hold more sectors
+	// than its size allows, however many it claims
+	maxSectors := len(data) / cf.sectorSize
+	if numFATSectors > maxSectors {
+		numFATSectors = maxSectors
+	}
+	if numDIFATSectors > maxSectors {
+		numDIFATSectors = maxSectors
+	}
+
+	// The header lists the first 109 FAT sectors and the DIFAT chain the rest
+	var fatSectors []uint32
+	for i := 0; i < cfbHeaderFATSlots && len(fatSectors) < numFATSectors; i++ {
+		fatSectors = append(fatSectors, le.Uint32(data[76+4*i:]))
+	}
+	next := firstDIFATSector
+	visited := make(map[uint32]bool)
+	for i := 0; i < numDIFATSectors && next < cfbEndOfChain; i++ {
+		if visited[next] {
+			return nil, fmt.Errorf("DIFAT chain loops at sector %d", next)
+		}
+		visited[next] = true
+		sector, err := cf.sector(next)
+		if err != nil {
+			return nil, err
+		}
+		slots := cf.sectorSize/4 - 1
+		for j := 0; j < slots && len(fatSectors) < numFATSectors; j++ {
+			fatSectors = append(fatSectors, le.Uint32(sector[4*j:]))
+		}
+		next = le.Uint32(sector[4*slots:])
+	}
+	seen := make(map[uint32]bool, len(fatSectors))
+	for _, id := range fatSectors {
+		if seen[id] {
+			return nil, fmt.Errorf("FAT sector %d is listed twice", id)
+		}
+		seen[id] = true
+		sector, err := cf.sector(id)
+		if err != nil {
+			return nil, err
+		}
+		cf.fat = append(cf.fat, uint32s(sector)...)
+	}
+
+	dir, err := cf.chain(firstDirSector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	for offset := 0; offset+cfbDirEntrySize <= len(dir); offset += cfbDirEntrySize {
+		cf.entries = append(cf.entries, cf.parseEntry(dir[offset:offset+cfbDirEntrySize]))
+	}
+	if len(cf.entries) == 0 || cf.entries[0].kind != cfbTypeRoot {
+		return nil, fmt.Errorf("compound file has no root entry")
+	}
+
+	if firstMiniFATSector < cfbEndOfChain {
+		miniFAT, err := cf.chain(firstMiniFATSector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mini FAT: %w", err)
+		}
+		cf.miniFAT = uint32s(miniFAT)
+	}
+
+	// Streams below the cutoff live in the mini stream, which is the root
+	// entry's own stream
+	root := cf.entries[0]
+	if root.start < cfbEndOfChain {
+		miniStream, err := cf.chain(root.start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mini stream: %w", err)
+		}
+		if uint64(len(miniStream)) > root.size {
+			miniStream = miniStream[:root.size]
+		}
+		cf.miniStream = miniStream
+	}
+	return cf, nil
+}
+
+// parseEntry decodes a 128-byte directory entry
+func (cf *compoundFile) parseEntry(raw []byte) cfbEntry {
+	le := binary.LittleEndian
+	nameLength := int(le.Uint16(raw[64:]))
+	if nameLength > 64 {
+		nameLength = 64
+	}
+	units := make([]uint16, 0, nameLength/2)
+	for i := 0; i+1 < nameLength; i += 2 {
+		if unit := le.Uint16(raw[i:]); unit != 0 {
+			units = append(units, unit)
+		}
+	}
+
+	size := le.Uint64(raw[120:])
+	if cf.sectorSize == 512 {
+		// Version 3 files may leave garbage in the high half
+		size &= 0xFFFFFFFF
+	}
+	return cfbEntry{
+		name:  string(utf16.Decode(units)),
+		kind:  raw[66],
+		left:  le.Uint32(raw[68:]),
+		right: le.Uint32(raw[72:]),
+		child: le.Uint32(raw[76:]),
+		start: le.Uint32(raw[116:]),
+		size:  size,
+	}
+}
+
+// sector returns the bytes of a regular sector; sector 0 follows the header
+func (cf *compoundFile) sector(id uint32) ([]byte, error) {
+	offset := (int(id) + 1) * cf.sectorSize
+	if id >= cfbEndOfChain || offset+cf.sectorSize > len(cf.data) {
+		return nil, fmt.Errorf("sector %d is out of range", id)
+	}
+	return cf.data[offset : offset+cf.sectorSize], nil
+}
+
+// chain concatenates the regular sectors of a FAT chain
+func (cf *compoundFile) chain(start uint32) ([]byte, error) {
+	var out []byte
+	visited := make(map[uint32]bool)
+	for id := start; id != cfbEndOfChain; {
+		if visited[id] || int(id) >= len(cf.fat) {
+			return nil, fmt.Errorf("broken sector chain at %d", id)
+		}
+		visited[id] = true
+		sector, err := cf.sector(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sector...)
+		id = cf.fat[id]
+	}
+	return out, nil
+}
+
+// miniChain concatenates the mini sectors of a mini FAT chain
+func (cf *compoundFile) miniChain(start uint32) ([]byte, error) {
+	var out []byte
+	visited := make(map[uint32]bool)
+	for id := start; id != cfbEndOfChain; {
+		offset := int(id) * cf.miniSize
+		if visited[id] || int(id) >= len(cf.miniFAT) || offset+cf.miniSize > len(cf.miniStream) {
+			return nil, fmt.Errorf("broken mini sector chain at %d", id)
+		}
+		visited[id] = true
+		out = append(out, cf.miniStream[offset:offset+cf.miniSize]...)
+		id = cf.miniFAT[id]
+	}
+	return out, nil
+}
+
+// readStream returns the contents of a stream entry
+func (cf *compoundFile) readStream(index int) ([]byte, error) {
+	entry := cf.entries[index]
+	if entry.kind != cfbTypeStream {
+		return nil, fmt.Errorf("%s is not a stream", entry.name)
+	}
+	if entry.size == 0 {
+		return []byte{}, nil
+	}
+
+	var data []byte
+	var err error
+	if entry.size < cf.miniCutoff {
+		data, err = cf.miniChain(entry.start)
+	} else {
+		data, err = cf.chain(entry.start)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < entry.size {
+		return nil, fmt.Errorf("stream %s is truncated", entry.name)
+	}
+	return data[:entry.size], nil
+}
+
+// children lists the entries directly inside a storage. Siblings form a
+// tree through their left and right links, walked here in full.
+func (cf *compoundFile) children(storage int) []int {
+	var out []int
+	visited := make(map[uint32]bool)
+	var walk func(id uint32)
+	walk = func(id uint32) {
+		if id == cfbNoEntry || int(id) >= len(cf.entries) || visited[id] {
+			return
+		}
+		visited[id] = true
+		walk(cf.entries[id].left)
+		out = append(out, int(id))
+		walk(cf.entries[id].right)
+	}
+	walk(cf.entries[storage].child)
+	return out
+}
+
+// find returns the child of a storage with the given name, compared
+// case-insensitively as the format requires
+func (cf *compoundFile) find(storage int, name string) (int, bool) {
+	for _, child := range cf.children(storage) {
+		if strings.EqualFold(cf.entries[child].name, name) {
+			return child, true
+		}
+	}
+	return 0, false
+}
+
+// uint32s decodes little-endian 32-bit values
+func uint32s(data []byte) []uint32 {
+	values := make([]uint32, len(data)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return values
+}
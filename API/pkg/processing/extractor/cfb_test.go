@@ -0,0 +1,80 @@
+package extractor
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// craftedHeader returns a two-sector compound file whose header claims
+// numFAT FAT sectors and numDIFAT DIFAT sectors starting at sector 0
+func craftedHeader(numFAT, numDIFAT uint32) []byte {
+	data := make([]byte, 1024)
+	copy(data, cfbSignature)
+	binary.LittleEndian.PutUint16(data[30:], 9)
+	binary.LittleEndian.PutUint16(data[32:], 6)
+	binary.LittleEndian.PutUint32(data[44:], numFAT)
+	binary.LittleEndian.PutUint32(data[48:], cfbEndOfChain)
+	binary.LittleEndian.PutUint32(data[60:], cfbEndOfChain)
+	binary.LittleEndian.PutUint32(data[68:], 0)
+	binary.LittleEndian.PutUint32(data[72:], numDIFAT)
+	for i := 0; i < cfbHeaderFATSlots; i++ {
+		binary.LittleEndian.PutUint32(data[76+4*i:], cfbFreeSector)
+	}
+	return data
+}
+
+func TestOpenCompoundFile_LoopingDIFAT(t *testing.T) {
+	// Sector 0 is a DIFAT sector listing itself as a FAT sector and as the
+	// next DIFAT sector
+	data := craftedHeader(0xFFFFFFF0, 0xFFFFFFF0)
+	for i := 0; i < 128; i++ {
+		binary.LittleEndian.PutUint32(data[512+4*i:], 0)
+	}
+
+	_, err := openCompoundFile(data)
+	require.Error(t, err)
+}
+
+func TestOpenCompoundFile_RepeatedFATSector(t *testing.T) {
+	data := craftedHeader(0xFFFFFFF0, 0)
+	for i := 0; i < cfbHeaderFATSlots; i++ {
+		binary.LittleEndian.PutUint32(data[76+4*i:], 0)
+	}
+
+	_, err := openCompoundFile(data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listed twice")
+}
+
+func TestOpenCompoundFile_LoopingChain(t *testing.T) {
+	msg := buildCompoundFile(t, []cfbNode{
+		{name: "__substg1.0_0037001F", data: utf16Bytes("Opposition to Motion to Continue")},
+	})
+	cf, err := openCompoundFile(msg)
+	require.NoError(t, err)
+
+	// Point the directory sector back at itself
+	dirStart := binary.LittleEndian.Uint32(msg[48:])
+	cf.fat[dirStart] = dirStart
+	_, err = cf.chain(dirStart)
+	require.Error(t, err)
+}
+
+func FuzzOpenCompoundFile(f *testing.F) {
+	f.Add(craftedHeader(0xFFFFFFF0, 0xFFFFFFF0))
+	f.Add(craftedHeader(1, 0))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cf, err := openCompoundFile(data)
+		if err != nil {
+			return
+		}
+		for i := range cf.entries {
+			if cf.entries[i].kind == cfbTypeStream {
+				_, _ = cf.readStream(i)
+			}
+		}
+	})
+}
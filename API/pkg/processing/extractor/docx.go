@@ -19,14 +19,14 @@ func NewDOCXExtractor() Extractor {
 
 // Extract extracts text from DOCX files
 func (e *docxExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
-	// Read all content into memory
-	content, err := io.ReadAll(reader)
+	// The zip reader reads the parts it needs, so a spooled upload stays on disk
+	source, size, err := readerAtSource(reader)
 	if err != nil {
 		return nil, NewExtractionError("docx", "failed to read DOCX file", err)
 	}
 
 	// Parse DOCX (which is a ZIP file)
-	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	zipReader, err := zip.NewReader(source, size)
 	if err != nil {
 		return nil, NewExtractionError("docx", "failed to parse DOCX file", err)
 	}
@@ -54,9 +54,10 @@ func (e *docxExtractor) Extract(ctx context.Context, reader io.Reader, metadata
 		CharCount: charCount,
 		PageCount: 1, // DOCX doesn't have a clear page count concept
 		Metadata: map[string]interface{}{
-			"format":     "docx",
-			"file_size":  len(content),
-			"properties": props,
+			"format":              "docx",
+			"file_size":           int(size),
+			"properties":          props,
+			MetadataKeyHyperlinks: extractDOCXHyperlinks(zipReader),
 		},
 	}, nil
 }
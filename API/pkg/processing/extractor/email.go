@@ -0,0 +1,401 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Metadata keys set by the email extractor
+const (
+	MetadataKeyEmail       = "email"       // *EmailHeaders parsed from the message
+	MetadataKeyAttachments = "attachments" // []EmailAttachment listed in the message
+)
+
+// maxAttachmentExtractSize bounds the attachments whose text is extracted;
+// larger attachments are still listed
+const maxAttachmentExtractSize = 25 * 1024 * 1024
+
+// EmailHeaders holds the headers of an email message. Addresses are
+// formatted as "Name <address>", or the bare address when there is no name.
+type EmailHeaders struct {
+	From      string     `json:"from,omitempty"`
+	To        []string   `json:"to,omitempty"`
+	Cc        []string   `json:"cc,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	Subject   string     `json:"subject,omitempty"`
+	MessageID string     `json:"message_id,omitempty"`
+}
+
+// EmailAttachment describes a file attached to an email message
+type EmailAttachment struct {
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	Text        string `json:"text,omitempty"` // Extracted text, for attachments in a supported format
+
+	content []byte
+}
+
+// parsedEmail is a message decoded from either file format
+type parsedEmail struct {
+	headers     EmailHeaders
+	plainBody   string
+	htmlBody    string
+	attachments []EmailAttachment
+}
+
+// emailExtractor handles RFC 822 (.eml) and Outlook (.msg) email files
+type emailExtractor struct {
+	attachments Service
+}
+
+// NewEmailExtractor creates a new email extractor. When attachments is not
+// nil it extracts the text of attached files in the formats it supports.
+func NewEmailExtractor(attachments Service) Extractor {
+	return &emailExtractor{attachments: attachments}
+}
+
+// Extract extracts the headers, body and attachments of an email file
+func (e *emailExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, NewExtractionError("email", "failed to read email file", err)
+	}
+
+	// The extension is not trusted: .msg files are compound files, anything
+	// else is parsed as an RFC 822 message
+	format := "eml"
+	var email *parsedEmail
+	if isCompoundFile(content) {
+		format = "msg"
+		email, err = parseMSG(content)
+	} else {
+		email, err = parseEML(content)
+	}
+	if err != nil {
+		return nil, NewExtractionError(format, "failed to parse email", err)
+	}
+
+	body := email.plainBody
+	if strings.TrimSpace(body) == "" {
+		body = email.htmlBody
+	}
+	// Only the body is cleaned: the HTML filter would take addresses in
+	// angle brackets for tags
+	cleaner := NewTextCleaner(DefaultCleaningConfig())
+	text := strings.TrimSpace(email.headers.preamble() + "\n\n" + cleaner.CleanText(body))
+
+	for i := range email.attachments {
+		e.extractAttachment(ctx, &email.attachments[i])
+	}
+	attachments := email.attachments
+	if attachments == nil {
+		attachments = []EmailAttachment{}
+	}
+
+	result := &ExtractionResult{
+		Text:      text,
+		WordCount: countWords(text),
+		CharCount: len(text),
+		PageCount: 1,
+		Metadata: map[string]interface{}{
+			"format":               format,
+			"file_size":            len(content),
+			MetadataKeyEmail:       &email.headers,
+			MetadataKeyAttachments: attachments,
+		},
+	}
+	if subject := strings.TrimSpace(email.headers.Subject); subject != "" {
+		result.Metadata[MetadataKeyTitle] = subject
+	}
+	return result, nil
+}
+
+// SupportedFormats returns the formats this extractor supports
+func (e *emailExtractor) SupportedFormats() []string {
+	return []string{"eml", "msg"}
+}
+
+// CanExtract checks if this extractor can handle the given format
+func (e *emailExtractor) CanExtract(format string) bool {
+	format = strings.ToLower(format)
+	for _, supported := range e.SupportedFormats() {
+		if format == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// extractAttachment fills in the text of an attachment the service can read.
+// Attached emails are listed but not opened, so nesting stays one level deep.
+func (e *emailExtractor) extractAttachment(ctx context.Context, attachment *EmailAttachment) {
+	content := attachment.content
+	attachment.content = nil
+	if e.attachments == nil || len(content) == 0 || len(content) > maxAttachmentExtractSize {
+		return
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(attachment.FileName)), ".")
+	if format == "" || e.CanExtract(format) {
+		return
+	}
+	if _, err := e.attachments.GetExtractor(format); err != nil {
+		return
+	}
+
+	result, err := e.attachments.ExtractText(ctx, bytes.NewReader(content), &DocumentMetadata{
+		FileName: attachment.FileName,
+		MimeType: attachment.ContentType,
+		Size:     int64(len(content)),
+		Format:   format,
+	})
+	if err != nil {
+		log.Printf("[EMAIL-EXTRACTOR] ⚠️ Failed to extract attachment %s: %v", attachment.FileName, err)
+		return
+	}
+	attachment.Text = result.Text
+}
+
+// preamble renders the headers as the first lines of the extracted text, so
+// the classifier sees who wrote to whom and when
+func (h *EmailHeaders) preamble() string {
+	var lines []string
+	if h.From != "" {
+		lines = append(lines, "From: "+h.From)
+	}
+	if len(h.To) > 0 {
+		lines = append(lines, "To: "+strings.Join(h.To, ", "))
+	}
+	if len(h.Cc) > 0 {
+		lines = append(lines, "Cc: "+strings.Join(h.Cc, ", "))
+	}
+	if h.Date != nil {
+		lines = append(lines, "Date: "+h.Date.Format(time.RFC1123Z))
+	}
+	if h.Subject != "" {
+		lines = append(lines, "Subject: "+h.Subject)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headerGetter is satisfied by both mail.Header and textproto.MIMEHeader
+type headerGetter interface {
+	Get(key string) string
+}
+
+// wordDecoder decodes RFC 2047 encoded words in headers
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// parseEML parses an RFC 822 message, walking its MIME parts for the body
+// and attachments
+func parseEML(content []byte) (*parsedEmail, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	header := message.Header
+	email := &parsedEmail{
+		headers: EmailHeaders{
+			From:      formatAddressHeader(header, "From"),
+			To:        addressList(header, "To"),
+			Cc:        addressList(header, "Cc"),
+			Subject:   decodeHeader(header.Get("Subject")),
+			MessageID: strings.Trim(strings.TrimSpace(header.Get("Message-ID")), "<>"),
+		},
+	}
+	if date, err := header.Date(); err == nil {
+		email.headers.Date = &date
+	}
+
+	if err := email.walkPart(header, message.Body, 0); err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+// walkPart decodes one MIME part, recursing into multipart containers. Parts
+// marked as attachments, or carrying a file name, are listed as attachments;
+// other text parts make up the body.
+func (email *parsedEmail) walkPart(header headerGetter, body io.Reader, depth int) error {
+	if depth > 20 {
+		return fmt.Errorf("MIME parts are nested too deeply")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := parts.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := email.walkPart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	fileName := decodeHeader(dispositionParams["filename"])
+	if fileName == "" {
+		fileName = decodeHeader(params["name"])
+	}
+	if disposition == "attachment" || fileName != "" || mediaType == "message/rfc822" {
+		if fileName == "" {
+			fileName = "attachment"
+			if mediaType == "message/rfc822" {
+				fileName = "attached-message.eml"
+			}
+		}
+		email.attachments = append(email.attachments, EmailAttachment{
+			FileName:    fileName,
+			ContentType: mediaType,
+			Size:        len(data),
+			content:     data,
+		})
+		return nil
+	}
+
+	switch mediaType {
+	case "text/plain":
+		email.plainBody = joinBody(email.plainBody, decodeCharset(data, params["charset"]))
+	case "text/html":
+		email.htmlBody = joinBody(email.htmlBody, decodeCharset(data, params["charset"]))
+	}
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding. The multipart reader
+// already decodes quoted-printable parts and drops the header.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// addressList returns the formatted addresses of a header, or the decoded raw
+// value when it does not parse as an address list
+func addressList(header mail.Header, key string) []string {
+	raw := header.Get(key)
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	addresses, err := header.AddressList(key)
+	if err != nil {
+		return []string{decodeHeader(raw)}
+	}
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		formatted = append(formatted, formatAddress(address.Name, address.Address))
+	}
+	return formatted
+}
+
+// formatAddressHeader returns the first address of a single-address header
+func formatAddressHeader(header mail.Header, key string) string {
+	if addresses := addressList(header, key); len(addresses) > 0 {
+		return addresses[0]
+	}
+	return ""
+}
+
+// formatAddress renders a name and address as "Name <address>"
+func formatAddress(name, address string) string {
+	name = strings.TrimSpace(name)
+	address = strings.TrimSpace(address)
+	switch {
+	case name == "" || name == address:
+		return address
+	case address == "":
+		return name
+	}
+	return fmt.Sprintf("%s <%s>", name, address)
+}
+
+// decodeHeader decodes RFC 2047 encoded words, keeping the raw value when it
+// cannot be decoded
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// charsetReader converts the single-byte Western charsets common in email;
+// UTF-8 and ASCII are handled by the word decoder itself
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	if !isLatin1(charset) {
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+	return strings.NewReader(latin1ToUTF8(data)), nil
+}
+
+// decodeCharset converts a text part to UTF-8
+func decodeCharset(data []byte, charset string) string {
+	if isLatin1(charset) && !utf8.Valid(data) {
+		return latin1ToUTF8(data)
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
+
+// isLatin1 reports whether a charset maps bytes one-to-one onto Latin-1
+// runes; Windows-1252 differs only in rarely used punctuation
+func isLatin1(charset string) bool {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		return true
+	}
+	return false
+}
+
+// latin1ToUTF8 decodes Latin-1 bytes
+func latin1ToUTF8(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// joinBody appends a body part to those already found
+func joinBody(existing, part string) string {
+	if strings.TrimSpace(existing) == "" {
+		return part
+	}
+	return existing + "\n\n" + part
+}
@@ -0,0 +1,330 @@
+package extractor
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMultipartEML = "From: \"Dana Reyes\" <dreyes@pd.example.gov>\r\n" +
+	"To: Clerk <clerk@court.example.gov>, records@court.example.gov\r\n" +
+	"Cc: =?UTF-8?Q?Jos=C3=A9_Ortiz?= <jortiz@pd.example.gov>\r\n" +
+	"Date: Tue, 04 Mar 2025 09:15:00 -0800\r\n" +
+	"Subject: =?UTF-8?Q?Motion_to_Suppress_=E2=80=93_People_v._Smith?=\r\n" +
+	"Message-ID: <abc123@pd.example.gov>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Please file the attached motion to suppress evidence under Penal Code =C2=A7 1=\r\n" +
+	"538.5 before Friday.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Please file the attached motion <b>before Friday</b>.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"motion.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"motion.txt\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"TU9USU9OIFRPIFNVUFBSRVNTIEVWSURFTkNFCkRlZmVuZGFudCBtb3ZlcyB0byBzdXBwcmVzcy4=\r\n" +
+	"--outer--\r\n"
+
+func TestEmailExtractor_EML(t *testing.T) {
+	result, err := NewService().ExtractText(context.Background(), strings.NewReader(sampleMultipartEML), &DocumentMetadata{
+		FileName: "motion.eml",
+	})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	headers, ok := result.Metadata[MetadataKeyEmail].(*EmailHeaders)
+	require.True(t, ok)
+	assert.Equal(t, "Dana Reyes <dreyes@pd.example.gov>", headers.From)
+	assert.Equal(t, []string{"Clerk <clerk@court.example.gov>", "records@court.example.gov"}, headers.To)
+	assert.Equal(t, []string{"José Ortiz <jortiz@pd.example.gov>"}, headers.Cc)
+	assert.Equal(t, "Motion to Suppress – People v. Smith", headers.Subject)
+	assert.Equal(t, "abc123@pd.example.gov", headers.MessageID)
+	require.NotNil(t, headers.Date)
+	assert.True(t, headers.Date.Equal(time.Date(2025, 3, 4, 17, 15, 0, 0, time.UTC)))
+
+	// The plain text part is preferred over the HTML alternative
+	assert.Contains(t, result.Text, "From: Dana Reyes <dreyes@pd.example.gov>")
+	assert.Contains(t, result.Text, "Subject: Motion to Suppress – People v. Smith")
+	assert.Contains(t, result.Text, "Penal Code § 1538.5 before Friday.")
+	assert.NotContains(t, result.Text, "<p>")
+	assert.NotContains(t, result.Text, "MOTION TO SUPPRESS EVIDENCE")
+	assert.Equal(t, "Motion to Suppress – People v. Smith", result.Metadata[MetadataKeyTitle])
+
+	attachments, ok := result.Metadata[MetadataKeyAttachments].([]EmailAttachment)
+	require.True(t, ok)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "motion.txt", attachments[0].FileName)
+	assert.Equal(t, "text/plain", attachments[0].ContentType)
+	assert.Equal(t, 56, attachments[0].Size)
+	assert.Contains(t, attachments[0].Text, "Defendant moves to suppress.")
+}
+
+func TestEmailExtractor_EMLHTMLOnly(t *testing.T) {
+	eml := "From: clerk@court.example.gov\r\n" +
+		"To: dreyes@pd.example.gov\r\n" +
+		"Subject: Hearing set\r\n" +
+		"Content-Type: text/html; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"<html><body><p>The hearing is set for Dept. 12 =E0 9:00.</p></body></html>\r\n"
+
+	result, err := NewEmailExtractor(nil).Extract(context.Background(), strings.NewReader(eml), &DocumentMetadata{})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Text, "The hearing is set for Dept. 12 à 9:00.")
+	assert.NotContains(t, result.Text, "<p>")
+	assert.Equal(t, "eml", result.Metadata["format"])
+	headers := result.Metadata[MetadataKeyEmail].(*EmailHeaders)
+	assert.Equal(t, "clerk@court.example.gov", headers.From)
+	assert.Nil(t, headers.Date)
+	assert.Empty(t, result.Metadata[MetadataKeyAttachments])
+}
+
+func TestEmailExtractor_InvalidEML(t *testing.T) {
+	_, err := NewEmailExtractor(nil).Extract(context.Background(), strings.NewReader("not an email"), &DocumentMetadata{})
+	require.Error(t, err)
+	assert.IsType(t, &ExtractionError{}, err)
+}
+
+func TestEmailExtractor_MSG(t *testing.T) {
+	sent := time.Date(2025, 3, 4, 17, 15, 0, 0, time.UTC)
+	body := "Counsel,\r\nThe People oppose the motion to continue. The opposition is attached."
+	// Large enough to be stored in regular sectors rather than the mini stream
+	attachment := strings.Repeat("The People oppose the motion. ", 200)
+
+	msg := buildCompoundFile(t, []cfbNode{
+		{name: "__substg1.0_0037001F", data: utf16Bytes("Opposition to Motion to Continue")},
+		{name: "__substg1.0_0C1A001F", data: utf16Bytes("Deputy DA Lee")},
+		{name: "__substg1.0_5D01001F", data: utf16Bytes("lee@da.example.gov")},
+		{name: "__substg1.0_1000001F", data: utf16Bytes(body)},
+		{name: "__substg1.0_0E04001F", data: utf16Bytes("Dana Reyes")},
+		{name: "__substg1.0_1035001F", data: utf16Bytes("<xyz789@da.example.gov>")},
+		{name: msgPropertiesStream, data: msgProperties(msgTopPropertiesHeader, 0x0039, mapiTypeTime, timeToFiletime(sent))},
+		{name: "__recip_version1.0_#00000000", children: []cfbNode{
+			{name: "__substg1.0_3001001F", data: utf16Bytes("Dana Reyes")},
+			{name: "__substg1.0_39FE001F", data: utf16Bytes("dreyes@pd.example.gov")},
+			{name: msgPropertiesStream, data: msgProperties(msgSubPropertiesHeader, mapiRecipientType, mapiTypeLong, 1)},
+		}},
+		{name: "__recip_version1.0_#00000001", children: []cfbNode{
+			{name: "__substg1.0_3001001F", data: utf16Bytes("Records Unit")},
+			{name: "__substg1.0_3003001F", data: utf16Bytes("records@court.example.gov")},
+			{name: msgPropertiesStream, data: msgProperties(msgSubPropertiesHeader, mapiRecipientType, mapiTypeLong, 2)},
+		}},
+		{name: "__attach_version1.0_#00000000", children: []cfbNode{
+			{name: "__substg1.0_3707001F", data: utf16Bytes("opposition.txt")},
+			{name: "__substg1.0_370E001F", data: utf16Bytes("text/plain")},
+			{name: "__substg1.0_37010102", data: []byte(attachment)},
+		}},
+	})
+
+	result, err := NewService().ExtractText(context.Background(), strings.NewReader(string(msg)), &DocumentMetadata{
+		FileName: "opposition.msg",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "msg", result.Metadata["format"])
+
+	headers := result.Metadata[MetadataKeyEmail].(*EmailHeaders)
+	assert.Equal(t, "Deputy DA Lee <lee@da.example.gov>", headers.From)
+	assert.Equal(t, []string{"Dana Reyes <dreyes@pd.example.gov>"}, headers.To)
+	assert.Equal(t, []string{"Records Unit <records@court.example.gov>"}, headers.Cc)
+	assert.Equal(t, "Opposition to Motion to Continue", headers.Subject)
+	assert.Equal(t, "xyz789@da.example.gov", headers.MessageID)
+	require.NotNil(t, headers.Date)
+	assert.True(t, headers.Date.Equal(sent))
+
+	assert.Contains(t, result.Text, "The People oppose the motion to continue.")
+	assert.Equal(t, "Opposition to Motion to Continue", result.Metadata[MetadataKeyTitle])
+
+	attachments := result.Metadata[MetadataKeyAttachments].([]EmailAttachment)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "opposition.txt", attachments[0].FileName)
+	assert.Equal(t, len(attachment), attachments[0].Size)
+	assert.Equal(t, strings.TrimSpace(attachment), attachments[0].Text)
+}
+
+// cfbNode is a stream, or a storage when children is set, in a compound
+// file built by buildCompoundFile
+type cfbNode struct {
+	name     string
+	data     []byte
+	children []cfbNode
+}
+
+// buildCompoundFile writes a version 3 compound file holding the nodes
+// under its root. Siblings are chained through their right links.
+func buildCompoundFile(t *testing.T, nodes []cfbNode) []byte {
+	t.Helper()
+	const sectorSize, miniSize, miniCutoff = 512, 64, 4096
+
+	type dirEntry struct {
+		name                      string
+		kind                      byte
+		left, right, child, start uint32
+		size                      uint64
+	}
+	var sectors [][]byte
+	var fat, miniFAT []uint32
+	var miniStream []byte
+
+	chain := func(links *[]uint32, first, count int) {
+		for i := 0; i < count-1; i++ {
+			*links = append(*links, uint32(first+i+1))
+		}
+		*links = append(*links, cfbEndOfChain)
+	}
+	writeSectors := func(data []byte) uint32 {
+		first := len(sectors)
+		count := (len(data) + sectorSize - 1) / sectorSize
+		for i := 0; i < count; i++ {
+			sector := make([]byte, sectorSize)
+			copy(sector, data[i*sectorSize:])
+			sectors = append(sectors, sector)
+		}
+		chain(&fat, first, count)
+		return uint32(first)
+	}
+	writeMini := func(data []byte) uint32 {
+		first := len(miniStream) / miniSize
+		count := (len(data) + miniSize - 1) / miniSize
+		miniStream = append(miniStream, make([]byte, count*miniSize)...)
+		copy(miniStream[first*miniSize:], data)
+		chain(&miniFAT, first, count)
+		return uint32(first)
+	}
+
+	entries := []dirEntry{{name: "Root Entry", kind: cfbTypeRoot, left: cfbNoEntry, right: cfbNoEntry}}
+	var add func(nodes []cfbNode) uint32
+	add = func(nodes []cfbNode) uint32 {
+		first, previous := uint32(cfbNoEntry), -1
+		for _, node := range nodes {
+			index := len(entries)
+			entries = append(entries, dirEntry{name: node.name, left: cfbNoEntry, right: cfbNoEntry, child: cfbNoEntry})
+			if node.children != nil {
+				entries[index].kind = cfbTypeStorage
+				child := add(node.children)
+				entries[index].child = child
+			} else {
+				entries[index].kind = cfbTypeStream
+				entries[index].size = uint64(len(node.data))
+				entries[index].start = cfbEndOfChain
+				if len(node.data) >= miniCutoff {
+					entries[index].start = writeSectors(node.data)
+				} else if len(node.data) > 0 {
+					entries[index].start = writeMini(node.data)
+				}
+			}
+			if previous < 0 {
+				first = uint32(index)
+			} else {
+				entries[previous].right = uint32(index)
+			}
+			previous = index
+		}
+		return first
+	}
+	entries[0].child = add(nodes)
+	entries[0].start = writeSectors(miniStream)
+	entries[0].size = uint64(len(miniStream))
+
+	miniFATBytes := make([]byte, 0, len(miniFAT)*4)
+	for _, link := range miniFAT {
+		miniFATBytes = binary.LittleEndian.AppendUint32(miniFATBytes, link)
+	}
+	for len(miniFATBytes)%sectorSize != 0 {
+		miniFATBytes = append(miniFATBytes, 0xFF)
+	}
+	miniFATStart := writeSectors(miniFATBytes)
+
+	dir := make([]byte, 0, len(entries)*cfbDirEntrySize)
+	for _, entry := range entries {
+		raw := make([]byte, cfbDirEntrySize)
+		name := utf16Bytes(entry.name)
+		copy(raw, name)
+		binary.LittleEndian.PutUint16(raw[64:], uint16(len(name)+2))
+		raw[66] = entry.kind
+		binary.LittleEndian.PutUint32(raw[68:], entry.left)
+		binary.LittleEndian.PutUint32(raw[72:], entry.right)
+		binary.LittleEndian.PutUint32(raw[76:], entry.child)
+		binary.LittleEndian.PutUint32(raw[116:], entry.start)
+		binary.LittleEndian.PutUint64(raw[120:], entry.size)
+		dir = append(dir, raw...)
+	}
+	dirStart := writeSectors(dir)
+
+	// One FAT sector covers the 128 sectors a test file needs
+	fatSector := uint32(len(sectors))
+	fat = append(fat, 0xFFFFFFFD)
+	require.LessOrEqual(t, len(fat), sectorSize/4)
+	fatBytes := make([]byte, 0, sectorSize)
+	for _, link := range fat {
+		fatBytes = binary.LittleEndian.AppendUint32(fatBytes, link)
+	}
+	for len(fatBytes) < sectorSize {
+		fatBytes = append(fatBytes, 0xFF)
+	}
+	sectors = append(sectors, fatBytes)
+
+	header := make([]byte, sectorSize)
+	copy(header, cfbSignature)
+	binary.LittleEndian.PutUint16(header[24:], 0x3E)
+	binary.LittleEndian.PutUint16(header[26:], 3)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], 1)
+	binary.LittleEndian.PutUint32(header[48:], dirStart)
+	binary.LittleEndian.PutUint32(header[56:], miniCutoff)
+	binary.LittleEndian.PutUint32(header[60:], miniFATStart)
+	binary.LittleEndian.PutUint32(header[64:], uint32(len(miniFATBytes)/sectorSize))
+	binary.LittleEndian.PutUint32(header[68:], cfbEndOfChain)
+	for i := 0; i < cfbHeaderFATSlots; i++ {
+		binary.LittleEndian.PutUint32(header[76+4*i:], cfbFreeSector)
+	}
+	binary.LittleEndian.PutUint32(header[76:], fatSector)
+
+	file := header
+	for _, sector := range sectors {
+		file = append(file, sector...)
+	}
+	return file
+}
+
+// msgProperties builds a properties stream holding one fixed-size property
+func msgProperties(headerSize int, id, propType uint16, value uint64) []byte {
+	data := make([]byte, headerSize+16)
+	binary.LittleEndian.PutUint32(data[headerSize:], uint32(id)<<16|uint32(propType))
+	binary.LittleEndian.PutUint64(data[headerSize+8:], value)
+	return data
+}
+
+// utf16Bytes encodes a string as UTF-16LE without a terminator
+func utf16Bytes(s string) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// timeToFiletime converts a time to a Windows FILETIME
+func timeToFiletime(t time.Time) uint64 {
+	return uint64(t.UnixNano()/100 + filetimeUnixEpochOffset)
+}
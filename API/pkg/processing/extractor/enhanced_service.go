@@ -23,6 +23,7 @@ type enhancedService struct {
 	dslipakExtractor Extractor
 	ocrExtractor     Extractor
 	docxExtractor    Extractor
+	emailExtractor   Extractor
 	
 	// Document analyzer - single responsibility
 	analyzer *DocumentAnalyzer
@@ -85,6 +86,7 @@ func (s *enhancedService) initializeExtractors() {
 	s.textExtractor = NewTextExtractor()
 	s.pdfExtractor = NewPDFExtractor() // Original ledongthuc/pdf
 	s.docxExtractor = NewDOCXExtractor()
+	s.emailExtractor = NewEmailExtractor(s)
 	
 	// Enhanced extractors
 	if s.config.EnableDslipakPDF {
@@ -136,6 +138,10 @@ func (s *enhancedService) extractWithIntelligentStrategy(ctx context.Context, co
 	case "text":
 		result, err = s.tryTextExtraction(ctx, content, metadata, analysis)
 	case "ocr":
+		if !s.ocrEnabled(metadata) {
+			result, err = s.tryTextExtraction(ctx, content, metadata, analysis)
+			break
+		}
 		result, err = s.tryOCRExtraction(ctx, content, metadata)
 	default:
 		result, err = s.tryTextExtraction(ctx, content, metadata, analysis)
@@ -148,16 +154,21 @@ func (s *enhancedService) extractWithIntelligentStrategy(ctx context.Context, co
 		return result, nil
 	}
 	
-	// Try fallback methods if enabled
+	// Try fallback methods if enabled. A document that asked for OCR gets it
+	// as a last resort even when the analysis did not suggest it.
 	if s.config.EnableFallbacks {
-		for _, fallbackMethod := range analysis.Fallbacks {
+		fallbacks := analysis.Fallbacks
+		if metadata.OCRRequested() && !containsString(fallbacks, "ocr") {
+			fallbacks = append(append([]string{}, fallbacks...), "ocr")
+		}
+		for _, fallbackMethod := range fallbacks {
 			switch fallbackMethod {
 			case "dslipak":
 				if s.config.EnableDslipakPDF && s.dslipakExtractor != nil {
 					result, err = s.tryDslipakExtraction(ctx, content, metadata)
 				}
 			case "ocr":
-				if s.config.EnableOCR && s.ocrExtractor != nil {
+				if s.ocrEnabled(metadata) {
 					result, err = s.tryOCRExtraction(ctx, content, metadata)
 				}
 			case "text":
@@ -250,6 +261,21 @@ func (s *enhancedService) tryOCRExtraction(ctx context.Context, content []byte,
 	return s.ocrExtractor.Extract(ctx, bytes.NewReader(content), metadata)
 }
 
+// ocrEnabled reports whether OCR is available and permitted for the document
+func (s *enhancedService) ocrEnabled(metadata *DocumentMetadata) bool {
+	return s.config.EnableOCR && s.ocrExtractor != nil && metadata.OCRAllowed()
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // isExtractionSuccessful determines if an extraction result is considered successful
 func (s *enhancedService) isExtractionSuccessful(result *ExtractionResult) bool {
 	if result == nil || !result.Success {
@@ -276,6 +302,8 @@ func (s *enhancedService) getExtractorForFormat(format string) (Extractor, error
 		return s.textExtractor, nil
 	case "docx":
 		return s.docxExtractor, nil
+	case "eml", "msg":
+		return s.emailExtractor, nil
 	default:
 		return nil, fmt.Errorf("no extractor available for format: %s", format)
 	}
@@ -305,7 +333,7 @@ func (s *enhancedService) GetExtractor(format string) (Extractor, error) {
 
 // SupportedFormats returns all supported formats
 func (s *enhancedService) SupportedFormats() []string {
-	formats := []string{"pdf", "txt", "docx"}
+	formats := []string{"pdf", "txt", "docx", "eml", "msg"}
 	
 	if s.config.EnableOCR && s.ocrExtractor != nil {
 		formats = append(formats, "png", "jpg", "jpeg", "tiff", "bmp", "gif")
@@ -343,6 +371,10 @@ func (s *enhancedService) detectFormat(fileName, mimeType string) string {
 			return "txt"
 		case "application/rtf":
 			return "rtf"
+		case "message/rfc822":
+			return "eml"
+		case "application/vnd.ms-outlook":
+			return "msg"
 		}
 		
 		// Check for image MIME types if OCR is enabled
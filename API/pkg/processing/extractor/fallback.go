@@ -0,0 +1,237 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultFallbackMinTextLength is the number of characters below which an
+// extraction is retried with the next extractor of a fallback chain
+const DefaultFallbackMinTextLength = 50
+
+// Extractors that can only be named in a fallback chain
+const (
+	ExtractorPDFOCR   = "pdf_ocr"  // Reads PDFs by OCR alone, skipping the text layer
+	ExtractorMetadata = "metadata" // Builds text from the file name and properties
+)
+
+// fallbackExtractor is a named link of a fallback chain
+type fallbackExtractor struct {
+	name      string
+	extractor Extractor
+}
+
+// ParseFallbackChains parses fallback chains given as semicolon-separated
+// format=extractor,extractor entries, e.g. "pdf=pdf_ocr,metadata;docx=metadata".
+// An extractor is a format with a built-in extractor, pdf_ocr or metadata.
+func ParseFallbackChains(spec string) (map[string][]string, error) {
+	known := map[string]bool{ExtractorPDFOCR: true, ExtractorMetadata: true}
+	for _, format := range NewService().SupportedFormats() {
+		known[format] = true
+	}
+
+	chains := make(map[string][]string)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		format, names, ok := strings.Cut(entry, "=")
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !ok || format == "" {
+			return nil, fmt.Errorf("invalid fallback chain %q: expected format=extractor,extractor", entry)
+		}
+		for _, name := range strings.Split(names, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if !known[name] {
+				return nil, fmt.Errorf("unknown extractor %q in fallback chain for %s", name, format)
+			}
+			chains[format] = append(chains[format], name)
+		}
+	}
+	return chains, nil
+}
+
+// RegisterFallback adds an extractor to the end of format's fallback chain
+func (s *service) RegisterFallback(format, name string, extractor Extractor) {
+	format = strings.ToLower(format)
+	s.fallbacks[format] = append(s.fallbacks[format], fallbackExtractor{name: name, extractor: extractor})
+}
+
+// registerFallbackChains builds the fallback chains named in the options
+func (s *service) registerFallbackChains() {
+	for format, names := range s.options.FallbackChains {
+		for _, name := range names {
+			switch name {
+			case ExtractorPDFOCR:
+				opts := s.options
+				opts.EnableOCR = true
+				ocr := newPDFOCR(opts)
+				if ocr == nil {
+					log.Printf("[EXTRACTOR-SERVICE] ⚠️ Leaving %s out of the %s fallback chain: OCR is unavailable", name, format)
+					continue
+				}
+				s.RegisterFallback(format, name, &pdfOCRExtractor{ocr: ocr})
+			case ExtractorMetadata:
+				s.RegisterFallback(format, name, metadataExtractor{})
+			default:
+				extractor, err := s.GetExtractor(name)
+				if err != nil {
+					log.Printf("[EXTRACTOR-SERVICE] ⚠️ Leaving %s out of the %s fallback chain: %v", name, format, err)
+					continue
+				}
+				s.RegisterFallback(format, name, extractor)
+			}
+		}
+	}
+}
+
+// extract runs the format's extractor and then, while each fails or finds
+// too little text, the extractors of the format's fallback chain in order.
+// When none finds enough text the longest result is kept. The result names
+// the extractor that produced it.
+func (s *service) extract(ctx context.Context, primary Extractor, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
+	format := strings.ToLower(metadata.Format)
+	fallbacks := s.fallbacks[format]
+	if len(fallbacks) == 0 {
+		result, err := primary.Extract(ctx, reader, metadata)
+		if err == nil && result != nil {
+			result.Extractor = format
+		}
+		return result, err
+	}
+
+	// Every extractor of the chain reads the document from the start
+	source, size, err := readerAtSource(reader)
+	if err != nil {
+		return nil, NewExtractionError(format, "failed to read document", err)
+	}
+
+	chain := append([]fallbackExtractor{{name: format, extractor: primary}}, fallbacks...)
+	var best *ExtractionResult
+	var firstErr error
+	for _, link := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := link.extractor.Extract(ctx, io.NewSectionReader(source, 0, size), metadata)
+		if err != nil || result == nil {
+			log.Printf("[EXTRACTOR-SERVICE] ⚠️ %s extractor failed on %s: %v", link.name, metadata.FileName, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		result.Extractor = link.name
+
+		length := utf8.RuneCountInString(strings.TrimSpace(result.Text))
+		if length >= s.fallbackMinTextLength() {
+			return result, nil
+		}
+		log.Printf("[EXTRACTOR-SERVICE] ⚠️ %s extractor found only %d characters in %s", link.name, length, metadata.FileName)
+		if best == nil || length > utf8.RuneCountInString(strings.TrimSpace(best.Text)) {
+			best = result
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if firstErr == nil {
+		firstErr = NewExtractionError(format, "no extractor produced a result", nil)
+	}
+	return nil, firstErr
+}
+
+// fallbackMinTextLength returns the configured threshold or its default
+func (s *service) fallbackMinTextLength() int {
+	if s.options.FallbackMinTextLength > 0 {
+		return s.options.FallbackMinTextLength
+	}
+	return DefaultFallbackMinTextLength
+}
+
+// pdfOCRExtractor reads PDFs by OCR without looking at their text layer
+type pdfOCRExtractor struct {
+	ocr *pdfOCR
+}
+
+// Extract OCRs every page of the PDF
+func (e *pdfOCRExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
+	if !metadata.OCRAllowed() {
+		return nil, NewExtractionError("pdf", "OCR is turned off for this document", nil)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, NewExtractionError("pdf", "failed to read PDF file", err)
+	}
+	return e.ocr.extract(ctx, content)
+}
+
+// SupportedFormats returns the formats OCR can read
+func (e *pdfOCRExtractor) SupportedFormats() []string {
+	return []string{"pdf"}
+}
+
+// CanExtract checks if this extractor can handle the given format
+func (e *pdfOCRExtractor) CanExtract(format string) bool {
+	return strings.ToLower(format) == "pdf"
+}
+
+// metadataExtractor describes a document from its file name and properties
+// without reading it, the last resort for files no extractor can read
+type metadataExtractor struct{}
+
+// Extract returns the file name, as words, followed by the document's
+// properties one per line
+func (metadataExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
+	var lines []string
+	if name := strings.TrimSuffix(filepath.Base(metadata.FileName), filepath.Ext(metadata.FileName)); name != "" && name != "." {
+		lines = append(lines, strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+			return r == '_' || r == '-' || r == ' '
+		}), " "))
+	}
+
+	keys := make([]string, 0, len(metadata.Properties))
+	for key := range metadata.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := strings.TrimSpace(metadata.Properties[key]); value != "" {
+			lines = append(lines, key+": "+value)
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, NewExtractionError(metadata.Format, "document has no metadata to extract", nil)
+	}
+
+	text := strings.Join(lines, "\n")
+	return &ExtractionResult{
+		Text:      text,
+		WordCount: countWords(text),
+		CharCount: len(text),
+		Metadata: map[string]interface{}{
+			"format":     metadata.Format,
+			"extraction": "metadata_only",
+		},
+	}, nil
+}
+
+// SupportedFormats returns no formats; the extractor is only used in fallback chains
+func (metadataExtractor) SupportedFormats() []string {
+	return nil
+}
+
+// CanExtract reports true, as every document has a file name
+func (metadataExtractor) CanExtract(format string) bool {
+	return true
+}
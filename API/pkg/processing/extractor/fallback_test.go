@@ -0,0 +1,125 @@
+package extractor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExtractor returns text, or err when set, and records what it read
+type fakeExtractor struct {
+	text string
+	err  error
+	read string
+}
+
+func (e *fakeExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
+	content, _ := io.ReadAll(reader)
+	e.read = string(content)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &ExtractionResult{Text: e.text, WordCount: countWords(e.text), CharCount: len(e.text)}, nil
+}
+
+func (e *fakeExtractor) SupportedFormats() []string { return []string{"stub"} }
+
+func (e *fakeExtractor) CanExtract(format string) bool { return format == "stub" }
+
+const fallbackText = "The defendant moves to dismiss the information for lack of probable cause."
+
+func TestService_ExtractText_FallsBackWhenExtractorFails(t *testing.T) {
+	svc := NewService().(*service)
+	native := &fakeExtractor{err: NewExtractionError("stub", "corrupt file", nil)}
+	ocr := &fakeExtractor{text: fallbackText}
+	svc.RegisterExtractor("stub", native)
+	svc.RegisterFallback("stub", "ocr", ocr)
+
+	result, err := svc.ExtractText(context.Background(), strings.NewReader("raw bytes"), &DocumentMetadata{FileName: "motion.stub", Format: "stub"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, fallbackText, result.Text)
+	assert.Equal(t, "ocr", result.Extractor)
+	assert.Equal(t, "raw bytes", native.read)
+	assert.Equal(t, "raw bytes", ocr.read, "each extractor reads the whole document")
+}
+
+func TestService_ExtractText_FallsBackOnShortText(t *testing.T) {
+	svc := NewServiceWithOptions(ExtractOptions{FallbackMinTextLength: 20}).(*service)
+	svc.RegisterExtractor("stub", &fakeExtractor{text: "%%EOF"})
+	svc.RegisterFallback("stub", "second", &fakeExtractor{text: fallbackText})
+	svc.RegisterFallback("stub", "third", &fakeExtractor{text: "never reached, the second found enough"})
+
+	result, err := svc.ExtractText(context.Background(), strings.NewReader("raw"), &DocumentMetadata{Format: "stub"})
+	require.NoError(t, err)
+	assert.Equal(t, "second", result.Extractor)
+	assert.Equal(t, fallbackText, result.Text)
+}
+
+func TestService_ExtractText_KeepsLongestShortResult(t *testing.T) {
+	svc := NewService().(*service)
+	svc.RegisterExtractor("stub", &fakeExtractor{text: "MOTION"})
+	svc.RegisterFallback("stub", "broken", &fakeExtractor{err: assert.AnError})
+	svc.RegisterFallback("stub", ExtractorMetadata, metadataExtractor{})
+
+	result, err := svc.ExtractText(context.Background(), strings.NewReader("raw"), &DocumentMetadata{
+		FileName:   "motion_to_dismiss.stub",
+		Format:     "stub",
+		Properties: map[string]string{"case_number": "22CR001234"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ExtractorMetadata, result.Extractor)
+	assert.Equal(t, "motion to dismiss\ncase_number: 22CR001234", result.Text)
+}
+
+func TestService_ExtractText_ChainFails(t *testing.T) {
+	svc := NewService().(*service)
+	first := NewExtractionError("stub", "corrupt file", nil)
+	svc.RegisterExtractor("stub", &fakeExtractor{err: first})
+	svc.RegisterFallback("stub", "second", &fakeExtractor{err: assert.AnError})
+
+	result, err := svc.ExtractText(context.Background(), strings.NewReader("raw"), &DocumentMetadata{Format: "stub"})
+	assert.ErrorIs(t, err, first, "the format's own extractor's error is reported")
+	assert.False(t, result.Success)
+}
+
+func TestService_ExtractText_NamesOwnExtractor(t *testing.T) {
+	result, err := NewService().ExtractText(context.Background(), strings.NewReader("Plain text motion."), &DocumentMetadata{FileName: "motion.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "txt", result.Extractor)
+}
+
+func TestNewServiceWithOptions_FallbackChains(t *testing.T) {
+	svc := NewServiceWithOptions(ExtractOptions{
+		FallbackChains: map[string][]string{"docx": {"txt", ExtractorMetadata}},
+	}).(*service)
+
+	var names []string
+	for _, link := range svc.fallbacks["docx"] {
+		names = append(names, link.name)
+	}
+	assert.Equal(t, []string{"txt", ExtractorMetadata}, names)
+}
+
+func TestParseFallbackChains(t *testing.T) {
+	chains, err := ParseFallbackChains(" pdf = pdf_ocr, metadata ; DOCX=txt;")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"pdf":  {ExtractorPDFOCR, ExtractorMetadata},
+		"docx": {"txt"},
+	}, chains)
+
+	chains, err = ParseFallbackChains("")
+	require.NoError(t, err)
+	assert.Empty(t, chains)
+
+	_, err = ParseFallbackChains("pdf")
+	assert.ErrorContains(t, err, "expected format=extractor")
+
+	_, err = ParseFallbackChains("pdf=ocr")
+	assert.ErrorContains(t, err, `unknown extractor "ocr"`)
+}
@@ -2,7 +2,10 @@ package extractor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"strings"
 )
 
 // Extractor defines the interface for text extraction from documents
@@ -29,6 +32,38 @@ type Service interface {
 	SupportedFormats() []string
 }
 
+// ExtractOptions configures a text extraction service
+type ExtractOptions struct {
+	// EnableOCR reads PDFs whose text layer is near empty, such as scanned
+	// filings, by OCR. It needs pdftoppm and tesseract on the PATH and is
+	// skipped with a warning when they are missing.
+	EnableOCR bool
+
+	// OCRMinTextLength is the number of characters below which a PDF's text
+	// layer is treated as empty; zero uses DefaultOCRMinTextLength
+	OCRMinTextLength int
+
+	// OCRLanguage is the tesseract language, e.g. "eng+spa"; empty uses
+	// DefaultOCRLanguage
+	OCRLanguage string
+
+	// OCRMaxPages limits how many pages are OCRed; zero reads them all
+	OCRMaxPages int
+
+	// IncludeLayout adds the words of each PDF page with their bounding
+	// boxes to ExtractionResult.Pages, for redaction. Pages read by OCR have
+	// no layout.
+	IncludeLayout bool
+
+	// FallbackChains maps a format to the extractors tried, in order, when
+	// the format's own extractor fails or finds fewer than
+	// FallbackMinTextLength characters (see ParseFallbackChains)
+	FallbackChains map[string][]string
+
+	// FallbackMinTextLength is zero for DefaultFallbackMinTextLength
+	FallbackMinTextLength int
+}
+
 // DocumentMetadata contains information about the document being processed
 type DocumentMetadata struct {
 	FileName   string            `json:"file_name"`
@@ -36,6 +71,20 @@ type DocumentMetadata struct {
 	Size       int64             `json:"size"`
 	Format     string            `json:"format"`
 	Properties map[string]string `json:"properties,omitempty"`
+
+	// OCR turns OCR on or off for this document where the service supports
+	// it; nil leaves the choice to the service
+	OCR *bool `json:"ocr,omitempty"`
+}
+
+// OCRAllowed reports whether the document's OCR preference permits OCR
+func (m *DocumentMetadata) OCRAllowed() bool {
+	return m == nil || m.OCR == nil || *m.OCR
+}
+
+// OCRRequested reports whether OCR was explicitly turned on for the document
+func (m *DocumentMetadata) OCRRequested() bool {
+	return m != nil && m.OCR != nil && *m.OCR
 }
 
 // ExtractionResult contains the result of text extraction
@@ -49,6 +98,50 @@ type ExtractionResult struct {
 	Success   bool                   `json:"success"`
 	Error     string                 `json:"error,omitempty"`
 	Duration  int64                  `json:"duration_ms"`
+
+	// Quality scores the extracted text so garbled extractions can be flagged
+	Quality *ExtractionQuality `json:"quality,omitempty"`
+
+	// Pages holds the text of each page for formats with page boundaries.
+	// Empty pages are omitted, so numbers may skip.
+	Pages []PageText `json:"pages,omitempty"`
+
+	// ContentHash identifies the extracted text (see ContentHash), so the
+	// same document uploaded twice can be recognized
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// OCRUsed reports that the text was read by OCR rather than from the
+	// document's text layer, so it may contain recognition errors
+	OCRUsed bool `json:"ocr_used,omitempty"`
+
+	// Extractor names the extractor that produced the text: the document's
+	// format for its own extractor, or the fallback that took over
+	Extractor string `json:"extractor,omitempty"`
+}
+
+// PageText is the text of a single page, numbered from 1
+type PageText struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+
+	// Tokens are the words of Text with their positions on the page, set
+	// when the extraction was asked for layout
+	Tokens []TextToken `json:"tokens,omitempty"`
+}
+
+// ContentHash returns the hex SHA-256 of text, or "" for blank text, which
+// would otherwise make every empty extraction a duplicate of the others
+func ContentHash(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewPageText normalizes whitespace in a page's text
+func NewPageText(number int, text string) PageText {
+	return PageText{Number: number, Text: strings.Join(strings.Fields(text), " ")}
 }
 
 // ExtractionError represents errors that occur during text extraction
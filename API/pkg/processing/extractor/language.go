@@ -0,0 +1,101 @@
+package extractor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LanguageUnknown is reported when no language profile matches the text with
+// enough confidence
+const LanguageUnknown = "unknown"
+
+const (
+	// languageSampleWords is how many leading words are scored
+	languageSampleWords = 300
+	// languageMinWords is the fewest words a confident guess needs
+	languageMinWords = 8
+	// languageMinShare is the share of sampled words that must be stopwords
+	// of the winning language
+	languageMinShare = 0.12
+	// languageMinMargin is how many times more stopword hits the winning
+	// language needs than the runner-up
+	languageMinMargin = 1.5
+)
+
+// languageStopwords are frequent function words (plus a few legal terms) of
+// each detectable language, keyed by ISO 639-1 code. Words shared between
+// languages count toward each of them, so only the distinctive ones decide.
+var languageStopwords = map[string]map[string]bool{
+	"en": wordSet(
+		"the", "and", "of", "to", "a", "in", "for", "is", "on", "that", "by", "this", "with",
+		"from", "or", "an", "be", "as", "was", "shall", "it", "not", "are", "which", "have",
+		"has", "been", "his", "her", "their", "any", "such", "court", "defendant", "plaintiff",
+	),
+	"es": wordSet(
+		"el", "la", "los", "las", "de", "del", "y", "en", "que", "por", "para", "con", "un",
+		"una", "se", "su", "sus", "al", "es", "lo", "como", "más", "pero", "sobre", "este",
+		"esta", "fue", "ha", "son", "tribunal", "demandado", "demandante", "corte", "juez",
+	),
+	"fr": wordSet(
+		"le", "la", "les", "de", "des", "du", "et", "en", "que", "qui", "pour", "par", "dans",
+		"un", "une", "est", "sur", "au", "aux", "ce", "cette", "il", "elle", "pas", "ne",
+		"sont", "avec", "été", "tribunal", "défendeur", "demandeur", "cour",
+	),
+	"pt": wordSet(
+		"o", "os", "a", "as", "de", "do", "da", "dos", "das", "e", "em", "no", "na", "que",
+		"por", "para", "com", "um", "uma", "se", "não", "ao", "é", "foi", "pelo", "pela",
+		"mais", "seu", "sua", "tribunal", "réu", "autor",
+	),
+}
+
+// wordSet builds a lookup set from words
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// DetectLanguage guesses the ISO 639-1 code of text from the frequency of
+// each language's stopwords in its first words. Returns LanguageUnknown when
+// the text is too short or no language clearly wins.
+func DetectLanguage(text string) string {
+	hits := make(map[string]int, len(languageStopwords))
+	sampled := 0
+	for _, field := range strings.Fields(text) {
+		word := strings.ToLower(strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		}))
+		if word == "" {
+			continue
+		}
+		for language, stopwords := range languageStopwords {
+			if stopwords[word] {
+				hits[language]++
+			}
+		}
+		if sampled++; sampled == languageSampleWords {
+			break
+		}
+	}
+	if sampled < languageMinWords {
+		return LanguageUnknown
+	}
+
+	best, bestHits, runnerUpHits := LanguageUnknown, 0, 0
+	for language, count := range hits {
+		switch {
+		case count > bestHits:
+			best, bestHits, runnerUpHits = language, count, bestHits
+		case count > runnerUpHits:
+			runnerUpHits = count
+		}
+	}
+
+	if float64(bestHits)/float64(sampled) < languageMinShare ||
+		float64(bestHits) < languageMinMargin*float64(runnerUpHits) {
+		return LanguageUnknown
+	}
+	return best
+}
@@ -0,0 +1,49 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]struct {
+		text     string
+		expected string
+	}{
+		"english motion": {
+			text:     "The defendant moves the court for an order suppressing all evidence obtained from the search of his vehicle, which was conducted without a warrant.",
+			expected: "en",
+		},
+		"spanish filing": {
+			text:     "El demandado solicita al tribunal que suprima toda la evidencia obtenida durante el registro de su vehículo, que se realizó sin una orden judicial.",
+			expected: "es",
+		},
+		"french filing": {
+			text:     "Le défendeur demande au tribunal de supprimer les preuves obtenues lors de la fouille de son véhicule, qui a été effectuée sans mandat.",
+			expected: "fr",
+		},
+		"portuguese filing": {
+			text:     "O réu requer ao tribunal que exclua as provas obtidas na busca do seu veículo, que foi realizada sem um mandado judicial.",
+			expected: "pt",
+		},
+		"too short": {
+			text:     "Motion to Suppress",
+			expected: LanguageUnknown,
+		},
+		"no stopwords": {
+			text:     "§ 1538.5 CR-2024-001234 12/03/2024 Dept. 22 Hon. Smith PC 459 PC 664 PC 187",
+			expected: LanguageUnknown,
+		},
+		"empty": {
+			text:     "",
+			expected: LanguageUnknown,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectLanguage(tt.text))
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package extractor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// MAPI property IDs read from Outlook .msg files
+const (
+	mapiSubject             = 0x0037
+	mapiClientSubmitTime    = 0x0039
+	mapiTransportHeaders    = 0x007D
+	mapiSenderName          = 0x0C1A
+	mapiSenderEmail         = 0x0C1F
+	mapiRecipientType       = 0x0C15
+	mapiDisplayCc           = 0x0E03
+	mapiDisplayTo           = 0x0E04
+	mapiDeliveryTime        = 0x0E06
+	mapiBody                = 0x1000
+	mapiHTMLBody            = 0x1013
+	mapiInternetMessageID   = 0x1035
+	mapiDisplayName         = 0x3001
+	mapiEmailAddress        = 0x3003
+	mapiAttachData          = 0x3701
+	mapiAttachFilename      = 0x3704
+	mapiAttachLongFilename  = 0x3707
+	mapiAttachMimeTag       = 0x370E
+	mapiSMTPAddress         = 0x39FE
+	mapiSenderSMTPAddress   = 0x5D01
+	mapiTypeString          = 0x001F
+	mapiTypeString8         = 0x001E
+	mapiTypeBinary          = 0x0102
+	mapiTypeObject          = 0x000D
+	mapiTypeLong            = 0x0003
+	mapiTypeTime            = 0x0040
+	msgPropertiesStream     = "__properties_version1.0"
+	msgRecipientPrefix      = "__recip_version1.0_"
+	msgAttachmentPrefix     = "__attach_version1.0_"
+	msgTopPropertiesHeader  = 32
+	msgSubPropertiesHeader  = 8
+	filetimeUnixEpochOffset = 116444736000000000
+)
+
+// parseMSG reads an Outlook .msg file: a compound file holding one stream
+// per MAPI property, with a storage for each recipient and attachment
+func parseMSG(content []byte) (*parsedEmail, error) {
+	cf, err := openCompoundFile(content)
+	if err != nil {
+		return nil, err
+	}
+
+	email := &parsedEmail{
+		headers: EmailHeaders{
+			Subject:   cf.msgString(0, mapiSubject),
+			MessageID: strings.Trim(cf.msgString(0, mapiInternetMessageID), "<>"),
+			From:      cf.msgSender(),
+		},
+		plainBody: cf.msgString(0, mapiBody),
+	}
+	if email.plainBody == "" {
+		if html := cf.msgBinary(0, mapiHTMLBody); html != nil {
+			email.htmlBody = strings.ToValidUTF8(string(html), "")
+		} else {
+			email.htmlBody = cf.msgString(0, mapiHTMLBody)
+		}
+	}
+
+	for _, id := range []uint16{mapiClientSubmitTime, mapiDeliveryTime} {
+		if value, ok := cf.msgFixedProperty(0, msgTopPropertiesHeader, id, mapiTypeTime); ok {
+			date := filetimeToTime(binary.LittleEndian.Uint64(value))
+			email.headers.Date = &date
+			break
+		}
+	}
+	// Messages that were received keep their internet headers, which have
+	// the original date when the MAPI properties do not
+	if raw := cf.msgString(0, mapiTransportHeaders); raw != "" && email.headers.Date == nil {
+		if message, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(raw, "\r\n") + "\r\n\r\n")); err == nil {
+			if date, err := message.Header.Date(); err == nil {
+				email.headers.Date = &date
+			}
+		}
+	}
+
+	for _, child := range cf.children(0) {
+		entry := cf.entries[child]
+		switch {
+		case strings.HasPrefix(entry.name, msgRecipientPrefix):
+			cf.msgRecipient(child, &email.headers)
+		case strings.HasPrefix(entry.name, msgAttachmentPrefix):
+			email.attachments = append(email.attachments, cf.msgAttachment(child))
+		}
+	}
+	if len(email.headers.To) == 0 {
+		email.headers.To = splitDisplayList(cf.msgString(0, mapiDisplayTo))
+	}
+	if len(email.headers.Cc) == 0 {
+		email.headers.Cc = splitDisplayList(cf.msgString(0, mapiDisplayCc))
+	}
+	return email, nil
+}
+
+// msgSender formats the sender, preferring the SMTP address over the
+// Exchange address Outlook may store for internal senders
+func (cf *compoundFile) msgSender() string {
+	address := cf.msgString(0, mapiSenderSMTPAddress)
+	if address == "" {
+		if email := cf.msgString(0, mapiSenderEmail); strings.Contains(email, "@") {
+			address = email
+		}
+	}
+	return formatAddress(cf.msgString(0, mapiSenderName), address)
+}
+
+// msgRecipient adds a recipient storage to the To or Cc list; blind copies
+// are left out
+func (cf *compoundFile) msgRecipient(storage int, headers *EmailHeaders) {
+	address := cf.msgString(storage, mapiSMTPAddress)
+	if address == "" {
+		if email := cf.msgString(storage, mapiEmailAddress); strings.Contains(email, "@") {
+			address = email
+		}
+	}
+	formatted := formatAddress(cf.msgString(storage, mapiDisplayName), address)
+	if formatted == "" {
+		return
+	}
+
+	recipientType := uint32(1)
+	if value, ok := cf.msgFixedProperty(storage, msgSubPropertiesHeader, mapiRecipientType, mapiTypeLong); ok {
+		recipientType = binary.LittleEndian.Uint32(value)
+	}
+	switch recipientType {
+	case 1:
+		headers.To = append(headers.To, formatted)
+	case 2:
+		headers.Cc = append(headers.Cc, formatted)
+	}
+}
+
+// msgAttachment reads an attachment storage. Attached messages are stored
+// as a nested storage rather than data and are listed without content.
+func (cf *compoundFile) msgAttachment(storage int) EmailAttachment {
+	attachment := EmailAttachment{
+		FileName:    cf.msgString(storage, mapiAttachLongFilename),
+		ContentType: cf.msgString(storage, mapiAttachMimeTag),
+		content:     cf.msgBinary(storage, mapiAttachData),
+	}
+	if attachment.FileName == "" {
+		attachment.FileName = cf.msgString(storage, mapiAttachFilename)
+	}
+	if attachment.FileName == "" {
+		attachment.FileName = cf.msgString(storage, mapiDisplayName)
+	}
+	if _, embedded := cf.find(storage, msgStreamName(mapiAttachData, mapiTypeObject)); embedded {
+		attachment.ContentType = "application/vnd.ms-outlook"
+		if !strings.HasSuffix(strings.ToLower(attachment.FileName), ".msg") {
+			attachment.FileName += ".msg"
+		}
+	}
+	if attachment.FileName == "" {
+		attachment.FileName = "attachment"
+	}
+	attachment.Size = len(attachment.content)
+	return attachment
+}
+
+// msgStreamName names the stream of a variable-length property
+func msgStreamName(id, propType uint16) string {
+	return fmt.Sprintf("__substg1.0_%04X%04X", id, propType)
+}
+
+// msgString reads a string property stored as UTF-16 or, in older files, as
+// 8-bit text
+func (cf *compoundFile) msgString(storage int, id uint16) string {
+	if data := cf.msgStream(storage, msgStreamName(id, mapiTypeString)); data != nil {
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, binary.LittleEndian.Uint16(data[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	if data := cf.msgStream(storage, msgStreamName(id, mapiTypeString8)); data != nil {
+		return strings.TrimRight(decodeCharset(data, "windows-1252"), "\x00")
+	}
+	return ""
+}
+
+// msgBinary reads a binary property, or nil when it is absent
+func (cf *compoundFile) msgBinary(storage int, id uint16) []byte {
+	return cf.msgStream(storage, msgStreamName(id, mapiTypeBinary))
+}
+
+// msgStream reads a named stream of a storage, or nil when it is absent or
+// unreadable
+func (cf *compoundFile) msgStream(storage int, name string) []byte {
+	index, ok := cf.find(storage, name)
+	if !ok {
+		return nil
+	}
+	data, err := cf.readStream(index)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// msgFixedProperty looks up a fixed-size property in a storage's properties
+// stream, which holds 16-byte entries after a header whose size depends on
+// the storage. It returns the 8-byte value.
+func (cf *compoundFile) msgFixedProperty(storage, headerSize int, id, propType uint16) ([]byte, bool) {
+	data := cf.msgStream(storage, msgPropertiesStream)
+	for offset := headerSize; offset+16 <= len(data); offset += 16 {
+		tag := binary.LittleEndian.Uint32(data[offset:])
+		if uint16(tag>>16) == id && uint16(tag) == propType {
+			return data[offset+8 : offset+16], true
+		}
+	}
+	return nil, false
+}
+
+// filetimeToTime converts a Windows FILETIME, in 100ns intervals since 1601,
+// to UTC
+func filetimeToTime(filetime uint64) time.Time {
+	return time.Unix(0, (int64(filetime)-filetimeUnixEpochOffset)*100).UTC()
+}
+
+// splitDisplayList splits Outlook's semicolon-separated display lists
+func splitDisplayList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -66,7 +66,7 @@ func (e *ocrExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 	// Calculate metrics
 	wordCount := countWords(text)
 	charCount := len(text)
-	language := e.detectLanguage(text)
+	language := DetectLanguage(text)
 	duration := time.Since(startTime).Milliseconds()
 
 	return &ExtractionResult{
@@ -307,38 +307,6 @@ func (e *ocrExtractor) cleanupTempFile(filename string) {
 	}
 }
 
-// detectLanguage performs basic language detection
-func (e *ocrExtractor) detectLanguage(text string) string {
-	// Simple heuristic-based language detection
-	englishWords := []string{
-		"the", "and", "of", "to", "a", "in", "for", "is", "on", "that",
-		"court", "case", "defendant", "plaintiff", "motion", "order",
-	}
-
-	words := strings.Fields(strings.ToLower(text))
-	if len(words) == 0 {
-		return "unknown"
-	}
-
-	englishCount := 0
-	sampleSize := min(50, len(words))
-
-	for i := 0; i < sampleSize; i++ {
-		for _, englishWord := range englishWords {
-			if words[i] == englishWord {
-				englishCount++
-				break
-			}
-		}
-	}
-
-	if float64(englishCount)/float64(sampleSize) > 0.15 {
-		return "en"
-	}
-
-	return e.config.Language // Return configured language as fallback
-}
-
 // GetOCRInfo returns information about the OCR system
 func (e *ocrExtractor) GetOCRInfo() map[string]interface{} {
 	client := gosseract.NewClient()
@@ -1,7 +1,6 @@
 package extractor
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -13,46 +12,94 @@ import (
 	"github.com/ledongthuc/pdf"
 )
 
-// pdfExtractor handles PDF files using the ledongthuc/pdf library
-type pdfExtractor struct{}
+// pdfTextLayerMethod names extraction from the PDF's text layer in result
+// metadata; fallback methods scrape raw streams instead
+const pdfTextLayerMethod = "ledongthuc/pdf"
+
+// pdfExtractor handles PDF files using the ledongthuc/pdf library, falling
+// back to OCR for image-only PDFs when ocr is set
+type pdfExtractor struct {
+	ocr    *pdfOCR
+	layout bool // Record the words of each page with their boxes
+}
 
 // NewPDFExtractor creates a new PDF extractor
 func NewPDFExtractor() Extractor {
 	return &pdfExtractor{}
 }
 
-// Extract extracts text from PDF files with fallback mechanisms
+// Extract extracts text from PDF files with fallback mechanisms. A reader with
+// random access, such as a spooled upload, is read in place; only the
+// fallbacks for PDFs without a readable text layer load the whole file.
 func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *DocumentMetadata) (*ExtractionResult, error) {
-	// Read the PDF content
-	content, err := io.ReadAll(reader)
+	source, size, err := readerAtSource(reader)
 	if err != nil {
 		return nil, NewExtractionError("pdf", "failed to read PDF file", err)
 	}
 
-	log.Printf("[PDF-EXTRACT] 📄 Processing PDF: %s, size: %d bytes", metadata.FileName, len(content))
+	log.Printf("[PDF-EXTRACT] 📄 Processing PDF: %s, size: %d bytes", metadata.FileName, size)
+
+	result, err := e.extractTextLayer(source, size)
+	if e.ocr == nil || !metadata.OCRAllowed() || !e.ocr.needed(result, err) {
+		return result, err
+	}
+
+	// The OCR tools render the file from disk
+	content, readErr := readAllAt(source, size)
+	if readErr != nil {
+		log.Printf("[PDF-EXTRACT] ⚠️ Failed to read PDF for OCR, keeping text layer result: %v", readErr)
+		return result, err
+	}
+
+	log.Printf("[PDF-EXTRACT] 🔄 No usable text layer, running OCR")
+	ocrResult, ocrErr := e.ocr.extract(ctx, content)
+	if ocrErr != nil {
+		log.Printf("[PDF-EXTRACT] ⚠️ OCR failed, keeping text layer result: %v", ocrErr)
+		return result, err
+	}
+	if ocrResult.Text == "" {
+		log.Printf("[PDF-EXTRACT] ⚠️ OCR found no text, keeping text layer result")
+		return result, err
+	}
+	ocrResult.Language = DetectLanguage(ocrResult.Text)
+	ocrResult.Metadata["pdf_version"] = e.extractPDFVersion(content)
+	ocrResult.Metadata[MetadataKeyTitle] = pdfTitle(content, ocrResult.Text)
+	return ocrResult, nil
+}
 
+// extractTextLayer extracts the text of a PDF's text layer
+func (e *pdfExtractor) extractTextLayer(source io.ReaderAt, size int64) (*ExtractionResult, error) {
 	// Enhanced PDF validation - be more lenient
-	if len(content) < 4 {
-		log.Printf("[PDF-EXTRACT] ❌ PDF too small: %d bytes", len(content))
+	if size < 4 {
+		log.Printf("[PDF-EXTRACT] ❌ PDF too small: %d bytes", size)
 		return nil, NewExtractionError("pdf", "file too small to be a valid PDF", nil)
 	}
 
+	// The header checks only need the start of the file
+	searchLimit := int64(1024)
+	if size < searchLimit {
+		searchLimit = size
+	}
+	start := make([]byte, searchLimit)
+	if _, err := source.ReadAt(start, 0); err != nil && err != io.EOF {
+		return nil, NewExtractionError("pdf", "failed to read PDF file", err)
+	}
+
 	// Check for PDF header (be more flexible)
-	header := string(content[:4])
+	header := string(start[:4])
 	log.Printf("[PDF-EXTRACT] 🔍 PDF header check: %q", header)
 	if header != "%PDF" {
 		// Try to find PDF header within the first 1024 bytes (some files have prefixes)
 		headerFound := false
-		searchLimit := 1024
-		if len(content) < searchLimit {
-			searchLimit = len(content)
-		}
 
 		log.Printf("[PDF-EXTRACT] 🔍 Searching for PDF header in first %d bytes", searchLimit)
-		for i := 0; i <= searchLimit-4; i++ {
-			if string(content[i:i+4]) == "%PDF" {
+		for i := 0; i <= len(start)-4; i++ {
+			if string(start[i:i+4]) == "%PDF" {
 				headerFound = true
-				content = content[i:] // Trim prefix
+				// Trim prefix
+				start = start[i:]
+				source = io.NewSectionReader(source, int64(i), size-int64(i))
+				size -= int64(i)
 				log.Printf("[PDF-EXTRACT] ✅ Found PDF header at position %d", i)
 				break
 			}
@@ -66,16 +113,20 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 
 	// Try primary extraction method
 	log.Printf("[PDF-EXTRACT] 🔄 Attempting primary extraction method (ledongthuc/pdf)")
-	text, pageCount, err := e.extractWithPrimaryMethod(content)
+	text, pages, pageCount, err := e.extractWithPrimaryMethod(source, size)
 	if err == nil && text != "" {
 		// Success with primary method
 		log.Printf("[PDF-EXTRACT] ✅ Primary method successful: %d chars, %d pages", len(text), pageCount)
+		title := ExtractTitle(&ExtractionResult{
+			Text:     text,
+			Metadata: map[string]interface{}{MetadataKeyOutlineTitle: pdfOutlineTitle(source, size)},
+		})
 		log.Printf("[PDF-EXTRACT] 🧹 Before cleaning: %d chars", len(text))
 		text = e.cleanText(text)
 		log.Printf("[PDF-EXTRACT] 🧹 After cleaning: %d chars", len(text))
 		wordCount := countWords(text)
 		charCount := len(text)
-		language := e.detectLanguage(text)
+		language := DetectLanguage(text)
 
 		log.Printf("[PDF-EXTRACT] 🔍 About to return result: Text=%d chars, WordCount=%d, CharCount=%d",
 			len(text), wordCount, charCount)
@@ -86,11 +137,14 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 			CharCount: charCount,
 			PageCount: pageCount,
 			Language:  language,
+			Pages:     pages,
 			Metadata: map[string]interface{}{
-				"format":      "pdf",
-				"file_size":   len(content),
-				"extraction":  "ledongthuc/pdf",
-				"pdf_version": e.extractPDFVersion(content),
+				"format":                "pdf",
+				"file_size":             int(size),
+				"extraction":            pdfTextLayerMethod,
+				"pdf_version":           e.extractPDFVersion(start),
+				MetadataKeyHyperlinks:   extractPDFLinkAnnotationsAt(source, size),
+				MetadataKeyTitle:        title,
 			},
 		}
 
@@ -100,7 +154,11 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 
 	log.Printf("[PDF-EXTRACT] ⚠️ Primary method failed: err=%v, text_len=%d", err, len(text))
 
-	// Primary method failed, try fallback methods
+	// Primary method failed; the fallbacks scan the whole file
+	content, readErr := readAllAt(source, size)
+	if readErr != nil {
+		return nil, NewExtractionError("pdf", "failed to read PDF file", readErr)
+	}
 	log.Printf("[PDF-EXTRACT] 🔄 Attempting fallback extraction methods")
 	text, pageCount, extractionMethod, fallbackErr := e.extractWithFallbackMethods(content)
 	if fallbackErr != nil {
@@ -110,6 +168,7 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 	}
 
 	// Clean up the text
+	title := pdfTitle(content, text)
 	text = e.cleanText(text)
 
 	// Count words and characters
@@ -117,7 +176,7 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 	charCount := len(text)
 
 	// Detect language (basic detection)
-	language := e.detectLanguage(text)
+	language := DetectLanguage(text)
 
 	log.Printf("[PDF-EXTRACT] 🔍 Fallback path - About to return result: Text=%d chars, WordCount=%d",
 		len(text), wordCount)
@@ -129,10 +188,12 @@ func (e *pdfExtractor) Extract(ctx context.Context, reader io.Reader, metadata *
 		PageCount: pageCount,
 		Language:  language,
 		Metadata: map[string]interface{}{
-			"format":      "pdf",
-			"file_size":   len(content),
-			"extraction":  extractionMethod,
-			"pdf_version": e.extractPDFVersion(content),
+			"format":                "pdf",
+			"file_size":             len(content),
+			"extraction":            extractionMethod,
+			"pdf_version":           e.extractPDFVersion(content),
+			MetadataKeyHyperlinks:   extractPDFLinkAnnotations(content),
+			MetadataKeyTitle:        title,
 		},
 	}
 
@@ -150,24 +211,22 @@ func (e *pdfExtractor) CanExtract(format string) bool {
 	return strings.ToLower(format) == "pdf"
 }
 
-// extractWithPrimaryMethod uses the original ledongthuc/pdf method
-func (e *pdfExtractor) extractWithPrimaryMethod(content []byte) (string, int, error) {
-	// Create a reader from the content
-	contentReader := bytes.NewReader(content)
-
+// extractWithPrimaryMethod uses the original ledongthuc/pdf method, which
+// reads the objects it needs from source
+func (e *pdfExtractor) extractWithPrimaryMethod(source io.ReaderAt, size int64) (string, []PageText, int, error) {
 	// Open PDF for reading
 	log.Printf("[PDF-EXTRACT] 🔓 Opening PDF with ledongthuc/pdf library")
-	pdfReader, err := pdf.NewReader(contentReader, int64(len(content)))
+	pdfReader, err := pdf.NewReader(source, size)
 	if err != nil {
 		log.Printf("[PDF-EXTRACT] ❌ Failed to open PDF with ledongthuc/pdf: %v", err)
-		return "", 0, err
+		return "", nil, 0, err
 	}
 
 	log.Printf("[PDF-EXTRACT] ✅ PDF opened successfully, extracting text from all pages")
 	// Extract text from all pages
-	text, pageCount, err := e.extractAllText(pdfReader)
+	text, pages, pageCount, err := e.extractAllText(pdfReader)
 	log.Printf("[PDF-EXTRACT] 📊 Primary extraction result: %d chars, %d pages, err: %v", len(text), pageCount, err)
-	return text, pageCount, err
+	return text, pages, pageCount, err
 }
 
 // extractWithFallbackMethods tries alternative extraction approaches
@@ -347,9 +406,11 @@ func (e *pdfExtractor) cleanExtractedText(text string) string {
 	return strings.TrimSpace(cleaned.String())
 }
 
-// extractAllText extracts text from all pages of the PDF
-func (e *pdfExtractor) extractAllText(reader *pdf.Reader) (string, int, error) {
+// extractAllText extracts text from all pages of the PDF, keeping each page's
+// text alongside the combined document text
+func (e *pdfExtractor) extractAllText(reader *pdf.Reader) (string, []PageText, int, error) {
 	var allText strings.Builder
+	var pages []PageText
 	pageCount := reader.NumPage()
 	log.Printf("[PDF-EXTRACT] 📖 PDF has %d pages", pageCount)
 
@@ -377,11 +438,23 @@ func (e *pdfExtractor) extractAllText(reader *pdf.Reader) (string, int, error) {
 			allText.WriteString("\n\n")
 		}
 		allText.WriteString(pageText)
+		pages = append(pages, e.pageText(pageNum, page, pageText))
 	}
 
 	finalText := allText.String()
 	log.Printf("[PDF-EXTRACT] 📊 Total extraction result: %d chars from %d pages", len(finalText), pageCount)
-	return finalText, pageCount, nil
+	return finalText, pages, pageCount, nil
+}
+
+// pageText returns a page's text, with its layout when the extractor
+// records layout and the page has one
+func (e *pdfExtractor) pageText(number int, page pdf.Page, plainText string) PageText {
+	if e.layout {
+		if text, tokens := pageLayout(page); len(tokens) > 0 {
+			return PageText{Number: number, Text: text, Tokens: tokens}
+		}
+	}
+	return NewPageText(number, plainText)
 }
 
 // cleanText performs comprehensive text cleaning using the enhanced TextCleaner
@@ -726,44 +799,3 @@ func (e *pdfExtractor) extractPDFVersion(content []byte) string {
 
 	return "unknown"
 }
-
-// detectLanguage performs basic language detection
-func (e *pdfExtractor) detectLanguage(text string) string {
-	// Simple heuristic-based language detection
-	// Count common English words
-	englishWords := []string{
-		"the", "and", "of", "to", "a", "in", "for", "is", "on", "that",
-		"by", "this", "with", "from", "they", "we", "say", "her", "she",
-		"or", "an", "will", "my", "one", "all", "would", "there", "their",
-	}
-
-	words := strings.Fields(strings.ToLower(text))
-	if len(words) == 0 {
-		return "unknown"
-	}
-
-	englishCount := 0
-	totalWords := len(words)
-	maxWords := 100 // Sample first 100 words for efficiency
-
-	if totalWords > maxWords {
-		words = words[:maxWords]
-		totalWords = maxWords
-	}
-
-	for _, word := range words {
-		for _, englishWord := range englishWords {
-			if word == englishWord {
-				englishCount++
-				break
-			}
-		}
-	}
-
-	// If more than 20% of words are common English words, assume English
-	if float64(englishCount)/float64(totalWords) > 0.2 {
-		return "en"
-	}
-
-	return "unknown"
-}
@@ -52,10 +52,11 @@ func (e *dslipakPDFExtractor) Extract(ctx context.Context, reader io.Reader, met
 	}
 
 	// Clean and process the extracted text
+	title := pdfTitle(content, text)
 	text = e.cleanText(text)
 	wordCount := countWords(text)
 	charCount := len(text)
-	language := e.detectLanguage(text)
+	language := DetectLanguage(text)
 
 	return &ExtractionResult{
 		Text:      text,
@@ -64,10 +65,12 @@ func (e *dslipakPDFExtractor) Extract(ctx context.Context, reader io.Reader, met
 		PageCount: pageCount,
 		Language:  language,
 		Metadata: map[string]interface{}{
-			"format":      "pdf",
-			"file_size":   len(content),
-			"extraction":  "dslipak/pdf",
-			"pdf_version": e.extractPDFVersion(content),
+			"format":                "pdf",
+			"file_size":             len(content),
+			"extraction":            "dslipak/pdf",
+			"pdf_version":           e.extractPDFVersion(content),
+			MetadataKeyHyperlinks:   extractPDFLinkAnnotations(content),
+			MetadataKeyTitle:        title,
 		},
 		Success:  true,
 		Duration: 0, // Will be set by service
@@ -312,42 +315,6 @@ func (e *dslipakPDFExtractor) extractPDFVersion(content []byte) string {
 	return "unknown"
 }
 
-// detectLanguage performs basic language detection
-func (e *dslipakPDFExtractor) detectLanguage(text string) string {
-	// Simple heuristic-based language detection for English
-	englishWords := []string{
-		"the", "and", "of", "to", "a", "in", "for", "is", "on", "that",
-		"by", "this", "with", "from", "they", "we", "say", "her", "she",
-		"or", "an", "will", "my", "one", "all", "would", "there", "their",
-		// Legal terms
-		"court", "case", "defendant", "plaintiff", "motion", "order",
-	}
-
-	words := strings.Fields(strings.ToLower(text))
-	if len(words) == 0 {
-		return "unknown"
-	}
-
-	englishCount := 0
-	sampleSize := min(100, len(words)) // Sample first 100 words
-
-	for i := 0; i < sampleSize; i++ {
-		for _, englishWord := range englishWords {
-			if words[i] == englishWord {
-				englishCount++
-				break
-			}
-		}
-	}
-
-	// If more than 20% of words are common English words, assume English
-	if float64(englishCount)/float64(sampleSize) > 0.2 {
-		return "en"
-	}
-
-	return "unknown"
-}
-
 // Helper function to get minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -0,0 +1,120 @@
+package extractor
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Glyph boxes are estimated from the font size, as the PDF text layer only
+// gives each glyph's baseline: descenders reach about a fifth of the size
+// below it and capitals about four fifths above
+const (
+	layoutDescent = 0.2
+	layoutAscent  = 0.8
+)
+
+// TextToken is a word on a page and the box it occupies, in PDF points
+// (1/72 inch) with the origin at the bottom-left corner of the page
+type TextToken struct {
+	Text   string     `json:"text"`
+	Offset int        `json:"offset"` // Byte offset of the word in the page's Text
+	BBox   [4]float64 `json:"bbox"`   // [x0, y0, x1, y1]
+}
+
+// layoutGlyph is one character of a page's text layer
+type layoutGlyph struct {
+	r         rune
+	x0, x1, y float64
+	size      float64
+}
+
+// pageLayout reads the words of a page with their boxes, returning the page
+// text with the words separated by single spaces so the tokens' offsets
+// index into it. The PDF reader panics on some malformed content streams,
+// in which case the page has no layout.
+func pageLayout(page pdf.Page) (text string, tokens []TextToken) {
+	defer func() {
+		if recover() != nil {
+			text, tokens = "", nil
+		}
+	}()
+	return layoutTokens(page.Content().Text)
+}
+
+// layoutTokens groups the glyphs of a page's text layer into words. A word
+// ends at whitespace, at a jump to another line, or at a gap wider than a
+// space between glyphs on the same line.
+func layoutTokens(texts []pdf.Text) (string, []TextToken) {
+	var glyphs []layoutGlyph
+	for _, t := range texts {
+		n := utf8.RuneCountInString(t.S)
+		if n == 0 {
+			continue
+		}
+		width := t.W / float64(n)
+		i := 0
+		for _, r := range t.S {
+			x0 := t.X + float64(i)*width
+			glyphs = append(glyphs, layoutGlyph{r: r, x0: x0, x1: x0 + width, y: t.Y, size: t.FontSize})
+			i++
+		}
+	}
+
+	var page strings.Builder
+	var tokens []TextToken
+	var word strings.Builder
+	var box [4]float64
+	var last *layoutGlyph
+
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		if page.Len() > 0 {
+			page.WriteByte(' ')
+		}
+		tokens = append(tokens, TextToken{Text: word.String(), Offset: page.Len(), BBox: box})
+		page.WriteString(word.String())
+		word.Reset()
+	}
+
+	for i := range glyphs {
+		g := &glyphs[i]
+		if unicode.IsSpace(g.r) || unicode.IsControl(g.r) {
+			flush()
+			last = nil
+			continue
+		}
+		if last != nil && (math.Abs(g.y-last.y) > last.size/2 || g.x0-last.x1 > last.size/4 || g.x0 < last.x0) {
+			flush()
+		}
+
+		y0, y1 := g.y-layoutDescent*g.size, g.y+layoutAscent*g.size
+		if word.Len() == 0 {
+			box = [4]float64{g.x0, y0, g.x1, y1}
+		} else {
+			box = [4]float64{math.Min(box[0], g.x0), math.Min(box[1], y0), math.Max(box[2], g.x1), math.Max(box[3], y1)}
+		}
+		word.WriteRune(g.r)
+		last = g
+	}
+	flush()
+
+	return page.String(), tokens
+}
+
+// TokensInRange returns the tokens overlapping the byte range [start, end)
+// of the page's Text
+func (p *PageText) TokensInRange(start, end int) []TextToken {
+	var tokens []TextToken
+	for _, token := range p.Tokens {
+		if token.Offset < end && token.Offset+len(token.Text) > start {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,61 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// glyphs lays s out from x on the baseline y as 6pt-wide glyphs of a 10pt
+// font, one pdf.Text per character as most PDF writers emit them
+func glyphs(s string, x, y float64) []pdf.Text {
+	var texts []pdf.Text
+	for _, r := range s {
+		texts = append(texts, pdf.Text{FontSize: 10, X: x, Y: y, W: 6, S: string(r)})
+		x += 6
+	}
+	return texts
+}
+
+func TestLayoutTokens(t *testing.T) {
+	texts := glyphs("SSN 123-45-6789", 72, 700)
+	texts = append(texts, glyphs("Judge", 72, 686)...)
+
+	text, tokens := layoutTokens(texts)
+
+	assert.Equal(t, "SSN 123-45-6789 Judge", text)
+	require.Len(t, tokens, 3)
+
+	assert.Equal(t, TextToken{Text: "SSN", Offset: 0, BBox: [4]float64{72, 698, 90, 708}}, tokens[0])
+	assert.Equal(t, TextToken{Text: "123-45-6789", Offset: 4, BBox: [4]float64{96, 698, 162, 708}}, tokens[1])
+	assert.Equal(t, "Judge", tokens[2].Text, "a new line starts a new word")
+	assert.Equal(t, [4]float64{72, 684, 102, 694}, tokens[2].BBox)
+
+	for _, token := range tokens {
+		assert.Equal(t, token.Text, text[token.Offset:token.Offset+len(token.Text)])
+	}
+}
+
+func TestLayoutTokens_SplitsWordsAtGaps(t *testing.T) {
+	texts := append(glyphs("Name:", 72, 700), glyphs("Jane", 200, 700)...)
+
+	text, tokens := layoutTokens(texts)
+
+	assert.Equal(t, "Name: Jane", text)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, 200.0, tokens[1].BBox[0])
+}
+
+func TestPageText_TokensInRange(t *testing.T) {
+	text, tokens := layoutTokens(glyphs("call 555-123-4567 today", 72, 700))
+	page := PageText{Number: 1, Text: text, Tokens: tokens}
+
+	matched := page.TokensInRange(5, 17)
+	require.Len(t, matched, 1)
+	assert.Equal(t, "555-123-4567", matched[0].Text)
+
+	assert.Len(t, page.TokensInRange(3, 6), 2, "a range spanning a space overlaps both words")
+	assert.Empty(t, page.TokensInRange(4, 5))
+}
@@ -0,0 +1,166 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Defaults for OCR of image-only PDFs
+const (
+	DefaultOCRMinTextLength = 50
+	DefaultOCRLanguage      = "eng"
+	DefaultOCRDPI           = 300
+)
+
+// pdfOCR reads image-only PDFs, such as scanned court filings, by rendering
+// their pages with pdftoppm and reading the images with tesseract
+type pdfOCR struct {
+	pdftoppm      string
+	tesseract     string
+	language      string
+	dpi           int
+	maxPages      int
+	minTextLength int
+}
+
+// newPDFOCR returns the OCR fallback configured by opts, or nil when OCR is
+// disabled or pdftoppm or tesseract is not installed
+func newPDFOCR(opts ExtractOptions) *pdfOCR {
+	if !opts.EnableOCR {
+		return nil
+	}
+	pdftoppm, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		log.Printf("[PDF-OCR] ⚠️ OCR disabled: pdftoppm not found (install poppler-utils)")
+		return nil
+	}
+	tesseract, err := exec.LookPath("tesseract")
+	if err != nil {
+		log.Printf("[PDF-OCR] ⚠️ OCR disabled: tesseract not found")
+		return nil
+	}
+
+	ocr := &pdfOCR{
+		pdftoppm:      pdftoppm,
+		tesseract:     tesseract,
+		language:      opts.OCRLanguage,
+		dpi:           DefaultOCRDPI,
+		maxPages:      opts.OCRMaxPages,
+		minTextLength: opts.OCRMinTextLength,
+	}
+	if ocr.language == "" {
+		ocr.language = DefaultOCRLanguage
+	}
+	if ocr.minTextLength <= 0 {
+		ocr.minTextLength = DefaultOCRMinTextLength
+	}
+	return ocr
+}
+
+// needed reports whether a text layer extraction is unlikely to be the
+// document's content: it failed, found almost no text, or only scraped raw
+// PDF streams because the PDF library could not read a text layer
+func (o *pdfOCR) needed(result *ExtractionResult, err error) bool {
+	if err != nil || result == nil {
+		return true
+	}
+	if method, _ := result.Metadata["extraction"].(string); method != pdfTextLayerMethod {
+		return true
+	}
+	return utf8.RuneCountInString(strings.TrimSpace(result.Text)) < o.minTextLength
+}
+
+// extract renders each page of a PDF and reads its text with tesseract
+func (o *pdfOCR) extract(ctx context.Context, content []byte) (*ExtractionResult, error) {
+	dir, err := os.MkdirTemp("", "pdf-ocr-")
+	if err != nil {
+		return nil, NewExtractionError("pdf", "failed to create OCR work directory", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, content, 0o600); err != nil {
+		return nil, NewExtractionError("pdf", "failed to write PDF for OCR", err)
+	}
+
+	args := []string{"-r", strconv.Itoa(o.dpi), "-gray", "-png"}
+	if o.maxPages > 0 {
+		args = append(args, "-l", strconv.Itoa(o.maxPages))
+	}
+	args = append(args, input, filepath.Join(dir, "page"))
+	if output, err := exec.CommandContext(ctx, o.pdftoppm, args...).CombinedOutput(); err != nil {
+		return nil, NewExtractionError("pdf", fmt.Sprintf("failed to render PDF pages: %s", strings.TrimSpace(string(output))), err)
+	}
+
+	images, err := renderedPages(dir)
+	if err != nil {
+		return nil, NewExtractionError("pdf", "failed to list rendered pages", err)
+	}
+	if len(images) == 0 {
+		return nil, NewExtractionError("pdf", "PDF has no pages to OCR", nil)
+	}
+
+	var pages []PageText
+	var texts []string
+	for _, image := range images {
+		output, err := exec.CommandContext(ctx, o.tesseract, image.path, "stdout", "-l", o.language).Output()
+		if err != nil {
+			return nil, NewExtractionError("pdf", fmt.Sprintf("OCR failed on page %d", image.number), err)
+		}
+		text := strings.TrimSpace(string(output))
+		if text == "" {
+			continue
+		}
+		texts = append(texts, text)
+		pages = append(pages, NewPageText(image.number, text))
+	}
+
+	text := strings.Join(texts, "\n\n")
+	log.Printf("[PDF-OCR] ✅ OCR read %d chars from %d pages", len(text), len(images))
+	return &ExtractionResult{
+		Text:      text,
+		PageCount: len(images),
+		WordCount: countWords(text),
+		CharCount: len(text),
+		Pages:     pages,
+		OCRUsed:   true,
+		Metadata: map[string]interface{}{
+			"format":       "pdf",
+			"file_size":    len(content),
+			"extraction":   "tesseract",
+			"ocr_language": o.language,
+		},
+	}, nil
+}
+
+// renderedPage is a page image written by pdftoppm
+type renderedPage struct {
+	number int
+	path   string
+}
+
+// renderedPages lists the page-N.png images in dir by page number
+func renderedPages(dir string) ([]renderedPage, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	pages := make([]renderedPage, 0, len(paths))
+	for _, path := range paths {
+		number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "page-"), ".png"))
+		if err != nil {
+			continue
+		}
+		pages = append(pages, renderedPage{number: number, path: path})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].number < pages[j].number })
+	return pages, nil
+}
@@ -0,0 +1,85 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installFakeOCRTools puts pdftoppm and tesseract stand-ins first on the
+// PATH. pdftoppm "renders" two pages and tesseract "reads" a line per page.
+func installFakeOCRTools(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"pdftoppm": "#!/bin/sh\nfor prefix; do :; done\ntouch \"$prefix-1.png\" \"$prefix-2.png\"\n",
+		"tesseract": "#!/bin/sh\ncase \"$1\" in\n" +
+			"*-1.png) echo 'SUPERIOR COURT OF CALIFORNIA, COUNTY OF ALAMEDA' ;;\n" +
+			"*-2.png) echo 'ORDER GRANTING MOTION TO SUPPRESS EVIDENCE' ;;\n" +
+			"esac\n",
+	}
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// scannedPDF is a PDF with a page but no text layer
+var scannedPDF = []byte("%PDF-1.4\n1 0 obj << /Type /Page /Resources << /XObject << /Im0 2 0 R >> >> >> endobj\n" +
+	"2 0 obj << /Type /XObject /Subtype /Image /Width 1 /Height 1 >> endobj\n%%EOF\n")
+
+func TestExtractText_OCRFallbackForImageOnlyPDF(t *testing.T) {
+	installFakeOCRTools(t)
+	svc := NewServiceWithOptions(ExtractOptions{EnableOCR: true})
+
+	result, err := svc.ExtractText(context.Background(), bytes.NewReader(scannedPDF), &DocumentMetadata{FileName: "order.pdf"})
+	require.NoError(t, err)
+
+	assert.True(t, result.OCRUsed)
+	assert.Equal(t, "SUPERIOR COURT OF CALIFORNIA, COUNTY OF ALAMEDA\n\nORDER GRANTING MOTION TO SUPPRESS EVIDENCE", result.Text)
+	assert.Equal(t, 2, result.PageCount)
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, 2, result.Pages[1].Number)
+	assert.Equal(t, "tesseract", result.Metadata["extraction"])
+	assert.Equal(t, DefaultOCRLanguage, result.Metadata["ocr_language"])
+}
+
+func TestExtractText_OCRFallbackGating(t *testing.T) {
+	installFakeOCRTools(t)
+
+	t.Run("disabled", func(t *testing.T) {
+		result, err := NewServiceWithOptions(ExtractOptions{}).ExtractText(context.Background(), bytes.NewReader(scannedPDF), &DocumentMetadata{FileName: "order.pdf"})
+		require.NoError(t, err)
+		assert.False(t, result.OCRUsed)
+	})
+
+	t.Run("turned off for the document", func(t *testing.T) {
+		off := false
+		result, err := NewServiceWithOptions(ExtractOptions{EnableOCR: true}).ExtractText(context.Background(), bytes.NewReader(scannedPDF), &DocumentMetadata{FileName: "order.pdf", OCR: &off})
+		require.NoError(t, err)
+		assert.False(t, result.OCRUsed)
+	})
+
+	t.Run("usable text layer", func(t *testing.T) {
+		ocr := &pdfOCR{minTextLength: DefaultOCRMinTextLength}
+		textLayer := func(text string) *ExtractionResult {
+			return &ExtractionResult{Text: text, Metadata: map[string]interface{}{"extraction": pdfTextLayerMethod}}
+		}
+		assert.True(t, ocr.needed(textLayer("  Page 1  "), nil))
+		assert.False(t, ocr.needed(textLayer("Notice of motion and motion to suppress evidence obtained in a warrantless search."), nil))
+
+		// Text scraped from raw streams is not a text layer, however long
+		scraped := &ExtractionResult{Text: "%PDF-1.4 1 0 obj > >> >> endobj 2 0 obj > endobj %%EOF obj obj obj", Metadata: map[string]interface{}{"extraction": "raw_streams"}}
+		assert.True(t, ocr.needed(scraped, nil))
+	})
+
+	t.Run("tools missing", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		assert.Nil(t, newPDFOCR(ExtractOptions{EnableOCR: true}))
+	})
+}
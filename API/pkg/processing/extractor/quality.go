@@ -0,0 +1,159 @@
+package extractor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultMinQualityScore is the score below which an extraction is flagged for review
+const DefaultMinQualityScore = 0.5
+
+// ExtractionQuality scores how much extracted text looks like real language.
+// Bad OCR and encoding problems produce runs of symbols, consonant clusters
+// and control characters, all of which pull the score down.
+type ExtractionQuality struct {
+	Score                 float64 `json:"score"`                   // 0 (garbage) to 1 (clean)
+	RecognizableWordRatio float64 `json:"recognizable_word_ratio"` // Share of tokens that look like words or numbers
+	NonPrintableRate      float64 `json:"non_printable_rate"`      // Share of characters that are control or replacement characters
+	AverageWordLength     float64 `json:"average_word_length"`
+	LowQuality            bool    `json:"low_quality"` // Set by callers that apply a threshold
+}
+
+// nonPrintablePenaltyScale makes 10% unprintable characters zero the score
+const nonPrintablePenaltyScale = 10
+
+// legalMarks are standalone punctuation tokens that are normal in legal text
+var legalMarks = map[string]bool{
+	"§": true, "§§": true, "¶": true, "-": true, "–": true, "—": true, "&": true, "*": true, "•": true, "...": true,
+}
+
+// AssessQuality computes the quality signals for extracted text. The score is
+// the recognizable word ratio, scaled down by the non-printable rate and by
+// an implausible average word length.
+func AssessQuality(text string) *ExtractionQuality {
+	quality := &ExtractionQuality{}
+	if strings.TrimSpace(text) == "" {
+		return quality
+	}
+
+	var runes, nonPrintable int
+	for _, r := range text {
+		runes++
+		if r == unicode.ReplacementChar || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			nonPrintable++
+		}
+	}
+	quality.NonPrintableRate = float64(nonPrintable) / float64(runes)
+
+	tokens := strings.Fields(text)
+	var recognizable, totalLength int
+	for _, token := range tokens {
+		totalLength += len([]rune(token))
+		if isRecognizableToken(token) {
+			recognizable++
+		}
+	}
+	quality.RecognizableWordRatio = float64(recognizable) / float64(len(tokens))
+	quality.AverageWordLength = float64(totalLength) / float64(len(tokens))
+
+	printableScore := 1 - quality.NonPrintableRate*nonPrintablePenaltyScale
+	if printableScore < 0 {
+		printableScore = 0
+	}
+
+	quality.Score = quality.RecognizableWordRatio * printableScore * (0.5 + 0.5*wordLengthScore(quality.AverageWordLength))
+	return quality
+}
+
+// ApplyThreshold marks the result as low quality when its score is below min
+func (q *ExtractionQuality) ApplyThreshold(min float64) {
+	if q != nil {
+		q.LowQuality = min > 0 && q.Score < min
+	}
+}
+
+// wordLengthScore is 1 for typical prose (3-8 characters per token) and falls off outside it
+func wordLengthScore(avg float64) float64 {
+	switch {
+	case avg <= 0:
+		return 0
+	case avg < 3:
+		return avg / 3
+	case avg > 8:
+		return 8 / avg
+	default:
+		return 1
+	}
+}
+
+// isRecognizableToken reports whether a token looks like a word, number or
+// citation fragment rather than extraction noise
+func isRecognizableToken(token string) bool {
+	core := strings.TrimFunc(token, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	if core == "" {
+		return legalMarks[token]
+	}
+
+	var letters, digits, other, switches int
+	prev := ' '
+	for _, r := range core {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+			if prev == 'd' {
+				switches++
+			}
+			prev = 'l'
+		case unicode.IsDigit(r):
+			digits++
+			if prev == 'l' {
+				switches++
+			}
+			prev = 'd'
+		case r == '.' || r == '-' || r == '\'' || r == '/' || r == '(' || r == ')':
+		default:
+			other++
+		}
+	}
+	if other > 0 {
+		return false
+	}
+	if letters == 0 {
+		return digits > 0
+	}
+	if digits > 0 {
+		// Docket and statute numbers like 22CR001234 or 12(b)(6) mix letters and
+		// digits a few times; OCR confusion such as "l1l1l1" alternates constantly
+		return switches <= 3 && len([]rune(core)) <= 20
+	}
+	if len([]rune(core)) > 25 {
+		return false
+	}
+	return looksPronounceable(core)
+}
+
+// looksPronounceable rejects letter runs without vowels or with long consonant clusters
+func looksPronounceable(word string) bool {
+	lower := strings.ToLower(word)
+	hasVowel := false
+	run := 0
+	for _, r := range lower {
+		if !unicode.IsLetter(r) {
+			run = 0
+			continue
+		}
+		if strings.ContainsRune("aeiouy", r) || r > unicode.MaxASCII {
+			hasVowel = true
+			run = 0
+			continue
+		}
+		run++
+		if run > 4 {
+			return false
+		}
+	}
+	// Short all-consonant tokens are usually abbreviations (Mr, St, LLC)
+	return hasVowel || len([]rune(word)) <= 3
+}
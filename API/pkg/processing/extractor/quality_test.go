@@ -0,0 +1,82 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cleanMotionText = `SUPERIOR COURT OF CALIFORNIA, COUNTY OF LOS ANGELES
+People v. Smith, Case No. 22CR001234
+NOTICE OF MOTION AND MOTION TO SUPPRESS EVIDENCE
+Defendant moves under Penal Code § 1538.5 to suppress all evidence obtained
+from the warrantless search of his vehicle on March 3, 2022. The officers
+lacked probable cause, and no exception to the warrant requirement applies.`
+
+func TestAssessQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		minimum float64
+		maximum float64
+	}{
+		{
+			name:    "clean legal text",
+			text:    cleanMotionText,
+			minimum: 0.85,
+			maximum: 1,
+		},
+		{
+			name:    "garbled OCR",
+			text:    "Tlhe dcfcndnnt rnovcs ~~|| xzqwrtkp l1l1l1 #@%& bcdfghjk ;;;; qzxv ^^ }{ mnbvcxz ::== 0O0Oo ¦¦ ¬¬",
+			minimum: 0,
+			maximum: 0.5,
+		},
+		{
+			name:    "encoding garbage",
+			text:    "\x00\x01��M\x02O�T\x03I\x04O\x05N� �\x06\x07\x08 ��\x0e\x0f",
+			minimum: 0,
+			maximum: 0.3,
+		},
+		{
+			name:    "empty text",
+			text:    "   \n\t ",
+			minimum: 0,
+			maximum: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quality := AssessQuality(tt.text)
+			assert.GreaterOrEqual(t, quality.Score, tt.minimum)
+			assert.LessOrEqual(t, quality.Score, tt.maximum)
+		})
+	}
+}
+
+func TestAssessQuality_Signals(t *testing.T) {
+	clean := AssessQuality(cleanMotionText)
+	assert.Greater(t, clean.RecognizableWordRatio, 0.95)
+	assert.Zero(t, clean.NonPrintableRate)
+	assert.InDelta(t, 5.5, clean.AverageWordLength, 2.5)
+
+	garbled := AssessQuality("�� xzqwrtkp \x00\x00 bcdfghjk")
+	assert.Greater(t, garbled.NonPrintableRate, 0.1)
+	assert.Less(t, garbled.RecognizableWordRatio, 0.5)
+}
+
+func TestExtractionQuality_ApplyThreshold(t *testing.T) {
+	quality := &ExtractionQuality{Score: 0.42}
+
+	quality.ApplyThreshold(DefaultMinQualityScore)
+	assert.True(t, quality.LowQuality)
+
+	quality.ApplyThreshold(0.4)
+	assert.False(t, quality.LowQuality)
+
+	// A zero threshold disables flagging
+	quality.Score = 0
+	quality.ApplyThreshold(0)
+	assert.False(t, quality.LowQuality)
+}
@@ -0,0 +1,185 @@
+package extractor
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// MetadataKeyReadability holds the *Readability in ExtractionResult.Metadata
+const MetadataKeyReadability = "readability"
+
+// Readability holds text complexity metrics for research use. The Flesch
+// reading ease formula is calibrated on English syllable counts, so it is
+// only computed for English text; other languages get the length metrics.
+type Readability struct {
+	FleschReadingEase     *float64 `json:"flesch_reading_ease,omitempty"` // Higher is easier; legal prose typically scores 10-40
+	AverageSentenceLength float64  `json:"average_sentence_length"`       // Words per sentence
+	AverageWordLength     float64  `json:"average_word_length"`           // Letters per word
+	SentenceCount         int      `json:"sentence_count"`
+	WordCount             int      `json:"word_count"`
+	Language              string   `json:"language"` // "en" when the English formulas were applied
+}
+
+// readabilityAbbreviations end with a period without ending a sentence
+var readabilityAbbreviations = map[string]bool{
+	"v.": true, "vs.": true, "no.": true, "nos.": true, "mr.": true, "mrs.": true, "ms.": true, "dr.": true,
+	"hon.": true, "jr.": true, "sr.": true, "st.": true, "inc.": true, "co.": true, "corp.": true, "ltd.": true,
+	"cal.": true, "app.": true, "supp.": true, "fed.": true, "civ.": true, "crim.": true, "evid.": true, "proc.": true,
+	"id.": true, "cf.": true, "al.": true, "sec.": true, "art.": true, "p.": true, "pp.": true, "ch.": true,
+}
+
+// englishMarkers are common English function words used to recognize English
+// text when the extractor did not report a language
+var englishMarkers = map[string]bool{
+	"the": true, "and": true, "of": true, "to": true, "a": true, "in": true, "for": true, "is": true,
+	"on": true, "that": true, "by": true, "this": true, "with": true, "from": true, "or": true, "an": true,
+	"be": true, "as": true, "was": true, "shall": true, "court": true, "it": true, "not": true, "are": true,
+}
+
+// AssessReadability computes readability metrics for text. language is the
+// extractor's detected language; when it is empty the text is checked for
+// common English words instead. Returns nil for text without words.
+func AssessReadability(text, language string) *Readability {
+	tokens := strings.Fields(text)
+
+	var words, letters, syllables, sentences int
+	for i, token := range tokens {
+		core := strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if n := countLetters(core); n > 0 {
+			words++
+			letters += n
+			syllables += countSyllables(core)
+		}
+		if endsSentence(token, tokens, i) {
+			sentences++
+		}
+	}
+	if words == 0 {
+		return nil
+	}
+	// Text that trails off without terminal punctuation is still a sentence
+	if !endsSentence(tokens[len(tokens)-1], tokens, len(tokens)-1) {
+		sentences++
+	}
+
+	readability := &Readability{
+		AverageSentenceLength: round2(float64(words) / float64(sentences)),
+		AverageWordLength:     round2(float64(letters) / float64(words)),
+		SentenceCount:         sentences,
+		WordCount:             words,
+	}
+
+	if isEnglish(language, tokens) {
+		readability.Language = "en"
+		ease := round2(206.835 - 1.015*float64(words)/float64(sentences) - 84.6*float64(syllables)/float64(words))
+		readability.FleschReadingEase = &ease
+	} else if language != "" && language != "unknown" {
+		readability.Language = language
+	}
+	return readability
+}
+
+// endsSentence reports whether the token at index i closes a sentence:
+// terminal punctuation that is not an abbreviation or initial, followed by
+// the end of the text or a capitalized word
+func endsSentence(token string, tokens []string, i int) bool {
+	trimmed := strings.TrimRight(token, `"')]”’`)
+	if trimmed == "" || !strings.ContainsRune(".!?", rune(trimmed[len(trimmed)-1])) {
+		return false
+	}
+
+	if strings.HasSuffix(trimmed, ".") {
+		lower := strings.ToLower(strings.TrimLeft(trimmed, `"'([“‘`))
+		if readabilityAbbreviations[lower] || isInitialism(lower) {
+			return false
+		}
+	}
+
+	if i+1 >= len(tokens) {
+		return true
+	}
+	for _, r := range tokens[i+1] {
+		if unicode.IsLetter(r) {
+			return unicode.IsUpper(r)
+		}
+		if unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isInitialism matches single-letter initials and dotted forms such as
+// "j." or "u.s.c."
+func isInitialism(token string) bool {
+	parts := strings.Split(strings.TrimSuffix(token, "."), ".")
+	for _, part := range parts {
+		if len([]rune(part)) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEnglish trusts the extractor's language when it reported English, and
+// otherwise checks the share of common English words in the first 200 tokens
+func isEnglish(language string, tokens []string) bool {
+	switch strings.ToLower(language) {
+	case "en", "eng", "english":
+		return true
+	case "", "unknown":
+	default:
+		return false
+	}
+
+	sample := tokens
+	if len(sample) > 200 {
+		sample = sample[:200]
+	}
+	markers := 0
+	for _, token := range sample {
+		if englishMarkers[strings.ToLower(strings.Trim(token, ".,;:()\"'"))] {
+			markers++
+		}
+	}
+	return float64(markers)/float64(len(sample)) > 0.15
+}
+
+// countLetters counts the letters in a word, ignoring digits and punctuation
+func countLetters(word string) int {
+	n := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// countSyllables estimates English syllables as vowel groups, discounting a
+// silent final "e". Every word has at least one syllable.
+func countSyllables(word string) int {
+	lower := strings.ToLower(word)
+	count := 0
+	inVowel := false
+	for _, r := range lower {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+	if strings.HasSuffix(lower, "e") && !strings.HasSuffix(lower, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+// round2 rounds to two decimal places
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
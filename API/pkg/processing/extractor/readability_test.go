@@ -0,0 +1,55 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssessReadability(t *testing.T) {
+	readability := AssessReadability("The court granted the motion. The defendant appealed the order.", "")
+	require.NotNil(t, readability)
+
+	assert.Equal(t, 10, readability.WordCount)
+	assert.Equal(t, 2, readability.SentenceCount)
+	assert.Equal(t, 5.0, readability.AverageSentenceLength)
+	assert.Equal(t, 5.2, readability.AverageWordLength)
+	assert.Equal(t, "en", readability.Language)
+	require.NotNil(t, readability.FleschReadingEase)
+	// 206.835 - 1.015*(10/2) - 84.6*(17/10)
+	assert.Equal(t, 57.94, *readability.FleschReadingEase)
+}
+
+func TestAssessReadability_LegalAbbreviations(t *testing.T) {
+	readability := AssessReadability(cleanMotionText, "en")
+	require.NotNil(t, readability)
+
+	// "v.", "No." and "§ 1538.5" do not end sentences; the caption lines
+	// without punctuation run into the first sentence
+	assert.Equal(t, 2, readability.SentenceCount)
+	require.NotNil(t, readability.FleschReadingEase)
+	assert.Less(t, *readability.FleschReadingEase, 50.0)
+}
+
+func TestAssessReadability_NonEnglish(t *testing.T) {
+	text := "El tribunal concedió la moción. El acusado apeló la orden del juez."
+
+	// Detected as not English: length metrics only
+	readability := AssessReadability(text, "")
+	require.NotNil(t, readability)
+	assert.Nil(t, readability.FleschReadingEase)
+	assert.Empty(t, readability.Language)
+	assert.Equal(t, 2, readability.SentenceCount)
+
+	// A language reported by the extractor is recorded
+	readability = AssessReadability(text, "es")
+	require.NotNil(t, readability)
+	assert.Nil(t, readability.FleschReadingEase)
+	assert.Equal(t, "es", readability.Language)
+}
+
+func TestAssessReadability_NoWords(t *testing.T) {
+	assert.Nil(t, AssessReadability("", ""))
+	assert.Nil(t, AssessReadability("§ 12 -- 1538.5", "en"))
+}
@@ -0,0 +1,273 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Metadata keys used for cross-references in ExtractionResult.Metadata
+const (
+	MetadataKeyHyperlinks = "hyperlinks" // Link targets embedded in the file (PDF annotations, DOCX relationships)
+	MetadataKeyLinks      = "links"      // Normalized links from annotations and text
+	MetadataKeyReferences = "references" // Normalized statutory references
+)
+
+// References holds the cross-references found in a document
+type References struct {
+	Links    []string `json:"links"`
+	Statutes []string `json:"statutes"`
+}
+
+var (
+	textURLPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'\x60]+`)
+
+	pdfURIPattern = regexp.MustCompile(`/URI\s*\(((?:\\.|[^\\)])*)\)`)
+
+	// 42 U.S.C. § 1983, 18 USC 922(g)(1)
+	uscPattern = regexp.MustCompile(`(?i)\b(\d{1,2})\s+U\.?\s?S\.?\s?C\.?(?:\s?A\.?)?\s*(?:§§?|sec(?:tion|\.)?)?\s*(\d+[a-z]?(?:\([a-z0-9]+\))*)`)
+
+	// 28 C.F.R. § 0.85
+	cfrPattern = regexp.MustCompile(`(?i)\b(\d{1,2})\s+C\.?\s?F\.?\s?R\.?\s*(?:§§?|sec(?:tion|\.)?|part)?\s*(\d+(?:\.\d+)?)`)
+
+	// Fed. R. Civ. P. 12(b)(6), Federal Rule of Evidence 404(b)
+	federalRulePattern = regexp.MustCompile(`(?i)\bFed(?:eral|\.)?\s*R(?:ules?|\.)?\s*(?:of\s+)?(Civ(?:il|\.)?\s*P(?:rocedure|\.)?|Crim(?:inal|\.)?\s*P(?:rocedure|\.)?|App(?:ellate|\.)?\s*P(?:rocedure|\.)?|Evid(?:ence|\.)?)\s*(?:Rule\s*)?(\d+(?:\.\d+)?(?:\([a-z0-9]+\))*)`)
+
+	// Penal Code § 1538.5, Cal. Evid. Code section 352, Welfare and Institutions Code § 707
+	californiaCodePattern = regexp.MustCompile(`(?i)\b(?:Cal(?:ifornia|\.)?\s+)?(Penal|Pen\.|Evid(?:ence|\.)|Civ(?:il|\.)\s*Proc(?:edure|\.)|Code\s+of\s+Civil\s+Procedure|Civ(?:il|\.)|Veh(?:icle|\.)|Welf(?:are|\.)?\s*(?:&|and)\s*Inst(?:itutions|\.)?|Health\s*(?:&|and)\s*Saf(?:ety|\.)|Gov(?:ernment|\.|'t)|Fam(?:ily|\.)|Bus(?:iness|\.)?\s*(?:&|and)\s*Prof(?:essions|\.)?)\s*(?:Code)?,?\s*(?:§§?|sec(?:tion|\.)?)\s*(\d+(?:\.\d+)*[a-z]?(?:\([a-z0-9]+\))*)`)
+)
+
+// californiaCodeNames maps the leading word of a California code name to its citation form
+var californiaCodeNames = []struct {
+	prefix string
+	name   string
+}{
+	{"pen", "Cal. Penal Code"},
+	{"evid", "Cal. Evid. Code"},
+	{"code of civil", "Cal. Civ. Proc. Code"},
+	{"civ", "Cal. Civ. Code"}, // Civil Procedure is resolved before the table is consulted
+	{"veh", "Cal. Veh. Code"},
+	{"welf", "Cal. Welf. & Inst. Code"},
+	{"health", "Cal. Health & Safety Code"},
+	{"gov", "Cal. Gov't Code"},
+	{"fam", "Cal. Fam. Code"},
+	{"bus", "Cal. Bus. & Prof. Code"},
+}
+
+// ExtractReferences collects links and statutory references from an extraction
+// result. Links embedded in the file are merged with URLs found in the text.
+// Both lists are normalized and deduplicated, keeping first-seen order.
+func ExtractReferences(result *ExtractionResult) *References {
+	refs := &References{Links: []string{}, Statutes: []string{}}
+	if result == nil {
+		return refs
+	}
+
+	var rawLinks []string
+	if embedded, ok := result.Metadata[MetadataKeyHyperlinks].([]string); ok {
+		rawLinks = append(rawLinks, embedded...)
+	}
+	rawLinks = append(rawLinks, textURLPattern.FindAllString(result.Text, -1)...)
+
+	refs.Links = dedupe(rawLinks, NormalizeLink)
+	refs.Statutes = ExtractStatuteReferences(result.Text)
+	return refs
+}
+
+// ExtractStatuteReferences finds statutory and rule citations in text and
+// returns them in a canonical form, e.g. "42 U.S.C. § 1983"
+func ExtractStatuteReferences(text string) []string {
+	var found []string
+
+	for _, m := range uscPattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, m[1]+" U.S.C. § "+strings.ToLower(m[2]))
+	}
+	for _, m := range cfrPattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, m[1]+" C.F.R. § "+m[2])
+	}
+	for _, m := range federalRulePattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, federalRuleName(m[1])+" "+strings.ToLower(m[2]))
+	}
+	for _, m := range californiaCodePattern.FindAllStringSubmatch(text, -1) {
+		found = append(found, californiaCodeName(m[1])+" § "+strings.ToLower(m[2]))
+	}
+
+	return dedupe(found, func(s string) string { return s })
+}
+
+// NormalizeLink canonicalizes an http(s) link for indexing. It returns an
+// empty string for anything that is not a usable web link.
+func NormalizeLink(raw string) string {
+	link := strings.TrimSpace(raw)
+	link = strings.TrimRight(link, ".,;:!?)]}'\"")
+	if strings.HasPrefix(strings.ToLower(link), "www.") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if u.Path == "/" {
+		u.Path = ""
+	}
+	return u.String()
+}
+
+// dedupe normalizes values and drops empties and repeats, keeping order
+func dedupe(values []string, normalize func(string) string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		n := normalize(v)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
+
+func federalRuleName(body string) string {
+	lower := strings.ToLower(body)
+	switch {
+	case strings.HasPrefix(lower, "civ"):
+		return "Fed. R. Civ. P."
+	case strings.HasPrefix(lower, "crim"):
+		return "Fed. R. Crim. P."
+	case strings.HasPrefix(lower, "app"):
+		return "Fed. R. App. P."
+	default:
+		return "Fed. R. Evid."
+	}
+}
+
+func californiaCodeName(body string) string {
+	lower := strings.ToLower(body)
+	if strings.HasPrefix(lower, "civ") && strings.Contains(lower, "proc") {
+		return "Cal. Civ. Proc. Code"
+	}
+	for _, code := range californiaCodeNames {
+		if strings.HasPrefix(lower, code.prefix) {
+			return code.name
+		}
+	}
+	return "Cal. " + body + " Code"
+}
+
+// extractPDFLinkAnnotations returns the targets of URI link annotations. Only
+// annotations stored outside compressed object streams are visible here, which
+// covers the common case of link dictionaries written directly in the file.
+func extractPDFLinkAnnotations(content []byte) []string {
+	return extractPDFLinkAnnotationsAt(bytes.NewReader(content), int64(len(content)))
+}
+
+// PDFs are scanned for link annotations in windows of pdfLinkScanWindow
+// bytes, each overlapping the next by pdfLinkScanOverlap so a link of up to
+// that length crossing into the next window is still found whole
+const (
+	pdfLinkScanWindow  = 1 << 20
+	pdfLinkScanOverlap = 16 << 10
+)
+
+// extractPDFLinkAnnotationsAt is extractPDFLinkAnnotations for a PDF read
+// from source a window at a time
+func extractPDFLinkAnnotationsAt(source io.ReaderAt, size int64) []string {
+	bufferSize := int64(pdfLinkScanWindow + pdfLinkScanOverlap)
+	if size < bufferSize {
+		bufferSize = size
+	}
+	buffer := make([]byte, bufferSize)
+
+	var links []string
+	for offset := int64(0); offset < size; offset += pdfLinkScanWindow {
+		n, err := source.ReadAt(buffer, offset)
+		if err != nil && err != io.EOF {
+			break
+		}
+		for _, m := range pdfURIPattern.FindAllSubmatchIndex(buffer[:n], -1) {
+			// Links starting in the overlap belong to the next window
+			if m[0] >= pdfLinkScanWindow {
+				continue
+			}
+			links = append(links, unescapePDFString(string(buffer[m[2]:m[3]])))
+		}
+	}
+	return links
+}
+
+// unescapePDFString resolves the backslash escapes allowed in PDF literal strings
+func unescapePDFString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// extractDOCXHyperlinks returns external hyperlink targets from the document relationships
+func extractDOCXHyperlinks(zipReader *zip.Reader) []string {
+	for _, file := range zipReader.File {
+		if file.Name != "word/_rels/document.xml.rels" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil
+		}
+
+		var rels struct {
+			Relationships []struct {
+				Type       string `xml:"Type,attr"`
+				Target     string `xml:"Target,attr"`
+				TargetMode string `xml:"TargetMode,attr"`
+			} `xml:"Relationship"`
+		}
+		if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&rels); err != nil {
+			return nil
+		}
+
+		var links []string
+		for _, rel := range rels.Relationships {
+			if strings.HasSuffix(rel.Type, "/hyperlink") && strings.EqualFold(rel.TargetMode, "External") {
+				links = append(links, rel.Target)
+			}
+		}
+		return links
+	}
+	return nil
+}
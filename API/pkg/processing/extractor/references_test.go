@@ -0,0 +1,103 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDocumentXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<w:body>
+<w:p><w:r><w:t>MOTION TO SUPPRESS EVIDENCE</w:t></w:r></w:p>
+<w:p><w:r><w:t>Defendant moves under Penal Code section 1538.5 and 42 USC § 1983.</w:t></w:r></w:p>
+<w:p><w:r><w:t>See also 42 U.S.C. § 1983, Fed. R. Civ. P. 12(b)(6) and 28 C.F.R. § 0.85.</w:t></w:r></w:p>
+<w:p><w:hyperlink r:id="rId5"><w:r><w:t>Opinion</w:t></w:r></w:hyperlink></w:p>
+<w:p><w:r><w:t>Full text at https://law.justia.com/cases/california/supreme-court/2012/s191126.html.</w:t></w:r></w:p>
+</w:body>
+</w:document>`
+
+const sampleRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="HTTPS://Scholar.Google.com/scholar_case?case=123#p4" TargetMode="External"/>
+<Relationship Id="rId6" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="#_Toc1"/>
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://law.justia.com/cases/california/supreme-court/2012/s191126.html" TargetMode="External"/>
+</Relationships>`
+
+// buildSampleDOCX returns a minimal DOCX containing the given parts
+func buildSampleDOCX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractReferences_DOCXWithLinksAndStatutes(t *testing.T) {
+	content := buildSampleDOCX(t, map[string]string{
+		"word/document.xml":            sampleDocumentXML,
+		"word/_rels/document.xml.rels": sampleRelsXML,
+	})
+
+	result, err := NewDOCXExtractor().Extract(context.Background(), bytes.NewReader(content), &DocumentMetadata{FileName: "motion.docx"})
+	require.NoError(t, err)
+
+	refs := ExtractReferences(result)
+
+	// The relationship and the inline URL for the same opinion collapse into one link;
+	// internal bookmarks are ignored
+	assert.Equal(t, []string{
+		"https://scholar.google.com/scholar_case?case=123",
+		"https://law.justia.com/cases/california/supreme-court/2012/s191126.html",
+	}, refs.Links)
+
+	assert.ElementsMatch(t, []string{
+		"42 U.S.C. § 1983",
+		"28 C.F.R. § 0.85",
+		"Fed. R. Civ. P. 12(b)(6)",
+		"Cal. Penal Code § 1538.5",
+	}, refs.Statutes)
+}
+
+func TestExtractPDFLinkAnnotations(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n" +
+		"5 0 obj << /Type /Annot /Subtype /Link /A << /S /URI /URI (https://www.courtlistener.com/opinion/1/\\(a\\)/) >> >> endobj\n" +
+		"6 0 obj << /Type /Annot /Subtype /Link /A << /S /URI /URI (mailto:clerk@example.com) >> >> endobj\n")
+
+	links := extractPDFLinkAnnotations(pdf)
+	require.Len(t, links, 2)
+	assert.Equal(t, "https://www.courtlistener.com/opinion/1/(a)/", links[0])
+
+	refs := ExtractReferences(&ExtractionResult{Metadata: map[string]interface{}{MetadataKeyHyperlinks: links}})
+	assert.Equal(t, []string{"https://www.courtlistener.com/opinion/1/(a)/"}, refs.Links)
+}
+
+func TestNormalizeLink(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://Example.COM/Path?q=1#frag", "https://example.com/Path?q=1"},
+		{"www.example.com/page).", "http://www.example.com/page"},
+		{"HTTP://example.com/", "http://example.com"},
+		{"ftp://example.com/file", ""},
+		{"not a link", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeLink(tt.input))
+		})
+	}
+}
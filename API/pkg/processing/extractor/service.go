@@ -13,16 +13,26 @@ import (
 // service implements the Service interface
 type service struct {
 	extractors map[string]Extractor
+	fallbacks  map[string][]fallbackExtractor // Tried in order after a format's own extractor
+	options    ExtractOptions
 }
 
 // NewService creates a new text extraction service
 func NewService() Service {
+	return NewServiceWithOptions(ExtractOptions{})
+}
+
+// NewServiceWithOptions creates a text extraction service configured by opts
+func NewServiceWithOptions(opts ExtractOptions) Service {
 	s := &service{
 		extractors: make(map[string]Extractor),
+		fallbacks:  make(map[string][]fallbackExtractor),
+		options:    opts,
 	}
 
 	// Register default extractors
 	s.registerDefaultExtractors()
+	s.registerFallbackChains()
 
 	return s
 }
@@ -36,7 +46,7 @@ func (s *service) registerDefaultExtractors() {
 	}
 
 	// Register PDF extractor (original ledongthuc/pdf)
-	pdfExtractor := NewPDFExtractor()
+	pdfExtractor := &pdfExtractor{ocr: newPDFOCR(s.options), layout: s.options.IncludeLayout}
 	for _, format := range pdfExtractor.SupportedFormats() {
 		s.extractors[format] = pdfExtractor
 	}
@@ -46,6 +56,12 @@ func (s *service) registerDefaultExtractors() {
 	for _, format := range docxExtractor.SupportedFormats() {
 		s.extractors[format] = docxExtractor
 	}
+
+	// Register email extractor, which extracts attachments through this service
+	emailExtractor := NewEmailExtractor(s)
+	for _, format := range emailExtractor.SupportedFormats() {
+		s.extractors[format] = emailExtractor
+	}
 }
 
 // ExtractText extracts text from a document using the appropriate extractor
@@ -67,8 +83,8 @@ func (s *service) ExtractText(ctx context.Context, reader io.Reader, metadata *D
 		}, err
 	}
 
-	// Extract text
-	result, err := extractor.Extract(ctx, reader, metadata)
+	// Extract text, falling back along the format's chain
+	result, err := s.extract(ctx, extractor, reader, metadata)
 	if err != nil {
 		log.Printf("[EXTRACTOR-SERVICE] ❌ Extraction failed for %s: %v", metadata.Format, err)
 		return &ExtractionResult{
@@ -78,8 +94,22 @@ func (s *service) ExtractText(ctx context.Context, reader io.Reader, metadata *D
 		}, err
 	}
 
-	log.Printf("[EXTRACTOR-SERVICE] 📊 Extraction result for %s: %d chars, %d words, %d pages",
-		metadata.Format, len(result.Text), result.WordCount, result.PageCount)
+	log.Printf("[EXTRACTOR-SERVICE] 📊 Extraction result for %s (%s extractor): %d chars, %d words, %d pages",
+		metadata.Format, result.Extractor, len(result.Text), result.WordCount, result.PageCount)
+
+	result.Quality = AssessQuality(result.Text)
+	if result.Language == "" {
+		result.Language = DetectLanguage(result.Text)
+	}
+	// PDF and text extractors detect the title before cleaning; other formats keep their line breaks
+	if existing, _ := result.Metadata[MetadataKeyTitle].(string); existing == "" {
+		if title := ExtractTitle(result); title != "" {
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]interface{})
+			}
+			result.Metadata[MetadataKeyTitle] = title
+		}
+	}
 
 	// Set duration
 	result.Duration = time.Since(startTime).Milliseconds()
@@ -141,6 +171,10 @@ func (s *service) detectFormat(fileName, mimeType string) string {
 			return "txt"
 		case "application/rtf":
 			return "rtf"
+		case "message/rfc822":
+			return "eml"
+		case "application/vnd.ms-outlook":
+			return "msg"
 		}
 	}
 
@@ -136,6 +136,9 @@ func TestService_DetectFormat(t *testing.T) {
 		{"PDF by MIME type", "document", "application/pdf", "pdf"},
 		{"DOCX by MIME type", "file", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "docx"},
 		{"Text by MIME type", "content", "text/plain", "txt"},
+		{"Email by extension", "message.eml", "", "eml"},
+		{"Email by MIME type", "message", "message/rfc822", "eml"},
+		{"Outlook by MIME type", "message", "application/vnd.ms-outlook", "msg"},
 		{"Case insensitive", "FILE.PDF", "", "pdf"},
 		{"Default fallback", "unknown", "unknown/type", "txt"},
 	}
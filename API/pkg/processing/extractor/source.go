@@ -0,0 +1,47 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// sizedReaderAt returns random access to reader's content, and its size,
+// without reading it when reader supports that: bytes, strings and section
+// readers, and regular files such as the temporary files large uploads are
+// spooled to
+func sizedReaderAt(reader io.Reader) (io.ReaderAt, int64, bool) {
+	at, ok := reader.(io.ReaderAt)
+	if !ok {
+		return nil, 0, false
+	}
+	switch r := reader.(type) {
+	case interface{ Size() int64 }:
+		return at, r.Size(), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, 0, false
+		}
+		return at, info.Size(), true
+	}
+	return nil, 0, false
+}
+
+// readerAtSource returns reader as an io.ReaderAt with its size, reading the
+// content into memory only when reader has no random access
+func readerAtSource(reader io.Reader) (io.ReaderAt, int64, error) {
+	if at, size, ok := sizedReaderAt(reader); ok {
+		return at, size, nil
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(content), int64(len(content)), nil
+}
+
+// readAllAt reads the first size bytes of source into memory
+func readAllAt(source io.ReaderAt, size int64) ([]byte, error) {
+	return io.ReadAll(io.NewSectionReader(source, 0, size))
+}
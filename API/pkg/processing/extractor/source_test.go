@@ -0,0 +1,100 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textPDF builds a one-page PDF showing lines of text, with a link annotation
+func textPDF(lines ...string) []byte {
+	var stream bytes.Buffer
+	stream.WriteString("BT /F1 12 Tf 72 720 Td\n")
+	for _, line := range lines {
+		fmt.Fprintf(&stream, "(%s) Tj 0 -16 Td\n", line)
+	}
+	stream.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> /Annots [6 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Annot /Subtype /Link /Rect [72 700 300 716] /A << /S /URI /URI (https://www.courtlistener.com/opinion/1/) >> >>",
+	}
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// readAtOnly offers random access but fails sequential reads, so an
+// extractor that reads it whole fails
+type readAtOnly struct {
+	*bytes.Reader
+}
+
+func (readAtOnly) Read([]byte) (int, error) {
+	return 0, errors.New("sequential read")
+}
+
+func TestPDFExtractor_ReadsInPlace(t *testing.T) {
+	pdf := textPDF("MOTION TO SUPPRESS EVIDENCE", "The defendant moves to suppress.")
+	metadata := &DocumentMetadata{FileName: "motion.pdf"}
+
+	expected, err := NewPDFExtractor().Extract(context.Background(), bytes.NewReader(pdf), metadata)
+	require.NoError(t, err)
+	assert.Contains(t, expected.Text, "MOTION TO SUPPRESS EVIDENCE")
+	assert.Equal(t, []string{"https://www.courtlistener.com/opinion/1/"}, expected.Metadata[MetadataKeyHyperlinks])
+
+	result, err := NewPDFExtractor().Extract(context.Background(), readAtOnly{bytes.NewReader(pdf)}, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	// A spooled upload
+	path := filepath.Join(t.TempDir(), "upload")
+	require.NoError(t, os.WriteFile(path, pdf, 0o600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	result, err = NewPDFExtractor().Extract(context.Background(), file, metadata)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestExtractPDFLinkAnnotationsAt_ScansInWindows(t *testing.T) {
+	link := func(n int) string { return fmt.Sprintf("/URI (https://example.com/%d)", n) }
+
+	// Links before, across and after each window boundary, and in the overlap
+	var content strings.Builder
+	content.WriteString(link(1))
+	content.WriteString(strings.Repeat(" ", pdfLinkScanWindow-content.Len()-10))
+	content.WriteString(link(2))
+	content.WriteString(strings.Repeat(" ", 100))
+	content.WriteString(link(3))
+	content.WriteString(strings.Repeat(" ", pdfLinkScanWindow))
+	content.WriteString(link(4))
+
+	expected := []string{"https://example.com/1", "https://example.com/2", "https://example.com/3", "https://example.com/4"}
+	data := []byte(content.String())
+	assert.Equal(t, expected, extractPDFLinkAnnotationsAt(bytes.NewReader(data), int64(len(data))))
+}
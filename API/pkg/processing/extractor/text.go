@@ -30,6 +30,12 @@ func (e *textExtractor) Extract(ctx context.Context, reader io.Reader, metadata
 		text = strings.ToValidUTF8(text, "�")
 	}
 
+	// Form feeds mark page breaks in text exported from paginated documents
+	pages := splitFormFeedPages(text)
+
+	// The cleaner collapses the line breaks title detection relies on
+	title := DetectTitle(text)
+
 	// Clean up the text using enhanced cleaner
 	cleaner := NewTextCleaner(DefaultCleaningConfig())
 	text = cleaner.CleanText(text)
@@ -38,14 +44,21 @@ func (e *textExtractor) Extract(ctx context.Context, reader io.Reader, metadata
 	wordCount := countWords(text)
 	charCount := len(text)
 
+	pageCount := 1 // Text files without form feeds are considered single page
+	if len(pages) > 0 {
+		pageCount = pages[len(pages)-1].Number
+	}
+
 	return &ExtractionResult{
 		Text:      text,
 		WordCount: wordCount,
 		CharCount: charCount,
-		PageCount: 1, // Text files are considered single page
+		PageCount: pageCount,
+		Pages:     pages,
 		Metadata: map[string]interface{}{
-			"encoding": "utf-8",
-			"format":   "plain_text",
+			"encoding":       "utf-8",
+			"format":         "plain_text",
+			MetadataKeyTitle: title,
 		},
 	}, nil
 }
@@ -66,6 +79,22 @@ func (e *textExtractor) CanExtract(format string) bool {
 	return false
 }
 
+// splitFormFeedPages splits text on form feed characters into numbered pages,
+// returning nil when the text has no page breaks
+func splitFormFeedPages(text string) []PageText {
+	if !strings.Contains(text, "\f") {
+		return nil
+	}
+
+	var pages []PageText
+	for i, pageText := range strings.Split(text, "\f") {
+		if page := NewPageText(i+1, pageText); page.Text != "" {
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
+
 // cleanText performs basic text cleaning
 func cleanText(text string) string {
 	// Normalize line endings
@@ -79,6 +79,20 @@ func TestTextExtractor_Extract(t *testing.T) {
 	}
 }
 
+func TestTextExtractor_PreservesPageBreaks(t *testing.T) {
+	content := "NOTICE OF MOTION\nPage one text.\f\fThe warrantless   search\nof the vehicle.\f"
+
+	result, err := NewTextExtractor().Extract(context.Background(), strings.NewReader(content), &DocumentMetadata{FileName: "motion.txt"})
+	require.NoError(t, err)
+
+	// Blank pages are dropped but keep their place in the numbering
+	assert.Equal(t, []PageText{
+		{Number: 1, Text: "NOTICE OF MOTION Page one text."},
+		{Number: 3, Text: "The warrantless search of the vehicle."},
+	}, result.Pages)
+	assert.Equal(t, 3, result.PageCount)
+}
+
 func TestTextExtractor_SupportedFormats(t *testing.T) {
 	extractor := NewTextExtractor()
 	formats := extractor.SupportedFormats()
@@ -0,0 +1,170 @@
+package extractor
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Metadata keys used for the document title in ExtractionResult.Metadata
+const (
+	MetadataKeyOutlineTitle = "outline_title" // First PDF bookmark, when the file has an outline
+	MetadataKeyTitle        = "title"         // Title chosen by ExtractTitle
+)
+
+// Limits for title detection
+const (
+	maxTitleLength     = 200 // Longer headings are truncated
+	maxTitleScanLines  = 60  // Titles sit in the caption on the first page
+	maxTitleBlockLines = 4   // Headings rarely wrap onto more lines
+)
+
+var (
+	// titleKeywordPattern matches the words that name a filing
+	titleKeywordPattern = regexp.MustCompile(`\b(MOTION|ORDER|NOTICE|BRIEF|MEMORANDUM|OPPOSITION|REPLY|DECLARATION|PETITION|COMPLAINT|STIPULATION|RESPONSE|APPLICATION|REQUEST|JUDGMENT|OBJECTIONS?|RULING|VERDICT|INFORMATION|INDICTMENT|SUBPOENA|WRIT|TRANSCRIPT)\b`)
+
+	// captionLinePattern matches caption lines that sit between heading lines
+	// but are not part of the title: field labels and case numbers
+	captionLinePattern = regexp.MustCompile(`(?i)^(case|dept|department|date|time|judge|hearing|courtroom|ctrm|trial date|action filed)\b[^:]*:|\bcase\s+no\b`)
+
+	// captionPartyPattern matches heading-like caption lines that name the court or parties
+	captionPartyPattern = regexp.MustCompile(`\b(COURT|COUNTY OF|DISTRICT OF|STATE OF|PEOPLE OF|PLAINTIFFS?|DEFENDANTS?|RESPONDENTS?|PETITIONERS?|ATTORNEYS? FOR|ESQ|BAR NO)\b|^(V|VS)\.?$`)
+)
+
+// ExtractTitle picks the document's own title: a PDF bookmark that names the
+// filing, otherwise the first heading block in the text. Returns an empty
+// string when nothing looks like a title.
+func ExtractTitle(result *ExtractionResult) string {
+	if result == nil {
+		return ""
+	}
+
+	if outline, ok := result.Metadata[MetadataKeyOutlineTitle].(string); ok {
+		outline = normalizeTitle(outline)
+		if titleKeywordPattern.MatchString(strings.ToUpper(outline)) {
+			return outline
+		}
+	}
+
+	return DetectTitle(result.Text)
+}
+
+// DetectTitle finds the first prominent heading in document text. Runs of
+// consecutive all-caps lines form a block; the first block naming a filing
+// type (MOTION, ORDER, ...) wins, falling back to the first block that is not
+// a court or party caption.
+func DetectTitle(text string) string {
+	var blocks [][]string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, current)
+			current = nil
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > maxTitleScanLines {
+		lines = lines[:maxTitleScanLines]
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		// Party names in the caption end with a comma ("JOHN SMITH,")
+		if line == "" || strings.HasSuffix(line, ",") || captionLinePattern.MatchString(line) || !isHeadingLine(line) {
+			flush()
+			continue
+		}
+		current = append(current, line)
+		if len(current) == maxTitleBlockLines {
+			flush()
+		}
+	}
+	flush()
+
+	for _, block := range blocks {
+		title := normalizeTitle(strings.Join(block, " "))
+		if titleKeywordPattern.MatchString(title) {
+			return title
+		}
+	}
+	for _, block := range blocks {
+		title := normalizeTitle(strings.Join(block, " "))
+		if !captionPartyPattern.MatchString(title) {
+			return title
+		}
+	}
+	return ""
+}
+
+// isHeadingLine reports whether a line is set in capitals, as filing titles are
+func isHeadingLine(line string) bool {
+	var letters, upper int
+	for _, r := range line {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 4 && float64(upper)/float64(letters) >= 0.8 && len(line) <= maxTitleLength
+}
+
+// normalizeTitle collapses whitespace, drops trailing separators and caps the length
+func normalizeTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	title = strings.TrimRight(title, " :;,-")
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength]))
+	}
+	return title
+}
+
+// pdfTitle picks a PDF's title from its outline or the raw page text. The PDF
+// extractors call it before cleaning, which collapses the line breaks that
+// DetectTitle relies on.
+func pdfTitle(content []byte, rawText string) string {
+	return ExtractTitle(&ExtractionResult{
+		Text:     rawText,
+		Metadata: map[string]interface{}{MetadataKeyOutlineTitle: extractPDFOutlineTitle(content)},
+	})
+}
+
+// extractPDFOutlineTitle returns the first bookmark title in a PDF's outline
+func extractPDFOutlineTitle(content []byte) string {
+	return pdfOutlineTitle(bytes.NewReader(content), int64(len(content)))
+}
+
+// pdfOutlineTitle returns the first bookmark title in the outline of the PDF
+// read from source
+func pdfOutlineTitle(source io.ReaderAt, size int64) (title string) {
+	// The outline parser panics on some malformed files; a title is optional
+	defer func() {
+		if recover() != nil {
+			title = ""
+		}
+	}()
+
+	reader, err := pdf.NewReader(source, size)
+	if err != nil {
+		return ""
+	}
+	return firstOutlineTitle(reader.Outline())
+}
+
+// firstOutlineTitle walks the outline depth-first for the first non-empty title
+func firstOutlineTitle(outline pdf.Outline) string {
+	if title := strings.TrimSpace(outline.Title); title != "" {
+		return title
+	}
+	for _, child := range outline.Child {
+		if title := firstOutlineTitle(child); title != "" {
+			return title
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package extractor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const captionedMotionText = `JANE DOE, ESQ. (SBN 123456)
+Law Office of Jane Doe
+123 Main Street, Los Angeles, CA 90012
+Attorney for Defendant
+
+SUPERIOR COURT OF THE STATE OF CALIFORNIA
+COUNTY OF LOS ANGELES
+
+THE PEOPLE OF THE STATE OF CALIFORNIA,
+Plaintiff,
+v.
+JOHN SMITH,
+Defendant.
+Case No. 22CR001234
+DEFENDANT'S NOTICE OF MOTION AND
+MOTION TO DISMISS; MEMORANDUM OF
+POINTS AND AUTHORITIES
+Date: March 3, 2023
+Dept: 42
+
+TO THE COURT AND ALL PARTIES: PLEASE TAKE NOTICE that the defendant moves to dismiss.`
+
+func TestDetectTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "caption with wrapped title",
+			text:     captionedMotionText,
+			expected: "DEFENDANT'S NOTICE OF MOTION AND MOTION TO DISMISS; MEMORANDUM OF POINTS AND AUTHORITIES",
+		},
+		{
+			name:     "heading without a filing keyword",
+			text:     "SUPERIOR COURT OF CALIFORNIA\n\nSTATEMENT OF FACTS\nOn March 3 the officers stopped the vehicle.",
+			expected: "STATEMENT OF FACTS",
+		},
+		{
+			name:     "no heading",
+			text:     "The defendant moves to dismiss the complaint for failure to state a claim.",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectTitle(tt.text))
+		})
+	}
+}
+
+func TestExtractTitle_PrefersOutline(t *testing.T) {
+	result := &ExtractionResult{
+		Text:     captionedMotionText,
+		Metadata: map[string]interface{}{MetadataKeyOutlineTitle: "  Order Granting Motion to Suppress  "},
+	}
+	assert.Equal(t, "Order Granting Motion to Suppress", ExtractTitle(result))
+
+	// Bookmarks that don't name the filing fall back to the text heading
+	result.Metadata[MetadataKeyOutlineTitle] = "Table of Contents"
+	assert.Contains(t, ExtractTitle(result), "MOTION TO DISMISS")
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"motion-index-fiber/internal/models"
+	pkgModels "motion-index-fiber/pkg/models"
 	"motion-index-fiber/pkg/processing/classifier"
 	"motion-index-fiber/pkg/processing/queue"
 )
@@ -34,6 +35,10 @@ type IndexingProcessorConfig struct {
 	RequestTimeout time.Duration `json:"request_timeout"`
 	MaxRetries     int           `json:"max_retries"`
 	RetryDelay     time.Duration `json:"retry_delay"`
+
+	// AuthToken returns the bearer token sent with each indexing request;
+	// nil sends none
+	AuthToken func() (string, error) `json:"-"`
 }
 
 // IndexingProcessor handles processing of indexing queue items
@@ -167,6 +172,10 @@ func (p *IndexingProcessor) indexDocument(ctx context.Context, item *IndexingQue
 		Size:                 item.Size,
 		FileURL:              item.FileURL,
 	}
+	if item.SourceJobID != "" {
+		apiRequest.SourceSystem = pkgModels.SourceSystemBatchProcessor
+		apiRequest.IngestionBatchID = item.SourceJobID
+	}
 
 	// Serialize request to JSON
 	requestBody, err := json.Marshal(apiRequest)
@@ -182,6 +191,13 @@ func (p *IndexingProcessor) indexDocument(ctx context.Context, item *IndexingQue
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if p.config.AuthToken != nil {
+		token, err := p.config.AuthToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create auth token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	// Execute request
 	resp, err := p.httpClient.Do(req)
@@ -182,7 +182,7 @@ func (m *MetadataMigrator) migrateMetadata(ctx context.Context, legacyDoc *model
 					CaseName:     result.CaseInfo.CaseName,
 					CaseType:     result.CaseInfo.CaseType,
 					Chapter:      result.CaseInfo.Chapter,
-					Docket:       result.CaseInfo.Docket,
+					Docket:       models.NormalizeDocketNumber(result.CaseInfo.Docket),
 					NatureOfSuit: result.CaseInfo.NatureOfSuit,
 				}
 			}
@@ -211,11 +211,7 @@ func (m *MetadataMigrator) migrateMetadata(ctx context.Context, legacyDoc *model
 			if len(result.Parties) > 0 {
 				enhanced.Parties = make([]models.Party, len(result.Parties))
 				for i, party := range result.Parties {
-					enhanced.Parties[i] = models.Party{
-						Name:      party.Name,
-						Role:      party.Role,
-						PartyType: party.PartyType,
-					}
+					enhanced.Parties[i] = models.NewParty(party.Name, party.Role, party.PartyType)
 				}
 			}
 			
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// DefaultMinConfidence is the classifier confidence a document must exceed to
+// count as AI-classified when no threshold is configured for its type
+const DefaultMinConfidence = 0.5
+
+// ConfidenceThresholds maps document types to the classifier confidence a
+// classification must exceed to be accepted. Common types such as notices
+// can be trusted at lower confidence than rare, high-stakes ones. Keys are
+// document types, a family such as "motion" that covers every "motion_*"
+// type, or "default" for unlisted types.
+type ConfidenceThresholds map[string]float64
+
+// ParseConfidenceThresholds parses a spec such as
+//
+//	notice:0.3;order:0.8;default:0.6
+//
+// Entries are separated by ";" and name a document type, a family or
+// "default", followed by a threshold between 0 and 1. Without a default entry
+// unlisted types use DefaultMinConfidence. An empty spec returns nil, which
+// applies DefaultMinConfidence to every type.
+func ParseConfidenceThresholds(spec string) (ConfidenceThresholds, error) {
+	thresholds := ConfidenceThresholds{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		docType, value, found := strings.Cut(entry, ":")
+		docType = strings.ToLower(strings.TrimSpace(docType))
+		if !found || docType == "" {
+			return nil, fmt.Errorf("confidence threshold entry %q must be type:threshold", entry)
+		}
+		if _, exists := thresholds[docType]; exists {
+			return nil, fmt.Errorf("confidence threshold for %q is defined more than once", docType)
+		}
+
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("confidence threshold for %q must be a number between 0 and 1, got %q", docType, value)
+		}
+		thresholds[docType] = threshold
+	}
+
+	if len(thresholds) == 0 {
+		return nil, nil
+	}
+	return thresholds, nil
+}
+
+// For returns the threshold for a document type: its own, else its
+// family's, else the default
+func (t ConfidenceThresholds) For(docType string) float64 {
+	docType = strings.ToLower(docType)
+	if threshold, ok := t[docType]; ok {
+		return threshold
+	}
+	if family, _, found := strings.Cut(docType, "_"); found {
+		if threshold, ok := t[family]; ok {
+			return threshold
+		}
+	}
+	if threshold, ok := t[DefaultProfileName]; ok {
+		return threshold
+	}
+	return DefaultMinConfidence
+}
+
+// Accepts reports whether a classification of the given type is confident
+// enough to be trusted without review
+func (t ConfidenceThresholds) Accepts(docType string, confidence float64) bool {
+	return confidence > t.For(docType)
+}
+
+// Flag marks classified metadata as AI-classified when the classifier's
+// confidence clears the threshold for its type, and for review when it does
+// not. A low confidence outranks missing fields, which may only be missing
+// because the type is wrong, so call it after RequiredFields.Flag.
+func (t ConfidenceThresholds) Flag(metadata *models.DocumentMetadata, confidence float64) {
+	if metadata == nil {
+		return
+	}
+
+	metadata.AIClassified = t.Accepts(string(metadata.DocumentType), confidence)
+	if !metadata.AIClassified {
+		metadata.ReviewStatus = models.ReviewStatusLowConfidence
+	}
+}
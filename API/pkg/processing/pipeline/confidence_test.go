@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/models"
+)
+
+func TestConfidenceThresholds_AppliesTypeThresholds(t *testing.T) {
+	thresholds, err := ParseConfidenceThresholds("notice:0.3; Order:0.8; motion:0.7")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.3, thresholds.For("notice"))
+	assert.Equal(t, 0.8, thresholds.For("order"))
+	assert.Equal(t, 0.7, thresholds.For("motion_to_suppress"), "a motion type falls back to the motion family")
+	assert.Equal(t, DefaultMinConfidence, thresholds.For("brief"), "unlisted types use the global default")
+
+	// The same confidence is accepted for a notice but flagged for an order
+	notice := &models.DocumentMetadata{DocumentType: models.DocTypeNotice}
+	thresholds.Flag(notice, 0.4)
+	assert.True(t, notice.AIClassified)
+	assert.Empty(t, notice.ReviewStatus)
+
+	order := &models.DocumentMetadata{DocumentType: models.DocTypeOrder}
+	thresholds.Flag(order, 0.4)
+	assert.False(t, order.AIClassified)
+	assert.Equal(t, models.ReviewStatusLowConfidence, order.ReviewStatus)
+
+	// A confident order is accepted
+	order = &models.DocumentMetadata{DocumentType: models.DocTypeOrder}
+	thresholds.Flag(order, 0.85)
+	assert.True(t, order.AIClassified)
+	assert.Empty(t, order.ReviewStatus)
+
+	// The threshold itself is not enough
+	motion := &models.DocumentMetadata{DocumentType: models.DocTypeMotionToSuppress}
+	thresholds.Flag(motion, 0.7)
+	assert.False(t, motion.AIClassified)
+
+	// Low confidence outranks incomplete metadata
+	rules, err := ParseRequiredFields("order:judge")
+	require.NoError(t, err)
+	order = &models.DocumentMetadata{DocumentType: models.DocTypeOrder}
+	rules.Flag(order)
+	thresholds.Flag(order, 0.5)
+	assert.Equal(t, models.ReviewStatusLowConfidence, order.ReviewStatus)
+	assert.Equal(t, []string{"judge"}, order.MissingFields)
+
+	// A default entry replaces the global default, and no thresholds at all
+	// apply it to every type
+	withDefault, err := ParseConfidenceThresholds("default:0.6")
+	require.NoError(t, err)
+	assert.Equal(t, 0.6, withDefault.For("brief"))
+
+	var none ConfidenceThresholds
+	assert.True(t, none.Accepts("order", 0.51))
+	assert.False(t, none.Accepts("order", 0.5))
+}
+
+func TestParseConfidenceThresholds_Validation(t *testing.T) {
+	thresholds, err := ParseConfidenceThresholds("")
+	require.NoError(t, err)
+	assert.Nil(t, thresholds)
+
+	for _, spec := range []string{
+		"order:high",
+		"order:1.5",
+		"order:-0.1",
+		"order",
+		":0.5",
+		"order:0.8;order:0.9",
+	} {
+		_, err := ParseConfidenceThresholds(spec)
+		assert.Error(t, err, spec)
+	}
+}
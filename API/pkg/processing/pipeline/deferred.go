@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/queue"
+	"motion-index-fiber/pkg/search"
+)
+
+// Defaults for the deferred indexing queue
+const (
+	defaultDeferredRetryInterval = time.Minute
+	defaultDeferredQueueSize     = 1000
+	deferredIndexMaxRetries      = 3
+)
+
+// DeferredIndexer holds documents that could not be indexed because the
+// search index was write-blocked, such as read-only after a disk watermark,
+// and indexes them once the block clears. The documents are already stored,
+// so only the index write is repeated.
+type DeferredIndexer struct {
+	service  search.Service
+	queue    queue.Queue
+	interval time.Duration
+}
+
+// NewDeferredIndexer creates a deferred indexer that retries every interval
+// and holds at most maxSize documents. Zero values use the defaults.
+func NewDeferredIndexer(service search.Service, interval time.Duration, maxSize int) *DeferredIndexer {
+	if interval <= 0 {
+		interval = defaultDeferredRetryInterval
+	}
+	if maxSize <= 0 {
+		maxSize = defaultDeferredQueueSize
+	}
+
+	return &DeferredIndexer{
+		service: service,
+		queue: queue.NewPriorityQueue(&queue.QueueConfig{
+			Name:          "deferred_indexing",
+			Type:          queue.QueueTypeIndexing,
+			MaxSize:       maxSize,
+			RetryAttempts: deferredIndexMaxRetries,
+			RetryDelay:    interval,
+		}),
+		interval: interval,
+	}
+}
+
+// Defer queues a document to be indexed once the index accepts writes again
+func (d *DeferredIndexer) Defer(doc *models.Document) error {
+	return d.queue.Enqueue(context.Background(), &queue.QueueItem{
+		ID:       "deferred_index_" + doc.ID,
+		Type:     queue.QueueTypeIndexing,
+		Priority: queue.PriorityNormal,
+		Data:     doc,
+		Metadata: map[string]interface{}{
+			"document_id": doc.ID,
+		},
+	})
+}
+
+// Pending returns the number of documents waiting to be indexed
+func (d *DeferredIndexer) Pending() int {
+	return d.queue.Size()
+}
+
+// Stats returns statistics for the deferred indexing queue
+func (d *DeferredIndexer) Stats() *queue.QueueStats {
+	return d.queue.GetStats()
+}
+
+// Start retries the queued documents every interval until ctx is cancelled
+func (d *DeferredIndexer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if indexed, err := d.Flush(ctx); err != nil {
+					log.Printf("[INDEXING] ⏸️ Deferred indexing still waiting (%d indexed, %d pending): %v", indexed, d.Pending(), err)
+				} else if indexed > 0 {
+					log.Printf("[INDEXING] ✅ Indexed %d deferred documents", indexed)
+				}
+			}
+		}
+	}()
+}
+
+// Flush tries to index each queued document once. It stops at the first
+// document the index still refuses with a block, returning that error and
+// leaving the rest queued. Documents failing for other reasons are retried
+// on later flushes up to a limit, then dropped.
+func (d *DeferredIndexer) Flush(ctx context.Context) (int, error) {
+	// Failed documents go back on the queue after the pass, so one failing
+	// document is not retried repeatedly ahead of the others
+	var retry []*queue.QueueItem
+	defer func() {
+		for _, item := range retry {
+			d.requeue(item)
+		}
+	}()
+
+	indexed := 0
+	for pending := d.queue.Size(); pending > 0; pending-- {
+		item, err := d.queue.Dequeue(ctx)
+		if err != nil {
+			return indexed, err
+		}
+		doc := item.Data.(*models.Document)
+
+		if _, err := d.service.IndexDocument(ctx, doc); err != nil {
+			if search.IsIndexBlocked(err) {
+				retry = append(retry, item)
+				return indexed, err
+			}
+
+			item.RetryCount++
+			if item.RetryCount >= item.MaxRetries {
+				log.Printf("[INDEXING] ❌ Dropped deferred document %s after %d attempts: %v", doc.ID, item.RetryCount, err)
+				continue
+			}
+			retry = append(retry, item)
+			continue
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// requeue puts a document back on the queue, logging it if the queue is full
+func (d *DeferredIndexer) requeue(item *queue.QueueItem) {
+	if err := d.queue.Enqueue(context.Background(), item); err != nil {
+		log.Printf("[INDEXING] ❌ Dropped deferred document %s: %v", item.Metadata["document_id"], err)
+	}
+}
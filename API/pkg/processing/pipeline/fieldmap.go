@@ -0,0 +1,347 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+)
+
+// FieldRule copies one classifier output field into one index field
+type FieldRule struct {
+	// Source is a ClassificationResult JSON field such as "summary", or
+	// "metadata.<key>" for a value in ClassificationResult.Metadata
+	Source string `json:"source"`
+	// Target is an index field such as "metadata.subject"
+	Target string `json:"target"`
+}
+
+// FieldMapping is the ordered list of rules used to build an indexed document
+// from a classification result. When several rules write the same target,
+// the last rule with a value wins.
+type FieldMapping []FieldRule
+
+// fieldKind is the type of value a field holds; a rule's source and target
+// must have the same kind
+type fieldKind string
+
+const (
+	kindString      fieldKind = "string"
+	kindFloat       fieldKind = "float"
+	kindDate        fieldKind = "date" // YYYY-MM-DD
+	kindStrings     fieldKind = "strings"
+	kindCase        fieldKind = "case"
+	kindCourt       fieldKind = "court"
+	kindParties     fieldKind = "parties"
+	kindAttorneys   fieldKind = "attorneys"
+	kindJudge       fieldKind = "judge"
+	kindCharges     fieldKind = "charges"
+	kindAuthorities fieldKind = "authorities"
+	kindAny         fieldKind = "any" // Classifier metadata; converted to scalar targets when applied
+)
+
+// metadataSourcePrefix selects a value from ClassificationResult.Metadata
+const metadataSourcePrefix = "metadata."
+
+type fieldSource struct {
+	kind fieldKind
+	get  func(result *classifier.ClassificationResult) interface{}
+}
+
+type fieldTarget struct {
+	kind fieldKind
+	set  func(doc *models.Document, value interface{})
+}
+
+// fieldSources are the classifier output fields a mapping can read
+var fieldSources = map[string]fieldSource{
+	"document_type":  {kindString, func(r *classifier.ClassificationResult) interface{} { return r.DocumentType }},
+	"legal_category": {kindString, func(r *classifier.ClassificationResult) interface{} { return r.LegalCategory }},
+	"sub_category":   {kindString, func(r *classifier.ClassificationResult) interface{} { return r.SubCategory }},
+	"subject":        {kindString, func(r *classifier.ClassificationResult) interface{} { return r.Subject }},
+	"summary":        {kindString, func(r *classifier.ClassificationResult) interface{} { return r.Summary }},
+	"status":         {kindString, func(r *classifier.ClassificationResult) interface{} { return r.Status }},
+	"confidence":     {kindFloat, func(r *classifier.ClassificationResult) interface{} { return r.Confidence }},
+	"filing_date":    {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.FilingDate) }},
+	"event_date":     {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.EventDate) }},
+	"hearing_date":   {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.HearingDate) }},
+	"decision_date":  {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.DecisionDate) }},
+	"served_date":    {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.ServedDate) }},
+	"signature_date": {kindDate, func(r *classifier.ClassificationResult) interface{} { return derefString(r.SignatureDate) }},
+	"keywords":       {kindStrings, func(r *classifier.ClassificationResult) interface{} { return r.Keywords }},
+	"legal_tags":     {kindStrings, func(r *classifier.ClassificationResult) interface{} { return r.LegalTags }},
+	"case_info":      {kindCase, func(r *classifier.ClassificationResult) interface{} { return r.CaseInfo }},
+	"court_info":     {kindCourt, func(r *classifier.ClassificationResult) interface{} { return r.CourtInfo }},
+	"parties":        {kindParties, func(r *classifier.ClassificationResult) interface{} { return r.Parties }},
+	"attorneys":      {kindAttorneys, func(r *classifier.ClassificationResult) interface{} { return r.Attorneys }},
+	"judge":          {kindJudge, func(r *classifier.ClassificationResult) interface{} { return r.Judge }},
+	"charges":        {kindCharges, func(r *classifier.ClassificationResult) interface{} { return r.Charges }},
+	"authorities":    {kindAuthorities, func(r *classifier.ClassificationResult) interface{} { return r.Authorities }},
+}
+
+// fieldTargets are the index fields a mapping can write
+var fieldTargets = map[string]fieldTarget{
+	"doc_type": {kindString, func(d *models.Document, v interface{}) {
+		d.DocType = v.(string)
+	}},
+	"category": {kindString, func(d *models.Document, v interface{}) {
+		d.Category = v.(string)
+	}},
+	"metadata.document_type": {kindString, func(d *models.Document, v interface{}) {
+		d.Metadata.DocumentType = models.ParseDocumentType(v.(string))
+	}},
+	"metadata.subject": {kindString, func(d *models.Document, v interface{}) {
+		d.Metadata.Subject = v.(string)
+	}},
+	"metadata.summary": {kindString, func(d *models.Document, v interface{}) {
+		d.Metadata.Summary = v.(string)
+	}},
+	"metadata.status": {kindString, func(d *models.Document, v interface{}) {
+		d.Metadata.Status = v.(string)
+	}},
+	"metadata.confidence": {kindFloat, func(d *models.Document, v interface{}) {
+		d.Metadata.Confidence = v.(float64)
+	}},
+	"metadata.filing_date":    dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.FilingDate = t }),
+	"metadata.event_date":     dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.EventDate = t }),
+	"metadata.hearing_date":   dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.HearingDate = t }),
+	"metadata.decision_date":  dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.DecisionDate = t }),
+	"metadata.served_date":    dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.ServedDate = t }),
+	"metadata.signature_date": dateTarget(func(m *models.DocumentMetadata, t *time.Time) { m.SignatureDate = t }),
+	"metadata.legal_tags": {kindStrings, func(d *models.Document, v interface{}) {
+		d.Metadata.LegalTags = v.([]string)
+	}},
+	"metadata.case": {kindCase, func(d *models.Document, v interface{}) {
+		d.Metadata.Case = convertCaseInfo(v.(*classifier.CaseInfo))
+	}},
+	"metadata.court": {kindCourt, func(d *models.Document, v interface{}) {
+		d.Metadata.Court = convertCourtInfo(v.(*classifier.CourtInfo))
+	}},
+	"metadata.parties": {kindParties, func(d *models.Document, v interface{}) {
+		d.Metadata.Parties = convertParties(v.([]classifier.Party))
+	}},
+	"metadata.attorneys": {kindAttorneys, func(d *models.Document, v interface{}) {
+		d.Metadata.Attorneys = convertAttorneys(v.([]classifier.Attorney))
+	}},
+	"metadata.judge": {kindJudge, func(d *models.Document, v interface{}) {
+		d.Metadata.Judge = convertJudge(v.(*classifier.Judge))
+	}},
+	"metadata.charges": {kindCharges, func(d *models.Document, v interface{}) {
+		d.Metadata.Charges = convertCharges(v.([]classifier.Charge))
+	}},
+	"metadata.authorities": {kindAuthorities, func(d *models.Document, v interface{}) {
+		d.Metadata.Authorities = convertAuthorities(v.([]classifier.Authority))
+	}},
+}
+
+// dateTarget builds a target for a YYYY-MM-DD date field; unparseable dates are skipped
+func dateTarget(set func(m *models.DocumentMetadata, t *time.Time)) fieldTarget {
+	return fieldTarget{kindDate, func(d *models.Document, v interface{}) {
+		if parsed, err := time.Parse("2006-01-02", v.(string)); err == nil {
+			set(d.Metadata, &parsed)
+		}
+	}}
+}
+
+// DefaultFieldMapping returns the built-in mapping from classifier output to index fields
+func DefaultFieldMapping() FieldMapping {
+	return FieldMapping{
+		{Source: "document_type", Target: "doc_type"},
+		{Source: "document_type", Target: "metadata.document_type"},
+		{Source: "legal_category", Target: "category"},
+		{Source: "subject", Target: "metadata.subject"},
+		{Source: "summary", Target: "metadata.summary"},
+		{Source: "status", Target: "metadata.status"},
+		{Source: "confidence", Target: "metadata.confidence"},
+		{Source: "filing_date", Target: "metadata.filing_date"},
+		{Source: "event_date", Target: "metadata.event_date"},
+		{Source: "hearing_date", Target: "metadata.hearing_date"},
+		{Source: "decision_date", Target: "metadata.decision_date"},
+		{Source: "served_date", Target: "metadata.served_date"},
+		{Source: "signature_date", Target: "metadata.signature_date"},
+		{Source: "legal_tags", Target: "metadata.legal_tags"},
+		{Source: "case_info", Target: "metadata.case"},
+		{Source: "court_info", Target: "metadata.court"},
+		{Source: "parties", Target: "metadata.parties"},
+		{Source: "attorneys", Target: "metadata.attorneys"},
+		{Source: "judge", Target: "metadata.judge"},
+		{Source: "charges", Target: "metadata.charges"},
+		{Source: "authorities", Target: "metadata.authorities"},
+	}
+}
+
+// ParseFieldMapping applies overrides to the default mapping. The spec is a
+// comma-separated list of source=target entries; each source named in the
+// spec drops its default rules, so "summary=metadata.subject" redirects the
+// summary and "keywords=" stops mapping keywords at all. The result is validated.
+func ParseFieldMapping(spec string) (FieldMapping, error) {
+	var overrides FieldMapping
+	overridden := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		source, target, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid field mapping entry %q: expected source=target", entry)
+		}
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		overridden[source] = true
+		if target != "" {
+			overrides = append(overrides, FieldRule{Source: source, Target: target})
+		}
+	}
+
+	var mapping FieldMapping
+	for _, rule := range DefaultFieldMapping() {
+		if !overridden[rule.Source] {
+			mapping = append(mapping, rule)
+		}
+	}
+	mapping = append(mapping, overrides...)
+
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// Validate checks that every rule names a known source and target of the same kind
+func (m FieldMapping) Validate() error {
+	for _, rule := range m {
+		sourceKind, err := sourceKindOf(rule.Source)
+		if err != nil {
+			return err
+		}
+		target, ok := fieldTargets[rule.Target]
+		if !ok {
+			return fmt.Errorf("unknown field mapping target %q", rule.Target)
+		}
+		if sourceKind == kindAny {
+			if !isScalarKind(target.kind) {
+				return fmt.Errorf("field mapping %s=%s: classifier metadata can only map to text, number, date or list fields", rule.Source, rule.Target)
+			}
+			continue
+		}
+		if sourceKind != target.kind {
+			return fmt.Errorf("field mapping %s=%s: cannot map a %s field to a %s field", rule.Source, rule.Target, sourceKind, target.kind)
+		}
+	}
+	return nil
+}
+
+// Apply copies classifier output into doc following the mapping. Empty
+// source values leave the target untouched.
+func (m FieldMapping) Apply(result *classifier.ClassificationResult, doc *models.Document) error {
+	if doc.Metadata == nil {
+		doc.Metadata = &models.DocumentMetadata{}
+	}
+	for _, rule := range m {
+		value, ok := sourceValue(result, rule.Source)
+		if !ok {
+			continue
+		}
+		target, ok := fieldTargets[rule.Target]
+		if !ok {
+			return fmt.Errorf("unknown field mapping target %q", rule.Target)
+		}
+		value, err := convertFieldValue(value, target.kind)
+		if err != nil {
+			return fmt.Errorf("field mapping %s=%s: %w", rule.Source, rule.Target, err)
+		}
+		target.set(doc, value)
+	}
+	return nil
+}
+
+func sourceKindOf(source string) (fieldKind, error) {
+	if key, ok := strings.CutPrefix(source, metadataSourcePrefix); ok && key != "" {
+		return kindAny, nil
+	}
+	if src, ok := fieldSources[source]; ok {
+		return src.kind, nil
+	}
+	return "", fmt.Errorf("unknown field mapping source %q", source)
+}
+
+// sourceValue reads a source field, reporting false when it is empty
+func sourceValue(result *classifier.ClassificationResult, source string) (interface{}, bool) {
+	var value interface{}
+	if key, ok := strings.CutPrefix(source, metadataSourcePrefix); ok {
+		value = result.Metadata[key]
+	} else if src, ok := fieldSources[source]; ok {
+		value = src.get(result)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return v, v != ""
+	case *classifier.CaseInfo:
+		return v, v != nil
+	case *classifier.CourtInfo:
+		return v, v != nil
+	case *classifier.Judge:
+		return v, v != nil
+	case []string:
+		return v, v != nil
+	}
+	return value, true
+}
+
+// convertFieldValue coerces classifier metadata values to the target kind;
+// typed source values pass through unchanged
+func convertFieldValue(value interface{}, kind fieldKind) (interface{}, error) {
+	switch kind {
+	case kindString, kindDate:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(value), nil
+	case kindFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("%v is not a number", value)
+	case kindStrings:
+		switch v := value.(type) {
+		case []string:
+			return v, nil
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, item := range v {
+				values[i] = fmt.Sprint(item)
+			}
+			return values, nil
+		case string:
+			return []string{v}, nil
+		}
+		return nil, fmt.Errorf("%v is not a list", value)
+	}
+	return value, nil
+}
+
+func isScalarKind(kind fieldKind) bool {
+	return kind == kindString || kind == kindFloat || kind == kindDate || kind == kindStrings
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
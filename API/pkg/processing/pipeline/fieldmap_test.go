@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/models"
+	"motion-index-fiber/pkg/processing/classifier"
+)
+
+func TestFieldMapping_RemappedField(t *testing.T) {
+	mapping, err := ParseFieldMapping("summary=metadata.subject, metadata.practice_area=category")
+	require.NoError(t, err)
+
+	filed := "2024-03-15"
+	result := &classifier.ClassificationResult{
+		DocumentType:  "motion_to_dismiss",
+		LegalCategory: "Civil",
+		Summary:       "Motion to dismiss for lack of jurisdiction",
+		FilingDate:    &filed,
+		Parties:       []classifier.Party{{Name: "Acme Corp", Role: "Defendant"}},
+		Metadata:      map[string]interface{}{"practice_area": "Employment"},
+	}
+	doc := &models.Document{Metadata: &models.DocumentMetadata{}}
+
+	require.NoError(t, mapping.Apply(result, doc))
+
+	assert.Equal(t, "Motion to dismiss for lack of jurisdiction", doc.Metadata.Subject)
+	assert.Empty(t, doc.Metadata.Summary, "summary's default rule is replaced by the override")
+	assert.Equal(t, "Employment", doc.Category, "later rules win over the default legal_category rule")
+	assert.Equal(t, "motion_to_dismiss", doc.DocType)
+	require.NotNil(t, doc.Metadata.FilingDate)
+	assert.Equal(t, filed, doc.Metadata.FilingDate.Format("2006-01-02"))
+	require.Len(t, doc.Metadata.Parties, 1)
+	assert.Equal(t, models.PartyRoleDefendant, doc.Metadata.Parties[0].Role)
+}
+
+func TestParseFieldMapping_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		err  string
+	}{
+		{name: "default", spec: ""},
+		{name: "drop a source", spec: "legal_tags="},
+		{name: "classifier metadata to date", spec: "metadata.filed_on=metadata.filing_date"},
+		{name: "missing separator", spec: "summary", err: "expected source=target"},
+		{name: "unknown source", spec: "abstract=metadata.summary", err: `unknown field mapping source "abstract"`},
+		{name: "unknown target", spec: "summary=metadata.abstract", err: `unknown field mapping target "metadata.abstract"`},
+		{name: "kind mismatch", spec: "parties=metadata.subject", err: "cannot map a parties field to a string field"},
+		{name: "classifier metadata to object", spec: "metadata.bench=metadata.judge", err: "can only map to text, number, date or list fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFieldMapping(tt.spec)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
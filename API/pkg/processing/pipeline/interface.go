@@ -40,6 +40,12 @@ type Processor interface {
 	IsHealthy() bool
 }
 
+// ConfigReporter is implemented by processors that can tell whether their
+// backing service was configured, independent of whether it is healthy
+type ConfigReporter interface {
+	IsConfigured() bool
+}
+
 // WorkerPoolInterface manages a pool of workers for concurrent processing
 type WorkerPoolInterface interface {
 	// Submit submits a job to the worker pool
@@ -68,6 +74,14 @@ type ProcessRequest struct {
 	Metadata    map[string]string `json:"metadata,omitempty"`
 	Options     *ProcessOptions   `json:"options,omitempty"`
 	Timestamp   time.Time         `json:"timestamp"`
+
+	// CustomMetadata is indexed under metadata.custom, separate from Metadata
+	// which carries pipeline state between steps
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+	// Profile tunes extraction and classification. The pipeline selects one
+	// from its configured profiles when the caller leaves it nil.
+	Profile *ProcessingProfile `json:"profile,omitempty"`
 }
 
 // ProcessOptions contains processing configuration
@@ -80,6 +94,33 @@ type ProcessOptions struct {
 	Priority       int  `json:"priority"`
 	TimeoutSeconds int  `json:"timeout_seconds"`
 	RetryCount     int  `json:"retry_count"`
+
+	// IndexImmediately waits for the index refresh so the document is searchable
+	// when processing returns, trading throughput for read-after-write visibility
+	IndexImmediately bool `json:"index_immediately"`
+
+	// ExtractReferences collects hyperlinks and statutory citations into the
+	// document's links and references metadata
+	ExtractReferences bool `json:"extract_references"`
+
+	// ExtractPages indexes each page's text as a nested object so searches can
+	// return matching page numbers. Roughly doubles the indexed text size.
+	ExtractPages bool `json:"extract_pages"`
+
+	// ComputeReadability stores readability metrics of the extracted text in
+	// the document's readability metadata
+	ComputeReadability bool `json:"compute_readability"`
+
+	// MinIndexableTextLength overrides the pipeline's minimum extracted text
+	// length for indexing when set
+	MinIndexableTextLength *int `json:"min_indexable_text_length,omitempty"`
+
+	// WarmCDN primes the CDN edge cache for the stored document
+	WarmCDN bool `json:"warm_cdn,omitempty"`
+
+	// OnConflict is the storage.ConflictPolicy for a storage path that is
+	// already taken; empty uses storage.DefaultConflictPolicy
+	OnConflict string `json:"on_conflict,omitempty"`
 }
 
 // ProcessResult contains the result of document processing
@@ -93,6 +134,8 @@ type ProcessResult struct {
 	IndexResult          *IndexResult                     `json:"index_result,omitempty"`
 	StorageResult        *StorageResult                   `json:"storage_result,omitempty"`
 	Document             *models.Document                 `json:"document,omitempty"`
+	PreClassifiedType    string                           `json:"pre_classified_type,omitempty"` // Quick guess from the file name and caption
+	Profile              *ProcessingProfile               `json:"profile,omitempty"`             // Profile the document was processed with
 	ProcessingTime       int64                            `json:"processing_time_ms"`
 	StartTime            time.Time                        `json:"start_time"`
 	EndTime              time.Time                        `json:"end_time"`
@@ -103,6 +146,7 @@ type ProcessStep struct {
 	Type      ProcessorType `json:"type"`
 	Success   bool          `json:"success"`
 	Error     string        `json:"error,omitempty"`
+	Skipped   bool          `json:"skipped,omitempty"`
 	Duration  int64         `json:"duration_ms"`
 	Timestamp time.Time     `json:"timestamp"`
 }
@@ -122,6 +166,16 @@ type BatchResult struct {
 type IndexResult struct {
 	DocumentID string `json:"document_id"`
 	Success    bool   `json:"success"`
+	Searchable bool   `json:"searchable"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	SkipReason string `json:"skip_reason,omitempty"`
+	Deferred   bool   `json:"deferred,omitempty"`
+
+	// DuplicateOf is the ID of the indexed document with the same content,
+	// when indexing was skipped as a duplicate
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	Warning    string `json:"warning,omitempty"`
 	Error      string `json:"error,omitempty"`
 }
 
@@ -129,6 +183,8 @@ type IndexResult struct {
 type StorageResult struct {
 	StoragePath string `json:"storage_path"`
 	URL         string `json:"url,omitempty"`
+	CDNURL      string `json:"cdn_url,omitempty"`
+	Action      string `json:"action,omitempty"` // created, overwritten, skipped or versioned
 	Success     bool   `json:"success"`
 	Error       string `json:"error,omitempty"`
 }
@@ -147,9 +203,10 @@ type PipelineStatus struct {
 
 // ProcessorStatus contains the status of a processor
 type ProcessorStatus struct {
-	Type    ProcessorType `json:"type"`
-	Healthy bool          `json:"healthy"`
-	Error   string        `json:"error,omitempty"`
+	Type       ProcessorType `json:"type"`
+	Configured bool          `json:"configured"`
+	Healthy    bool          `json:"healthy"`
+	Error      string        `json:"error,omitempty"`
 }
 
 // PoolStats contains worker pool statistics
@@ -188,6 +245,15 @@ const (
 	ProcessorTypeValidation     ProcessorType = "validation"
 )
 
+// processorOrder is the order in which ProcessDocument runs the stages
+var processorOrder = []ProcessorType{
+	ProcessorTypeExtraction,
+	ProcessorTypeClassification,
+	ProcessorTypeStorage,
+	ProcessorTypeIndexing,
+	ProcessorTypeValidation,
+}
+
 // Default processing options
 func DefaultProcessOptions() *ProcessOptions {
 	return &ProcessOptions{
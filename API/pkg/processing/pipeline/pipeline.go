@@ -1,11 +1,16 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"motion-index-fiber/pkg/processing/classifier"
 	"motion-index-fiber/pkg/processing/extractor"
@@ -39,6 +44,49 @@ type Config struct {
 	RetryAttempts  int           `json:"retry_attempts"`
 	RetryDelay     time.Duration `json:"retry_delay"`
 	EnableMetrics  bool          `json:"enable_metrics"`
+
+	// MinExtractionQuality flags extractions scoring below it as low quality
+	MinExtractionQuality float64 `json:"min_extraction_quality"`
+
+	// MinIndexableTextLength skips indexing documents whose extracted text is
+	// shorter than this many characters; zero indexes everything
+	MinIndexableTextLength int `json:"min_indexable_text_length"`
+
+	// FieldMapping maps classifier output to index fields; nil uses
+	// DefaultFieldMapping
+	FieldMapping FieldMapping `json:"field_mapping,omitempty"`
+
+	// Profiles tune extraction and classification by document type, chosen
+	// from a quick pre-classification of the file name and caption. Nil
+	// processes every document the same way.
+	Profiles Profiles `json:"profiles,omitempty"`
+
+	// RequiredFields flags indexed documents missing metadata their type
+	// requires; nil flags nothing
+	RequiredFields RequiredFields `json:"required_fields,omitempty"`
+
+	// ConfidenceThresholds sets, per document type, the classifier confidence
+	// below which a document is flagged for review; nil applies
+	// DefaultMinConfidence to every type
+	ConfidenceThresholds ConfidenceThresholds `json:"confidence_thresholds,omitempty"`
+
+	// DeferredIndexer queues documents the index refuses while write-blocked
+	// and indexes them when the block clears; nil fails the indexing step
+	DeferredIndexer *DeferredIndexer `json:"-"`
+
+	// SkipDuplicates skips indexing documents whose extracted text is already
+	// indexed under another ID, reporting that document's ID instead
+	SkipDuplicates bool `json:"skip_duplicates"`
+
+	// IndexFileURLs indexes the storage URL and CDN URL of stored documents
+	// alongside their storage path
+	IndexFileURLs bool `json:"index_file_urls"`
+
+	// MaxFileSize and AllowedContentTypes limit the documents the pipeline
+	// accepts; zero and nil use DefaultMaxFileSize and
+	// DefaultAllowedContentTypes
+	MaxFileSize         int64    `json:"max_file_size"`
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty"`
 }
 
 // NewPipeline creates a new document processing pipeline
@@ -53,14 +101,19 @@ func NewPipeline(
 		config = DefaultConfig()
 	}
 
+	if err := config.FieldMapping.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid field mapping: %w", err)
+	}
+
 	// Create worker pool
 	workerPool := NewWorkerPool(config.MaxWorkers, config.QueueSize)
 
 	// Create processors
 	processors := make(map[ProcessorType]Processor)
-	processors[ProcessorTypeExtraction] = NewExtractionProcessor(extractorSvc)
+	processors[ProcessorTypeValidation] = NewValidationProcessor(config.MaxFileSize, config.AllowedContentTypes)
+	processors[ProcessorTypeExtraction] = NewExtractionProcessor(extractorSvc, config.MinExtractionQuality)
 	processors[ProcessorTypeClassification] = NewClassificationProcessor(classifierSvc)
-	processors[ProcessorTypeIndexing] = NewIndexingProcessor(searchSvc)
+	processors[ProcessorTypeIndexing] = NewIndexingProcessor(searchSvc, config.FieldMapping, config.RequiredFields, config.ConfidenceThresholds, config.DeferredIndexer, config.SkipDuplicates)
 	processors[ProcessorTypeStorage] = NewStorageProcessor(storageSvc)
 
 	return &pipeline{
@@ -176,6 +229,24 @@ func (p *pipeline) executeProcessingSteps(ctx context.Context, req *ProcessReque
 		req.Metadata = make(map[string]string)
 	}
 
+	p.selectProfile(req, result)
+
+	// Storage uploads the content after extraction has read it, so it has to
+	// be able to start over
+	if req.Options.ExtractText && req.Options.StoreDocument && req.Content != nil {
+		if _, ok := req.Content.(io.Seeker); !ok {
+			content, err := io.ReadAll(req.Content)
+			if err != nil {
+				return NewPipelineError("storage_failed", "failed to read document content", ProcessorTypeStorage, err)
+			}
+			req.Content = bytes.NewReader(content)
+		}
+	}
+
+	if err := p.executeStep(ctx, ProcessorTypeValidation, req, result); err != nil {
+		return NewPipelineError("validation_failed", "document validation failed", ProcessorTypeValidation, err)
+	}
+
 	// Step 1: Text Extraction
 	if req.Options.ExtractText {
 		if err := p.executeStep(ctx, ProcessorTypeExtraction, req, result); err != nil {
@@ -188,6 +259,7 @@ func (p *pipeline) executeProcessingSteps(ctx context.Context, req *ProcessReque
 			req.Metadata["word_count"] = fmt.Sprintf("%d", result.ExtractionResult.WordCount)
 			req.Metadata["page_count"] = fmt.Sprintf("%d", result.ExtractionResult.PageCount)
 			req.Metadata["char_count"] = fmt.Sprintf("%d", result.ExtractionResult.CharCount)
+			req.Metadata["content_hash"] = result.ExtractionResult.ContentHash
 			if result.ExtractionResult.Language != "" {
 				req.Metadata["language"] = result.ExtractionResult.Language
 			}
@@ -234,6 +306,9 @@ func (p *pipeline) executeProcessingSteps(ctx context.Context, req *ProcessReque
 			if result.ClassificationResult.ServedDate != nil {
 				req.Metadata["served_date"] = *result.ClassificationResult.ServedDate
 			}
+			if result.ClassificationResult.SignatureDate != nil {
+				req.Metadata["signature_date"] = *result.ClassificationResult.SignatureDate
+			}
 		}
 	}
 
@@ -246,14 +321,33 @@ func (p *pipeline) executeProcessingSteps(ctx context.Context, req *ProcessReque
 		// Pass storage results to subsequent steps
 		if result.StorageResult != nil {
 			req.Metadata["storage_path"] = result.StorageResult.StoragePath
-			if result.StorageResult.URL != "" {
+			if p.config.IndexFileURLs && result.StorageResult.URL != "" {
 				req.Metadata["storage_url"] = result.StorageResult.URL
 			}
+			if p.config.IndexFileURLs && result.StorageResult.CDNURL != "" {
+				req.Metadata["storage_cdn_url"] = result.StorageResult.CDNURL
+			}
 		}
 	}
 
 	// Step 4: Document Indexing (uses all previous results)
 	if req.Options.IndexDocument {
+		if reason := p.indexSkipReason(req, result); reason != "" {
+			log.Printf("[PIPELINE] ⏭️ Skipping indexing for %s: %s", req.FileName, reason)
+			result.IndexResult = &IndexResult{
+				DocumentID: req.ID,
+				Skipped:    true,
+				SkipReason: reason,
+			}
+			result.Steps = append(result.Steps, &ProcessStep{
+				Type:      ProcessorTypeIndexing,
+				Success:   true,
+				Skipped:   true,
+				Timestamp: time.Now(),
+			})
+			return nil
+		}
+
 		// Special handling for indexing processor to pass full ClassificationResult
 		if err := p.executeIndexingStep(ctx, req, result); err != nil {
 			return NewPipelineError("indexing_failed", "document indexing failed", ProcessorTypeIndexing, err)
@@ -263,6 +357,35 @@ func (p *pipeline) executeProcessingSteps(ctx context.Context, req *ProcessReque
 	return nil
 }
 
+// selectProfile pre-classifies the document and picks its processing
+// profile, unless the caller already chose one
+func (p *pipeline) selectProfile(req *ProcessRequest, result *ProcessResult) {
+	if req.Profile == nil && p.config.Profiles != nil {
+		result.PreClassifiedType = PreClassify(req.FileName, peekCaption(req))
+		req.Profile = p.config.Profiles.Select(result.PreClassifiedType)
+	}
+	result.Profile = req.Profile
+}
+
+// indexSkipReason returns why a document should not be indexed, or an empty
+// string if it should. Documents without an extraction result are indexed as
+// before since their text length is unknown.
+func (p *pipeline) indexSkipReason(req *ProcessRequest, result *ProcessResult) string {
+	minLength := p.config.MinIndexableTextLength
+	if req.Options.MinIndexableTextLength != nil {
+		minLength = *req.Options.MinIndexableTextLength
+	}
+	if minLength <= 0 || result.ExtractionResult == nil {
+		return ""
+	}
+
+	length := utf8.RuneCountInString(strings.TrimSpace(result.ExtractionResult.Text))
+	if length < minLength {
+		return fmt.Sprintf("extracted text is %d characters, below the minimum of %d", length, minLength)
+	}
+	return ""
+}
+
 // executeIndexingStep executes the indexing step with access to full ProcessResult
 func (p *pipeline) executeIndexingStep(ctx context.Context, req *ProcessRequest, result *ProcessResult) error {
 	stepStart := time.Now()
@@ -298,6 +421,7 @@ func (p *pipeline) executeIndexingStep(ctx context.Context, req *ProcessRequest,
 		step := &ProcessStep{
 			Type:      ProcessorTypeIndexing,
 			Success:   true,
+			Skipped:   stepResult.IndexResult != nil && stepResult.IndexResult.Skipped,
 			Duration:  time.Since(stepStart).Milliseconds(),
 			Timestamp: time.Now(),
 		}
@@ -379,14 +503,25 @@ func (p *pipeline) GetStatus() *PipelineStatus {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	// Get processor statuses
+	// Get processor statuses in stage order
 	processorStatuses := make([]*ProcessorStatus, 0, len(p.processors))
-	for procType, processor := range p.processors {
+	for _, procType := range processorOrder {
+		processor, exists := p.processors[procType]
+		if !exists {
+			continue
+		}
 		status := &ProcessorStatus{
-			Type:    procType,
-			Healthy: processor.IsHealthy(),
+			Type:       procType,
+			Configured: true,
+			Healthy:    processor.IsHealthy(),
+		}
+		if reporter, ok := processor.(ConfigReporter); ok {
+			status.Configured = reporter.IsConfigured()
 		}
-		if !status.Healthy {
+		switch {
+		case !status.Configured:
+			status.Error = "processor not configured"
+		case !status.Healthy:
 			status.Error = "processor unhealthy"
 		}
 		processorStatuses = append(processorStatuses, status)
@@ -474,5 +609,9 @@ func DefaultConfig() *Config {
 		RetryAttempts:  3,
 		RetryDelay:     time.Second,
 		EnableMetrics:  true,
+
+		MinExtractionQuality: extractor.DefaultMinQualityScore,
+
+		IndexFileURLs: true,
 	}
 }
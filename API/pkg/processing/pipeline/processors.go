@@ -3,6 +3,9 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,13 +18,15 @@ import (
 
 // extractionProcessor handles text extraction
 type extractionProcessor struct {
-	service extractor.Service
+	service    extractor.Service
+	minQuality float64 // Extractions scoring below this are flagged as low quality; 0 disables
 }
 
 // NewExtractionProcessor creates a new extraction processor
-func NewExtractionProcessor(service extractor.Service) Processor {
+func NewExtractionProcessor(service extractor.Service, minQuality float64) Processor {
 	return &extractionProcessor{
-		service: service,
+		service:    service,
+		minQuality: minQuality,
 	}
 }
 
@@ -37,6 +42,10 @@ func (p *extractionProcessor) Process(ctx context.Context, req *ProcessRequest)
 		MimeType: req.ContentType,
 		Size:     req.Size,
 	}
+	if req.Profile != nil && req.Profile.OCR != nil {
+		ocr := *req.Profile.OCR
+		metadata.OCR = &ocr
+	}
 
 	// Extract text
 	result, err := p.service.ExtractText(ctx, req.Content, metadata)
@@ -44,6 +53,40 @@ func (p *extractionProcessor) Process(ctx context.Context, req *ProcessRequest)
 		return nil, fmt.Errorf("text extraction failed: %w", err)
 	}
 
+	// Score the text so garbled extractions are flagged for review rather than indexed as good
+	if result.Quality == nil {
+		result.Quality = extractor.AssessQuality(result.Text)
+	}
+	result.Quality.ApplyThreshold(p.minQuality)
+	result.ContentHash = extractor.ContentHash(result.Text)
+	if result.Quality.LowQuality {
+		log.Printf("[EXTRACTION] ⚠️ Low-quality extraction for %s: score %.2f (threshold %.2f)",
+			req.FileName, result.Quality.Score, p.minQuality)
+	}
+
+	// Page text is only kept when requested since it roughly doubles the indexed text
+	if req.Options == nil || !req.Options.ExtractPages {
+		result.Pages = nil
+	}
+
+	if req.Options != nil && req.Options.ExtractReferences {
+		refs := extractor.ExtractReferences(result)
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata[extractor.MetadataKeyLinks] = refs.Links
+		result.Metadata[extractor.MetadataKeyReferences] = refs.Statutes
+	}
+
+	if req.Options != nil && req.Options.ComputeReadability {
+		if readability := extractor.AssessReadability(result.Text, result.Language); readability != nil {
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]interface{})
+			}
+			result.Metadata[extractor.MetadataKeyReadability] = readability
+		}
+	}
+
 	return &ProcessResult{
 		ID:               req.ID,
 		ExtractionResult: result,
@@ -60,6 +103,11 @@ func (p *extractionProcessor) IsHealthy() bool {
 	return p.service != nil
 }
 
+// IsConfigured reports whether the processor has a backing service
+func (p *extractionProcessor) IsConfigured() bool {
+	return p.service != nil
+}
+
 // classificationProcessor handles document classification
 type classificationProcessor struct {
 	service classifier.Service
@@ -118,6 +166,10 @@ func (p *classificationProcessor) Process(ctx context.Context, req *ProcessReque
 		WordCount: wordCount,
 		PageCount: pageCount,
 	}
+	if req.Profile != nil {
+		metadata.Model = req.Profile.Model
+		metadata.MaxTextLength = req.Profile.ClassificationWindow
+	}
 
 	// Classify document
 	result, err := p.service.ClassifyDocument(ctx, text, metadata)
@@ -141,15 +193,38 @@ func (p *classificationProcessor) IsHealthy() bool {
 	return p.service != nil && p.service.IsHealthy()
 }
 
-// indexingProcessor handles document indexing
-type indexingProcessor struct {
-	service search.Service
+// IsConfigured reports whether the processor has a backing service
+func (p *classificationProcessor) IsConfigured() bool {
+	return p.service != nil
 }
 
-// NewIndexingProcessor creates a new indexing processor
-func NewIndexingProcessor(service search.Service) Processor {
+// indexingProcessor handles document indexing
+type indexingProcessor struct {
+	service        search.Service
+	fields         FieldMapping
+	required       RequiredFields
+	confidence     ConfidenceThresholds
+	deferred       *DeferredIndexer
+	skipDuplicates bool
+}
+
+// NewIndexingProcessor creates a new indexing processor that maps classifier
+// output with fields, or DefaultFieldMapping when fields is nil, and flags
+// documents missing the metadata required for their type or classified below
+// their type's confidence threshold. Documents refused by a write-blocked
+// index are handed to deferred when it is set. With skipDuplicates, documents
+// whose content hash is already indexed under another ID are skipped.
+func NewIndexingProcessor(service search.Service, fields FieldMapping, required RequiredFields, confidence ConfidenceThresholds, deferred *DeferredIndexer, skipDuplicates bool) Processor {
+	if fields == nil {
+		fields = DefaultFieldMapping()
+	}
 	return &indexingProcessor{
-		service: service,
+		service:        service,
+		fields:         fields,
+		required:       required,
+		confidence:     confidence,
+		deferred:       deferred,
+		skipDuplicates: skipDuplicates,
 	}
 }
 
@@ -180,7 +255,7 @@ func (p *indexingProcessor) Process(ctx context.Context, req *ProcessRequest) (*
 		ContentType: req.ContentType,
 		Size:        req.Size,
 		Text:        extractedText,
-		Hash:        fmt.Sprintf("hash_%s", req.ID), // Generate a basic hash
+		Hash:        contentHash(req),
 		Metadata:    &models.DocumentMetadata{},
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -215,96 +290,64 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 		ContentType: req.ContentType,
 		Size:        req.Size,
 		Text:        extractedText,
-		Hash:        fmt.Sprintf("hash_%s", req.ID), // Generate a basic hash
+		Hash:        contentHash(req),
 		Metadata:    &models.DocumentMetadata{},
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
+	if duplicate := p.findDuplicate(ctx, doc); duplicate != "" {
+		log.Printf("[INDEXING] ⏭️ Skipping %s: same content as indexed document %s", doc.ID, duplicate)
+		return &ProcessResult{
+			ID: req.ID,
+			IndexResult: &IndexResult{
+				DocumentID:  duplicate,
+				Skipped:     true,
+				SkipReason:  "duplicate of indexed document " + duplicate,
+				DuplicateOf: duplicate,
+			},
+			Document: doc,
+		}, nil
+	}
+
 	// Populate metadata from processing results
 	doc.Metadata.DocumentName = req.FileName
+	doc.Metadata.OriginalFileName = req.FileName
+	if originalName, exists := req.Metadata["original_file_name"]; exists && originalName != "" {
+		doc.Metadata.OriginalFileName = originalName
+	}
 
 	// Use full ClassificationResult if available (THIS IS THE KEY FIX)
 	if fullResult != nil && fullResult.ClassificationResult != nil {
 		classResult := fullResult.ClassificationResult
-		
-		// Map core classification fields
-		doc.DocType = classResult.DocumentType
-		doc.Category = classResult.LegalCategory
-		
-		// Map DocumentType to metadata as well
-		if classResult.DocumentType != "" {
-			doc.Metadata.DocumentType = models.ParseDocumentType(classResult.DocumentType)
-		}
-		
-		// Properly map both Subject and Summary fields
-		if classResult.Subject != "" {
-			doc.Metadata.Subject = classResult.Subject
-		}
-		if classResult.Summary != "" {
-			doc.Metadata.Summary = classResult.Summary
-			// If no explicit subject, use summary as fallback for subject
-			if doc.Metadata.Subject == "" {
-				doc.Metadata.Subject = classResult.Summary
-			}
-		}
-		if classResult.Status != "" {
-			doc.Metadata.Status = classResult.Status
-		}
-		doc.Metadata.Confidence = classResult.Confidence
-		doc.Metadata.AIClassified = classResult.Confidence > 0.5
 
-		// Map all date fields from ClassificationResult
-		if classResult.FilingDate != nil {
-			if parsedDate, err := time.Parse("2006-01-02", *classResult.FilingDate); err == nil {
-				doc.Metadata.FilingDate = &parsedDate
-			}
+		if err := p.fields.Apply(classResult, doc); err != nil {
+			return nil, fmt.Errorf("failed to map classification result: %w", err)
 		}
-		if classResult.EventDate != nil {
-			if parsedDate, err := time.Parse("2006-01-02", *classResult.EventDate); err == nil {
-				doc.Metadata.EventDate = &parsedDate
-			}
-		}
-		if classResult.HearingDate != nil {
-			if parsedDate, err := time.Parse("2006-01-02", *classResult.HearingDate); err == nil {
-				doc.Metadata.HearingDate = &parsedDate
-			}
+		if err := doc.SetClassification(classResult); err != nil {
+			return nil, err
 		}
-		if classResult.DecisionDate != nil {
-			if parsedDate, err := time.Parse("2006-01-02", *classResult.DecisionDate); err == nil {
-				doc.Metadata.DecisionDate = &parsedDate
-			}
-		}
-		if classResult.ServedDate != nil {
-			if parsedDate, err := time.Parse("2006-01-02", *classResult.ServedDate); err == nil {
-				doc.Metadata.ServedDate = &parsedDate
-			}
+
+		// If no explicit subject, use summary as fallback for subject
+		if doc.Metadata.Subject == "" {
+			doc.Metadata.Subject = doc.Metadata.Summary
 		}
 
-		// Map complex legal entities (THIS FIXES THE MISSING FIELDS ISSUE)
-		// Note: We need to convert between classifier types and models types
-		if classResult.CaseInfo != nil {
-			doc.Metadata.Case = convertCaseInfo(classResult.CaseInfo)
+		// Always initialize arrays even if empty to ensure consistent structure
+		if doc.Metadata.Parties == nil {
+			doc.Metadata.Parties = []models.Party{}
 		}
-		if classResult.CourtInfo != nil {
-			doc.Metadata.Court = convertCourtInfo(classResult.CourtInfo)
+		if doc.Metadata.Attorneys == nil {
+			doc.Metadata.Attorneys = []models.Attorney{}
 		}
-		
-		// Always initialize arrays even if empty to ensure consistent structure
-		doc.Metadata.Parties = convertParties(classResult.Parties)
-		doc.Metadata.Attorneys = convertAttorneys(classResult.Attorneys)
-		doc.Metadata.Charges = convertCharges(classResult.Charges)
-		doc.Metadata.Authorities = convertAuthorities(classResult.Authorities)
-		
-		// Map LegalTags array (copy directly as it's already []string)
-		if classResult.LegalTags != nil {
-			doc.Metadata.LegalTags = classResult.LegalTags
-		} else {
-			doc.Metadata.LegalTags = []string{} // Initialize empty slice
+		if doc.Metadata.Charges == nil {
+			doc.Metadata.Charges = []models.Charge{}
 		}
-		
-		if classResult.Judge != nil {
-			doc.Metadata.Judge = convertJudge(classResult.Judge)
+		if doc.Metadata.Authorities == nil {
+			doc.Metadata.Authorities = []models.Authority{}
+		}
+		if doc.Metadata.LegalTags == nil {
+			doc.Metadata.LegalTags = []string{}
 		}
 	} else {
 		// Fallback to string metadata parsing (for backwards compatibility)
@@ -351,6 +394,11 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 				doc.Metadata.ServedDate = &parsedDate
 			}
 		}
+		if signatureDateStr, exists := req.Metadata["signature_date"]; exists {
+			if parsedDate, err := time.Parse("2006-01-02", signatureDateStr); err == nil {
+				doc.Metadata.SignatureDate = &parsedDate
+			}
+		}
 		
 		if status, exists := req.Metadata["status"]; exists {
 			doc.Metadata.Status = status
@@ -365,6 +413,69 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 		}
 	}
 
+	// Fall back to the document text when the classifier found no docket number
+	if doc.Metadata.Case == nil || doc.Metadata.Case.Docket == "" {
+		doc.Metadata.SetDocket(classifier.ExtractDocketNumber(extractedText))
+	}
+
+	// Carry over the title, cross-references, quality score, readability and email headers when the extraction step collected them
+	if fullResult != nil && fullResult.ExtractionResult != nil {
+		for _, page := range fullResult.ExtractionResult.Pages {
+			doc.Pages = append(doc.Pages, models.DocumentPage{Number: page.Number, Text: page.Text})
+		}
+		if title, ok := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyTitle].(string); ok {
+			doc.Metadata.Title = title
+		}
+		if quality := fullResult.ExtractionResult.Quality; quality != nil {
+			doc.Metadata.ExtractionQuality = quality.Score
+			doc.Metadata.LowQualityExtraction = quality.LowQuality
+		}
+		if links, ok := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyLinks].([]string); ok {
+			doc.Metadata.Links = links
+		}
+		if refs, ok := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyReferences].([]string); ok {
+			doc.Metadata.References = refs
+		}
+		if readability, ok := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyReadability].(*extractor.Readability); ok {
+			doc.Metadata.Readability = &models.Readability{
+				FleschReadingEase:     readability.FleschReadingEase,
+				AverageSentenceLength: readability.AverageSentenceLength,
+				AverageWordLength:     readability.AverageWordLength,
+				SentenceCount:         readability.SentenceCount,
+				Language:              readability.Language,
+			}
+		}
+		if headers, ok := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyEmail].(*extractor.EmailHeaders); ok {
+			doc.Metadata.Email = &models.EmailMetadata{
+				From:      headers.From,
+				To:        headers.To,
+				Cc:        headers.Cc,
+				SentDate:  headers.Date,
+				Subject:   headers.Subject,
+				MessageID: headers.MessageID,
+			}
+			attachments, _ := fullResult.ExtractionResult.Metadata[extractor.MetadataKeyAttachments].([]extractor.EmailAttachment)
+			var attachmentText []string
+			for _, attachment := range attachments {
+				doc.Metadata.Email.Attachments = append(doc.Metadata.Email.Attachments, attachment.FileName)
+				if attachment.Text != "" {
+					attachmentText = append(attachmentText, attachment.Text)
+				}
+			}
+			doc.Metadata.Email.AttachmentText = strings.Join(attachmentText, "\n\n")
+		}
+	}
+
+	if len(req.CustomMetadata) > 0 {
+		doc.Metadata.Custom = req.CustomMetadata
+	}
+	doc.Metadata.SourceSystem = req.Metadata["source_system"]
+	doc.Metadata.IngestionBatchID = req.Metadata["ingestion_batch_id"]
+	doc.Metadata.Language = req.Metadata["language"]
+	if doc.Metadata.Language == "" {
+		doc.Metadata.Language = extractor.DetectLanguage(extractedText)
+	}
+
 	// Add storage metadata
 	if storagePath, exists := req.Metadata["storage_path"]; exists {
 		doc.FilePath = storagePath
@@ -372,6 +483,9 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 	if storageURL, exists := req.Metadata["storage_url"]; exists {
 		doc.FileURL = storageURL
 	}
+	if cdnURL, exists := req.Metadata["storage_cdn_url"]; exists {
+		doc.CDNURL = cdnURL
+	}
 
 	// Set processing timestamp (remove redundant timestamp field)
 	now := time.Now()
@@ -380,9 +494,21 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 	// Populate legacy fields for backward compatibility
 	doc.Metadata.SetLegacyFields()
 
-	// Index document
-	docID, err := p.service.IndexDocument(ctx, doc)
+	// Flag, rather than reject, documents missing metadata their type requires
+	// or classified with too little confidence for their type
+	p.required.Flag(doc.Metadata)
+	if fullResult != nil && fullResult.ClassificationResult != nil {
+		p.confidence.Flag(doc.Metadata, fullResult.ClassificationResult.Confidence)
+	}
+
+	// Index document, waiting for the refresh when the caller needs it searchable right away
+	immediate := req.Options != nil && req.Options.IndexImmediately
+	_, canWait := p.service.(search.RefreshingIndexer)
+	docID, err := search.IndexDocument(ctx, p.service, doc, immediate)
 	if err != nil {
+		if p.deferred != nil && search.IsIndexBlocked(err) {
+			return p.deferIndexing(req, doc, err)
+		}
 		return nil, fmt.Errorf("document indexing failed: %w", err)
 	}
 
@@ -391,6 +517,54 @@ func (p *indexingProcessor) ProcessWithFullResult(ctx context.Context, req *Proc
 		IndexResult: &IndexResult{
 			DocumentID: docID,
 			Success:    true,
+			Searchable: immediate && canWait,
+		},
+		Document: doc,
+	}, nil
+}
+
+// findDuplicate returns the ID of another indexed document with the same
+// content hash, or "" when duplicates are allowed or there is none. A failed
+// lookup is logged and the document indexed, so uploads do not depend on it.
+func (p *indexingProcessor) findDuplicate(ctx context.Context, doc *models.Document) string {
+	if !p.skipDuplicates || doc.Hash == "" {
+		return ""
+	}
+	existing, err := p.service.FindByHash(ctx, doc.Hash)
+	if err != nil {
+		log.Printf("[INDEXING] ⚠️ Duplicate check for %s failed, indexing anyway: %v", doc.ID, err)
+		return ""
+	}
+	if existing == nil || existing.ID == doc.ID {
+		return ""
+	}
+	return existing.ID
+}
+
+// contentHash returns the extraction step's content hash, or hashes the
+// extracted text passed in by the caller when that step did not run
+func contentHash(req *ProcessRequest) string {
+	if hash := req.Metadata["content_hash"]; hash != "" {
+		return hash
+	}
+	return extractor.ContentHash(req.Metadata["extracted_text"])
+}
+
+// deferIndexing queues a document refused by a write-blocked index. The
+// upload succeeds with a warning instead of losing the stored document.
+func (p *indexingProcessor) deferIndexing(req *ProcessRequest, doc *models.Document, indexErr error) (*ProcessResult, error) {
+	if err := p.deferred.Defer(doc); err != nil {
+		return nil, fmt.Errorf("document indexing failed: %w (deferring also failed: %v)", indexErr, err)
+	}
+
+	log.Printf("[INDEXING] ⏸️ Index is write-blocked; deferred indexing of %s: %v", doc.ID, indexErr)
+	return &ProcessResult{
+		ID: req.ID,
+		IndexResult: &IndexResult{
+			DocumentID: doc.ID,
+			Deferred:   true,
+			Warning:    "indexing deferred: the search index is read-only due to cluster state; the document is stored and will be indexed when the block clears",
+			Error:      indexErr.Error(),
 		},
 		Document: doc,
 	}, nil
@@ -406,6 +580,11 @@ func (p *indexingProcessor) IsHealthy() bool {
 	return p.service != nil && p.service.IsHealthy()
 }
 
+// IsConfigured reports whether the processor has a backing service
+func (p *indexingProcessor) IsConfigured() bool {
+	return p.service != nil
+}
+
 // storageProcessor handles document storage
 type storageProcessor struct {
 	service storage.Service
@@ -418,24 +597,82 @@ func NewStorageProcessor(service storage.Service) Processor {
 	}
 }
 
-// Process executes document storage
+// Process uploads the document content to storage
 func (p *storageProcessor) Process(ctx context.Context, req *ProcessRequest) (*ProcessResult, error) {
 	if p.service == nil {
 		return nil, fmt.Errorf("storage service not available")
 	}
+	if req.Content == nil {
+		return nil, fmt.Errorf("no document content to store")
+	}
+
+	// Extraction has already read the content
+	if seeker, ok := req.Content.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind document content: %w", err)
+		}
+	}
 
 	// Generate storage path
 	storagePath := p.generateStoragePath(req.FileName, req.ID)
 
-	// Store document (this is a placeholder implementation)
-	// In a real implementation, you would upload the document to storage
-	url := fmt.Sprintf("https://storage.example.com/%s", storagePath)
+	onConflict := ""
+	if req.Options != nil {
+		onConflict = req.Options.OnConflict
+	}
+	policy, err := storage.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return nil, err
+	}
+	storagePath, action, err := storage.ResolveConflict(ctx, p.service, storagePath, policy)
+	if err != nil {
+		return nil, err
+	}
+	if action == storage.ConflictActionSkipped {
+		// Keep the stored file; later steps refer to it
+		return &ProcessResult{
+			ID: req.ID,
+			StorageResult: &StorageResult{
+				StoragePath: storagePath,
+				URL:         p.service.GetURL(storagePath),
+				CDNURL:      storage.CDNURL(p.service, storagePath),
+				Action:      action,
+				Success:     true,
+			},
+		}, nil
+	}
+
+	uploadResult, err := p.service.Upload(ctx, storagePath, req.Content, &storage.UploadMetadata{
+		ContentType:        req.ContentType,
+		Size:               req.Size,
+		FileName:           req.FileName,
+		ContentDisposition: storage.ContentDisposition("inline", req.FileName),
+		Tags: map[string]string{
+			"document_id": req.ID,
+		},
+		WarmAfterUpload: req.Options != nil && req.Options.WarmCDN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload document: %w", err)
+	}
+	if uploadResult.Path != "" {
+		storagePath = uploadResult.Path
+	}
+
+	// The CDN would keep serving the replaced file
+	if action == storage.ConflictActionOverwritten {
+		if err := storage.InvalidateCache(ctx, p.service, []string{storagePath}); err != nil {
+			log.Printf("[STORAGE] ⚠️ CDN cache invalidation failed for %s: %v", storagePath, err)
+		}
+	}
 
 	return &ProcessResult{
 		ID: req.ID,
 		StorageResult: &StorageResult{
 			StoragePath: storagePath,
-			URL:         url,
+			URL:         p.service.GetURL(storagePath),
+			CDNURL:      storage.CDNURL(p.service, storagePath),
+			Action:      action,
 			Success:     true,
 		},
 	}, nil
@@ -449,9 +686,8 @@ func (p *storageProcessor) generateStoragePath(fileName, docID string) string {
 	year := now.Format("2006")
 	month := now.Format("01")
 
-	// Clean filename
-	cleanName := strings.ReplaceAll(fileName, " ", "_")
-	cleanName = strings.ReplaceAll(cleanName, "/", "_")
+	// The original filename is kept in document metadata; the key only uses a safe form
+	cleanName := storage.SanitizeFileName(fileName)
 
 	return fmt.Sprintf("documents/%s/%s/%s/%s", year, month, docID, cleanName)
 }
@@ -466,35 +702,69 @@ func (p *storageProcessor) IsHealthy() bool {
 	return p.service != nil
 }
 
+// IsConfigured reports whether the processor has a backing service
+func (p *storageProcessor) IsConfigured() bool {
+	return p.service != nil
+}
+
 // validationProcessor handles document validation (optional)
-type validationProcessor struct{}
+type validationProcessor struct {
+	maxFileSize  int64
+	allowedTypes map[string]bool
+}
+
+// NewValidationProcessor creates a new validation processor accepting
+// documents up to maxFileSize bytes of the allowed content types. Zero and
+// nil use DefaultMaxFileSize and DefaultAllowedContentTypes.
+func NewValidationProcessor(maxFileSize int64, allowedContentTypes []string) Processor {
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+	if len(allowedContentTypes) == 0 {
+		allowedContentTypes = DefaultAllowedContentTypes
+	}
 
-// NewValidationProcessor creates a new validation processor
-func NewValidationProcessor() Processor {
-	return &validationProcessor{}
+	allowedTypes := make(map[string]bool, len(allowedContentTypes))
+	for _, contentType := range allowedContentTypes {
+		allowedTypes[strings.ToLower(contentType)] = true
+	}
+	return &validationProcessor{
+		maxFileSize:  maxFileSize,
+		allowedTypes: allowedTypes,
+	}
 }
 
 // Process executes document validation
 func (p *validationProcessor) Process(ctx context.Context, req *ProcessRequest) (*ProcessResult, error) {
 	// Validate file size
-	if req.Size > 100*1024*1024 { // 100MB limit
-		return nil, fmt.Errorf("file size too large: %d bytes", req.Size)
+	if req.Size > p.maxFileSize {
+		return nil, &ValidationError{
+			Field:   "size",
+			Value:   fmt.Sprintf("%d", req.Size),
+			Limit:   fmt.Sprintf("%d", p.maxFileSize),
+			Message: fmt.Sprintf("file size %d bytes exceeds the limit of %d bytes", req.Size, p.maxFileSize),
+		}
 	}
 
 	// Validate file type
-	allowedTypes := map[string]bool{
-		"application/pdf":  true,
-		"application/docx": true,
-		"text/plain":       true,
-	}
-
-	if !allowedTypes[req.ContentType] {
-		return nil, fmt.Errorf("unsupported file type: %s", req.ContentType)
+	contentType := documentContentType(req.ContentType, req.FileName)
+	if !p.allowedTypes[contentType] {
+		allowed := make([]string, 0, len(p.allowedTypes))
+		for allowedType := range p.allowedTypes {
+			allowed = append(allowed, allowedType)
+		}
+		sort.Strings(allowed)
+		return nil, &ValidationError{
+			Field:   "content_type",
+			Value:   contentType,
+			Limit:   strings.Join(allowed, ","),
+			Message: fmt.Sprintf("unsupported file type: %s", contentType),
+		}
 	}
 
 	// Validate filename
 	if req.FileName == "" {
-		return nil, fmt.Errorf("filename is required")
+		return nil, &ValidationError{Field: "file_name", Message: "filename is required"}
 	}
 
 	return &ProcessResult{
@@ -524,7 +794,7 @@ func convertCaseInfo(classifierCase *classifier.CaseInfo) *models.CaseInfo {
 		CaseName:      classifierCase.CaseName,
 		CaseType:      classifierCase.CaseType,
 		Chapter:       classifierCase.Chapter,
-		Docket:        classifierCase.Docket,
+		Docket:        models.NormalizeDocketNumber(classifierCase.Docket),
 		NatureOfSuit:  classifierCase.NatureOfSuit,
 	}
 }
@@ -557,12 +827,7 @@ func convertParties(classifierParties []classifier.Party) []models.Party {
 	}
 	parties := make([]models.Party, len(classifierParties))
 	for i, party := range classifierParties {
-		parties[i] = models.Party{
-			Name:      party.Name,
-			Role:      party.Role,
-			PartyType: party.PartyType,
-			Date:      nil, // classifier.Party doesn't provide date info
-		}
+		parties[i] = models.NewParty(party.Name, party.Role, party.PartyType)
 	}
 	return parties
 }
@@ -0,0 +1,275 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/processing/extractor"
+	"motion-index-fiber/pkg/storage"
+)
+
+func TestExtractionProcessor_FlagsLowQualityText(t *testing.T) {
+	processor := NewExtractionProcessor(extractor.NewService(), extractor.DefaultMinQualityScore)
+
+	tests := []struct {
+		name       string
+		text       string
+		lowQuality bool
+	}{
+		{
+			name:       "clean text",
+			text:       "The defendant moves to dismiss the complaint for failure to state a claim upon which relief can be granted.",
+			lowQuality: false,
+		},
+		{
+			name:       "garbled text",
+			text:       "xzqwrtkp ~~|| bcdfghjk #@%& l1l1l1 mnbvcxz ::== qzxvbn ;;;; 0O0Oo",
+			lowQuality: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.Process(context.Background(), &ProcessRequest{
+				ID:          "doc-1",
+				FileName:    "sample.txt",
+				ContentType: "text/plain",
+				Size:        int64(len(tt.text)),
+				Content:     strings.NewReader(tt.text),
+			})
+			require.NoError(t, err)
+			require.NotNil(t, result.ExtractionResult.Quality)
+			assert.Equal(t, tt.lowQuality, result.ExtractionResult.Quality.LowQuality)
+		})
+	}
+}
+
+func TestExtractionProcessor_KeepsPagesOnlyWhenRequested(t *testing.T) {
+	processor := NewExtractionProcessor(extractor.NewService(), 0)
+	content := "Page one of the motion.\fPage two discusses the warrantless search."
+
+	for _, extractPages := range []bool{false, true} {
+		result, err := processor.Process(context.Background(), &ProcessRequest{
+			ID:          "doc-1",
+			FileName:    "motion.txt",
+			ContentType: "text/plain",
+			Size:        int64(len(content)),
+			Content:     strings.NewReader(content),
+			Options:     &ProcessOptions{ExtractText: true, ExtractPages: extractPages},
+		})
+		require.NoError(t, err)
+		if extractPages {
+			require.Len(t, result.ExtractionResult.Pages, 2)
+			assert.Equal(t, 2, result.ExtractionResult.Pages[1].Number)
+		} else {
+			assert.Empty(t, result.ExtractionResult.Pages)
+		}
+	}
+}
+
+func TestExtractionProcessor_ComputesReadabilityOnlyWhenRequested(t *testing.T) {
+	processor := NewExtractionProcessor(extractor.NewService(), 0)
+	content := "The court granted the motion. The defendant appealed the order."
+
+	for _, computeReadability := range []bool{false, true} {
+		result, err := processor.Process(context.Background(), &ProcessRequest{
+			ID:          "doc-1",
+			FileName:    "order.txt",
+			ContentType: "text/plain",
+			Size:        int64(len(content)),
+			Content:     strings.NewReader(content),
+			Options:     &ProcessOptions{ExtractText: true, ComputeReadability: computeReadability},
+		})
+		require.NoError(t, err)
+		readability, ok := result.ExtractionResult.Metadata[extractor.MetadataKeyReadability].(*extractor.Readability)
+		assert.Equal(t, computeReadability, ok)
+		if computeReadability {
+			assert.Equal(t, 2, readability.SentenceCount)
+			assert.NotNil(t, readability.FleschReadingEase)
+		}
+	}
+}
+
+func TestValidationProcessor(t *testing.T) {
+	processor := NewValidationProcessor(0, nil)
+
+	tests := []struct {
+		name        string
+		req         ProcessRequest
+		field       string
+		limit       string
+		contentType string
+	}{
+		{name: "pdf", req: ProcessRequest{FileName: "motion.pdf", ContentType: "application/pdf", Size: 1024}},
+		{name: "rtf storage accepts", req: ProcessRequest{FileName: "brief.rtf", ContentType: "application/rtf", Size: 1024}},
+		{name: "image with parameters", req: ProcessRequest{FileName: "exhibit.png", ContentType: "image/png; name=exhibit.png", Size: 1024}},
+		{name: "untyped upload uses extension", req: ProcessRequest{FileName: "order.html", ContentType: "application/octet-stream", Size: 1024}},
+		{
+			name:  "too large",
+			req:   ProcessRequest{FileName: "motion.pdf", ContentType: "application/pdf", Size: DefaultMaxFileSize + 1},
+			field: "size",
+			limit: "104857600",
+		},
+		{
+			name:        "unsupported type",
+			req:         ProcessRequest{FileName: "setup.exe", ContentType: "application/x-msdownload", Size: 1024},
+			field:       "content_type",
+			contentType: "application/x-msdownload",
+		},
+		{name: "missing filename", req: ProcessRequest{ContentType: "application/pdf", Size: 1024}, field: "file_name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := processor.Process(context.Background(), &tt.req)
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+			if tt.limit != "" {
+				assert.Equal(t, tt.limit, validationErr.Limit)
+			}
+			if tt.contentType != "" {
+				assert.Equal(t, tt.contentType, validationErr.Value)
+				assert.Contains(t, validationErr.Limit, "application/rtf")
+			}
+		})
+	}
+
+	// Configured limits replace the defaults
+	types, err := ParseContentTypes(" application/pdf , text/plain")
+	require.NoError(t, err)
+	processor = NewValidationProcessor(2048, types)
+	_, err = processor.Process(context.Background(), &ProcessRequest{FileName: "brief.rtf", ContentType: "application/rtf", Size: 1024})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "application/pdf,text/plain", validationErr.Limit)
+	_, err = processor.Process(context.Background(), &ProcessRequest{FileName: "motion.pdf", ContentType: "application/pdf", Size: 4096})
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "2048", validationErr.Limit)
+
+	_, err = ParseContentTypes("application/pdf,pdf")
+	assert.Error(t, err)
+}
+
+// memoryStorage keeps uploads in memory; it implements only the storage
+// methods the pipeline calls
+type memoryStorage struct {
+	storage.Service
+	files       map[string]string
+	metadata    map[string]*storage.UploadMetadata
+	invalidated []string
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{files: map[string]string{}, metadata: map[string]*storage.UploadMetadata{}}
+}
+
+func (s *memoryStorage) Upload(ctx context.Context, path string, content io.Reader, metadata *storage.UploadMetadata) (*storage.UploadResult, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	s.files[path] = string(data)
+	s.metadata[path] = metadata
+	return &storage.UploadResult{Path: path, Size: int64(len(data)), Success: true}, nil
+}
+
+func (s *memoryStorage) Exists(ctx context.Context, path string) (bool, error) {
+	_, ok := s.files[path]
+	return ok, nil
+}
+
+func (s *memoryStorage) GetURL(path string) string {
+	return "https://storage.example/" + path
+}
+
+func (s *memoryStorage) BulkInvalidateCache(ctx context.Context, paths []string) error {
+	s.invalidated = append(s.invalidated, paths...)
+	return nil
+}
+
+func TestPipeline_StoresExtractedContent(t *testing.T) {
+	store := newMemoryStorage()
+	p, err := NewPipeline(&recordingExtractor{}, nil, nil, store, DefaultConfig())
+	require.NoError(t, err)
+
+	// A plain reader can't be rewound, so the pipeline buffers it
+	result, err := p.ProcessDocument(context.Background(), &ProcessRequest{
+		ID:          "doc-1",
+		FileName:    "Motion to Suppress.txt",
+		ContentType: "text/plain",
+		Size:        18,
+		Content:     strings.NewReader("MOTION TO SUPPRESS"),
+		Options:     &ProcessOptions{ExtractText: true, StoreDocument: true, WarmCDN: true},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.StorageResult)
+
+	path := result.StorageResult.StoragePath
+	assert.Equal(t, "MOTION TO SUPPRESS", store.files[path])
+	assert.Equal(t, "MOTION TO SUPPRESS", result.ExtractionResult.Text)
+	assert.Equal(t, "https://storage.example/"+path, result.StorageResult.URL)
+	assert.True(t, store.metadata[path].WarmAfterUpload)
+	assert.Equal(t, "Motion to Suppress.txt", store.metadata[path].FileName)
+	assert.Equal(t, "doc-1", store.metadata[path].Tags["document_id"])
+}
+
+func TestStorageProcessor_AppliesConflictPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		action      string
+		versioned   bool
+		stored      string
+		invalidated bool
+	}{
+		{policy: "", action: storage.ConflictActionVersioned, versioned: true, stored: "ORIGINAL"},
+		{policy: "version", action: storage.ConflictActionVersioned, versioned: true, stored: "ORIGINAL"},
+		{policy: "skip", action: storage.ConflictActionSkipped, stored: "ORIGINAL"},
+		{policy: "overwrite", action: storage.ConflictActionOverwritten, stored: "REPLACEMENT", invalidated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy="+tt.policy, func(t *testing.T) {
+			store := newMemoryStorage()
+			processor := NewStorageProcessor(store)
+			request := func(content string) *ProcessRequest {
+				return &ProcessRequest{
+					ID:       "doc-1",
+					FileName: "Motion.txt",
+					Content:  strings.NewReader(content),
+					Options:  &ProcessOptions{StoreDocument: true, OnConflict: tt.policy},
+				}
+			}
+
+			first, err := processor.Process(context.Background(), request("ORIGINAL"))
+			require.NoError(t, err)
+			assert.Equal(t, storage.ConflictActionCreated, first.StorageResult.Action)
+			path := first.StorageResult.StoragePath
+
+			second, err := processor.Process(context.Background(), request("REPLACEMENT"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.action, second.StorageResult.Action)
+			assert.Equal(t, tt.stored, store.files[path])
+			if tt.versioned {
+				assert.Equal(t, strings.TrimSuffix(path, ".txt")+"_v2.txt", second.StorageResult.StoragePath)
+				assert.Equal(t, "REPLACEMENT", store.files[second.StorageResult.StoragePath])
+			} else {
+				assert.Equal(t, path, second.StorageResult.StoragePath)
+			}
+			if tt.invalidated {
+				assert.Equal(t, []string{path}, store.invalidated)
+			} else {
+				assert.Empty(t, store.invalidated)
+			}
+		})
+	}
+}
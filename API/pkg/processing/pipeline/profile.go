@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
+)
+
+// ProcessingProfile tunes extraction and classification for a kind of
+// document. Scanned orders need OCR while text-native briefs do not, and
+// long briefs benefit from a wider classification window.
+type ProcessingProfile struct {
+	Name                 string `json:"name"`
+	OCR                  *bool  `json:"ocr,omitempty"`                   // Run OCR when the extractor supports it; nil keeps the extractor's choice
+	ClassificationWindow int    `json:"classification_window,omitempty"` // Characters sent to the classifier; 0 keeps its default
+	Model                string `json:"model,omitempty"`                 // Classifier model; empty keeps the configured model
+}
+
+// DefaultProfileName is the profile key used when no type-specific profile matches
+const DefaultProfileName = "default"
+
+// Profiles maps document types to processing profiles. Keys are document
+// types as returned by PreClassify, or a family such as "motion" that covers
+// every "motion_*" type.
+type Profiles map[string]*ProcessingProfile
+
+// Select returns the profile for a pre-classified document type: an exact
+// match, then the type's family, then the default profile. It returns nil
+// when nothing applies.
+func (p Profiles) Select(docType string) *ProcessingProfile {
+	if docType != "" {
+		if profile, ok := p[docType]; ok {
+			return profile
+		}
+		if family, _, found := strings.Cut(docType, "_"); found {
+			if profile, ok := p[family]; ok {
+				return profile
+			}
+		}
+	}
+	return p[DefaultProfileName]
+}
+
+// ParseProfiles parses a profile spec such as
+//
+//	order:ocr=true,window=4000;brief:ocr=false,window=20000,model=gpt-4o
+//
+// Entries are separated by ";" and name a document type, a family such as
+// "motion", or "default". Settings are ocr (bool), window (non-negative
+// characters) and model. An empty spec returns nil, which disables
+// profile selection.
+func ParseProfiles(spec string) (Profiles, error) {
+	profiles := Profiles{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, settings, _ := strings.Cut(entry, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("profile %q has no document type", entry)
+		}
+		if _, exists := profiles[name]; exists {
+			return nil, fmt.Errorf("profile %q is defined more than once", name)
+		}
+
+		profile := &ProcessingProfile{Name: name}
+		for _, setting := range strings.Split(settings, ",") {
+			setting = strings.TrimSpace(setting)
+			if setting == "" {
+				continue
+			}
+			key, value, found := strings.Cut(setting, "=")
+			if !found {
+				return nil, fmt.Errorf("profile %q: setting %q must be key=value", name, setting)
+			}
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+			switch key {
+			case "ocr":
+				ocr, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("profile %q: ocr must be true or false, got %q", name, value)
+				}
+				profile.OCR = &ocr
+			case "window":
+				window, err := strconv.Atoi(value)
+				if err != nil || window < 0 {
+					return nil, fmt.Errorf("profile %q: window must be a non-negative integer, got %q", name, value)
+				}
+				profile.ClassificationWindow = window
+			case "model":
+				profile.Model = value
+			default:
+				return nil, fmt.Errorf("profile %q: unknown setting %q", name, key)
+			}
+		}
+		profiles[name] = profile
+	}
+
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	return profiles, nil
+}
+
+// preClassifyRule maps a filing-name pattern to a document type
+type preClassifyRule struct {
+	pattern *regexp.Regexp
+	docType string
+}
+
+// preClassifyRules recognise filing names in file names and captions. The
+// earliest match wins, so "order granting motion to dismiss" is an order.
+var preClassifyRules = []preClassifyRule{
+	{regexp.MustCompile(`\bmotion to suppress\b`), classifier.DocumentTypeMotionToSuppress},
+	{regexp.MustCompile(`\bmotion to dismiss\b`), classifier.DocumentTypeMotionToDismiss},
+	{regexp.MustCompile(`\bmotion to compel\b`), classifier.DocumentTypeMotionToCompel},
+	{regexp.MustCompile(`\bmotion in limine\b`), classifier.DocumentTypeMotionInLimine},
+	{regexp.MustCompile(`\b(motion|mtn)\b`), "motion"},
+	{regexp.MustCompile(`\b(order|ord)\b`), classifier.DocumentTypeOrder},
+	{regexp.MustCompile(`\bruling\b`), classifier.DocumentTypeRuling},
+	{regexp.MustCompile(`\bjudgment\b`), classifier.DocumentTypeJudgment},
+	{regexp.MustCompile(`\bsentenc(e|ing)\b`), classifier.DocumentTypeSentence},
+	{regexp.MustCompile(`\b(brief|memorandum|memo)\b`), classifier.DocumentTypeBrief},
+	{regexp.MustCompile(`\bcomplaint\b`), classifier.DocumentTypeComplaint},
+	{regexp.MustCompile(`\bnotice\b`), classifier.DocumentTypeNotice},
+	{regexp.MustCompile(`\bstipulation\b`), classifier.DocumentTypeStipulation},
+	{regexp.MustCompile(`\btranscript\b`), classifier.DocumentTypeTranscript},
+	{regexp.MustCompile(`\b(exhibit|evidence)\b`), classifier.DocumentTypeEvidence},
+}
+
+// nameSeparators turns file name punctuation into word breaks
+var nameSeparators = strings.NewReplacer("_", " ", "-", " ", ".", " ", "+", " ")
+
+// PreClassify makes a quick guess at a document's type from its file name
+// and caption, before any text is extracted. It returns an empty string when
+// neither names a recognised filing. When both do, the caption wins.
+func PreClassify(fileName, caption string) string {
+	if docType := matchFilingName(strings.ToLower(caption)); docType != "" {
+		return docType
+	}
+	name := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	return matchFilingName(nameSeparators.Replace(strings.ToLower(name)))
+}
+
+// matchFilingName returns the type of the earliest filing name in text,
+// preferring the longer match when two start at the same place
+func matchFilingName(text string) string {
+	best, bestStart, bestLen := "", -1, 0
+	for _, rule := range preClassifyRules {
+		loc := rule.pattern.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		if bestStart == -1 || loc[0] < bestStart || (loc[0] == bestStart && loc[1]-loc[0] > bestLen) {
+			best, bestStart, bestLen = rule.docType, loc[0], loc[1]-loc[0]
+		}
+	}
+	return best
+}
+
+// maxCaptionBytes bounds how much of a plain-text upload is scanned for its caption
+const maxCaptionBytes = 2048
+
+// peekCaption returns the title heading of a plain-text upload without
+// consuming its content. Other formats need extraction first, so they
+// return "".
+func peekCaption(req *ProcessRequest) string {
+	if !strings.HasPrefix(req.ContentType, "text/") {
+		return ""
+	}
+	seeker, ok := req.Content.(io.ReadSeeker)
+	if !ok {
+		return ""
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ""
+	}
+	buf := make([]byte, maxCaptionBytes)
+	n, _ := io.ReadFull(seeker, buf)
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return ""
+	}
+	return extractor.DetectTitle(string(buf[:n]))
+}
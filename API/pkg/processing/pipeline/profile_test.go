@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/processing/classifier"
+	"motion-index-fiber/pkg/processing/extractor"
+)
+
+// recordingExtractor returns the content as text and records the metadata it was given
+type recordingExtractor struct {
+	metadata *extractor.DocumentMetadata
+}
+
+func (e *recordingExtractor) ExtractText(ctx context.Context, reader io.Reader, metadata *extractor.DocumentMetadata) (*extractor.ExtractionResult, error) {
+	e.metadata = metadata
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor.ExtractionResult{Text: string(content), Success: true}, nil
+}
+
+func (e *recordingExtractor) GetExtractor(format string) (extractor.Extractor, error) {
+	return nil, nil
+}
+
+func (e *recordingExtractor) SupportedFormats() []string {
+	return []string{"pdf", "txt"}
+}
+
+// recordingClassifier records the metadata it was asked to classify with
+type recordingClassifier struct {
+	metadata *classifier.DocumentMetadata
+}
+
+func (c *recordingClassifier) ClassifyDocument(ctx context.Context, text string, metadata *classifier.DocumentMetadata) (*classifier.ClassificationResult, error) {
+	c.metadata = metadata
+	return &classifier.ClassificationResult{DocumentType: classifier.DocumentTypeOrder, Success: true}, nil
+}
+
+func (c *recordingClassifier) GetAvailableCategories() []string {
+	return nil
+}
+
+func (c *recordingClassifier) IsHealthy() bool {
+	return true
+}
+
+func (c *recordingClassifier) ValidateResult(result *classifier.ClassificationResult) error {
+	return nil
+}
+
+func TestProfiles_ScannedOrderSelectsOCRProfile(t *testing.T) {
+	profiles, err := ParseProfiles("order:ocr=true,window=4000,model=gpt-4o-mini; brief:ocr=false,window=20000; default:ocr=false")
+	require.NoError(t, err)
+
+	extractorSvc := &recordingExtractor{}
+	classifierSvc := &recordingClassifier{}
+	config := DefaultConfig()
+	config.Profiles = profiles
+	p, err := NewPipeline(extractorSvc, classifierSvc, nil, nil, config)
+	require.NoError(t, err)
+
+	options := &ProcessOptions{ExtractText: true, ClassifyDoc: true}
+	result, err := p.ProcessDocument(context.Background(), &ProcessRequest{
+		ID:          "doc-1",
+		FileName:    "Scanned_Order_2024-03-01.pdf",
+		ContentType: "application/pdf",
+		Content:     strings.NewReader("ORDER GRANTING CONTINUANCE"),
+		Options:     options,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, classifier.DocumentTypeOrder, result.PreClassifiedType)
+	require.NotNil(t, result.Profile)
+	assert.Equal(t, "order", result.Profile.Name)
+	require.NotNil(t, extractorSvc.metadata.OCR)
+	assert.True(t, *extractorSvc.metadata.OCR)
+	assert.Equal(t, 4000, classifierSvc.metadata.MaxTextLength)
+	assert.Equal(t, "gpt-4o-mini", classifierSvc.metadata.Model)
+
+	// A text-native brief gets the profile without OCR
+	result, err = p.ProcessDocument(context.Background(), &ProcessRequest{
+		ID:          "doc-2",
+		FileName:    "reply_brief.pdf",
+		ContentType: "application/pdf",
+		Content:     strings.NewReader("REPLY BRIEF"),
+		Options:     options,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "brief", result.Profile.Name)
+	assert.False(t, *extractorSvc.metadata.OCR)
+	assert.Equal(t, 20000, classifierSvc.metadata.MaxTextLength)
+	assert.Empty(t, classifierSvc.metadata.Model)
+
+	// A profile without an ocr setting leaves OCR to the extractor
+	profiles["default"] = &ProcessingProfile{Name: "default", ClassificationWindow: 8000}
+	result, err = p.ProcessDocument(context.Background(), &ProcessRequest{
+		ID:          "doc-3",
+		FileName:    "exhibit_list.pdf",
+		ContentType: "application/pdf",
+		Content:     strings.NewReader("EXHIBIT LIST"),
+		Options:     options,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "default", result.Profile.Name)
+	assert.Nil(t, extractorSvc.metadata.OCR)
+	assert.Equal(t, 8000, classifierSvc.metadata.MaxTextLength)
+}
+
+func TestProfiles_DisabledWithoutConfig(t *testing.T) {
+	extractorSvc := &recordingExtractor{}
+	p, err := NewPipeline(extractorSvc, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	result, err := p.ProcessDocument(context.Background(), &ProcessRequest{
+		ID:          "doc-1",
+		FileName:    "scanned_order.pdf",
+		ContentType: "application/pdf",
+		Content:     strings.NewReader("ORDER"),
+		Options:     &ProcessOptions{ExtractText: true},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, result.Profile)
+	assert.Nil(t, extractorSvc.metadata.OCR, "the extractor keeps its own OCR choice")
+}
+
+func TestPreClassify(t *testing.T) {
+	tests := []struct {
+		fileName string
+		caption  string
+		expected string
+	}{
+		{"scanned-order.pdf", "", classifier.DocumentTypeOrder},
+		{"2024-03-01_Motion_to_Suppress.pdf", "", classifier.DocumentTypeMotionToSuppress},
+		{"order_granting_motion_to_dismiss.pdf", "", classifier.DocumentTypeOrder},
+		{"mtn_continue.pdf", "", "motion"},
+		{"notes.txt", "NOTICE OF MOTION AND MOTION TO COMPEL", classifier.DocumentTypeNotice},
+		{"upload.txt", "MOTION TO COMPEL DISCOVERY", classifier.DocumentTypeMotionToCompel},
+		{"ordinance-summary.pdf", "", ""},
+		{"scan0001.pdf", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PreClassify(tt.fileName, tt.caption))
+		})
+	}
+}
+
+func TestProfiles_Select(t *testing.T) {
+	profiles, err := ParseProfiles("motion:window=12000;motion_to_suppress:ocr=true;default:ocr=false")
+	require.NoError(t, err)
+
+	assert.Equal(t, "motion_to_suppress", profiles.Select(classifier.DocumentTypeMotionToSuppress).Name)
+	assert.Equal(t, "motion", profiles.Select(classifier.DocumentTypeMotionToCompel).Name)
+	assert.Equal(t, "default", profiles.Select(classifier.DocumentTypeOrder).Name)
+	assert.Equal(t, "default", profiles.Select("").Name)
+}
+
+func TestParseProfiles_Validation(t *testing.T) {
+	profiles, err := ParseProfiles("  ")
+	require.NoError(t, err)
+	assert.Nil(t, profiles)
+
+	for _, spec := range []string{
+		"order:ocr=maybe",
+		"order:window=-1",
+		"order:dpi=300",
+		"order:ocr",
+		":ocr=true",
+		"order:ocr=true;order:ocr=false",
+	} {
+		_, err := ParseProfiles(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// RequiredFields lists, per document type, the metadata a record of that type
+// needs to be useful, such as the judge and decision date of an order. Keys
+// are document types or a family such as "motion" that covers every
+// "motion_*" type; a type with its own entry does not also use its family's.
+type RequiredFields map[string][]string
+
+// requiredFieldChecks report whether a document's metadata has a value for
+// each field that can be required
+var requiredFieldChecks = map[string]func(m *models.DocumentMetadata) bool{
+	"title":          func(m *models.DocumentMetadata) bool { return m.Title != "" },
+	"subject":        func(m *models.DocumentMetadata) bool { return m.Subject != "" },
+	"summary":        func(m *models.DocumentMetadata) bool { return m.Summary != "" },
+	"status":         func(m *models.DocumentMetadata) bool { return m.Status != "" },
+	"case_name":      func(m *models.DocumentMetadata) bool { return m.GetCaseName() != "" },
+	"case_number":    func(m *models.DocumentMetadata) bool { return m.GetCaseNumber() != "" },
+	"docket":         func(m *models.DocumentMetadata) bool { return m.Case != nil && m.Case.Docket != "" },
+	"court":          func(m *models.DocumentMetadata) bool { return m.GetCourtName() != "" },
+	"judge":          func(m *models.DocumentMetadata) bool { return m.GetJudgeName() != "" },
+	"parties":        func(m *models.DocumentMetadata) bool { return len(m.Parties) > 0 },
+	"attorneys":      func(m *models.DocumentMetadata) bool { return len(m.Attorneys) > 0 },
+	"charges":        func(m *models.DocumentMetadata) bool { return len(m.Charges) > 0 },
+	"authorities":    func(m *models.DocumentMetadata) bool { return len(m.Authorities) > 0 },
+	"legal_tags":     func(m *models.DocumentMetadata) bool { return len(m.LegalTags) > 0 },
+	"filing_date":    func(m *models.DocumentMetadata) bool { return m.FilingDate != nil },
+	"event_date":     func(m *models.DocumentMetadata) bool { return m.EventDate != nil },
+	"hearing_date":   func(m *models.DocumentMetadata) bool { return m.HearingDate != nil },
+	"decision_date":  func(m *models.DocumentMetadata) bool { return m.DecisionDate != nil },
+	"served_date":    func(m *models.DocumentMetadata) bool { return m.ServedDate != nil },
+	"signature_date": func(m *models.DocumentMetadata) bool { return m.SignatureDate != nil },
+}
+
+// RequirableFields returns the field names a RequiredFields rule may use
+func RequirableFields() []string {
+	fields := make([]string, 0, len(requiredFieldChecks))
+	for field := range requiredFieldChecks {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ParseRequiredFields parses a spec such as
+//
+//	order:judge,decision_date;sentence:judge,case_number
+//
+// Entries are separated by ";" and name a document type or family followed
+// by its required fields. An empty spec returns nil, which disables the check.
+func ParseRequiredFields(spec string) (RequiredFields, error) {
+	rules, err := parseTypeFieldLists(spec, "required fields")
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// parseTypeFieldLists parses a "type:field,field;type:field" spec whose
+// fields are all requirable. kind names the setting in errors.
+func parseTypeFieldLists(spec, kind string) (map[string][]string, error) {
+	rules := map[string][]string{}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		docType, list, found := strings.Cut(entry, ":")
+		docType = strings.ToLower(strings.TrimSpace(docType))
+		if !found || docType == "" {
+			return nil, fmt.Errorf("%s entry %q must be type:field,field", kind, entry)
+		}
+		if _, exists := rules[docType]; exists {
+			return nil, fmt.Errorf("%s for %q are defined more than once", kind, docType)
+		}
+
+		var fields []string
+		for _, field := range strings.Split(list, ",") {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if field == "" {
+				continue
+			}
+			if _, ok := requiredFieldChecks[field]; !ok {
+				return nil, fmt.Errorf("unknown field %q in %s for %q (valid fields: %s)",
+					field, kind, docType, strings.Join(RequirableFields(), ", "))
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%s entry for %q lists no fields", kind, docType)
+		}
+		rules[docType] = fields
+	}
+	return rules, nil
+}
+
+// fieldsFor returns the required fields for a document type: its own rule,
+// else its family's
+func (r RequiredFields) fieldsFor(docType string) []string {
+	if fields, ok := r[docType]; ok {
+		return fields
+	}
+	if family, _, found := strings.Cut(docType, "_"); found {
+		return r[family]
+	}
+	return nil
+}
+
+// Missing returns the required fields that metadata lacks for its document type
+func (r RequiredFields) Missing(metadata *models.DocumentMetadata) []string {
+	if metadata == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, field := range r.fieldsFor(strings.ToLower(string(metadata.DocumentType))) {
+		if !requiredFieldChecks[field](metadata) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// Flag marks metadata that lacks required fields for review. The document is
+// still indexed; curators find it by its review status or missing fields.
+func (r RequiredFields) Flag(metadata *models.DocumentMetadata) {
+	if metadata == nil {
+		return
+	}
+
+	metadata.MissingFields = r.Missing(metadata)
+	if len(metadata.MissingFields) > 0 {
+		metadata.ReviewStatus = models.ReviewStatusIncompleteMetadata
+	} else {
+		metadata.ReviewStatus = ""
+	}
+}
+
+// Reflag re-evaluates metadata after its fields were edited, so filling in a
+// missing field clears the incomplete_metadata status and removing one sets
+// it. A low-confidence status is kept, since editing fields does not confirm
+// the classification. It returns the metadata fields to set and remove to
+// store the result, and false when nothing changed.
+func (r RequiredFields) Reflag(metadata *models.DocumentMetadata) (map[string]interface{}, []string, bool) {
+	if metadata == nil {
+		return nil, nil, false
+	}
+
+	status, missing := metadata.ReviewStatus, metadata.MissingFields
+	metadata.MissingFields = r.Missing(metadata)
+	switch {
+	case status == models.ReviewStatusLowConfidence:
+	case len(metadata.MissingFields) > 0:
+		metadata.ReviewStatus = models.ReviewStatusIncompleteMetadata
+	case status == models.ReviewStatusIncompleteMetadata:
+		metadata.ReviewStatus = ""
+	}
+	if metadata.ReviewStatus == status && slices.Equal(metadata.MissingFields, missing) {
+		return nil, nil, false
+	}
+
+	set := map[string]interface{}{}
+	var remove []string
+	if metadata.ReviewStatus != "" {
+		set["review_status"] = metadata.ReviewStatus
+	} else {
+		remove = append(remove, "review_status")
+	}
+	if len(metadata.MissingFields) > 0 {
+		set["missing_fields"] = metadata.MissingFields
+	} else {
+		remove = append(remove, "missing_fields")
+	}
+	return set, remove, true
+}
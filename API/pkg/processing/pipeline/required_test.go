@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/models"
+)
+
+func TestRequiredFields_FlagsMissingMetadata(t *testing.T) {
+	rules, err := ParseRequiredFields("order:judge,decision_date; motion:case_number")
+	require.NoError(t, err)
+
+	decided := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	order := &models.DocumentMetadata{DocumentType: models.DocTypeOrder, DecisionDate: &decided}
+	rules.Flag(order)
+	assert.Equal(t, models.ReviewStatusIncompleteMetadata, order.ReviewStatus)
+	assert.Equal(t, []string{"judge"}, order.MissingFields)
+
+	// Completing the record clears the flag when it is checked again
+	order.Judge = &models.Judge{Name: "Hon. Jane Doe"}
+	rules.Flag(order)
+	assert.Empty(t, order.ReviewStatus)
+	assert.Empty(t, order.MissingFields)
+
+	// A motion type falls back to the motion family rule
+	motion := &models.DocumentMetadata{DocumentType: models.DocTypeMotionToSuppress}
+	assert.Equal(t, []string{"case_number"}, rules.Missing(motion))
+
+	// Types without a rule are never flagged
+	brief := &models.DocumentMetadata{DocumentType: models.DocTypeBrief}
+	rules.Flag(brief)
+	assert.Empty(t, brief.ReviewStatus)
+
+	var none RequiredFields
+	assert.Empty(t, none.Missing(order))
+}
+
+func TestRequiredFields_Reflag(t *testing.T) {
+	rules, err := ParseRequiredFields("order:judge,decision_date")
+	require.NoError(t, err)
+
+	// Removing a required field flags the record
+	order := &models.DocumentMetadata{DocumentType: models.DocTypeOrder, Judge: &models.Judge{Name: "Hon. Jane Doe"}}
+	set, remove, changed := rules.Reflag(order)
+	require.True(t, changed)
+	assert.Equal(t, map[string]interface{}{
+		"review_status":  models.ReviewStatusIncompleteMetadata,
+		"missing_fields": []string{"decision_date"},
+	}, set)
+	assert.Empty(t, remove)
+
+	_, _, changed = rules.Reflag(order)
+	assert.False(t, changed, "nothing to store when the verdict is unchanged")
+
+	// Completing it clears the flag
+	decided := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	order.DecisionDate = &decided
+	set, remove, changed = rules.Reflag(order)
+	require.True(t, changed)
+	assert.Empty(t, set)
+	assert.Equal(t, []string{"review_status", "missing_fields"}, remove)
+
+	// A low-confidence classification stays flagged as such
+	lowConfidence := &models.DocumentMetadata{DocumentType: models.DocTypeOrder, ReviewStatus: models.ReviewStatusLowConfidence}
+	set, _, changed = rules.Reflag(lowConfidence)
+	require.True(t, changed)
+	assert.Equal(t, models.ReviewStatusLowConfidence, set["review_status"])
+	assert.Equal(t, []string{"judge", "decision_date"}, set["missing_fields"])
+}
+
+func TestParseRequiredFields_Validation(t *testing.T) {
+	rules, err := ParseRequiredFields("")
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	for _, spec := range []string{
+		"order:judge,gavel",
+		"order",
+		"order:",
+		":judge",
+		"order:judge;order:decision_date",
+	} {
+		_, err := ParseRequiredFields(spec)
+		assert.Error(t, err, spec)
+	}
+}
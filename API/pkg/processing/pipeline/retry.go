@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// retryableStatusPattern matches the HTTP status codes that backing services
+// report in error messages for overload or outage: 429 and any 5xx
+var retryableStatusPattern = regexp.MustCompile(`status:? (429|5\d\d)\b`)
+
+// retryableMessages are error message fragments that indicate a dependency
+// was briefly unreachable rather than that the document itself is bad
+var retryableMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no such host",
+	"i/o timeout",
+	"is not healthy",
+	"service unavailable",
+	"too many requests",
+}
+
+// IsRetryable reports whether a pipeline error is transient, such as a
+// timeout or an unavailable search cluster, so that processing the same
+// document again may succeed. Errors caused by the document itself, like an
+// unsupported file type or unreadable text, are permanent. Cancellation is
+// never retried.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// A rejected document fails the same way every time
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	if retryableStatusPattern.MatchString(message) {
+		return true
+	}
+	for _, fragment := range retryableMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", NewPipelineError("indexing_failed", "document indexing failed", ProcessorTypeIndexing, context.DeadlineExceeded), true},
+		{"connection refused", fmt.Errorf("index request failed: %w", syscall.ECONNREFUSED), true},
+		{"search unavailable", errors.New("index failed with status: 503 Service Unavailable"), true},
+		{"rate limited", errors.New("API request failed with status 429: slow down"), true},
+		{"unhealthy processor", errors.New("indexing processor is not healthy"), true},
+		{"bad request", errors.New("index failed with status: 400 Bad Request"), false},
+		{"unsupported file", NewPipelineError("extraction_failed", "text extraction failed", ProcessorTypeExtraction, errors.New("unsupported file type: image/png")), false},
+		{"rejected document", NewPipelineError("validation_failed", "document validation failed", ProcessorTypeValidation, &ValidationError{Field: "size", Message: "file size 503 bytes exceeds the limit of 100 bytes"}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
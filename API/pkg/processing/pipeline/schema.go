@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MetadataField is one field of a document type's metadata schema
+type MetadataField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // string, date or array
+	Required bool   `json:"required"`
+}
+
+// MetadataSchema lists the metadata fields that apply to a document type, in
+// the order a form should show them
+type MetadataSchema struct {
+	DocumentType string          `json:"document_type"`
+	Fields       []MetadataField `json:"fields"`
+	Default      bool            `json:"default"` // No schema is configured for the type or its family
+}
+
+// MetadataSchemas lists, per document type, the metadata fields that apply to
+// it. Keys are document types or families, as for RequiredFields.
+type MetadataSchemas map[string][]string
+
+// DefaultMetadataSchemas are the schemas of types METADATA_SCHEMAS does not configure
+var DefaultMetadataSchemas = MetadataSchemas{
+	"motion":     {"title", "case_name", "case_number", "court", "judge", "parties", "attorneys", "filing_date", "hearing_date", "authorities", "legal_tags"},
+	"order":      {"title", "case_name", "case_number", "court", "judge", "filing_date", "decision_date", "legal_tags"},
+	"ruling":     {"title", "case_name", "case_number", "court", "judge", "filing_date", "decision_date", "legal_tags"},
+	"judgment":   {"title", "case_name", "case_number", "court", "judge", "parties", "decision_date", "legal_tags"},
+	"sentence":   {"title", "case_name", "case_number", "court", "judge", "charges", "decision_date"},
+	"injunction": {"title", "case_name", "case_number", "court", "judge", "parties", "decision_date", "served_date"},
+	"complaint":  {"title", "case_name", "case_number", "court", "parties", "attorneys", "filing_date", "served_date", "legal_tags"},
+	"answer":     {"title", "case_name", "case_number", "court", "parties", "attorneys", "filing_date", "served_date"},
+	"brief":      {"title", "case_name", "case_number", "court", "attorneys", "filing_date", "authorities", "legal_tags"},
+	"transcript": {"title", "case_name", "case_number", "court", "judge", "event_date"},
+}
+
+// defaultMetadataFields apply to document types with no schema
+var defaultMetadataFields = []string{"title", "case_name", "case_number", "court", "filing_date", "legal_tags"}
+
+// arrayMetadataFields hold lists rather than single values
+var arrayMetadataFields = map[string]bool{
+	"parties":     true,
+	"attorneys":   true,
+	"charges":     true,
+	"authorities": true,
+	"legal_tags":  true,
+}
+
+// ParseMetadataSchemas parses a spec in the format of ParseRequiredFields,
+// such as
+//
+//	order:judge,decision_date;complaint:parties,filing_date
+//
+// Configured entries replace the default schema of their type; every other
+// default is kept.
+func ParseMetadataSchemas(spec string) (MetadataSchemas, error) {
+	configured, err := parseTypeFieldLists(spec, "metadata schema")
+	if err != nil {
+		return nil, err
+	}
+	schemas := maps.Clone(DefaultMetadataSchemas)
+	maps.Copy(schemas, configured)
+	return schemas, nil
+}
+
+// SchemaFor returns the schema of a document type: its own, else its
+// family's, else the default fields. Fields required for the type are marked
+// and added when the schema lacks them.
+func (s MetadataSchemas) SchemaFor(docType string, required RequiredFields) MetadataSchema {
+	docType = strings.ToLower(strings.TrimSpace(docType))
+	schema := MetadataSchema{DocumentType: docType}
+
+	names := RequiredFields(s).fieldsFor(docType)
+	if names == nil {
+		names = defaultMetadataFields
+		schema.Default = true
+	}
+	requiredNames := required.fieldsFor(docType)
+	for _, name := range requiredNames {
+		if !slices.Contains(names, name) {
+			names = append(slices.Clip(names), name)
+		}
+	}
+
+	for _, name := range names {
+		schema.Fields = append(schema.Fields, MetadataField{
+			Name:     name,
+			Type:     metadataFieldType(name),
+			Required: slices.Contains(requiredNames, name),
+		})
+	}
+	return schema
+}
+
+// metadataFieldType returns the value type of a requirable field
+func metadataFieldType(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_date"):
+		return "date"
+	case arrayMetadataFields[name]:
+		return "array"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataSchemas_SchemaFor(t *testing.T) {
+	schemas, err := ParseMetadataSchemas("order:judge,decision_date")
+	require.NoError(t, err)
+	required, err := ParseRequiredFields("order:decision_date,case_number")
+	require.NoError(t, err)
+
+	// The configured schema replaces the default, and required fields are added
+	assert.Equal(t, MetadataSchema{
+		DocumentType: "order",
+		Fields: []MetadataField{
+			{Name: "judge", Type: "string"},
+			{Name: "decision_date", Type: "date", Required: true},
+			{Name: "case_number", Type: "string", Required: true},
+		},
+	}, schemas.SchemaFor("Order", required))
+
+	// Motion types use the motion family's default schema
+	schema := schemas.SchemaFor("motion_to_suppress", nil)
+	assert.False(t, schema.Default)
+	assert.Contains(t, schema.Fields, MetadataField{Name: "hearing_date", Type: "date"})
+	assert.Contains(t, schema.Fields, MetadataField{Name: "parties", Type: "array"})
+
+	// Unknown types get the default fields
+	schema = schemas.SchemaFor("affidavit", nil)
+	assert.True(t, schema.Default)
+	assert.Len(t, schema.Fields, len(defaultMetadataFields))
+
+	// The defaults themselves are untouched
+	assert.NotEqual(t, []string{"judge", "decision_date"}, DefaultMetadataSchemas["order"])
+}
+
+func TestParseMetadataSchemas_Validation(t *testing.T) {
+	schemas, err := ParseMetadataSchemas("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMetadataSchemas, schemas)
+
+	for _, spec := range []string{"order:judge,gavel", "order:", "order:judge;order:court"} {
+		_, err := ParseMetadataSchemas(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxFileSize is the largest document the validation processor
+// accepts when the pipeline is not configured with a limit
+const DefaultMaxFileSize int64 = 100 * 1024 * 1024
+
+// DefaultAllowedContentTypes are the content types the validation processor
+// accepts by default, one or more for each file extension document storage
+// accepts
+var DefaultAllowedContentTypes = []string{
+	"application/pdf",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/docx",
+	"text/plain",
+	"application/rtf",
+	"text/rtf",
+	"message/rfc822",
+	"application/vnd.ms-outlook",
+	"application/json",
+	"application/xml",
+	"text/xml",
+	"text/html",
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/bmp",
+	"image/tiff",
+	"image/webp",
+}
+
+// extensionContentTypes are the content types of file extensions that
+// mime.TypeByExtension may not know, for documents uploaded without a type
+var extensionContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".txt":  "text/plain",
+	".rtf":  "application/rtf",
+	".eml":  "message/rfc822",
+	".msg":  "application/vnd.ms-outlook",
+	".json": "application/json",
+	".xml":  "application/xml",
+	".html": "text/html",
+	".htm":  "text/html",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".bmp":  "image/bmp",
+	".tiff": "image/tiff",
+	".tif":  "image/tiff",
+	".webp": "image/webp",
+}
+
+// ValidationError is a document the validation processor rejects. Field names
+// the request field at fault and Limit the limit it broke, so callers can
+// report both without parsing the message.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Value   string `json:"value,omitempty"`
+	Limit   string `json:"limit,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ParseContentTypes parses a comma-separated list of content types such as
+//
+//	application/pdf,text/plain,image/png
+//
+// An empty spec returns nil, which allows DefaultAllowedContentTypes.
+func ParseContentTypes(spec string) ([]string, error) {
+	var types []string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(entry)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			return nil, fmt.Errorf("content type %q must be type/subtype", entry)
+		}
+		types = append(types, mediaType)
+	}
+	return types, nil
+}
+
+// documentContentType returns the media type of a document without its
+// parameters. Documents sent without a specific type get the type of their
+// file extension.
+func documentContentType(contentType, fileName string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if known, ok := extensionContentTypes[ext]; ok {
+		return known
+	}
+	if byExtension := mime.TypeByExtension(ext); byExtension != "" {
+		mediaType, _, _ = mime.ParseMediaType(byExtension)
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
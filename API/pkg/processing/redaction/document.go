@@ -0,0 +1,635 @@
+package redaction
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document formats the redaction service accepts
+const (
+	FormatPDF  = "pdf"
+	FormatDOCX = "docx"
+	FormatRTF  = "rtf"
+)
+
+// SupportedFormats lists the document formats that can be redacted
+var SupportedFormats = []string{FormatPDF, FormatDOCX, FormatRTF}
+
+// formatContentTypes maps content types to document formats
+var formatContentTypes = map[string]string{
+	"application/pdf": FormatPDF,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": FormatDOCX,
+	"application/rtf": FormatRTF,
+	"text/rtf":        FormatRTF,
+}
+
+// ErrInvalidDocument is returned for DOCX and RTF files that cannot be parsed
+var ErrInvalidDocument = errors.New("invalid document")
+
+// UnsupportedFormatError is returned for documents the redaction service
+// cannot read
+type UnsupportedFormatError struct {
+	Format string // The file extension or content type that was given
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Format == "" {
+		return "unknown document format: redaction supports PDF, DOCX and RTF files"
+	}
+	return fmt.Sprintf("unsupported document format %q: redaction supports PDF, DOCX and RTF files", e.Format)
+}
+
+// DetectFormat returns the format of a document from its file extension,
+// falling back to its content type when the extension is not recognized
+func DetectFormat(filename, contentType string) (string, error) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	for _, format := range SupportedFormats {
+		if ext == format {
+			return format, nil
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if format, ok := formatContentTypes[mediaType]; ok {
+			return format, nil
+		}
+	}
+
+	if ext != "" {
+		return "", &UnsupportedFormatError{Format: ext}
+	}
+	return "", &UnsupportedFormatError{Format: mediaType}
+}
+
+// AnalyzeDocument analyzes a document of the given format for potential
+// redactions without applying them
+func (s *service) AnalyzeDocument(ctx context.Context, data io.Reader, format string, options *Options) (*AnalysisResult, error) {
+	if format == FormatPDF {
+		return s.AnalyzePDF(ctx, data, options)
+	}
+
+	_, text, err := s.readDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var redactions []RedactionItem
+	for _, match := range findMatches(text.String(), options) {
+		redactions = append(redactions, match.item)
+	}
+	return &AnalysisResult{
+		Redactions: redactions,
+		TotalCount: len(redactions),
+		Success:    true,
+	}, nil
+}
+
+// RedactDocument redacts a document of the given format. DOCX and RTF files
+// are re-emitted in their own format when options.PreserveFormat is set, and
+// converted to a redacted PDF of their text otherwise.
+func (s *service) RedactDocument(ctx context.Context, data io.Reader, format string, options *Options) (*Result, error) {
+	if format == FormatPDF {
+		return s.RedactPDF(ctx, data, options)
+	}
+
+	content, text, err := s.readDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := findMatches(text.String(), options)
+	edits := text.redact(matches, options)
+	redactions := make([]RedactionItem, len(matches))
+	for i, match := range matches {
+		redactions[i] = match.item
+		redactions[i].Applied = true
+	}
+
+	result := &Result{
+		Redactions: redactions,
+		TotalCount: len(redactions),
+		Success:    true,
+	}
+	if options == nil || !options.PreserveFormat {
+		result.Format = FormatPDF
+		result.RedactedPDF = renderTextPDF(redactedText(text.String(), edits))
+		result.PDFBase64 = base64.StdEncoding.EncodeToString(result.RedactedPDF)
+		return result, nil
+	}
+
+	switch format {
+	case FormatDOCX:
+		result.RedactedDocument, err = redactDOCX(content, options, s.limits.MaxFileSize)
+		if err != nil {
+			return nil, err
+		}
+	case FormatRTF:
+		result.RedactedDocument = text.rewrite(content, edits, rtfEscape)
+	}
+	result.Format = format
+	result.DocumentBase64 = base64.StdEncoding.EncodeToString(result.RedactedDocument)
+	return result, nil
+}
+
+// readDocument reads a DOCX or RTF document and extracts its text
+func (s *service) readDocument(data io.Reader, format string) ([]byte, *sourceText, error) {
+	if format != FormatDOCX && format != FormatRTF {
+		return nil, nil, &UnsupportedFormatError{Format: format}
+	}
+
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s data: %w", strings.ToUpper(format), err)
+	}
+	if err := s.limits.Check(content); err != nil {
+		return nil, nil, err
+	}
+
+	if format == FormatRTF {
+		if !bytes.HasPrefix(bytes.TrimLeft(content, " \t\r\n"), []byte(`{\rtf`)) {
+			return nil, nil, fmt.Errorf("%w: RTF file has no {\\rtf header", ErrInvalidDocument)
+		}
+		return content, parseRTF(content), nil
+	}
+
+	parts, err := docxTextParts(content, s.limits.MaxFileSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := &sourceText{}
+	for _, part := range parts {
+		text.appendText(part)
+	}
+	return content, text, nil
+}
+
+// textMatch is a redaction pattern match at text[start:end]
+type textMatch struct {
+	item       RedactionItem
+	start, end int
+}
+
+// findMatches runs the California and custom patterns enabled by options
+// over text
+func findMatches(text string, options *Options) []textMatch {
+	if options == nil {
+		return nil
+	}
+
+	var matches []textMatch
+	find := func(pattern, prefix string, item RedactionItem) {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return
+		}
+		for _, loc := range regex.FindAllStringIndex(text, -1) {
+			item.ID = fmt.Sprintf("%s_%d", prefix, len(matches)+1)
+			item.Text = text[loc[0]:loc[1]]
+			matches = append(matches, textMatch{item: item, start: loc[0], end: loc[1]})
+		}
+	}
+
+	if options.CaliforniaLaws {
+		for _, pattern := range CaliforniaPatterns {
+			find(pattern.Pattern, "redaction", RedactionItem{
+				Type:      pattern.Name,
+				Citation:  pattern.Citation.Description,
+				Reason:    pattern.Reason,
+				LegalCode: pattern.Citation.Code,
+			})
+		}
+	}
+	for _, pattern := range options.IncludePatterns {
+		find(pattern, "custom_redaction", RedactionItem{
+			Type:      "custom_pattern",
+			Citation:  "Custom Pattern",
+			Reason:    "Matches custom redaction pattern",
+			LegalCode: "CUSTOM",
+		})
+	}
+	return matches
+}
+
+// sourceRune is a character of extracted text and the bytes of the source
+// file it was read from. Separators the extractor adds, such as paragraph
+// breaks, have no source and are never replaced.
+type sourceRune struct {
+	offset     int // Byte offset in the extracted text
+	start, end int // Source byte range, or -1 for separators
+}
+
+// sourceText is the text of a document with the position of each character
+// in the source file, so matches in the text can be replaced in the source
+type sourceText struct {
+	text  strings.Builder
+	runes []sourceRune
+}
+
+// add appends a character read from source[start:end]
+func (t *sourceText) add(r rune, start, end int) {
+	t.runes = append(t.runes, sourceRune{offset: t.text.Len(), start: start, end: end})
+	t.text.WriteRune(r)
+}
+
+// addSeparator appends a character that is not in the source text
+func (t *sourceText) addSeparator(r rune) {
+	t.add(r, -1, -1)
+}
+
+// appendText appends the text of another source, keeping its positions
+func (t *sourceText) appendText(other *sourceText) {
+	base := t.text.Len()
+	for _, r := range other.runes {
+		r.offset += base
+		t.runes = append(t.runes, r)
+	}
+	t.text.WriteString(other.text.String())
+}
+
+func (t *sourceText) String() string {
+	return t.text.String()
+}
+
+// redact returns the edit of each character, replacing each match in the
+// style options give its redaction type. Where matches overlap, characters
+// an earlier match replaced keep that edit.
+func (t *sourceText) redact(matches []textMatch, options *Options) []runeEdit {
+	edits := make([]runeEdit, len(t.runes))
+	text := t.String()
+	for _, match := range matches {
+		matchEdits := replacementStyle(options, match.item.Type).edits(text[match.start:match.end])
+		i := sort.Search(len(t.runes), func(i int) bool { return t.runes[i].offset >= match.start })
+		for k := 0; i < len(t.runes) && t.runes[i].offset < match.end; i, k = i+1, k+1 {
+			if !edits[i].replaced && k < len(matchEdits) {
+				edits[i] = matchEdits[k]
+			}
+		}
+	}
+	return edits
+}
+
+// rewrite returns source with each replaced character rewritten, escaping
+// replacements for the source format. Only the characters of t that were
+// read from source are rewritten.
+func (t *sourceText) rewrite(source []byte, edits []runeEdit, escape func(string) string) []byte {
+	var out bytes.Buffer
+	last := 0
+	for i, r := range t.runes {
+		if !edits[i].replaced || r.start < last {
+			continue
+		}
+		out.Write(source[last:r.start])
+		out.WriteString(escape(edits[i].text))
+		last = r.end
+	}
+	out.Write(source[last:])
+	return out.Bytes()
+}
+
+// docxTextPart matches the parts of a DOCX package that hold document text:
+// the body, headers, footers, footnotes and endnotes
+var docxTextPart = regexp.MustCompile(`^word/(document|header\d*|footer\d*|footnotes|endnotes)\.xml$`)
+
+// docxTextNode matches the WordprocessingML elements that carry text or
+// break it up: text runs, tabs, line breaks and paragraph ends
+var docxTextNode = regexp.MustCompile(`(?s)<w:t(?:\s[^>]*[^/])?>(.*?)</w:t>|<w:tab\s*/>|<w:br\s*/>|<w:cr\s*/>|</w:p>`)
+
+// docxTextParts extracts the text of each text part of a DOCX package, with
+// positions relative to that part. No part may unpack to more than maxSize
+// bytes, unless maxSize is zero.
+func docxTextParts(content []byte, maxSize int64) ([]*sourceText, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a DOCX package: %v", ErrInvalidDocument, err)
+	}
+
+	var parts []*sourceText
+	found := false
+	for _, file := range reader.File {
+		if !docxTextPart.MatchString(file.Name) {
+			continue
+		}
+		found = found || file.Name == "word/document.xml"
+		xmlContent, err := readZipFile(file, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		parts = append(parts, parseDOCXPart(xmlContent))
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: DOCX package has no word/document.xml", ErrInvalidDocument)
+	}
+	return parts, nil
+}
+
+// redactDOCX re-emits a DOCX package with the matches in each text part
+// replaced. Matches are found part by part, so text does not run from the
+// body into a header.
+func redactDOCX(content []byte, options *Options, maxSize int64) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a DOCX package: %v", ErrInvalidDocument, err)
+	}
+
+	var out bytes.Buffer
+	writer := zip.NewWriter(&out)
+	for _, file := range reader.File {
+		if !docxTextPart.MatchString(file.Name) {
+			if err := writer.Copy(file); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		xmlContent, err := readZipFile(file, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		text := parseDOCXPart(xmlContent)
+		edits := text.redact(findMatches(text.String(), options), options)
+
+		header := file.FileHeader
+		w, err := writer.CreateHeader(&header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+		if _, err := w.Write(text.rewrite(xmlContent, edits, xmlEscape)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write DOCX document: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// readZipFile reads one file from a zip archive, returning a *LimitError
+// when it unpacks to more than maxSize bytes. The declared size is checked
+// first, and the read stops past maxSize in case the header understates it.
+// A zero maxSize reads the whole file.
+func readZipFile(file *zip.File, maxSize int64) ([]byte, error) {
+	if maxSize > 0 && file.UncompressedSize64 > uint64(maxSize) {
+		return nil, &LimitError{Limit: "uncompressed_size", Max: maxSize, Actual: int64(min(file.UncompressedSize64, math.MaxInt64))}
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	if maxSize <= 0 {
+		return io.ReadAll(rc)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, &LimitError{Limit: "uncompressed_size", Max: maxSize, Actual: int64(len(content))}
+	}
+	return content, nil
+}
+
+// parseDOCXPart extracts the text of a WordprocessingML part. Runs of a
+// paragraph are joined, so a number split across runs is still matched.
+func parseDOCXPart(xmlContent []byte) *sourceText {
+	text := &sourceText{}
+	for _, loc := range docxTextNode.FindAllSubmatchIndex(xmlContent, -1) {
+		node := string(xmlContent[loc[0]:loc[1]])
+		switch {
+		case strings.HasPrefix(node, "<w:tab"):
+			text.addSeparator('\t')
+		case strings.HasPrefix(node, "<w:br"), strings.HasPrefix(node, "<w:cr"), node == "</w:p>":
+			text.addSeparator('\n')
+		default:
+			addXMLText(text, xmlContent, loc[2], loc[3])
+		}
+	}
+	return text
+}
+
+// xmlEntities are the predefined XML entities
+var xmlEntities = map[string]rune{"amp": '&', "lt": '<', "gt": '>', "quot": '"', "apos": '\''}
+
+// addXMLText adds the characters of escaped XML text in content[start:end],
+// each entity reference being one character
+func addXMLText(text *sourceText, content []byte, start, end int) {
+	for i := start; i < end; {
+		if content[i] == '&' {
+			if semi := bytes.IndexByte(content[i:end], ';'); semi > 0 {
+				if r, ok := xmlEntity(string(content[i+1 : i+semi])); ok {
+					text.add(r, i, i+semi+1)
+					i += semi + 1
+					continue
+				}
+			}
+		}
+		r, size := utf8.DecodeRune(content[i:end])
+		text.add(r, i, i+size)
+		i += size
+	}
+}
+
+// xmlEntity decodes a named or numeric character reference without its & and ;
+func xmlEntity(name string) (rune, bool) {
+	if r, ok := xmlEntities[name]; ok {
+		return r, true
+	}
+	if !strings.HasPrefix(name, "#") {
+		return 0, false
+	}
+	base, digits := 10, name[1:]
+	if strings.HasPrefix(digits, "x") {
+		base, digits = 16, digits[1:]
+	}
+	n, err := strconv.ParseInt(digits, base, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// xmlEscape escapes text for an XML text node
+func xmlEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rtfSkippedDestinations are RTF groups that hold no document text
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "listtable": true, "listoverridetable": true,
+	"rsidtbl": true, "generator": true, "themedata": true, "datastore": true,
+	"latentstyles": true, "xmlnstbl": true, "filetbl": true, "revtbl": true,
+}
+
+// parseRTF extracts the text of an RTF document. Characters written as hex
+// (\'hh) or Unicode (\uN) escapes are read as one character each, and the
+// fallback character after a Unicode escape is part of that character.
+func parseRTF(content []byte) *sourceText {
+	text := &sourceText{}
+
+	type group struct {
+		skip     bool
+		skipNext int // Fallback characters per \u escape, set by \ucN
+	}
+	stack := []group{{skipNext: 1}}
+	current := func() *group { return &stack[len(stack)-1] }
+
+	for i := 0; i < len(content); {
+		switch c := content[i]; c {
+		case '{':
+			stack = append(stack, *current())
+			i++
+		case '}':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			i++
+		case '\r', '\n':
+			i++
+		case '\\':
+			start := i
+			r, word, param, end := readRTFControl(content, i)
+			i = end
+			g := current()
+			switch {
+			case word == "*" || rtfSkippedDestinations[word]:
+				g.skip = true
+			case g.skip:
+			case word == "uc":
+				g.skipNext = param
+			case word == "u":
+				if param < 0 {
+					param += 65536
+				}
+				for n := 0; n < g.skipNext && i < len(content); n++ {
+					i = skipRTFFallback(content, i)
+				}
+				text.add(rune(param), start, i)
+			case word == "par" || word == "line" || word == "sect" || word == "page" || word == "\n" || word == "\r":
+				text.addSeparator('\n')
+			case word == "tab" || word == "cell":
+				text.addSeparator('\t')
+			case r != 0:
+				text.add(r, start, i)
+			}
+		default:
+			if !current().skip {
+				text.add(rune(c), i, i+1)
+			}
+			i++
+		}
+	}
+	return text
+}
+
+// readRTFControl reads the control word or symbol at content[i], which is a
+// backslash. It returns the character the control stands for, if it is a
+// literal, and the index just past it.
+func readRTFControl(content []byte, i int) (r rune, word string, param int, end int) {
+	i++
+	if i >= len(content) {
+		return 0, "", 0, i
+	}
+
+	c := content[i]
+	if !isASCIILetter(c) {
+		switch c {
+		case '\\', '{', '}':
+			return rune(c), "", 0, i + 1
+		case '~':
+			return ' ', "", 0, i + 1
+		case '_':
+			return '-', "", 0, i + 1
+		case '\'':
+			if i+3 <= len(content) {
+				if n, err := strconv.ParseUint(string(content[i+1:i+3]), 16, 8); err == nil {
+					return rune(n), "", 0, i + 3
+				}
+			}
+			return 0, "", 0, i + 1
+		}
+		return 0, string(c), 0, i + 1
+	}
+
+	start := i
+	for i < len(content) && isASCIILetter(content[i]) {
+		i++
+	}
+	word = string(content[start:i])
+
+	paramStart := i
+	if i < len(content) && content[i] == '-' {
+		i++
+	}
+	for i < len(content) && content[i] >= '0' && content[i] <= '9' {
+		i++
+	}
+	param, _ = strconv.Atoi(string(content[paramStart:i]))
+	if i < len(content) && content[i] == ' ' {
+		i++
+	}
+	return 0, word, param, i
+}
+
+// skipRTFFallback returns the index after the fallback character at
+// content[i] that follows a Unicode escape
+func skipRTFFallback(content []byte, i int) int {
+	if content[i] != '\\' {
+		return i + 1
+	}
+	if i+1 < len(content) && content[i+1] == '\'' {
+		return min(i+4, len(content))
+	}
+	return i
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// rtfEscape escapes text for an RTF document, writing characters outside
+// ASCII as Unicode escapes with a ? fallback
+func rtfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x80:
+			b.WriteRune(r)
+		default:
+			b.WriteString(fmt.Sprintf("\\u%d?", int16(r)))
+		}
+	}
+	return b.String()
+}
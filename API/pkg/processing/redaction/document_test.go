@@ -0,0 +1,248 @@
+package redaction
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildDOCX returns a DOCX package with the given word/document.xml body and
+// a styles part that must be carried over unchanged
+func buildDOCX(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types/>`,
+		"word/styles.xml":     `<w:styles>SSN 123-45-6789 in a style name</w:styles>`,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`,
+	}
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml", "word/styles.xml"} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// readDOCXFile returns one file of a DOCX package
+func readDOCXFile(t *testing.T, docx []byte, name string) string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	require.NoError(t, err)
+	for _, f := range r.File {
+		if f.Name == name {
+			content, err := readZipFile(f, 0)
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("%s not found", name)
+	return ""
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename, contentType, want string
+	}{
+		{"motion.pdf", "application/octet-stream", FormatPDF},
+		{"Motion.DOCX", "", FormatDOCX},
+		{"order.rtf", "", FormatRTF},
+		{"upload", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", FormatDOCX},
+		{"upload", "text/rtf; charset=us-ascii", FormatRTF},
+	}
+	for _, tt := range tests {
+		format, err := DetectFormat(tt.filename, tt.contentType)
+		require.NoError(t, err, tt.filename)
+		assert.Equal(t, tt.want, format, tt.filename)
+	}
+
+	_, err := DetectFormat("brief.doc", "application/msword")
+	var unsupported *UnsupportedFormatError
+	require.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, "doc", unsupported.Format)
+	assert.Contains(t, err.Error(), "PDF, DOCX and RTF")
+}
+
+func TestRedactDocument_DOCX(t *testing.T) {
+	// The SSN is split across runs and the email has an escaped character
+	body := `<w:p><w:r><w:t>Defendant SSN 123-</w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">45-6789 on file.</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Contact j&amp;doe@example.com</w:t></w:r></w:p>`
+	docx := buildDOCX(t, body)
+	svc := NewService(false, "")
+	options := &Options{CaliforniaLaws: true, ReplacementChar: "X", PreserveFormat: true}
+
+	analysis, err := svc.AnalyzeDocument(context.Background(), bytes.NewReader(docx), FormatDOCX, options)
+	require.NoError(t, err)
+	require.True(t, analysis.Success)
+	var types []string
+	for _, item := range analysis.Redactions {
+		types = append(types, item.Type)
+		assert.False(t, item.Applied)
+	}
+	assert.Contains(t, types, "ssn")
+	assert.Contains(t, types, "email")
+
+	result, err := svc.RedactDocument(context.Background(), bytes.NewReader(docx), FormatDOCX, options)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, FormatDOCX, result.Format)
+	assert.Empty(t, result.PDFBase64)
+	assert.NotEmpty(t, result.DocumentBase64)
+	for _, item := range result.Redactions {
+		assert.True(t, item.Applied)
+	}
+
+	document := readDOCXFile(t, result.RedactedDocument, "word/document.xml")
+	assert.NotContains(t, document, "6789")
+	assert.NotContains(t, document, "example.com")
+	assert.Contains(t, document, `<w:t>Defendant SSN XXXX</w:t>`)
+	assert.Contains(t, document, `<w:t xml:space="preserve">XXXXXXX on file.</w:t>`)
+	assert.Contains(t, document, `<w:rPr><w:b/></w:rPr>`, "formatting is kept")
+	assert.Equal(t, readDOCXFile(t, docx, "word/styles.xml"), readDOCXFile(t, result.RedactedDocument, "word/styles.xml"))
+}
+
+func TestRedactDocument_ReplacementRules(t *testing.T) {
+	body := `<w:p><w:r><w:t>Defendant SSN 123-</w:t></w:r><w:r><w:t xml:space="preserve">45-6789 on file.</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Contact jdoe@example.com or 555-123-4567</w:t></w:r></w:p>`
+	options := &Options{
+		CaliforniaLaws:  true,
+		ReplacementChar: "#",
+		PreserveFormat:  true,
+		ReplacementRules: map[string]ReplacementStyle{
+			"ssn":   {Mode: ReplacementPartial},
+			"email": {Mode: ReplacementLabel},
+		},
+	}
+	svc := NewService(false, "")
+
+	result, err := svc.RedactDocument(context.Background(), bytes.NewReader(buildDOCX(t, body)), FormatDOCX, options)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	// The partial mask runs across both runs of the SSN; the phone number
+	// has no rule and is blocked out with the replacement character
+	document := readDOCXFile(t, result.RedactedDocument, "word/document.xml")
+	assert.Contains(t, document, `<w:t>Defendant SSN XXX-</w:t>`)
+	assert.Contains(t, document, `<w:t xml:space="preserve">XX-6789 on file.</w:t>`)
+	assert.Contains(t, document, `<w:t>Contact [REDACTED-EMAIL] or ############</w:t>`)
+
+	// Converted to PDF, blocked out text is drawn as boxes and the rest written out
+	options.PreserveFormat = false
+	options.ReplacementRules["ssn"] = ReplacementStyle{Mode: ReplacementLabel, Label: "[SSN]"}
+	result, err = svc.RedactDocument(context.Background(), bytes.NewReader(buildDOCX(t, body)), FormatDOCX, options)
+	require.NoError(t, err)
+	assert.Contains(t, string(result.RedactedPDF), "(Defendant SSN [SSN] on file.) Tj")
+	assert.Contains(t, string(result.RedactedPDF), "(Contact [REDACTED-EMAIL] or             ) Tj")
+}
+
+func TestReplacementStyle(t *testing.T) {
+	options := &Options{ReplacementRules: map[string]ReplacementStyle{
+		"ssn":           {Mode: ReplacementPartial},
+		"credit_card":   {Mode: ReplacementPartial, Char: "*", Visible: 2},
+		"date_of_birth": {Mode: ReplacementLabel},
+		"phone":         {Mode: ReplacementBlock, Char: "X"},
+	}}
+	apply := func(redactionType, text string) string {
+		redacted, _ := redactedText(text, replacementStyle(options, redactionType).edits(text))
+		return redacted
+	}
+
+	assert.Equal(t, "XXX-XX-1234", apply("ssn", "123-45-1234"))
+	assert.Equal(t, "**** **** **** **21", apply("credit_card", "4111 1111 1111 1121"))
+	assert.Equal(t, "[REDACTED-DATE_OF_BIRTH]", apply("date_of_birth", "01/02/1990"))
+
+	// Blocked out characters stay in the text, flagged for boxes
+	redacted, masked := redactedText("555-1234", replacementStyle(options, "phone").edits("555-1234"))
+	assert.Equal(t, "555-1234", redacted)
+	assert.Equal(t, []bool{true, true, true, true, true, true, true, true}, masked)
+	assert.Equal(t, ReplacementStyle{Mode: ReplacementBlock, Char: "■"}, replacementStyle(options, "email"))
+
+	require.NoError(t, ValidateReplacementRules(options.ReplacementRules))
+	assert.Error(t, ValidateReplacementRules(map[string]ReplacementStyle{"ssn": {Mode: "hash"}}))
+	assert.Error(t, ValidateReplacementRules(map[string]ReplacementStyle{"ssn": {Mode: ReplacementBlock, Char: "XX"}}))
+	assert.Error(t, ValidateReplacementRules(map[string]ReplacementStyle{"ssn": {Mode: ReplacementPartial, Visible: -1}}))
+}
+
+func TestRedactDocument_RTF(t *testing.T) {
+	rtf := `{\rtf1\ansi\deff0{\fonttbl{\f0 Times 555-123-4567;}}` + "\n" +
+		`{\*\generator Writer 123-45-6789;}\f0 Victim phone 555-123-4567\par` + "\n" +
+		`SSN 123-45-6789 caf\'e9 na\u239?ve end}`
+	svc := NewService(false, "")
+
+	result, err := svc.RedactDocument(context.Background(), bytes.NewReader([]byte(rtf)), FormatRTF,
+		&Options{CaliforniaLaws: true, ReplacementChar: "■", PreserveFormat: true})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, FormatRTF, result.Format)
+
+	var texts []string
+	for _, item := range result.Redactions {
+		texts = append(texts, item.Text)
+	}
+	assert.Contains(t, texts, "555-123-4567")
+	assert.Contains(t, texts, "123-45-6789")
+
+	redacted := string(result.RedactedDocument)
+	assert.Contains(t, redacted, `Victim phone `+strings.Repeat(rtfEscape("■"), 12)+`\par`)
+	assert.Contains(t, redacted, `SSN `+strings.Repeat(rtfEscape("■"), 11)+` caf`)
+	assert.Contains(t, redacted, `{\fonttbl{\f0 Times 555-123-4567;}}`, "destinations that are not text are left alone")
+	assert.Contains(t, redacted, `{\*\generator Writer 123-45-6789;}`)
+	assert.Contains(t, redacted, `caf\'e9 na\u239?ve end}`)
+}
+
+func TestRedactDocument_ConvertsToPDF(t *testing.T) {
+	docx := buildDOCX(t, `<w:p><w:r><w:t>SSN 123-45-6789 (redacted)</w:t></w:r></w:p>`)
+
+	result, err := NewService(false, "").RedactDocument(context.Background(), bytes.NewReader(docx), FormatDOCX,
+		&Options{CaliforniaLaws: true})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	assert.Equal(t, FormatPDF, result.Format)
+	assert.Empty(t, result.RedactedDocument)
+
+	pdf := result.RedactedPDF
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, 1, CountPages(pdf))
+	assert.NotContains(t, string(pdf), "6789")
+	assert.Contains(t, string(pdf), `(SSN             \(redacted\)) Tj`)
+	assert.Contains(t, string(pdf), " re f\n", "masked text is covered by a box")
+}
+
+func TestRedactDocument_RejectsUnsupportedFormats(t *testing.T) {
+	svc := NewService(false, "")
+
+	_, err := svc.RedactDocument(context.Background(), bytes.NewReader([]byte("text")), "doc", &Options{})
+	var unsupported *UnsupportedFormatError
+	assert.True(t, errors.As(err, &unsupported))
+
+	_, err = svc.AnalyzeDocument(context.Background(), bytes.NewReader([]byte("not a zip")), FormatDOCX, &Options{})
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+	assert.ErrorContains(t, err, "not a DOCX package")
+
+	_, err = svc.AnalyzeDocument(context.Background(), io.LimitReader(bytes.NewReader([]byte("plain")), 5), FormatRTF, &Options{})
+	assert.ErrorIs(t, err, ErrInvalidDocument)
+}
+
+func TestAnalyzeDocument_DOCXBombIsRejected(t *testing.T) {
+	// Well under the file size limit compressed, far over it unpacked
+	docx := buildDOCX(t, `<w:p><w:r><w:t>`+strings.Repeat(" ", 4<<20)+`</w:t></w:r></w:p>`)
+	svc := NewServiceWithLimits(false, "", Limits{MaxFileSize: 64 << 10})
+	require.Less(t, len(docx), 64<<10)
+
+	_, err := svc.AnalyzeDocument(context.Background(), bytes.NewReader(docx), FormatDOCX, &Options{})
+	var limitErr *LimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "uncompressed_size", limitErr.Limit)
+	assert.Contains(t, err.Error(), "unpacks to at least")
+}
@@ -15,6 +15,13 @@ type Service interface {
 	
 	// ApplyCustomRedactions applies custom redactions to a PDF
 	ApplyCustomRedactions(ctx context.Context, pdfData io.Reader, redactions []RedactionItem) (*Result, error)
+
+	// RedactDocument redacts a document in one of SupportedFormats
+	RedactDocument(ctx context.Context, data io.Reader, format string, options *Options) (*Result, error)
+
+	// AnalyzeDocument analyzes a document in one of SupportedFormats for
+	// potential redactions without applying them
+	AnalyzeDocument(ctx context.Context, data io.Reader, format string, options *Options) (*AnalysisResult, error)
 }
 
 // Options configures redaction behavior
@@ -24,14 +31,29 @@ type Options struct {
 	IncludePatterns  []string `json:"include_patterns,omitempty"`
 	ExcludePatterns  []string `json:"exclude_patterns,omitempty"`
 	ReplacementChar  string   `json:"replacement_char"`
+
+	// ReplacementRules replaces the matches of a redaction type, such as
+	// "ssn" or "date_of_birth", in their own style; types without a rule
+	// are blocked out with ReplacementChar
+	ReplacementRules map[string]ReplacementStyle `json:"replacement_rules,omitempty"`
+
+	// PreserveFormat returns redacted DOCX and RTF files in their own format
+	// instead of converting them to PDF
+	PreserveFormat bool `json:"preserve_format,omitempty"`
 }
 
+// CoordinateSpacePDFPoints is the coordinate space of RedactionItem.BBox:
+// PDF user space units (1/72 inch) with the origin at the bottom-left corner
+// of the page, as [x0, y0, x1, y1]. Pages are numbered from 1; page 0 means
+// the position could not be determined.
+const CoordinateSpacePDFPoints = "pdf_points"
+
 // RedactionItem represents a single redaction
 type RedactionItem struct {
 	ID          string    `json:"id"`
 	Page        int       `json:"page"`
 	Text        string    `json:"text"`
-	BBox        []float64 `json:"bbox"` // [x0, y0, x1, y1]
+	BBox        []float64 `json:"bbox"` // [x0, y0, x1, y1] in CoordinateSpacePDFPoints
 	Type        string    `json:"type"`
 	Citation    string    `json:"citation,omitempty"`
 	Reason      string    `json:"reason,omitempty"`
@@ -47,6 +69,11 @@ type Result struct {
 	TotalCount   int             `json:"total_count"`
 	Success      bool            `json:"success"`
 	Error        string          `json:"error,omitempty"`
+
+	// Set for DOCX and RTF documents redacted with PreserveFormat
+	RedactedDocument []byte `json:"-"`
+	DocumentBase64   string `json:"document_base64,omitempty"`
+	Format           string `json:"format,omitempty"` // Format of the redacted output
 }
 
 // AnalysisResult represents the result of redaction analysis
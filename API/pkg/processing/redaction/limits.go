@@ -0,0 +1,73 @@
+package redaction
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/dslipak/pdf"
+)
+
+// Limits bounds the documents a redaction service will analyze. Zero values
+// disable the corresponding limit.
+type Limits struct {
+	MaxPages    int   // Maximum page count
+	MaxFileSize int64 // Maximum file size in bytes
+}
+
+// LimitError is returned when a document exceeds a configured limit
+type LimitError struct {
+	Limit  string // "pages", "file_size" or "uncompressed_size"
+	Max    int64
+	Actual int64
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "pages":
+		return fmt.Sprintf("document has %d pages, exceeding the redaction limit of %d pages", e.Actual, e.Max)
+	case "uncompressed_size":
+		return fmt.Sprintf("document unpacks to at least %d bytes, exceeding the redaction limit of %d bytes", e.Actual, e.Max)
+	}
+	return fmt.Sprintf("document is %d bytes, exceeding the redaction limit of %d bytes", e.Actual, e.Max)
+}
+
+// pageObjectPattern matches page objects but not the /Pages tree nodes
+var pageObjectPattern = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// CountPages returns the number of pages in a PDF. Files the PDF reader
+// cannot open are counted by scanning for page objects instead.
+func CountPages(pdfBytes []byte) int {
+	if n := readerPageCount(pdfBytes); n > 0 {
+		return n
+	}
+	return len(pageObjectPattern.FindAll(pdfBytes, -1))
+}
+
+// readerPageCount counts pages with the PDF reader, returning 0 when the file
+// cannot be parsed. The reader panics on some malformed input.
+func readerPageCount(pdfBytes []byte) (n int) {
+	defer func() {
+		if recover() != nil {
+			n = 0
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(pdfBytes), int64(len(pdfBytes)))
+	if err != nil {
+		return 0
+	}
+	return r.NumPage()
+}
+
+// Check returns a *LimitError if the PDF is too large to analyze
+func (l Limits) Check(pdfBytes []byte) error {
+	if l.MaxFileSize > 0 && int64(len(pdfBytes)) > l.MaxFileSize {
+		return &LimitError{Limit: "file_size", Max: l.MaxFileSize, Actual: int64(len(pdfBytes))}
+	}
+	if l.MaxPages > 0 {
+		if pages := CountPages(pdfBytes); pages > l.MaxPages {
+			return &LimitError{Limit: "pages", Max: int64(l.MaxPages), Actual: int64(pages)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package redaction
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Replacement modes of a ReplacementStyle
+const (
+	// ReplacementBlock replaces every character of the match, e.g. ■■■■■■■■■■■
+	ReplacementBlock = "block"
+	// ReplacementPartial replaces the letters and digits of the match except
+	// the last few, keeping separators, e.g. XXX-XX-1234
+	ReplacementPartial = "partial"
+	// ReplacementLabel replaces the whole match with a label, e.g. [REDACTED-SSN]
+	ReplacementLabel = "label"
+)
+
+// defaultPartialVisible is how many trailing letters and digits a partial
+// mask leaves visible when the style does not say
+const defaultPartialVisible = 4
+
+// ReplacementStyle is how the matches of one redaction type are replaced
+type ReplacementStyle struct {
+	Mode    string `json:"mode"`              // ReplacementBlock, ReplacementPartial or ReplacementLabel
+	Char    string `json:"char,omitempty"`    // Masking character; block defaults to Options.ReplacementChar, partial to "X"
+	Visible int    `json:"visible,omitempty"` // Partial: trailing letters and digits left visible, default 4
+	Label   string `json:"label,omitempty"`   // Label: replacement text, default "[REDACTED-<TYPE>]"
+}
+
+// ValidateReplacementRules checks the replacement rules of Options
+func ValidateReplacementRules(rules map[string]ReplacementStyle) error {
+	for redactionType, style := range rules {
+		switch style.Mode {
+		case ReplacementBlock, ReplacementPartial, ReplacementLabel:
+		default:
+			return fmt.Errorf("replacement rule for %q: mode must be %s, %s or %s", redactionType, ReplacementBlock, ReplacementPartial, ReplacementLabel)
+		}
+		if utf8.RuneCountInString(style.Char) > 1 {
+			return fmt.Errorf("replacement rule for %q: char must be a single character", redactionType)
+		}
+		if style.Visible < 0 {
+			return fmt.Errorf("replacement rule for %q: visible cannot be negative", redactionType)
+		}
+	}
+	return nil
+}
+
+// replacementStyle returns the style for matches of redactionType: its rule,
+// or a block of options.ReplacementChar when there is none
+func replacementStyle(options *Options, redactionType string) ReplacementStyle {
+	fallback := "■"
+	if options != nil && options.ReplacementChar != "" {
+		fallback = options.ReplacementChar
+	}
+	if options == nil {
+		return ReplacementStyle{Mode: ReplacementBlock, Char: fallback}
+	}
+	style, ok := options.ReplacementRules[redactionType]
+	if !ok {
+		return ReplacementStyle{Mode: ReplacementBlock, Char: fallback}
+	}
+
+	switch style.Mode {
+	case ReplacementPartial:
+		if style.Char == "" {
+			style.Char = "X"
+		}
+		if style.Visible == 0 {
+			style.Visible = defaultPartialVisible
+		}
+	case ReplacementLabel:
+		if style.Label == "" {
+			style.Label = "[REDACTED-" + strings.ToUpper(redactionType) + "]"
+		}
+	default:
+		style.Mode = ReplacementBlock
+		if style.Char == "" {
+			style.Char = fallback
+		}
+	}
+	return style
+}
+
+// runeEdit is what becomes of one character of redacted text
+type runeEdit struct {
+	replaced bool   // The character is replaced with text
+	text     string // Replacement; empty removes the character
+	block    bool   // The character is blocked out rather than rewritten
+}
+
+// edits returns the edit of each character of match, which is the matched text
+func (s ReplacementStyle) edits(match string) []runeEdit {
+	runes := []rune(match)
+	edits := make([]runeEdit, len(runes))
+	switch s.Mode {
+	case ReplacementLabel:
+		for i := range edits {
+			edits[i].replaced = true
+		}
+		if len(edits) > 0 {
+			edits[0].text = s.Label
+		}
+	case ReplacementPartial:
+		visible := s.Visible
+		for i := len(runes) - 1; i >= 0; i-- {
+			if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+				continue
+			}
+			if visible > 0 {
+				visible--
+				continue
+			}
+			edits[i] = runeEdit{replaced: true, text: s.Char}
+		}
+	default:
+		for i := range edits {
+			edits[i] = runeEdit{replaced: true, text: s.Char, block: true}
+		}
+	}
+	return edits
+}
+
+// redactedText applies edits, one per character of text, for rendering as a
+// text PDF. Blocked out characters are kept and flagged so they are drawn as
+// boxes; other replacements are written out as text.
+func redactedText(text string, edits []runeEdit) (string, []bool) {
+	var out strings.Builder
+	var masked []bool
+	i := 0
+	for _, r := range text {
+		edit := runeEdit{}
+		if i < len(edits) {
+			edit = edits[i]
+		}
+		i++
+		switch {
+		case !edit.replaced:
+			out.WriteRune(r)
+			masked = append(masked, false)
+		case edit.block:
+			out.WriteRune(r)
+			masked = append(masked, true)
+		default:
+			for _, replacement := range edit.text {
+				out.WriteRune(replacement)
+				masked = append(masked, false)
+			}
+		}
+	}
+	return out.String(), masked
+}
@@ -0,0 +1,86 @@
+package redaction
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// scrubMask replaces each character of scrubbed text, as in redacted PDFs
+const scrubMask = "■"
+
+// scrubPatterns is CaliforniaPatterns compiled once for ScrubText
+var scrubPatterns = sync.OnceValue(func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(CaliforniaPatterns))
+	for _, pattern := range CaliforniaPatterns {
+		if regex, err := regexp.Compile(pattern.Pattern); err == nil {
+			patterns = append(patterns, regex)
+		}
+	}
+	return patterns
+})
+
+// ScrubText masks every match of the California redaction patterns in text.
+// Occurrences of the given markup tags, such as search highlight tags, are
+// kept and ignored while matching, so a number split by tags is still found.
+func ScrubText(text string, tags ...string) string {
+	// Match against the text with the tags taken out
+	var plain strings.Builder
+	forEachSegment(text, tags, func(segment string, isTag bool) {
+		if !isTag {
+			plain.WriteString(segment)
+		}
+	})
+
+	masked := make([]bool, plain.Len())
+	found := false
+	for _, regex := range scrubPatterns() {
+		for _, match := range regex.FindAllStringIndex(plain.String(), -1) {
+			for i := match[0]; i < match[1]; i++ {
+				masked[i] = true
+			}
+			found = true
+		}
+	}
+	if !found {
+		return text
+	}
+
+	var out strings.Builder
+	offset := 0
+	forEachSegment(text, tags, func(segment string, isTag bool) {
+		if isTag {
+			out.WriteString(segment)
+			return
+		}
+		if masked[offset] {
+			out.WriteString(scrubMask)
+		} else {
+			out.WriteString(segment)
+		}
+		offset += len(segment)
+	})
+	return out.String()
+}
+
+// forEachSegment walks text one tag or one character at a time
+func forEachSegment(text string, tags []string, fn func(segment string, isTag bool)) {
+	for i := 0; i < len(text); {
+		tag := ""
+		for _, t := range tags {
+			if t != "" && strings.HasPrefix(text[i:], t) {
+				tag = t
+				break
+			}
+		}
+		if tag != "" {
+			fn(tag, true)
+			i += len(tag)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		fn(text[i:i+size], false)
+		i += size
+	}
+}
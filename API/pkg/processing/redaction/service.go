@@ -1,25 +1,38 @@
 package redaction
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
-	"regexp"
+	"sort"
 	"strings"
+
+	"motion-index-fiber/pkg/processing/extractor"
 )
 
 // service implements the Service interface
 type service struct {
 	aiEnabled bool
 	openaiKey string
+	limits    Limits
+	layout    extractor.Service // Reads PDF pages with the positions of their words
 }
 
 // NewService creates a new redaction service
 func NewService(aiEnabled bool, openaiKey string) Service {
+	return NewServiceWithLimits(aiEnabled, openaiKey, Limits{})
+}
+
+// NewServiceWithLimits creates a redaction service that rejects documents
+// over the given limits with a *LimitError
+func NewServiceWithLimits(aiEnabled bool, openaiKey string, limits Limits) Service {
 	return &service{
 		aiEnabled: aiEnabled,
 		openaiKey: openaiKey,
+		limits:    limits,
+		layout:    extractor.NewServiceWithOptions(extractor.ExtractOptions{IncludeLayout: true}),
 	}
 }
 
@@ -119,22 +132,29 @@ var CaliforniaPatterns = []RedactionPattern{
 	},
 }
 
-// RedactPDF redacts a PDF document and returns the redacted PDF and metadata
+// ErrPDFRedactionNotImplemented is returned when redactions would have to be
+// drawn into a PDF, which needs a PDF writer the service does not have yet.
+// PDFs can still be analyzed, and redacted through overlay output.
+var ErrPDFRedactionNotImplemented = errors.New("applying redactions to a PDF is not implemented")
+
+// RedactPDF redacts a PDF document and returns the redacted PDF and metadata.
+// It currently checks the PDF against the limits and returns
+// ErrPDFRedactionNotImplemented.
 func (s *service) RedactPDF(ctx context.Context, pdfData io.Reader, options *Options) (*Result, error) {
-	// For now, return a placeholder implementation
-	// In a full implementation, this would:
-	// 1. Extract text from PDF with position information
-	// 2. Find patterns to redact using regex and optionally AI
-	// 3. Apply redactions to the PDF
-	// 4. Return the redacted PDF as base64
+	pdfBytes, err := io.ReadAll(pdfData)
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read PDF data: %v", err),
+		}, nil
+	}
+	if err := s.limits.Check(pdfBytes); err != nil {
+		return nil, err
+	}
 
-	return &Result{
-		Success:     false,
-		PDFBase64:   "",
-		Redactions:  []RedactionItem{},
-		TotalCount:  0,
-		Error:       "PDF redaction is not yet fully implemented - requires PDF processing library",
-	}, nil
+	// Drawing the boxes located by AnalyzePDF over the page content needs a
+	// PDF writer
+	return nil, ErrPDFRedactionNotImplemented
 }
 
 // AnalyzePDF analyzes a PDF for potential redactions without applying them
@@ -147,69 +167,26 @@ func (s *service) AnalyzePDF(ctx context.Context, pdfData io.Reader, options *Op
 			Error:   fmt.Sprintf("Failed to read PDF data: %v", err),
 		}, nil
 	}
-
-	// For demonstration, convert to string and analyze text patterns
-	// In a real implementation, this would use a PDF library to extract positioned text
-	pdfText := string(pdfBytes)
-	
-	var redactions []RedactionItem
-	redactionID := 0
-
-	// Apply California patterns if enabled
-	if options != nil && options.CaliforniaLaws {
-		for _, pattern := range CaliforniaPatterns {
-			regex, err := regexp.Compile(pattern.Pattern)
-			if err != nil {
-				continue
-			}
-
-			matches := regex.FindAllStringSubmatch(pdfText, -1)
-			for _, match := range matches {
-				if len(match) > 0 {
-					redactionID++
-					redactions = append(redactions, RedactionItem{
-						ID:        fmt.Sprintf("redaction_%d", redactionID),
-						Page:      0, // Would be calculated from PDF position
-						Text:      match[0],
-						BBox:      []float64{0, 0, 100, 20}, // Would be calculated from PDF position
-						Type:      pattern.Name,
-						Citation:  pattern.Citation.Description,
-						Reason:    pattern.Reason,
-						LegalCode: pattern.Citation.Code,
-						Applied:   false,
-					})
-				}
-			}
-		}
+	if err := s.limits.Check(pdfBytes); err != nil {
+		return nil, err
 	}
 
-	// Apply custom patterns if provided
-	if options != nil && len(options.IncludePatterns) > 0 {
-		for _, customPattern := range options.IncludePatterns {
-			regex, err := regexp.Compile(customPattern)
-			if err != nil {
-				continue
-			}
-
-			matches := regex.FindAllStringSubmatch(pdfText, -1)
-			for _, match := range matches {
-				if len(match) > 0 {
-					redactionID++
-					redactions = append(redactions, RedactionItem{
-						ID:        fmt.Sprintf("custom_redaction_%d", redactionID),
-						Page:      0,
-						Text:      match[0],
-						BBox:      []float64{0, 0, 100, 20},
-						Type:      "custom_pattern",
-						Citation:  "Custom Pattern",
-						Reason:    "Matches custom redaction pattern",
-						LegalCode: "CUSTOM",
-						Applied:   false,
-					})
-				}
-			}
+	// Apply California patterns if enabled, then custom patterns, to the text
+	// of each page so matches can be placed over the words they cover. A PDF
+	// without a readable text layer is scanned as it is, and its matches have
+	// no position.
+	var redactions []RedactionItem
+	if pages := s.pageLayout(ctx, pdfBytes); pages != nil {
+		redactions = locateMatches(pages, options)
+	} else {
+		for _, match := range findMatches(string(pdfBytes), options) {
+			item := match.item
+			item.Page = 0
+			item.BBox = unknownBBox()
+			redactions = append(redactions, item)
 		}
 	}
+	redactionID := len(redactions)
 
 	// TODO: Add AI-powered redaction detection if enabled and API key is available
 	if s.aiEnabled && s.openaiKey != "" && options != nil && options.UseAI {
@@ -220,7 +197,7 @@ func (s *service) AnalyzePDF(ctx context.Context, pdfData io.Reader, options *Op
 			ID:        fmt.Sprintf("ai_redaction_%d", redactionID),
 			Page:      0,
 			Text:      "[AI-detected sensitive info]",
-			BBox:      []float64{0, 0, 100, 20},
+			BBox:      unknownBBox(),
 			Type:      "ai_identified",
 			Citation:  "AI Analysis",
 			Reason:    "AI identified as potentially sensitive information",
@@ -236,9 +213,82 @@ func (s *service) AnalyzePDF(ctx context.Context, pdfData io.Reader, options *Op
 	}, nil
 }
 
-// ApplyCustomRedactions applies custom redactions to a PDF
+// pageLayout returns the text of each page of a PDF with the positions of its
+// words, or nil when the PDF has no text layer that can be read
+func (s *service) pageLayout(ctx context.Context, pdfBytes []byte) []extractor.PageText {
+	result, err := s.layout.ExtractText(ctx, bytes.NewReader(pdfBytes), &extractor.DocumentMetadata{
+		FileName: "document.pdf",
+		Format:   "pdf",
+		Size:     int64(len(pdfBytes)),
+	})
+	if err != nil || result == nil {
+		return nil
+	}
+	for _, page := range result.Pages {
+		if len(page.Tokens) > 0 {
+			return result.Pages
+		}
+	}
+	return nil
+}
+
+// locateMatches finds the matches of the patterns enabled by options in the
+// text of pages, and places each on the page where it starts, bounded by the
+// words it covers there
+func locateMatches(pages []extractor.PageText, options *Options) []RedactionItem {
+	var text strings.Builder
+	starts := make([]int, len(pages))
+	for i, page := range pages {
+		if i > 0 {
+			text.WriteByte('\n')
+		}
+		starts[i] = text.Len()
+		text.WriteString(page.Text)
+	}
+
+	var redactions []RedactionItem
+	for _, match := range findMatches(text.String(), options) {
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > match.start }) - 1
+		page := &pages[i]
+
+		item := match.item
+		item.Page = page.Number
+		item.BBox = tokenBounds(page.TokensInRange(match.start-starts[i], match.end-starts[i]))
+		if item.BBox == nil {
+			item.Page = 0
+			item.BBox = unknownBBox()
+		}
+		redactions = append(redactions, item)
+	}
+	return redactions
+}
+
+// tokenBounds returns the smallest box containing every token, or nil when
+// there are none
+func tokenBounds(tokens []extractor.TextToken) []float64 {
+	if len(tokens) == 0 {
+		return nil
+	}
+	bbox := tokens[0].BBox
+	for _, token := range tokens[1:] {
+		bbox[0] = min(bbox[0], token.BBox[0])
+		bbox[1] = min(bbox[1], token.BBox[1])
+		bbox[2] = max(bbox[2], token.BBox[2])
+		bbox[3] = max(bbox[3], token.BBox[3])
+	}
+	return bbox[:]
+}
+
+// unknownBBox is the box given to a redaction whose position could not be
+// determined, on page 0
+func unknownBBox() []float64 {
+	return []float64{0, 0, 100, 20}
+}
+
+// ApplyCustomRedactions applies custom redactions to a PDF. Like RedactPDF it
+// currently returns ErrPDFRedactionNotImplemented, rather than the original
+// PDF with the redactions marked applied.
 func (s *service) ApplyCustomRedactions(ctx context.Context, pdfData io.Reader, redactions []RedactionItem) (*Result, error) {
-	// Read PDF data
 	pdfBytes, err := io.ReadAll(pdfData)
 	if err != nil {
 		return &Result{
@@ -246,25 +296,10 @@ func (s *service) ApplyCustomRedactions(ctx context.Context, pdfData io.Reader,
 			Error:   fmt.Sprintf("Failed to read PDF data: %v", err),
 		}, nil
 	}
-
-	// For now, return the original PDF as base64 with metadata
-	// In a full implementation, this would apply the redactions to the PDF
-	encodedPDF := base64.StdEncoding.EncodeToString(pdfBytes)
-
-	// Mark all redactions as applied
-	appliedRedactions := make([]RedactionItem, len(redactions))
-	for i, redaction := range redactions {
-		appliedRedactions[i] = redaction
-		appliedRedactions[i].Applied = true
+	if err := s.limits.Check(pdfBytes); err != nil {
+		return nil, err
 	}
-
-	return &Result{
-		RedactedPDF: pdfBytes,
-		PDFBase64:   encodedPDF,
-		Redactions:  appliedRedactions,
-		TotalCount:  len(appliedRedactions),
-		Success:     true,
-	}, nil
+	return nil, ErrPDFRedactionNotImplemented
 }
 
 // getReplacementText returns the replacement text for redacted content
@@ -0,0 +1,36 @@
+package redaction
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePDF_PlacesMatchesOverTheirWords(t *testing.T) {
+	// Second line of the first page: the baseline is at 698 and the number
+	// starts after 14 Courier characters of 6pt
+	pdf := renderTextPDF("People v. Doe\nDefendant SSN 123-45-6789", nil)
+
+	result, err := NewService(false, "").AnalyzePDF(context.Background(), bytes.NewReader(pdf), &Options{CaliforniaLaws: true})
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.Len(t, result.Redactions, 1)
+
+	item := result.Redactions[0]
+	assert.Equal(t, "123-45-6789", item.Text)
+	assert.Equal(t, 1, item.Page)
+	assert.Equal(t, []float64{156, 696, 222, 706}, item.BBox)
+}
+
+func TestAnalyzePDF_WithoutTextLayerHasNoPosition(t *testing.T) {
+	pdf := []byte("%PDF-1.4\nSSN 123-45-6789\n%%EOF\n")
+
+	result, err := NewService(false, "").AnalyzePDF(context.Background(), bytes.NewReader(pdf), &Options{CaliforniaLaws: true})
+	require.NoError(t, err)
+	require.Len(t, result.Redactions, 1)
+	assert.Equal(t, 0, result.Redactions[0].Page)
+	assert.Equal(t, "123-45-6789", result.Redactions[0].Text)
+}
@@ -0,0 +1,162 @@
+package redaction
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Layout of PDFs rendered from document text: US Letter pages with one-inch
+// margins, set in 10pt Courier so every character has the same width
+const (
+	textPDFPageWidth    = 612.0
+	textPDFPageHeight   = 792.0
+	textPDFMargin       = 72.0
+	textPDFFontSize     = 10.0
+	textPDFCharWidth    = 6.0 // Courier glyphs are 600/1000 em wide
+	textPDFLineHeight   = 12.0
+	textPDFLineLength   = int((textPDFPageWidth - 2*textPDFMargin) / textPDFCharWidth)
+	textPDFLinesPerPage = int((textPDFPageHeight - 2*textPDFMargin) / textPDFLineHeight)
+)
+
+// textCell is one character position of a rendered line
+type textCell struct {
+	r      rune
+	masked bool
+}
+
+// renderTextPDF lays out text as a PDF, drawing a black box in place of each
+// masked character. masked holds one flag per rune of text. The masked
+// characters themselves are not written to the file.
+func renderTextPDF(text string, masked []bool) []byte {
+	var lines [][]textCell
+	var line []textCell
+	i := 0
+	for _, r := range text {
+		isMasked := i < len(masked) && masked[i]
+		i++
+		switch r {
+		case '\n':
+			lines = append(lines, line)
+			line = nil
+			continue
+		case '\r':
+			continue
+		case '\t':
+			for n := 0; n < 4; n++ {
+				line = append(line, textCell{r: ' ', masked: isMasked})
+			}
+			continue
+		}
+		line = append(line, textCell{r: r, masked: isMasked})
+	}
+	lines = append(lines, line)
+
+	var wrapped [][]textCell
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapTextLine(line, textPDFLineLength)...)
+	}
+
+	var pages []string
+	for start := 0; start < len(wrapped); start += textPDFLinesPerPage {
+		end := min(start+textPDFLinesPerPage, len(wrapped))
+		pages = append(pages, textPageContent(wrapped[start:end]))
+	}
+
+	return writeTextPDF(pages)
+}
+
+// wrapTextLine breaks a line into lines of at most width characters, at the
+// last space where there is one
+func wrapTextLine(line []textCell, width int) [][]textCell {
+	var lines [][]textCell
+	for len(line) > width {
+		cut := width
+		for j := width; j > 0; j-- {
+			if line[j].r == ' ' {
+				cut = j
+				break
+			}
+		}
+		lines = append(lines, line[:cut])
+		line = line[cut:]
+		if len(line) > 0 && line[0].r == ' ' && !line[0].masked {
+			line = line[1:]
+		}
+	}
+	return append(lines, line)
+}
+
+// textPageContent returns the content stream for one page of lines
+func textPageContent(lines [][]textCell) string {
+	var text, boxes strings.Builder
+	text.WriteString(fmt.Sprintf("BT /F1 %g Tf %g TL %g %g Td\n", textPDFFontSize, textPDFLineHeight, textPDFMargin, textPDFPageHeight-textPDFMargin-textPDFFontSize))
+	for n, line := range lines {
+		y := textPDFPageHeight - textPDFMargin - textPDFFontSize - float64(n)*textPDFLineHeight
+
+		var out strings.Builder
+		for col := 0; col < len(line); col++ {
+			if !line[col].masked {
+				out.WriteString(pdfEscapeRune(line[col].r))
+				continue
+			}
+			run := col
+			for col+1 < len(line) && line[col+1].masked {
+				col++
+			}
+			out.WriteString(strings.Repeat(" ", col-run+1))
+			boxes.WriteString(fmt.Sprintf("%g %g %g %g re f\n",
+				textPDFMargin+float64(run)*textPDFCharWidth, y-2, float64(col-run+1)*textPDFCharWidth, textPDFLineHeight))
+		}
+		text.WriteString("(" + out.String() + ") Tj T*\n")
+	}
+	text.WriteString("ET\n")
+	return text.String() + boxes.String()
+}
+
+// pdfEscapeRune writes a character for a WinAnsi-encoded PDF string,
+// replacing characters the encoding cannot show with ?
+func pdfEscapeRune(r rune) string {
+	switch {
+	case r == '(' || r == ')' || r == '\\':
+		return "\\" + string(r)
+	case r >= 0x20 && r < 0x7f:
+		return string(r)
+	case r >= 0xa0 && r <= 0xff:
+		return fmt.Sprintf("\\%03o", r)
+	}
+	return "?"
+}
+
+// writeTextPDF writes a PDF with one page per content stream
+func writeTextPDF(pages []string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding /FirstChar 32 /LastChar 255 /Widths [%s] >>",
+		strings.TrimSpace(strings.Repeat(fmt.Sprintf("%g ", textPDFCharWidth/textPDFFontSize*1000), 255-32+1))))
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			textPDFPageWidth, textPDFPageHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
@@ -210,11 +210,17 @@ func (s *service) GetMetadataFieldValuesWithFilters(ctx context.Context, req *mo
 			case map[string]interface{}:
 				// Handle complex filters like date_range
 				if field == "date_range" {
+					dateField := models.DateRangeFields[models.DefaultDateRangeField]
+					if name, ok := v["field"].(string); ok {
+						if path, known := models.DateRangeFields[name]; known {
+							dateField = path
+						}
+					}
 					if from, ok := v["from"]; ok {
 						if to, ok := v["to"]; ok {
 							filterClauses = append(filterClauses, map[string]interface{}{
 								"range": map[string]interface{}{
-									"created_at": map[string]interface{}{
+									dateField: map[string]interface{}{
 										"gte": from,
 										"lte": to,
 									},
@@ -292,7 +298,7 @@ func (s *service) GetDocumentStats(ctx context.Context) (*models.DocumentStats,
 		Body:  buildRequestBody(query),
 	}
 
-	res, err := searchReq.Do(ctx, s.client.GetClient())
+	res, err := searchReq.Do(ctx, s.readClient())
 	if err != nil {
 		return nil, fmt.Errorf("stats aggregation request failed: %w", err)
 	}
@@ -355,9 +361,16 @@ func (s *service) GetDocumentStats(ctx context.Context) (*models.DocumentStats,
 		}
 	}
 
+	// The size is informational, so a failed stats call leaves it unknown
+	// rather than failing the whole request
+	indexSize := "Unknown"
+	if indexStats, err := GetIndexStats(ctx, s.client); err == nil {
+		indexSize = FormatBytes(indexStats.TotalSizeBytes)
+	}
+
 	return &models.DocumentStats{
 		TotalDocuments: response.Hits.Total.Value,
-		IndexSize:      "Unknown", // Would need index stats API for this
+		IndexSize:      indexSize,
 		TypeCounts:     typeCounts,
 		TagCounts:      tagCounts,
 		LastUpdated:    time.Now(),
@@ -365,8 +378,143 @@ func (s *service) GetDocumentStats(ctx context.Context) (*models.DocumentStats,
 	}, nil
 }
 
+// GetCaseStats returns document counts by type, the filing date range, the
+// parties, attorneys and judges involved, and a monthly filing timeline for
+// one case. Documents match on either the structured or the legacy case number.
+func (s *service) GetCaseStats(ctx context.Context, caseNumber string) (*models.CaseStats, error) {
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{"metadata.case.case_number": caseNumber}},
+					map[string]interface{}{"term": map[string]interface{}{"metadata.case_number": caseNumber}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+		"aggs": map[string]interface{}{
+			"doc_types": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "doc_type",
+					"size":  50,
+				},
+			},
+			"first_filing": map[string]interface{}{
+				"min": map[string]interface{}{"field": "metadata.filing_date"},
+			},
+			"last_filing": map[string]interface{}{
+				"max": map[string]interface{}{"field": "metadata.filing_date"},
+			},
+			"parties":   nestedTermsAggregation("metadata.parties", "names", "metadata.parties.name", 100),
+			"attorneys": nestedTermsAggregation("metadata.attorneys", "names", "metadata.attorneys.name", 100),
+			"judges": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.judge.name",
+					"size":  20,
+				},
+			},
+			"timeline": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "metadata.filing_date",
+					"calendar_interval": "month",
+					"format":            "yyyy-MM",
+					"min_doc_count":     1,
+				},
+			},
+		},
+	}
+
+	res, err := s.executeAggregationQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations map[string]interface{} `json:"aggregations"`
+	}
+	if err := parseResponse(res, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse case stats response: %w", err)
+	}
+
+	stats := &models.CaseStats{
+		CaseNumber:     caseNumber,
+		TotalDocuments: response.Hits.Total.Value,
+		TypeCounts:     []*models.TypeCount{},
+		Parties:        extractNestedTermOptions(response.Aggregations, "parties", "names"),
+		Attorneys:      extractNestedTermOptions(response.Aggregations, "attorneys", "names"),
+		Judges:         []*models.FieldValue{},
+		Timeline:       []*models.FilingPeriod{},
+		FirstFiling:    extractDateMetric(response.Aggregations, "first_filing"),
+		LastFiling:     extractDateMetric(response.Aggregations, "last_filing"),
+	}
+
+	docTypes, _ := s.extractBucketsFromAgg(response.Aggregations, "doc_types")
+	for _, bucket := range docTypes {
+		stats.TypeCounts = append(stats.TypeCounts, &models.TypeCount{Type: bucket.Key, Count: bucket.DocCount})
+	}
+
+	judges, _ := s.extractBucketsFromAgg(response.Aggregations, "judges")
+	for _, bucket := range judges {
+		stats.Judges = append(stats.Judges, &models.FieldValue{Value: bucket.Key, Count: bucket.DocCount})
+	}
+
+	// Histogram keys are epoch millis; key_as_string holds the formatted month
+	if timeline, ok := response.Aggregations["timeline"].(map[string]interface{}); ok {
+		buckets, _ := timeline["buckets"].([]interface{})
+		for _, bucketInterface := range buckets {
+			bucket, ok := bucketInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			period, _ := bucket["key_as_string"].(string)
+			count, _ := bucket["doc_count"].(float64)
+			stats.Timeline = append(stats.Timeline, &models.FilingPeriod{Period: period, Count: int64(count)})
+		}
+	}
+
+	if stats.Parties == nil {
+		stats.Parties = []*models.FieldValue{}
+	}
+	if stats.Attorneys == nil {
+		stats.Attorneys = []*models.FieldValue{}
+	}
+
+	return stats, nil
+}
+
+// extractDateMetric reads a min or max aggregation over a date field. It
+// returns nil when no document has the field.
+func extractDateMetric(aggregations map[string]interface{}, aggName string) *time.Time {
+	agg, ok := aggregations[aggName].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	millis, ok := agg["value"].(float64)
+	if !ok {
+		return nil
+	}
+	date := time.UnixMilli(int64(millis)).UTC()
+	return &date
+}
+
 // GetAllFieldOptions returns all available filter options for the UI
 func (s *service) GetAllFieldOptions(ctx context.Context) (*models.FieldOptions, error) {
+	return s.GetFieldOptionsForQuery(ctx, nil)
+}
+
+// GetFieldOptionsForQuery returns filter options with counts limited to the
+// documents matching req. The query and filters are built by the same builder
+// used for searches, so facet counts line up with search results. A nil or
+// empty request counts the whole corpus.
+func (s *service) GetFieldOptionsForQuery(ctx context.Context, req *models.SearchRequest) (*models.FieldOptions, error) {
 	query := map[string]interface{}{
 		"size": 0,
 		"aggs": map[string]interface{}{
@@ -406,9 +554,36 @@ func (s *service) GetAllFieldOptions(ctx context.Context) (*models.FieldOptions,
 					"size":  100,
 				},
 			},
+			"dockets": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.case.docket",
+					"size":  100,
+				},
+			},
+			"source_systems": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.source_system",
+					"size":  50,
+				},
+			},
+			"languages": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.language",
+					"size":  20,
+				},
+			},
+			"party_roles": nestedTermsAggregation("metadata.parties", "roles", "metadata.parties.role", 20),
 		},
 	}
 
+	if req != nil && (req.Query != "" || req.HasFilters()) {
+		searchQuery, err := s.builder.BuildQuery(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build field options query: %w", err)
+		}
+		query["query"] = searchQuery["query"]
+	}
+
 	res, err := s.executeAggregationQuery(ctx, query)
 	if err != nil {
 		return nil, err
@@ -467,11 +642,93 @@ func (s *service) GetAllFieldOptions(ctx context.Context) (*models.FieldOptions,
 		}
 	}
 
+	if dockets, err := s.extractBucketsFromAgg(response.Aggregations, "dockets"); err == nil {
+		options.Dockets = make([]*models.FieldValue, len(dockets))
+		for i, bucket := range dockets {
+			options.Dockets[i] = &models.FieldValue{Value: bucket.Key, Count: bucket.DocCount}
+		}
+	}
+
+	if sourceSystems, err := s.extractBucketsFromAgg(response.Aggregations, "source_systems"); err == nil {
+		options.SourceSystems = make([]*models.FieldValue, len(sourceSystems))
+		for i, bucket := range sourceSystems {
+			options.SourceSystems[i] = &models.FieldValue{Value: bucket.Key, Count: bucket.DocCount}
+		}
+	}
+
+	if languages, err := s.extractBucketsFromAgg(response.Aggregations, "languages"); err == nil {
+		options.Languages = make([]*models.FieldValue, len(languages))
+		for i, bucket := range languages {
+			options.Languages[i] = &models.FieldValue{Value: bucket.Key, Count: bucket.DocCount}
+		}
+	}
+
+	options.PartyRoles = extractNestedTermOptions(response.Aggregations, "party_roles", "roles")
+
 	return options, nil
 }
 
+// extractNestedTermOptions reads a terms aggregation inside a nested one
+// (see nestedTermsAggregation), counting each value by the documents it
+// appears in
+func extractNestedTermOptions(aggregations map[string]interface{}, nestedName, termsName string) []*models.FieldValue {
+	nested, ok := aggregations[nestedName].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	terms, ok := nested[termsName].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	buckets, ok := terms["buckets"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	options := make([]*models.FieldValue, 0, len(buckets))
+	for _, bucketInterface := range buckets {
+		bucket, ok := bucketInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := bucket["key"].(string)
+		count, _ := bucket["doc_count"].(float64)
+		if documents, ok := bucket["documents"].(map[string]interface{}); ok {
+			if documentCount, ok := documents["doc_count"].(float64); ok {
+				count = documentCount
+			}
+		}
+		options = append(options, &models.FieldValue{Value: key, Count: int64(count)})
+	}
+	return options
+}
+
 // Helper functions for aggregations
 
+// nestedTermsAggregation counts the values of a field inside nested objects
+// such as parties. reverse_nested makes each bucket count documents rather
+// than nested objects; read the result with extractNestedTermOptions.
+func nestedTermsAggregation(path, termsName, field string, size int) map[string]interface{} {
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": path,
+		},
+		"aggs": map[string]interface{}{
+			termsName: map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": field,
+					"size":  size,
+				},
+				"aggs": map[string]interface{}{
+					"documents": map[string]interface{}{
+						"reverse_nested": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}
+}
+
 type aggregationBucket struct {
 	Key      string `json:"key"`
 	DocCount int64  `json:"doc_count"`
@@ -483,7 +740,7 @@ func (s *service) executeAggregationQuery(ctx context.Context, query map[string]
 		Body:  buildRequestBody(query),
 	}
 
-	res, err := searchReq.Do(ctx, s.client.GetClient())
+	res, err := searchReq.Do(ctx, s.readClient())
 	if err != nil {
 		return nil, fmt.Errorf("aggregation request failed: %w", err)
 	}
@@ -643,7 +900,8 @@ func BuildJudgeAggregation() map[string]interface{} {
 	}
 }
 
-// BuildCombinedAggregation builds a combined aggregation query
+// BuildCombinedAggregation builds a combined aggregation query over the
+// indexed fields behind each aggregation type. Unknown types are skipped.
 func BuildCombinedAggregation(aggregations []string) map[string]interface{} {
 	result := map[string]interface{}{
 		"size": 0,
@@ -657,15 +915,15 @@ func BuildCombinedAggregation(aggregations []string) map[string]interface{} {
 		case "document_types":
 			aggs["document_types"] = map[string]interface{}{
 				"terms": map[string]interface{}{
-					"field": "document_type.keyword",
-					"size":  20,
+					"field": "doc_type",
+					"size":  50,
 				},
 			}
 		case "categories":
 			aggs["categories"] = map[string]interface{}{
 				"terms": map[string]interface{}{
-					"field": "category.keyword",
-					"size":  15,
+					"field": "category",
+					"size":  20,
 				},
 			}
 		case "date_ranges":
@@ -699,15 +957,22 @@ func BuildCombinedAggregation(aggregations []string) map[string]interface{} {
 		case "courts":
 			aggs["courts"] = map[string]interface{}{
 				"terms": map[string]interface{}{
-					"field": "court.keyword",
-					"size":  25,
+					"field": "metadata.court.court_name",
+					"size":  100,
 				},
 			}
 		case "judges":
 			aggs["judges"] = map[string]interface{}{
 				"terms": map[string]interface{}{
-					"field": "judge.keyword",
-					"size":  30,
+					"field": "metadata.judge.name",
+					"size":  100,
+				},
+			}
+		case "dockets":
+			aggs["dockets"] = map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "metadata.case.docket",
+					"size":  100,
 				},
 			}
 		}
@@ -775,11 +1040,50 @@ func ParseAggregationResponse(rawResponse map[string]interface{}) (*models.Aggre
 				}
 			}
 		}
+		
+		// Parse dockets
+		if dockets, ok := aggs["dockets"].(map[string]interface{}); ok {
+			if buckets, ok := dockets["buckets"].([]interface{}); ok {
+				for _, bucket := range buckets {
+					if b, ok := bucket.(map[string]interface{}); ok {
+						if key, ok := b["key"].(string); ok {
+							if docCount, ok := b["doc_count"].(float64); ok {
+								response.Dockets = append(response.Dockets, models.AggregationBucket{
+									Key:      key,
+									DocCount: int(docCount),
+								})
+							}
+						}
+					}
+				}
+			}
+		}
 	}
 	
 	return response, nil
 }
 
+// BuildFacetAggregations builds the aggregations for facets returned with
+// search hits, keyed by facet name. Facet names are those of
+// GetAvailableAggregations; unknown names are skipped.
+func BuildFacetAggregations(facets []string) map[string]interface{} {
+	return BuildCombinedAggregation(facets)["aggs"].(map[string]interface{})
+}
+
+// extractFacets reads the buckets of each facet from a search response.
+// Facets missing from the response are returned without buckets.
+func (s *service) extractFacets(aggregations map[string]interface{}, facets []string) map[string][]models.AggregationBucket {
+	result := make(map[string][]models.AggregationBucket, len(facets))
+	for _, facet := range facets {
+		buckets, _ := s.extractBucketsFromAgg(aggregations, facet)
+		result[facet] = make([]models.AggregationBucket, len(buckets))
+		for i, bucket := range buckets {
+			result[facet][i] = models.AggregationBucket{Key: bucket.Key, DocCount: int(bucket.DocCount)}
+		}
+	}
+	return result
+}
+
 // GetAvailableAggregations returns list of available aggregation types
 func GetAvailableAggregations() []string {
 	return []string{
@@ -788,6 +1092,7 @@ func GetAvailableAggregations() []string {
 		"date_ranges",
 		"courts",
 		"judges",
+		"dockets",
 	}
 }
 
@@ -1,9 +1,12 @@
 package search
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"motion-index-fiber/pkg/models"
 )
@@ -187,8 +190,8 @@ func TestBuildCombinedAggregation(t *testing.T) {
 				"aggs": map[string]interface{}{
 					"document_types": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "document_type.keyword",
-							"size":  20,
+							"field": "doc_type",
+							"size":  50,
 						},
 					},
 				},
@@ -202,14 +205,14 @@ func TestBuildCombinedAggregation(t *testing.T) {
 				"aggs": map[string]interface{}{
 					"document_types": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "document_type.keyword",
-							"size":  20,
+							"field": "doc_type",
+							"size":  50,
 						},
 					},
 					"categories": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "category.keyword",
-							"size":  15,
+							"field": "category",
+							"size":  20,
 						},
 					},
 				},
@@ -223,14 +226,14 @@ func TestBuildCombinedAggregation(t *testing.T) {
 				"aggs": map[string]interface{}{
 					"document_types": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "document_type.keyword",
-							"size":  20,
+							"field": "doc_type",
+							"size":  50,
 						},
 					},
 					"categories": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "category.keyword",
-							"size":  15,
+							"field": "category",
+							"size":  20,
 						},
 					},
 					"date_ranges": map[string]interface{}{
@@ -262,14 +265,29 @@ func TestBuildCombinedAggregation(t *testing.T) {
 					},
 					"courts": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "court.keyword",
-							"size":  25,
+							"field": "metadata.court.court_name",
+							"size":  100,
 						},
 					},
 					"judges": map[string]interface{}{
 						"terms": map[string]interface{}{
-							"field": "judge.keyword",
-							"size":  30,
+							"field": "metadata.judge.name",
+							"size":  100,
+						},
+					},
+				},
+				"size": 0,
+			},
+		},
+		{
+			name:         "docket facet",
+			aggregations: []string{"dockets"},
+			expected: map[string]interface{}{
+				"aggs": map[string]interface{}{
+					"dockets": map[string]interface{}{
+						"terms": map[string]interface{}{
+							"field": "metadata.case.docket",
+							"size":  100,
 						},
 					},
 				},
@@ -418,6 +436,7 @@ func TestGetAvailableAggregations(t *testing.T) {
 		"date_ranges",
 		"courts",
 		"judges",
+		"dockets",
 	}
 
 	result := GetAvailableAggregations()
@@ -476,3 +495,133 @@ func TestValidateAggregations(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAggregationResponse_Dockets(t *testing.T) {
+	raw := map[string]interface{}{
+		"aggregations": map[string]interface{}{
+			"dockets": map[string]interface{}{
+				"buckets": []interface{}{
+					map[string]interface{}{"key": "CR-2024-001234", "doc_count": float64(7)},
+					map[string]interface{}{"key": "2:23-CV-01234", "doc_count": float64(2)},
+				},
+			},
+		},
+	}
+
+	result, err := ParseAggregationResponse(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AggregationBucket{
+		{Key: "CR-2024-001234", DocCount: 7},
+		{Key: "2:23-CV-01234", DocCount: 2},
+	}, result.Dockets)
+}
+
+const fieldOptionsResponse = `{"aggregations":{` +
+	`"courts":{"buckets":[{"key":"Superior Court","doc_count":4}]},` +
+	`"doc_types":{"buckets":[{"key":"motion","doc_count":3},{"key":"order","doc_count":1}]}}}`
+
+func TestGetFieldOptionsForQuery_WithFilter(t *testing.T) {
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, fieldOptionsResponse, &body)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	req := &models.SearchRequest{
+		Court:     []string{"Superior Court"},
+		DateRange: &models.DateRange{From: &from},
+	}
+	options, err := svc.GetFieldOptionsForQuery(context.Background(), req)
+	require.NoError(t, err)
+
+	// Aggregations are scoped by the same bool query a search would use
+	assert.Equal(t, float64(0), body["size"])
+	assert.Contains(t, body, "aggs")
+	boolQuery := body["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	filters := boolQuery["filter"].([]interface{})
+	assert.Len(t, filters, 2)
+	assert.Contains(t, filters, map[string]interface{}{
+		"terms": map[string]interface{}{"metadata.court": []interface{}{"Superior Court"}},
+	})
+
+	require.Len(t, options.Courts, 1)
+	assert.Equal(t, int64(4), options.Courts[0].Count)
+	assert.Len(t, options.DocTypes, 2)
+}
+
+func TestGetAllFieldOptions_NoFilter(t *testing.T) {
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, fieldOptionsResponse, &body)
+
+	_, err := svc.GetFieldOptionsForQuery(context.Background(), &models.SearchRequest{})
+	require.NoError(t, err)
+	assert.NotContains(t, body, "query")
+}
+
+func TestGetFieldOptionsForQuery_PartyRoles(t *testing.T) {
+	response := `{"aggregations":{"party_roles":{"doc_count":9,"roles":{"buckets":[` +
+		`{"key":"defendant","doc_count":5,"documents":{"doc_count":4}},` +
+		`{"key":"plaintiff","doc_count":3,"documents":{"doc_count":3}}]}}}}`
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, response, &body)
+
+	options, err := svc.GetFieldOptionsForQuery(context.Background(), nil)
+	require.NoError(t, err)
+
+	// Counts are documents, not parties: one document names two defendants
+	require.Len(t, options.PartyRoles, 2)
+	assert.Equal(t, &models.FieldValue{Value: "defendant", Count: 4}, options.PartyRoles[0])
+	assert.Equal(t, &models.FieldValue{Value: "plaintiff", Count: 3}, options.PartyRoles[1])
+}
+
+func TestGetFieldOptionsForQuery_SourceSystems(t *testing.T) {
+	response := `{"aggregations":{"source_systems":{"buckets":[` +
+		`{"key":"court-feed-sf","doc_count":12},{"key":"upload","doc_count":3}]},` +
+		`"languages":{"buckets":[{"key":"en","doc_count":13},{"key":"es","doc_count":2}]}}}`
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, response, &body)
+
+	options, err := svc.GetFieldOptionsForQuery(context.Background(), &models.SearchRequest{IngestionBatchID: "import-2024-03"})
+	require.NoError(t, err)
+
+	filters := body["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+	assert.Contains(t, filters, map[string]interface{}{
+		"term": map[string]interface{}{"metadata.ingestion_batch_id": "import-2024-03"},
+	})
+	assert.Equal(t, []*models.FieldValue{{Value: "court-feed-sf", Count: 12}, {Value: "upload", Count: 3}}, options.SourceSystems)
+	assert.Equal(t, []*models.FieldValue{{Value: "en", Count: 13}, {Value: "es", Count: 2}}, options.Languages)
+}
+
+func TestGetCaseStats(t *testing.T) {
+	response := `{"hits":{"total":{"value":5}},"aggregations":{` +
+		`"doc_types":{"buckets":[{"key":"Motion","doc_count":3},{"key":"Order","doc_count":2}]},` +
+		`"first_filing":{"value":1704153600000,"value_as_string":"2024-01-02T00:00:00.000Z"},` +
+		`"last_filing":{"value":1709251200000,"value_as_string":"2024-03-01T00:00:00.000Z"},` +
+		`"parties":{"doc_count":9,"names":{"buckets":[{"key":"State of California","doc_count":5,"documents":{"doc_count":5}},` +
+		`{"key":"John Doe","doc_count":4,"documents":{"doc_count":4}}]}},` +
+		`"attorneys":{"doc_count":3,"names":{"buckets":[{"key":"Jane Roe","doc_count":3,"documents":{"doc_count":2}}]}},` +
+		`"judges":{"buckets":[{"key":"Hon. A. Smith","doc_count":4}]},` +
+		`"timeline":{"buckets":[{"key_as_string":"2024-01","key":1704067200000,"doc_count":2},` +
+		`{"key_as_string":"2024-03","key":1709251200000,"doc_count":3}]}}}`
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, response, &body)
+
+	stats, err := svc.GetCaseStats(context.Background(), "CR/2024/001")
+	require.NoError(t, err)
+
+	// The case filter matches the structured and the legacy case number
+	should := body["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"].([]interface{})
+	require.Len(t, should, 2)
+	assert.Equal(t, "CR/2024/001", should[0].(map[string]interface{})["term"].(map[string]interface{})["metadata.case.case_number"])
+	assert.Equal(t, "CR/2024/001", should[1].(map[string]interface{})["term"].(map[string]interface{})["metadata.case_number"])
+
+	assert.Equal(t, "CR/2024/001", stats.CaseNumber)
+	assert.Equal(t, int64(5), stats.TotalDocuments)
+	assert.Equal(t, []*models.TypeCount{{Type: "Motion", Count: 3}, {Type: "Order", Count: 2}}, stats.TypeCounts)
+	require.NotNil(t, stats.FirstFiling)
+	require.NotNil(t, stats.LastFiling)
+	assert.Equal(t, "2024-01-02", stats.FirstFiling.Format("2006-01-02"))
+	assert.Equal(t, "2024-03-01", stats.LastFiling.Format("2006-01-02"))
+	assert.Equal(t, []*models.FieldValue{{Value: "State of California", Count: 5}, {Value: "John Doe", Count: 4}}, stats.Parties)
+	assert.Equal(t, []*models.FieldValue{{Value: "Jane Roe", Count: 2}}, stats.Attorneys)
+	assert.Equal(t, []*models.FieldValue{{Value: "Hon. A. Smith", Count: 4}}, stats.Judges)
+	assert.Equal(t, []*models.FilingPeriod{{Period: "2024-01", Count: 2}, {Period: "2024-03", Count: 3}}, stats.Timeline)
+}
@@ -0,0 +1,32 @@
+package search
+
+import "strings"
+
+// indexBlockMessages are fragments of the errors OpenSearch returns when a
+// cluster block stops writes to the index, most often the read-only block
+// applied once a node crosses the flood-stage disk watermark
+var indexBlockMessages = []string{
+	"cluster_block_exception",
+	"read_only_allow_delete",
+	"index read-only",
+	"index.blocks.write",
+	"index.blocks.read_only",
+	"flood-stage watermark",
+}
+
+// IsIndexBlocked reports whether an indexing error was caused by a cluster
+// block rather than by the document. The block is cluster state that
+// operators clear, so the same document can be indexed again afterwards.
+func IsIndexBlocked(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, fragment := range indexBlockMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
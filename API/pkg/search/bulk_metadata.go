@@ -0,0 +1,74 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// bulkMetadataScript sets metadata fields and appends a version recording
+// the values they replaced
+const bulkMetadataScript = "if (ctx._source.metadata == null) { ctx._source.metadata = [:]; } " +
+	"Map previous = [:]; " +
+	"for (String key : params.values.keySet()) { if (ctx._source.metadata.containsKey(key)) { previous.put(key, ctx._source.metadata.get(key)); } } " +
+	"ctx._source.metadata.putAll(params.source); " +
+	"if (ctx._source.metadata_versions == null) { ctx._source.metadata_versions = []; } " +
+	"Map version = ['values': params.values, 'changed_at': params.changed_at, 'previous': previous]; " +
+	"if (params.operator != '') { version.operator = params.operator; } " +
+	"ctx._source.metadata_versions.add(version); ctx._source.updated_at = params.changed_at;"
+
+// BulkUpdateMetadata sets metadata fields of many documents in one bulk
+// request, recording each change in the document's metadata versions.
+// Documents that fail, such as IDs not in the index, are reported in the
+// result without affecting the others.
+func (s *service) BulkUpdateMetadata(ctx context.Context, updates []models.BulkMetadataUpdate, operator string) (*models.BulkResult, error) {
+	if len(updates) == 0 {
+		return &models.BulkResult{}, nil
+	}
+
+	now := time.Now()
+	var bulkBody strings.Builder
+	for _, update := range updates {
+		source := make(map[string]interface{}, len(update.Metadata))
+		for field, value := range update.Metadata {
+			source[field] = models.EffectiveDatedSource(field, value)
+		}
+
+		action := map[string]interface{}{
+			"update": map[string]interface{}{
+				"_index":            s.client.GetIndex(),
+				"_id":               update.ID,
+				"retry_on_conflict": 3,
+			},
+		}
+		body := map[string]interface{}{
+			"script": map[string]interface{}{
+				"lang":   "painless",
+				"source": bulkMetadataScript,
+				"params": map[string]interface{}{
+					"values":     update.Metadata,
+					"source":     source,
+					"operator":   operator,
+					"changed_at": now,
+				},
+			},
+		}
+		for _, line := range []interface{}{action, body} {
+			lineJSON, _ := json.Marshal(line)
+			bulkBody.Write(lineJSON)
+			bulkBody.WriteString("\n")
+		}
+	}
+
+	result, succeeded, err := s.executeBulk(ctx, bulkBody.String())
+	if err != nil {
+		return nil, fmt.Errorf("bulk metadata update failed: %w", err)
+	}
+	result.Updated = succeeded
+
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// BulkRetryPolicy controls how bulk indexing retries documents OpenSearch
+// rejects while it is throttling writes or briefly unavailable
+type BulkRetryPolicy struct {
+	// MaxAttempts is how many bulk requests a document may be sent in,
+	// including the first; 1 disables retries
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry. It doubles for each
+	// further retry, up to maxBulkRetryDelay, with random jitter so batches
+	// throttled together do not retry together.
+	Backoff time.Duration
+}
+
+// DefaultBulkRetryPolicy is the retry policy of a new search service
+var DefaultBulkRetryPolicy = BulkRetryPolicy{MaxAttempts: 3, Backoff: 500 * time.Millisecond}
+
+// maxBulkRetryDelay caps the delay between bulk retries
+const maxBulkRetryDelay = 30 * time.Second
+
+// delay returns how long to wait before the given retry, numbered from 1:
+// between half and all of the backoff for that retry
+func (p BulkRetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && d < maxBulkRetryDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxBulkRetryDelay)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// bulkStatusError is a bulk request rejected as a whole by OpenSearch
+type bulkStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *bulkStatusError) Error() string {
+	return fmt.Sprintf("bulk request failed with status: %s", e.Status)
+}
+
+// retryableBulkStatus reports whether a bulk request or item that failed
+// with status may succeed if sent again
+func retryableBulkStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// SetBulkRetryPolicy sets how BulkIndexDocuments retries throttled documents
+func (s *service) SetBulkRetryPolicy(policy BulkRetryPolicy) {
+	s.bulkRetry = policy
+}
+
+// bulkIndexWithRetry sends docs to the _bulk API, resending those rejected
+// with a retryable status until they succeed or run out of attempts. The
+// result has the final outcome of each document and the number of attempts
+// it took. A request that fails as a whole before any document is indexed
+// is returned as an error.
+func (s *service) bulkIndexWithRetry(ctx context.Context, docs []*models.Document) (*models.BulkResult, error) {
+	maxAttempts := max(s.bulkRetry.MaxAttempts, 1)
+
+	result := &models.BulkResult{Attempts: make(map[string]int)}
+	pending := docs
+	for attempt := 1; len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			timer := time.NewTimer(s.bulkRetry.delay(attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				failBulkDocs(result, pending, ctx.Err().Error(), 0)
+				return result, nil
+			case <-timer.C:
+			}
+		}
+
+		for _, doc := range pending {
+			result.Attempts[doc.ID] = attempt
+		}
+		last := attempt == maxAttempts
+
+		res, _, err := s.executeBulk(ctx, s.bulkIndexBody(pending))
+		if err != nil {
+			var statusErr *bulkStatusError
+			if !last && errors.As(err, &statusErr) && retryableBulkStatus(statusErr.StatusCode) {
+				continue
+			}
+			if len(result.Items) == 0 && len(result.FailedDocs) == 0 {
+				return nil, err
+			}
+			status := 0
+			if statusErr != nil {
+				status = statusErr.StatusCode
+			}
+			failBulkDocs(result, pending, err.Error(), status)
+			return result, nil
+		}
+		result.Took += res.Took
+
+		failures := make(map[string]*models.BulkFailedDoc, len(res.FailedDocs))
+		for _, failed := range res.FailedDocs {
+			failures[failed.ID] = failed
+		}
+		byID := make(map[string]*models.Document, len(pending))
+		for _, doc := range pending {
+			byID[doc.ID] = doc
+		}
+
+		var retry []*models.Document
+		for _, item := range res.Items {
+			if item.Index == nil {
+				continue
+			}
+			status := item.Index.Status
+			if !last && retryableBulkStatus(status) && byID[item.Index.ID] != nil {
+				retry = append(retry, byID[item.Index.ID])
+				continue
+			}
+
+			result.Items = append(result.Items, item)
+			if status >= 200 && status < 300 {
+				result.Indexed++
+				continue
+			}
+			result.Failed++
+			if failed, ok := failures[item.Index.ID]; ok {
+				result.FailedDocs = append(result.FailedDocs, failed)
+			}
+		}
+		pending = retry
+	}
+
+	result.Errors = result.Failed > 0
+	return result, nil
+}
+
+// failBulkDocs records docs as failed with the given error
+func failBulkDocs(result *models.BulkResult, docs []*models.Document, reason string, status int) {
+	for _, doc := range docs {
+		result.FailedDocs = append(result.FailedDocs, &models.BulkFailedDoc{ID: doc.ID, Error: reason, Status: status})
+	}
+	result.Failed += len(docs)
+	result.Errors = true
+}
@@ -34,4 +34,12 @@ type SearchClient interface {
 	
 	// Close closes the client connection
 	Close() error
+}
+
+// ReadRouter is implemented by clients that send searches and aggregations
+// to a separate read endpoint, such as a replica cluster, so bulk indexing
+// on the write endpoint does not slow queries down
+type ReadRouter interface {
+	// GetReadClient returns the OpenSearch client for read operations
+	GetReadClient() *opensearch.Client
 }
\ No newline at end of file
@@ -15,10 +15,11 @@ import (
 
 // Client wraps the OpenSearch client with additional functionality
 type Client struct {
-	client    *opensearch.Client
-	index     string
-	config    *config.OpenSearchConfig
-	isHealthy bool
+	client     *opensearch.Client
+	readClient *opensearch.Client // Searches and aggregations; client unless a read endpoint is configured
+	index      string
+	config     *config.OpenSearchConfig
+	isHealthy  bool
 }
 
 // HealthStatus represents the health status of the OpenSearch cluster
@@ -36,12 +37,51 @@ func NewClient(cfg *config.OpenSearchConfig) (*Client, error) {
 		return nil, fmt.Errorf("OpenSearch host is required")
 	}
 
+	client, err := newOpenSearchClient(cfg, cfg.Host, cfg.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		client:     client,
+		readClient: client,
+		index:      cfg.Index,
+		config:     cfg,
+	}
+
+	// Test connection
+	if err := ping(context.Background(), c.client); err != nil {
+		return nil, fmt.Errorf("failed to connect to OpenSearch: %w", err)
+	}
+
+	readPort := cfg.ReadPort
+	if readPort == 0 {
+		readPort = cfg.Port
+	}
+	if cfg.ReadHost != "" && (cfg.ReadHost != cfg.Host || readPort != cfg.Port) {
+		readClient, err := newOpenSearchClient(cfg, cfg.ReadHost, readPort)
+		if err != nil {
+			return nil, err
+		}
+		if err := ping(context.Background(), readClient); err != nil {
+			return nil, fmt.Errorf("failed to connect to OpenSearch read endpoint: %w", err)
+		}
+		c.readClient = readClient
+	}
+
+	c.isHealthy = true
+	return c, nil
+}
+
+// newOpenSearchClient creates an OpenSearch client for the endpoint at host
+// and port, with the connection settings and credentials of cfg
+func newOpenSearchClient(cfg *config.OpenSearchConfig, host string, port int) (*opensearch.Client, error) {
 	// Build the OpenSearch URL
 	protocol := "http"
 	if cfg.UseSSL {
 		protocol = "https"
 	}
-	url := fmt.Sprintf("%s://%s:%d", protocol, cfg.Host, cfg.Port)
+	url := fmt.Sprintf("%s://%s:%d", protocol, host, port)
 
 	// Configure OpenSearch client
 	opensearchConfig := opensearch.Config{
@@ -67,20 +107,7 @@ func NewClient(cfg *config.OpenSearchConfig) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenSearch client: %w", err)
 	}
-
-	c := &Client{
-		client: client,
-		index:  cfg.Index,
-		config: cfg,
-	}
-
-	// Test connection
-	if err := c.ping(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to connect to OpenSearch: %w", err)
-	}
-
-	c.isHealthy = true
-	return c, nil
+	return client, nil
 }
 
 // GetClient returns the underlying OpenSearch client
@@ -88,6 +115,11 @@ func (c *Client) GetClient() *opensearch.Client {
 	return c.client
 }
 
+// GetReadClient returns the OpenSearch client for searches and aggregations
+func (c *Client) GetReadClient() *opensearch.Client {
+	return c.readClient
+}
+
 // GetIndex returns the configured index name
 func (c *Client) GetIndex() string {
 	return c.index
@@ -98,10 +130,10 @@ func (c *Client) IsHealthy() bool {
 	return c.isHealthy
 }
 
-// ping tests the connection to OpenSearch
-func (c *Client) ping(ctx context.Context) error {
+// ping tests the connection to OpenSearch through client
+func ping(ctx context.Context, client *opensearch.Client) error {
 	req := opensearchapi.InfoRequest{}
-	res, err := req.Do(ctx, c.client)
+	res, err := req.Do(ctx, client)
 	if err != nil {
 		return fmt.Errorf("ping request failed: %w", err)
 	}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"motion-index-fiber/pkg/search/client"
+)
+
+// IndexStats is the document count and storage size of the search index, as
+// reported by the _stats API. Sizes are in bytes; TotalSizeBytes includes
+// replicas. An alias reports the totals of the indices behind it.
+type IndexStats struct {
+	Index            string `json:"index"`
+	DocumentCount    int64  `json:"document_count"`
+	DeletedCount     int64  `json:"deleted_count"`
+	PrimarySizeBytes int64  `json:"primary_size_bytes"`
+	TotalSizeBytes   int64  `json:"total_size_bytes"`
+}
+
+// IndexHealth is the shard health of the search index, as reported by the
+// _cluster/health API for that index alone. An alias reports the worst status
+// and the shard totals of the indices behind it.
+type IndexHealth struct {
+	Index               string `json:"index"`
+	Status              string `json:"status"` // green, yellow or red
+	NumberOfShards      int    `json:"number_of_shards"`
+	NumberOfReplicas    int    `json:"number_of_replicas"`
+	ActivePrimaryShards int    `json:"active_primary_shards"`
+	ActiveShards        int    `json:"active_shards"`
+	RelocatingShards    int    `json:"relocating_shards"`
+	InitializingShards  int    `json:"initializing_shards"`
+	UnassignedShards    int    `json:"unassigned_shards"`
+}
+
+// GetIndexStats fetches the document count and storage size of the client's index
+func GetIndexStats(ctx context.Context, c client.SearchClient) (*IndexStats, error) {
+	statsReq := opensearchapi.IndicesStatsRequest{
+		Index:  []string{c.GetIndex()},
+		Metric: []string{"docs", "store"},
+	}
+
+	res, err := statsReq.Do(ctx, c.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("index stats request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("index stats failed with status: %s", res.Status())
+	}
+
+	type shardStats struct {
+		Docs struct {
+			Count   int64 `json:"count"`
+			Deleted int64 `json:"deleted"`
+		} `json:"docs"`
+		Store struct {
+			SizeInBytes int64 `json:"size_in_bytes"`
+		} `json:"store"`
+	}
+	var response struct {
+		All *struct {
+			Primaries shardStats `json:"primaries"`
+			Total     shardStats `json:"total"`
+		} `json:"_all"`
+	}
+
+	if err := parseResponse(res, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse index stats response: %w", err)
+	}
+	if response.All == nil {
+		return nil, fmt.Errorf("index stats response has no totals for index %q", c.GetIndex())
+	}
+
+	return &IndexStats{
+		Index:            c.GetIndex(),
+		DocumentCount:    response.All.Primaries.Docs.Count,
+		DeletedCount:     response.All.Primaries.Docs.Deleted,
+		PrimarySizeBytes: response.All.Primaries.Store.SizeInBytes,
+		TotalSizeBytes:   response.All.Total.Store.SizeInBytes,
+	}, nil
+}
+
+// GetIndexHealth fetches the shard health of the client's index
+func GetIndexHealth(ctx context.Context, c client.SearchClient) (*IndexHealth, error) {
+	healthReq := opensearchapi.ClusterHealthRequest{
+		Index:   []string{c.GetIndex()},
+		Level:   "indices",
+		Timeout: 10 * time.Second,
+	}
+
+	res, err := healthReq.Do(ctx, c.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("index health request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	// A missing index times out with 408 rather than failing outright
+	if res.IsError() {
+		return nil, fmt.Errorf("index health failed with status: %s", res.Status())
+	}
+
+	// The top-level fields cover only the requested index; shard and replica
+	// counts are only reported per index
+	var response struct {
+		IndexHealth
+		Indices map[string]struct {
+			NumberOfShards   int `json:"number_of_shards"`
+			NumberOfReplicas int `json:"number_of_replicas"`
+		} `json:"indices"`
+	}
+	if err := parseResponse(res, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse index health response: %w", err)
+	}
+	if len(response.Indices) == 0 {
+		return nil, fmt.Errorf("index %q not found", c.GetIndex())
+	}
+
+	health := response.IndexHealth
+	health.Index = c.GetIndex()
+	for _, index := range response.Indices {
+		health.NumberOfShards += index.NumberOfShards
+		health.NumberOfReplicas = max(health.NumberOfReplicas, index.NumberOfReplicas)
+	}
+	return &health, nil
+}
+
+// FormatBytes renders a byte count for people, such as "12.4 MB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
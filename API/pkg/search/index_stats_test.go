@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeIndexClient returns a client for index "documents" whose OpenSearch
+// replies to each path with the given body
+func newFakeIndexClient(t *testing.T, responses map[string]string) *MockSearchClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	osClient, err := opensearch.NewClient(opensearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+
+	mockClient := &MockSearchClient{}
+	mockClient.On("GetClient").Return(osClient)
+	mockClient.On("GetIndex").Return("documents")
+	return mockClient
+}
+
+func TestGetIndexStatsAndHealth(t *testing.T) {
+	c := newFakeIndexClient(t, map[string]string{
+		"/documents/_stats/docs,store": `{"_all":{` +
+			`"primaries":{"docs":{"count":1200,"deleted":15},"store":{"size_in_bytes":5242880}},` +
+			`"total":{"docs":{"count":2400,"deleted":30},"store":{"size_in_bytes":10485760}}}}`,
+		"/_cluster/health/documents": `{"cluster_name":"motion","status":"yellow","active_primary_shards":2,` +
+			`"active_shards":2,"relocating_shards":0,"initializing_shards":0,"unassigned_shards":2,` +
+			`"indices":{"documents":{"status":"yellow","number_of_shards":2,"number_of_replicas":1}}}`,
+	})
+
+	stats, err := GetIndexStats(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, &IndexStats{
+		Index:            "documents",
+		DocumentCount:    1200,
+		DeletedCount:     15,
+		PrimarySizeBytes: 5242880,
+		TotalSizeBytes:   10485760,
+	}, stats)
+
+	health, err := GetIndexHealth(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, &IndexHealth{
+		Index:               "documents",
+		Status:              "yellow",
+		NumberOfShards:      2,
+		NumberOfReplicas:    1,
+		ActivePrimaryShards: 2,
+		ActiveShards:        2,
+		UnassignedShards:    2,
+	}, health)
+}
+
+func TestGetIndexStats_MissingIndex(t *testing.T) {
+	c := newFakeIndexClient(t, map[string]string{
+		"/_cluster/health/documents": `{"status":"red","indices":{}}`,
+	})
+
+	_, err := GetIndexStats(context.Background(), c)
+	assert.Error(t, err)
+
+	_, err = GetIndexHealth(context.Background(), c)
+	assert.ErrorContains(t, err, `index "documents" not found`)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KB", FormatBytes(1536))
+	assert.Equal(t, "2.0 GB", FormatBytes(2<<30))
+}
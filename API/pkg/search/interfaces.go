@@ -18,17 +18,99 @@ type SearchService interface {
 	// BulkIndexDocuments indexes multiple documents in a single operation
 	BulkIndexDocuments(ctx context.Context, docs []*models.Document) (*models.BulkResult, error)
 
-	// UpdateDocumentMetadata updates metadata for an existing document
-	UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}) error
+	// UpdateDocumentMetadata sets the metadata fields of an existing document
+	// by dotted path, e.g. "court.court_name", and unsets those in remove
+	UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string) error
 
 	// DeleteDocument removes a document from the index
 	DeleteDocument(ctx context.Context, docID string) error
 
+	// BulkDeleteDocuments removes multiple documents in a single operation
+	BulkDeleteDocuments(ctx context.Context, ids []string) (*models.BulkResult, error)
+
 	// GetDocument retrieves a document by ID
 	GetDocument(ctx context.Context, docID string) (*models.Document, error)
 
 	// DocumentExists checks if a document exists in the index
 	DocumentExists(ctx context.Context, docID string) (bool, error)
+
+	// FindByHash returns an indexed document with the given content hash, or
+	// nil when there is none
+	FindByHash(ctx context.Context, hash string) (*models.Document, error)
+}
+
+// BulkRetryConfigurer is implemented by search services whose bulk indexing
+// retries documents rejected while OpenSearch is throttling
+type BulkRetryConfigurer interface {
+	SetBulkRetryPolicy(policy BulkRetryPolicy)
+}
+
+// BulkMetadataUpdater is implemented by search services that can update the
+// metadata of many documents in one request
+type BulkMetadataUpdater interface {
+	// BulkUpdateMetadata sets metadata fields of each document, recording the
+	// change and the operator in its metadata versions
+	BulkUpdateMetadata(ctx context.Context, updates []models.BulkMetadataUpdate, operator string) (*models.BulkResult, error)
+}
+
+// VersionedMetadataUpdater is implemented by search services that can record
+// single-document metadata updates in the document's metadata versions
+type VersionedMetadataUpdater interface {
+	// UpdateVersionedMetadata sets and unsets metadata fields as
+	// UpdateDocumentMetadata does, recording the change, the values it
+	// replaced and the operator in the document's metadata versions
+	UpdateVersionedMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string, operator string) error
+}
+
+// RefreshingIndexer is implemented by search services that can index a document
+// and wait until it is visible to search. Waiting costs a refresh per request, so
+// it suits interactive single uploads rather than bulk ingestion.
+type RefreshingIndexer interface {
+	// IndexDocumentAndWait indexes a document and returns once it is searchable
+	IndexDocumentAndWait(ctx context.Context, doc *models.Document) (string, error)
+}
+
+// IndexDocument indexes doc, waiting for it to become searchable when immediate
+// is set and the service supports it
+func IndexDocument(ctx context.Context, svc SearchService, doc *models.Document, immediate bool) (string, error) {
+	if immediate {
+		if indexer, ok := svc.(RefreshingIndexer); ok {
+			return indexer.IndexDocumentAndWait(ctx, doc)
+		}
+	}
+	return svc.IndexDocument(ctx, doc)
+}
+
+// RedactionStore is implemented by search services that can store a redaction
+// analysis with an indexed document
+type RedactionStore interface {
+	// SaveRedactions replaces the document's stored redaction analysis
+	SaveRedactions(ctx context.Context, docID string, analysis *models.RedactionAnalysis) error
+}
+
+// RedactionAuditStore is implemented by search services that can keep an
+// append-only audit log of the redactions applied to a document
+type RedactionAuditStore interface {
+	// AppendRedactionAudit adds a record to the document's redaction audit
+	// log, leaving earlier records unchanged
+	AppendRedactionAudit(ctx context.Context, docID string, record *models.RedactionAuditRecord) error
+}
+
+// MetadataHistoryStore is implemented by search services that can keep the
+// past values of effective-dated metadata fields for as-of searches
+type MetadataHistoryStore interface {
+	// AmendDocumentMetadata records that each field took its value from the
+	// effective date. Earlier values stay in the document's metadata
+	// history; the value in effect now becomes the field's current value.
+	AmendDocumentMetadata(ctx context.Context, docID string, values map[string]string, effective time.Time) error
+}
+
+// MappingChecker is implemented by search services that can compare a
+// document with the live index mapping without indexing it
+type MappingChecker interface {
+	// CheckDocumentMapping reports the fields of doc the live mapping would
+	// reject, and those dynamic mapping would add
+	CheckDocumentMapping(ctx context.Context, doc *models.Document) (*MappingCheck, error)
 }
 
 // AggregationService defines the interface for metadata aggregations
@@ -50,6 +132,12 @@ type AggregationService interface {
 
 	// GetAllFieldOptions returns all available filter options for the UI
 	GetAllFieldOptions(ctx context.Context) (*models.FieldOptions, error)
+
+	// GetFieldOptionsForQuery returns filter options counted over the documents matching a search request
+	GetFieldOptionsForQuery(ctx context.Context, req *models.SearchRequest) (*models.FieldOptions, error)
+
+	// GetCaseStats returns aggregated statistics for the documents in one case
+	GetCaseStats(ctx context.Context, caseNumber string) (*models.CaseStats, error)
 }
 
 // QueryBuilder defines the interface for search query construction
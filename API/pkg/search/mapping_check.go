@@ -0,0 +1,460 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// MappingIssue is a document field the live mapping would reject or could
+// not search. Warning marks a field that is accepted but not searchable.
+type MappingIssue struct {
+	Index       string `json:"index"`
+	Field       string `json:"field"`
+	MappingType string `json:"mapping_type,omitempty"`
+	Problem     string `json:"problem"`
+	Warning     bool   `json:"warning,omitempty"`
+}
+
+// MappingCheck is the result of checking a document against the live mapping
+// of every index behind the search index name. NewFields are fields the
+// mapping does not have yet, which indexing would add dynamically. Verified
+// is false when an index could not be tried for real and its verdict comes
+// from comparing the document with the mapping alone.
+type MappingCheck struct {
+	Indices    []string       `json:"indices"`
+	Compatible bool           `json:"compatible"`
+	Verified   bool           `json:"verified"`
+	Issues     []MappingIssue `json:"issues"`
+	NewFields  []string       `json:"new_fields"`
+}
+
+// numericMappingTypes are the field types that only accept numbers
+var numericMappingTypes = map[string]bool{
+	"long": true, "integer": true, "short": true, "byte": true, "double": true,
+	"float": true, "half_float": true, "scaled_float": true, "unsigned_long": true,
+}
+
+// failedFieldPattern and strictFieldPattern find the field in OpenSearch's reason for rejecting a
+// document: a value that failed to parse, or a field a strict mapping refused
+var (
+	failedFieldPattern = regexp.MustCompile(`failed to parse field \[([^\]]+)\]`)
+	strictFieldPattern = regexp.MustCompile(`dynamic introduction of \[([^\]]+)\] within \[([^\]]+)\]`)
+)
+
+// CheckDocumentMapping checks doc against the live mapping of the index, so
+// mapping drift shows up before a large import. Each index behind the name is
+// tried for real by indexing doc into a temporary index with the same mapping
+// and analysis settings, which is deleted afterwards; the live index is never
+// written. The comparison with the mapping only explains what was rejected.
+func (s *service) CheckDocumentMapping(ctx context.Context, doc *models.Document) (*MappingCheck, error) {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	var source map[string]interface{}
+	if err := json.Unmarshal(encoded, &source); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	mappingReq := opensearchapi.IndicesGetMappingRequest{
+		Index: []string{s.client.GetIndex()},
+	}
+	res, err := mappingReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("get mapping request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get mapping failed with status: %s", res.Status())
+	}
+
+	var response map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := parseResponse(res, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping response: %w", err)
+	}
+	if len(response) == 0 {
+		return nil, fmt.Errorf("index %q not found", s.client.GetIndex())
+	}
+
+	check := &MappingCheck{Compatible: true, Verified: true, Issues: []MappingIssue{}, NewFields: []string{}}
+	newFields := make(map[string]bool)
+	for index, mapping := range response {
+		check.Indices = append(check.Indices, index)
+		issues, added := CheckMapping(mapping.Mappings, source)
+		for i := range issues {
+			issues[i].Index = index
+		}
+
+		reason, err := s.trialIndexDocument(ctx, index, mapping.Mappings, encoded)
+		switch {
+		case err != nil:
+			log.Printf("[SEARCH] ⚠️ Could not try the document against %s, comparing with its mapping only: %v", index, err)
+			check.Verified = false
+			if hasRejection(issues) {
+				check.Compatible = false
+			}
+		case reason == "":
+			issues = warningsOnly(issues)
+		default:
+			check.Compatible = false
+			if !hasRejection(issues) {
+				issues = append(issues, rejectionIssue(index, reason))
+			}
+		}
+		check.Issues = append(check.Issues, issues...)
+		for _, field := range added {
+			if !newFields[field] {
+				newFields[field] = true
+				check.NewFields = append(check.NewFields, field)
+			}
+		}
+	}
+	sort.Strings(check.Indices)
+	sort.Strings(check.NewFields)
+	sort.SliceStable(check.Issues, func(i, j int) bool {
+		if check.Issues[i].Index != check.Issues[j].Index {
+			return check.Issues[i].Index < check.Issues[j].Index
+		}
+		return check.Issues[i].Field < check.Issues[j].Field
+	})
+	return check, nil
+}
+
+// trialIndexDocument indexes encoded into a temporary index created with the
+// mapping and analysis settings of index, then deletes the temporary index.
+// It returns OpenSearch's reason when the document is rejected and "" when it
+// is accepted.
+func (s *service) trialIndexDocument(ctx context.Context, index string, mappings map[string]interface{}, encoded []byte) (string, error) {
+	analysis, err := s.indexAnalysis(ctx, index)
+	if err != nil {
+		return "", err
+	}
+	settings := map[string]interface{}{"number_of_shards": 1, "number_of_replicas": 0}
+	if analysis != nil {
+		settings["analysis"] = analysis
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"settings": map[string]interface{}{"index": settings},
+		"mappings": mappings,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode trial index: %w", err)
+	}
+
+	trial := fmt.Sprintf("mapping-check-%s-%d", index, time.Now().UnixNano())
+	createReq := opensearchapi.IndicesCreateRequest{
+		Index: trial,
+		Body:  bytes.NewReader(body),
+	}
+	res, err := createReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return "", fmt.Errorf("create trial index request failed: %w", err)
+	}
+	res.Body.Close()
+	if res.IsError() {
+		return "", fmt.Errorf("create trial index failed with status: %s", res.Status())
+	}
+	defer s.deleteTrialIndex(trial)
+
+	indexReq := opensearchapi.IndexRequest{
+		Index: trial,
+		Body:  bytes.NewReader(encoded),
+	}
+	res, err = indexReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return "", fmt.Errorf("trial index request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 400 {
+		var failure struct {
+			Error struct {
+				Reason   string `json:"reason"`
+				CausedBy struct {
+					Reason string `json:"reason"`
+				} `json:"caused_by"`
+			} `json:"error"`
+		}
+		if err := parseResponse(res, &failure); err != nil || failure.Error.Reason == "" {
+			return "document rejected by the index mapping", nil
+		}
+		if failure.Error.CausedBy.Reason != "" {
+			return failure.Error.Reason + ": " + failure.Error.CausedBy.Reason, nil
+		}
+		return failure.Error.Reason, nil
+	}
+	if res.IsError() {
+		return "", fmt.Errorf("trial index failed with status: %s", res.Status())
+	}
+	return "", nil
+}
+
+// indexAnalysis returns the analysis settings of index, so analyzers the
+// mapping names exist in the trial index, or nil when it has none
+func (s *service) indexAnalysis(ctx context.Context, index string) (map[string]interface{}, error) {
+	settingsReq := opensearchapi.IndicesGetSettingsRequest{
+		Index: []string{index},
+		Name:  []string{"index.analysis*"},
+	}
+	res, err := settingsReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("get settings request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("get settings failed with status: %s", res.Status())
+	}
+
+	var response map[string]struct {
+		Settings struct {
+			Index struct {
+				Analysis map[string]interface{} `json:"analysis"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := parseResponse(res, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse settings response: %w", err)
+	}
+	return response[index].Settings.Index.Analysis, nil
+}
+
+// deleteTrialIndex deletes a temporary index. It runs after the request
+// context may have expired, so it has its own timeout.
+func (s *service) deleteTrialIndex(trial string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	deleteReq := opensearchapi.IndicesDeleteRequest{
+		Index: []string{trial},
+	}
+	res, err := deleteReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		log.Printf("[SEARCH] ❌ Failed to delete trial index %s: %v", trial, err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		log.Printf("[SEARCH] ❌ Failed to delete trial index %s: %s", trial, res.Status())
+	}
+}
+
+// hasRejection reports whether issues include one that fails indexing
+func hasRejection(issues []MappingIssue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// warningsOnly drops the issues a real index accepted despite the mapping
+// comparison flagging them
+func warningsOnly(issues []MappingIssue) []MappingIssue {
+	var warnings []MappingIssue
+	for _, issue := range issues {
+		if issue.Warning {
+			warnings = append(warnings, issue)
+		}
+	}
+	return warnings
+}
+
+// rejectionIssue turns OpenSearch's reason for rejecting a document into an
+// issue, naming the field when the reason does
+func rejectionIssue(index, reason string) MappingIssue {
+	issue := MappingIssue{Index: index, Problem: reason}
+	if match := failedFieldPattern.FindStringSubmatch(reason); match != nil {
+		issue.Field = match[1]
+	} else if match := strictFieldPattern.FindStringSubmatch(reason); match != nil {
+		issue.Field = match[1]
+		if match[2] != "_doc" {
+			issue.Field = match[2] + "." + match[1]
+		}
+	}
+	return issue
+}
+
+// CheckMapping compares a document source with an index mapping (the value
+// of its "mappings" key). It returns the fields whose values the mapping
+// would reject or not index, and the fields dynamic mapping would add.
+func CheckMapping(mapping map[string]interface{}, source map[string]interface{}) ([]MappingIssue, []string) {
+	checker := &mappingChecker{}
+	checker.checkObject("", source, mapping, dynamicSetting(mapping, "true"))
+	return checker.issues, checker.newFields
+}
+
+// mappingChecker collects the results of walking a document against a mapping
+type mappingChecker struct {
+	issues    []MappingIssue
+	newFields []string
+}
+
+// dynamicSetting reads an object mapping's dynamic setting, which may be a
+// boolean or a string, falling back to the inherited one
+func dynamicSetting(mapping map[string]interface{}, inherited string) string {
+	if value, ok := mapping["dynamic"]; ok {
+		return fmt.Sprint(value)
+	}
+	return inherited
+}
+
+// checkObject checks each field of an object against the object mapping's properties
+func (c *mappingChecker) checkObject(prefix string, object map[string]interface{}, mapping map[string]interface{}, dynamic string) {
+	properties, _ := mapping["properties"].(map[string]interface{})
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := object[key]
+		if value == nil {
+			continue
+		}
+		path := prefix + key
+
+		field, ok := properties[key].(map[string]interface{})
+		if !ok {
+			switch dynamic {
+			case "strict":
+				c.addIssue(path, "", "field is not in the mapping and dynamic mapping is strict, so indexing fails")
+			case "false":
+				c.issues = append(c.issues, MappingIssue{
+					Field:   path,
+					Problem: "field is not in the mapping and dynamic mapping is disabled, so it is stored but not searchable",
+					Warning: true,
+				})
+			default:
+				c.newFields = append(c.newFields, path)
+			}
+			continue
+		}
+		c.checkValue(path, value, field, dynamic)
+	}
+}
+
+// checkValue checks one field value, or each element of an array value
+func (c *mappingChecker) checkValue(path string, value interface{}, field map[string]interface{}, dynamic string) {
+	if values, ok := value.([]interface{}); ok {
+		for _, element := range values {
+			if element != nil {
+				c.checkValue(path, element, field, dynamic)
+			}
+		}
+		return
+	}
+	if enabled, ok := field["enabled"].(bool); ok && !enabled {
+		return
+	}
+
+	fieldType, _ := field["type"].(string)
+	if fieldType == "" {
+		fieldType = "object"
+	}
+	object, isObject := value.(map[string]interface{})
+
+	switch {
+	case fieldType == "object" || fieldType == "nested":
+		if !isObject {
+			c.addIssue(path, fieldType, fmt.Sprintf("expected an object, got %s", describeValue(value)))
+			return
+		}
+		c.checkObject(path+".", object, field, dynamicSetting(field, dynamic))
+	case fieldType == "flat_object":
+		if !isObject {
+			c.addIssue(path, fieldType, fmt.Sprintf("expected an object, got %s", describeValue(value)))
+		}
+	case isObject:
+		c.addIssue(path, fieldType, fmt.Sprintf("expected a %s value, got an object", fieldType))
+	case numericMappingTypes[fieldType]:
+		if !acceptsNumber(value) {
+			c.addIssue(path, fieldType, fmt.Sprintf("expected a number, got %s", describeValue(value)))
+		}
+	case fieldType == "date":
+		if _, hasFormat := field["format"]; !hasFormat && !acceptsDate(value) {
+			c.addIssue(path, fieldType, fmt.Sprintf("expected a date, got %s", describeValue(value)))
+		}
+	case fieldType == "boolean":
+		if !acceptsBoolean(value) {
+			c.addIssue(path, fieldType, fmt.Sprintf("expected a boolean, got %s", describeValue(value)))
+		}
+	}
+}
+
+func (c *mappingChecker) addIssue(path, mappingType, problem string) {
+	c.issues = append(c.issues, MappingIssue{Field: path, MappingType: mappingType, Problem: problem})
+}
+
+// acceptsNumber reports whether a numeric field accepts value; OpenSearch
+// coerces numeric strings
+func acceptsNumber(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	}
+	return false
+}
+
+// acceptsDate reports whether a date field with the default format accepts
+// value: an ISO 8601 date or date-time string, or epoch milliseconds
+func acceptsDate(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if _, err := time.Parse(layout, v); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptsBoolean reports whether a boolean field accepts value
+func acceptsBoolean(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return true
+	case string:
+		return v == "true" || v == "false" || v == ""
+	}
+	return false
+}
+
+// describeValue names the JSON type of value for issue messages
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
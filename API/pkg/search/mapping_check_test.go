@@ -0,0 +1,254 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// sampleMappingDocument is a classified document as the indexing handlers build it
+func sampleMappingDocument() *models.Document {
+	filed := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &models.Document{
+		ID:       "doc-1",
+		FileName: "motion.pdf",
+		Text:     "The defendant moves to suppress.",
+		Metadata: &models.DocumentMetadata{
+			DocumentType: models.DocTypeMotionToSuppress,
+			FilingDate:   &filed,
+			Judge:        &models.Judge{Name: "Hon. Maria Lopez"},
+			Confidence:   0.9,
+			AIClassified: true,
+			LegalTags:    []string{"suppression"},
+		},
+	}
+}
+
+func TestCheckMapping_CurrentMapping(t *testing.T) {
+	mapping := models.GetDocumentMapping()["mappings"].(map[string]interface{})
+	source := map[string]interface{}{
+		"id":   "doc-1",
+		"text": "The defendant moves to suppress.",
+		"metadata": map[string]interface{}{
+			"filing_date": "2024-03-01T00:00:00Z",
+			"judge":       map[string]interface{}{"name": "Hon. Maria Lopez"},
+			"legal_tags":  []interface{}{"suppression"},
+			"custom":      map[string]interface{}{"matter": "A-12"},
+		},
+		"classification": map[string]interface{}{"anything": []interface{}{1.0, "x"}},
+	}
+
+	issues, newFields := CheckMapping(mapping, source)
+	assert.Empty(t, issues)
+	assert.Empty(t, newFields)
+}
+
+func TestCheckMapping_IncompatibleSample(t *testing.T) {
+	mapping := map[string]interface{}{
+		"dynamic": "strict",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "keyword"},
+			"metadata": map[string]interface{}{
+				"dynamic": true,
+				"properties": map[string]interface{}{
+					"filing_date":   map[string]interface{}{"type": "date"},
+					"judge":         map[string]interface{}{"type": "keyword"},
+					"confidence":    map[string]interface{}{"type": "float"},
+					"ai_classified": map[string]interface{}{"type": "boolean"},
+					"parties":       map[string]interface{}{"type": "nested", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "keyword"}}},
+				},
+			},
+		},
+	}
+	source := map[string]interface{}{
+		"id":    "doc-1",
+		"pages": []interface{}{map[string]interface{}{"number": 1.0}},
+		"metadata": map[string]interface{}{
+			"filing_date":   "March 1st, 2024",
+			"judge":         map[string]interface{}{"name": "Hon. Maria Lopez"},
+			"confidence":    "high",
+			"ai_classified": "yes",
+			"parties":       "State of California",
+			"language":      "en",
+		},
+	}
+
+	issues, newFields := CheckMapping(mapping, source)
+	assert.Equal(t, []MappingIssue{
+		{Field: "metadata.ai_classified", MappingType: "boolean", Problem: `expected a boolean, got "yes"`},
+		{Field: "metadata.confidence", MappingType: "float", Problem: `expected a number, got "high"`},
+		{Field: "metadata.filing_date", MappingType: "date", Problem: `expected a date, got "March 1st, 2024"`},
+		{Field: "metadata.judge", MappingType: "keyword", Problem: "expected a keyword value, got an object"},
+		{Field: "metadata.parties", MappingType: "nested", Problem: `expected an object, got "State of California"`},
+		{Field: "pages", Problem: "field is not in the mapping and dynamic mapping is strict, so indexing fails"},
+	}, issues)
+	assert.Equal(t, []string{"metadata.language"}, newFields)
+}
+
+// judgeKeywordMapping is the mapping of an index created before
+// metadata.judge became an object
+const judgeKeywordMapping = `{"properties":{` +
+	`"id":{"type":"keyword"},"file_name":{"type":"keyword"},"text":{"type":"text","analyzer":"legal"},` +
+	`"metadata":{"properties":{"judge":{"type":"keyword"},"filing_date":{"type":"date"}}}}}`
+
+// mappingServer fakes the OpenSearch calls CheckDocumentMapping makes for
+// one index, documents-v1, whose mapping defaults to judgeKeywordMapping
+type mappingServer struct {
+	mapping      string
+	createStatus int
+	indexStatus  int
+	indexBody    string
+
+	mu       sync.Mutex
+	requests []string
+	created  map[string]interface{}
+	deleted  []string
+}
+
+func (m *mappingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.URL.Path == "/documents/_mapping":
+		mapping := m.mapping
+		if mapping == "" {
+			mapping = judgeKeywordMapping
+		}
+		w.Write([]byte(`{"documents-v1":{"mappings":` + mapping + `}}`))
+	case r.URL.Path == "/documents-v1/_settings/index.analysis*":
+		w.Write([]byte(`{"documents-v1":{"settings":{"index":{"analysis":{"analyzer":{"legal":{"type":"standard"}}}}}}}`))
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/mapping-check-documents-v1-"):
+		json.NewDecoder(r.Body).Decode(&m.created)
+		w.WriteHeader(m.createStatus)
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/_doc"):
+		w.WriteHeader(m.indexStatus)
+		w.Write([]byte(m.indexBody))
+	case r.Method == http.MethodDelete:
+		m.deleted = append(m.deleted, r.URL.Path)
+		w.Write([]byte(`{"acknowledged":true}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{}`))
+	}
+}
+
+// checkWithServer runs CheckDocumentMapping on the sample document against fake
+func checkWithServer(t *testing.T, fake *mappingServer) *MappingCheck {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	osClient, err := opensearch.NewClient(opensearch.Config{Addresses: []string{server.URL}})
+	require.NoError(t, err)
+	mockClient := &MockSearchClient{}
+	mockClient.On("GetClient").Return(osClient)
+	mockClient.On("GetIndex").Return("documents")
+
+	check, err := NewService(mockClient).(MappingChecker).CheckDocumentMapping(context.Background(), sampleMappingDocument())
+	require.NoError(t, err)
+	return check
+}
+
+func TestCheckDocumentMapping(t *testing.T) {
+	judgeIssue := MappingIssue{
+		Index:       "documents-v1",
+		Field:       "metadata.judge",
+		MappingType: "keyword",
+		Problem:     "expected a keyword value, got an object",
+	}
+
+	t.Run("rejected by the trial index", func(t *testing.T) {
+		fake := &mappingServer{
+			createStatus: http.StatusOK,
+			indexStatus:  http.StatusBadRequest,
+			indexBody: `{"error":{"type":"mapper_parsing_exception",` +
+				`"reason":"failed to parse field [metadata.judge] of type [keyword] in document with id 'doc-1'",` +
+				`"caused_by":{"type":"illegal_state_exception","reason":"Can't get text on a START_OBJECT"}},"status":400}`,
+		}
+		check := checkWithServer(t, fake)
+
+		assert.Equal(t, "GET /documents/_mapping", fake.requests[0])
+		assert.Equal(t, []string{"documents-v1"}, check.Indices)
+		assert.False(t, check.Compatible)
+		assert.True(t, check.Verified)
+		assert.Equal(t, []MappingIssue{judgeIssue}, check.Issues)
+		assert.Contains(t, check.NewFields, "metadata.document_type")
+		assert.NotContains(t, check.NewFields, "metadata.filing_date")
+
+		// The trial index copies the live mapping and analysis, and is deleted
+		require.NotNil(t, fake.created)
+		assert.Equal(t, "legal", fake.created["mappings"].(map[string]interface{})["properties"].(map[string]interface{})["text"].(map[string]interface{})["analyzer"])
+		assert.Contains(t, fake.created["settings"].(map[string]interface{})["index"], "analysis")
+		require.Len(t, fake.deleted, 1)
+		assert.True(t, strings.HasPrefix(fake.deleted[0], "/mapping-check-documents-v1-"))
+		for _, request := range fake.requests {
+			assert.NotContains(t, request, "/documents-v1/_doc", "the live index must not be written")
+		}
+	})
+
+	t.Run("rejection the mapping comparison missed", func(t *testing.T) {
+		// The comparison finds nothing wrong with this mapping, but the
+		// index still refuses the document
+		fake := &mappingServer{
+			mapping:      `{"properties":{"metadata":{"type":"object"}}}`,
+			createStatus: http.StatusOK,
+			indexStatus:  http.StatusBadRequest,
+			indexBody: `{"error":{"type":"strict_dynamic_mapping_exception",` +
+				`"reason":"mapping set to strict, dynamic introduction of [court] within [metadata] is not allowed"},"status":400}`,
+		}
+		check := checkWithServer(t, fake)
+
+		assert.False(t, check.Compatible)
+		assert.Equal(t, []MappingIssue{{
+			Index:   "documents-v1",
+			Field:   "metadata.court",
+			Problem: "mapping set to strict, dynamic introduction of [court] within [metadata] is not allowed",
+		}}, check.Issues)
+	})
+
+	t.Run("accepted by the trial index", func(t *testing.T) {
+		fake := &mappingServer{
+			createStatus: http.StatusOK,
+			indexStatus:  http.StatusCreated,
+			indexBody:    `{"result":"created"}`,
+		}
+		check := checkWithServer(t, fake)
+
+		assert.True(t, check.Compatible)
+		assert.True(t, check.Verified)
+		assert.Empty(t, check.Issues)
+		assert.Len(t, fake.deleted, 1)
+	})
+
+	t.Run("trial index not allowed", func(t *testing.T) {
+		fake := &mappingServer{createStatus: http.StatusForbidden}
+		check := checkWithServer(t, fake)
+
+		assert.False(t, check.Compatible)
+		assert.False(t, check.Verified)
+		assert.Equal(t, []MappingIssue{judgeIssue}, check.Issues)
+		assert.Empty(t, fake.deleted)
+	})
+}
+
+func TestRejectionIssue(t *testing.T) {
+	assert.Equal(t, MappingIssue{Index: "documents-v1", Field: "metadata.court", Problem: "mapping set to strict, dynamic introduction of [court] within [metadata] is not allowed"},
+		rejectionIssue("documents-v1", "mapping set to strict, dynamic introduction of [court] within [metadata] is not allowed"))
+	assert.Equal(t, "pages", rejectionIssue("documents-v1", "mapping set to strict, dynamic introduction of [pages] within [_doc] is not allowed").Field)
+	assert.Equal(t, "metadata.judge", rejectionIssue("documents-v1", "failed to parse field [metadata.judge] of type [keyword]").Field)
+}
@@ -0,0 +1,135 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// metadataAmendAttempts is how many times an amendment is retried when the
+// document changed between reading and writing its history
+const metadataAmendAttempts = 3
+
+// errMetadataConflict reports that the document changed while it was being amended
+var errMetadataConflict = errors.New("document changed during metadata amendment")
+
+// AmendDocumentMetadata records new values of effective-dated metadata fields
+// from the effective date, keeping their earlier values in the document's
+// metadata history. The history is written back only if the document is
+// unchanged since it was read, retrying otherwise. Future effective dates
+// are rejected.
+func (s *service) AmendDocumentMetadata(ctx context.Context, docID string, values map[string]string, effective time.Time) error {
+	if err := models.CheckEffectiveDate(effective, time.Now()); err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err := s.amendDocumentMetadata(ctx, docID, values, effective)
+		if !errors.Is(err, errMetadataConflict) || attempt == metadataAmendAttempts {
+			return err
+		}
+	}
+}
+
+// amendDocumentMetadata makes one attempt at AmendDocumentMetadata
+func (s *service) amendDocumentMetadata(ctx context.Context, docID string, values map[string]string, effective time.Time) error {
+	getReq := opensearchapi.GetRequest{
+		Index:          s.client.GetIndex(),
+		DocumentID:     docID,
+		SourceIncludes: []string{"metadata", "metadata_history"},
+	}
+
+	res, err := getReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return fmt.Errorf("get request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return fmt.Errorf("document not found")
+	}
+	if res.IsError() {
+		return fmt.Errorf("get failed with status: %s", res.Status())
+	}
+
+	var getResponse struct {
+		SeqNo       int  `json:"_seq_no"`
+		PrimaryTerm int  `json:"_primary_term"`
+		Found       bool `json:"found"`
+		Source      struct {
+			Metadata        *models.DocumentMetadata   `json:"metadata"`
+			MetadataHistory []models.MetadataAmendment `json:"metadata_history"`
+		} `json:"_source"`
+	}
+	if err := parseResponse(res, &getResponse); err != nil {
+		return fmt.Errorf("failed to parse get response: %w", err)
+	}
+	if !getResponse.Found {
+		return fmt.Errorf("document not found")
+	}
+
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	now := time.Now()
+	history := getResponse.Source.MetadataHistory
+	current := make(map[string]interface{})
+	for _, field := range fields {
+		previous := ""
+		if getResponse.Source.Metadata != nil {
+			previous = getResponse.Source.Metadata.EffectiveDatedValue(field)
+		}
+		history = models.AmendMetadataHistory(history, field, previous, values[field], effective, now)
+
+		// A backdated amendment may predate a later one, which stays current
+		if value, ok := models.MetadataValueAsOf(history, field, now); ok {
+			current[field] = models.EffectiveDatedSource(field, value)
+		}
+	}
+
+	updateDoc := map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang": "painless",
+			"source": "if (ctx._source.metadata == null) { ctx._source.metadata = [:]; } " +
+				"ctx._source.metadata.putAll(params.current); ctx._source.metadata_history = params.history; " +
+				"ctx._source.updated_at = params.updated_at;",
+			"params": map[string]interface{}{
+				"current":    current,
+				"history":    history,
+				"updated_at": now,
+			},
+		},
+	}
+
+	updateReq := opensearchapi.UpdateRequest{
+		Index:         s.client.GetIndex(),
+		DocumentID:    docID,
+		Body:          buildRequestBody(updateDoc),
+		IfSeqNo:       &getResponse.SeqNo,
+		IfPrimaryTerm: &getResponse.PrimaryTerm,
+	}
+
+	updateRes, err := updateReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return fmt.Errorf("update request failed: %w", err)
+	}
+	defer updateRes.Body.Close()
+
+	switch {
+	case updateRes.StatusCode == 409:
+		return errMetadataConflict
+	case updateRes.StatusCode == 404:
+		return fmt.Errorf("document not found")
+	case updateRes.IsError():
+		return fmt.Errorf("update failed with status: %s", updateRes.Status())
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"motion-index-fiber/pkg/models"
+)
+
+// metadataUpdateScript sets and unsets metadata fields by dotted path,
+// creating the objects a set path runs through. Fields missing from the
+// document are ignored when unset.
+const metadataUpdateScript = "if (ctx._source.metadata == null) { ctx._source.metadata = [:]; } " +
+	"for (Map op : params.set) { Map parent = ctx._source.metadata; String[] parts = op.path.splitOnToken('.'); " +
+	"for (int i = 0; i < parts.length - 1; i++) { if (!(parent.get(parts[i]) instanceof Map)) { parent.put(parts[i], [:]); } parent = (Map) parent.get(parts[i]); } " +
+	"parent.put(parts[parts.length - 1], op.value); } " +
+	"for (String path : params.remove) { Map parent = ctx._source.metadata; String[] parts = path.splitOnToken('.'); " +
+	"for (int i = 0; i < parts.length - 1 && parent != null; i++) { def child = parent.get(parts[i]); parent = child instanceof Map ? (Map) child : null; } " +
+	"if (parent != null) { parent.remove(parts[parts.length - 1]); } } " +
+	"ctx._source.updated_at = params.updated_at;"
+
+// metadataVersionScript wraps metadataUpdateScript to record the update in
+// the document's metadata versions, with the values of the changed paths
+// before it ran
+const metadataVersionScript = "if (ctx._source.metadata == null) { ctx._source.metadata = [:]; } " +
+	"Map previous = [:]; " +
+	"for (String path : params.changed) { def value = ctx._source.metadata; " +
+	"for (String part : path.splitOnToken('.')) { value = value instanceof Map ? ((Map) value).get(part) : null; } " +
+	"if (value != null) { previous.put(path, value); } } " +
+	metadataUpdateScript + " " +
+	"if (ctx._source.metadata_versions == null) { ctx._source.metadata_versions = []; } " +
+	"Map version = ['values': params.values, 'changed_at': params.updated_at, 'previous': previous]; " +
+	"if (params.remove.size() > 0) { version.removed = params.remove; } " +
+	"if (params.operator != '') { version.operator = params.operator; } " +
+	"ctx._source.metadata_versions.add(version);"
+
+// UpdateVersionedMetadata sets and unsets metadata fields by dotted path as
+// UpdateDocumentMetadata does, appending a version that records the change
+func (s *service) UpdateVersionedMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string, operator string) error {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if remove == nil {
+		remove = []string{}
+	}
+	changed := append(slices.Sorted(maps.Keys(metadata)), remove...)
+	return s.runMetadataUpdate(ctx, docID, metadataVersionScript, map[string]interface{}{
+		"set":        metadataSetOps(metadata),
+		"remove":     remove,
+		"values":     metadata,
+		"changed":    changed,
+		"operator":   operator,
+		"updated_at": time.Now(),
+	})
+}
+
+// metadataSetOps returns the set operations of the update script for
+// metadata, keyed by field path, in path order
+func metadataSetOps(metadata map[string]interface{}) []map[string]interface{} {
+	paths := make([]string, 0, len(metadata))
+	for path := range metadata {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	ops := make([]map[string]interface{}, len(paths))
+	for i, path := range paths {
+		ops[i] = map[string]interface{}{"path": path, "value": metadata[path]}
+	}
+	return ops
+}
+
+// ValidateMetadataUpdate checks a partial metadata update against the
+// document mapping. Each path, such as "court.court_name", must name a
+// metadata field of the mapping or lie inside a flat_object field such as
+// "custom"; values must suit the field's type. A path may not be both set
+// and removed.
+func ValidateMetadataUpdate(set map[string]interface{}, remove []string) error {
+	mapping := models.GetDocumentMapping()["mappings"].(map[string]interface{})
+	properties, _ := mapping["properties"].(map[string]interface{})
+	metadataMapping, _ := properties["metadata"].(map[string]interface{})
+
+	for _, path := range remove {
+		if err := checkMetadataPath(metadataMapping, path); err != nil {
+			return err
+		}
+		if _, ok := set[path]; ok {
+			return fmt.Errorf("field %q cannot be both set and removed", path)
+		}
+	}
+
+	paths := make([]string, 0, len(set))
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := checkMetadataPath(metadataMapping, path); err != nil {
+			return err
+		}
+		issues, _ := CheckMapping(mapping, map[string]interface{}{"metadata": nestValue(path, set[path])})
+		if len(issues) > 0 {
+			return fmt.Errorf("field %q: %s", path, issues[0].Problem)
+		}
+	}
+	return nil
+}
+
+// checkMetadataPath reports whether path names a field of the metadata mapping
+func checkMetadataPath(mapping map[string]interface{}, path string) error {
+	if path == "" {
+		return fmt.Errorf("field paths cannot be empty")
+	}
+
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		properties, _ := mapping["properties"].(map[string]interface{})
+		field, ok := properties[part].(map[string]interface{})
+		if part == "" || !ok {
+			return fmt.Errorf("unknown metadata field %q", path)
+		}
+		if i == len(parts)-1 {
+			return nil
+		}
+
+		switch field["type"] {
+		case "flat_object":
+			return nil
+		case nil, "object":
+			mapping = field
+		case "nested":
+			return fmt.Errorf("field %q is inside the list %q; set the whole list instead", path, strings.Join(parts[:i+1], "."))
+		default:
+			return fmt.Errorf("unknown metadata field %q", path)
+		}
+	}
+	return nil
+}
+
+// nestValue returns value placed at a dotted path, e.g. "court.court_name"
+// becomes {"court": {"court_name": value}}
+func nestValue(path string, value interface{}) map[string]interface{} {
+	parts := strings.Split(path, ".")
+	object := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		object = map[string]interface{}{parts[i]: object}
+	}
+	return object
+}
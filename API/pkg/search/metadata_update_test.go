@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetadataUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]interface{}
+		remove  []string
+		wantErr string
+	}{
+		{
+			name: "nested object field",
+			set:  map[string]interface{}{"court.court_name": "Superior Court of Alameda County"},
+		},
+		{
+			name: "whole object",
+			set:  map[string]interface{}{"court": map[string]interface{}{"court_name": "Superior Court", "jurisdiction": "state"}},
+		},
+		{
+			name: "inside flat object",
+			set:  map[string]interface{}{"custom.matter": "2024-017"},
+		},
+		{
+			name:   "remove",
+			remove: []string{"court.jurisdiction", "confidence"},
+		},
+		{
+			name:    "unknown field",
+			set:     map[string]interface{}{"evil_field": "x"},
+			wantErr: `unknown metadata field "evil_field"`,
+		},
+		{
+			name:    "unknown remove",
+			remove:  []string{"court.nickname"},
+			wantErr: `unknown metadata field "court.nickname"`,
+		},
+		{
+			name:    "inside nested list",
+			set:     map[string]interface{}{"parties.name": "State"},
+			wantErr: `inside the list "parties"`,
+		},
+		{
+			name:    "wrong type",
+			set:     map[string]interface{}{"filing_date": "not a date"},
+			wantErr: `field "filing_date"`,
+		},
+		{
+			name:    "set and removed",
+			set:     map[string]interface{}{"status": "filed"},
+			remove:  []string{"status"},
+			wantErr: "both set and removed",
+		},
+		{
+			name:    "empty path",
+			remove:  []string{""},
+			wantErr: "cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetadataUpdate(tt.set, tt.remove)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestUpdateDocumentMetadata_Script(t *testing.T) {
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, `{"_id":"doc-1","result":"updated"}`, &body)
+
+	err := svc.UpdateDocumentMetadata(context.Background(), "doc-1",
+		map[string]interface{}{"status": "filed", "court.court_name": "Superior Court"},
+		[]string{"court.jurisdiction"})
+	require.NoError(t, err)
+
+	script, ok := body["script"].(map[string]interface{})
+	require.True(t, ok, "update should use a script")
+	params := script["params"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"path": "court.court_name", "value": "Superior Court"},
+		map[string]interface{}{"path": "status", "value": "filed"},
+	}, params["set"])
+	assert.Equal(t, []interface{}{"court.jurisdiction"}, params["remove"])
+	assert.NotEmpty(t, params["updated_at"])
+}
+
+func TestUpdateVersionedMetadata_Script(t *testing.T) {
+	var body map[string]interface{}
+	svc := newServiceWithFakeOpenSearch(t, `{"_id":"doc-1","result":"updated"}`, &body)
+
+	err := svc.(VersionedMetadataUpdater).UpdateVersionedMetadata(context.Background(), "doc-1",
+		map[string]interface{}{"status": "filed", "court.court_name": "Superior Court"},
+		[]string{"court.jurisdiction"}, "curator@example.com")
+	require.NoError(t, err)
+
+	script := body["script"].(map[string]interface{})
+	assert.Contains(t, script["source"], "metadata_versions")
+	params := script["params"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"status": "filed", "court.court_name": "Superior Court"}, params["values"])
+	assert.Equal(t, []interface{}{"court.court_name", "status", "court.jurisdiction"}, params["changed"],
+		"the previous value of every set and removed path is recorded")
+	assert.Equal(t, []interface{}{"court.jurisdiction"}, params["remove"])
+	assert.Equal(t, "curator@example.com", params["operator"])
+}
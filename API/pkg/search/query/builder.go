@@ -1,6 +1,7 @@
 package query
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -12,8 +13,15 @@ type Builder struct {
 	query       map[string]interface{}
 	filters     []map[string]interface{}
 	mustQueries []map[string]interface{}
+	should      []map[string]interface{}
 	sort        []map[string]interface{}
 	highlight   map[string]interface{}
+	explain     bool
+	searchAfter []interface{}
+
+	// Highlight fragment caps; zero uses the models defaults
+	fragmentSize int
+	fragments    int
 	from        int
 	size        int
 }
@@ -52,24 +60,69 @@ func (b *Builder) BuildQuery(req *models.SearchRequest) (map[string]interface{},
 
 	// Add date range filter
 	if req.DateRange != nil {
-		b.AddDateRange("created_at", req.DateRange.From, req.DateRange.To)
+		b.AddDateRange(req.DateRange.GetField(), req.DateRange.GetFrom(), req.DateRange.GetTo())
+	}
+
+	if req.MinExtractionQuality > 0 {
+		b.AddMinimum("metadata.extraction_quality", req.MinExtractionQuality)
+	}
+
+	if req.MinReadingEase != nil || req.MaxReadingEase != nil {
+		b.AddRange("metadata.readability.flesch_reading_ease", req.MinReadingEase, req.MaxReadingEase)
+	}
+
+	if len(req.PartyRole) > 0 {
+		b.AddPartyRoleFilter(req.PartyRole)
+	}
+
+	if req.AsOf != nil {
+		b.AddAsOfFilter("judge", req.Judge, *req.AsOf)
+		b.AddAsOfFilter("court", req.Court, *req.AsOf)
+		if req.Status != "" {
+			b.AddAsOfFilter("status", []string{req.Status}, *req.AsOf)
+		}
 	}
 
 	// Add sorting
-	if req.SortBy != "" {
+	if len(req.Sort) > 0 {
+		if err := b.addSortClauses(req.Sort); err != nil {
+			return nil, err
+		}
+	} else if req.SortBy != "" {
 		order := models.SortOrderDesc
 		if req.SortOrder == "asc" {
 			order = models.SortOrderAsc
 		}
 		b.AddSorting(req.SortBy, order)
+	} else if req.Query == "" {
+		// Every document scores the same without a query, so browse in a
+		// fixed order or pages would overlap
+		b.addBrowseSort(req.DefaultSort)
 	} else {
 		// Default sort by relevance score
 		b.AddSorting("_score", models.SortOrderDesc)
 	}
 
+	if req.SearchAfter != nil {
+		if err := b.AddSearchAfter(req.SearchAfter); err != nil {
+			return nil, err
+		}
+	}
+
+	b.fragmentSize = req.HighlightFragmentSize
+	b.fragments = req.HighlightFragments
+
 	// Add highlighting if requested
 	if req.IncludeHighlights {
-		b.AddHighlighting([]string{"text", "metadata.subject", "metadata.case_name"})
+		b.AddHighlighting([]string{"text", "metadata.title", "metadata.subject", "metadata.case_name"})
+	}
+
+	if req.Explain {
+		b.AddExplain()
+	}
+
+	if req.IncludePages && req.Query != "" {
+		b.AddPageMatches(req.Query, req.FuzzySearch, models.MaxPageMatches)
 	}
 
 	return b.Build(), nil
@@ -91,26 +144,61 @@ func (b *Builder) buildMetadataFilters(req *models.SearchRequest) map[string]int
 		filters["metadata.case_name"] = req.CaseName
 	}
 
+	if req.Docket != "" {
+		filters["metadata.case.docket"] = models.NormalizeDocketNumber(req.Docket)
+	}
+
 	if req.Author != "" {
 		filters["metadata.author"] = req.Author
 	}
 
-	if req.Status != "" {
-		filters["metadata.status"] = req.Status
-	}
+	// As-of searches filter these fields on their history instead
+	if req.AsOf == nil {
+		if req.Status != "" {
+			filters["metadata.status"] = req.Status
+		}
 
-	if len(req.Judge) > 0 {
-		filters["metadata.judge"] = req.Judge
-	}
+		if len(req.Judge) > 0 {
+			filters["metadata.judge"] = req.Judge
+		}
 
-	if len(req.Court) > 0 {
-		filters["metadata.court"] = req.Court
+		if len(req.Court) > 0 {
+			filters["metadata.court"] = req.Court
+		}
 	}
 
 	if len(req.LegalTags) > 0 {
 		filters["metadata.legal_tags"] = req.LegalTags
 	}
 
+	if req.LowQualityExtraction != nil {
+		filters["metadata.low_quality_extraction"] = *req.LowQualityExtraction
+	}
+
+	if req.ReviewStatus != "" {
+		filters["metadata.review_status"] = req.ReviewStatus
+	}
+
+	if len(req.MissingFields) > 0 {
+		filters["metadata.missing_fields"] = req.MissingFields
+	}
+
+	if len(req.SourceSystem) > 0 {
+		filters["metadata.source_system"] = req.SourceSystem
+	}
+
+	if req.IngestionBatchID != "" {
+		filters["metadata.ingestion_batch_id"] = req.IngestionBatchID
+	}
+
+	if len(req.Language) > 0 {
+		filters["metadata.language"] = req.Language
+	}
+
+	for key, value := range req.CustomMetadata {
+		filters["metadata.custom."+key] = value
+	}
+
 	return filters
 }
 
@@ -123,7 +211,7 @@ func (b *Builder) AddTextQuery(query string, fuzzy bool) *Builder {
 	textQuery := map[string]interface{}{
 		"multi_match": map[string]interface{}{
 			"query":  query,
-			"fields": []string{"text^2", "metadata.subject^1.5", "metadata.case_name^1.5", "file_name"},
+			"fields": []string{"metadata.title^4", "text^2", "metadata.subject^1.5", "metadata.case_name^1.5", "metadata.references", "metadata.email.attachment_text", "file_name"},
 			"type":   "best_fields",
 		},
 	}
@@ -222,6 +310,117 @@ func (b *Builder) AddDateRange(field string, from, to *time.Time) *Builder {
 	return b
 }
 
+// AddMinimum filters out documents whose numeric field is below min
+func (b *Builder) AddMinimum(field string, min float64) *Builder {
+	b.filters = append(b.filters, map[string]interface{}{
+		"range": map[string]interface{}{
+			field: map[string]interface{}{"gte": min},
+		},
+	})
+	return b
+}
+
+// AddRange keeps documents whose numeric field lies within the inclusive
+// bounds; a nil bound is open
+func (b *Builder) AddRange(field string, min, max *float64) *Builder {
+	bounds := map[string]interface{}{}
+	if min != nil {
+		bounds["gte"] = *min
+	}
+	if max != nil {
+		bounds["lte"] = *max
+	}
+	b.filters = append(b.filters, map[string]interface{}{
+		"range": map[string]interface{}{field: bounds},
+	})
+	return b
+}
+
+// AddPartyRoleFilter keeps documents with a party in any of the given roles.
+// Roles are normalized, so "Def." and "defendant" filter the same way.
+func (b *Builder) AddPartyRoleFilter(roles []string) *Builder {
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role = models.NormalizePartyRole(role); role != "" {
+			normalized = append(normalized, role)
+		}
+	}
+	if len(normalized) == 0 {
+		return b
+	}
+
+	b.filters = append(b.filters, map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path": "metadata.parties",
+			"query": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"metadata.parties.role": normalized,
+				},
+			},
+		},
+	})
+	return b
+}
+
+// AddAsOfFilter keeps documents in which an effective-dated metadata field
+// held any of the given values at asOf. Documents with no history for the
+// field, because it was never amended, match on its current value.
+func (b *Builder) AddAsOfFilter(field string, values []string, asOf time.Time) *Builder {
+	currentField, ok := models.EffectiveDatableFields[field]
+	if !ok || len(values) == 0 {
+		return b
+	}
+
+	historyForField := map[string]interface{}{
+		"term": map[string]interface{}{"metadata_history.field": field},
+	}
+	at := asOf.Format(time.RFC3339)
+	inEffect := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []map[string]interface{}{
+				historyForField,
+				{"terms": map[string]interface{}{"metadata_history.value": values}},
+			},
+			"must_not": []map[string]interface{}{
+				{"range": map[string]interface{}{"metadata_history.effective_from": map[string]interface{}{"gt": at}}},
+				{"range": map[string]interface{}{"metadata_history.effective_to": map[string]interface{}{"lte": at}}},
+			},
+		},
+	}
+
+	b.filters = append(b.filters, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []map[string]interface{}{
+				{
+					"nested": map[string]interface{}{
+						"path":            "metadata_history",
+						"ignore_unmapped": true,
+						"query":           inEffect,
+					},
+				},
+				{
+					"bool": map[string]interface{}{
+						"filter": []map[string]interface{}{
+							{"terms": map[string]interface{}{currentField: values}},
+						},
+						"must_not": []map[string]interface{}{
+							{
+								"nested": map[string]interface{}{
+									"path":            "metadata_history",
+									"ignore_unmapped": true,
+									"query":           historyForField,
+								},
+							},
+						},
+					},
+				},
+			},
+			"minimum_should_match": 1,
+		},
+	})
+	return b
+}
+
 // AddSorting adds sorting to the query
 func (b *Builder) AddSorting(field string, order models.SortOrder) *Builder {
 	sortQuery := map[string]interface{}{
@@ -244,6 +443,34 @@ func (b *Builder) AddSorting(field string, order models.SortOrder) *Builder {
 	return b
 }
 
+// addBrowseSort applies the sort for empty-query searches, falling back to
+// models.DefaultBrowseSort
+func (b *Builder) addBrowseSort(sorts []models.SortOptions) {
+	if len(sorts) == 0 {
+		sorts, _ = models.ParseSortSpec(models.DefaultBrowseSort)
+	}
+	for _, sort := range sorts {
+		b.AddSorting(sort.Field, sort.Order)
+	}
+}
+
+// addSortClauses sorts by each clause in turn, on the indexed field that
+// sorts its values
+func (b *Builder) addSortClauses(clauses []models.SortClause) error {
+	for _, clause := range clauses {
+		field, err := models.ResolveSortField(clause.Field)
+		if err != nil {
+			return err
+		}
+		order := models.SortOrderDesc
+		if clause.Order == models.SortOrderAsc {
+			order = models.SortOrderAsc
+		}
+		b.AddSorting(field, order)
+	}
+	return nil
+}
+
 // AddPagination adds pagination parameters
 func (b *Builder) AddPagination(from, size int) *Builder {
 	if from >= 0 {
@@ -255,6 +482,71 @@ func (b *Builder) AddPagination(from, size int) *Builder {
 	return b
 }
 
+// AddSearchAfter pages by cursor: it adds a tiebreaker on _id to the sort,
+// so every hit has distinct sort values, and starts after the hit whose sort
+// values are after. An empty after starts at the first page.
+func (b *Builder) AddSearchAfter(after []interface{}) error {
+	b.sort = append(b.sort, map[string]interface{}{
+		"_id": map[string]interface{}{"order": string(models.SortOrderAsc)},
+	})
+	if len(after) > 0 && len(after) != len(b.sort) {
+		return fmt.Errorf("search_after has %d values but the sort has %d", len(after), len(b.sort))
+	}
+	b.searchAfter = after
+	b.from = 0
+	return nil
+}
+
+// AddExplain asks OpenSearch to return a scoring explanation for each hit
+func (b *Builder) AddExplain() *Builder {
+	b.explain = true
+	return b
+}
+
+// AddPageMatches collects the pages matching the query as inner hits. The
+// nested clause is optional, so documents indexed without page text still
+// match through the main query.
+func (b *Builder) AddPageMatches(query string, fuzzy bool, size int) *Builder {
+	if query == "" {
+		return b
+	}
+
+	match := map[string]interface{}{
+		"query": query,
+	}
+	if fuzzy {
+		match["fuzziness"] = "AUTO"
+	}
+
+	b.should = append(b.should, map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path":            "pages",
+			"ignore_unmapped": true,
+			"query": map[string]interface{}{
+				"match": map[string]interface{}{
+					"pages.text": match,
+				},
+			},
+			"inner_hits": map[string]interface{}{
+				"name":    "pages",
+				"size":    size,
+				"_source": []string{"pages.number"},
+				"highlight": map[string]interface{}{
+					"fields": map[string]interface{}{
+						"pages.text": map[string]interface{}{
+							"fragment_size":       min(150, b.fragmentLimit()),
+							"number_of_fragments": min(2, b.fragmentCount()),
+						},
+					},
+					"pre_tags":  []string{models.HighlightPreTag},
+					"post_tags": []string{models.HighlightPostTag},
+				},
+			},
+		},
+	})
+	return b
+}
+
 // AddHighlighting adds highlighting for search terms
 func (b *Builder) AddHighlighting(fields []string) *Builder {
 	if len(fields) == 0 {
@@ -263,15 +555,15 @@ func (b *Builder) AddHighlighting(fields []string) *Builder {
 
 	highlight := map[string]interface{}{
 		"fields":    make(map[string]interface{}),
-		"pre_tags":  []string{"<mark>"},
-		"post_tags": []string{"</mark>"},
+		"pre_tags":  []string{models.HighlightPreTag},
+		"post_tags": []string{models.HighlightPostTag},
 	}
 
 	highlightFields := highlight["fields"].(map[string]interface{})
 	for _, field := range fields {
 		highlightFields[field] = map[string]interface{}{
-			"fragment_size":       200,
-			"number_of_fragments": 3,
+			"fragment_size":       b.fragmentLimit(),
+			"number_of_fragments": b.fragmentCount(),
 		}
 	}
 
@@ -279,13 +571,34 @@ func (b *Builder) AddHighlighting(fields []string) *Builder {
 	return b
 }
 
+// fragmentLimit returns the highlight fragment length in characters
+func (b *Builder) fragmentLimit() int {
+	if b.fragmentSize > 0 {
+		return b.fragmentSize
+	}
+	return models.DefaultHighlightFragmentSize
+}
+
+// fragmentCount returns the number of highlight fragments per field
+func (b *Builder) fragmentCount() int {
+	if b.fragments > 0 {
+		return b.fragments
+	}
+	return models.DefaultHighlightFragments
+}
+
 // Reset clears the current query builder state
 func (b *Builder) Reset() *Builder {
 	b.query = make(map[string]interface{})
 	b.filters = make([]map[string]interface{}, 0)
 	b.mustQueries = make([]map[string]interface{}, 0)
+	b.should = nil
 	b.sort = make([]map[string]interface{}, 0)
 	b.highlight = nil
+	b.explain = false
+	b.searchAfter = nil
+	b.fragmentSize = 0
+	b.fragments = 0
 	b.from = 0
 	b.size = models.DefaultSearchSize
 	return b
@@ -321,6 +634,11 @@ func (b *Builder) Build() map[string]interface{} {
 			boolQueryContent["filter"] = b.filters
 		}
 
+		// Optional clauses only add to the score of documents the must clauses match
+		if len(b.should) > 0 {
+			boolQueryContent["should"] = b.should
+		}
+
 		query["query"] = boolQuery
 	} else {
 		// Match all documents if no specific query
@@ -339,6 +657,14 @@ func (b *Builder) Build() map[string]interface{} {
 		query["highlight"] = b.highlight
 	}
 
+	if b.explain {
+		query["explain"] = true
+	}
+
+	if len(b.searchAfter) > 0 {
+		query["search_after"] = b.searchAfter
+	}
+
 	// Add pagination (only add if non-zero to match test expectations)
 	if b.from != 0 {
 		query["from"] = b.from
@@ -396,6 +722,13 @@ func (b *Builder) WithFilters(filters interface{}) *Builder {
 		if len(f.Judge) > 0 {
 			filterMap["metadata.judge"] = f.Judge
 		}
+		if len(f.Docket) > 0 {
+			dockets := make([]string, 0, len(f.Docket))
+			for _, docket := range f.Docket {
+				dockets = append(dockets, models.NormalizeDocketNumber(docket))
+			}
+			filterMap["metadata.case.docket"] = dockets
+		}
 		if len(f.Author) > 0 {
 			filterMap["metadata.author"] = f.Author
 		}
@@ -427,7 +760,7 @@ func (b *Builder) WithDateRange(args ...interface{}) *Builder {
 	if len(args) == 1 {
 		// Single argument - expect *models.DateRange
 		if dateRange, ok := args[0].(*models.DateRange); ok && dateRange != nil {
-			return b.AddDateRange("created_at", dateRange.From, dateRange.To)
+			return b.AddDateRange(dateRange.GetField(), dateRange.From, dateRange.To)
 		}
 	} else if len(args) == 3 {
 		// Three arguments - field, from, to strings for test compatibility
@@ -513,8 +846,8 @@ func BuildFromSearchRequest(req *models.SearchRequest) map[string]interface{} {
 		builder.WithFilters(req.Filters)
 	}
 	
-	if req.Sort != nil {
-		builder.WithSort(req.Sort)
+	for _, clause := range req.Sort {
+		builder.WithSort(&models.SortOptions{Field: clause.Field, Order: clause.Order})
 	}
 	
 	if req.Pagination != nil {
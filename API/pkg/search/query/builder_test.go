@@ -1,9 +1,13 @@
 package query
 
 import (
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"motion-index-fiber/pkg/models"
 )
@@ -530,9 +534,8 @@ func TestBuildFromSearchRequest(t *testing.T) {
 			request: &models.SearchRequest{
 				Query:   "test query",
 				Filters: map[string]interface{}{"document_type": "motion"},
-				Sort: &models.SortOptions{
-					Field:     "created_at",
-					Ascending: false,
+				Sort: []models.SortClause{
+					{Field: "created_at", Order: models.SortOrderDesc},
 				},
 				Pagination: &models.PaginationOptions{
 					Offset: 10,
@@ -637,3 +640,469 @@ func TestBuildFromSearchRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilder_BuildQuery_DateRangeField(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		field         string
+		expectedField string
+	}{
+		{name: "defaults to created_at", field: "", expectedField: "created_at"},
+		{name: "signature date", field: "signature_date", expectedField: "metadata.signature_date"},
+		{name: "filing date", field: "filing_date", expectedField: "metadata.filing_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.SearchRequest{
+				Size:      10,
+				DateRange: &models.DateRange{From: &from, To: &to, Field: tt.field},
+			}
+
+			result, err := NewBuilder().BuildQuery(req)
+			assert.NoError(t, err)
+
+			filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+			assert.Len(t, filters, 1)
+			assert.Equal(t, map[string]interface{}{
+				tt.expectedField: map[string]interface{}{
+					"gte": from.Format(time.RFC3339),
+					"lte": to.Format(time.RFC3339),
+				},
+			}, filters[0]["range"])
+		})
+	}
+}
+
+func TestValidateSearchRequest_DateRangeField(t *testing.T) {
+	req := &models.SearchRequest{DateRange: &models.DateRange{Field: "signature_date"}}
+	assert.NoError(t, models.ValidateSearchRequest(req))
+
+	req = &models.SearchRequest{DateRange: &models.DateRange{Field: "text"}}
+	assert.Error(t, models.ValidateSearchRequest(req))
+}
+
+func TestBuilder_BuildQuery_DocketFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		docket   string
+		expected string
+	}{
+		{name: "federal civil number", docket: "Case No. 2:23 cv 01234", expected: "2:23-CV-01234"},
+		{name: "state criminal number", docket: "Docket #: cr–2024–001234", expected: "CR-2024-001234"},
+		{name: "already normalized", docket: "CR-2024-001234", expected: "CR-2024-001234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &models.SearchRequest{Size: 10, Docket: tt.docket}
+
+			result, err := NewBuilder().BuildQuery(req)
+			assert.NoError(t, err)
+
+			filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+			assert.Len(t, filters, 1)
+			assert.Equal(t, map[string]interface{}{"metadata.case.docket": tt.expected}, filters[0]["term"])
+		})
+	}
+}
+
+func TestBuilder_BuildQuery_PartyRoleFilter(t *testing.T) {
+	req := &models.SearchRequest{Size: 10, PartyRole: []string{"Def.", "Plaintiff and Respondent", "DEFENDANT"}}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	require.Len(t, filters, 1)
+	assert.Equal(t, map[string]interface{}{
+		"path": "metadata.parties",
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"metadata.parties.role": []string{"defendant", "respondent", "defendant"},
+			},
+		},
+	}, filters[0]["nested"])
+}
+
+func TestNormalizePartyRole(t *testing.T) {
+	tests := map[string]string{
+		"Defendant":               models.PartyRoleDefendant,
+		"Def.":                    models.PartyRoleDefendant,
+		"Deft":                    models.PartyRoleDefendant,
+		"defendants":              models.PartyRoleDefendant,
+		"Cross-Defendant":         models.PartyRoleDefendant,
+		"Pltf.":                   models.PartyRolePlaintiff,
+		"PLAINTIFF":               models.PartyRolePlaintiff,
+		"Petitioner":              models.PartyRolePetitioner,
+		"Resp.":                   models.PartyRoleRespondent,
+		"Respondent":              models.PartyRoleRespondent,
+		"Defendant and Appellant": models.PartyRoleAppellant,
+		"Plaintiff-Appellee":      models.PartyRoleAppellee,
+		"Intervener":              models.PartyRoleIntervenor,
+		"Real Party in Interest":  models.PartyRoleRealPartyInInterest,
+		"Witness":                 models.PartyRoleOther,
+		"  ":                      "",
+	}
+
+	for raw, expected := range tests {
+		assert.Equal(t, expected, models.NormalizePartyRole(raw), raw)
+	}
+
+	party := models.NewParty("John Doe", " Def. ", "individual")
+	assert.Equal(t, models.PartyRoleDefendant, party.Role)
+	assert.Equal(t, "Def.", party.RawRole)
+}
+
+func TestBuilder_BuildQuery_BrowseSortIsStableAcrossPages(t *testing.T) {
+	builder := NewBuilder()
+
+	first, err := builder.BuildQuery(&models.SearchRequest{Size: 10, From: 0})
+	require.NoError(t, err)
+	second, err := builder.BuildQuery(&models.SearchRequest{Size: 10, From: 10})
+	require.NoError(t, err)
+
+	expected := []map[string]interface{}{
+		{"metadata.filing_date": map[string]interface{}{"order": "desc"}},
+		{"id": map[string]interface{}{"order": "asc"}},
+	}
+	assert.Equal(t, expected, first["sort"])
+	assert.Equal(t, first["sort"], second["sort"])
+	assert.Equal(t, 10, second["from"])
+
+	// A configured sort gets the ID tiebreak too; a query still sorts by relevance
+	sorts, err := models.ParseSortSpec("created_at:asc")
+	require.NoError(t, err)
+	configured, err := builder.BuildQuery(&models.SearchRequest{Size: 10, DefaultSort: sorts})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"created_at": map[string]interface{}{"order": "asc"}},
+		{"id": map[string]interface{}{"order": "asc"}},
+	}, configured["sort"])
+
+	queried, err := builder.BuildQuery(&models.SearchRequest{Size: 10, Query: "suppress", DefaultSort: sorts})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"_score": map[string]interface{}{"order": "desc"}},
+	}, queried["sort"])
+
+	_, err = models.ParseSortSpec("created_at:sideways")
+	assert.Error(t, err)
+}
+
+func TestBuilder_BuildQuery_MultiFieldSort(t *testing.T) {
+	req := &models.SearchRequest{
+		Size:      10,
+		Query:     "suppress",
+		SortBy:    "created_at",
+		SortOrder: "asc",
+		Sort: []models.SortClause{
+			{Field: "metadata.court.court_name", Order: models.SortOrderAsc},
+			{Field: "metadata.filing_date"},
+			{Field: "metadata.case_name", Order: models.SortOrderAsc},
+			{Field: "_score"},
+		},
+	}
+	require.NoError(t, models.ValidateSearchRequest(req))
+
+	result, err := NewBuilder().BuildQuery(req)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"metadata.court.court_name": map[string]interface{}{"order": "asc"}},
+		{"metadata.filing_date": map[string]interface{}{"order": "desc"}},
+		{"metadata.case_name.keyword": map[string]interface{}{"order": "asc"}},
+		{"_score": map[string]interface{}{"order": "desc"}},
+	}, result["sort"])
+
+	// Without sort clauses the legacy single-field sort applies
+	legacy, err := NewBuilder().BuildQuery(&models.SearchRequest{Size: 10, Query: "suppress", SortBy: "created_at", SortOrder: "asc"})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"created_at": map[string]interface{}{"order": "asc"}},
+	}, legacy["sort"])
+}
+
+func TestValidateSortClauses(t *testing.T) {
+	for _, field := range []string{"text", "metadata.summary", "metadata.parties.name", "redactions", "file_url", "metadata.unknown", ""} {
+		err := models.ValidateSortClauses([]models.SortClause{{Field: field}})
+		assert.Error(t, err, field)
+	}
+
+	err := models.ValidateSortClauses([]models.SortClause{{Field: "created_at", Order: "sideways"}})
+	assert.Error(t, err)
+
+	clauses := []models.SortClause{{Field: "file_name", Order: "ASC"}, {Field: "metadata.confidence"}}
+	require.NoError(t, models.ValidateSortClauses(clauses))
+	assert.Equal(t, models.SortOrderAsc, clauses[0].Order)
+	assert.Equal(t, models.SortOrderDesc, clauses[1].Order)
+
+	field, err := models.ResolveSortField("file_name")
+	require.NoError(t, err)
+	assert.Equal(t, "file_name.keyword", field)
+}
+
+func TestBuilder_BuildQuery_ExtractionQualityFilters(t *testing.T) {
+	lowQuality := true
+	req := &models.SearchRequest{Size: 10, MinExtractionQuality: 0.7, LowQualityExtraction: &lowQuality}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Len(t, filters, 2)
+	assert.Contains(t, filters, map[string]interface{}{
+		"term": map[string]interface{}{"metadata.low_quality_extraction": true},
+	})
+	assert.Contains(t, filters, map[string]interface{}{
+		"range": map[string]interface{}{"metadata.extraction_quality": map[string]interface{}{"gte": 0.7}},
+	})
+}
+
+func TestBuilder_BuildQuery_ReviewFilters(t *testing.T) {
+	req := &models.SearchRequest{
+		Size:          10,
+		ReviewStatus:  models.ReviewStatusIncompleteMetadata,
+		MissingFields: []string{"judge", "decision_date"},
+	}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Len(t, filters, 2)
+	assert.Contains(t, filters, map[string]interface{}{
+		"term": map[string]interface{}{"metadata.review_status": "incomplete_metadata"},
+	})
+	assert.Contains(t, filters, map[string]interface{}{
+		"terms": map[string]interface{}{"metadata.missing_fields": []string{"judge", "decision_date"}},
+	})
+}
+
+func TestBuilder_BuildQuery_SourceFilters(t *testing.T) {
+	req := &models.SearchRequest{
+		Size:             10,
+		SourceSystem:     []string{"court-feed-sf"},
+		IngestionBatchID: "import-2024-03",
+	}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Len(t, filters, 2)
+	assert.Contains(t, filters, map[string]interface{}{
+		"terms": map[string]interface{}{"metadata.source_system": []string{"court-feed-sf"}},
+	})
+	assert.Contains(t, filters, map[string]interface{}{
+		"term": map[string]interface{}{"metadata.ingestion_batch_id": "import-2024-03"},
+	})
+}
+
+func TestBuilder_BuildQuery_LanguageFilter(t *testing.T) {
+	req := &models.SearchRequest{Size: 10, Language: []string{"es", "unknown"}}
+	assert.True(t, req.HasFilters())
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{
+		{"terms": map[string]interface{}{"metadata.language": []string{"es", "unknown"}}},
+	}, filters)
+}
+
+func TestBuilder_BuildQuery_ReadingEaseRange(t *testing.T) {
+	min, max := 0.0, 30.0
+	req := &models.SearchRequest{Size: 10, MinReadingEase: &min, MaxReadingEase: &max}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Contains(t, filters, map[string]interface{}{
+		"range": map[string]interface{}{
+			"metadata.readability.flesch_reading_ease": map[string]interface{}{"gte": 0.0, "lte": 30.0},
+		},
+	})
+}
+
+func TestBuilder_BuildQuery_CustomMetadataFilter(t *testing.T) {
+	req := &models.SearchRequest{Size: 10, CustomMetadata: map[string]string{"client_matter": "CM-2024-017"}}
+
+	result, err := NewBuilder().BuildQuery(req)
+	assert.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Contains(t, filters, map[string]interface{}{
+		"term": map[string]interface{}{"metadata.custom.client_matter": "CM-2024-017"},
+	})
+}
+
+func TestBuilder_AddTextQuery_BoostsTitle(t *testing.T) {
+	result := NewBuilder().AddTextQuery("motion to dismiss", false).Build()
+
+	must := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	fields := must[0]["multi_match"].(map[string]interface{})["fields"].([]string)
+
+	// The document's own heading outweighs every other field, including the body text
+	boosts := make(map[string]float64)
+	for _, field := range fields {
+		name, boost := field, 1.0
+		if i := strings.Index(field, "^"); i >= 0 {
+			name = field[:i]
+			boost, _ = strconv.ParseFloat(field[i+1:], 64)
+		}
+		boosts[name] = boost
+	}
+	require.Contains(t, boosts, "metadata.title")
+	for name, boost := range boosts {
+		if name != "metadata.title" {
+			assert.Greater(t, boosts["metadata.title"], boost, name)
+		}
+	}
+}
+
+func TestBuilder_WithFilters_Docket(t *testing.T) {
+	result := NewBuilder().WithFilters(&models.Filters{Docket: []string{"dkt. no. 45 1", "1:23-cv-00456"}}).Build()
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	assert.Len(t, filters, 1)
+	assert.Equal(t, map[string]interface{}{
+		"metadata.case.docket": []string{"45-1", "1:23-CV-00456"},
+	}, filters[0]["terms"])
+}
+
+func TestBuilder_BuildQuery_HighlightFragmentCaps(t *testing.T) {
+	req := &models.SearchRequest{Size: 10, Query: "suppress", IncludeHighlights: true}
+
+	result, err := NewBuilder().BuildQuery(req)
+	require.NoError(t, err)
+	fields := result["highlight"].(map[string]interface{})["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"fragment_size":       models.DefaultHighlightFragmentSize,
+		"number_of_fragments": models.DefaultHighlightFragments,
+	}, fields["text"])
+
+	req.HighlightFragmentSize = 80
+	req.HighlightFragments = 1
+	req.IncludePages = true
+
+	result, err = NewBuilder().BuildQuery(req)
+	require.NoError(t, err)
+	fields = result["highlight"].(map[string]interface{})["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"fragment_size": 80, "number_of_fragments": 1}, fields["text"])
+
+	// Page fragments are capped too
+	should := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	pages := should[len(should)-1]["nested"].(map[string]interface{})["inner_hits"].(map[string]interface{})["highlight"].(map[string]interface{})["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"fragment_size": 80, "number_of_fragments": 1}, pages["pages.text"])
+}
+
+func TestMetadataValueAsOf(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	recorded := date("2024-07-01")
+
+	// Reassigned from Smith to Jones in March, then backdated: Lee sat from
+	// January until Jones took over. A future transfer to Park is scheduled.
+	var history []models.MetadataAmendment
+	history = models.AmendMetadataHistory(history, "judge", "Hon. Smith", "Hon. Jones", date("2024-03-01"), recorded)
+	history = models.AmendMetadataHistory(history, "judge", "Hon. Jones", "Hon. Lee", date("2024-01-15"), recorded)
+	history = models.AmendMetadataHistory(history, "judge", "Hon. Jones", "Hon. Park", date("2025-01-01"), recorded)
+	history = models.AmendMetadataHistory(history, "court", "", "Alameda Superior", date("2024-02-01"), recorded)
+
+	tests := map[string]string{
+		"2023-06-01": "Hon. Smith",
+		"2024-01-15": "Hon. Lee",
+		"2024-02-29": "Hon. Lee",
+		"2024-03-01": "Hon. Jones",
+		"2024-12-31": "Hon. Jones",
+		"2025-06-01": "Hon. Park",
+	}
+	for asOf, judge := range tests {
+		value, ok := models.MetadataValueAsOf(history, "judge", date(asOf))
+		assert.True(t, ok, asOf)
+		assert.Equal(t, judge, value, asOf)
+	}
+
+	// The court had no value before its first amendment
+	_, ok := models.MetadataValueAsOf(history, "court", date("2024-01-01"))
+	assert.False(t, ok)
+	court, _ := models.MetadataValueAsOf(history, "court", date("2024-02-01"))
+	assert.Equal(t, "Alameda Superior", court)
+
+	// Amending the same date again replaces the value
+	history = models.AmendMetadataHistory(history, "judge", "Hon. Jones", "Hon. Nguyen", date("2024-03-01"), recorded)
+	value, _ := models.MetadataValueAsOf(history, "judge", date("2024-06-01"))
+	assert.Equal(t, "Hon. Nguyen", value)
+	assert.Len(t, history, 5)
+}
+
+func TestBuilder_BuildQuery_AsOfFilter(t *testing.T) {
+	asOf := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	req := &models.SearchRequest{Size: 10, Judge: []string{"Hon. Lee"}, Status: "approved", AsOf: &asOf}
+
+	result, err := NewBuilder().BuildQuery(req)
+	require.NoError(t, err)
+
+	filters := result["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+	require.Len(t, filters, 2, "judge and status are filtered on their history, not their current value")
+
+	should := filters[0]["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	require.Len(t, should, 2)
+	history := should[0]["nested"].(map[string]interface{})
+	assert.Equal(t, "metadata_history", history["path"])
+	inEffect := history["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{
+		{"term": map[string]interface{}{"metadata_history.field": "judge"}},
+		{"terms": map[string]interface{}{"metadata_history.value": []string{"Hon. Lee"}}},
+	}, inEffect["filter"])
+	assert.Equal(t, []map[string]interface{}{
+		{"range": map[string]interface{}{"metadata_history.effective_from": map[string]interface{}{"gt": "2024-02-01T00:00:00Z"}}},
+		{"range": map[string]interface{}{"metadata_history.effective_to": map[string]interface{}{"lte": "2024-02-01T00:00:00Z"}}},
+	}, inEffect["must_not"])
+
+	// Documents never amended match on the current value
+	current := should[1]["bool"].(map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{
+		{"terms": map[string]interface{}{"metadata.judge.name": []string{"Hon. Lee"}}},
+	}, current["filter"])
+
+	status := filters[1]["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	assert.Equal(t, []map[string]interface{}{
+		{"terms": map[string]interface{}{"metadata.status": []string{"approved"}}},
+	}, status[1]["bool"].(map[string]interface{})["filter"])
+}
+
+func TestBuilder_BuildQuery_SearchAfter(t *testing.T) {
+	builder := NewBuilder()
+
+	// The first page of a cursor search only adds the tiebreaker
+	result, err := builder.BuildQuery(&models.SearchRequest{Query: "suppress", Size: 50, SearchAfter: []interface{}{}})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"_score": map[string]interface{}{"order": "desc"}},
+		{"_id": map[string]interface{}{"order": "asc"}},
+	}, result["sort"])
+	assert.NotContains(t, result, "search_after")
+
+	result, err = builder.BuildQuery(&models.SearchRequest{Query: "suppress", Size: 50, SearchAfter: []interface{}{2.5, "doc-50"}})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{2.5, "doc-50"}, result["search_after"])
+	assert.NotContains(t, result, "from")
+
+	_, err = builder.BuildQuery(&models.SearchRequest{Query: "suppress", Size: 50, SearchAfter: []interface{}{"doc-50"}})
+	assert.Error(t, err, "a cursor must have a value for each sort clause")
+
+	// Without a cursor the sort has no tiebreaker
+	result, err = builder.BuildQuery(&models.SearchRequest{Query: "suppress", Size: 50})
+	require.NoError(t, err)
+	assert.Len(t, result["sort"], 1)
+	assert.NotContains(t, result, "search_after")
+}
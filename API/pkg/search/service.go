@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/opensearch-project/opensearch-go/v2"
 	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
 
 	"motion-index-fiber/pkg/search/client"
@@ -18,18 +19,30 @@ import (
 
 // service implements the Service interface
 type service struct {
-	client  client.SearchClient
-	builder *query.Builder
+	client    client.SearchClient
+	builder   *query.Builder
+	bulkRetry BulkRetryPolicy
 }
 
 // NewService creates a new search service
 func NewService(searchClient client.SearchClient) Service {
 	return &service{
-		client:  searchClient,
-		builder: query.NewBuilder(),
+		client:    searchClient,
+		builder:   query.NewBuilder(),
+		bulkRetry: DefaultBulkRetryPolicy,
 	}
 }
 
+// readClient returns the client for searches and aggregations, which goes to
+// the read endpoint when the search client has one. Lookups that must see
+// the latest writes, such as duplicate checks, use s.client.GetClient().
+func (s *service) readClient() *opensearch.Client {
+	if router, ok := s.client.(client.ReadRouter); ok {
+		return router.GetReadClient()
+	}
+	return s.client.GetClient()
+}
+
 // SearchDocuments performs a search query and returns results
 func (s *service) SearchDocuments(ctx context.Context, req *models.SearchRequest) (*models.SearchResult, error) {
 	// Validate request
@@ -39,6 +52,9 @@ func (s *service) SearchDocuments(ctx context.Context, req *models.SearchRequest
 	if req.Size > models.MaxSearchSize {
 		req.Size = models.MaxSearchSize
 	}
+	if req.Explain && req.Size > models.MaxExplainSize {
+		req.Size = models.MaxExplainSize
+	}
 
 	// Build OpenSearch query
 	searchQuery, err := s.builder.BuildQuery(req)
@@ -46,13 +62,22 @@ func (s *service) SearchDocuments(ctx context.Context, req *models.SearchRequest
 		return nil, fmt.Errorf("failed to build search query: %w", err)
 	}
 
+	// Count facets over the same matching documents in the one request
+	facets, err := ValidateAggregations(req.Facets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid facets: %w", err)
+	}
+	if len(facets) > 0 {
+		searchQuery["aggs"] = BuildFacetAggregations(facets)
+	}
+
 	// Execute search
 	searchReq := opensearchapi.SearchRequest{
 		Index: []string{s.client.GetIndex()},
 		Body:  buildRequestBody(searchQuery),
 	}
 
-	res, err := searchReq.Do(ctx, s.client.GetClient())
+	res, err := searchReq.Do(ctx, s.readClient())
 	if err != nil {
 		return nil, fmt.Errorf("search request failed: %w", err)
 	}
@@ -76,6 +101,10 @@ func (s *service) SearchDocuments(ctx context.Context, req *models.SearchRequest
 				Score     float64                `json:"_score"`
 				Source    map[string]interface{} `json:"_source"`
 				Highlight map[string][]string    `json:"highlight,omitempty"`
+
+				Explanation map[string]interface{} `json:"_explanation,omitempty"`
+				InnerHits   map[string]innerHits   `json:"inner_hits,omitempty"`
+				Sort        []interface{}          `json:"sort,omitempty"`
 			} `json:"hits"`
 		} `json:"hits"`
 		Aggregations map[string]interface{} `json:"aggregations,omitempty"`
@@ -94,21 +123,76 @@ func (s *service) SearchDocuments(ctx context.Context, req *models.SearchRequest
 		Took:         searchResponse.Took,
 		TimedOut:     searchResponse.TimedOut,
 	}
+	if req.DebugQuery {
+		result.GeneratedQuery = searchQuery
+	}
+	if len(facets) > 0 {
+		result.Facets = s.extractFacets(searchResponse.Aggregations, facets)
+	}
+
+	// A full page of a cursor search may have more after it
+	if hits := searchResponse.Hits.Hits; req.SearchAfter != nil && len(hits) > 0 && len(hits) >= req.Size {
+		result.NextSearchAfter = hits[len(hits)-1].Sort
+	}
 
 	for i, hit := range searchResponse.Hits.Hits {
+		// Page text duplicates the document text; matching pages come back as inner hits
+		delete(hit.Source, "pages")
+
 		result.Documents[i] = &models.SearchDocument{
 			ID:         hit.ID,
 			Score:      hit.Score,
 			Document:   hit.Source,
 			Highlights: hit.Highlight,
+
+			Explanation: hit.Explanation,
+			Pages:       hit.InnerHits["pages"].pageMatches(),
 		}
 	}
 
 	return result, nil
 }
 
-// IndexDocument indexes a single document
+// innerHits is the inner_hits section of a search hit
+type innerHits struct {
+	Hits struct {
+		Hits []struct {
+			Score  float64 `json:"_score"`
+			Source struct {
+				Number int `json:"number"`
+			} `json:"_source"`
+			Highlight map[string][]string `json:"highlight,omitempty"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// pageMatches converts page inner hits into page matches, best first
+func (h innerHits) pageMatches() []*models.PageMatch {
+	var pages []*models.PageMatch
+	for _, hit := range h.Hits.Hits {
+		pages = append(pages, &models.PageMatch{
+			Number:     hit.Source.Number,
+			Score:      hit.Score,
+			Highlights: hit.Highlight["pages.text"],
+		})
+	}
+	return pages
+}
+
+// IndexDocument indexes a single document. The document becomes searchable
+// after the next periodic index refresh.
 func (s *service) IndexDocument(ctx context.Context, doc *models.Document) (string, error) {
+	return s.indexDocument(ctx, doc, "")
+}
+
+// IndexDocumentAndWait indexes a single document and blocks until a refresh
+// has made it visible to search (refresh=wait_for)
+func (s *service) IndexDocumentAndWait(ctx context.Context, doc *models.Document) (string, error) {
+	return s.indexDocument(ctx, doc, "wait_for")
+}
+
+// indexDocument indexes a document with the given refresh policy
+func (s *service) indexDocument(ctx context.Context, doc *models.Document, refresh string) (string, error) {
 	if doc.ID == "" {
 		return "", fmt.Errorf("document ID is required")
 	}
@@ -131,6 +215,7 @@ func (s *service) IndexDocument(ctx context.Context, doc *models.Document) (stri
 		Index:      s.client.GetIndex(),
 		DocumentID: sanitizedID,
 		Body:       strings.NewReader(string(docData)),
+		Refresh:    refresh,
 	}
 
 	res, err := indexReq.Do(ctx, s.client.GetClient())
@@ -160,19 +245,115 @@ func (s *service) IndexDocument(ctx context.Context, doc *models.Document) (stri
 	return indexResponse.ID, nil
 }
 
-// BulkIndexDocuments indexes multiple documents in a single operation
+// BulkIndexDocuments indexes multiple documents in a single operation.
+// Documents rejected while OpenSearch is throttling are retried with backoff
+// under the service's BulkRetryPolicy.
 func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Document) (*models.BulkResult, error) {
-	if len(docs) == 0 {
+	var withIDs []*models.Document
+	for _, doc := range docs {
+		if doc.ID != "" {
+			withIDs = append(withIDs, doc)
+		}
+	}
+	if len(withIDs) == 0 {
 		return &models.BulkResult{}, nil
 	}
 
-	// Build bulk request body
-	var bulkBody strings.Builder
-	for _, doc := range docs {
-		if doc.ID == "" {
+	withIDs, err := s.carryOverDocumentRecords(ctx, withIDs)
+	if err != nil {
+		return nil, fmt.Errorf("bulk indexing failed: %w", err)
+	}
+
+	result, err := s.bulkIndexWithRetry(ctx, withIDs)
+	if err != nil {
+		return nil, fmt.Errorf("bulk indexing failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// documentRecordFields are the fields kept on an indexed document by later
+// edits rather than by processing, which re-indexing must not drop
+var documentRecordFields = []string{"redactions", "redaction_audit", "metadata_history", "metadata_versions"}
+
+// carryOverDocumentRecords fetches the records of docs already in the index
+// and returns docs with each record they leave empty copied from the
+// indexed document, since a bulk index replaces the whole document. The
+// documents passed in are not modified.
+func (s *service) carryOverDocumentRecords(ctx context.Context, docs []*models.Document) ([]*models.Document, error) {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	body, err := json.Marshal(map[string]interface{}{"ids": ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mget request: %w", err)
+	}
+
+	mgetReq := opensearchapi.MgetRequest{
+		Index:          s.client.GetIndex(),
+		Body:           strings.NewReader(string(body)),
+		SourceIncludes: documentRecordFields,
+	}
+	res, err := mgetReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("mget request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("mget failed with status: %s", res.Status())
+	}
+
+	var mgetResponse struct {
+		Docs []struct {
+			ID     string          `json:"_id"`
+			Found  bool            `json:"found"`
+			Source models.Document `json:"_source"`
+		} `json:"docs"`
+	}
+	if err := parseResponse(res, &mgetResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse mget response: %w", err)
+	}
+
+	indexed := make(map[string]*models.Document, len(mgetResponse.Docs))
+	for i := range mgetResponse.Docs {
+		if mgetResponse.Docs[i].Found {
+			indexed[mgetResponse.Docs[i].ID] = &mgetResponse.Docs[i].Source
+		}
+	}
+	if len(indexed) == 0 {
+		return docs, nil
+	}
+
+	merged := make([]*models.Document, len(docs))
+	for i, doc := range docs {
+		previous, ok := indexed[doc.ID]
+		if !ok {
+			merged[i] = doc
 			continue
 		}
+		copied := *doc
+		if copied.Redactions == nil {
+			copied.Redactions = previous.Redactions
+		}
+		if len(copied.RedactionAudit) == 0 {
+			copied.RedactionAudit = previous.RedactionAudit
+		}
+		if len(copied.MetadataHistory) == 0 {
+			copied.MetadataHistory = previous.MetadataHistory
+		}
+		if len(copied.MetadataVersions) == 0 {
+			copied.MetadataVersions = previous.MetadataVersions
+		}
+		merged[i] = &copied
+	}
+	return merged, nil
+}
 
+// bulkIndexBody builds the NDJSON _bulk body indexing docs
+func (s *service) bulkIndexBody(docs []*models.Document) string {
+	var bulkBody strings.Builder
+	for _, doc := range docs {
 		// Add index action
 		action := map[string]interface{}{
 			"index": map[string]interface{}{
@@ -189,20 +370,57 @@ func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Documen
 		bulkBody.Write(docJSON)
 		bulkBody.WriteString("\n")
 	}
+	return bulkBody.String()
+}
+
+// BulkDeleteDocuments removes multiple documents in a single operation. IDs
+// that are not in the index are reported as failed documents.
+func (s *service) BulkDeleteDocuments(ctx context.Context, ids []string) (*models.BulkResult, error) {
+	if len(ids) == 0 {
+		return &models.BulkResult{}, nil
+	}
+
+	var bulkBody strings.Builder
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+
+		action := map[string]interface{}{
+			"delete": map[string]interface{}{
+				"_index": s.client.GetIndex(),
+				"_id":    id,
+			},
+		}
+		actionJSON, _ := json.Marshal(action)
+		bulkBody.Write(actionJSON)
+		bulkBody.WriteString("\n")
+	}
+
+	result, succeeded, err := s.executeBulk(ctx, bulkBody.String())
+	if err != nil {
+		return nil, fmt.Errorf("bulk deletion failed: %w", err)
+	}
+	result.Deleted = succeeded
 
-	// Execute bulk request
+	return result, nil
+}
+
+// executeBulk sends an NDJSON body to the _bulk API and converts the
+// per-item results, returning the number of items that succeeded
+func (s *service) executeBulk(ctx context.Context, body string) (*models.BulkResult, int, error) {
 	bulkReq := opensearchapi.BulkRequest{
-		Body: strings.NewReader(bulkBody.String()),
+		Body: strings.NewReader(body),
 	}
 
 	res, err := bulkReq.Do(ctx, s.client.GetClient())
 	if err != nil {
-		return nil, fmt.Errorf("bulk request failed: %w", err)
+		return nil, 0, fmt.Errorf("bulk request failed: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("bulk indexing failed with status: %s", res.Status())
+		return nil, 0, &bulkStatusError{StatusCode: res.StatusCode, Status: res.Status()}
 	}
 
 	// Parse bulk response
@@ -213,7 +431,7 @@ func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Documen
 	}
 
 	if err := parseResponse(res, &bulkResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse bulk response: %w", err)
+		return nil, 0, fmt.Errorf("failed to parse bulk response: %w", err)
 	}
 
 	// Convert to result format
@@ -223,7 +441,7 @@ func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Documen
 		Items:  make([]models.BulkResultItem, len(bulkResponse.Items)),
 	}
 
-	indexed := 0
+	succeeded := 0
 	failed := 0
 	var failedDocs []*models.BulkFailedDoc
 
@@ -240,24 +458,36 @@ func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Documen
 
 		resultItem := models.BulkResultItem{}
 		status := int(opResult["status"].(float64))
+		docID, _ := opResult["_id"].(string)
+		itemResult := &models.BulkItemResult{
+			ID:     docID,
+			Status: status,
+		}
+		itemResult.Index, _ = opResult["_index"].(string)
 
 		switch opType {
 		case "index":
-			resultItem.Index = &models.BulkItemResult{
-				ID:     opResult["_id"].(string),
-				Index:  opResult["_index"].(string),
-				Status: status,
-			}
+			resultItem.Index = itemResult
+		case "update":
+			resultItem.Update = itemResult
+		case "delete":
+			resultItem.Delete = itemResult
 		}
 
 		if status >= 200 && status < 300 {
-			indexed++
+			succeeded++
 		} else {
 			failed++
-			if errorInfo, exists := opResult["error"]; exists {
+			if opType != "index" && status == 404 {
+				failedDocs = append(failedDocs, &models.BulkFailedDoc{
+					ID:     docID,
+					Error:  "document not found",
+					Status: status,
+				})
+			} else if errorInfo, exists := opResult["error"]; exists {
 				errorMap := errorInfo.(map[string]interface{})
 				failedDoc := &models.BulkFailedDoc{
-					ID:     opResult["_id"].(string),
+					ID:     docID,
 					Error:  errorMap["reason"].(string),
 					Status: status,
 				}
@@ -268,18 +498,60 @@ func (s *service) BulkIndexDocuments(ctx context.Context, docs []*models.Documen
 		result.Items[i] = resultItem
 	}
 
-	result.Indexed = indexed
 	result.Failed = failed
 	result.FailedDocs = failedDocs
 
-	return result, nil
+	return result, succeeded, nil
+}
+
+// UpdateDocumentMetadata sets metadata fields of an existing document by
+// dotted path and unsets the fields in remove. Set values replace the field
+// at their path, other fields are kept.
+func (s *service) UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}, remove []string) error {
+	if remove == nil {
+		remove = []string{}
+	}
+	return s.runMetadataUpdate(ctx, docID, metadataUpdateScript, map[string]interface{}{
+		"set":        metadataSetOps(metadata),
+		"remove":     remove,
+		"updated_at": time.Now(),
+	})
+}
+
+// runMetadataUpdate runs a metadata update script on one document
+func (s *service) runMetadataUpdate(ctx context.Context, docID, script string, params map[string]interface{}) error {
+	updateDoc := map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": script,
+			"params": params,
+		},
+	}
+
+	updateReq := opensearchapi.UpdateRequest{
+		Index:      s.client.GetIndex(),
+		DocumentID: docID,
+		Body:       buildRequestBody(updateDoc),
+	}
+
+	res, err := updateReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return fmt.Errorf("update request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("update failed with status: %s", res.Status())
+	}
+
+	return nil
 }
 
-// UpdateDocumentMetadata updates metadata for an existing document
-func (s *service) UpdateDocumentMetadata(ctx context.Context, docID string, metadata map[string]interface{}) error {
+// SaveRedactions replaces the redaction analysis stored with a document
+func (s *service) SaveRedactions(ctx context.Context, docID string, analysis *models.RedactionAnalysis) error {
 	updateDoc := map[string]interface{}{
 		"doc": map[string]interface{}{
-			"metadata":   metadata,
+			"redactions": analysis,
 			"updated_at": time.Now(),
 		},
 	}
@@ -296,6 +568,48 @@ func (s *service) UpdateDocumentMetadata(ctx context.Context, docID string, meta
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == 404 {
+		return fmt.Errorf("document not found")
+	}
+	if res.IsError() {
+		return fmt.Errorf("update failed with status: %s", res.Status())
+	}
+
+	return nil
+}
+
+// AppendRedactionAudit appends a record to a document's redaction audit log.
+// The script only ever adds to the list, so earlier records cannot be
+// rewritten through this path.
+func (s *service) AppendRedactionAudit(ctx context.Context, docID string, record *models.RedactionAuditRecord) error {
+	updateDoc := map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang": "painless",
+			"source": "if (ctx._source.redaction_audit == null) { ctx._source.redaction_audit = []; } " +
+				"ctx._source.redaction_audit.add(params.record); ctx._source.updated_at = params.updated_at;",
+			"params": map[string]interface{}{
+				"record":     record,
+				"updated_at": time.Now(),
+			},
+		},
+	}
+
+	updateReq := opensearchapi.UpdateRequest{
+		Index:           s.client.GetIndex(),
+		DocumentID:      docID,
+		Body:            buildRequestBody(updateDoc),
+		RetryOnConflict: opensearchapi.IntPtr(3),
+	}
+
+	res, err := updateReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return fmt.Errorf("update request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return fmt.Errorf("document not found")
+	}
 	if res.IsError() {
 		return fmt.Errorf("update failed with status: %s", res.Status())
 	}
@@ -375,6 +689,49 @@ func (s *service) DocumentExists(ctx context.Context, docID string) (bool, error
 	return res.StatusCode == 200, nil
 }
 
+// FindByHash returns an indexed document with the given content hash, or nil
+// when there is none
+func (s *service) FindByHash(ctx context.Context, hash string) (*models.Document, error) {
+	searchReq := opensearchapi.SearchRequest{
+		Index: []string{s.client.GetIndex()},
+		Body: buildRequestBody(map[string]interface{}{
+			"size":  1,
+			"query": map[string]interface{}{"term": map[string]interface{}{"hash": hash}},
+		}),
+	}
+
+	res, err := searchReq.Do(ctx, s.client.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("hash search request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("hash search failed with status: %s", res.Status())
+	}
+
+	var searchResponse struct {
+		Hits struct {
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Source models.Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := parseResponse(res, &searchResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse hash search response: %w", err)
+	}
+
+	if len(searchResponse.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	hit := searchResponse.Hits.Hits[0]
+	if hit.Source.ID == "" {
+		hit.Source.ID = hit.ID
+	}
+	return &hit.Source, nil
+}
+
 // IsHealthy returns true if the search service is healthy
 func (s *service) IsHealthy() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CDNFlusher purges the cached copies of files from the CDN in front of a
+// bucket, in one request for all of files
+type CDNFlusher interface {
+	FlushCDNCache(ctx context.Context, files []string) error
+}
+
+// CDNFlushPolicy bounds the flush requests of a bulk cache invalidation
+type CDNFlushPolicy struct {
+	// MaxConcurrent is how many flush requests run at once, to stay under
+	// the API rate limit
+	MaxConcurrent int
+
+	// MaxRetries is how many times a failed flush is sent again
+	MaxRetries int
+
+	// Backoff is the delay before the first retry. It doubles for each
+	// further retry, up to maxCDNFlushDelay.
+	Backoff time.Duration
+}
+
+// DefaultCDNFlushPolicy is the flush policy when none is given
+var DefaultCDNFlushPolicy = CDNFlushPolicy{MaxConcurrent: 2, MaxRetries: 3, Backoff: time.Second}
+
+// maxCDNFlushFiles is the most paths the DigitalOcean API accepts in one CDN
+// cache flush
+const maxCDNFlushFiles = 50
+
+// maxCDNFlushDelay caps the delay between retries of one flush
+const maxCDNFlushDelay = 30 * time.Second
+
+// InvalidateCache drops the CDN's cached copies of paths when svc sits
+// behind a CDN it can flush, and does nothing otherwise
+func InvalidateCache(ctx context.Context, svc Service, paths []string) error {
+	invalidator, ok := svc.(CacheInvalidator)
+	if !ok || len(paths) == 0 {
+		return nil
+	}
+	return invalidator.BulkInvalidateCache(ctx, paths)
+}
+
+// SetCDNFlusher enables BulkInvalidateCache, flushing through flusher as
+// policy allows
+func (s *SpacesService) SetCDNFlusher(flusher CDNFlusher, policy CDNFlushPolicy) {
+	s.cdnFlusher = flusher
+	s.cdnFlushPolicy = policy
+}
+
+// BulkInvalidateCache flushes the CDN cache for many paths in batches of up
+// to maxCDNFlushFiles, instead of one API call per file. Up to the policy's
+// number of flushes run at once, and each failed flush is retried with
+// backoff. It does nothing without a CDN flusher. The error lists the
+// batches that still failed; the others were flushed.
+func (s *SpacesService) BulkInvalidateCache(ctx context.Context, paths []string) error {
+	if s.cdnFlusher == nil || len(paths) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(paths))
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "/")
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, path)
+	}
+
+	var batches [][]string
+	for start := 0; start < len(files); start += maxCDNFlushFiles {
+		batches = append(batches, files[start:min(start+maxCDNFlushFiles, len(files))])
+	}
+
+	sem := make(chan struct{}, max(s.cdnFlushPolicy.MaxConcurrent, 1))
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			if err := s.flushWithRetry(ctx, batch); err != nil {
+				errs[i] = fmt.Errorf("flushing %d paths from %s: %w", len(batch), batch[0], err)
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// flushWithRetry flushes one batch of paths, retrying failures as the flush
+// policy allows
+func (s *SpacesService) flushWithRetry(ctx context.Context, files []string) error {
+	delay := s.cdnFlushPolicy.Backoff
+	for attempt := 0; ; attempt++ {
+		err := s.cdnFlusher.FlushCDNCache(ctx, files)
+		if err == nil || attempt >= s.cdnFlushPolicy.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxCDNFlushDelay)
+	}
+}
+
+// cdnMaxConsecutiveFailures is how many CDN requests in a row may fail
+// before the circuit breaker opens
+const cdnMaxConsecutiveFailures = 3
+
+// cdnBreakerTimeout is how long an open circuit breaker waits before letting
+// a request through to try the CDN again
+const cdnBreakerTimeout = 30 * time.Second
+
+// cdnHealth is the circuit breaker state of the CDN, updated from the
+// outcome of warming requests. It is read by health checks while uploads
+// update it, so every access holds mu.
+type cdnHealth struct {
+	mu                  sync.Mutex
+	lastCheck           time.Time
+	lastFailure         time.Time
+	consecutiveFailures int
+	breakerOpen         bool
+}
+
+// available reports whether a request may go to the CDN: the breaker is
+// closed, or has been open for cdnBreakerTimeout
+func (h *cdnHealth) available() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.breakerOpen || time.Since(h.lastFailure) >= cdnBreakerTimeout
+}
+
+func (h *cdnHealth) recordSuccess() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = time.Now()
+	h.consecutiveFailures = 0
+	h.breakerOpen = false
+}
+
+func (h *cdnHealth) recordFailure() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCheck = time.Now()
+	h.lastFailure = h.lastCheck
+	h.consecutiveFailures++
+	if h.consecutiveFailures >= cdnMaxConsecutiveFailures {
+		h.breakerOpen = true
+	}
+}
+
+var (
+	_ CacheInvalidator  = (*SpacesService)(nil)
+	_ CDNHealthReporter = (*SpacesService)(nil)
+)
+
+// GetCDNHealthStatus reports the CDN's circuit breaker state. The CDN is
+// healthy until a request to it fails.
+func (s *SpacesService) GetCDNHealthStatus() map[string]interface{} {
+	if s.cdnDomain == "" {
+		return map[string]interface{}{"cdn_configured": false}
+	}
+
+	s.cdnHealth.mu.Lock()
+	defer s.cdnHealth.mu.Unlock()
+	return map[string]interface{}{
+		"cdn_configured":       true,
+		"cdn_domain":           s.cdnDomain,
+		"is_healthy":           s.cdnHealth.consecutiveFailures == 0,
+		"last_health_check":    s.cdnHealth.lastCheck,
+		"last_failure":         s.cdnHealth.lastFailure,
+		"consecutive_failures": s.cdnHealth.consecutiveFailures,
+		"circuit_breaker_open": s.cdnHealth.breakerOpen,
+		"max_failures":         cdnMaxConsecutiveFailures,
+		"circuit_timeout":      cdnBreakerTimeout.String(),
+	}
+}
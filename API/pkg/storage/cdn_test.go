@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingFlusher records the batches it is asked to flush, failing the
+// first failures calls
+type recordingFlusher struct {
+	mu       sync.Mutex
+	batches  [][]string
+	failures int
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	delay    time.Duration
+}
+
+func (f *recordingFlusher) FlushCDNCache(ctx context.Context, files []string) error {
+	current := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if current <= peak || f.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]string(nil), files...))
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("HTTP 429")
+	}
+	return nil
+}
+
+func TestSpacesService_BulkInvalidateCache(t *testing.T) {
+	t.Run("without a flusher", func(t *testing.T) {
+		service := &SpacesService{bucket: "test-bucket"}
+		assert.NoError(t, service.BulkInvalidateCache(context.Background(), []string{"documents/a.pdf"}))
+	})
+
+	t.Run("batches deduplicated paths", func(t *testing.T) {
+		flusher := &recordingFlusher{delay: 10 * time.Millisecond}
+		service := &SpacesService{bucket: "test-bucket"}
+		service.SetCDNFlusher(flusher, CDNFlushPolicy{MaxConcurrent: 2})
+
+		var paths []string
+		for i := 0; i < 120; i++ {
+			paths = append(paths, fmt.Sprintf("/documents/motion-%03d.pdf", i))
+		}
+		paths = append(paths, "documents/motion-000.pdf", "")
+
+		require.NoError(t, service.BulkInvalidateCache(context.Background(), paths))
+
+		var sizes []int
+		flushed := map[string]bool{}
+		for _, batch := range flusher.batches {
+			sizes = append(sizes, len(batch))
+			for _, file := range batch {
+				flushed[file] = true
+			}
+		}
+		assert.ElementsMatch(t, []int{50, 50, 20}, sizes)
+		assert.Len(t, flushed, 120)
+		assert.True(t, flushed["documents/motion-000.pdf"])
+		assert.LessOrEqual(t, flusher.peak.Load(), int32(2))
+	})
+
+	t.Run("retries failed flushes", func(t *testing.T) {
+		flusher := &recordingFlusher{failures: 2}
+		service := &SpacesService{bucket: "test-bucket"}
+		service.SetCDNFlusher(flusher, CDNFlushPolicy{MaxConcurrent: 1, MaxRetries: 2, Backoff: time.Millisecond})
+
+		require.NoError(t, service.BulkInvalidateCache(context.Background(), []string{"documents/a.pdf"}))
+		assert.Len(t, flusher.batches, 3)
+	})
+
+	t.Run("reports batches that still fail", func(t *testing.T) {
+		flusher := &recordingFlusher{failures: 5}
+		service := &SpacesService{bucket: "test-bucket"}
+		service.SetCDNFlusher(flusher, CDNFlushPolicy{MaxConcurrent: 1, MaxRetries: 1, Backoff: time.Millisecond})
+
+		err := service.BulkInvalidateCache(context.Background(), []string{"documents/a.pdf"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "flushing 1 paths from documents/a.pdf: HTTP 429")
+		assert.Len(t, flusher.batches, 2)
+	})
+}
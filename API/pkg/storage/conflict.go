@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ConflictPolicy decides what an upload does when its path is already taken
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the existing object
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip keeps the existing object and does not upload
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictVersion uploads next to the existing object with a _v2, _v3, ... suffix
+	ConflictVersion ConflictPolicy = "version"
+)
+
+// DefaultConflictPolicy never loses a previously stored file
+const DefaultConflictPolicy = ConflictVersion
+
+// Actions reported after resolving an upload path
+const (
+	ConflictActionCreated     = "created"     // Path was free
+	ConflictActionOverwritten = "overwritten" // Existing object replaced
+	ConflictActionSkipped     = "skipped"     // Existing object kept, nothing uploaded
+	ConflictActionVersioned   = "versioned"   // Uploaded under a suffixed path
+)
+
+// maxConflictVersions bounds the search for a free versioned path
+const maxConflictVersions = 1000
+
+// ExistenceChecker reports whether an object exists; every Service satisfies it
+type ExistenceChecker interface {
+	Exists(ctx context.Context, path string) (bool, error)
+}
+
+// ParseConflictPolicy validates a policy name. An empty name returns the default.
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case "":
+		return DefaultConflictPolicy, nil
+	case ConflictOverwrite, ConflictSkip, ConflictVersion:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid on_conflict policy %q: must be overwrite, skip or version", name)
+	}
+}
+
+// ResolveConflict checks whether path is taken and applies the policy, returning
+// the path to upload to and the action taken. For ConflictActionSkipped the
+// returned path is the existing object and the caller should not upload.
+func ResolveConflict(ctx context.Context, checker ExistenceChecker, objectPath string, policy ConflictPolicy) (string, string, error) {
+	exists, err := checker.Exists(ctx, objectPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check for existing object: %w", err)
+	}
+	if !exists {
+		return objectPath, ConflictActionCreated, nil
+	}
+
+	switch policy {
+	case ConflictOverwrite:
+		return objectPath, ConflictActionOverwritten, nil
+	case ConflictSkip:
+		return objectPath, ConflictActionSkipped, nil
+	}
+
+	ext := path.Ext(objectPath)
+	base := strings.TrimSuffix(objectPath, ext)
+	for version := 2; version <= maxConflictVersions; version++ {
+		candidate := fmt.Sprintf("%s_v%d%s", base, version, ext)
+		exists, err := checker.Exists(ctx, candidate)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to check for existing object: %w", err)
+		}
+		if !exists {
+			return candidate, ConflictActionVersioned, nil
+		}
+	}
+	return "", "", fmt.Errorf("no free version of %s after %d attempts", objectPath, maxConflictVersions)
+}
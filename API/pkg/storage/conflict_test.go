@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// existingPaths is an ExistenceChecker backed by a set of taken paths
+type existingPaths map[string]bool
+
+func (e existingPaths) Exists(ctx context.Context, path string) (bool, error) {
+	return e[path], nil
+}
+
+type failingChecker struct{}
+
+func (failingChecker) Exists(ctx context.Context, path string) (bool, error) {
+	return false, errors.New("storage unavailable")
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictVersion, policy)
+
+	policy, err = ParseConflictPolicy("Overwrite")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictOverwrite, policy)
+
+	_, err = ParseConflictPolicy("rename")
+	assert.Error(t, err)
+}
+
+func TestResolveConflict(t *testing.T) {
+	taken := existingPaths{
+		"cases/smith/motion.pdf":    true,
+		"cases/smith/motion_v2.pdf": true,
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		policy       ConflictPolicy
+		expectedPath string
+		expectedAct  string
+	}{
+		{"free path is created", "cases/smith/order.pdf", ConflictSkip, "cases/smith/order.pdf", ConflictActionCreated},
+		{"overwrite replaces", "cases/smith/motion.pdf", ConflictOverwrite, "cases/smith/motion.pdf", ConflictActionOverwritten},
+		{"skip keeps existing", "cases/smith/motion.pdf", ConflictSkip, "cases/smith/motion.pdf", ConflictActionSkipped},
+		{"version finds next free suffix", "cases/smith/motion.pdf", ConflictVersion, "cases/smith/motion_v3.pdf", ConflictActionVersioned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, action, err := ResolveConflict(context.Background(), taken, tt.path, tt.policy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPath, path)
+			assert.Equal(t, tt.expectedAct, action)
+		})
+	}
+}
+
+func TestResolveConflict_VersionsFilesWithoutExtension(t *testing.T) {
+	path, action, err := ResolveConflict(context.Background(), existingPaths{"cases/notes": true}, "cases/notes", ConflictVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "cases/notes_v2", path)
+	assert.Equal(t, ConflictActionVersioned, action)
+}
+
+func TestResolveConflict_CheckFailure(t *testing.T) {
+	_, _, err := ResolveConflict(context.Background(), failingChecker{}, "cases/motion.pdf", ConflictOverwrite)
+	assert.Error(t, err)
+}
@@ -29,6 +29,10 @@ type Service interface {
 	// List lists documents in a directory
 	List(ctx context.Context, prefix string) ([]*StorageObject, error)
 
+	// ListPaged lists one page of at most maxKeys documents under prefix,
+	// resuming where continuationToken left off ("" for the first page)
+	ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*ListPage, error)
+
 	// IsHealthy returns true if the storage service is healthy
 	IsHealthy() bool
 
@@ -36,14 +40,60 @@ type Service interface {
 	GetMetrics() map[string]interface{}
 }
 
+// MetadataProvider is implemented by storage backends that can report the metadata
+// recorded for an object at upload time (for example the user's original filename)
+type MetadataProvider interface {
+	GetFileMetadata(ctx context.Context, path string) (*FileMetadata, error)
+}
+
+// DispositionSigner is implemented by storage backends whose signed URLs can
+// set the Content-Disposition of the response, so a browser redirected to
+// the URL still displays or saves the file as asked
+type DispositionSigner interface {
+	GetSignedURLWithDisposition(path string, expiration time.Duration, disposition string) (string, error)
+}
+
+// CacheInvalidator is implemented by storage backends behind a CDN that can
+// drop the cached copies of many objects at once, for bulk deletes and
+// replacements that would otherwise flush the CDN once per file
+type CacheInvalidator interface {
+	BulkInvalidateCache(ctx context.Context, paths []string) error
+}
+
+// CDNURLGenerator is implemented by storage backends served through a CDN,
+// returning the CDN URL of a stored file
+type CDNURLGenerator interface {
+	GenerateCDNURL(path string) string
+}
+
+// CDNURL returns the CDN URL of the file at path, or its storage URL when
+// the backend has no CDN
+func CDNURL(svc Service, path string) string {
+	if generator, ok := svc.(CDNURLGenerator); ok {
+		return generator.GenerateCDNURL(path)
+	}
+	return svc.GetURL(path)
+}
+
+// CDNHealthReporter is implemented by storage backends that track the
+// health of the CDN in front of them
+type CDNHealthReporter interface {
+	GetCDNHealthStatus() map[string]interface{}
+}
+
 // UploadMetadata contains metadata for document uploads
 type UploadMetadata struct {
-	ContentType     string            `json:"content_type"`
-	Size            int64             `json:"size"`
-	FileName        string            `json:"file_name"`
-	Tags            map[string]string `json:"tags,omitempty"`
-	CacheControl    string            `json:"cache_control,omitempty"`
-	ContentEncoding string            `json:"content_encoding,omitempty"`
+	ContentType        string            `json:"content_type"`
+	Size               int64             `json:"size"`
+	FileName           string            `json:"file_name"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+
+	// WarmAfterUpload asks storage backends with a CDN to prime the edge
+	// cache for the new object, for files that are read often right away
+	WarmAfterUpload bool `json:"warm_after_upload,omitempty"`
 }
 
 // UploadResult contains the result of a document upload
@@ -67,6 +117,15 @@ type StorageObject struct {
 	Metadata     map[string]string `json:"metadata,omitempty"`
 }
 
+// ListPage is one page of a storage listing
+type ListPage struct {
+	Objects []*StorageObject `json:"objects"`
+
+	// NextContinuationToken resumes the listing after this page; it is
+	// empty on the last page
+	NextContinuationToken string `json:"next_continuation_token,omitempty"`
+}
+
 // FileMetadata represents metadata for a file in storage
 type FileMetadata struct {
 	FileName    string            `json:"file_name"`
@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,12 +22,33 @@ import (
 	appconfig "motion-index-fiber/internal/config"
 )
 
+// originalFileNameMetaKey is the S3 user-metadata key holding the uploaded filename
+const originalFileNameMetaKey = "original-filename"
+
 type SpacesService struct {
 	client    *s3.Client
 	bucket    string
 	region    string
 	cdnDomain string
 	config    *SpacesConfig
+
+	// Downloads hold a slot until their reader is closed; one waits at most
+	// downloadQueueTimeout for a slot when all are taken. A nil downloadSlots
+	// leaves downloads unbounded.
+	downloadSlots        chan struct{}
+	downloadQueueTimeout time.Duration
+	inFlightDownloads    atomic.Int64
+
+	// cdnHTTPClient sends the HEAD requests that warm the CDN cache
+	cdnHTTPClient *http.Client
+
+	// cdnFlusher purges CDN cache entries for BulkInvalidateCache; nil
+	// leaves the cache to expire on its own
+	cdnFlusher     CDNFlusher
+	cdnFlushPolicy CDNFlushPolicy
+
+	// cdnHealth tracks the outcome of requests to the CDN
+	cdnHealth cdnHealth
 }
 
 // SpacesUploadResult contains specific spaces upload result
@@ -73,13 +99,19 @@ func NewSpacesService(cfg *appconfig.Config) (*SpacesService, error) {
 		CDNDomain:   cfg.Storage.CDNDomain,
 	}
 
-	return &SpacesService{
+	service := &SpacesService{
 		client:    client,
 		bucket:    cfg.Storage.Bucket,
 		region:    cfg.Storage.Region,
 		cdnDomain: cfg.Storage.CDNDomain,
 		config:    spacesConfig,
-	}, nil
+
+		cdnHTTPClient: &http.Client{Timeout: cdnWarmTimeout},
+	}
+	if cfg.Storage.MaxConcurrentDownloads > 0 {
+		service.downloadSlots = make(chan struct{}, cfg.Storage.MaxConcurrentDownloads)
+	}
+	return service, nil
 }
 
 func (s *SpacesService) Upload(ctx context.Context, path string, content io.Reader, metadata *UploadMetadata) (*UploadResult, error) {
@@ -94,14 +126,29 @@ func (s *SpacesService) Upload(ctx context.Context, path string, content io.Read
 		contentType = metadata.ContentType
 	}
 
-	// Upload object to Spaces
-	putResult, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(path),
 		Body:        content,
 		ContentType: aws.String(contentType),
 		ACL:         types.ObjectCannedACLPublicRead,
-	})
+	}
+
+	// Keep the user's original filename with the object. S3 user metadata must be
+	// ASCII, so the name is stored percent-encoded.
+	if metadata != nil {
+		if metadata.ContentDisposition != "" {
+			input.ContentDisposition = aws.String(metadata.ContentDisposition)
+		}
+		if metadata.FileName != "" {
+			input.Metadata = map[string]string{
+				originalFileNameMetaKey: url.PathEscape(metadata.FileName),
+			}
+		}
+	}
+
+	// Upload object to Spaces
+	putResult, err := s.client.PutObject(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload to Spaces: %w", err)
 	}
@@ -114,6 +161,17 @@ func (s *SpacesService) Upload(ctx context.Context, path string, content io.Read
 		size = metadata.Size
 	}
 
+	// Warm in the background so the upload doesn't wait on the CDN
+	if metadata != nil && metadata.WarmAfterUpload && s.cdnDomain != "" {
+		go func() {
+			warmCtx, cancel := context.WithTimeout(context.Background(), cdnWarmTimeout)
+			defer cancel()
+			if err := s.WarmCDN(warmCtx, []string{path}); err != nil {
+				log.Printf("[SPACES] ⚠️ CDN cache warming failed for %s: %v", path, err)
+			}
+		}()
+	}
+
 	return &UploadResult{
 		Path:       path,
 		URL:        directURL,
@@ -124,16 +182,130 @@ func (s *SpacesService) Upload(ctx context.Context, path string, content io.Read
 	}, nil
 }
 
-// Download downloads a document from storage
+// cdnWarmTimeout bounds each cache warming request
+const cdnWarmTimeout = 10 * time.Second
+
+// WarmCDN primes the CDN edge cache for the given paths with HEAD requests
+// against their CDN URLs, so the first reader doesn't hit a cold cache. It
+// does nothing without a configured CDN domain.
+func (s *SpacesService) WarmCDN(ctx context.Context, paths []string) error {
+	if s.cdnDomain == "" || len(paths) == 0 {
+		return nil
+	}
+
+	client := s.cdnHTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var errs []error
+	for i, path := range paths {
+		if !s.cdnHealth.available() {
+			errs = append(errs, fmt.Errorf("CDN circuit breaker is open, skipped warming %d paths", len(paths)-i))
+			break
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.GenerateCDNURL(strings.TrimPrefix(path, "/")), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				errs = append(errs, ctx.Err())
+				break
+			}
+			s.cdnHealth.recordFailure()
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		resp.Body.Close()
+
+		// A 4xx is about the file, not the CDN, which still answered
+		if resp.StatusCode >= http.StatusInternalServerError {
+			s.cdnHealth.recordFailure()
+		} else {
+			s.cdnHealth.recordSuccess()
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			errs = append(errs, fmt.Errorf("%s: CDN returned HTTP %d", path, resp.StatusCode))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Download downloads a document from storage. The download holds one of
+// the concurrent download slots until the returned reader is closed.
 func (s *SpacesService) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	release, err := s.acquireDownloadSlot(ctx)
+	if err != nil {
+		return nil, NewStorageError("download", "too many concurrent downloads from Spaces", path, err)
+	}
+
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(path),
 	})
 	if err != nil {
+		release()
 		return nil, fmt.Errorf("failed to download from Spaces: %w", err)
 	}
-	return result.Body, nil
+	return &slotReader{ReadCloser: result.Body, release: release}, nil
+}
+
+// defaultDownloadQueueTimeout is how long a download waits for a slot when
+// the service is at its concurrent download limit
+const defaultDownloadQueueTimeout = 30 * time.Second
+
+// errDownloadsSaturated is returned when no download slot frees up in time
+var errDownloadsSaturated = errors.New("timed out waiting for a download slot")
+
+// acquireDownloadSlot waits for a download slot and returns the function
+// that gives it back. Without a limit it returns at once.
+func (s *SpacesService) acquireDownloadSlot(ctx context.Context) (func(), error) {
+	if s.downloadSlots != nil {
+		select {
+		case s.downloadSlots <- struct{}{}:
+		default:
+			timeout := s.downloadQueueTimeout
+			if timeout <= 0 {
+				timeout = defaultDownloadQueueTimeout
+			}
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			select {
+			case s.downloadSlots <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-timer.C:
+				return nil, errDownloadsSaturated
+			}
+		}
+	}
+
+	s.inFlightDownloads.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.inFlightDownloads.Add(-1)
+			if s.downloadSlots != nil {
+				<-s.downloadSlots
+			}
+		})
+	}, nil
+}
+
+// slotReader gives its download slot back when closed
+type slotReader struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *slotReader) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
 }
 
 // Delete deletes a document from storage
@@ -169,6 +341,23 @@ func (s *SpacesService) GetSignedURL(path string, expiration time.Duration) (str
 	return presignResult.URL, nil
 }
 
+// GetSignedURLWithDisposition returns a signed URL whose response carries the
+// given Content-Disposition header
+func (s *SpacesService) GetSignedURLWithDisposition(path string, expiration time.Duration, disposition string) (string, error) {
+	presignClient := s3.NewPresignClient(s.client)
+	presignResult, err := presignClient.PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket:                     aws.String(s.bucket),
+		Key:                        aws.String(path),
+		ResponseContentDisposition: aws.String(disposition),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expiration
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+	return presignResult.URL, nil
+}
+
 // List lists documents in a directory with pagination support
 func (s *SpacesService) List(ctx context.Context, prefix string) ([]*StorageObject, error) {
 	var objects []*StorageObject
@@ -210,6 +399,38 @@ func (s *SpacesService) List(ctx context.Context, prefix string) ([]*StorageObje
 	return objects, nil
 }
 
+// ListPaged lists a single page of documents, returning the token for the next one
+func (s *SpacesService) ListPaged(ctx context.Context, prefix, continuationToken string, maxKeys int) (*ListPage, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(int32(maxKeys)),
+	}
+	if continuationToken != "" {
+		input.ContinuationToken = aws.String(continuationToken)
+	}
+
+	result, err := s.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	page := &ListPage{Objects: make([]*StorageObject, 0, len(result.Contents))}
+	for _, obj := range result.Contents {
+		page.Objects = append(page.Objects, &StorageObject{
+			Path:         aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			LastModified: aws.ToTime(obj.LastModified),
+			ETag:         aws.ToString(obj.ETag),
+		})
+	}
+	if aws.ToBool(result.IsTruncated) {
+		page.NextContinuationToken = aws.ToString(result.NextContinuationToken)
+	}
+
+	return page, nil
+}
+
 // IsHealthy returns true if the storage service is healthy
 func (s *SpacesService) IsHealthy() bool {
 	// Simple health check - try to list objects with empty prefix
@@ -259,6 +480,7 @@ func (s *SpacesService) GeneratePresignedURL(ctx context.Context, key string, ex
 	return request.URL, nil
 }
 
+// GenerateCDNURL returns the CDN URL of the object at key
 func (s *SpacesService) GenerateCDNURL(key string) string {
 	// Use CDN domain if configured, otherwise use default CDN endpoint
 	if s.cdnDomain != "" {
@@ -307,6 +529,38 @@ func (s *SpacesService) Exists(ctx context.Context, path string) (bool, error) {
 	return true, nil
 }
 
+// GetFileMetadata returns the metadata recorded for an object at upload time,
+// or a "not_found" *StorageError when there is no object at path
+func (s *SpacesService) GetFileMetadata(ctx context.Context, path string) (*FileMetadata, error) {
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) || strings.Contains(err.Error(), "NotFound") {
+			return nil, NewStorageError("not_found", "object not found", path, err)
+		}
+		return nil, fmt.Errorf("failed to read object metadata: %w", err)
+	}
+
+	metadata := &FileMetadata{
+		ContentType: aws.ToString(head.ContentType),
+		Size:        aws.ToInt64(head.ContentLength),
+		Tags:        head.Metadata,
+	}
+	if head.LastModified != nil {
+		metadata.UpdatedAt = *head.LastModified
+	}
+	if encoded, ok := head.Metadata[originalFileNameMetaKey]; ok {
+		if name, err := url.PathUnescape(encoded); err == nil {
+			metadata.FileName = name
+		}
+	}
+
+	return metadata, nil
+}
+
 // ObjectExists is an alias for Exists for backward compatibility
 func (s *SpacesService) ObjectExists(ctx context.Context, key string) (bool, error) {
 	return s.Exists(ctx, key)
@@ -323,6 +577,8 @@ func (s *SpacesService) GetMetrics() map[string]interface{} {
 	metrics["bucket"] = s.bucket
 	metrics["region"] = s.region
 	metrics["healthy"] = s.IsHealthy()
+	metrics["in_flight_downloads"] = s.inFlightDownloads.Load()
+	metrics["max_concurrent_downloads"] = cap(s.downloadSlots)
 	if s.cdnDomain != "" {
 		metrics["cdn_enabled"] = true
 		metrics["cdn_domain"] = s.cdnDomain
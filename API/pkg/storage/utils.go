@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // GenerateStorageKey generates a unique storage key for a file
@@ -50,6 +51,10 @@ func GetContentTypeFromFilename(filename string) string {
 		return "application/rtf"
 	case ".html", ".htm":
 		return "text/html"
+	case ".eml":
+		return "message/rfc822"
+	case ".msg":
+		return "application/vnd.ms-outlook"
 	case ".xml":
 		return "application/xml"
 	case ".json":
@@ -119,6 +124,94 @@ func SanitizeStoragePath(path string) string {
 	return path
 }
 
+// SanitizeFileName converts a user-supplied filename into a storage-safe key segment.
+// Only ASCII letters, digits, '.', '-' and '_' are kept; every other run of characters
+// collapses to a single underscore. The user's original name should be kept separately
+// (see models.DocumentMetadata.OriginalFileName) for display and downloads.
+func SanitizeFileName(filename string) string {
+	base := filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	ext := strings.ToLower(filepath.Ext(base))
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+
+	clean := func(s string) string {
+		var b strings.Builder
+		lastUnderscore := false
+		for _, r := range s {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+				b.WriteRune(r)
+				lastUnderscore = false
+			default:
+				if !lastUnderscore {
+					b.WriteByte('_')
+					lastUnderscore = true
+				}
+			}
+		}
+		return strings.Trim(b.String(), "_.")
+	}
+
+	name = clean(name)
+	ext = clean(ext)
+	if name == "" {
+		name = "document"
+	}
+
+	// Leave room for the extension within common key length limits
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	if ext != "" {
+		return name + "." + ext
+	}
+	return name
+}
+
+// ContentDisposition builds a Content-Disposition header value for the given filename.
+// Non-ASCII names are sent as an RFC 5987 filename* parameter alongside a plain ASCII
+// fallback for clients that do not understand the extended syntax.
+func ContentDisposition(dispositionType, filename string) string {
+	if dispositionType == "" {
+		dispositionType = "inline"
+	}
+	if filename == "" {
+		return dispositionType
+	}
+
+	var fallback strings.Builder
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('_')
+		case r < 0x20 || r == 0x7f || r >= utf8.RuneSelf:
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	value := fmt.Sprintf("%s; filename=\"%s\"", dispositionType, fallback.String())
+	if fallback.String() != filename {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// encodeRFC5987 percent-encodes everything outside the RFC 5987 attr-char set
+func encodeRFC5987(s string) string {
+	const attrChars = "!#$&+-.^_`|~"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.IndexByte(attrChars, c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
 // GetFileExtension extracts file extension from filename
 func GetFileExtension(filename string) string {
 	ext := filepath.Ext(filename)
@@ -140,6 +233,9 @@ func IsLegalDocumentType(contentType string) bool {
 		"text/html":          true,
 		"application/xml":    true,
 		"text/xml":           true, // Also support text/xml
+		"message/rfc822":     true,
+
+		"application/vnd.ms-outlook": true,
 	}
 
 	return supportedTypes[baseContentType]
@@ -208,6 +304,8 @@ func FileTypeFromExtension(filename string) string {
 		return "Rich Text Document"
 	case ".html", ".htm":
 		return "HTML Document"
+	case ".eml", ".msg":
+		return "Email Message"
 	case ".xml":
 		return "XML Document"
 	case ".json":
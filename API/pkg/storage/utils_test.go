@@ -223,6 +223,8 @@ func TestIsLegalDocumentType(t *testing.T) {
 		{"application/xml", true},
 		{"text/xml", true},
 		{"text/xml; charset=utf-8", true}, // With charset
+		{"message/rfc822", true},
+		{"application/vnd.ms-outlook", true},
 		{"image/jpeg", false},
 		{"video/mp4", false},
 		{"application/octet-stream", false},
@@ -350,3 +352,34 @@ func TestFileTypeFromExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"motion.pdf", "motion.pdf"},
+		{"Motion to Dismiss.PDF", "Motion_to_Dismiss.pdf"},
+		{"Moción de prueba ñ.pdf", "Moci_n_de_prueba.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{"C:\\Users\\clerk\\brief (final).docx", "brief_final.docx"},
+		{"ñññ.pdf", "document.pdf"},
+		{"", "document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeFileName(tt.input))
+		})
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `attachment; filename="motion.pdf"`, ContentDisposition("attachment", "motion.pdf"))
+	assert.Equal(t,
+		`inline; filename="Moci_n.pdf"; filename*=UTF-8''Moci%C3%B3n.pdf`,
+		ContentDisposition("inline", "Moción.pdf"))
+	assert.Equal(t,
+		`inline; filename="a_b_.pdf"; filename*=UTF-8''a%22b%5C.pdf`,
+		ContentDisposition("", `a"b\.pdf`))
+}